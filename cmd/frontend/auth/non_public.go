@@ -27,6 +27,10 @@ var RequireAuthMiddleware = &Middleware{
 			if !actor.FromContext(r.Context()).IsAuthenticated() && !AllowAnonymousRequest(r) {
 				// Report HTTP 401 Unauthorized for API requests.
 				code := anonymousStatusCode(r, http.StatusUnauthorized)
+				if strings.HasPrefix(r.URL.Path, "/.api/git/") {
+					// Git clients only send credentials when asked to.
+					w.Header().Set("WWW-Authenticate", `Basic realm="Sourcegraph"`)
+				}
 				http.Error(w, "Private mode requires authentication.", code)
 				return
 			}
@@ -124,6 +128,11 @@ func AllowAnonymousRequest(req *http.Request) bool {
 		return true
 	}
 
+	// Authentication is performed by repo-updater, which receives the push webhooks.
+	if strings.HasPrefix(req.URL.Path, "/.api/push-webhooks/") {
+		return true
+	}
+
 	apiRouteName := matchedRouteName(req, router.Router())
 	if apiRouteName == router.UI {
 		// Test against UI router. (Some of its handlers inject private data into the title or meta tags.)
@@ -10,10 +10,10 @@ import (
 
 	"github.com/inconshreveable/log15"
 	"github.com/pkg/errors"
-	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/env"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/inventory"
 	"github.com/sourcegraph/sourcegraph/internal/rcache"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
@@ -22,7 +22,7 @@ import (
 // filenames. Enabled by default.
 var useEnhancedLanguageDetection, _ = strconv.ParseBool(env.Get("USE_ENHANCED_LANGUAGE_DETECTION", "true", "Enable more accurate but slower language detection that uses file contents"))
 
-var inventoryCache = rcache.New(fmt.Sprintf("inv:v2:enhanced_%v", useEnhancedLanguageDetection))
+var inventoryCache = rcache.New(fmt.Sprintf("inv:v3:enhanced_%v", useEnhancedLanguageDetection))
 
 // InventoryContext returns the inventory context for computing the inventory for the repository at
 // the given commit.
@@ -74,6 +74,8 @@ func InventoryContext(repo gitserver.Repo, commitID api.CommitID, forceEnhancedL
 			}
 			inventoryCache.Set(cacheKey, b)
 		},
+		// The cache key of a file is the ID of its blob.
+		BlobID: cacheKey,
 	}
 
 	if !useEnhancedLanguageDetection && !forceEnhancedLanguageDetection {
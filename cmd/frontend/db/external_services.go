@@ -38,6 +38,8 @@ var ExternalServiceKinds = map[string]ExternalServiceKind{
 	extsvc.KindAWSCodeCommit:   {CodeHost: true, JSONSchema: schema.AWSCodeCommitSchemaJSON},
 	extsvc.KindBitbucketCloud:  {CodeHost: true, JSONSchema: schema.BitbucketCloudSchemaJSON},
 	extsvc.KindBitbucketServer: {CodeHost: true, JSONSchema: schema.BitbucketServerSchemaJSON},
+	extsvc.KindGerrit:          {CodeHost: true, JSONSchema: schema.GerritSchemaJSON},
+	extsvc.KindGitea:           {CodeHost: true, JSONSchema: schema.GiteaSchemaJSON},
 	extsvc.KindGitHub:          {CodeHost: true, JSONSchema: schema.GitHubSchemaJSON},
 	extsvc.KindGitLab:          {CodeHost: true, JSONSchema: schema.GitLabSchemaJSON},
 	extsvc.KindGitolite:        {CodeHost: true, JSONSchema: schema.GitoliteSchemaJSON},
@@ -138,6 +140,20 @@ func (e *ExternalServicesStore) ValidateConfig(ctx context.Context, id int64, ki
 		}
 		err = e.validateBitbucketCloudConnection(ctx, id, &c)
 
+	case extsvc.KindGerrit:
+		var c schema.GerritConnection
+		if err = json.Unmarshal(normalized, &c); err != nil {
+			return err
+		}
+		err = validateGerritConnection(&c)
+
+	case extsvc.KindGitea:
+		var c schema.GiteaConnection
+		if err = json.Unmarshal(normalized, &c); err != nil {
+			return err
+		}
+		err = validateGiteaConnection(&c)
+
 	case extsvc.KindOther:
 		var c schema.OtherExternalServiceConnection
 		if err = json.Unmarshal(normalized, &c); err != nil {
@@ -223,6 +239,20 @@ func (e *ExternalServicesStore) validateBitbucketCloudConnection(ctx context.Con
 	return e.validateDuplicateRateLimits(ctx, id, extsvc.KindBitbucketCloud, c)
 }
 
+func validateGerritConnection(c *schema.GerritConnection) error {
+	if c.Projects == nil && c.ProjectPrefixes == nil {
+		return errors.New("at least one of projects or projectPrefixes must be set")
+	}
+	return nil
+}
+
+func validateGiteaConnection(c *schema.GiteaConnection) error {
+	if c.Orgs == nil && c.Users == nil && c.RepositoryQuery == nil && c.Repos == nil {
+		return errors.New("at least one of orgs, users, repositoryQuery or repos must be set")
+	}
+	return nil
+}
+
 func (e *ExternalServicesStore) validateDuplicateRateLimits(ctx context.Context, id int64, kind string, parsedConfig interface{}) error {
 	// Check if rate limit is already defined for this code host on another external service
 	rlc, err := extsvc.GetLimitFromConfig(kind, parsedConfig)
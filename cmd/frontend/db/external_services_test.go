@@ -113,6 +113,30 @@ func TestExternalServicesStore_ValidateConfig(t *testing.T) {
 			},
 			wantErr: "1 error occurred:\n\t* existing external service, \"GITHUB 1\", already has a rate limit set\n\n",
 		},
+		{
+			name:    "gerrit with project prefixes",
+			kind:    extsvc.KindGerrit,
+			config:  `{"url": "https://gerrit.mycorp.com", "projectPrefixes": [""]}`,
+			wantErr: "<nil>",
+		},
+		{
+			name:    "gerrit without projects",
+			kind:    extsvc.KindGerrit,
+			config:  `{"url": "https://gerrit.mycorp.com"}`,
+			wantErr: "1 error occurred:\n\t* at least one of projects or projectPrefixes must be set\n\n",
+		},
+		{
+			name:    "gitea with orgs",
+			kind:    extsvc.KindGitea,
+			config:  `{"url": "https://gitea.mycorp.com", "token": "abc", "orgs": ["sourcegraph"]}`,
+			wantErr: "<nil>",
+		},
+		{
+			name:    "gitea without repositories",
+			kind:    extsvc.KindGitea,
+			config:  `{"url": "https://gitea.mycorp.com", "token": "abc"}`,
+			wantErr: "1 error occurred:\n\t* at least one of orgs, users, repositoryQuery or repos must be set\n\n",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
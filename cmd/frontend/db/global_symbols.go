@@ -0,0 +1,457 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/inconshreveable/log15"
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/segmentio/fasthash/fnv1"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+// globalSymbolsInsertBatchSize is the number of symbols inserted per
+// statement, which keeps the number of query parameters below the limit of
+// Postgres.
+const globalSymbolsInsertBatchSize = 1000
+
+// globalSymbols is the global symbol index: the symbols of the default
+// branch of repositories, which are searched without querying the symbols
+// service of each repository.
+type globalSymbols struct{}
+
+// ListIndexes returns the state of the global symbol index of the given
+// repositories. Repositories which are not indexed are omitted.
+func (s *globalSymbols) ListIndexes(ctx context.Context, repoIDs []api.RepoID) ([]*types.GlobalSymbolsIndex, error) {
+	if Mocks.GlobalSymbols.ListIndexes != nil {
+		return Mocks.GlobalSymbols.ListIndexes(ctx, repoIDs)
+	}
+
+	if len(repoIDs) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, len(repoIDs))
+	for i, id := range repoIDs {
+		ids[i] = int64(id)
+	}
+	q := sqlf.Sprintf(`
+SELECT repo_id, commit_id, limit_hit, indexed_at
+FROM global_symbols_repos
+WHERE repo_id = ANY(%s)
+ORDER BY repo_id ASC`, pq.Array(ids))
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []*types.GlobalSymbolsIndex
+	for rows.Next() {
+		var (
+			index  types.GlobalSymbolsIndex
+			commit string
+		)
+		if err := rows.Scan(&index.RepoID, &commit, &index.LimitHit, &index.IndexedAt); err != nil {
+			return nil, err
+		}
+		index.Commit = api.CommitID(commit)
+		indexes = append(indexes, &index)
+	}
+	return indexes, rows.Err()
+}
+
+// Replace replaces the symbols of a repository in the global symbol index
+// with the given symbols, computed at index.Commit. The RepoID and Commit
+// fields of the symbols are ignored.
+func (s *globalSymbols) Replace(ctx context.Context, index *types.GlobalSymbolsIndex, symbols []*types.GlobalSymbol) error {
+	if Mocks.GlobalSymbols.Replace != nil {
+		return Mocks.GlobalSymbols.Replace(ctx, index, symbols)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+INSERT INTO global_symbols_repos(repo_id, commit_id, limit_hit, indexed_at)
+VALUES($1, $2, $3, now())
+ON CONFLICT (repo_id) DO UPDATE SET commit_id=EXCLUDED.commit_id, limit_hit=EXCLUDED.limit_hit, indexed_at=EXCLUDED.indexed_at`,
+			index.RepoID, string(index.Commit), index.LimitHit,
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM global_symbols WHERE repo_id=$1", index.RepoID); err != nil {
+			return err
+		}
+
+		for len(symbols) > 0 {
+			batch := symbols
+			if len(batch) > globalSymbolsInsertBatchSize {
+				batch = batch[:globalSymbolsInsertBatchSize]
+			}
+			symbols = symbols[len(batch):]
+
+			values := make([]*sqlf.Query, len(batch))
+			for i, sym := range batch {
+				values[i] = sqlf.Sprintf("(%d, %s, %s, %s, %d, %s, %s, %s, %s, %s)",
+					index.RepoID, sym.Name, sym.Kind, sym.Path, sym.Line, sym.Language, sym.Parent, sym.ParentKind, sym.Signature, sym.Pattern)
+			}
+			q := sqlf.Sprintf(`
+INSERT INTO global_symbols(repo_id, name, kind, path, line, language, parent, parent_kind, signature, pattern)
+VALUES %s`, sqlf.Join(values, ","))
+			if _, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// globalSymbolsUpdateLockID is the ID of the Postgres advisory lock that is
+// held while the global symbol index is updated. Advisory lock IDs are a
+// global namespace within one database, so it is hashed from a name that is
+// unlikely to be used by other locks.
+var globalSymbolsUpdateLockID = int32(fnv1.HashString32("global_symbols_update"))
+
+// TryLockUpdate tries to acquire the lock that is held while the global
+// symbol index is updated, so that only one frontend replica updates it at a
+// time. If ok is true, the lock is held until unlock is called.
+func (s *globalSymbols) TryLockUpdate(ctx context.Context) (unlock func(), ok bool, err error) {
+	if Mocks.GlobalSymbols.TryLockUpdate != nil {
+		return Mocks.GlobalSymbols.TryLockUpdate(ctx)
+	}
+
+	// Session level advisory locks are held by a connection, so the lock is
+	// acquired and released on the same connection.
+	conn, err := dbconn.Global.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", globalSymbolsUpdateLockID).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	return func() {
+		// Closing the connection doesn't release the lock if it is returned
+		// to the connection pool, so release it explicitly.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", globalSymbolsUpdateLockID); err != nil {
+			log15.Error("globalSymbols: releasing the update lock failed", "error", err)
+		}
+		conn.Close()
+	}, true, nil
+}
+
+// GlobalSymbolsSearchOptions specifies the options for searching the global
+// symbol index.
+type GlobalSymbolsSearchOptions struct {
+	// RepoIDs are the repositories to search.
+	RepoIDs []api.RepoID
+
+	// Query is a regexp that the names of the symbols must match. It and the
+	// path patterns use Go regexp syntax, and are translated to the regexp
+	// syntax of Postgres.
+	Query string
+
+	// IsCaseSensitive is whether Query and the path patterns are case
+	// sensitive.
+	IsCaseSensitive bool
+
+	// IncludePatterns are regexps that the paths of the symbols must all
+	// match, and ExcludePattern is a regexp they must not match (if set).
+	IncludePatterns []string
+	ExcludePattern  string
+
+	// Limit is the maximum number of symbols returned.
+	Limit int
+}
+
+// Search returns the symbols in the global symbol index which match the
+// options.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can access the
+// repositories. It is the caller's responsibility to only pass the IDs of
+// repositories the user can access.
+func (s *globalSymbols) Search(ctx context.Context, opt GlobalSymbolsSearchOptions) ([]*types.GlobalSymbol, error) {
+	if Mocks.GlobalSymbols.Search != nil {
+		return Mocks.GlobalSymbols.Search(ctx, opt)
+	}
+
+	if len(opt.RepoIDs) == 0 {
+		return nil, nil
+	}
+	ids := make([]int64, len(opt.RepoIDs))
+	for i, id := range opt.RepoIDs {
+		ids[i] = int64(id)
+	}
+
+	// The case insensitive name condition is on lower(name), which is what
+	// global_symbols_name_trgm indexes.
+	conds := []*sqlf.Query{sqlf.Sprintf("global_symbols.repo_id = ANY(%s)", pq.Array(ids))}
+	if opt.Query != "" {
+		query, err := postgresRegexp(opt.Query)
+		if err != nil {
+			return nil, err
+		}
+		if opt.IsCaseSensitive {
+			conds = append(conds, sqlf.Sprintf("global_symbols.name ~ %s", query))
+		} else {
+			conds = append(conds, sqlf.Sprintf("lower(global_symbols.name) ~* %s", query))
+		}
+	}
+	for _, pattern := range opt.IncludePatterns {
+		pattern, err := postgresRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if opt.IsCaseSensitive {
+			conds = append(conds, sqlf.Sprintf("global_symbols.path ~ %s", pattern))
+		} else {
+			conds = append(conds, sqlf.Sprintf("global_symbols.path ~* %s", pattern))
+		}
+	}
+	if opt.ExcludePattern != "" {
+		pattern, err := postgresRegexp(opt.ExcludePattern)
+		if err != nil {
+			return nil, err
+		}
+		if opt.IsCaseSensitive {
+			conds = append(conds, sqlf.Sprintf("global_symbols.path !~ %s", pattern))
+		} else {
+			conds = append(conds, sqlf.Sprintf("global_symbols.path !~* %s", pattern))
+		}
+	}
+
+	q := sqlf.Sprintf(`
+SELECT global_symbols.repo_id, global_symbols_repos.commit_id, name, kind, path, line, language, parent, parent_kind, signature, pattern
+FROM global_symbols
+JOIN global_symbols_repos ON global_symbols_repos.repo_id = global_symbols.repo_id
+WHERE %s
+ORDER BY global_symbols.id ASC
+LIMIT %d`, sqlf.Join(conds, "AND"), opt.Limit)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == invalidRegularExpression {
+			return nil, &GlobalSymbolsRegexpError{Err: err}
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []*types.GlobalSymbol
+	for rows.Next() {
+		var (
+			sym    types.GlobalSymbol
+			commit string
+		)
+		if err := rows.Scan(&sym.RepoID, &commit, &sym.Name, &sym.Kind, &sym.Path, &sym.Line, &sym.Language, &sym.Parent, &sym.ParentKind, &sym.Signature, &sym.Pattern); err != nil {
+			return nil, err
+		}
+		sym.Commit = api.CommitID(commit)
+		symbols = append(symbols, &sym)
+	}
+	return symbols, rows.Err()
+}
+
+// invalidRegularExpression is the code of the error Postgres returns for
+// regexps it can't compile.
+const invalidRegularExpression = "2201B"
+
+// GlobalSymbolsRegexpError is the error GlobalSymbols.Search returns when
+// one of the patterns of the search can't be searched with Postgres. The
+// repositories can still be searched with the symbols service.
+type GlobalSymbolsRegexpError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *GlobalSymbolsRegexpError) Error() string {
+	if e.Pattern == "" {
+		return fmt.Sprintf("searching the global symbol index: %s", e.Err)
+	}
+	return fmt.Sprintf("searching the global symbol index for %q: %s", e.Pattern, e.Err)
+}
+
+// IsGlobalSymbolsRegexpError reports whether err is a *GlobalSymbolsRegexpError.
+func IsGlobalSymbolsRegexpError(err error) bool {
+	_, ok := errors.Cause(err).(*GlobalSymbolsRegexpError)
+	return ok
+}
+
+// postgresMaxRepeat is the maximum count of a repetition in a Postgres
+// regexp.
+const postgresMaxRepeat = 255
+
+// postgresRegexp translates a Go regexp to a Postgres regexp (an ARE, see
+// https://www.postgresql.org/docs/current/functions-matching.html) which
+// matches the same strings. It only translates the regexps that are used to
+// search the names and paths of symbols: they don't contain newlines, so the
+// line anchors are translated to the anchors of the whole string.
+func postgresRegexp(pattern string) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", &GlobalSymbolsRegexpError{Pattern: pattern, Err: err}
+	}
+	var b strings.Builder
+	if err := writePostgresRegexp(&b, re); err != nil {
+		return "", &GlobalSymbolsRegexpError{Pattern: pattern, Err: err}
+	}
+	return b.String(), nil
+}
+
+func writePostgresRegexp(b *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if r == 0 {
+				return errors.New("NUL characters are not supported")
+			}
+			folds := []rune{r}
+			if re.Flags&syntax.FoldCase != 0 {
+				for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+					folds = append(folds, f)
+				}
+			}
+			if len(folds) == 1 {
+				writePostgresRune(b, r)
+				continue
+			}
+			b.WriteByte('[')
+			for _, f := range folds {
+				writePostgresRune(b, f)
+			}
+			b.WriteByte(']')
+		}
+	case syntax.OpCharClass:
+		var ranges []rune
+		for i := 0; i < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if lo == 0 {
+				lo = 1 // Postgres strings can't contain NUL characters.
+			}
+			if lo <= hi {
+				ranges = append(ranges, lo, hi)
+			}
+		}
+		if len(ranges) == 0 {
+			return errors.New("empty character classes are not supported")
+		}
+		b.WriteByte('[')
+		for i := 0; i < len(ranges); i += 2 {
+			writePostgresRune(b, ranges[i])
+			if ranges[i+1] > ranges[i] {
+				b.WriteByte('-')
+				writePostgresRune(b, ranges[i+1])
+			}
+		}
+		b.WriteByte(']')
+	case syntax.OpAnyCharNotNL:
+		b.WriteString(`[^\n]`)
+	case syntax.OpAnyChar:
+		// . matches newlines in Postgres by default.
+		b.WriteByte('.')
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		b.WriteByte('^')
+	case syntax.OpEndLine, syntax.OpEndText:
+		b.WriteByte('$')
+	case syntax.OpWordBoundary:
+		b.WriteString(`\y`)
+	case syntax.OpNoWordBoundary:
+		b.WriteString(`\Y`)
+	case syntax.OpCapture:
+		b.WriteByte('(')
+		if err := writePostgresRegexp(b, re.Sub[0]); err != nil {
+			return err
+		}
+		b.WriteByte(')')
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if err := writePostgresGroup(b, re.Sub[0]); err != nil {
+			return err
+		}
+		switch re.Op {
+		case syntax.OpStar:
+			b.WriteByte('*')
+		case syntax.OpPlus:
+			b.WriteByte('+')
+		case syntax.OpQuest:
+			b.WriteByte('?')
+		case syntax.OpRepeat:
+			if re.Min > postgresMaxRepeat || re.Max > postgresMaxRepeat {
+				return fmt.Errorf("repetitions of more than %d are not supported", postgresMaxRepeat)
+			}
+			switch {
+			case re.Max == -1:
+				fmt.Fprintf(b, "{%d,}", re.Min)
+			case re.Min == re.Max:
+				fmt.Fprintf(b, "{%d}", re.Min)
+			default:
+				fmt.Fprintf(b, "{%d,%d}", re.Min, re.Max)
+			}
+		}
+		if re.Flags&syntax.NonGreedy != 0 {
+			b.WriteByte('?')
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpAlternate {
+				if err := writePostgresGroup(b, sub); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writePostgresRegexp(b, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		for i, sub := range re.Sub {
+			if i > 0 {
+				b.WriteByte('|')
+			}
+			if err := writePostgresRegexp(b, sub); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%s is not supported", re)
+	}
+	return nil
+}
+
+// writePostgresGroup writes re so that a repetition operator applies to all
+// of it.
+func writePostgresGroup(b *strings.Builder, re *syntax.Regexp) error {
+	atomic := re.Op == syntax.OpCharClass || re.Op == syntax.OpAnyChar || re.Op == syntax.OpAnyCharNotNL || re.Op == syntax.OpCapture ||
+		(re.Op == syntax.OpLiteral && len(re.Rune) == 1)
+	if atomic {
+		return writePostgresRegexp(b, re)
+	}
+	b.WriteString("(?:")
+	if err := writePostgresRegexp(b, re); err != nil {
+		return err
+	}
+	b.WriteByte(')')
+	return nil
+}
+
+// writePostgresRune writes r so that it is matched literally, both in and
+// outside of bracket expressions.
+func writePostgresRune(b *strings.Builder, r rune) {
+	switch {
+	case r < 0x20 || r == 0x7f || r == unicode.MaxRune:
+		fmt.Fprintf(b, `\U%08x`, r)
+	case r < 0x80 && !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' || r == ' '):
+		b.WriteByte('\\')
+		b.WriteRune(r)
+	default:
+		b.WriteRune(r)
+	}
+}
@@ -0,0 +1,16 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type MockGlobalSymbols struct {
+	ListIndexes func(ctx context.Context, repoIDs []api.RepoID) ([]*types.GlobalSymbolsIndex, error)
+	Replace     func(ctx context.Context, index *types.GlobalSymbolsIndex, symbols []*types.GlobalSymbol) error
+	Search      func(ctx context.Context, opt GlobalSymbolsSearchOptions) ([]*types.GlobalSymbol, error)
+
+	TryLockUpdate func(ctx context.Context) (unlock func(), ok bool, err error)
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+)
+
+func TestGlobalSymbols(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	repos := mustCreate(ctx, t, &types.Repo{Name: "a"}, &types.Repo{Name: "b"})
+	repoIDs := []api.RepoID{repos[0].ID, repos[1].ID}
+
+	if indexes, err := GlobalSymbols.ListIndexes(ctx, repoIDs); err != nil || len(indexes) != 0 {
+		t.Fatalf("got indexes %+v (error %v), want none", indexes, err)
+	}
+
+	replace := func(repoID api.RepoID, commit api.CommitID, names ...string) {
+		t.Helper()
+		symbols := make([]*types.GlobalSymbol, len(names))
+		for i, name := range names {
+			symbols[i] = &types.GlobalSymbol{Name: name, Kind: "func", Path: "dir/" + name + ".go", Line: i + 1, Language: "Go"}
+		}
+		if err := GlobalSymbols.Replace(ctx, &types.GlobalSymbolsIndex{RepoID: repoID, Commit: commit}, symbols); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(opt GlobalSymbolsSearchOptions) []string {
+		t.Helper()
+		if opt.RepoIDs == nil {
+			opt.RepoIDs = repoIDs
+		}
+		if opt.Limit == 0 {
+			opt.Limit = 10
+		}
+		symbols, err := GlobalSymbols.Search(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, sym := range symbols {
+			names = append(names, string(sym.Commit)+":"+sym.Name)
+		}
+		return names
+	}
+
+	replace(repos[0].ID, "c1", "Foo", "fooBar", "Baz")
+	replace(repos[1].ID, "c2", "Foo")
+
+	indexes, err := GlobalSymbols.ListIndexes(ctx, repoIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 2 || indexes[0].Commit != "c1" || indexes[1].Commit != "c2" {
+		t.Errorf("got indexes %+v", indexes)
+	}
+
+	for _, test := range []struct {
+		opt  GlobalSymbolsSearchOptions
+		want []string
+	}{
+		{opt: GlobalSymbolsSearchOptions{Query: "foo"}, want: []string{"c1:Foo", "c1:fooBar", "c2:Foo"}},
+		{opt: GlobalSymbolsSearchOptions{Query: "^Foo$", IsCaseSensitive: true}, want: []string{"c1:Foo", "c2:Foo"}},
+		{opt: GlobalSymbolsSearchOptions{Query: "foo", RepoIDs: []api.RepoID{repos[1].ID}}, want: []string{"c2:Foo"}},
+		{opt: GlobalSymbolsSearchOptions{Query: "foo", Limit: 1}, want: []string{"c1:Foo"}},
+		{opt: GlobalSymbolsSearchOptions{IncludePatterns: []string{`baz\.go$`}}, want: []string{"c1:Baz"}},
+		{opt: GlobalSymbolsSearchOptions{Query: "foo", ExcludePattern: `bar`}, want: []string{"c1:Foo", "c2:Foo"}},
+		{opt: GlobalSymbolsSearchOptions{Query: `\bBaz\b`, IsCaseSensitive: true}, want: []string{"c1:Baz"}},
+	} {
+		if got := search(test.opt); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: got %q, want %q", test.opt, got, test.want)
+		}
+	}
+
+	unlock, ok, err := GlobalSymbols.TryLockUpdate(ctx)
+	if err != nil || !ok {
+		t.Fatalf("got (%v, %v) acquiring the update lock, want (true, nil)", ok, err)
+	}
+	if _, ok, err := GlobalSymbols.TryLockUpdate(ctx); err != nil || ok {
+		t.Errorf("got (%v, %v) acquiring the held update lock, want (false, nil)", ok, err)
+	}
+	unlock()
+
+	// Reindexing a repository at a new commit replaces its symbols.
+	replace(repos[0].ID, "c3", "Qux")
+	if got, want := search(GlobalSymbolsSearchOptions{Query: "foo|qux"}), []string{"c2:Foo", "c3:Qux"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after reindex: got %q, want %q", got, want)
+	}
+}
+
+func TestPostgresRegexp(t *testing.T) {
+	tests := map[string]string{
+		"foo":          "foo",
+		`^Foo$`:        "^Foo$",
+		`\bfoo\B`:      `\yfoo\Y`,
+		`(?i)foo`:      "[Ff][Oo][Oo]",
+		`foo|ba[rz]`:   "foo|ba[rz]",
+		`x(foo|bar)y`:  "x(foo|bar)y",
+		`x(?:foo|b)y`:  "x(?:foo|b)y",
+		`(?:ab)+?c`:    "(?:ab)+?c",
+		`a{2,}b{3}`:    "a{2,}b{3}",
+		`\.go$`:        `\.go$`,
+		`\d+\s`:        `[0-9]+[\U00000009-\U0000000a\U0000000c-\U0000000d ]`,
+		`a.b`:          `a[^\n]b`,
+		`(?s)a.b`:      "a.b",
+		`[^a]`:         `[\U00000001-\` + "`" + `b-\U0010ffff]`,
+		`(?P<name>ab)`: "(ab)",
+	}
+	for pattern, want := range tests {
+		got, err := postgresRegexp(pattern)
+		if err != nil {
+			t.Errorf("%q: %s", pattern, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", pattern, got, want)
+		}
+	}
+
+	for _, pattern := range []string{`a{256}`, `\x00`, `(`} {
+		if _, err := postgresRegexp(pattern); !IsGlobalSymbolsRegexpError(err) {
+			t.Errorf("%q: got error %v, want *GlobalSymbolsRegexpError", pattern, err)
+		}
+	}
+}
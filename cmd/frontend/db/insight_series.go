@@ -0,0 +1,440 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+// InsightSeriesNotFoundError occurs when an insight series is not found.
+type InsightSeriesNotFoundError struct {
+	args []interface{}
+}
+
+// NotFound implements errcode.NotFounder.
+func (err InsightSeriesNotFoundError) NotFound() bool { return true }
+
+func (err InsightSeriesNotFoundError) Error() string {
+	return fmt.Sprintf("insight series not found: %v", err.args)
+}
+
+// InsightSeriesMaxFailures is the number of times the computation of a point
+// is attempted before it is marked as errored.
+const InsightSeriesMaxFailures = 3
+
+// insightSeriesRetryDelay is how long a failed point waits in the queue
+// before it is retried.
+const insightSeriesRetryDelay = 5 * time.Minute
+
+type insightSeries struct{}
+
+// GetByID returns the insight series with the given ID.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can access the saved
+// search of the series. It is the caller's responsibility to do so.
+func (s *insightSeries) GetByID(ctx context.Context, id int32) (*types.InsightSeries, error) {
+	if Mocks.InsightSeries.GetByID != nil {
+		return Mocks.InsightSeries.GetByID(ctx, id)
+	}
+
+	series, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE id=%d LIMIT 1", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return nil, InsightSeriesNotFoundError{args: []interface{}{"id", id}}
+	}
+	return series[0], nil
+}
+
+// InsightSeriesListOptions specifies the options for listing insight series.
+type InsightSeriesListOptions struct {
+	// SavedSearchIDs, if non-nil, only lists the series of these saved
+	// searches.
+	SavedSearchIDs []int32
+	*LimitOffset
+}
+
+// List returns the insight series matching the options, ordered by ID.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can access the saved
+// searches of the series. It is the caller's responsibility to do so.
+func (s *insightSeries) List(ctx context.Context, opt InsightSeriesListOptions) ([]*types.InsightSeries, error) {
+	if Mocks.InsightSeries.List != nil {
+		return Mocks.InsightSeries.List(ctx, opt)
+	}
+	return s.getBySQL(ctx, sqlf.Sprintf("WHERE %s ORDER BY id ASC %s", s.listSQL(opt), opt.LimitOffset.SQL()))
+}
+
+// Count counts the insight series matching the options.
+func (s *insightSeries) Count(ctx context.Context, opt InsightSeriesListOptions) (int, error) {
+	if Mocks.InsightSeries.Count != nil {
+		return Mocks.InsightSeries.Count(ctx, opt)
+	}
+
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM insight_series WHERE %s", s.listSQL(opt))
+	var count int
+	err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count)
+	return count, err
+}
+
+func (*insightSeries) listSQL(opt InsightSeriesListOptions) *sqlf.Query {
+	if opt.SavedSearchIDs == nil {
+		return sqlf.Sprintf("TRUE")
+	}
+	return sqlf.Sprintf("saved_search_id = ANY(%s)", pq.Array(opt.SavedSearchIDs))
+}
+
+func (*insightSeries) getBySQL(ctx context.Context, conds *sqlf.Query) ([]*types.InsightSeries, error) {
+	q := sqlf.Sprintf(`
+SELECT id, saved_search_id, repo_ids, interval_days, start_time, created_at, updated_at, creator_user_id
+FROM insight_series
+%s`, conds)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []*types.InsightSeries
+	for rows.Next() {
+		var (
+			s       types.InsightSeries
+			repoIDs []int64
+		)
+		if err := rows.Scan(&s.ID, &s.SavedSearchID, pq.Array(&repoIDs), &s.IntervalDays, &s.StartTime, &s.CreatedAt, &s.UpdatedAt, &s.CreatorUserID); err != nil {
+			return nil, err
+		}
+		for _, id := range repoIDs {
+			s.RepoIDs = append(s.RepoIDs, api.RepoID(id))
+		}
+		series = append(series, &s)
+	}
+	return series, rows.Err()
+}
+
+// Create creates a new insight series. The ID field must be zero. The points
+// of the series are enqueued by the insights background worker.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can access the saved
+// search or the repositories of the series. It is the caller's responsibility
+// to do so.
+func (s *insightSeries) Create(ctx context.Context, series *types.InsightSeries) (*types.InsightSeries, error) {
+	if Mocks.InsightSeries.Create != nil {
+		return Mocks.InsightSeries.Create(ctx, series)
+	}
+
+	if series.ID != 0 {
+		return nil, errors.New("series.ID must be zero")
+	}
+	if series.IntervalDays <= 0 {
+		return nil, errors.New("insight series interval must be at least one day")
+	}
+	if series.CreatorUserID == 0 {
+		return nil, errors.New("insight series must have a creator")
+	}
+
+	repoIDs := make([]int64, len(series.RepoIDs))
+	for i, id := range series.RepoIDs {
+		repoIDs[i] = int64(id)
+	}
+
+	created := *series
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	err := dbconn.Global.QueryRowContext(ctx, `
+INSERT INTO insight_series(saved_search_id, repo_ids, interval_days, start_time, created_at, updated_at, creator_user_id)
+VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		created.SavedSearchID,
+		pq.Array(repoIDs),
+		created.IntervalDays,
+		created.StartTime,
+		created.CreatedAt,
+		created.UpdatedAt,
+		created.CreatorUserID,
+	).Scan(&created.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Delete deletes an insight series and its points.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can access the saved
+// search of the series. It is the caller's responsibility to do so.
+func (s *insightSeries) Delete(ctx context.Context, id int32) error {
+	if Mocks.InsightSeries.Delete != nil {
+		return Mocks.InsightSeries.Delete(ctx, id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM insight_series WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return InsightSeriesNotFoundError{args: []interface{}{"id", id}}
+	}
+	return nil
+}
+
+// LastPointTimes returns the latest time for which points of the series were
+// enqueued, by repository. Repositories without points are not in the map.
+func (s *insightSeries) LastPointTimes(ctx context.Context, seriesID int32) (map[api.RepoID]time.Time, error) {
+	if Mocks.InsightSeries.LastPointTimes != nil {
+		return Mocks.InsightSeries.LastPointTimes(ctx, seriesID)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, `SELECT repo_id, MAX("time") FROM insight_series_points WHERE series_id=$1 GROUP BY repo_id`, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	times := map[api.RepoID]time.Time{}
+	for rows.Next() {
+		var repoID api.RepoID
+		var t time.Time
+		if err := rows.Scan(&repoID, &t); err != nil {
+			return nil, err
+		}
+		times[repoID] = t
+	}
+	return times, rows.Err()
+}
+
+// EnqueuePoints enqueues the computation of the points of the series for the
+// given repositories at the given times. Points which already exist are left
+// untouched.
+func (s *insightSeries) EnqueuePoints(ctx context.Context, seriesID int32, repoIDs []api.RepoID, times []time.Time) error {
+	if Mocks.InsightSeries.EnqueuePoints != nil {
+		return Mocks.InsightSeries.EnqueuePoints(ctx, seriesID, repoIDs, times)
+	}
+
+	if len(times) == 0 || len(repoIDs) == 0 {
+		return nil
+	}
+	var values []*sqlf.Query
+	for _, t := range times {
+		for _, repoID := range repoIDs {
+			values = append(values, sqlf.Sprintf("(%d, %d, %s)", seriesID, repoID, t))
+		}
+	}
+	q := sqlf.Sprintf(`
+INSERT INTO insight_series_points(series_id, repo_id, "time")
+VALUES %s
+ON CONFLICT DO NOTHING`, sqlf.Join(values, ","))
+	_, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	return err
+}
+
+// Dequeue marks the oldest queued point as processing and returns it, with
+// the repository name, the query and the creator of the series. ok is false if no point
+// is ready to be processed. Points are dequeued with SKIP LOCKED, so that multiple workers
+// can process the queue concurrently.
+func (s *insightSeries) Dequeue(ctx context.Context) (p *types.InsightSeriesPoint, ok bool, err error) {
+	if Mocks.InsightSeries.Dequeue != nil {
+		return Mocks.InsightSeries.Dequeue(ctx)
+	}
+
+	p = &types.InsightSeriesPoint{}
+	err = dbconn.Global.QueryRowContext(ctx, `
+WITH point AS (
+	UPDATE insight_series_points SET state='processing', started_at=now() WHERE id = (
+		SELECT id FROM insight_series_points
+		WHERE state='queued' AND (process_after IS NULL OR process_after <= now())
+		ORDER BY "time" ASC, id ASC
+		FOR UPDATE SKIP LOCKED LIMIT 1
+	)
+	RETURNING id, series_id, repo_id, "time", num_failures
+)
+SELECT point.id, point.series_id, point.repo_id, point."time", point.num_failures, repo.name, saved_searches.query, insight_series.creator_user_id
+FROM point
+JOIN repo ON repo.id = point.repo_id
+JOIN insight_series ON insight_series.id = point.series_id
+JOIN saved_searches ON saved_searches.id = insight_series.saved_search_id`,
+	).Scan(&p.ID, &p.SeriesID, &p.RepoID, &p.Time, &p.NumFailures, &p.RepoName, &p.Query, &p.CreatorUserID)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p.State = "processing"
+	return p, true, nil
+}
+
+// Complete stores the match count of a processing point.
+func (s *insightSeries) Complete(ctx context.Context, id int64, commit api.CommitID, matchCount int32, limitHit bool) error {
+	if Mocks.InsightSeries.Complete != nil {
+		return Mocks.InsightSeries.Complete(ctx, id, commit, matchCount, limitHit)
+	}
+
+	var commitID *string
+	if commit != "" {
+		c := string(commit)
+		commitID = &c
+	}
+	_, err := dbconn.Global.ExecContext(ctx, `
+UPDATE insight_series_points
+SET state='completed', commit_id=$1, match_count=$2, limit_hit=$3, failure_message=NULL, finished_at=now()
+WHERE id=$4`,
+		commitID, matchCount, limitHit, id)
+	return err
+}
+
+// Fail records that the computation of a processing point failed. The point
+// is queued again to be retried after insightSeriesRetryDelay, unless it
+// failed InsightSeriesMaxFailures times.
+func (s *insightSeries) Fail(ctx context.Context, id int64, failureMessage string) error {
+	if Mocks.InsightSeries.Fail != nil {
+		return Mocks.InsightSeries.Fail(ctx, id, failureMessage)
+	}
+
+	_, err := dbconn.Global.ExecContext(ctx, `
+UPDATE insight_series_points
+SET state=CASE WHEN num_failures + 1 >= $1 THEN 'errored' ELSE 'queued' END,
+	num_failures=num_failures + 1, failure_message=$2, finished_at=now(),
+	process_after=now() + $3 * interval '1 second'
+WHERE id=$4`,
+		InsightSeriesMaxFailures, failureMessage, insightSeriesRetryDelay.Seconds(), id)
+	return err
+}
+
+// ResetStalled queues again the points which started processing before the
+// given time, e.g. because the worker processing them was restarted. It
+// returns the number of points queued again.
+func (s *insightSeries) ResetStalled(ctx context.Context, startedBefore time.Time) (int, error) {
+	if Mocks.InsightSeries.ResetStalled != nil {
+		return Mocks.InsightSeries.ResetStalled(ctx, startedBefore)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, `
+UPDATE insight_series_points SET state='queued', started_at=NULL
+WHERE state='processing' AND started_at < $1`, startedBefore)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// CompletedPointByCommit returns a completed point of the series in the
+// repository which was computed at the given commit. Its match count is
+// reused for later points while the repository doesn't change. ok is false
+// if there is no such point.
+func (s *insightSeries) CompletedPointByCommit(ctx context.Context, seriesID int32, repoID api.RepoID, commit api.CommitID) (p *types.InsightSeriesPoint, ok bool, err error) {
+	if Mocks.InsightSeries.CompletedPointByCommit != nil {
+		return Mocks.InsightSeries.CompletedPointByCommit(ctx, seriesID, repoID, commit)
+	}
+
+	points, err := s.listPointsBySQL(ctx, sqlf.Sprintf("WHERE series_id=%d AND repo_id=%d AND commit_id=%s AND state='completed' LIMIT 1", seriesID, repoID, string(commit)))
+	if err != nil || len(points) == 0 {
+		return nil, false, err
+	}
+	return points[0], true, nil
+}
+
+// InsightSeriesPointsListOptions specifies the options for listing the
+// points of an insight series.
+type InsightSeriesPointsListOptions struct {
+	SeriesID int32
+	RepoID   api.RepoID // only list the points in this repository, if nonzero
+	States   []string   // only list points in these states, if non-empty
+
+	// RepoIDs, if non-nil, only lists the points in these repositories.
+	RepoIDs []api.RepoID
+	*LimitOffset
+}
+
+// ListPoints returns the points of a series, ordered by time and repository.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can access the saved
+// search of the series. It is the caller's responsibility to do so.
+func (s *insightSeries) ListPoints(ctx context.Context, opt InsightSeriesPointsListOptions) ([]*types.InsightSeriesPoint, error) {
+	if Mocks.InsightSeries.ListPoints != nil {
+		return Mocks.InsightSeries.ListPoints(ctx, opt)
+	}
+	return s.listPointsBySQL(ctx, sqlf.Sprintf(`WHERE %s ORDER BY "time" ASC, repo_id ASC %s`, s.listPointsSQL(opt), opt.LimitOffset.SQL()))
+}
+
+// CountPoints counts the points of a series matching the options.
+func (s *insightSeries) CountPoints(ctx context.Context, opt InsightSeriesPointsListOptions) (int, error) {
+	if Mocks.InsightSeries.CountPoints != nil {
+		return Mocks.InsightSeries.CountPoints(ctx, opt)
+	}
+
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM insight_series_points WHERE %s", s.listPointsSQL(opt))
+	var count int
+	err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count)
+	return count, err
+}
+
+func (*insightSeries) listPointsSQL(opt InsightSeriesPointsListOptions) *sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("series_id=%d", opt.SeriesID)}
+	if opt.RepoID != 0 {
+		conds = append(conds, sqlf.Sprintf("repo_id=%d", opt.RepoID))
+	}
+	if opt.RepoIDs != nil {
+		ids := make([]int64, len(opt.RepoIDs))
+		for i, id := range opt.RepoIDs {
+			ids[i] = int64(id)
+		}
+		conds = append(conds, sqlf.Sprintf("repo_id = ANY(%s)", pq.Array(ids)))
+	}
+	if len(opt.States) > 0 {
+		conds = append(conds, sqlf.Sprintf("state = ANY(%s)", pq.Array(opt.States)))
+	}
+	return sqlf.Join(conds, "AND")
+}
+
+func (*insightSeries) listPointsBySQL(ctx context.Context, conds *sqlf.Query) ([]*types.InsightSeriesPoint, error) {
+	q := sqlf.Sprintf(`
+SELECT id, series_id, repo_id, "time", state, commit_id, match_count, limit_hit, num_failures, failure_message
+FROM insight_series_points
+%s`, conds)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []*types.InsightSeriesPoint
+	for rows.Next() {
+		var (
+			p      types.InsightSeriesPoint
+			commit string
+		)
+		if err := rows.Scan(
+			&p.ID,
+			&p.SeriesID,
+			&p.RepoID,
+			&p.Time,
+			&p.State,
+			&dbutil.NullString{S: &commit},
+			&dbutil.NullInt32{N: &p.MatchCount},
+			&p.LimitHit,
+			&p.NumFailures,
+			&dbutil.NullString{S: &p.FailureMessage},
+		); err != nil {
+			return nil, err
+		}
+		p.Commit = api.CommitID(commit)
+		points = append(points, &p)
+	}
+	return points, rows.Err()
+}
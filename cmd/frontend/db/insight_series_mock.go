@@ -0,0 +1,26 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type MockInsightSeries struct {
+	GetByID                func(ctx context.Context, id int32) (*types.InsightSeries, error)
+	List                   func(ctx context.Context, opt InsightSeriesListOptions) ([]*types.InsightSeries, error)
+	Count                  func(ctx context.Context, opt InsightSeriesListOptions) (int, error)
+	Create                 func(ctx context.Context, series *types.InsightSeries) (*types.InsightSeries, error)
+	Delete                 func(ctx context.Context, id int32) error
+	LastPointTimes         func(ctx context.Context, seriesID int32) (map[api.RepoID]time.Time, error)
+	EnqueuePoints          func(ctx context.Context, seriesID int32, repoIDs []api.RepoID, times []time.Time) error
+	Dequeue                func(ctx context.Context) (*types.InsightSeriesPoint, bool, error)
+	Complete               func(ctx context.Context, id int64, commit api.CommitID, matchCount int32, limitHit bool) error
+	Fail                   func(ctx context.Context, id int64, failureMessage string) error
+	ResetStalled           func(ctx context.Context, startedBefore time.Time) (int, error)
+	CompletedPointByCommit func(ctx context.Context, seriesID int32, repoID api.RepoID, commit api.CommitID) (*types.InsightSeriesPoint, bool, error)
+	ListPoints             func(ctx context.Context, opt InsightSeriesPointsListOptions) ([]*types.InsightSeriesPoint, error)
+	CountPoints            func(ctx context.Context, opt InsightSeriesPointsListOptions) (int, error)
+}
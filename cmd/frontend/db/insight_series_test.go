@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestInsightSeries(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	user, err := Users.Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss, err := SavedSearches.Create(ctx, &types.SavedSearch{Query: "foo", Description: "foo", UserID: &user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := mustCreate(ctx, t, &types.Repo{Name: "a"}, &types.Repo{Name: "b"})
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	series, err := InsightSeries.Create(ctx, &types.InsightSeries{
+		SavedSearchID: ss.ID,
+		RepoIDs:       []api.RepoID{repos[0].ID, repos[1].ID},
+		IntervalDays:  7,
+		StartTime:     start,
+		CreatorUserID: user.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := InsightSeries.GetByID(ctx, series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.RepoIDs, series.RepoIDs) || got.IntervalDays != 7 || !got.StartTime.Equal(start) || got.CreatorUserID != user.ID {
+		t.Errorf("got %+v, want %+v", got, series)
+	}
+	if list, err := InsightSeries.List(ctx, InsightSeriesListOptions{SavedSearchIDs: []int32{ss.ID}}); err != nil {
+		t.Fatal(err)
+	} else if len(list) != 1 || list[0].ID != series.ID {
+		t.Errorf("got series %+v, want [%d]", list, series.ID)
+	}
+
+	if last, err := InsightSeries.LastPointTimes(ctx, series.ID); err != nil || len(last) != 0 {
+		t.Fatalf("got last point times %v (error %v), want no points", last, err)
+	}
+	times := []time.Time{start, start.AddDate(0, 0, 7)}
+	for i := 0; i < 2; i++ {
+		// Enqueuing the same points again is a no-op.
+		if err := InsightSeries.EnqueuePoints(ctx, series.ID, series.RepoIDs, times); err != nil {
+			t.Fatal(err)
+		}
+	}
+	last, err := InsightSeries.LastPointTimes(ctx, series.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(last) != 2 || !last[repos[0].ID].Equal(times[1]) || !last[repos[1].ID].Equal(times[1]) {
+		t.Errorf("got last point times %v, want %v for both repositories", last, times[1])
+	}
+	if n, err := InsightSeries.CountPoints(ctx, InsightSeriesPointsListOptions{SeriesID: series.ID, States: []string{"queued"}}); err != nil || n != 4 {
+		t.Errorf("got %d queued points (error %v), want 4", n, err)
+	}
+	if n, err := InsightSeries.CountPoints(ctx, InsightSeriesPointsListOptions{SeriesID: series.ID, RepoIDs: []api.RepoID{repos[1].ID}}); err != nil || n != 2 {
+		t.Errorf("got %d points in repo b (error %v), want 2", n, err)
+	}
+
+	t.Run("queue", func(t *testing.T) {
+		p, ok, err := InsightSeries.Dequeue(ctx)
+		if err != nil || !ok {
+			t.Fatalf("got ok %v (error %v), want a point", ok, err)
+		}
+		if p.RepoName != "a" || p.Query != "foo" || p.CreatorUserID != user.ID || !p.Time.Equal(start) {
+			t.Errorf("got point %+v", p)
+		}
+		if err := InsightSeries.Complete(ctx, p.ID, "deadbeef", 42, false); err != nil {
+			t.Fatal(err)
+		}
+		if reused, ok, err := InsightSeries.CompletedPointByCommit(ctx, series.ID, repos[0].ID, "deadbeef"); err != nil || !ok || reused.MatchCount != 42 {
+			t.Errorf("got point %+v (ok %v, error %v), want match count 42", reused, ok, err)
+		}
+
+		p, _, err = InsightSeries.Dequeue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < InsightSeriesMaxFailures; i++ {
+			if err := InsightSeries.Fail(ctx, p.ID, "boom"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		points, err := InsightSeries.ListPoints(ctx, InsightSeriesPointsListOptions{SeriesID: series.ID, RepoID: repos[1].ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if points[0].State != "errored" || points[0].NumFailures != InsightSeriesMaxFailures || points[0].FailureMessage != "boom" {
+			t.Errorf("got point %+v, want errored", points[0])
+		}
+
+		// Stalled points are queued again.
+		p, _, err = InsightSeries.Dequeue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := InsightSeries.ResetStalled(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+			t.Errorf("got %d reset points (error %v), want 1", n, err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := InsightSeries.Delete(ctx, series.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := InsightSeries.GetByID(ctx, series.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+		if n, err := InsightSeries.CountPoints(ctx, InsightSeriesPointsListOptions{SeriesID: series.ID}); err != nil || n != 0 {
+			t.Errorf("got %d points (error %v), want none", n, err)
+		}
+	})
+}
@@ -20,6 +20,16 @@ type MockStores struct {
 
 	OrgInvitations MockOrgInvitations
 
+	OrgRepositorySets MockOrgRepositorySets
+
+	VersionContexts MockVersionContexts
+
+	InsightSeries MockInsightSeries
+
+	GlobalSymbols MockGlobalSymbols
+
+	SearchHistory MockSearchHistory
+
 	ExternalServices MockExternalServices
 
 	Authz MockAuthz
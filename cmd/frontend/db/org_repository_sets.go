@@ -0,0 +1,290 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+)
+
+// OrgRepositorySetNotFoundError occurs when an organization repository set is not found.
+type OrgRepositorySetNotFoundError struct {
+	args []interface{}
+}
+
+// NotFound implements errcode.NotFounder.
+func (err OrgRepositorySetNotFoundError) NotFound() bool { return true }
+
+func (err OrgRepositorySetNotFoundError) Error() string {
+	return fmt.Sprintf("organization repository set not found: %v", err.args)
+}
+
+var errOrgRepositorySetNameAlreadyExists = errors.New("organization already has a repository set with this name")
+
+type orgRepositorySets struct{}
+
+// GetByID returns the repository set with the given ID.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a member of the
+// organization that owns the set. It is the caller's responsibility to do so.
+func (s *orgRepositorySets) GetByID(ctx context.Context, id int32) (*types.OrgRepositorySet, error) {
+	if Mocks.OrgRepositorySets.GetByID != nil {
+		return Mocks.OrgRepositorySets.GetByID(ctx, id)
+	}
+
+	sets, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE s.id=%d LIMIT 1", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		return nil, OrgRepositorySetNotFoundError{args: []interface{}{"id", id}}
+	}
+	return sets[0], nil
+}
+
+// GetByName returns the repository set of the organization with the given name.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a member of the
+// organization. It is the caller's responsibility to do so.
+func (s *orgRepositorySets) GetByName(ctx context.Context, orgID int32, name string) (*types.OrgRepositorySet, error) {
+	if Mocks.OrgRepositorySets.GetByName != nil {
+		return Mocks.OrgRepositorySets.GetByName(ctx, orgID, name)
+	}
+
+	sets, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE s.org_id=%d AND s.name=%s LIMIT 1", orgID, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		return nil, OrgRepositorySetNotFoundError{args: []interface{}{"orgID", orgID, "name", name}}
+	}
+	return sets[0], nil
+}
+
+// ListByOrgID returns all repository sets of the organization, ordered by name.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a member of the
+// organization. It is the caller's responsibility to do so.
+func (s *orgRepositorySets) ListByOrgID(ctx context.Context, orgID int32) ([]*types.OrgRepositorySet, error) {
+	if Mocks.OrgRepositorySets.ListByOrgID != nil {
+		return Mocks.OrgRepositorySets.ListByOrgID(ctx, orgID)
+	}
+	return s.getBySQL(ctx, sqlf.Sprintf("WHERE s.org_id=%d ORDER BY s.name ASC", orgID))
+}
+
+// ListDefaultsByUserID returns the default repository sets of all organizations the
+// user is a member of, ordered by organization ID.
+func (s *orgRepositorySets) ListDefaultsByUserID(ctx context.Context, userID int32) ([]*types.OrgRepositorySet, error) {
+	if Mocks.OrgRepositorySets.ListDefaultsByUserID != nil {
+		return Mocks.OrgRepositorySets.ListDefaultsByUserID(ctx, userID)
+	}
+	return s.getBySQL(ctx, sqlf.Sprintf(`
+WHERE s.is_default AND s.org_id IN (SELECT org_id FROM org_members WHERE user_id=%d)
+ORDER BY s.org_id ASC`, userID))
+}
+
+func (*orgRepositorySets) getBySQL(ctx context.Context, conds *sqlf.Query) ([]*types.OrgRepositorySet, error) {
+	q := sqlf.Sprintf(`
+SELECT s.id, s.org_id, s.name, s.description, s.repository_names, s.name_patterns, s.is_default, s.created_at, s.updated_at
+FROM org_repository_sets s
+JOIN orgs ON orgs.id = s.org_id AND orgs.deleted_at IS NULL
+%s`, conds)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sets []*types.OrgRepositorySet
+	for rows.Next() {
+		var set types.OrgRepositorySet
+		if err := rows.Scan(
+			&set.ID,
+			&set.OrgID,
+			&set.Name,
+			&set.Description,
+			pq.Array(&set.RepositoryNames),
+			pq.Array(&set.NamePatterns),
+			&set.IsDefault,
+			&set.CreatedAt,
+			&set.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		sets = append(sets, &set)
+	}
+	return sets, rows.Err()
+}
+
+// Create creates a new repository set. The ID field must be zero. If the new set
+// is the default, the organization's previous default set (if any) is no longer
+// the default.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a member of the
+// organization. It is the caller's responsibility to do so.
+func (s *orgRepositorySets) Create(ctx context.Context, set *types.OrgRepositorySet) (created *types.OrgRepositorySet, err error) {
+	if Mocks.OrgRepositorySets.Create != nil {
+		return Mocks.OrgRepositorySets.Create(ctx, set)
+	}
+
+	if set.ID != 0 {
+		return nil, errors.New("set.ID must be zero")
+	}
+
+	tx, err := dbconn.Global.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rollErr := tx.Rollback()
+			if rollErr != nil {
+				err = multierror.Append(err, rollErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if set.IsDefault {
+		if err := clearDefaultOrgRepositorySet(ctx, tx, set.OrgID); err != nil {
+			return nil, err
+		}
+	}
+
+	created = copyOrgRepositorySet(set)
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	err = tx.QueryRowContext(ctx, `
+INSERT INTO org_repository_sets(org_id, name, description, repository_names, name_patterns, is_default, created_at, updated_at)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		created.OrgID,
+		created.Name,
+		created.Description,
+		pq.Array(created.RepositoryNames),
+		pq.Array(created.NamePatterns),
+		created.IsDefault,
+		created.CreatedAt,
+		created.UpdatedAt,
+	).Scan(&created.ID)
+	if err != nil {
+		return nil, orgRepositorySetConstraintError(err)
+	}
+	return created, nil
+}
+
+// Update updates the name, description, repositories and default status of an
+// existing repository set. The organization of a set can't be changed.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a member of the
+// organization. It is the caller's responsibility to do so.
+func (s *orgRepositorySets) Update(ctx context.Context, set *types.OrgRepositorySet) (updated *types.OrgRepositorySet, err error) {
+	if Mocks.OrgRepositorySets.Update != nil {
+		return Mocks.OrgRepositorySets.Update(ctx, set)
+	}
+
+	tx, err := dbconn.Global.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			rollErr := tx.Rollback()
+			if rollErr != nil {
+				err = multierror.Append(err, rollErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if set.IsDefault {
+		if err := clearDefaultOrgRepositorySet(ctx, tx, set.OrgID); err != nil {
+			return nil, err
+		}
+	}
+
+	updated = copyOrgRepositorySet(set)
+	updated.UpdatedAt = time.Now()
+	err = tx.QueryRowContext(ctx, `
+UPDATE org_repository_sets
+SET name=$1, description=$2, repository_names=$3, name_patterns=$4, is_default=$5, updated_at=$6
+WHERE id=$7 AND org_id=$8
+RETURNING created_at`,
+		updated.Name,
+		updated.Description,
+		pq.Array(updated.RepositoryNames),
+		pq.Array(updated.NamePatterns),
+		updated.IsDefault,
+		updated.UpdatedAt,
+		updated.ID,
+		updated.OrgID,
+	).Scan(&updated.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, OrgRepositorySetNotFoundError{args: []interface{}{"id", set.ID}}
+	}
+	if err != nil {
+		return nil, orgRepositorySetConstraintError(err)
+	}
+	return updated, nil
+}
+
+// Delete deletes a repository set.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a member of the
+// organization. It is the caller's responsibility to do so.
+func (s *orgRepositorySets) Delete(ctx context.Context, id int32) error {
+	if Mocks.OrgRepositorySets.Delete != nil {
+		return Mocks.OrgRepositorySets.Delete(ctx, id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM org_repository_sets WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return OrgRepositorySetNotFoundError{args: []interface{}{"id", id}}
+	}
+	return nil
+}
+
+func clearDefaultOrgRepositorySet(ctx context.Context, tx *sql.Tx, orgID int32) error {
+	_, err := tx.ExecContext(ctx, "UPDATE org_repository_sets SET is_default=false WHERE org_id=$1 AND is_default", orgID)
+	return err
+}
+
+func copyOrgRepositorySet(set *types.OrgRepositorySet) *types.OrgRepositorySet {
+	c := *set
+	// Store empty lists rather than NULL, because the columns are NOT NULL.
+	if c.RepositoryNames == nil {
+		c.RepositoryNames = []string{}
+	}
+	if c.NamePatterns == nil {
+		c.NamePatterns = []string{}
+	}
+	return &c
+}
+
+func orgRepositorySetConstraintError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Constraint {
+		case "org_repository_sets_org_id_name":
+			return errOrgRepositorySetNameAlreadyExists
+		case "org_repository_sets_name_valid_chars", "org_repository_sets_name_max_length":
+			return fmt.Errorf("repository set name invalid: %s", pqErr.Constraint)
+		}
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockOrgRepositorySets struct {
+	GetByID              func(ctx context.Context, id int32) (*types.OrgRepositorySet, error)
+	GetByName            func(ctx context.Context, orgID int32, name string) (*types.OrgRepositorySet, error)
+	ListByOrgID          func(ctx context.Context, orgID int32) ([]*types.OrgRepositorySet, error)
+	ListDefaultsByUserID func(ctx context.Context, userID int32) ([]*types.OrgRepositorySet, error)
+	Create               func(ctx context.Context, set *types.OrgRepositorySet) (*types.OrgRepositorySet, error)
+	Update               func(ctx context.Context, set *types.OrgRepositorySet) (*types.OrgRepositorySet, error)
+	Delete               func(ctx context.Context, id int32) error
+}
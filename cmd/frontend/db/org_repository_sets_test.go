@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestOrgRepositorySets(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	org, err := Orgs.Create(ctx, "o", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend, err := OrgRepositorySets.Create(ctx, &types.OrgRepositorySet{
+		OrgID:           org.ID,
+		Name:            "backend",
+		RepositoryNames: []string{"github.com/o/api"},
+		NamePatterns:    []string{"^github\\.com/o/svc-"},
+		IsDefault:       true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := OrgRepositorySets.GetByName(ctx, org.ID, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.RepositoryNames, backend.RepositoryNames) || !reflect.DeepEqual(got.NamePatterns, backend.NamePatterns) || !got.IsDefault {
+		t.Errorf("got %+v, want %+v", got, backend)
+	}
+
+	if _, err := OrgRepositorySets.Create(ctx, &types.OrgRepositorySet{OrgID: org.ID, Name: "backend"}); err != errOrgRepositorySetNameAlreadyExists {
+		t.Errorf("got error %v, want %v", err, errOrgRepositorySetNameAlreadyExists)
+	}
+	if _, err := OrgRepositorySets.Create(ctx, &types.OrgRepositorySet{OrgID: org.ID, Name: "in valid"}); err == nil {
+		t.Error("got nil error for invalid name")
+	}
+
+	// Creating a new default set replaces the previous default.
+	frontend, err := OrgRepositorySets.Create(ctx, &types.OrgRepositorySet{
+		OrgID:     org.ID,
+		Name:      "frontend",
+		IsDefault: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := OrgRepositorySets.GetByID(ctx, backend.ID); err != nil {
+		t.Fatal(err)
+	} else if got.IsDefault {
+		t.Error("previous default set is still the default")
+	}
+
+	sets, err := OrgRepositorySets.ListByOrgID(ctx, org.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 2 || sets[0].Name != "backend" || sets[1].Name != "frontend" {
+		t.Errorf("got sets %+v, want [backend frontend]", sets)
+	}
+
+	t.Run("ListDefaultsByUserID", func(t *testing.T) {
+		user, err := Users.Create(ctx, NewUser{Username: "u"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sets, err := OrgRepositorySets.ListDefaultsByUserID(ctx, user.ID); err != nil {
+			t.Fatal(err)
+		} else if len(sets) != 0 {
+			t.Errorf("got %d default sets for non-member, want 0", len(sets))
+		}
+
+		if _, err := OrgMembers.Create(ctx, org.ID, user.ID); err != nil {
+			t.Fatal(err)
+		}
+		sets, err := OrgRepositorySets.ListDefaultsByUserID(ctx, user.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sets) != 1 || sets[0].ID != frontend.ID {
+			t.Errorf("got default sets %+v, want [frontend]", sets)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		frontend.RepositoryNames = []string{"github.com/o/web"}
+		frontend.IsDefault = false
+		updated, err := OrgRepositorySets.Update(ctx, frontend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := OrgRepositorySets.GetByID(ctx, frontend.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.RepositoryNames, updated.RepositoryNames) || got.IsDefault {
+			t.Errorf("got %+v, want %+v", got, updated)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := OrgRepositorySets.Delete(ctx, backend.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OrgRepositorySets.GetByID(ctx, backend.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+		if err := OrgRepositorySets.Delete(ctx, backend.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+	})
+
+	t.Run("deleted org", func(t *testing.T) {
+		if err := Orgs.Delete(ctx, org.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OrgRepositorySets.GetByID(ctx, frontend.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+	})
+}
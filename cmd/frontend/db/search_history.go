@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+type searchHistory struct{}
+
+// Add adds a search to the search history of a user.
+func (s *searchHistory) Add(ctx context.Context, entry *types.SearchHistoryEntry) error {
+	if Mocks.SearchHistory.Add != nil {
+		return Mocks.SearchHistory.Add(ctx, entry)
+	}
+
+	var versionContext *string
+	if entry.VersionContext != "" {
+		versionContext = &entry.VersionContext
+	}
+	_, err := dbconn.Global.ExecContext(ctx, `
+INSERT INTO search_history(user_id, query, pattern_type, version_context, result_count, duration_ms)
+VALUES($1, $2, $3, $4, $5, $6)`,
+		entry.UserID,
+		entry.Query,
+		entry.PatternType,
+		versionContext,
+		entry.ResultCount,
+		entry.Duration.Milliseconds(),
+	)
+	return err
+}
+
+// ListByUser returns the search history of a user, most recent first.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is the current user
+// or a site admin. It is the caller's responsibility to do so.
+func (s *searchHistory) ListByUser(ctx context.Context, userID int32, limitOffset *LimitOffset) ([]*types.SearchHistoryEntry, error) {
+	if Mocks.SearchHistory.ListByUser != nil {
+		return Mocks.SearchHistory.ListByUser(ctx, userID, limitOffset)
+	}
+
+	q := sqlf.Sprintf(`
+SELECT id, user_id, query, pattern_type, version_context, result_count, duration_ms, created_at
+FROM search_history
+WHERE user_id=%d
+ORDER BY created_at DESC, id DESC
+%s`, userID, limitOffset.SQL())
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*types.SearchHistoryEntry
+	for rows.Next() {
+		var (
+			e          types.SearchHistoryEntry
+			durationMs int64
+		)
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Query, &e.PatternType, &dbutil.NullString{S: &e.VersionContext}, &e.ResultCount, &durationMs, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Duration = time.Duration(durationMs) * time.Millisecond
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// CountByUser counts the searches in the search history of a user.
+func (s *searchHistory) CountByUser(ctx context.Context, userID int32) (int, error) {
+	if Mocks.SearchHistory.CountByUser != nil {
+		return Mocks.SearchHistory.CountByUser(ctx, userID)
+	}
+
+	var count int
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT COUNT(*) FROM search_history WHERE user_id=$1", userID).Scan(&count)
+	return count, err
+}
+
+// DeleteByUser deletes the search history of a user.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is the current user
+// or a site admin. It is the caller's responsibility to do so.
+func (s *searchHistory) DeleteByUser(ctx context.Context, userID int32) error {
+	if Mocks.SearchHistory.DeleteByUser != nil {
+		return Mocks.SearchHistory.DeleteByUser(ctx, userID)
+	}
+
+	_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_history WHERE user_id=$1", userID)
+	return err
+}
+
+// DeleteOlderThan deletes the searches of all users which were run before the
+// given time. It returns the number of deleted searches.
+func (s *searchHistory) DeleteOlderThan(ctx context.Context, t time.Time) (int, error) {
+	if Mocks.SearchHistory.DeleteOlderThan != nil {
+		return Mocks.SearchHistory.DeleteOlderThan(ctx, t)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_history WHERE created_at < $1", t)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
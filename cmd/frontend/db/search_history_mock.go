@@ -0,0 +1,16 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockSearchHistory struct {
+	Add             func(ctx context.Context, entry *types.SearchHistoryEntry) error
+	ListByUser      func(ctx context.Context, userID int32, limitOffset *LimitOffset) ([]*types.SearchHistoryEntry, error)
+	CountByUser     func(ctx context.Context, userID int32) (int, error)
+	DeleteByUser    func(ctx context.Context, userID int32) error
+	DeleteOlderThan func(ctx context.Context, t time.Time) (int, error)
+}
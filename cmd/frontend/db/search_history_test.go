@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+)
+
+func TestSearchHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	u1, err := Users.Create(ctx, NewUser{Username: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u2, err := Users.Create(ctx, NewUser{Username: "u2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add := func(userID int32, query string) {
+		t.Helper()
+		if err := SearchHistory.Add(ctx, &types.SearchHistoryEntry{
+			UserID:      userID,
+			Query:       query,
+			PatternType: "literal",
+			ResultCount: 3,
+			Duration:    1500 * time.Millisecond,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	queries := func(userID int32) []string {
+		t.Helper()
+		entries, err := SearchHistory.ListByUser(ctx, userID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var queries []string
+		for _, e := range entries {
+			queries = append(queries, e.Query)
+		}
+		return queries
+	}
+
+	add(u1.ID, "a")
+	add(u1.ID, "b")
+	add(u2.ID, "c")
+
+	entries, err := SearchHistory.ListByUser(ctx, u1.ID, &LimitOffset{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Query != "b" || entries[0].Duration != 1500*time.Millisecond || entries[0].ResultCount != 3 || entries[0].VersionContext != "" {
+		t.Errorf("got entries %+v, want the most recent search", entries)
+	}
+	if n, err := SearchHistory.CountByUser(ctx, u1.ID); err != nil || n != 2 {
+		t.Errorf("got count %d (error %v), want 2", n, err)
+	}
+
+	t.Run("retention", func(t *testing.T) {
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE search_history SET created_at=now() - interval '10 days' WHERE query='a'"); err != nil {
+			t.Fatal(err)
+		}
+		n, err := SearchHistory.DeleteOlderThan(ctx, time.Now().AddDate(0, 0, -7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("got %d deleted searches, want 1", n)
+		}
+		if got, want := queries(u1.ID), []string{"b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got queries %q, want %q", got, want)
+		}
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		if err := SearchHistory.DeleteByUser(ctx, u1.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got := queries(u1.ID); len(got) != 0 {
+			t.Errorf("got queries %q, want none", got)
+		}
+		if got, want := queries(u2.ID), []string{"c"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got queries of other user %q, want %q", got, want)
+		}
+	})
+
+	t.Run("user deleted", func(t *testing.T) {
+		if err := Users.Delete(ctx, u2.ID); err != nil {
+			t.Fatal(err)
+		}
+		if got := queries(u2.ID); len(got) != 0 {
+			t.Errorf("got queries %q of deleted user, want none", got)
+		}
+	})
+}
@@ -118,23 +118,89 @@ func (o *settings) ListAll(ctx context.Context, impreciseSubstring string) (_ []
 	return o.parseQueryRows(ctx, rows)
 }
 
-func (o *settings) getLatest(ctx context.Context, queryTarget queryable, subject api.SettingsSubject) (*api.Settings, error) {
-	var cond *sqlf.Query
+// ListBySubject lists the settings of the subject, newest first. If limit is
+// positive, at most limit settings are returned.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can view the subject's
+// settings. It is the caller's responsibility to do so.
+func (o *settings) ListBySubject(ctx context.Context, subject api.SettingsSubject, limit int) ([]*api.Settings, error) {
+	if Mocks.Settings.ListBySubject != nil {
+		return Mocks.Settings.ListBySubject(ctx, subject, limit)
+	}
+
+	limitQuery := sqlf.Sprintf("")
+	if limit > 0 {
+		limitQuery = sqlf.Sprintf("LIMIT %d", limit)
+	}
+	q := sqlf.Sprintf(`
+		SELECT s.id, s.org_id, s.user_id, CASE WHEN users.deleted_at IS NULL THEN s.author_user_id ELSE NULL END, s.contents, s.created_at FROM settings s
+		LEFT JOIN users ON users.id=s.author_user_id
+		WHERE %s
+		ORDER BY id DESC %s`, settingsSubjectCond(subject), limitQuery)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	return o.parseQueryRows(ctx, rows)
+}
+
+// GetByID returns the settings of the subject with the given ID.
+//
+// 🚨 SECURITY: This method does NOT verify that the user can view the subject's
+// settings. It is the caller's responsibility to do so.
+func (o *settings) GetByID(ctx context.Context, subject api.SettingsSubject, id int32) (*api.Settings, error) {
+	if Mocks.Settings.GetByID != nil {
+		return Mocks.Settings.GetByID(ctx, subject, id)
+	}
+
+	q := sqlf.Sprintf(`
+		SELECT s.id, s.org_id, s.user_id, CASE WHEN users.deleted_at IS NULL THEN s.author_user_id ELSE NULL END, s.contents, s.created_at FROM settings s
+		LEFT JOIN users ON users.id=s.author_user_id
+		WHERE s.id=%d AND %s`, id, settingsSubjectCond(subject))
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := o.parseQueryRows(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings) != 1 {
+		return nil, SettingsNotFoundError{id: id}
+	}
+	return settings[0], nil
+}
+
+// SettingsNotFoundError occurs when settings with a given ID are not found.
+type SettingsNotFoundError struct {
+	id int32
+}
+
+// NotFound implements errcode.NotFounder.
+func (err SettingsNotFoundError) NotFound() bool { return true }
+
+func (err SettingsNotFoundError) Error() string {
+	return fmt.Sprintf("settings not found: %d", err.id)
+}
+
+func settingsSubjectCond(subject api.SettingsSubject) *sqlf.Query {
 	switch {
 	case subject.Org != nil:
-		cond = sqlf.Sprintf("org_id=%d", *subject.Org)
+		return sqlf.Sprintf("org_id=%d", *subject.Org)
 	case subject.User != nil:
-		cond = sqlf.Sprintf("user_id=%d AND EXISTS (SELECT NULL FROM users WHERE id=%d AND deleted_at IS NULL)", *subject.User, *subject.User)
+		return sqlf.Sprintf("user_id=%d AND EXISTS (SELECT NULL FROM users WHERE id=%d AND deleted_at IS NULL)", *subject.User, *subject.User)
 	default:
 		// No org and no user represents global site settings.
-		cond = sqlf.Sprintf("user_id IS NULL AND org_id IS NULL")
+		return sqlf.Sprintf("user_id IS NULL AND org_id IS NULL")
 	}
+}
 
+func (o *settings) getLatest(ctx context.Context, queryTarget queryable, subject api.SettingsSubject) (*api.Settings, error) {
 	q := sqlf.Sprintf(`
 		SELECT s.id, s.org_id, s.user_id, CASE WHEN users.deleted_at IS NULL THEN s.author_user_id ELSE NULL END, s.contents, s.created_at FROM settings s
 		LEFT JOIN users ON users.id=s.author_user_id
 		WHERE %s
-		ORDER BY id DESC LIMIT 1`, cond)
+		ORDER BY id DESC LIMIT 1`, settingsSubjectCond(subject))
 	rows, err := queryTarget.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
 	if err != nil {
 		return nil, err
@@ -9,4 +9,6 @@ import (
 type MockSettings struct {
 	GetLatest        func(ctx context.Context, subject api.SettingsSubject) (*api.Settings, error)
 	CreateIfUpToDate func(ctx context.Context, subject api.SettingsSubject, lastID, authorUserID *int32, contents string) (latestSetting *api.Settings, err error)
+	ListBySubject    func(ctx context.Context, subject api.SettingsSubject, limit int) ([]*api.Settings, error)
+	GetByID          func(ctx context.Context, subject api.SettingsSubject, id int32) (*api.Settings, error)
 }
@@ -2,10 +2,12 @@ package db
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 )
 
 func TestSettings_ListAll(t *testing.T) {
@@ -55,3 +57,58 @@ func TestSettings_ListAll(t *testing.T) {
 		}
 	})
 }
+
+func TestSettings_ListBySubjectAndGetByID(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	user, err := Users.Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject := api.SettingsSubject{User: &user.ID}
+
+	var lastID *int32
+	for _, contents := range []string{`{"a": 1}`, `{"a": 2}`, `{"a": 3}`} {
+		s, err := Settings.CreateIfUpToDate(ctx, subject, lastID, &user.ID, contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lastID = &s.ID
+	}
+	// Settings of other subjects must not be listed.
+	if _, err := Settings.CreateIfUpToDate(ctx, api.SettingsSubject{Site: true}, nil, nil, `{"site": true}`); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := Settings.ListBySubject(ctx, subject, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range history {
+		got = append(got, s.Contents)
+	}
+	if want := []string{`{"a": 3}`, `{"a": 2}`, `{"a": 1}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got history %q, want %q", got, want)
+	}
+
+	if history, err := Settings.ListBySubject(ctx, subject, 1); err != nil {
+		t.Fatal(err)
+	} else if len(history) != 1 {
+		t.Errorf("got %d settings, want 1", len(history))
+	}
+
+	oldest := history[len(history)-1]
+	if s, err := Settings.GetByID(ctx, subject, oldest.ID); err != nil {
+		t.Fatal(err)
+	} else if s.Contents != oldest.Contents {
+		t.Errorf("got contents %q, want %q", s.Contents, oldest.Contents)
+	}
+	if _, err := Settings.GetByID(ctx, api.SettingsSubject{Site: true}, oldest.ID); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found for settings of another subject", err)
+	}
+}
@@ -21,5 +21,15 @@ var (
 
 	OrgInvitations = &orgInvitations{}
 
+	OrgRepositorySets = &orgRepositorySets{}
+
+	VersionContexts = &versionContexts{}
+
+	InsightSeries = &insightSeries{}
+
+	GlobalSymbols = &globalSymbols{}
+
+	SearchHistory = &searchHistory{}
+
 	Authz AuthzStore = &authzStore{}
 )
@@ -438,6 +438,10 @@ func (u *users) Delete(ctx context.Context, id int32) error {
 	if _, err := tx.ExecContext(ctx, "UPDATE registry_extensions SET deleted_at=now() WHERE deleted_at IS NULL AND publisher_user_id=$1", id); err != nil {
 		return err
 	}
+	// The search history is private to the user, so it is not kept.
+	if _, err := tx.ExecContext(ctx, "DELETE FROM search_history WHERE user_id=$1", id); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -15,6 +15,10 @@ import (
 )
 
 func (u *users) IsPassword(ctx context.Context, id int32, password string) (bool, error) {
+	if Mocks.Users.IsPassword != nil {
+		return Mocks.Users.IsPassword(ctx, id, password)
+	}
+
 	var passwd sql.NullString
 	if err := dbconn.Global.QueryRowContext(ctx, "SELECT passwd FROM users WHERE deleted_at IS NULL AND id=$1", id).Scan(&passwd); err != nil {
 		return false, err
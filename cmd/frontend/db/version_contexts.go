@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+// VersionContextNotFoundError occurs when a version context is not found.
+type VersionContextNotFoundError struct {
+	args []interface{}
+}
+
+// NotFound implements errcode.NotFounder.
+func (err VersionContextNotFoundError) NotFound() bool { return true }
+
+func (err VersionContextNotFoundError) Error() string {
+	return fmt.Sprintf("version context not found: %v", err.args)
+}
+
+var errVersionContextNameAlreadyExists = errors.New("a version context with this name already exists")
+
+type versionContexts struct{}
+
+// GetByID returns the version context with the given ID.
+//
+// 🚨 SECURITY: Version contexts are readable by all users. Callers that modify the
+// version context must check that the user is allowed to do so.
+func (s *versionContexts) GetByID(ctx context.Context, id int32) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.GetByID != nil {
+		return Mocks.VersionContexts.GetByID(ctx, id)
+	}
+
+	vcs, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE vc.id=%d AND %s LIMIT 1", id, versionContextOwnerNotDeleted))
+	if err != nil {
+		return nil, err
+	}
+	if len(vcs) == 0 {
+		return nil, VersionContextNotFoundError{args: []interface{}{"id", id}}
+	}
+	return vcs[0], nil
+}
+
+// GetByName returns the version context with the given name.
+//
+// 🚨 SECURITY: Version contexts are readable by all users. Callers that modify the
+// version context must check that the user is allowed to do so.
+func (s *versionContexts) GetByName(ctx context.Context, name string) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.GetByName != nil {
+		return Mocks.VersionContexts.GetByName(ctx, name)
+	}
+
+	vcs, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE vc.name=%s AND %s LIMIT 1", name, versionContextOwnerNotDeleted))
+	if err != nil {
+		return nil, err
+	}
+	if len(vcs) == 0 {
+		return nil, VersionContextNotFoundError{args: []interface{}{"name", name}}
+	}
+	return vcs[0], nil
+}
+
+// List returns all version contexts, ordered by name.
+func (s *versionContexts) List(ctx context.Context) ([]*types.VersionContext, error) {
+	if Mocks.VersionContexts.List != nil {
+		return Mocks.VersionContexts.List(ctx)
+	}
+	return s.getBySQL(ctx, sqlf.Sprintf("WHERE %s ORDER BY vc.name ASC", versionContextOwnerNotDeleted))
+}
+
+// versionContextOwnerNotDeleted hides the version contexts of deleted
+// organizations.
+var versionContextOwnerNotDeleted = sqlf.Sprintf("(vc.org_id IS NULL OR orgs.deleted_at IS NULL)")
+
+func (*versionContexts) getBySQL(ctx context.Context, conds *sqlf.Query) ([]*types.VersionContext, error) {
+	q := sqlf.Sprintf(`
+SELECT vc.id, vc.name, vc.description, vc.org_id, vc.revisions, vc.created_at, vc.updated_at
+FROM version_contexts vc
+LEFT JOIN orgs ON orgs.id = vc.org_id
+%s`, conds)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var vcs []*types.VersionContext
+	for rows.Next() {
+		var (
+			vc        types.VersionContext
+			revisions []byte
+		)
+		if err := rows.Scan(&vc.ID, &vc.Name, &vc.Description, &dbutil.NullInt32{N: &vc.OrgID}, &revisions, &vc.CreatedAt, &vc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(revisions, &vc.Revisions); err != nil {
+			return nil, err
+		}
+		vcs = append(vcs, &vc)
+	}
+	return vcs, rows.Err()
+}
+
+// Create creates a new version context. The ID field must be zero.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a site admin (for
+// site-wide version contexts) or a member of the owning organization. It is the
+// caller's responsibility to do so.
+func (s *versionContexts) Create(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.Create != nil {
+		return Mocks.VersionContexts.Create(ctx, vc)
+	}
+
+	if vc.ID != 0 {
+		return nil, errors.New("vc.ID must be zero")
+	}
+
+	revisions, err := marshalVersionContextRevisions(vc.Revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	created := *vc
+	var orgID *int32
+	if created.OrgID != 0 {
+		orgID = &created.OrgID
+	}
+	created.CreatedAt = time.Now()
+	created.UpdatedAt = created.CreatedAt
+	err = dbconn.Global.QueryRowContext(ctx, `
+INSERT INTO version_contexts(name, description, org_id, revisions, created_at, updated_at)
+VALUES($1, $2, $3, $4, $5, $6) RETURNING id`,
+		created.Name,
+		created.Description,
+		orgID,
+		revisions,
+		created.CreatedAt,
+		created.UpdatedAt,
+	).Scan(&created.ID)
+	if err != nil {
+		return nil, versionContextConstraintError(err)
+	}
+	return &created, nil
+}
+
+// Update updates the name, description and revisions of an existing version
+// context. The owner of a version context can't be changed.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a site admin (for
+// site-wide version contexts) or a member of the owning organization. It is the
+// caller's responsibility to do so.
+func (s *versionContexts) Update(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.Update != nil {
+		return Mocks.VersionContexts.Update(ctx, vc)
+	}
+
+	revisions, err := marshalVersionContextRevisions(vc.Revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *vc
+	updated.UpdatedAt = time.Now()
+	err = dbconn.Global.QueryRowContext(ctx, `
+UPDATE version_contexts
+SET name=$1, description=$2, revisions=$3, updated_at=$4
+WHERE id=$5
+RETURNING org_id, created_at`,
+		updated.Name,
+		updated.Description,
+		revisions,
+		updated.UpdatedAt,
+		updated.ID,
+	).Scan(&dbutil.NullInt32{N: &updated.OrgID}, &updated.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, VersionContextNotFoundError{args: []interface{}{"id", vc.ID}}
+	}
+	if err != nil {
+		return nil, versionContextConstraintError(err)
+	}
+	return &updated, nil
+}
+
+// Delete deletes a version context.
+//
+// 🚨 SECURITY: This method does NOT verify that the user is a site admin (for
+// site-wide version contexts) or a member of the owning organization. It is the
+// caller's responsibility to do so.
+func (s *versionContexts) Delete(ctx context.Context, id int32) error {
+	if Mocks.VersionContexts.Delete != nil {
+		return Mocks.VersionContexts.Delete(ctx, id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM version_contexts WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return VersionContextNotFoundError{args: []interface{}{"id", id}}
+	}
+	return nil
+}
+
+func marshalVersionContextRevisions(revisions []*types.VersionContextRevision) ([]byte, error) {
+	if revisions == nil {
+		// Store an empty list rather than JSON null.
+		revisions = []*types.VersionContextRevision{}
+	}
+	return json.Marshal(revisions)
+}
+
+func versionContextConstraintError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Constraint {
+		case "version_contexts_name":
+			return errVersionContextNameAlreadyExists
+		case "version_contexts_name_not_empty", "version_contexts_name_max_length":
+			return fmt.Errorf("version context name invalid: %s", pqErr.Constraint)
+		}
+	}
+	return err
+}
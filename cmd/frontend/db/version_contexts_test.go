@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestVersionContexts(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	org, err := Orgs.Create(ctx, "o", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	site, err := VersionContexts.Create(ctx, &types.VersionContext{
+		Name:      "3.16",
+		Revisions: []*types.VersionContextRevision{{Repo: "github.com/o/a", Rev: "v3.16.0"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgVC, err := VersionContexts.Create(ctx, &types.VersionContext{
+		Name:        "3.17",
+		Description: "release 3.17",
+		OrgID:       org.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := VersionContexts.GetByName(ctx, "3.16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OrgID != 0 || !reflect.DeepEqual(got.Revisions, site.Revisions) {
+		t.Errorf("got %+v, want %+v", got, site)
+	}
+	if got, err := VersionContexts.GetByID(ctx, orgVC.ID); err != nil {
+		t.Fatal(err)
+	} else if got.OrgID != org.ID || got.Description != "release 3.17" || len(got.Revisions) != 0 {
+		t.Errorf("got %+v, want %+v", got, orgVC)
+	}
+
+	if _, err := VersionContexts.Create(ctx, &types.VersionContext{Name: "3.16"}); err != errVersionContextNameAlreadyExists {
+		t.Errorf("got error %v, want %v", err, errVersionContextNameAlreadyExists)
+	}
+
+	t.Run("Update", func(t *testing.T) {
+		orgVC.Revisions = []*types.VersionContextRevision{{Repo: "github.com/o/a", Rev: "v3.17.0"}}
+		if _, err := VersionContexts.Update(ctx, orgVC); err != nil {
+			t.Fatal(err)
+		}
+		got, err := VersionContexts.GetByID(ctx, orgVC.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Revisions, orgVC.Revisions) || got.OrgID != org.ID {
+			t.Errorf("got %+v, want %+v", got, orgVC)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		vcs, err := VersionContexts.List(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vcs) != 2 || vcs[0].Name != "3.16" || vcs[1].Name != "3.17" {
+			t.Errorf("got %+v, want [3.16 3.17]", vcs)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := VersionContexts.Delete(ctx, site.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VersionContexts.GetByID(ctx, site.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+	})
+
+	t.Run("deleted org", func(t *testing.T) {
+		if err := Orgs.Delete(ctx, org.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VersionContexts.GetByName(ctx, "3.17"); !errcode.IsNotFound(err) {
+			t.Errorf("got error %v, want not found", err)
+		}
+	})
+}
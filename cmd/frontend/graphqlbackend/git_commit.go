@@ -3,6 +3,7 @@ package graphqlbackend
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"sync"
 
@@ -192,6 +193,18 @@ func (r *GitCommitResolver) Tree(ctx context.Context, args *struct {
 	}
 	stat, err := git.Stat(ctx, *cachedRepo, api.CommitID(r.oid), args.Path)
 	if err != nil {
+		// The path may be inside a submodule.
+		if os.IsNotExist(err) {
+			if commit, subpath, err := r.submoduleCommit(ctx, *cachedRepo, args.Path); commit != nil || err != nil {
+				if err != nil {
+					return nil, err
+				}
+				return commit.Tree(ctx, &struct {
+					Path      string
+					Recursive bool
+				}{Path: subpath, Recursive: args.Recursive})
+			}
+		}
 		return nil, err
 	}
 	if !stat.Mode().IsDir() {
@@ -213,6 +226,15 @@ func (r *GitCommitResolver) Blob(ctx context.Context, args *struct {
 	}
 	stat, err := git.Stat(ctx, *cachedRepo, api.CommitID(r.oid), args.Path)
 	if err != nil {
+		// The path may be inside a submodule.
+		if os.IsNotExist(err) {
+			if commit, subpath, err := r.submoduleCommit(ctx, *cachedRepo, args.Path); commit != nil || err != nil {
+				if err != nil {
+					return nil, err
+				}
+				return commit.Blob(ctx, &struct{ Path string }{Path: subpath})
+			}
+		}
 		return nil, err
 	}
 	if !stat.Mode().IsRegular() {
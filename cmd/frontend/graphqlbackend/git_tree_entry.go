@@ -2,7 +2,6 @@ package graphqlbackend
 
 import (
 	"context"
-	"fmt"
 	neturl "net/url"
 	"os"
 	"path"
@@ -18,6 +17,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/highlight"
+	"github.com/sourcegraph/sourcegraph/internal/textencoding"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -39,7 +39,9 @@ type GitTreeEntryResolver struct {
 	commit *GitCommitResolver
 
 	contentOnce sync.Once
-	content     []byte
+	content     []byte // the content transcoded to UTF-8
+	rawContent  []byte
+	encoding    string // the original encoding of the content, or "" if it is binary
 	contentErr  error
 
 	// stat is this tree entry's file info. Its Name method must return the full path relative to
@@ -63,11 +65,10 @@ func (r *GitTreeEntryResolver) ToGitBlob() (*GitTreeEntryResolver, bool) { retur
 func (r *GitTreeEntryResolver) ToVirtualFile() (*virtualFileResolver, bool) { return nil, false }
 
 func (r *GitTreeEntryResolver) ByteSize(ctx context.Context) (int32, error) {
-	content, err := r.Content(ctx)
-	if err != nil {
+	if _, err := r.Content(ctx); err != nil {
 		return 0, err
 	}
-	return int32(len([]byte(content))), nil
+	return int32(len(r.rawContent)), nil
 }
 
 func (r *GitTreeEntryResolver) Content(ctx context.Context) (string, error) {
@@ -80,12 +81,23 @@ func (r *GitTreeEntryResolver) Content(ctx context.Context) (string, error) {
 			r.contentErr = err
 		}
 
-		r.content, r.contentErr = git.ReadFile(ctx, *cachedRepo, api.CommitID(r.commit.OID()), r.Path(), 0)
+		r.rawContent, r.contentErr = git.ReadFile(ctx, *cachedRepo, api.CommitID(r.commit.OID()), r.Path(), 0)
+		r.content, r.encoding = textencoding.Decode(r.rawContent)
 	})
 
 	return string(r.content), r.contentErr
 }
 
+func (r *GitTreeEntryResolver) Encoding(ctx context.Context) (*string, error) {
+	if _, err := r.Content(ctx); err != nil {
+		return nil, err
+	}
+	if r.encoding == "" {
+		return nil, nil
+	}
+	return &r.encoding, nil
+}
+
 func (r *GitTreeEntryResolver) RichHTML(ctx context.Context) (string, error) {
 	content, err := r.Content(ctx)
 	if err != nil {
@@ -121,12 +133,15 @@ func (r *GitTreeEntryResolver) IsRecursive() bool { return r.isRecursive }
 
 func (r *GitTreeEntryResolver) URL(ctx context.Context) (string, error) {
 	if submodule := r.Submodule(); submodule != nil {
-		repoName, err := cloneURLToRepoName(ctx, submodule.URL())
+		repo, err := submodule.resolveRepo(ctx)
 		if err != nil {
-			log15.Error("Failed to resolve submodule repository name from clone URL", "cloneURL", submodule.URL(), "err", err)
+			log15.Error("Failed to resolve submodule repository from clone URL", "cloneURL", submodule.URL(), "err", err)
 			return "", nil
 		}
-		return "/" + repoName + "@" + submodule.Commit(), nil
+		if repo == nil {
+			return "", nil
+		}
+		return "/" + string(repo.Name) + "@" + submodule.Commit(), nil
 	}
 	url, err := r.commit.repoRevURL()
 	if err != nil {
@@ -177,22 +192,11 @@ func (r *GitTreeEntryResolver) RawZipArchiveURL() string {
 
 func (r *GitTreeEntryResolver) Submodule() *gitSubmoduleResolver {
 	if submoduleInfo, ok := r.stat.Sys().(git.Submodule); ok {
-		return &gitSubmoduleResolver{submodule: submoduleInfo}
+		return &gitSubmoduleResolver{submodule: submoduleInfo, superproject: r.commit.repo.repo}
 	}
 	return nil
 }
 
-func cloneURLToRepoName(ctx context.Context, cloneURL string) (string, error) {
-	repoName, err := reposourceCloneURLToRepoName(ctx, cloneURL)
-	if err != nil {
-		return "", err
-	}
-	if repoName == "" {
-		return "", fmt.Errorf("No matching code host found for %s", cloneURL)
-	}
-	return string(repoName), nil
-}
-
 // reposourceCloneURLToRepoName maps a Git clone URL (format documented here:
 // https://git-scm.com/docs/git-clone#_git_urls_a_id_urls_a) to the corresponding repo name if there
 // exists a code host configuration that matches the clone URL. Implicitly, it includes a code host
@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
@@ -64,3 +65,77 @@ func TestGitTreeEntry_Content(t *testing.T) {
 		t.Fatalf("wrong file size, want=%d have=%d", want, have)
 	}
 }
+
+func TestGitTreeEntry_Content_encoding(t *testing.T) {
+	// "héllo" in UTF-16LE with a byte order mark.
+	rawContent := []byte{0xFF, 0xFE, 'h', 0, 0xE9, 0, 'l', 0, 'l', 0, 'o', 0}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		return rawContent, nil
+	}
+	t.Cleanup(func() { git.Mocks.ReadFile = nil })
+
+	gitTree := &GitTreeEntryResolver{
+		commit: &GitCommitResolver{
+			repo: &RepositoryResolver{
+				repo: &types.Repo{Name: "my/repo"},
+			},
+		},
+		stat: CreateFileInfo("a.txt", false),
+	}
+
+	ctx := context.Background()
+	content, err := gitTree.Content(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != "héllo" {
+		t.Errorf("got content %q, want %q", content, "héllo")
+	}
+	encoding, err := gitTree.Encoding(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoding == nil || *encoding != "UTF-16LE" {
+		t.Errorf("got encoding %v, want UTF-16LE", encoding)
+	}
+	if binary, err := gitTree.Binary(ctx); err != nil || binary {
+		t.Errorf("got binary %v (error %v), want false", binary, err)
+	}
+	if byteSize, err := gitTree.ByteSize(ctx); err != nil || byteSize != int32(len(rawContent)) {
+		t.Errorf("got byte size %d (error %v), want %d", byteSize, err, len(rawContent))
+	}
+}
+
+func TestGitTreeEntry_LFS(t *testing.T) {
+	git.Mocks.ReadLFSPointer = func(commit api.CommitID, name string) (*lfs.Pointer, error) {
+		if name != "big.bin" {
+			return nil, nil
+		}
+		return &lfs.Pointer{OID: "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", Size: 12345}, nil
+	}
+	t.Cleanup(func() { git.Mocks.ReadLFSPointer = nil })
+
+	for path, want := range map[string]*float64{
+		"big.bin":  func() *float64 { f := float64(12345); return &f }(),
+		"small.md": nil,
+	} {
+		entry := &GitTreeEntryResolver{
+			commit: &GitCommitResolver{
+				repo: &RepositoryResolver{
+					repo: &types.Repo{Name: "my/repo"},
+				},
+			},
+			stat: CreateFileInfo(path, false),
+		}
+		got, err := entry.LFS(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (got == nil) != (want == nil) {
+			t.Fatalf("%s: got LFS %v, want %v", path, got, want)
+		}
+		if got != nil && got.ByteSize() != *want {
+			t.Errorf("%s: got byte size %v, want %v", path, got.ByteSize(), *want)
+		}
+	}
+}
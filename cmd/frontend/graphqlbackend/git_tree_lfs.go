@@ -0,0 +1,36 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+type lfsResolver struct {
+	pointer *lfs.Pointer
+}
+
+func (r *lfsResolver) ByteSize() float64 {
+	return float64(r.pointer.Size)
+}
+
+func (r *GitTreeEntryResolver) LFS(ctx context.Context) (*lfsResolver, error) {
+	// Files larger than a pointer can't be stored in Git LFS, so we don't
+	// need to read them.
+	if !r.stat.Mode().IsRegular() || r.stat.Size() > lfs.MaxPointerSize {
+		return nil, nil
+	}
+
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	pointer, err := git.ReadLFSPointer(ctx, *cachedRepo, api.CommitID(r.commit.OID()), r.Path())
+	if err != nil || pointer == nil {
+		return nil, err
+	}
+	return &lfsResolver{pointer: pointer}, nil
+}
@@ -1,9 +1,30 @@
 package graphqlbackend
 
-import "github.com/sourcegraph/sourcegraph/internal/vcs/git"
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
 
 type gitSubmoduleResolver struct {
 	submodule git.Submodule
+
+	// superproject is the repository containing the submodule.
+	superproject *types.Repo
+
+	repoOnce sync.Once
+	repo     *types.Repo
+	repoErr  error
 }
 
 func (r *gitSubmoduleResolver) URL() string {
@@ -17,3 +38,117 @@ func (r *gitSubmoduleResolver) Commit() string {
 func (r *gitSubmoduleResolver) Path() string {
 	return r.submodule.Path
 }
+
+func (r *gitSubmoduleResolver) Repository(ctx context.Context) (*RepositoryResolver, error) {
+	repo, err := r.resolveRepo(ctx)
+	if repo == nil || err != nil {
+		return nil, err
+	}
+	return &RepositoryResolver{repo: repo}, nil
+}
+
+func (r *gitSubmoduleResolver) Tree(ctx context.Context) (*GitTreeEntryResolver, error) {
+	repo, err := r.Repository(ctx)
+	if repo == nil || err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitFromID(ctx, &RepositoryCommitArgs{Rev: string(r.submodule.CommitID)}, r.submodule.CommitID)
+	if commit == nil || err != nil {
+		return nil, err
+	}
+	return commit.Tree(ctx, &struct {
+		Path      string
+		Recursive bool
+	}{})
+}
+
+func (r *gitSubmoduleResolver) resolveRepo(ctx context.Context) (*types.Repo, error) {
+	r.repoOnce.Do(func() {
+		var superproject api.RepoName
+		if r.superproject != nil {
+			superproject = r.superproject.Name
+		}
+		r.repo, r.repoErr = resolveSubmoduleRepo(ctx, superproject, r.submodule.URL)
+	})
+	return r.repo, r.repoErr
+}
+
+// submoduleRepoName returns the name of the repository that the submodule
+// URL of a submodule of the superproject refers to, or "" if no code host
+// matches the URL.
+//
+// Relative URLs (such as "../lib.git") are relative to the superproject's
+// clone URL. Since repository names usually follow the path of the clone URL
+// on the code host, they are resolved relative to the superproject's name.
+func submoduleRepoName(ctx context.Context, superproject api.RepoName, url string) (api.RepoName, error) {
+	if git.IsRelativeSubmoduleURL(url) {
+		if superproject == "" {
+			return "", nil
+		}
+		name := path.Join(string(superproject), url)
+		// The superproject's URL is the base, so ".." names a sibling
+		// of the superproject. The root of the name is the code host,
+		// which we must not leave.
+		if !strings.Contains(name, "/") || strings.HasPrefix(name, "..") {
+			return "", nil
+		}
+		return api.RepoName(strings.TrimSuffix(name, ".git")), nil
+	}
+	return reposourceCloneURLToRepoName(ctx, url)
+}
+
+// resolveSubmoduleRepo returns the repository on Sourcegraph that the URL of
+// a submodule of the superproject refers to, or nil if the repository does
+// not exist or the current user can't access it.
+func resolveSubmoduleRepo(ctx context.Context, superproject api.RepoName, url string) (*types.Repo, error) {
+	name, err := submoduleRepoName(ctx, superproject, url)
+	if err != nil || name == "" {
+		return nil, err
+	}
+
+	repo, err := backend.Repos.GetByName(ctx, name)
+	if err != nil {
+		if _, ok := err.(backend.ErrRepoSeeOther); ok || errcode.IsNotFound(err) {
+			return nil, nil
+		}
+		log15.Warn("Failed to look up submodule repository", "repo", name, "error", err)
+		return nil, err
+	}
+	return repo, nil
+}
+
+// submoduleCommit returns the pinned commit of the submodule containing the
+// given path, which does not exist in the commit r itself, and the path
+// relative to the submodule. It returns nil if the path is not inside a
+// submodule whose repository is known to Sourcegraph.
+func (r *GitCommitResolver) submoduleCommit(ctx context.Context, cachedRepo gitserver.Repo, filePath string) (*GitCommitResolver, string, error) {
+	parts := strings.Split(strings.Trim(path.Clean(filePath), "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		stat, err := git.Stat(ctx, cachedRepo, api.CommitID(r.oid), strings.Join(parts[:i], "/"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, "", nil
+			}
+			return nil, "", err
+		}
+
+		submodule, ok := stat.Sys().(git.Submodule)
+		if !ok {
+			if !stat.Mode().IsDir() {
+				return nil, "", nil
+			}
+			continue
+		}
+
+		repo, err := resolveSubmoduleRepo(ctx, r.repo.repo.Name, submodule.URL)
+		if repo == nil || err != nil {
+			return nil, "", err
+		}
+		commit, err := (&RepositoryResolver{repo: repo}).CommitFromID(ctx, &RepositoryCommitArgs{Rev: string(submodule.CommitID)}, submodule.CommitID)
+		if commit == nil || err != nil {
+			return nil, "", err
+		}
+		return commit, strings.Join(parts[i:], "/"), nil
+	}
+	return nil, "", nil
+}
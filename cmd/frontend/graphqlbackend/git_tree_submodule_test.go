@@ -0,0 +1,93 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestSubmoduleRepoName(t *testing.T) {
+	db.Mocks.ExternalServices.List = func(opt db.ExternalServicesListOptions) ([]*types.ExternalService, error) {
+		return nil, nil
+	}
+	defer func() { db.Mocks.ExternalServices = db.MockExternalServices{} }()
+
+	tests := []struct {
+		superproject api.RepoName
+		url          string
+		want         api.RepoName
+	}{
+		{"github.com/foo/firmware", "../lib.git", "github.com/foo/lib"},
+		{"github.com/foo/firmware", "../../bar/lib", "github.com/bar/lib"},
+		{"github.com/foo/firmware", "./vendor/lib", "github.com/foo/firmware/vendor/lib"},
+		{"github.com/foo/firmware", "../../../lib", ""},
+		{"github.com/foo/firmware", "https://github.com/bar/baz.git", "github.com/bar/baz"},
+		{"github.com/foo/firmware", "git@github.com:bar/baz.git", "github.com/bar/baz"},
+		{"github.com/foo/firmware", "https://unknown.example.com/baz.git", ""},
+	}
+	for _, test := range tests {
+		got, err := submoduleRepoName(context.Background(), test.superproject, test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s in %s: got %q, want %q", test.url, test.superproject, got, test.want)
+		}
+	}
+}
+
+func TestWithSubmodules(t *testing.T) {
+	repos := map[api.RepoName]*types.Repo{
+		"github.com/foo/firmware": {ID: 1, Name: "github.com/foo/firmware"},
+		"github.com/foo/hal":      {ID: 2, Name: "github.com/foo/hal"},
+		"github.com/foo/rtos":     {ID: 3, Name: "github.com/foo/rtos"},
+	}
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		if repo, ok := repos[name]; ok {
+			return repo, nil
+		}
+		return nil, &errcode.Mock{IsNotFound: true}
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return api.CommitID("c-" + spec), nil
+	}
+	git.Mocks.Submodules = func(commit api.CommitID) ([]git.Submodule, error) {
+		switch commit {
+		case "c-":
+			return []git.Submodule{
+				{Path: "hal", URL: "../hal", CommitID: "aaa"},
+				{Path: "rtos", URL: "../rtos.git", CommitID: "bbb"},
+				{Path: "unknown", URL: "../unknown", CommitID: "ccc"},
+			}, nil
+		case "c-v1":
+			return []git.Submodule{{Path: "hal", URL: "../hal", CommitID: "ddd"}}, nil
+		}
+		return nil, nil
+	}
+	defer func() {
+		backend.Mocks = backend.MockServices{}
+		git.ResetMocks()
+	}()
+
+	got := withSubmodules(context.Background(), []*search.RepositoryRevisions{
+		{Repo: repos["github.com/foo/firmware"], Revs: []search.RevisionSpecifier{{RevSpec: ""}, {RevSpec: "v1"}, {RefGlob: "refs/heads/*"}}},
+		{Repo: repos["github.com/foo/rtos"], Revs: []search.RevisionSpecifier{{RevSpec: ""}}},
+	})
+
+	want := []*search.RepositoryRevisions{
+		{Repo: repos["github.com/foo/firmware"], Revs: []search.RevisionSpecifier{{RevSpec: ""}, {RevSpec: "v1"}, {RefGlob: "refs/heads/*"}}},
+		{Repo: repos["github.com/foo/rtos"], Revs: []search.RevisionSpecifier{{RevSpec: ""}, {RevSpec: "bbb"}}},
+		{Repo: repos["github.com/foo/hal"], Revs: []search.RevisionSpecifier{{RevSpec: "aaa"}, {RevSpec: "ddd"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
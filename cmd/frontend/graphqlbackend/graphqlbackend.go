@@ -469,6 +469,11 @@ func (r *NodeResolver) ToOrganizationInvitation() (*organizationInvitationResolv
 	return n, ok
 }
 
+func (r *NodeResolver) ToOrgRepositorySet() (*orgRepositorySetResolver, bool) {
+	n, ok := r.Node.(*orgRepositorySetResolver)
+	return n, ok
+}
+
 func (r *NodeResolver) ToGitCommit() (*GitCommitResolver, bool) {
 	n, ok := r.Node.(*GitCommitResolver)
 	return n, ok
@@ -501,6 +506,11 @@ func (r *NodeResolver) ToVersionContext() (*versionContextResolver, bool) {
 	return n, ok
 }
 
+func (r *NodeResolver) ToInsightSeries() (*insightSeriesResolver, bool) {
+	n, ok := r.Node.(*insightSeriesResolver)
+	return n, ok
+}
+
 // schemaResolver handles all GraphQL queries for Sourcegraph. To do this, it
 // uses subresolvers which are globals. Enterprise-only resolvers are assigned
 // to a field of EnterpriseResolvers.
@@ -576,6 +586,12 @@ func (r *schemaResolver) nodeByID(ctx context.Context, id graphql.ID) (Node, err
 		return OrgByID(ctx, id)
 	case "OrganizationInvitation":
 		return orgInvitationByID(ctx, id)
+	case "OrgRepositorySet":
+		return orgRepositorySetByID(ctx, id)
+	case "VersionContext":
+		return versionContextByID(ctx, id)
+	case "InsightSeries":
+		return insightSeriesByID(ctx, id)
 	case "GitCommit":
 		return gitCommitByID(ctx, id)
 	case "RegistryExtension":
@@ -0,0 +1,338 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+type insightSeriesResolver struct {
+	series *types.InsightSeries
+}
+
+func marshalInsightSeriesID(id int32) graphql.ID { return relay.MarshalID("InsightSeries", id) }
+
+func unmarshalInsightSeriesID(id graphql.ID) (seriesID int32, err error) {
+	err = relay.UnmarshalSpec(id, &seriesID)
+	return
+}
+
+func insightSeriesByID(ctx context.Context, id graphql.ID) (*insightSeriesResolver, error) {
+	seriesID, err := unmarshalInsightSeriesID(id)
+	if err != nil {
+		return nil, err
+	}
+	series, err := db.InsightSeries.GetByID(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only users who can access the saved search may view the series.
+	if _, err := savedSearchByID(ctx, marshalSavedSearchID(series.SavedSearchID)); err != nil {
+		return nil, err
+	}
+	return &insightSeriesResolver{series: series}, nil
+}
+
+func (r *insightSeriesResolver) ID() graphql.ID { return marshalInsightSeriesID(r.series.ID) }
+
+func (r *insightSeriesResolver) SavedSearch(ctx context.Context) (*savedSearchResolver, error) {
+	return savedSearchByID(ctx, marshalSavedSearchID(r.series.SavedSearchID))
+}
+
+// repositories returns the repositories of the series the user can access, in
+// the order of the series.
+//
+// 🚨 SECURITY: The points of a series are computed with the permissions of its
+// creator. Other users who can access the saved search (e.g. the members of
+// its organization) must only see the points of the repositories they can
+// access themselves.
+func (r *insightSeriesResolver) repositories(ctx context.Context) ([]*types.Repo, error) {
+	repos, err := db.Repos.GetByIDs(ctx, r.series.RepoIDs...)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[api.RepoID]*types.Repo, len(repos))
+	for _, repo := range repos {
+		byID[repo.ID] = repo
+	}
+	visible := make([]*types.Repo, 0, len(repos))
+	for _, id := range r.series.RepoIDs {
+		if repo, ok := byID[id]; ok {
+			visible = append(visible, repo)
+		}
+	}
+	return visible, nil
+}
+
+// pointsListOptions returns the options to list the points of the series in
+// the given states, in the repositories the user can access.
+func (r *insightSeriesResolver) pointsListOptions(ctx context.Context, states ...string) (db.InsightSeriesPointsListOptions, error) {
+	repos, err := r.repositories(ctx)
+	if err != nil {
+		return db.InsightSeriesPointsListOptions{}, err
+	}
+	opt := db.InsightSeriesPointsListOptions{
+		SeriesID: r.series.ID,
+		States:   states,
+		RepoIDs:  make([]api.RepoID, len(repos)),
+	}
+	for i, repo := range repos {
+		opt.RepoIDs[i] = repo.ID
+	}
+	return opt, nil
+}
+
+func (r *insightSeriesResolver) Repositories(ctx context.Context) ([]*RepositoryResolver, error) {
+	repos, err := r.repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*RepositoryResolver, len(repos))
+	for i, repo := range repos {
+		resolvers[i] = NewRepositoryResolver(repo)
+	}
+	return resolvers, nil
+}
+
+func (r *insightSeriesResolver) IntervalDays() int32 { return r.series.IntervalDays }
+
+func (r *insightSeriesResolver) StartTime() DateTime { return DateTime{Time: r.series.StartTime} }
+
+func (r *insightSeriesResolver) CreatedAt() DateTime { return DateTime{Time: r.series.CreatedAt} }
+
+func (r *insightSeriesResolver) Points(ctx context.Context, args *struct {
+	Repository *graphql.ID
+	graphqlutil.ConnectionArgs
+}) (*insightSeriesPointConnectionResolver, error) {
+	opt, err := r.pointsListOptions(ctx, "completed")
+	if err != nil {
+		return nil, err
+	}
+	if args.Repository != nil {
+		repoID, err := UnmarshalRepositoryID(*args.Repository)
+		if err != nil {
+			return nil, err
+		}
+		opt.RepoID = repoID
+	}
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &insightSeriesPointConnectionResolver{opt: opt}, nil
+}
+
+func (r *insightSeriesResolver) PendingPoints(ctx context.Context) (int32, error) {
+	opt, err := r.pointsListOptions(ctx, "queued", "processing")
+	if err != nil {
+		return 0, err
+	}
+	count, err := db.InsightSeries.CountPoints(ctx, opt)
+	return int32(count), err
+}
+
+func (r *insightSeriesResolver) FailedPoints(ctx context.Context) (int32, error) {
+	opt, err := r.pointsListOptions(ctx, "errored")
+	if err != nil {
+		return 0, err
+	}
+	count, err := db.InsightSeries.CountPoints(ctx, opt)
+	return int32(count), err
+}
+
+type insightSeriesPointConnectionResolver struct {
+	opt db.InsightSeriesPointsListOptions
+}
+
+func (r *insightSeriesPointConnectionResolver) Nodes(ctx context.Context) ([]*insightSeriesPointResolver, error) {
+	points, err := db.InsightSeries.ListPoints(ctx, r.opt)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*insightSeriesPointResolver, len(points))
+	for i, p := range points {
+		resolvers[i] = &insightSeriesPointResolver{point: p}
+	}
+	return resolvers, nil
+}
+
+func (r *insightSeriesPointConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.InsightSeries.CountPoints(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *insightSeriesPointConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	count, err := db.InsightSeries.CountPoints(ctx, r.opt)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && count > r.opt.Limit), nil
+}
+
+type insightSeriesPointResolver struct {
+	point *types.InsightSeriesPoint
+}
+
+func (r *insightSeriesPointResolver) Repository(ctx context.Context) (*RepositoryResolver, error) {
+	return RepositoryByIDInt32(ctx, r.point.RepoID)
+}
+
+func (r *insightSeriesPointResolver) Time() DateTime { return DateTime{Time: r.point.Time} }
+
+func (r *insightSeriesPointResolver) Commit(ctx context.Context) (*GitCommitResolver, error) {
+	if r.point.Commit == "" {
+		return nil, nil
+	}
+	repo, err := r.Repository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GitCommitResolver{repo: repo, includeUserInfo: true, oid: GitObjectID(r.point.Commit)}, nil
+}
+
+func (r *insightSeriesPointResolver) MatchCount() int32 { return r.point.MatchCount }
+
+func (r *insightSeriesPointResolver) LimitHit() bool { return r.point.LimitHit }
+
+func (r *schemaResolver) InsightSeries(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+}) (*insightSeriesConnectionResolver, error) {
+	currentUser, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser == nil {
+		return nil, errors.New("no current user")
+	}
+
+	// 🚨 SECURITY: Only list the series of the saved searches the user can access.
+	savedSearches, err := db.SavedSearches.ListSavedSearchesByUserID(ctx, currentUser.DatabaseID())
+	if err != nil {
+		return nil, err
+	}
+	opt := db.InsightSeriesListOptions{SavedSearchIDs: make([]int32, 0, len(savedSearches))}
+	for _, ss := range savedSearches {
+		opt.SavedSearchIDs = append(opt.SavedSearchIDs, ss.ID)
+	}
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &insightSeriesConnectionResolver{opt: opt}, nil
+}
+
+type insightSeriesConnectionResolver struct {
+	opt db.InsightSeriesListOptions
+}
+
+func (r *insightSeriesConnectionResolver) Nodes(ctx context.Context) ([]*insightSeriesResolver, error) {
+	series, err := db.InsightSeries.List(ctx, r.opt)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*insightSeriesResolver, len(series))
+	for i, s := range series {
+		resolvers[i] = &insightSeriesResolver{series: s}
+	}
+	return resolvers, nil
+}
+
+func (r *insightSeriesConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.InsightSeries.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *insightSeriesConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	count, err := db.InsightSeries.Count(ctx, r.opt)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && count > r.opt.Limit), nil
+}
+
+func (*schemaResolver) CreateInsightSeries(ctx context.Context, args *struct {
+	SavedSearch  graphql.ID
+	Repositories []graphql.ID
+	IntervalDays int32
+	StartTime    DateTime
+}) (*insightSeriesResolver, error) {
+	currentUser, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentUser == nil {
+		return nil, errors.New("no current user")
+	}
+
+	// 🚨 SECURITY: Only users who can access the saved search may create a
+	// series for it.
+	savedSearch, err := savedSearchByID(ctx, args.SavedSearch)
+	if err != nil {
+		return nil, err
+	}
+	if args.IntervalDays < 1 {
+		return nil, errors.New("intervalDays must be at least 1")
+	}
+	if len(args.Repositories) == 0 {
+		return nil, errors.New("at least one repository is required")
+	}
+
+	series := &types.InsightSeries{
+		SavedSearchID: savedSearch.s.ID,
+		IntervalDays:  args.IntervalDays,
+		StartTime:     args.StartTime.Time,
+		CreatorUserID: currentUser.DatabaseID(),
+	}
+	seen := make(map[api.RepoID]bool, len(args.Repositories))
+	for _, id := range args.Repositories {
+		// 🚨 SECURITY: RepositoryByID checks that the user can access the
+		// repository.
+		repo, err := RepositoryByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if seen[repo.repo.ID] {
+			continue
+		}
+		seen[repo.repo.ID] = true
+		series.RepoIDs = append(series.RepoIDs, repo.repo.ID)
+	}
+
+	created, err := db.InsightSeries.Create(ctx, series)
+	if err != nil {
+		return nil, err
+	}
+	return &insightSeriesResolver{series: created}, nil
+}
+
+func (*schemaResolver) DeleteInsightSeries(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: insightSeriesByID checks that the user can access the
+	// saved search of the series.
+	series, err := insightSeriesByID(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.InsightSeries.Delete(ctx, series.series.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+// CountSearchMatches runs a search query as the given user and returns the
+// number of matches, and whether the match limit was hit. It computes the
+// points of insight series as their creators.
+func CountSearchMatches(ctx context.Context, userID int32, query string) (matchCount int32, limitHit bool, err error) {
+	ctx = actor.WithActor(ctx, actor.FromUser(userID))
+	search, err := NewSearchImplementer(&SearchArgs{Version: "V2", Query: query})
+	if err != nil {
+		return 0, false, err
+	}
+	results, err := search.Results(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	return results.MatchCount(), results.LimitHit(), nil
+}
@@ -0,0 +1,139 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestCreateInsightSeries(t *testing.T) {
+	const userID, otherUserID = 1, 2
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: userID}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+	db.Mocks.SavedSearches.GetByID = func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error) {
+		owner := int32(userID)
+		if id != 1 {
+			owner = otherUserID
+		}
+		return &api.SavedQuerySpecAndConfig{Config: api.ConfigSavedQuery{Query: "foo", UserID: &owner}}, nil
+	}
+	db.Mocks.Repos.Get = func(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: id, Name: "r"}, nil
+	}
+	var created *types.InsightSeries
+	db.Mocks.InsightSeries.Create = func(ctx context.Context, series *types.InsightSeries) (*types.InsightSeries, error) {
+		created = series
+		return series, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(userID))
+	startTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	create := func(savedSearchID int32, intervalDays int32) error {
+		created = nil
+		_, err := (&schemaResolver{}).CreateInsightSeries(ctx, &struct {
+			SavedSearch  graphql.ID
+			Repositories []graphql.ID
+			IntervalDays int32
+			StartTime    DateTime
+		}{
+			SavedSearch:  marshalSavedSearchID(savedSearchID),
+			Repositories: []graphql.ID{MarshalRepositoryID(1), MarshalRepositoryID(2), MarshalRepositoryID(1)},
+			IntervalDays: intervalDays,
+			StartTime:    DateTime{Time: startTime},
+		})
+		return err
+	}
+
+	t.Run("own saved search", func(t *testing.T) {
+		if err := create(1, 7); err != nil {
+			t.Fatal(err)
+		}
+		want := &types.InsightSeries{SavedSearchID: 1, RepoIDs: []api.RepoID{1, 2}, IntervalDays: 7, StartTime: startTime, CreatorUserID: userID}
+		if !reflect.DeepEqual(created, want) {
+			t.Errorf("got created series %+v, want %+v", created, want)
+		}
+	})
+
+	t.Run("invalid interval", func(t *testing.T) {
+		if err := create(1, 0); err == nil {
+			t.Error("got nil error for invalid interval")
+		}
+	})
+
+	t.Run("other user's saved search", func(t *testing.T) {
+		if err := create(2, 7); err == nil {
+			t.Error("got nil error for saved search of another user")
+		}
+		if created != nil {
+			t.Error("series of another user's saved search was created")
+		}
+	})
+}
+
+func TestInsightSeries_viewerRepositories(t *testing.T) {
+	// The viewer can only access repository 2 of the series.
+	db.Mocks.Repos.GetByIDs = func(ctx context.Context, ids ...api.RepoID) ([]*types.Repo, error) {
+		var repos []*types.Repo
+		for _, id := range ids {
+			if id == 2 {
+				repos = append(repos, &types.Repo{ID: id, Name: "b"})
+			}
+		}
+		return repos, nil
+	}
+	var counted []db.InsightSeriesPointsListOptions
+	db.Mocks.InsightSeries.CountPoints = func(ctx context.Context, opt db.InsightSeriesPointsListOptions) (int, error) {
+		counted = append(counted, opt)
+		return 1, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	ctx := context.Background()
+	r := &insightSeriesResolver{series: &types.InsightSeries{ID: 1, RepoIDs: []api.RepoID{1, 2, 3}, CreatorUserID: 1}}
+
+	repos, err := r.Repositories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].repo.ID != 2 {
+		t.Errorf("got %d repositories, want only repository 2", len(repos))
+	}
+
+	points, err := r.Points(ctx, &struct {
+		Repository *graphql.ID
+		graphqlutil.ConnectionArgs
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := points.TotalCount(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.PendingPoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.FailedPoints(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range counted {
+		if !reflect.DeepEqual(opt.RepoIDs, []api.RepoID{2}) {
+			t.Errorf("got points counted in repositories %v, want [2]", opt.RepoIDs)
+		}
+	}
+	if len(counted) != 3 {
+		t.Errorf("got %d counts, want 3", len(counted))
+	}
+}
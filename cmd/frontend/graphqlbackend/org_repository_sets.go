@@ -0,0 +1,257 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+)
+
+type orgRepositorySetResolver struct {
+	set *types.OrgRepositorySet
+}
+
+func marshalOrgRepositorySetID(id int32) graphql.ID { return relay.MarshalID("OrgRepositorySet", id) }
+
+func unmarshalOrgRepositorySetID(id graphql.ID) (setID int32, err error) {
+	err = relay.UnmarshalSpec(id, &setID)
+	return
+}
+
+func orgRepositorySetByID(ctx context.Context, id graphql.ID) (*orgRepositorySetResolver, error) {
+	setID, err := unmarshalOrgRepositorySetID(id)
+	if err != nil {
+		return nil, err
+	}
+	set, err := db.OrgRepositorySets.GetByID(ctx, setID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only org members and site admins may view the org's repository sets.
+	if err := backend.CheckOrgAccess(ctx, set.OrgID); err != nil {
+		return nil, err
+	}
+	return &orgRepositorySetResolver{set: set}, nil
+}
+
+func (r *orgRepositorySetResolver) ID() graphql.ID { return marshalOrgRepositorySetID(r.set.ID) }
+
+func (r *orgRepositorySetResolver) Name() string { return r.set.Name }
+
+func (r *orgRepositorySetResolver) Description() string { return r.set.Description }
+
+func (r *orgRepositorySetResolver) Organization(ctx context.Context) (*OrgResolver, error) {
+	return OrgByIDInt32(ctx, r.set.OrgID)
+}
+
+func (r *orgRepositorySetResolver) Repositories() []string { return r.set.RepositoryNames }
+
+func (r *orgRepositorySetResolver) NamePatterns() []string { return r.set.NamePatterns }
+
+func (r *orgRepositorySetResolver) IsDefault() bool { return r.set.IsDefault }
+
+func (r *orgRepositorySetResolver) CreatedAt() DateTime { return DateTime{Time: r.set.CreatedAt} }
+
+func (r *orgRepositorySetResolver) UpdatedAt() DateTime { return DateTime{Time: r.set.UpdatedAt} }
+
+func (o *OrgResolver) RepositorySets(ctx context.Context) ([]*orgRepositorySetResolver, error) {
+	// 🚨 SECURITY: Only org members and site admins may list the org's repository sets.
+	if err := backend.CheckOrgAccess(ctx, o.org.ID); err != nil {
+		return nil, err
+	}
+
+	sets, err := db.OrgRepositorySets.ListByOrgID(ctx, o.org.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*orgRepositorySetResolver, len(sets))
+	for i, set := range sets {
+		resolvers[i] = &orgRepositorySetResolver{set: set}
+	}
+	return resolvers, nil
+}
+
+func (*schemaResolver) CreateOrgRepositorySet(ctx context.Context, args *struct {
+	Organization graphql.ID
+	Name         string
+	Description  *string
+	Repositories []string
+	NamePatterns *[]string
+	IsDefault    *bool
+}) (*orgRepositorySetResolver, error) {
+	orgID, err := UnmarshalOrgID(args.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Check that the current user is a member of the org (or a site admin).
+	if err := backend.CheckOrgAccess(ctx, orgID); err != nil {
+		return nil, err
+	}
+
+	set := &types.OrgRepositorySet{
+		OrgID:           orgID,
+		Name:            args.Name,
+		RepositoryNames: args.Repositories,
+	}
+	if args.NamePatterns != nil {
+		if err := validateOrgRepositorySetNamePatterns(*args.NamePatterns); err != nil {
+			return nil, err
+		}
+		set.NamePatterns = *args.NamePatterns
+	}
+	if args.Description != nil {
+		set.Description = *args.Description
+	}
+	if args.IsDefault != nil {
+		set.IsDefault = *args.IsDefault
+	}
+	created, err := db.OrgRepositorySets.Create(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+	return &orgRepositorySetResolver{set: created}, nil
+}
+
+func (*schemaResolver) UpdateOrgRepositorySet(ctx context.Context, args *struct {
+	ID           graphql.ID
+	Name         *string
+	Description  *string
+	Repositories *[]string
+	NamePatterns *[]string
+	IsDefault    *bool
+}) (*orgRepositorySetResolver, error) {
+	setID, err := unmarshalOrgRepositorySetID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	set, err := db.OrgRepositorySets.GetByID(ctx, setID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Check that the current user is a member of the org that owns the set (or a
+	// site admin).
+	if err := backend.CheckOrgAccess(ctx, set.OrgID); err != nil {
+		return nil, err
+	}
+
+	if args.Name != nil {
+		set.Name = *args.Name
+	}
+	if args.Description != nil {
+		set.Description = *args.Description
+	}
+	if args.Repositories != nil {
+		set.RepositoryNames = *args.Repositories
+	}
+	if args.NamePatterns != nil {
+		if err := validateOrgRepositorySetNamePatterns(*args.NamePatterns); err != nil {
+			return nil, err
+		}
+		set.NamePatterns = *args.NamePatterns
+	}
+	if args.IsDefault != nil {
+		set.IsDefault = *args.IsDefault
+	}
+	updated, err := db.OrgRepositorySets.Update(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+	return &orgRepositorySetResolver{set: updated}, nil
+}
+
+func (*schemaResolver) DeleteOrgRepositorySet(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	setID, err := unmarshalOrgRepositorySetID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	set, err := db.OrgRepositorySets.GetByID(ctx, setID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Check that the current user is a member of the org that owns the set (or a
+	// site admin).
+	if err := backend.CheckOrgAccess(ctx, set.OrgID); err != nil {
+		return nil, err
+	}
+
+	if err := db.OrgRepositorySets.Delete(ctx, set.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func validateOrgRepositorySetNamePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("invalid repository name pattern %q: %s", p, err)
+		}
+	}
+	return nil
+}
+
+// resolveOrgRepositorySets looks up the repository sets referred to by the
+// values of reposet: filters, which have the form ORG/NAME.
+func resolveOrgRepositorySets(ctx context.Context, values []string) ([]*types.OrgRepositorySet, error) {
+	sets := make([]*types.OrgRepositorySet, 0, len(values))
+	for _, v := range values {
+		i := strings.Index(v, "/")
+		if i <= 0 || i == len(v)-1 {
+			return nil, &badRequestError{fmt.Errorf("invalid reposet: value %q (expected ORG/NAME)", v)}
+		}
+		org, err := db.Orgs.GetByName(ctx, v[:i])
+		if err != nil {
+			return nil, err
+		}
+		// 🚨 SECURITY: Only org members and site admins may search the org's repository
+		// sets, because the set contents may reveal information about private repositories.
+		if err := backend.CheckOrgAccess(ctx, org.ID); err != nil {
+			return nil, err
+		}
+		set, err := db.OrgRepositorySets.GetByName(ctx, org.ID, v[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// defaultOrgRepositorySets returns the default repository sets of the
+// organizations the current user is a member of. Anonymous users have none.
+func defaultOrgRepositorySets(ctx context.Context) ([]*types.OrgRepositorySet, error) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return nil, nil
+	}
+	return db.OrgRepositorySets.ListDefaultsByUserID(ctx, a.UID)
+}
+
+// orgRepositorySetPatterns returns repository name patterns matching the
+// repositories in any of the sets.
+func orgRepositorySetPatterns(sets []*types.OrgRepositorySet) []string {
+	var patterns []string
+	for _, set := range sets {
+		for _, name := range set.RepositoryNames {
+			patterns = append(patterns, "^"+regexp.QuoteMeta(name)+"$")
+		}
+		patterns = append(patterns, set.NamePatterns...)
+	}
+	if len(patterns) == 0 {
+		// The sets are empty, so match no repositories (an empty pattern would
+		// match all of them).
+		patterns = []string{"^$"}
+	}
+	return patterns
+}
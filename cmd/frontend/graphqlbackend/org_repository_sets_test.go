@@ -0,0 +1,128 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestOrgRepositorySetPatterns(t *testing.T) {
+	tests := []struct {
+		sets []*types.OrgRepositorySet
+		want []string
+	}{
+		{
+			sets: []*types.OrgRepositorySet{{}},
+			want: []string{"^$"},
+		},
+		{
+			sets: []*types.OrgRepositorySet{
+				{RepositoryNames: []string{"github.com/acme/api.v2"}, NamePatterns: []string{"^github\\.com/acme/svc-"}},
+				{RepositoryNames: []string{"github.com/acme/web"}},
+			},
+			want: []string{`^github\.com/acme/api\.v2$`, "^github\\.com/acme/svc-", `^github\.com/acme/web$`},
+		},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(test.want, orgRepositorySetPatterns(test.sets)); diff != "" {
+			t.Errorf("patterns mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestSearchResolver_OrgRepositorySets(t *testing.T) {
+	mockDecodedViewerFinalSettings = &schema.Settings{}
+	defer func() { mockDecodedViewerFinalSettings = nil }()
+
+	const userID, orgID = 1, 2
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: userID}, nil
+	}
+	db.Mocks.Orgs.GetByName = func(ctx context.Context, name string) (*types.Org, error) {
+		return &types.Org{ID: orgID, Name: name}, nil
+	}
+	isMember := true
+	db.Mocks.OrgMembers.GetByOrgIDAndUserID = func(ctx context.Context, orgID, userID int32) (*types.OrgMembership, error) {
+		if !isMember {
+			return nil, &errcode.Mock{Message: "not a member", IsNotFound: true}
+		}
+		return &types.OrgMembership{OrgID: orgID, UserID: userID}, nil
+	}
+	db.Mocks.OrgRepositorySets.GetByName = func(ctx context.Context, orgID int32, name string) (*types.OrgRepositorySet, error) {
+		return &types.OrgRepositorySet{OrgID: orgID, Name: name, RepositoryNames: []string{"github.com/acme/" + name}}, nil
+	}
+	db.Mocks.OrgRepositorySets.ListDefaultsByUserID = func(ctx context.Context, userID int32) ([]*types.OrgRepositorySet, error) {
+		return []*types.OrgRepositorySet{{OrgID: orgID, Name: "default", NamePatterns: []string{"^github\\.com/acme/"}}}, nil
+	}
+	var gotIncludePatterns []string
+	db.Mocks.Repos.List = func(ctx context.Context, opts db.ReposListOptions) ([]*types.Repo, error) {
+		gotIncludePatterns = opts.IncludePatterns
+		return nil, nil
+	}
+	db.Mocks.Repos.Count = func(context.Context, db.ReposListOptions) (int, error) { return 0, nil }
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(userID))
+	resolveIncludePatterns := func(t *testing.T, q string) ([]string, error) {
+		t.Helper()
+		qinfo, err := query.ParseAndCheck(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotIncludePatterns = nil
+		_, _, _, _, err = (&searchResolver{query: qinfo}).resolveRepositories(ctx, nil)
+		return gotIncludePatterns, err
+	}
+
+	t.Run("reposet filter", func(t *testing.T) {
+		got, err := resolveIncludePatterns(t, "reposet:acme/api foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{`^github\.com/acme/api$`}, got); diff != "" {
+			t.Errorf("include patterns mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("default scope", func(t *testing.T) {
+		got, err := resolveIncludePatterns(t, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"^github\\.com/acme/"}, got); diff != "" {
+			t.Errorf("include patterns mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("repo filter overrides default scope", func(t *testing.T) {
+		got, err := resolveIncludePatterns(t, "repo:^github\\.com/other/ foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"^github\\.com/other/"}, got); diff != "" {
+			t.Errorf("include patterns mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("invalid reposet value", func(t *testing.T) {
+		if _, err := resolveIncludePatterns(t, "reposet:acme foo"); err == nil {
+			t.Error("got nil error for reposet value without a set name")
+		}
+	})
+
+	t.Run("non-member", func(t *testing.T) {
+		isMember = false
+		defer func() { isMember = true }()
+		if _, err := resolveIncludePatterns(t, "reposet:acme/api foo"); err != backend.ErrNotAnOrgMember {
+			t.Errorf("got error %v, want %v", err, backend.ErrNotAnOrgMember)
+		}
+	})
+}
@@ -123,13 +123,37 @@ func (r *RepositoryResolver) GitRefs(ctx context.Context, args *refsArgs) (*gitR
 		}
 	}
 
-	// Combine branches and tags.
-	refs := make([]*GitRefResolver, len(branches)+len(tags))
-	for i, b := range branches {
-		refs[i] = &GitRefResolver{name: "refs/heads/" + b.Name, repo: r, target: GitObjectID(b.Head)}
+	// Other refs include code review refs (such as refs/pull/*) and the refs
+	// fetched from additional refspecs and remotes of the code host
+	// connection. There can be tens of thousands of them (e.g. on GitHub
+	// mirrors), so they are only listed when they are requested explicitly.
+	var others []git.Ref
+	if args.Type != nil && *args.Type == gitRefTypeOther {
+		cachedRepo, err := backend.CachedGitRepo(ctx, r.repo)
+		if err != nil {
+			return nil, err
+		}
+		allRefs, err := git.ListRefs(ctx, *cachedRepo)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range allRefs {
+			if gitRefType(ref.Name) == gitRefTypeOther {
+				others = append(others, ref)
+			}
+		}
+	}
+
+	// Combine branches, tags and other refs.
+	refs := make([]*GitRefResolver, 0, len(branches)+len(tags)+len(others))
+	for _, b := range branches {
+		refs = append(refs, &GitRefResolver{name: "refs/heads/" + b.Name, repo: r, target: GitObjectID(b.Head)})
+	}
+	for _, t := range tags {
+		refs = append(refs, &GitRefResolver{name: "refs/tags/" + t.Name, repo: r, target: GitObjectID(t.CommitID)})
 	}
-	for i, t := range tags {
-		refs[i+len(branches)] = &GitRefResolver{name: "refs/tags/" + t.Name, repo: r, target: GitObjectID(t.CommitID)}
+	for _, o := range others {
+		refs = append(refs, &GitRefResolver{name: o.Name, repo: r, target: GitObjectID(o.CommitID)})
 	}
 
 	if args.Query != nil {
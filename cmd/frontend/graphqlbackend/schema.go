@@ -161,6 +161,11 @@ type Mutation {
     #
     # Only site admins may perform this mutation.
     randomizeUserPassword(user: ID!): RandomizeUserPasswordResult!
+    # Unlocks a user account that was temporarily locked because of too many failed sign-in
+    # attempts, and clears its failed sign-in attempt count.
+    #
+    # Only site admins may perform this mutation.
+    unlockUserAccount(user: ID!): EmptyResponse!
     # Adds an email address to the user's account. The email address will be marked as unverified until the user
     # has followed the email verification process.
     #
@@ -190,6 +195,10 @@ type Mutation {
     # - User, Organization, or Global settings authored by the user.
     #
     deleteUser(user: ID!, hard: Boolean): EmptyResponse
+    # Deletes the search history of a user.
+    #
+    # Only the user and site admins may perform this mutation.
+    clearSearchHistory(user: ID!): EmptyResponse!
     # Updates the current user's password. The oldPassword arg must match the user's current password.
     updatePassword(oldPassword: String!, newPassword: String!): EmptyResponse
     # Creates an access token that grants the privileges of the specified user (referred to as the access token's
@@ -254,6 +263,98 @@ type Mutation {
     #
     # Only site admins and any member of the organization may perform this mutation.
     removeUserFromOrganization(user: ID!, organization: ID!): EmptyResponse
+    # Creates a named set of repositories owned by an organization. The set can be searched with the
+    # reposet:ORG/NAME filter.
+    #
+    # Only site admins and any member of the organization may perform this mutation.
+    createOrgRepositorySet(
+        # The organization that owns the set.
+        organization: ID!
+        # The name of the set, unique within the organization.
+        name: String!
+        # A description of the set.
+        description: String
+        # The names of the repositories in the set.
+        repositories: [String!]!
+        # Regular expressions matching the names of additional repositories in the set.
+        namePatterns: [String!]
+        # Whether the set is the organization's default search scope. If true, the organization's previous
+        # default set (if any) is no longer the default.
+        isDefault: Boolean
+    ): OrgRepositorySet!
+    # Updates an organization repository set. Fields that are not given are left unchanged.
+    #
+    # Only site admins and any member of the organization may perform this mutation.
+    updateOrgRepositorySet(
+        # The repository set to update.
+        id: ID!
+        # The new name of the set.
+        name: String
+        # The new description of the set.
+        description: String
+        # The new names of the repositories in the set.
+        repositories: [String!]
+        # The new name patterns of the set.
+        namePatterns: [String!]
+        # Whether the set is the organization's default search scope.
+        isDefault: Boolean
+    ): OrgRepositorySet!
+    # Deletes an organization repository set.
+    #
+    # Only site admins and any member of the organization may perform this mutation.
+    deleteOrgRepositorySet(id: ID!): EmptyResponse
+    # (experimental) Creates a version context. Each revision must exist in its repository.
+    #
+    # Only site admins may create version contexts owned by the site. Only site admins and any member of
+    # the organization may create version contexts owned by an organization.
+    createVersionContext(
+        # The name of the version context, unique among all version contexts.
+        name: String!
+        # A description of the version context.
+        description: String
+        # The organization that owns the version context. If null, the site owns it.
+        organization: ID
+        # The repository revisions of the version context.
+        revisions: [VersionContextRevisionInput!]!
+    ): VersionContext!
+    # (experimental) Updates a version context. Fields that are not given are left unchanged.
+    #
+    # Only site admins and (for version contexts owned by an organization) any member of the organization
+    # may perform this mutation.
+    updateVersionContext(
+        # The version context to update.
+        id: ID!
+        # The new name of the version context.
+        name: String
+        # The new description of the version context.
+        description: String
+        # The new repository revisions of the version context. Each revision must exist in its repository.
+        revisions: [VersionContextRevisionInput!]
+    ): VersionContext!
+    # (experimental) Deletes a version context.
+    #
+    # Only site admins and (for version contexts owned by an organization) any member of the organization
+    # may perform this mutation.
+    deleteVersionContext(id: ID!): EmptyResponse
+    # (experimental) Creates a code insights time series of the match counts of a saved search in a set of
+    # repositories. The points of the series are computed in the background, starting with the points since
+    # the start time, and the series is extended as time passes.
+    #
+    # Only users who can access the saved search and the repositories may perform this mutation.
+    createInsightSeries(
+        # The saved search whose query is counted.
+        savedSearch: ID!
+        # The repositories to count the matches in.
+        repositories: [ID!]!
+        # The number of days between the points of the series. It must be at least 1.
+        intervalDays: Int!
+        # The time of the first point of the series.
+        startTime: DateTime!
+    ): InsightSeries!
+    # (experimental) Deletes a code insights time series and its points.
+    #
+    # Only users who can access the saved search of the series may perform this mutation.
+    deleteInsightSeries(id: ID!): EmptyResponse
     # Adds or removes a tag on a user.
     #
     # Tags are used internally by Sourcegraph as feature flags for experimental features.
@@ -349,6 +450,18 @@ type Mutation {
         # with this new value.
         input: String!
     ): Boolean!
+    # Restores an earlier version of the site configuration by saving its contents as the new site
+    # configuration. Returns whether or not a restart is required for the update to be applied.
+    #
+    # Only site admins may perform this mutation.
+    restoreSiteConfiguration(
+        # The last ID of the site configuration that is known by the client, to
+        # prevent race conditions. An error will be returned if someone else
+        # has already written a new update.
+        lastID: Int!
+        # The ID of the site configuration version to restore (see Site.configurationHistory).
+        id: Int!
+    ): Boolean!
     # Sets whether the user with the specified user ID is a site admin.
     #
     # Only site admins may perform this mutation.
@@ -985,6 +1098,12 @@ type SettingsMutation {
         # entire previous settings value will be overwritten by this new value.
         contents: String!
     ): UpdateSettingsPayload
+    # Restore an earlier version of the settings by saving its contents as the new settings. The history of
+    # earlier versions is preserved.
+    restoreSettings(
+        # The ID of the settings version to restore (see Query.settingsHistory).
+        id: Int!
+    ): UpdateSettingsPayload
 }
 
 # An edit to a JSON property in a settings JSON object. The JSON property to edit can be nested.
@@ -1197,6 +1316,23 @@ type Query {
     # Looks up an instance of a type that implements SettingsSubject (i.e., something that has settings). This can
     # be a site (which has global settings), an organization, or a user.
     settingsSubject(id: ID!): SettingsSubject
+    # The versions of the settings of a settings subject (a site, an organization, or a user), newest first.
+    settingsHistory(
+        # The ID of the settings subject.
+        subject: ID!
+        # Returns the first n versions from the list.
+        first: Int
+    ): [Settings!]!
+    # The structural differences between two versions of the settings of a settings subject. Differences in
+    # comments and formatting are ignored.
+    settingsDiff(
+        # The ID of the settings subject.
+        subject: ID!
+        # The ID of the older settings version.
+        base: Int!
+        # The ID of the newer settings version.
+        head: Int!
+    ): ConfigurationDiff!
     # The settings for the viewer. The viewer is either an anonymous visitor (in which case viewer settings is
     # global settings) or an authenticated user (in which case viewer settings are the user's settings).
     viewerSettings: SettingsCascade!
@@ -1240,6 +1376,10 @@ type Query {
         # the first N results (relative to the cursor) should be returned. i.e.
         # how many results to return per page. It must be in the range of 0-5000.
         first: Int
+
+        # (experimental) Whether to save the search in the search history of the current user, if search history
+        # is enabled and the user didn't opt out of it with the search.saveHistory setting.
+        saveHistory: Boolean = false
     ): Search
     # All saved searches configured for the current user, merged from all configurations.
     savedSearches: [SavedSearch!]!
@@ -1247,6 +1387,12 @@ type Query {
     repoGroups: [RepoGroup!]!
     # (experimental) All version contexts.
     versionContexts: [VersionContext!]!
+    # (experimental) The code insights time series of the saved searches of the current user and the
+    # user's organizations.
+    insightSeries(
+        # Returns the first n series from the list.
+        first: Int
+    ): InsightSeriesConnection!
     # The current site.
     site: Site!
     # Retrieve responses to surveys.
@@ -1327,6 +1473,61 @@ type Search {
     # cached and thus quicker to query. Useful for e.g. querying sparkline
     # data.
     stats: SearchResultsStats!
+    # The number of matches of all results of the search, grouped by the given
+    # property. Unlike the results, the aggregation is not paginated, and it
+    # includes up to 10,000 results (or the count: of the query, if lower).
+    aggregations(
+        # The property to group the matches by.
+        groupBy: SearchAggregationGroupBy!
+        # The number of path components of the groups when grouping by
+        # PATH_PREFIX (default 1).
+        pathDepth: Int
+        # The regular expression whose first capture group (or whole match, if
+        # it has no capture group) is the group of a match when grouping by
+        # CAPTURE_GROUP. It is applied to the text of each match, and matches
+        # whose text it doesn't match are ungrouped.
+        captureGroupPattern: String
+        # The maximum number of groups returned (default 50, at most 1000).
+        # The matches of the remaining groups are counted in otherCount.
+        limit: Int
+    ): SearchAggregation!
+}
+
+# The property to group the matches of a search by.
+enum SearchAggregationGroupBy {
+    # The repository of the match.
+    REPOSITORY
+    # The leading directories of the path of the matching file.
+    PATH_PREFIX
+    # The author of the matching line, according to git blame, or of the
+    # matching commit.
+    AUTHOR
+    # The language of the matching file.
+    LANGUAGE
+    # The value of a capture group of a regular expression in the matching line.
+    CAPTURE_GROUP
+}
+
+# The number of matches of a search, grouped by a property.
+type SearchAggregation {
+    # The groups with the most matches, sorted by descending number of matches.
+    groups: [SearchAggregationGroup!]!
+    # The number of matches in groups that were omitted because of the limit.
+    otherCount: Int!
+    # The number of matches that have no value of the property, e.g. repository
+    # results when grouping by LANGUAGE.
+    ungroupedCount: Int!
+    # Whether the search hit the result limit of the aggregation, in which case
+    # the counts only include the matches found before the limit was hit.
+    limitHit: Boolean!
+}
+
+# A group of matches of a search aggregation.
+type SearchAggregationGroup {
+    # The value of the property of the matches of the group.
+    label: String!
+    # The number of matches in the group.
+    count: Int!
 }
 
 # Predefined suggestions for search filters when backfill.
@@ -1629,6 +1830,8 @@ enum ExternalServiceKind {
     AWSCODECOMMIT
     BITBUCKETCLOUD
     BITBUCKETSERVER
+    GERRIT
+    GITEA
     GITHUB
     GITLAB
     GITOLITE
@@ -1747,10 +1950,8 @@ type Repository implements Node & GenericSearchResultInterface {
         first: Int
         # Return Git refs whose names match the query.
         query: String
-        # Return only Git refs of the given type.
-        #
-        # Known issue: It is only supported to retrieve Git branch and tag refs, not
-        # other Git refs.
+        # Return only Git refs of the given type. If not set, branches and tags are returned: other refs
+        # (such as refs/pull/*) are only returned if GIT_REF_OTHER is requested.
         type: GitRefType
         # Ordering for Git refs in the list.
         orderBy: GitRefOrder
@@ -1951,10 +2152,12 @@ type ExternalRepository {
 # (experimental) A version context. Used to change the set of default repository and
 # revisions searched.
 #
-# Note: We do not expose the list of repositories and revisions in the version
-# context. This is intentional. However, if a need arises we can add it in.
+# Version contexts are stored in the database and owned by an organization or by the site. Version
+# contexts defined in the experimentalFeatures.versionContexts site configuration are also listed, but
+# can't be changed with the API.
 type VersionContext implements Node {
-    # The version context ID is its name.
+    # The unique ID for the version context. For version contexts defined in the site configuration, the ID
+    # is its name.
     id: ID!
 
     # The name of the version context.
@@ -1962,6 +2165,117 @@ type VersionContext implements Node {
 
     # The description of the version context.
     description: String!
+
+    # The organization that owns the version context, or null if it is owned by the site.
+    organization: Org
+
+    # The repository revisions that are searched when using the version context. Only the revisions of
+    # repositories that the viewer has access to are included.
+    revisions: [VersionContextRevision!]!
+
+    # Whether the viewer can update or delete the version context. Site admins can administer all version
+    # contexts stored in the database, and organization members can administer the organization's.
+    viewerCanAdminister: Boolean!
+}
+
+# (experimental) A repository revision in a version context.
+type VersionContextRevision {
+    # The name of the repository.
+    repo: String!
+
+    # The revision (e.g., a branch, tag or commit SHA).
+    rev: String!
+}
+
+# (experimental) A repository revision in a version context.
+input VersionContextRevisionInput {
+    # The name of the repository.
+    repo: String!
+
+    # The revision (e.g., a branch, tag or commit SHA). It must exist in the repository.
+    rev: String!
+}
+
+# (experimental) A code insights time series: the number of matches of a saved search in a set of
+# repositories over time.
+#
+# The series has a point for every repository every intervalDays days since the start time. The match count
+# of a point is the number of matches at the last commit of the repository's default branch before the time
+# of the point. Points are computed in the background.
+type InsightSeries implements Node {
+    # The unique ID of the series.
+    id: ID!
+
+    # The saved search whose query is counted.
+    savedSearch: SavedSearch!
+
+    # The repositories the matches are counted in.
+    repositories: [Repository!]!
+
+    # The number of days between the points of the series.
+    intervalDays: Int!
+
+    # The time of the first point of the series.
+    startTime: DateTime!
+
+    # The computed points of the series, ordered by time.
+    points(
+        # Only return the points in this repository.
+        repository: ID
+        # Returns the first n points from the list.
+        first: Int
+    ): InsightSeriesPointConnection!
+
+    # The number of points that are not computed yet.
+    pendingPoints: Int!
+
+    # The number of points that could not be computed.
+    failedPoints: Int!
+
+    # The date when the series was created.
+    createdAt: DateTime!
+}
+
+# (experimental) A list of code insights time series.
+type InsightSeriesConnection {
+    # A list of series.
+    nodes: [InsightSeries!]!
+
+    # The total number of series in the connection.
+    totalCount: Int!
+
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# (experimental) The match count of an insight series in a repository at a point in time.
+type InsightSeriesPoint {
+    # The repository.
+    repository: Repository!
+
+    # The time of the point.
+    time: DateTime!
+
+    # The commit that was searched, or null if the repository had no commits at the time of the point.
+    commit: GitCommit
+
+    # The number of matches.
+    matchCount: Int!
+
+    # Whether the match count is a lower bound because the match limit of the search was hit.
+    limitHit: Boolean!
+}
+
+# (experimental) A list of points of a code insights time series.
+type InsightSeriesPointConnection {
+    # A list of points.
+    nodes: [InsightSeriesPoint!]!
+
+    # The total number of points in the connection.
+    totalCount: Int!
+
+    # Pagination information.
+    pageInfo: PageInfo!
 }
 
 # Information about a repository's text search index.
@@ -2455,7 +2769,8 @@ type GitCommit implements Node {
     canonicalURL: String!
     # The URLs to this commit on its repository's external services.
     externalURLs: [ExternalLink!]!
-    # The Git tree in this commit at the given path.
+    # The Git tree in this commit at the given path. If the path is inside a submodule whose repository is known
+    # to Sourcegraph, this is the tree in the submodule repository at the commit of the submodule.
     tree(
         # The path of the tree.
         path: String = ""
@@ -2465,7 +2780,8 @@ type GitCommit implements Node {
         # DEPRECATED: Use the "recursive" parameter on GitTree's fields instead.
         recursive: Boolean = false
     ): GitTree
-    # The Git blob in this commit at the given path.
+    # The Git blob in this commit at the given path. If the path is inside a submodule whose repository is known
+    # to Sourcegraph, this is the blob in the submodule repository at the commit of the submodule.
     blob(path: String!): GitBlob
     # The file at the given path for this commit.
     #
@@ -2538,6 +2854,19 @@ type Submodule {
     commit: String!
     # The path to which the submodule is checked out.
     path: String!
+    # The repository on Sourcegraph that the submodule URL refers to, or null if it is not known to
+    # Sourcegraph. Relative submodule URLs are resolved relative to the name of the repository containing the
+    # submodule.
+    repository: Repository
+    # The root tree of the submodule repository at the commit of the submodule, or null if the repository or
+    # commit is not known to Sourcegraph.
+    tree: GitTree
+}
+
+# Git LFS metadata of a file stored in Git LFS.
+type LFS {
+    # The size of the file stored in Git LFS in bytes.
+    byteSize: Float!
 }
 
 # A file, directory, or other tree entry.
@@ -2746,12 +3075,15 @@ type GitBlob implements TreeEntry & File2 {
     name: String!
     # False because this is a blob (file), not a directory.
     isDirectory: Boolean!
-    # The content of this blob.
+    # The content of this blob. Text in an encoding other than UTF-8 is transcoded to UTF-8.
     content: String!
     # The file size in bytes.
     byteSize: Int!
     # Whether or not it is binary.
     binary: Boolean!
+    # The text encoding of this blob, detected from its byte order mark or with heuristics (e.g.
+    # "UTF-8", "UTF-16LE", "Shift_JIS" or "windows-1252"), or null if it is binary.
+    encoding: String
     # The blob contents rendered as rich HTML, or an empty string if it is not a supported
     # rich file type.
     #
@@ -2773,6 +3105,9 @@ type GitBlob implements TreeEntry & File2 {
     highlight(disableTimeout: Boolean!, isLightTheme: Boolean!, highlightLongLines: Boolean = false): HighlightedFile!
     # Submodule metadata if this tree points to a submodule
     submodule: Submodule
+    # Git LFS metadata if this blob is stored in Git LFS. The content of the blob is the content of the
+    # file stored in Git LFS if it was fetched, and otherwise the Git LFS pointer file.
+    lfs: LFS
     # Symbols defined in this blob.
     symbols(
         # Returns the first n symbols from the list.
@@ -2871,6 +3206,9 @@ type FileMatch {
     # The revspec of the revision that contains this match. If no revspec was given (such as when no
     # repository filter or revspec is specified in the search query), it is null.
     revSpec: GitRevSpec
+    # The other revspecs that contain this file with the same contents, when several revisions of the
+    # repository were searched. The file match is only returned once for all of them.
+    otherRevSpecs: [GitRevSpec!]!
     # The resource.
     resource: String! @deprecated(reason: "use the file field instead")
     # The symbols found in this file that match the query.
@@ -2892,6 +3230,19 @@ type LineMatch {
     offsetAndLengths: [[Int!]!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
+    # The lines around the matched line, if the search query has a "context:"
+    # field. Null if there are no lines of context.
+    context: LineMatchContext
+}
+
+# The lines of context around a matched line. Lines are only returned once:
+# lines which are matched lines themselves or in the context of a previous
+# line match are omitted.
+type LineMatchContext {
+    # The lines before the matched line, in order.
+    before: [String!]!
+    # The lines after the matched line, in order.
+    after: [String!]!
 }
 
 # A hunk.
@@ -2995,6 +3346,14 @@ type User implements Node & SettingsSubject & Namespace {
         # Returns the first n event logs from the list.
         first: Int
     ): EventLogsConnection!
+    # The user's recent searches, most recent first. Searches are only saved if search history is enabled in
+    # the site configuration and the user has not opted out of it with the "search.saveHistory" setting.
+    #
+    # Only the user and site admins can access this field.
+    searchHistory(
+        # Returns the first n searches from the list.
+        first: Int
+    ): SearchHistoryConnection!
     # The user's email addresses.
     #
     # Only the user and site admins can access this field.
@@ -3235,11 +3594,39 @@ type Org implements Node & SettingsSubject & Namespace {
     url: String!
     # The URL to the organization's settings.
     settingsURL: String
+    # The named repository sets owned by this organization, ordered by name.
+    #
+    # Only organization members and site admins can access this field.
+    repositorySets: [OrgRepositorySet!]!
 
     # The name of this user namespace's component. For organizations, this is the organization's name.
     namespaceName: String!
 }
 
+# A named set of repositories owned by an organization. It is searched with the reposet:ORG/NAME
+# filter, and an organization's default set scopes its members' searches that don't specify any
+# repositories.
+type OrgRepositorySet implements Node {
+    # The unique ID for the repository set.
+    id: ID!
+    # The name of the set, unique within the organization.
+    name: String!
+    # A description of the set.
+    description: String!
+    # The organization that owns the set.
+    organization: Org!
+    # The names of the repositories explicitly included in the set.
+    repositories: [String!]!
+    # Regular expressions matching the names of additional repositories in the set.
+    namePatterns: [String!]!
+    # Whether the set is the organization's default search scope.
+    isDefault: Boolean!
+    # The date when the set was created.
+    createdAt: DateTime!
+    # The date when the set was last updated.
+    updatedAt: DateTime!
+}
+
 # The result of Mutation.inviteUserToOrganization.
 type InviteUserToOrganizationResult {
     # Whether an invitation email was sent. If emails are not enabled on this site or if the user has no verified
@@ -3327,6 +3714,19 @@ type Site implements SettingsSubject {
     configuration: SiteConfiguration!
     # The site's critical configuration. Only visible to site admins.
     criticalConfiguration: CriticalConfiguration!
+    # The versions of the site configuration, newest first. Only visible to site admins.
+    configurationHistory(
+        # Returns the first n versions from the list.
+        first: Int
+    ): [SiteConfigurationVersion!]!
+    # The structural differences between two versions of the site configuration. Differences in comments and
+    # formatting are ignored. Only visible to site admins.
+    configurationDiff(
+        # The ID of the older site configuration version.
+        base: Int!
+        # The ID of the newer site configuration version.
+        head: Int!
+    ): ConfigurationDiff!
     # The site's latest site-wide settings (which are the second-lowest-precedence
     # in the configuration cascade for a user).
     latestSettings: Settings
@@ -3433,6 +3833,44 @@ type SiteConfiguration {
     validationMessages: [String!]!
 }
 
+# A saved version of the site configuration.
+type SiteConfigurationVersion {
+    # The unique identifier of this site configuration version.
+    id: Int!
+    # The raw JSON contents of this site configuration version.
+    contents: JSONCString!
+    # The date when this site configuration version was saved.
+    createdAt: DateTime!
+}
+
+# The structural differences between two versions of settings or site configuration.
+type ConfigurationDiff {
+    # The changed values, ordered by key path.
+    changes: [ConfigurationChange!]!
+}
+
+# A difference between two versions of settings or site configuration at a single key path.
+type ConfigurationChange {
+    # The path of object property names to the changed value. Arrays are compared as a whole.
+    path: [String!]!
+    # How the value changed.
+    kind: ConfigurationChangeKind!
+    # The value in the older version, or null if the value was added.
+    oldValue: JSONValue
+    # The value in the newer version, or null if the value was removed.
+    newValue: JSONValue
+}
+
+# How a value changed between two versions of settings or site configuration.
+enum ConfigurationChangeKind {
+    # The value was added.
+    ADDED
+    # The value was removed.
+    REMOVED
+    # The value was changed.
+    CHANGED
+}
+
 # The critical configuration for a site.
 type CriticalConfiguration {
     # The unique identifier of this site configuration version.
@@ -4477,6 +4915,32 @@ type EventLog {
 }
 
 # A list of event logs.
+type SearchHistoryConnection {
+    # A list of searches.
+    nodes: [SearchHistoryEntry!]!
+    # The total count of searches in the connection. This total count may be larger than the number of nodes
+    # in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A search in the search history of a user.
+type SearchHistoryEntry {
+    # The search query.
+    query: String!
+    # The pattern type of the search query.
+    patternType: SearchPatternType!
+    # The version context of the search, if any.
+    versionContext: String
+    # The number of results of the search.
+    resultCount: Int!
+    # The time it took to run the search, in milliseconds.
+    durationMilliseconds: Int!
+    # When the search was run.
+    createdAt: DateTime!
+}
+
 type EventLogsConnection {
     # A list of event logs.
     nodes: [EventLog!]!
@@ -58,6 +58,7 @@ type SearchArgs struct {
 	After          *string
 	First          *int32
 	VersionContext *string
+	SaveHistory    bool
 }
 
 type SearchImplementer interface {
@@ -65,6 +66,8 @@ type SearchImplementer interface {
 	Suggestions(context.Context, *searchSuggestionsArgs) ([]*searchSuggestionResolver, error)
 	//lint:ignore U1000 is used by graphql via reflection
 	Stats(context.Context) (*searchResultsStats, error)
+	//lint:ignore U1000 is used by graphql via reflection
+	Aggregations(context.Context, *searchAggregationArgs) (*searchAggregationResolver, error)
 }
 
 // NewSearchImplementer returns a SearchImplementer that provides search results and suggestions.
@@ -133,7 +136,14 @@ func NewSearchImplementer(args *SearchArgs) (SearchImplementer, error) {
 }
 
 func (r *schemaResolver) Search(args *SearchArgs) (SearchImplementer, error) {
-	return NewSearchImplementer(args)
+	search, err := NewSearchImplementer(args)
+	if sr, ok := search.(*searchResolver); ok {
+		// Only the searches that clients ask to save are saved in the search
+		// history of the user, not e.g. the internal ones or those of API
+		// scripts.
+		sr.saveHistory = args.SaveHistory
+	}
+	return search, err
 }
 
 // queryForStableResults transforms a query that returns a stable result
@@ -242,6 +252,14 @@ type searchResolver struct {
 	patternType    query.SearchType
 	versionContext *string
 
+	// saveHistory is whether the search is saved in the search history of
+	// the current user when its results are resolved.
+	saveHistory bool
+
+	// resultLimit, if nonzero, is the maximum number of results of the
+	// search. It takes precedence over the count: of the query.
+	resultLimit int32
+
 	// Cached resolveRepositories results.
 	reposMu                   sync.Mutex
 	repoRevs, missingRepoRevs []*search.RepositoryRevisions
@@ -274,6 +292,9 @@ func (r *searchResolver) maxResults() int32 {
 		// search_pagination.go for details on why this is necessary .
 		return math.MaxInt32
 	}
+	if r.resultLimit > 0 {
+		return r.resultLimit
+	}
 	count, _ := r.query.StringValues(query.FieldCount)
 	if len(count) > 0 {
 		n, _ := strconv.Atoi(count[0])
@@ -333,17 +354,6 @@ func resolveRepoGroups(ctx context.Context) (map[string][]*types.Repo, error) {
 	return groups, nil
 }
 
-// NOTE: This function is not called if the version context is not used
-func resolveVersionContext(versionContext string) (*schema.VersionContext, error) {
-	for _, vc := range conf.Get().ExperimentalFeatures.VersionContexts {
-		if vc.Name == versionContext {
-			return vc, nil
-		}
-	}
-
-	return nil, errors.New("version context not found")
-}
-
 // Cf. golang/go/src/regexp/syntax/parse.go.
 const regexpFlags regexpsyntax.Flags = regexpsyntax.ClassNL | regexpsyntax.PerlX | regexpsyntax.UnicodeGroups
 
@@ -480,11 +490,25 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 		versionContextName = *r.versionContext
 	}
 
+	repoSetFilters, _ := r.query.StringValues(query.FieldRepoSet)
+	var repoSets []*types.OrgRepositorySet
+	if len(repoSetFilters) > 0 {
+		repoSets, err = resolveOrgRepositorySets(ctx, repoSetFilters)
+	} else if len(repoFilters) == 0 && len(repoGroupFilters) == 0 && versionContextName == "" {
+		// Scope the search to the default repository sets of the user's
+		// organizations when the query doesn't specify any repositories.
+		repoSets, err = defaultOrgRepositorySets(ctx)
+	}
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
 	tr.LazyPrintf("resolveRepositories - start")
 	options := resolveRepoOp{
 		repoFilters:        repoFilters,
 		minusRepoFilters:   minusRepoFilters,
 		repoGroupFilters:   repoGroupFilters,
+		repoSets:           repoSets,
 		versionContextName: versionContextName,
 		onlyForks:          fork == Only || fork == True,
 		noForks:            fork == No || fork == False,
@@ -497,6 +521,12 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 	}
 	repoRevs, missingRepoRevs, overLimit, excludedRepos, err = resolveRepositories(ctx, options)
 	tr.LazyPrintf("resolveRepositories - done")
+
+	submodulesStr, _ := r.query.StringValue(query.FieldSubmodules)
+	if submodules := parseYesNoOnly(submodulesStr); err == nil && (submodules == Yes || submodules == True) {
+		repoRevs = withSubmodules(ctx, repoRevs)
+		tr.LazyPrintf("withSubmodules - done")
+	}
 	if effectiveRepoFieldValues == nil {
 		r.repoRevs = repoRevs
 		r.missingRepoRevs = missingRepoRevs
@@ -604,6 +634,7 @@ type resolveRepoOp struct {
 	repoFilters        []string
 	minusRepoFilters   []string
 	repoGroupFilters   []string
+	repoSets           []*types.OrgRepositorySet
 	versionContextName string
 	noForks            bool
 	onlyForks          bool
@@ -654,6 +685,18 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 		}
 	}
 
+	// Organization repository sets work like repo groups, except that they may
+	// also contain name patterns.
+	if len(op.repoSets) > 0 {
+		patterns := orgRepositorySetPatterns(op.repoSets)
+		includePatterns = append(includePatterns, unionRegExps(patterns))
+
+		// Ensure we don't omit any repos explicitly included via a repo set.
+		if len(patterns) > maxRepoListSize {
+			maxRepoListSize = len(patterns)
+		}
+	}
+
 	// note that this mutates the strings in includePatterns, stripping their
 	// revision specs, if they had any.
 	includePatternRevs, err := findPatternRevs(includePatterns)
@@ -664,10 +707,10 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 	// If a version context is specified, gather the list of repository names
 	// to limit the results to these repositories.
 	var versionContextRepositories []string
-	var versionContext *schema.VersionContext
+	var versionContext *types.VersionContext
 	// If a ref is specified we skip using version contexts.
 	if len(includePatternRevs) == 0 && op.versionContextName != "" {
-		versionContext, err = resolveVersionContext(op.versionContextName)
+		versionContext, err = resolveVersionContext(ctx, op.versionContextName)
 		if err != nil {
 			return nil, nil, false, nil, err
 		}
@@ -0,0 +1,383 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// The values of the SearchAggregationGroupBy GraphQL enum.
+const (
+	aggregateByRepository   = "REPOSITORY"
+	aggregateByPathPrefix   = "PATH_PREFIX"
+	aggregateByAuthor       = "AUTHOR"
+	aggregateByLanguage     = "LANGUAGE"
+	aggregateByCaptureGroup = "CAPTURE_GROUP"
+)
+
+const (
+	defaultAggregationLimit = 50
+	maxAggregationLimit     = 1000
+
+	// maxAggregationBlameFiles is the maximum number of files blamed to group
+	// matches by author. The matches in other files are not grouped.
+	maxAggregationBlameFiles = 500
+
+	// maxAggregationResults is the maximum number of results of the search
+	// whose matches are grouped. It is used instead of the default count of
+	// searches, and bounds the count: of the query.
+	maxAggregationResults = 10000
+)
+
+type searchAggregationArgs struct {
+	GroupBy             string
+	PathDepth           *int32
+	CaptureGroupPattern *string
+	Limit               *int32
+}
+
+// Aggregations groups all the matches of the search, not only the ones on the
+// current page of results, up to maxAggregationResults results.
+func (r *searchResolver) Aggregations(ctx context.Context, args *searchAggregationArgs) (*searchAggregationResolver, error) {
+	a, err := newSearchAggregator(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The aggregation runs its own search, with a higher limit than the
+	// default count of the results.
+	limit := int32(maxAggregationResults)
+	if r.countIsSet() && r.maxResults() < limit {
+		limit = r.maxResults()
+	}
+	// An and/or query is rewritten while it is evaluated, so the search gets
+	// its own copy of it.
+	q := r.query
+	if andOr, ok := q.(*query.AndOrQuery); ok {
+		q = &query.AndOrQuery{Query: andOr.Query}
+	}
+	search := &searchResolver{
+		query:          q,
+		originalQuery:  r.originalQuery,
+		patternType:    r.patternType,
+		versionContext: r.versionContext,
+		resultLimit:    limit,
+		zoekt:          r.zoekt,
+		searcherURLs:   r.searcherURLs,
+	}
+	results, err := search.results(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.add(ctx, results.SearchResults); err != nil {
+		return nil, err
+	}
+	agg := a.result()
+	agg.limitHit = results.LimitHit()
+	return agg, nil
+}
+
+// searchAggregator counts the matches of search results by group.
+type searchAggregator struct {
+	groupBy   string
+	pathDepth int
+	pattern   *regexp.Regexp // for aggregateByCaptureGroup
+	limit     int
+
+	mu        sync.Mutex
+	counts    map[string]int32
+	ungrouped int32
+}
+
+func newSearchAggregator(args *searchAggregationArgs) (*searchAggregator, error) {
+	a := &searchAggregator{
+		groupBy:   args.GroupBy,
+		pathDepth: 1,
+		limit:     defaultAggregationLimit,
+		counts:    map[string]int32{},
+	}
+	if args.Limit != nil {
+		if *args.Limit < 1 || *args.Limit > maxAggregationLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxAggregationLimit)
+		}
+		a.limit = int(*args.Limit)
+	}
+
+	switch args.GroupBy {
+	case aggregateByRepository, aggregateByAuthor, aggregateByLanguage:
+	case aggregateByPathPrefix:
+		if args.PathDepth != nil {
+			if *args.PathDepth < 1 {
+				return nil, errors.New("pathDepth must be at least 1")
+			}
+			a.pathDepth = int(*args.PathDepth)
+		}
+	case aggregateByCaptureGroup:
+		if args.CaptureGroupPattern == nil || *args.CaptureGroupPattern == "" {
+			return nil, errors.New("captureGroupPattern is required to group by capture group")
+		}
+		pattern, err := regexp.Compile(*args.CaptureGroupPattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid captureGroupPattern: %s", err)
+		}
+		a.pattern = pattern
+	default:
+		return nil, fmt.Errorf("unsupported groupBy %q", args.GroupBy)
+	}
+	return a, nil
+}
+
+func (a *searchAggregator) count(label string, n int32) {
+	a.mu.Lock()
+	a.counts[label] += n
+	a.mu.Unlock()
+}
+
+func (a *searchAggregator) countUngrouped(n int32) {
+	a.mu.Lock()
+	a.ungrouped += n
+	a.mu.Unlock()
+}
+
+// add counts the matches of the results.
+func (a *searchAggregator) add(ctx context.Context, results []SearchResultResolver) error {
+	if a.groupBy == aggregateByAuthor {
+		return a.addAuthors(ctx, results)
+	}
+
+	for _, res := range results {
+		switch a.groupBy {
+		case aggregateByRepository:
+			if repo := searchResultRepository(res); repo != nil {
+				a.count(repo.Name(), res.resultCount())
+			} else {
+				a.countUngrouped(res.resultCount())
+			}
+
+		case aggregateByPathPrefix:
+			if fm, ok := res.ToFileMatch(); ok {
+				a.count(pathPrefix(fm.JPath, a.pathDepth), res.resultCount())
+			} else {
+				a.countUngrouped(res.resultCount())
+			}
+
+		case aggregateByLanguage:
+			fm, ok := res.ToFileMatch()
+			if !ok {
+				a.countUngrouped(res.resultCount())
+				continue
+			}
+			if lang, _ := inventory.GetLanguageByFilename(fm.JPath); lang != "" {
+				a.count(lang, res.resultCount())
+			} else {
+				a.countUngrouped(res.resultCount())
+			}
+
+		case aggregateByCaptureGroup:
+			fm, ok := res.ToFileMatch()
+			if !ok || len(fm.JLineMatches) == 0 {
+				a.countUngrouped(res.resultCount())
+				continue
+			}
+			for _, lm := range fm.JLineMatches {
+				a.addCaptureGroups(lm)
+			}
+		}
+	}
+	return nil
+}
+
+// addCaptureGroups counts the matches in the line by the value of the first
+// capture group (or the whole match, if the pattern has no capture group) of
+// the pattern in the text of each match. Matches whose text doesn't match the
+// pattern are not grouped.
+func (a *searchAggregator) addCaptureGroups(lm *lineMatch) {
+	if len(lm.JOffsetAndLengths) == 0 {
+		a.countUngrouped(1)
+		return
+	}
+	// Offsets and lengths are measured in characters, not bytes.
+	line := []rune(lm.JPreview)
+	for _, ol := range lm.JOffsetAndLengths {
+		start, end := int(ol[0]), int(ol[0]+ol[1])
+		if start < 0 || start > end || end > len(line) {
+			a.countUngrouped(1)
+			continue
+		}
+		m := a.pattern.FindStringSubmatch(string(line[start:end]))
+		switch {
+		case m == nil:
+			a.countUngrouped(1)
+		case len(m) > 1:
+			a.count(m[1], 1)
+		default:
+			a.count(m[0], 1)
+		}
+	}
+}
+
+// addAuthors counts the matches of the results by the author of the matching
+// lines, according to git blame, or by the author of matching commits.
+func (a *searchAggregator) addAuthors(ctx context.Context, results []SearchResultResolver) error {
+	run := parallel.NewRun(16)
+	blamed := 0
+	for _, res := range results {
+		if commit, ok := res.ToCommitSearchResult(); ok {
+			author, err := commit.Commit().Author(ctx)
+			if err != nil {
+				return err
+			}
+			a.count(author.person.name, res.resultCount())
+			continue
+		}
+
+		fm, ok := res.ToFileMatch()
+		if !ok || len(fm.JLineMatches) == 0 || blamed >= maxAggregationBlameFiles {
+			a.countUngrouped(res.resultCount())
+			continue
+		}
+		blamed++
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			if err := a.addBlame(ctx, fm); err != nil {
+				run.Error(err)
+			}
+		})
+	}
+	return run.Wait()
+}
+
+// addBlame counts the line matches of the file match by the author of the
+// lines.
+func (a *searchAggregator) addBlame(ctx context.Context, fm *FileMatchResolver) error {
+	// Blame the range of lines with matches at once. Line numbers of line
+	// matches are 0-based, blame line numbers are 1-based.
+	start, end := fm.JLineMatches[0].JLineNumber, fm.JLineMatches[0].JLineNumber
+	for _, lm := range fm.JLineMatches {
+		if lm.JLineNumber < start {
+			start = lm.JLineNumber
+		}
+		if lm.JLineNumber > end {
+			end = lm.JLineNumber
+		}
+	}
+	hunks, err := git.BlameFile(ctx, gitserver.Repo{Name: fm.Repo.Name}, fm.JPath, &git.BlameOptions{
+		NewestCommit: fm.CommitID,
+		StartLine:    int(start) + 1,
+		EndLine:      int(end) + 1,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, lm := range fm.JLineMatches {
+		line := int(lm.JLineNumber) + 1
+		author := ""
+		for _, h := range hunks {
+			// The end line of a hunk is exclusive.
+			if h.StartLine <= line && line < h.EndLine {
+				author = h.Author.Name
+				break
+			}
+		}
+		if author == "" {
+			a.countUngrouped(lineMatchCount(lm))
+		} else {
+			a.count(author, lineMatchCount(lm))
+		}
+	}
+	return nil
+}
+
+// result returns the groups with the most matches.
+func (a *searchAggregator) result() *searchAggregationResolver {
+	groups := make([]*searchAggregationGroupResolver, 0, len(a.counts))
+	for label, count := range a.counts {
+		groups = append(groups, &searchAggregationGroupResolver{label: label, count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		return groups[i].label < groups[j].label
+	})
+
+	agg := &searchAggregationResolver{ungroupedCount: a.ungrouped}
+	for i, g := range groups {
+		if i < a.limit {
+			agg.groups = append(agg.groups, g)
+		} else {
+			agg.otherCount += g.count
+		}
+	}
+	return agg
+}
+
+// searchResultRepository returns the repository of a search result, or nil.
+func searchResultRepository(res SearchResultResolver) *RepositoryResolver {
+	if fm, ok := res.ToFileMatch(); ok {
+		return fm.Repository()
+	}
+	if repo, ok := res.ToRepository(); ok {
+		return repo
+	}
+	if commit, ok := res.ToCommitSearchResult(); ok {
+		return commit.Commit().Repository()
+	}
+	return nil
+}
+
+// pathPrefix returns the directory of path with at most depth components,
+// with a trailing slash. Files with fewer directories are grouped by their
+// full path.
+func pathPrefix(path string, depth int) string {
+	parts := strings.Split(path, "/")
+	if len(parts) <= depth {
+		return path
+	}
+	return strings.Join(parts[:depth], "/") + "/"
+}
+
+// lineMatchCount returns the number of matches in the line.
+func lineMatchCount(lm *lineMatch) int32 {
+	if n := len(lm.JOffsetAndLengths); n > 0 {
+		return int32(n)
+	}
+	return 1
+}
+
+type searchAggregationResolver struct {
+	groups         []*searchAggregationGroupResolver
+	otherCount     int32
+	ungroupedCount int32
+	limitHit       bool
+}
+
+func (r *searchAggregationResolver) Groups() []*searchAggregationGroupResolver { return r.groups }
+
+func (r *searchAggregationResolver) OtherCount() int32 { return r.otherCount }
+
+func (r *searchAggregationResolver) UngroupedCount() int32 { return r.ungroupedCount }
+
+func (r *searchAggregationResolver) LimitHit() bool { return r.limitHit }
+
+type searchAggregationGroupResolver struct {
+	label string
+	count int32
+}
+
+func (r *searchAggregationGroupResolver) Label() string { return r.label }
+
+func (r *searchAggregationGroupResolver) Count() int32 { return r.count }
@@ -0,0 +1,225 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestSearchAggregator(t *testing.T) {
+	repoA := &types.Repo{ID: 1, Name: "a"}
+	repoB := &types.Repo{ID: 2, Name: "b"}
+	results := []SearchResultResolver{
+		&FileMatchResolver{
+			Repo: repoA, JPath: "cmd/foo/main.go", CommitID: "c1", MatchCount: 3,
+			JLineMatches: []*lineMatch{
+				{JPreview: "x := Foo(1) + Foo(2)", JLineNumber: 0, JOffsetAndLengths: [][2]int32{{5, 6}, {14, 6}}},
+				{JPreview: "y := Bar(3)", JLineNumber: 4, JOffsetAndLengths: [][2]int32{{5, 6}}},
+			},
+		},
+		&FileMatchResolver{
+			Repo: repoA, JPath: "README.md", CommitID: "c1", MatchCount: 1,
+			JLineMatches: []*lineMatch{{JPreview: "# Ünï Baz Qux(5)", JLineNumber: 0, JOffsetAndLengths: [][2]int32{{10, 6}}}},
+		},
+		&FileMatchResolver{
+			Repo: repoB, JPath: "cmd/bar/x.go", CommitID: "c2", MatchCount: 1,
+			JLineMatches: []*lineMatch{{JPreview: "Foo(4)", JLineNumber: 9, JOffsetAndLengths: [][2]int32{{0, 6}}}},
+		},
+		&RepositoryResolver{repo: repoB},
+	}
+
+	git.Mocks.BlameFile = func(repo gitserver.Repo, path string, opt *git.BlameOptions) ([]*git.Hunk, error) {
+		switch path {
+		case "cmd/foo/main.go":
+			if opt.NewestCommit != "c1" || opt.StartLine != 1 || opt.EndLine != 5 {
+				t.Errorf("got blame options %+v", opt)
+			}
+			return []*git.Hunk{
+				{StartLine: 1, EndLine: 3, Author: git.Signature{Name: "alice"}},
+				{StartLine: 3, EndLine: 6, Author: git.Signature{Name: "bob"}},
+			}, nil
+		case "README.md", "cmd/bar/x.go":
+			return []*git.Hunk{{StartLine: 1, EndLine: 11, Author: git.Signature{Name: "bob"}}}, nil
+		}
+		t.Fatalf("unexpected blame of %s", path)
+		return nil, nil
+	}
+	defer git.ResetMocks()
+
+	type group struct {
+		Label string
+		Count int32
+	}
+	tests := map[string]struct {
+		args          searchAggregationArgs
+		wantGroups    []group
+		wantOther     int32
+		wantUngrouped int32
+	}{
+		"repository": {
+			args:       searchAggregationArgs{GroupBy: aggregateByRepository},
+			wantGroups: []group{{"a", 4}, {"b", 2}},
+		},
+		"path prefix": {
+			args:          searchAggregationArgs{GroupBy: aggregateByPathPrefix},
+			wantGroups:    []group{{"cmd/", 4}, {"README.md", 1}},
+			wantUngrouped: 1,
+		},
+		"path prefix depth 2": {
+			args:          searchAggregationArgs{GroupBy: aggregateByPathPrefix, PathDepth: int32ptr(2)},
+			wantGroups:    []group{{"cmd/foo/", 3}, {"README.md", 1}, {"cmd/bar/", 1}},
+			wantUngrouped: 1,
+		},
+		"language": {
+			args:          searchAggregationArgs{GroupBy: aggregateByLanguage},
+			wantGroups:    []group{{"Go", 4}, {"Markdown", 1}},
+			wantUngrouped: 1,
+		},
+		"capture group": {
+			args:          searchAggregationArgs{GroupBy: aggregateByCaptureGroup, CaptureGroupPattern: strptr(`(\w+)\(`)},
+			wantGroups:    []group{{"Foo", 3}, {"Bar", 1}, {"Qux", 1}},
+			wantUngrouped: 1,
+		},
+		"capture group of whole match": {
+			args:          searchAggregationArgs{GroupBy: aggregateByCaptureGroup, CaptureGroupPattern: strptr(`\(\d\)`)},
+			wantGroups:    []group{{"(1)", 1}, {"(2)", 1}, {"(3)", 1}, {"(4)", 1}, {"(5)", 1}},
+			wantUngrouped: 1,
+		},
+		"author": {
+			args:          searchAggregationArgs{GroupBy: aggregateByAuthor},
+			wantGroups:    []group{{"bob", 3}, {"alice", 2}},
+			wantUngrouped: 1,
+		},
+		"limit": {
+			args:       searchAggregationArgs{GroupBy: aggregateByRepository, Limit: int32ptr(1)},
+			wantGroups: []group{{"a", 4}},
+			wantOther:  2,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, err := newSearchAggregator(&test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := a.add(context.Background(), results); err != nil {
+				t.Fatal(err)
+			}
+			agg := a.result()
+			var groups []group
+			for _, g := range agg.Groups() {
+				groups = append(groups, group{g.Label(), g.Count()})
+			}
+			if !reflect.DeepEqual(groups, test.wantGroups) {
+				t.Errorf("got groups %+v, want %+v", groups, test.wantGroups)
+			}
+			if agg.OtherCount() != test.wantOther {
+				t.Errorf("got other count %d, want %d", agg.OtherCount(), test.wantOther)
+			}
+			if agg.UngroupedCount() != test.wantUngrouped {
+				t.Errorf("got ungrouped count %d, want %d", agg.UngroupedCount(), test.wantUngrouped)
+			}
+		})
+	}
+}
+
+func TestSearchResolver_Aggregations_andOrQuery(t *testing.T) {
+	mockDecodedViewerFinalSettings = &schema.Settings{}
+	defer func() { mockDecodedViewerFinalSettings = nil }()
+
+	repo := &types.Repo{ID: 1, Name: "repo"}
+	db.Mocks.Repos.List = func(context.Context, db.ReposListOptions) ([]*types.Repo, error) {
+		return []*types.Repo{repo}, nil
+	}
+	db.Mocks.Repos.Count = mockCount
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	mockSearchRepositories = func(*search.TextParameters) ([]SearchResultResolver, *searchResultsCommon, error) {
+		return nil, &searchResultsCommon{}, nil
+	}
+	defer func() { mockSearchRepositories = nil }()
+
+	// Each operand of the or query is searched on its own.
+	var patterns []string
+	mockSearchFilesInRepos = func(args *search.TextParameters) ([]*FileMatchResolver, *searchResultsCommon, error) {
+		patterns = append(patterns, args.PatternInfo.Pattern)
+		return []*FileMatchResolver{{
+			uri:          "git://repo?rev#" + args.PatternInfo.Pattern + "/file",
+			JPath:        args.PatternInfo.Pattern + "/file",
+			JLineMatches: []*lineMatch{{JLineNumber: 1}},
+			MatchCount:   1,
+			Repo:         repo,
+		}}, &searchResultsCommon{repos: []*types.Repo{repo}}, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+
+	q, err := query.ProcessAndOr("foo or bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &searchResolver{query: q, patternType: query.SearchTypeRegex, zoekt: &searchbackend.Zoekt{}}
+	agg, err := r.Aggregations(context.Background(), &searchAggregationArgs{GroupBy: aggregateByPathPrefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"foo", "bar"}; !reflect.DeepEqual(patterns, want) {
+		t.Errorf("got patterns %v, want %v", patterns, want)
+	}
+	var groups []string
+	for _, g := range agg.Groups() {
+		groups = append(groups, fmt.Sprintf("%s:%d", g.Label(), g.Count()))
+	}
+	if want := []string{"bar/:1", "foo/:1"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("got groups %v, want %v", groups, want)
+	}
+	// The query of the resolver is not rewritten by the aggregation search.
+	if got := q.(*query.AndOrQuery).Query; len(got) != 1 {
+		t.Errorf("got query %v, want the original or query", got)
+	}
+}
+
+func TestNewSearchAggregator_invalid(t *testing.T) {
+	tests := map[string]searchAggregationArgs{
+		"unknown group by":       {GroupBy: "FOO"},
+		"missing pattern":        {GroupBy: aggregateByCaptureGroup},
+		"invalid pattern":        {GroupBy: aggregateByCaptureGroup, CaptureGroupPattern: strptr("(")},
+		"invalid path depth":     {GroupBy: aggregateByPathPrefix, PathDepth: int32ptr(0)},
+		"limit too large":        {GroupBy: aggregateByRepository, Limit: int32ptr(maxAggregationLimit + 1)},
+		"limit must be positive": {GroupBy: aggregateByRepository, Limit: int32ptr(0)},
+	}
+	for name, args := range tests {
+		if _, err := newSearchAggregator(&args); err == nil {
+			t.Errorf("%s: got nil error", name)
+		}
+	}
+}
+
+func TestPathPrefix(t *testing.T) {
+	tests := []struct {
+		path  string
+		depth int
+		want  string
+	}{
+		{"a/b/c.go", 1, "a/"},
+		{"a/b/c.go", 2, "a/b/"},
+		{"a/b/c.go", 3, "a/b/c.go"},
+		{"c.go", 1, "c.go"},
+	}
+	for _, test := range tests {
+		if got := pathPrefix(test.path, test.depth); got != test.want {
+			t.Errorf("pathPrefix(%q, %d) = %q, want %q", test.path, test.depth, got, test.want)
+		}
+	}
+}
+
+func int32ptr(v int32) *int32 { return &v }
@@ -604,3 +604,7 @@ func (searchAlert) Suggestions(context.Context, *searchSuggestionsArgs) ([]*sear
 	return nil, nil
 }
 func (searchAlert) Stats(context.Context) (*searchResultsStats, error) { return nil, nil }
+
+func (searchAlert) Aggregations(context.Context, *searchAggregationArgs) (*searchAggregationResolver, error) {
+	return nil, nil
+}
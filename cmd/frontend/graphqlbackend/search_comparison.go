@@ -0,0 +1,179 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+var mockSearchComparisonsInRepos func(args *search.TextParameters) ([]SearchResultResolver, *searchResultsCommon, error)
+
+// searchComparisonsInRepos searches the lines changed in the comparison of the
+// "rev:" field of the query (base...head) in a set of repos. There is a result
+// for each changed file with matching lines, which links to the file in the
+// comparison view.
+func searchComparisonsInRepos(ctx context.Context, args *search.TextParameters) ([]SearchResultResolver, *searchResultsCommon, error) {
+	if mockSearchComparisonsInRepos != nil {
+		return mockSearchComparisonsInRepos(args)
+	}
+
+	value, _ := args.Query.StringValue(query.FieldRev)
+	base, head, err := query.ParseRevisionRange(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tr, ctx := trace.New(ctx, "searchComparisonsInRepos", fmt.Sprintf("query: %+v, range: %s, numRepoRevs: %d", args.PatternInfo, value, len(args.Repos)))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		unflattened [][]*commitSearchResultResolver
+		common      = &searchResultsCommon{}
+	)
+	common.repos = make([]*types.Repo, len(args.Repos))
+	for i, repo := range args.Repos {
+		common.repos[i] = repo.Repo
+	}
+	for _, repoRev := range args.Repos {
+		// Limit the number of concurrent searches like searchFilesInRepos
+		// does. The only reason acquire can fail is if ctx is canceled, so
+		// we can stop looping through the repos.
+		limitCtx, limitDone, acquireErr := textSearchLimiter.Acquire(ctx)
+		if acquireErr != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(ctx context.Context, done context.CancelFunc, repoRev *search.RepositoryRevisions) {
+			defer wg.Done()
+			defer done()
+
+			results, repoLimitHit, searchErr := searchComparisonInRepo(ctx, repoRev, base, head, args.PatternInfo)
+			if ctx.Err() == context.Canceled {
+				// Our request has been canceled (either because another one of args.repos had a
+				// fatal error, or otherwise), so we can just ignore these results.
+				return
+			}
+			repoTimedOut := ctx.Err() == context.DeadlineExceeded
+			if searchErr != nil {
+				tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.String("searchErr", searchErr.Error()), otlog.Bool("timeout", errcode.IsTimeout(searchErr)), otlog.Bool("temporary", errcode.IsTemporary(searchErr)))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if fatalErr := handleRepoSearchResult(common, repoRev, repoLimitHit, repoTimedOut, searchErr); fatalErr != nil {
+				err = errors.Wrapf(searchErr, "failed to search comparison %s in %s", value, repoRev.String())
+				cancel()
+			}
+			if len(results) > 0 {
+				unflattened = append(unflattened, results)
+			}
+		}(limitCtx, limitDone, repoRev)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var flattened []*commitSearchResultResolver
+	for _, results := range unflattened {
+		flattened = append(flattened, results...)
+	}
+	return commitSearchResultsToSearchResults(flattened), common, nil
+}
+
+// searchComparisonInRepo searches the lines changed between the merge base of
+// base and head, and head in a repo.
+func searchComparisonInRepo(ctx context.Context, repoRev *search.RepositoryRevisions, base, head string, info *search.TextPatternInfo) (results []*commitSearchResultResolver, limitHit bool, err error) {
+	repo := repoRev.GitserverRepo()
+	// Do not trigger a repo-updater lookup (see searchFilesInRepo).
+	baseCommit, err := git.ResolveRevision(ctx, repo, nil, base, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, false, err
+	}
+	headCommit, err := git.ResolveRevision(ctx, repo, nil, head, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, false, err
+	}
+
+	fileMatches, limitHit, err := git.ComparisonDiffSearch(ctx, repo, git.ComparisonDiffSearchOptions{
+		Base: baseCommit,
+		Head: headCommit,
+		Query: git.TextSearchOptions{
+			Pattern:         info.Pattern,
+			IsRegExp:        info.IsRegExp,
+			IsCaseSensitive: info.IsCaseSensitive,
+		},
+		Paths: git.PathOptions{
+			IncludePatterns: info.IncludePatterns,
+			ExcludePattern:  info.ExcludePattern,
+			IsCaseSensitive: info.PathPatternsAreCaseSensitive,
+			IsRegExp:        info.PathPatternsAreRegExps,
+		},
+		MaxFiles: int(info.FileMatchLimit),
+	})
+	if err != nil || len(fileMatches) == 0 {
+		return nil, limitHit, err
+	}
+
+	commit, err := git.GetCommit(ctx, repo, nil, headCommit)
+	if err != nil {
+		return nil, false, err
+	}
+	repoResolver := &RepositoryResolver{repo: repoRev.Repo}
+	commitResolver := toGitCommitResolver(repoResolver, commit)
+
+	comparisonURL := repoResolver.URL() + "/-/compare/" + url.PathEscape(base) + "..." + url.PathEscape(head)
+	label := fmt.Sprintf("[%s](%s) › [%s...%s](%s)", displayRepoName(repoResolver.Name()), repoResolver.URL(), base, head, comparisonURL)
+	detail := fmt.Sprintf("[`%s...%s`](%s)", shortCommitID(baseCommit), shortCommitID(headCommit), comparisonURL)
+
+	results = make([]*commitSearchResultResolver, len(fileMatches))
+	for i, fm := range fileMatches {
+		fileDiff := &FileDiffResolver{FileDiff: fm.FileDiff}
+		path := fm.FileDiff.NewName
+		if diffPathOrNull(path) == nil {
+			path = fm.FileDiff.OrigName
+		}
+		fileURL := comparisonURL + "#diff-" + fileDiff.InternalID()
+
+		highlights := fromVCSHighlights(fm.Highlights)
+		matchBody, matchHighlights := cleanDiffPreview(fromVCSHighlights(fm.Highlights), fm.Raw)
+		results[i] = &commitSearchResultResolver{
+			commit:      commitResolver,
+			diffPreview: &highlightedString{value: fm.Raw, highlights: highlights},
+			icon:        commitIcon,
+			label:       fmt.Sprintf("%s: [%s](%s)", label, path, fileURL),
+			url:         fileURL,
+			detail:      detail,
+			matches:     []*searchResultMatchResolver{{body: matchBody, highlights: matchHighlights, url: fileURL}},
+		}
+	}
+	return results, limitHit, nil
+}
+
+// shortCommitID returns the abbreviated form of a commit ID, as displayed in
+// search results.
+func shortCommitID(commit api.CommitID) string {
+	if len(commit) > 7 {
+		return string(commit[:7])
+	}
+	return string(commit)
+}
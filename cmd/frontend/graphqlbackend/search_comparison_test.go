@@ -0,0 +1,95 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestSearchComparisonInRepo(t *testing.T) {
+	const (
+		baseCommit = api.CommitID("1111111111111111111111111111111111111111")
+		headCommit = api.CommitID("2222222222222222222222222222222222222222")
+	)
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		switch spec {
+		case "v1.0":
+			return baseCommit, nil
+		case "v2.0":
+			return headCommit, nil
+		}
+		t.Fatalf("unexpected revision %q", spec)
+		return "", nil
+	}
+	git.Mocks.GetCommit = func(id api.CommitID) (*git.Commit, error) {
+		return &git.Commit{ID: id}, nil
+	}
+	const raw = "diff --git a.go a.go\nindex 1..2 100644\n--- a.go\n+++ a.go\n@@ -1,1 +1,1 @@\n-exec(a)\n+exec(b)\n"
+	git.Mocks.ComparisonDiffSearch = func(opt git.ComparisonDiffSearchOptions) ([]*git.ComparisonFileMatch, bool, error) {
+		if opt.Base != baseCommit || opt.Head != headCommit {
+			t.Errorf("got range %s...%s, want %s...%s", opt.Base, opt.Head, baseCommit, headCommit)
+		}
+		if want := (git.TextSearchOptions{Pattern: "exec", IsRegExp: true}); opt.Query != want {
+			t.Errorf("got query %+v, want %+v", opt.Query, want)
+		}
+		if opt.MaxFiles != 30 {
+			t.Errorf("got MaxFiles %d, want 30", opt.MaxFiles)
+		}
+		return []*git.ComparisonFileMatch{{
+			FileDiff:   &diff.FileDiff{OrigName: "a.go", NewName: "a.go"},
+			Raw:        raw,
+			Highlights: []git.Highlight{{Line: 6, Character: 1, Length: 4}, {Line: 7, Character: 1, Length: 4}},
+		}}, true, nil
+	}
+	defer git.ResetMocks()
+
+	repoRev := &search.RepositoryRevisions{Repo: &types.Repo{ID: 1, Name: "github.com/a/b"}}
+	results, limitHit, err := searchComparisonInRepo(context.Background(), repoRev, "v1.0", "v2.0", &search.TextPatternInfo{
+		Pattern:        "exec",
+		IsRegExp:       true,
+		FileMatchLimit: 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limitHit {
+		t.Error("expected limitHit")
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	r := results[0]
+
+	fileURL := "/github.com/a/b/-/compare/v1.0...v2.0#diff-" + (&FileDiffResolver{FileDiff: &diff.FileDiff{OrigName: "a.go", NewName: "a.go"}}).InternalID()
+	if r.URL() != fileURL {
+		t.Errorf("got URL %q, want %q", r.URL(), fileURL)
+	}
+	if want := "[a/b](/github.com/a/b) › [v1.0...v2.0](/github.com/a/b/-/compare/v1.0...v2.0): [a.go](" + fileURL + ")"; r.label != want {
+		t.Errorf("got label %q, want %q", r.label, want)
+	}
+	if want := "[`1111111...2222222`](/github.com/a/b/-/compare/v1.0...v2.0)"; r.detail != want {
+		t.Errorf("got detail %q, want %q", r.detail, want)
+	}
+	if r.Commit().OID() != GitObjectID(headCommit) {
+		t.Errorf("got commit %s, want %s", r.Commit().OID(), headCommit)
+	}
+	if r.diffPreview.value != raw {
+		t.Errorf("got diff preview %q, want %q", r.diffPreview.value, raw)
+	}
+
+	match := r.matches[0]
+	if !strings.HasPrefix(match.body, "```diff\na.go a.go\n@@") {
+		t.Errorf("got match body %q", match.body)
+	}
+	wantHighlights := []*highlightedRange{{line: 3, character: 1, length: 4}, {line: 4, character: 1, length: 4}}
+	if !reflect.DeepEqual(match.highlights, wantHighlights) {
+		t.Errorf("got highlights %+v, want %+v", match.highlights, wantHighlights)
+	}
+}
@@ -0,0 +1,152 @@
+package graphqlbackend
+
+import (
+	"context"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// addToSearchHistory saves the search in the search history of the current
+// user, unless search history is disabled in the site configuration or the
+// user opted out of it with the "search.saveHistory" setting. Failures are
+// logged and don't fail the search.
+func (r *searchResolver) addToSearchHistory(ctx context.Context, rr *SearchResultsResolver, duration time.Duration) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() || conf.SearchHistoryRetentionDays() == 0 {
+		return
+	}
+
+	settings, err := decodedViewerFinalSettings(ctx)
+	if err != nil {
+		log15.Warn("Could not save search history", "error", err)
+		return
+	}
+	if searchHistoryOptedOut(settings) {
+		return
+	}
+
+	entry := &types.SearchHistoryEntry{
+		UserID:      a.UID,
+		Query:       r.originalQuery,
+		PatternType: searchTypeName(r.patternType),
+		ResultCount: rr.MatchCount(),
+		Duration:    duration,
+	}
+	if r.versionContext != nil {
+		entry.VersionContext = *r.versionContext
+	}
+	if err := db.SearchHistory.Add(ctx, entry); err != nil {
+		log15.Warn("Could not save search history", "error", err)
+	}
+}
+
+// searchHistoryOptedOut reports whether the "search.saveHistory" setting
+// disables search history in the given settings.
+func searchHistoryOptedOut(settings *schema.Settings) bool {
+	return settings.SearchSaveHistory != nil && !*settings.SearchSaveHistory
+}
+
+// searchTypeName returns the name of the search type, as in the
+// SearchPatternType GraphQL enum.
+func searchTypeName(searchType query.SearchType) string {
+	switch searchType {
+	case query.SearchTypeLiteral:
+		return "literal"
+	case query.SearchTypeStructural:
+		return "structural"
+	default:
+		return "regexp"
+	}
+}
+
+func (r *UserResolver) SearchHistory(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+}) (*searchHistoryConnectionResolver, error) {
+	// 🚨 SECURITY: Search history can only be viewed by the user or site admin.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, r.user.ID); err != nil {
+		return nil, err
+	}
+	var opt *db.LimitOffset
+	args.ConnectionArgs.Set(&opt)
+	return &searchHistoryConnectionResolver{userID: r.user.ID, opt: opt}, nil
+}
+
+func (*schemaResolver) ClearSearchHistory(ctx context.Context, args *struct {
+	User graphql.ID
+}) (*EmptyResponse, error) {
+	userID, err := UnmarshalUserID(args.User)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Search history can only be cleared by the user or site admin.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := db.SearchHistory.DeleteByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+type searchHistoryConnectionResolver struct {
+	userID int32
+	opt    *db.LimitOffset
+}
+
+func (r *searchHistoryConnectionResolver) Nodes(ctx context.Context) ([]*searchHistoryEntryResolver, error) {
+	entries, err := db.SearchHistory.ListByUser(ctx, r.userID, r.opt)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*searchHistoryEntryResolver, len(entries))
+	for i, e := range entries {
+		resolvers[i] = &searchHistoryEntryResolver{entry: e}
+	}
+	return resolvers, nil
+}
+
+func (r *searchHistoryConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.SearchHistory.CountByUser(ctx, r.userID)
+	return int32(count), err
+}
+
+func (r *searchHistoryConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	count, err := db.SearchHistory.CountByUser(ctx, r.userID)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt != nil && count > r.opt.Limit), nil
+}
+
+type searchHistoryEntryResolver struct {
+	entry *types.SearchHistoryEntry
+}
+
+func (r *searchHistoryEntryResolver) Query() string { return r.entry.Query }
+
+func (r *searchHistoryEntryResolver) PatternType() string { return r.entry.PatternType }
+
+func (r *searchHistoryEntryResolver) VersionContext() *string {
+	if r.entry.VersionContext == "" {
+		return nil
+	}
+	return &r.entry.VersionContext
+}
+
+func (r *searchHistoryEntryResolver) ResultCount() int32 { return r.entry.ResultCount }
+
+func (r *searchHistoryEntryResolver) DurationMilliseconds() int32 {
+	return int32(r.entry.Duration.Milliseconds())
+}
+
+func (r *searchHistoryEntryResolver) CreatedAt() DateTime { return DateTime{Time: r.entry.CreatedAt} }
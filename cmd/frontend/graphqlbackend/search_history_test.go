@@ -0,0 +1,203 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestSearchResolver_addToSearchHistory(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+	intPtr := func(i int) *int { return &i }
+	versionContext := "release"
+
+	tests := map[string]struct {
+		ctx           context.Context
+		retentionDays *int
+		saveHistory   *bool
+		wantAdded     bool
+	}{
+		"authenticated user": {
+			ctx:       actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			wantAdded: true,
+		},
+		"anonymous user": {
+			ctx: context.Background(),
+		},
+		"search history disabled": {
+			ctx:           actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			retentionDays: intPtr(0),
+		},
+		"user opted in": {
+			ctx:         actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			saveHistory: boolPtr(true),
+			wantAdded:   true,
+		},
+		"user opted out": {
+			ctx:         actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			saveHistory: boolPtr(false),
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			defer resetMocks()
+			conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{SearchHistoryRetentionDays: test.retentionDays}})
+			defer conf.Mock(nil)
+			mockDecodedViewerFinalSettings = &schema.Settings{SearchSaveHistory: test.saveHistory}
+			defer func() { mockDecodedViewerFinalSettings = nil }()
+
+			var added *types.SearchHistoryEntry
+			db.Mocks.SearchHistory.Add = func(ctx context.Context, entry *types.SearchHistoryEntry) error {
+				added = entry
+				return nil
+			}
+			var deletedUserID int32
+			db.Mocks.SearchHistory.DeleteByUser = func(ctx context.Context, userID int32) error {
+				deletedUserID = userID
+				return nil
+			}
+
+			r := &searchResolver{
+				originalQuery:  "foo repo:bar",
+				patternType:    query.SearchTypeStructural,
+				versionContext: &versionContext,
+			}
+			rr := &SearchResultsResolver{SearchResults: []SearchResultResolver{
+				&FileMatchResolver{MatchCount: 2},
+				&FileMatchResolver{MatchCount: 3},
+			}}
+			r.addToSearchHistory(test.ctx, rr, 1500*time.Millisecond)
+
+			if test.wantAdded {
+				if added == nil {
+					t.Fatal("search was not added to the search history")
+				}
+				want := types.SearchHistoryEntry{
+					UserID:         1,
+					Query:          "foo repo:bar",
+					PatternType:    "structural",
+					VersionContext: "release",
+					ResultCount:    5,
+					Duration:       1500 * time.Millisecond,
+				}
+				if *added != want {
+					t.Errorf("got entry %+v, want %+v", *added, want)
+				}
+			} else if added != nil {
+				t.Errorf("got entry %+v added to the search history, want none", *added)
+			}
+
+			if deletedUserID != 0 {
+				t.Errorf("got search history of user %d deleted by a search, want none deleted", deletedUserID)
+			}
+		})
+	}
+}
+
+func TestSettingsCreateIfUpToDate_searchHistoryOptOut(t *testing.T) {
+	tests := map[string]struct {
+		subject     *settingsSubject
+		oldSettings string
+		newSettings string
+		wantDeleted bool
+	}{
+		"opted out": {
+			oldSettings: `{}`,
+			newSettings: `{"search.saveHistory": false}`,
+			wantDeleted: true,
+		},
+		"opted out of enabled search history": {
+			oldSettings: `{"search.saveHistory": true}`,
+			newSettings: `{"search.saveHistory": false}`,
+			wantDeleted: true,
+		},
+		"already opted out": {
+			oldSettings: `{"search.saveHistory": false}`,
+			newSettings: `{"search.saveHistory": false, "search.contextLines": 3}`,
+		},
+		"opted in": {
+			oldSettings: `{"search.saveHistory": false}`,
+			newSettings: `{}`,
+		},
+		"other setting": {
+			oldSettings: `{}`,
+			newSettings: `{"search.contextLines": 3}`,
+		},
+		"org settings": {
+			subject:     &settingsSubject{org: &OrgResolver{org: &types.Org{ID: 1}}},
+			oldSettings: `{}`,
+			newSettings: `{"search.saveHistory": false}`,
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			defer resetMocks()
+			db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+				return &types.User{ID: id}, nil
+			}
+			db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+				return &types.User{ID: 1, SiteAdmin: true}, nil
+			}
+			db.Mocks.Settings.GetLatest = func(context.Context, api.SettingsSubject) (*api.Settings, error) {
+				return &api.Settings{ID: 1, Contents: test.oldSettings}, nil
+			}
+			db.Mocks.Settings.CreateIfUpToDate = func(ctx context.Context, subject api.SettingsSubject, lastID, authorUserID *int32, contents string) (*api.Settings, error) {
+				return &api.Settings{ID: 2, Contents: contents}, nil
+			}
+			var deletedUserID int32
+			db.Mocks.SearchHistory.DeleteByUser = func(ctx context.Context, userID int32) error {
+				deletedUserID = userID
+				return nil
+			}
+
+			subject := test.subject
+			if subject == nil {
+				subject = &settingsSubject{user: &UserResolver{user: &types.User{ID: 1}}}
+			}
+			lastID := int32(1)
+			ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+			if _, err := settingsCreateIfUpToDate(ctx, subject, &lastID, 1, test.newSettings); err != nil {
+				t.Fatal(err)
+			}
+			if deleted := deletedUserID != 0; deleted != test.wantDeleted {
+				t.Errorf("got search history deleted %v, want %v", deleted, test.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestUser_SearchHistory(t *testing.T) {
+	defer resetMocks()
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: 2}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+
+	_, err := (&UserResolver{user: &types.User{ID: 1}}).SearchHistory(context.Background(), &struct {
+		graphqlutil.ConnectionArgs
+	}{})
+	if err == nil {
+		t.Error("got nil error listing the search history of another user, want non-nil")
+	}
+
+	db.Mocks.SearchHistory.DeleteByUser = func(ctx context.Context, userID int32) error {
+		t.Errorf("search history of user %d deleted by another user", userID)
+		return nil
+	}
+	_, err = (&schemaResolver{}).ClearSearchHistory(context.Background(), &struct{ User graphql.ID }{User: MarshalUserID(1)})
+	if err == nil {
+		t.Error("got nil error clearing the search history of another user, want non-nil")
+	}
+}
@@ -27,6 +27,7 @@ func searchRepositories(ctx context.Context, args *search.TextParameters, limit
 	fieldWhitelist := map[string]struct{}{
 		query.FieldRepo:               {},
 		query.FieldRepoGroup:          {},
+		query.FieldRepoSet:            {},
 		query.FieldType:               {},
 		query.FieldDefault:            {},
 		query.FieldIndex:              {},
@@ -35,10 +36,12 @@ func searchRepositories(ctx context.Context, args *search.TextParameters, limit
 		query.FieldTimeout:            {},
 		query.FieldFork:               {},
 		query.FieldArchived:           {},
+		query.FieldSubmodules:         {},
 		query.FieldVisibility:         {},
 		query.FieldCase:               {},
 		query.FieldRepoHasFile:        {},
 		query.FieldRepoHasCommitAfter: {},
+		query.FieldContext:            {},
 	}
 	// Don't return repo results if the search contains fields that aren't on the whitelist.
 	// Matching repositories based whether they contain files at a certain path (etc.) is not yet implemented.
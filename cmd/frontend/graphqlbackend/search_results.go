@@ -15,9 +15,9 @@ import (
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
-	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/usagestats"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/inventory"
 	"github.com/src-d/enry/v2"
 
 	"github.com/hashicorp/go-multierror"
@@ -818,6 +818,9 @@ func (r *searchResolver) evaluateOr(ctx context.Context, scopeParameters []query
 	if countStr != "" {
 		wantCount, _ = strconv.Atoi(countStr) // Invariant: count is validated.
 	}
+	if r.resultLimit > 0 {
+		wantCount = int(r.resultLimit)
+	}
 
 	result, err := r.evaluatePatternExpression(ctx, scopeParameters, operands[0])
 	if err != nil {
@@ -905,6 +908,15 @@ func (r *searchResolver) evaluate(ctx context.Context, q []query.Node) (*SearchR
 }
 
 func (r *searchResolver) Results(ctx context.Context) (*SearchResultsResolver, error) {
+	start := time.Now()
+	rr, err := r.results(ctx)
+	if err == nil && r.saveHistory {
+		r.addToSearchHistory(ctx, rr, time.Since(start))
+	}
+	return rr, err
+}
+
+func (r *searchResolver) results(ctx context.Context) (*SearchResultsResolver, error) {
 	switch q := r.query.(type) {
 	case *query.OrdinaryQuery:
 		return r.evaluateLeaf(ctx)
@@ -1204,6 +1216,14 @@ func getPatternInfo(q query.QueryInfo, opts *getPatternInfoOptions) (*search.Tex
 
 	languages, _ := q.StringValues(query.FieldLang)
 
+	var contextLines int
+	if value, _ := q.StringValue(query.FieldContext); value != "" {
+		contextLines, err = query.ParseContextLines(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	patternInfo := &search.TextPatternInfo{
 		IsRegExp:                     isRegExp,
 		IsStructuralPat:              isStructuralPat,
@@ -1215,8 +1235,10 @@ func getPatternInfo(q query.QueryInfo, opts *getPatternInfoOptions) (*search.Tex
 		FilePatternsReposMustExclude: filePatternsReposMustExclude,
 		PathPatternsAreRegExps:       true,
 		Languages:                    languages,
+		LangIncludePatterns:          langIncludePatterns,
 		PathPatternsAreCaseSensitive: q.IsCaseSensitive(),
 		CombyRule:                    strings.Join(combyRule, ""),
+		ContextLines:                 int32(contextLines),
 	}
 	if len(excludePatterns) > 0 {
 		patternInfo.ExcludePattern = unionRegExps(excludePatterns)
@@ -1293,6 +1315,10 @@ func (r *searchResolver) determineResultTypes(args search.TextParameters, forceO
 		resultTypes = []string{forceOnlyResultType}
 	} else if len(r.query.Values(query.FieldReplace)) > 0 {
 		resultTypes = []string{"codemod"}
+	} else if len(r.query.Values(query.FieldRev)) > 0 {
+		// Only the changed lines of the comparison are searched. "type:"
+		// is rejected together with "rev:" by query validation.
+		resultTypes = []string{"comparison"}
 	} else {
 		resultTypes, _ = r.query.StringValues(query.FieldType)
 		if len(resultTypes) == 0 {
@@ -1341,7 +1367,7 @@ func alertOnSearchLimit(resultTypes []string, args *search.TextParameters) ([]st
 		if len(resultTypes) == 1 {
 			resultType := resultTypes[0]
 			switch resultType {
-			case "commit", "diff":
+			case "commit", "diff", "comparison":
 				if _, afterPresent := args.Query.Fields()["after"]; afterPresent {
 					break
 				}
@@ -1668,6 +1694,30 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					commonMu.Unlock()
 				}
 			})
+		case "comparison":
+			wg := waitGroup(true)
+			wg.Add(1)
+			goroutine.Go(func() {
+				defer wg.Done()
+
+				comparisonResults, comparisonCommon, err := searchComparisonsInRepos(ctx, &args)
+				// Timeouts are reported through searchResultsCommon so don't report an error for them
+				if err != nil && !isContextError(ctx, err) {
+					multiErrMu.Lock()
+					multiErr = multierror.Append(multiErr, errors.Wrap(err, "comparison search failed"))
+					multiErrMu.Unlock()
+				}
+				if comparisonResults != nil {
+					resultsMu.Lock()
+					results = append(results, comparisonResults...)
+					resultsMu.Unlock()
+				}
+				if comparisonCommon != nil {
+					commonMu.Lock()
+					common.update(*comparisonCommon)
+					commonMu.Unlock()
+				}
+			})
 		case "codemod":
 			wg := waitGroup(true)
 			wg.Add(1)
@@ -392,6 +392,7 @@ func TestSearchResolver_getPatternInfo(t *testing.T) {
 			PathPatternsAreRegExps: true,
 			IncludePatterns:        []string{`\.graphql$|\.gql$|\.graphqls$`},
 			Languages:              []string{"graphql"},
+			LangIncludePatterns:    []string{`\.graphql$|\.gql$|\.graphqls$`},
 		},
 		"p lang:graphql file:f": {
 			Pattern:                "p",
@@ -399,6 +400,7 @@ func TestSearchResolver_getPatternInfo(t *testing.T) {
 			PathPatternsAreRegExps: true,
 			IncludePatterns:        []string{"f", `\.graphql$|\.gql$|\.graphqls$`},
 			Languages:              []string{"graphql"},
+			LangIncludePatterns:    []string{`\.graphql$|\.gql$|\.graphqls$`},
 		},
 		"p -lang:graphql file:f": {
 			Pattern:                "p",
@@ -0,0 +1,122 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// maxSubmoduleSearchRepos is the maximum number of searched repositories whose
+// submodules are added to a search with submodules:yes.
+const maxSubmoduleSearchRepos = 200
+
+// withSubmodules returns repoRevs with the submodules of the searched
+// revisions added, at the commits the revisions pin them to, so that
+// submodule content is searched too. Only direct submodules whose
+// repository is known to Sourcegraph are added.
+//
+// Ref globs are not expanded, and failures to list the submodules of a
+// repository are logged and otherwise ignored.
+func withSubmodules(ctx context.Context, repoRevs []*search.RepositoryRevisions) []*search.RepositoryRevisions {
+	superprojects := repoRevs
+	if len(superprojects) > maxSubmoduleSearchRepos {
+		superprojects = superprojects[:maxSubmoduleSearchRepos]
+	}
+
+	type pinned struct {
+		repo   *types.Repo
+		commit api.CommitID
+	}
+	var (
+		mu    sync.Mutex
+		found []pinned
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, 10)
+	)
+	for _, rr := range superprojects {
+		for _, rev := range rr.Revs {
+			if rev.RefGlob != "" || rev.ExcludeRefGlob != "" {
+				continue
+			}
+			wg.Add(1)
+			go func(repo *types.Repo, rev string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				submodules, err := listSubmodules(ctx, repo, rev)
+				if err != nil {
+					log15.Warn("Failed to list submodules for search", "repo", repo.Name, "rev", rev, "error", err)
+					return
+				}
+				for _, sm := range submodules {
+					subrepo, err := resolveSubmoduleRepo(ctx, repo.Name, sm.URL)
+					if err != nil {
+						log15.Warn("Failed to resolve submodule repository for search", "repo", repo.Name, "submodule", sm.Path, "error", err)
+						continue
+					}
+					if subrepo == nil {
+						continue
+					}
+					mu.Lock()
+					found = append(found, pinned{repo: subrepo, commit: sm.CommitID})
+					mu.Unlock()
+				}
+			}(rr.Repo, rev.RevSpec)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].repo.Name != found[j].repo.Name {
+			return found[i].repo.Name < found[j].repo.Name
+		}
+		return found[i].commit < found[j].commit
+	})
+
+	byName := make(map[api.RepoName]*search.RepositoryRevisions, len(repoRevs))
+	for _, rr := range repoRevs {
+		byName[rr.Repo.Name] = rr
+	}
+	for _, p := range found {
+		rev := search.RevisionSpecifier{RevSpec: string(p.commit)}
+		rr, ok := byName[p.repo.Name]
+		if !ok {
+			rr = &search.RepositoryRevisions{Repo: p.repo}
+			byName[p.repo.Name] = rr
+			repoRevs = append(repoRevs, rr)
+		}
+		if !hasRev(rr.Revs, rev) {
+			rr.Revs = append(rr.Revs, rev)
+		}
+	}
+	return repoRevs
+}
+
+func listSubmodules(ctx context.Context, repo *types.Repo, rev string) ([]git.Submodule, error) {
+	cachedRepo, err := backend.CachedGitRepo(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := git.ResolveRevision(ctx, *cachedRepo, nil, rev, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, err
+	}
+	return git.Submodules(ctx, *cachedRepo, commit)
+}
+
+func hasRev(revs []search.RevisionSpecifier, rev search.RevisionSpecifier) bool {
+	for _, r := range revs {
+		if r == rev {
+			return true
+		}
+	}
+	return false
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/pkg/errors"
 	lsp "github.com/sourcegraph/go-lsp"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -68,8 +69,28 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 	defer cancelAll()
 
 	common = &searchResultsCommon{partial: make(map[api.RepoName]struct{})}
+
+	// The default branch of repositories in the global symbol index is
+	// searched there, and the other repositories are searched with zoekt or
+	// the symbols service.
 	var (
-		searcherRepos = args.Repos
+		repos       = args.Repos
+		globalRepos []*search.RepositoryRevisions
+	)
+	if conf.GlobalSymbolIndexEnabled() {
+		globalRepos, repos, err = globalSymbolIndexedRepos(ctx, args.Repos)
+		if err != nil {
+			tr.LogFields(otlog.String("globalSymbolIndexErr", err.Error()))
+			if ctx.Err() == nil {
+				log15.Warn("globalSymbolIndexedRepos failed", "error", err)
+			}
+			globalRepos, repos, err = nil, args.Repos, nil
+		}
+		tr.LogFields(otlog.Int("global-symbol-indexed-repos", len(globalRepos)))
+	}
+
+	var (
+		searcherRepos = repos
 		zoektRepos    []*search.RepositoryRevisions
 	)
 
@@ -77,7 +98,7 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 		filter := func(repo *zoekt.Repository) bool {
 			return repo.HasSymbols
 		}
-		zoektRepos, searcherRepos, err = zoektIndexedRepos(ctx, args.Zoekt, args.Repos, filter)
+		zoektRepos, searcherRepos, err = zoektIndexedRepos(ctx, args.Zoekt, repos, filter)
 		if err != nil {
 			// Don't hard fail if index is not available yet.
 			tr.LogFields(otlog.String("indexErr", err.Error()))
@@ -157,6 +178,62 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 		}
 	}
 
+	// addRepoSymbols adds the results of searching a repository with the
+	// symbols service. The caller must hold mu.
+	addRepoSymbols := func(repoRevs *search.RepositoryRevisions, repoSymbols []*FileMatchResolver, repoErr error) {
+		limitHit := symbolCount(res) > limit
+		repoErr = handleRepoSearchResult(common, repoRevs, limitHit, false, repoErr)
+		if repoErr != nil {
+			if ctx.Err() == nil || errors.Cause(repoErr) != ctx.Err() {
+				// Only record error if it's not directly caused by a context error.
+				run.Error(repoErr)
+			}
+		} else {
+			common.searched = append(common.searched, repoRevs.Repo)
+		}
+		if repoSymbols != nil {
+			addMatches(repoSymbols)
+		}
+	}
+
+	if len(globalRepos) > 0 {
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			matches, searchErr := searchGlobalSymbols(ctx, globalRepos, args.PatternInfo, limit)
+			if searchErr != nil {
+				tr.LogFields(otlog.String("globalSymbolsErr", searchErr.Error()))
+			}
+			if db.IsGlobalSymbolsRegexpError(searchErr) {
+				// Postgres can't search for the pattern, so search the
+				// repositories with the symbols service instead.
+				for _, repoRevs := range globalRepos {
+					if ctx.Err() != nil {
+						break
+					}
+					repoSymbols, repoErr := searchSymbolsInRepo(ctx, repoRevs, args.PatternInfo, args.Query, limit)
+					mu.Lock()
+					addRepoSymbols(repoRevs, repoSymbols, repoErr)
+					mu.Unlock()
+				}
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if searchErr != nil {
+				if ctx.Err() == nil || errors.Cause(searchErr) != ctx.Err() {
+					run.Error(searchErr)
+				}
+				return
+			}
+			for _, repo := range globalRepos {
+				common.searched = append(common.searched, repo.Repo)
+				common.indexed = append(common.indexed, repo.Repo)
+			}
+			addMatches(matches)
+		})
+	}
+
 	run.Acquire()
 	goroutine.Go(func() {
 		defer run.Release()
@@ -200,19 +277,7 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 			}
 			mu.Lock()
 			defer mu.Unlock()
-			limitHit := symbolCount(res) > limit
-			repoErr = handleRepoSearchResult(common, repoRevs, limitHit, false, repoErr)
-			if repoErr != nil {
-				if ctx.Err() == nil || errors.Cause(repoErr) != ctx.Err() {
-					// Only record error if it's not directly caused by a context error.
-					run.Error(repoErr)
-				}
-			} else {
-				common.searched = append(common.searched, repoRevs.Repo)
-			}
-			if repoSymbols != nil {
-				addMatches(repoSymbols)
-			}
+			addRepoSymbols(repoRevs, repoSymbols, repoErr)
 		})
 	}
 	err = run.Wait()
@@ -289,22 +354,31 @@ func searchSymbolsInRepo(ctx context.Context, repoRevs *search.RepositoryRevisio
 		// Ask for limit + 1 so we can detect whether there are more results than the limit.
 		First: limit + 1,
 	})
-	fileMatchesByURI := make(map[string]*FileMatchResolver)
-	fileMatches := make([]*FileMatchResolver, 0)
-	for _, symbol := range symbols {
+	symbolResults := make([]*searchSymbolResult, len(symbols))
+	for i, symbol := range symbols {
 		commit := &GitCommitResolver{
 			repo:     &RepositoryResolver{repo: repoRevs.Repo},
 			oid:      GitObjectID(commitID),
 			inputRev: &inputRev,
 			// NOTE: Not all fields are set, for performance.
 		}
-		symbolRes := &searchSymbolResult{
+		symbolResults[i] = &searchSymbolResult{
 			symbol:  symbol,
 			baseURI: baseURI,
 			lang:    strings.ToLower(symbol.Language),
 			commit:  commit,
 		}
-		uri := makeFileMatchURIFromSymbol(symbolRes, inputRev)
+	}
+	return symbolFileMatches(symbolResults), err
+}
+
+// symbolFileMatches groups symbol results by file, in the order of their
+// first symbol.
+func symbolFileMatches(symbolResults []*searchSymbolResult) []*FileMatchResolver {
+	fileMatchesByURI := make(map[string]*FileMatchResolver)
+	fileMatches := make([]*FileMatchResolver, 0)
+	for _, symbolRes := range symbolResults {
+		uri := makeFileMatchURIFromSymbol(symbolRes, *symbolRes.commit.inputRev)
 		if fileMatch, ok := fileMatchesByURI[uri]; ok {
 			fileMatch.symbols = append(fileMatch.symbols, symbolRes)
 		} else {
@@ -321,7 +395,156 @@ func searchSymbolsInRepo(ctx context.Context, repoRevs *search.RepositoryRevisio
 			fileMatches = append(fileMatches, fileMatch)
 		}
 	}
-	return fileMatches, err
+	return fileMatches
+}
+
+// isDefaultBranchOnly reports whether only the default branch of the
+// repository is searched.
+func isDefaultBranchOnly(repoRevs *search.RepositoryRevisions) bool {
+	if len(repoRevs.Revs) != 1 || len(repoRevs.RevSpecs()) != 1 {
+		return false
+	}
+	rev := repoRevs.Revs[0].RevSpec
+	return rev == "" || rev == "HEAD"
+}
+
+// globalSymbolIndexedRepos splits repos into the repositories which are
+// searched in the global symbol index, and the others. A repository is
+// searched in the index if only its default branch is searched, all of its
+// symbols are indexed, and the indexed commit is still the head of the
+// default branch. Otherwise it is searched with the symbols service, which
+// returns the symbols of the current commit.
+func globalSymbolIndexedRepos(ctx context.Context, repos []*search.RepositoryRevisions) (indexed, unindexed []*search.RepositoryRevisions, err error) {
+	var repoIDs []api.RepoID
+	for _, repoRevs := range repos {
+		if isDefaultBranchOnly(repoRevs) {
+			repoIDs = append(repoIDs, repoRevs.Repo.ID)
+		}
+	}
+	if len(repoIDs) == 0 {
+		return nil, repos, nil
+	}
+
+	indexes, err := db.GlobalSymbols.ListIndexes(ctx, repoIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	indexedCommits := make(map[api.RepoID]api.CommitID, len(indexes))
+	for _, index := range indexes {
+		if !index.LimitHit {
+			indexedCommits[index.RepoID] = index.Commit
+		}
+	}
+
+	var (
+		run     = parallel.NewRun(conf.SearchSymbolsParallelism())
+		mu      sync.Mutex
+		current = make(map[api.RepoID]bool, len(indexedCommits))
+	)
+	for _, repoRevs := range repos {
+		indexedCommit, ok := indexedCommits[repoRevs.Repo.ID]
+		if !ok || !isDefaultBranchOnly(repoRevs) {
+			continue
+		}
+		repoRevs := repoRevs
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			// Do not trigger a repo-updater lookup (see searchSymbolsInRepo).
+			head, err := git.ResolveRevision(ctx, repoRevs.GitserverRepo(), nil, "HEAD", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+			if err != nil {
+				// The symbols service reports the error.
+				return
+			}
+			mu.Lock()
+			current[repoRevs.Repo.ID] = head == indexedCommit
+			mu.Unlock()
+		})
+	}
+	if err := run.Wait(); err != nil {
+		return nil, nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+
+	for _, repoRevs := range repos {
+		if isDefaultBranchOnly(repoRevs) && current[repoRevs.Repo.ID] {
+			indexed = append(indexed, repoRevs)
+		} else {
+			unindexed = append(unindexed, repoRevs)
+		}
+	}
+	return indexed, unindexed, nil
+}
+
+// searchGlobalSymbols searches the symbols of the default branch of repos in
+// the global symbol index. The results are at the indexed commit of each
+// repository.
+func searchGlobalSymbols(ctx context.Context, repos []*search.RepositoryRevisions, patternInfo *search.TextPatternInfo, limit int) (res []*FileMatchResolver, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "Search global symbols")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("repos", len(repos))
+
+	repoRevsByID := make(map[api.RepoID]*search.RepositoryRevisions, len(repos))
+	repoIDs := make([]api.RepoID, len(repos))
+	for i, repoRevs := range repos {
+		repoRevsByID[repoRevs.Repo.ID] = repoRevs
+		repoIDs[i] = repoRevs.Repo.ID
+	}
+
+	symbols, err := db.GlobalSymbols.Search(ctx, db.GlobalSymbolsSearchOptions{
+		RepoIDs:         repoIDs,
+		Query:           patternInfo.Pattern,
+		IsCaseSensitive: patternInfo.IsCaseSensitive,
+		IncludePatterns: patternInfo.IncludePatterns,
+		ExcludePattern:  patternInfo.ExcludePattern,
+		// Ask for limit + 1 so we can detect whether there are more results than the limit.
+		Limit: limit + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	symbolResults := make([]*searchSymbolResult, 0, len(symbols))
+	for _, sym := range symbols {
+		repoRevs, ok := repoRevsByID[sym.RepoID]
+		if !ok {
+			continue
+		}
+		inputRev := repoRevs.RevSpecs()[0]
+		baseURI, err := gituri.Parse("git://" + string(repoRevs.Repo.Name) + "?" + url.QueryEscape(inputRev))
+		if err != nil {
+			return nil, err
+		}
+		symbolResults = append(symbolResults, &searchSymbolResult{
+			symbol: protocol.Symbol{
+				Name:       sym.Name,
+				Path:       sym.Path,
+				Line:       sym.Line,
+				Kind:       sym.Kind,
+				Language:   sym.Language,
+				Parent:     sym.Parent,
+				ParentKind: sym.ParentKind,
+				Signature:  sym.Signature,
+				Pattern:    sym.Pattern,
+			},
+			baseURI: baseURI,
+			lang:    strings.ToLower(sym.Language),
+			commit: &GitCommitResolver{
+				repo:     &RepositoryResolver{repo: repoRevs.Repo},
+				oid:      GitObjectID(sym.Commit),
+				inputRev: &inputRev,
+			},
+		})
+	}
+	return symbolFileMatches(symbolResults), nil
 }
 
 // makeFileMatchURIFromSymbol makes a git://repo?rev#path URI from a symbol
@@ -1,14 +1,19 @@
 package graphqlbackend
 
 import (
+	"context"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gituri"
+	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
@@ -257,3 +262,103 @@ func TestLimitingSymbolResults(t *testing.T) {
 		}
 	})
 }
+
+func TestGlobalSymbolIndexedRepos(t *testing.T) {
+	repoRevs := func(id api.RepoID, revs ...search.RevisionSpecifier) *search.RepositoryRevisions {
+		return &search.RepositoryRevisions{Repo: &types.Repo{ID: id, Name: api.RepoName(fmt.Sprintf("r%d", id))}, Revs: revs}
+	}
+	defaultBranch := repoRevs(1, search.RevisionSpecifier{RevSpec: ""})
+	head := repoRevs(2, search.RevisionSpecifier{RevSpec: "HEAD"})
+	otherBranch := repoRevs(3, search.RevisionSpecifier{RevSpec: "feature"})
+	multipleRevs := repoRevs(4, search.RevisionSpecifier{RevSpec: ""}, search.RevisionSpecifier{RevSpec: "feature"})
+	refGlob := repoRevs(5, search.RevisionSpecifier{RefGlob: "refs/heads/*"})
+	notIndexed := repoRevs(6, search.RevisionSpecifier{RevSpec: ""})
+	limitHit := repoRevs(7, search.RevisionSpecifier{RevSpec: ""})
+	headMoved := repoRevs(8, search.RevisionSpecifier{RevSpec: ""})
+
+	db.Mocks.GlobalSymbols.ListIndexes = func(ctx context.Context, repoIDs []api.RepoID) ([]*types.GlobalSymbolsIndex, error) {
+		if want := []api.RepoID{1, 2, 6, 7, 8}; !reflect.DeepEqual(repoIDs, want) {
+			t.Errorf("got repo IDs %v, want %v", repoIDs, want)
+		}
+		return []*types.GlobalSymbolsIndex{
+			{RepoID: 1, Commit: "c1"},
+			{RepoID: 2, Commit: "c1"},
+			{RepoID: 7, Commit: "c1", LimitHit: true},
+			{RepoID: 8, Commit: "c0"},
+		}, nil
+	}
+	defer func() { db.Mocks.GlobalSymbols = db.MockGlobalSymbols{} }()
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != "HEAD" || opt == nil || !opt.NoEnsureRevision {
+			t.Errorf("got ResolveRevision(%q, %+v), want HEAD without ensuring the revision", spec, opt)
+		}
+		return "c1", nil
+	}
+	defer git.ResetMocks()
+
+	indexed, unindexed, err := globalSymbolIndexedRepos(context.Background(), []*search.RepositoryRevisions{
+		defaultBranch, head, otherBranch, multipleRevs, refGlob, notIndexed, limitHit, headMoved,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*search.RepositoryRevisions{defaultBranch, head}; !reflect.DeepEqual(indexed, want) {
+		t.Errorf("got indexed %v, want %v", indexed, want)
+	}
+	if want := []*search.RepositoryRevisions{otherBranch, multipleRevs, refGlob, notIndexed, limitHit, headMoved}; !reflect.DeepEqual(unindexed, want) {
+		t.Errorf("got unindexed %v, want %v", unindexed, want)
+	}
+}
+
+func TestSearchGlobalSymbols(t *testing.T) {
+	repo := &search.RepositoryRevisions{Repo: &types.Repo{ID: 1, Name: "github.com/a/b"}, Revs: []search.RevisionSpecifier{{RevSpec: ""}}}
+
+	// indexedCommit is the commit of the default branch of the repository
+	// when it was last indexed.
+	indexedCommit := api.CommitID("c1")
+	db.Mocks.GlobalSymbols.Search = func(ctx context.Context, opt db.GlobalSymbolsSearchOptions) ([]*types.GlobalSymbol, error) {
+		want := db.GlobalSymbolsSearchOptions{RepoIDs: []api.RepoID{1}, Query: "foo", IncludePatterns: []string{`\.go$`}, Limit: 11}
+		if !reflect.DeepEqual(opt, want) {
+			t.Errorf("got options %+v, want %+v", opt, want)
+		}
+		return []*types.GlobalSymbol{
+			{RepoID: 1, Commit: indexedCommit, Name: "Foo", Kind: "func", Path: "a.go", Line: 3, Language: "Go"},
+			{RepoID: 1, Commit: indexedCommit, Name: "foo", Kind: "var", Path: "a.go", Line: 5, Language: "Go"},
+			{RepoID: 1, Commit: indexedCommit, Name: "fooBar", Kind: "func", Path: "b.go", Line: 1, Language: "Go"},
+		}, nil
+	}
+	defer func() { db.Mocks.GlobalSymbols = db.MockGlobalSymbols{} }()
+
+	run := func() []*FileMatchResolver {
+		t.Helper()
+		res, err := searchGlobalSymbols(context.Background(), []*search.RepositoryRevisions{repo}, &search.TextPatternInfo{
+			Pattern:         "foo",
+			IncludePatterns: []string{`\.go$`},
+		}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := run()
+	if len(res) != 2 {
+		t.Fatalf("got %d file matches, want 2", len(res))
+	}
+	if res[0].uri != "git://github.com/a/b#a.go" || len(res[0].symbols) != 2 || res[0].symbols[1].symbol.Kind != "var" {
+		t.Errorf("got file match %q with symbols %+v", res[0].uri, res[0].symbols)
+	}
+	if res[1].uri != "git://github.com/a/b#b.go" || len(res[1].symbols) != 1 {
+		t.Errorf("got file match %q with symbols %+v", res[1].uri, res[1].symbols)
+	}
+	if res[0].CommitID != "c1" || res[0].symbols[0].commit.oid != "c1" {
+		t.Errorf("got commit %s, want c1", res[0].CommitID)
+	}
+
+	// When the default branch moves and the repository is reindexed, the
+	// results are at the new commit.
+	indexedCommit = "c2"
+	if res := run(); res[0].CommitID != "c2" || res[0].symbols[0].commit.oid != "c2" {
+		t.Errorf("got commit %s after reindexing, want c2", res[0].CommitID)
+	}
+}
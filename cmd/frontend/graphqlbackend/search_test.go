@@ -15,6 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	querytypes "github.com/sourcegraph/sourcegraph/internal/search/query/types"
@@ -540,6 +541,20 @@ func TestVersionContext(t *testing.T) {
 	mockDecodedViewerFinalSettings = &schema.Settings{}
 	defer func() { mockDecodedViewerFinalSettings = nil }()
 
+	db.Mocks.VersionContexts.GetByName = func(ctx context.Context, name string) (*types.VersionContext, error) {
+		if name != "db-ctx" {
+			return nil, &errcode.Mock{Message: "version context not found", IsNotFound: true}
+		}
+		return &types.VersionContext{
+			ID:   1,
+			Name: "db-ctx",
+			Revisions: []*types.VersionContextRevision{
+				{Repo: "github.com/sourcegraph/foo", Rev: "v2.0.0"},
+			},
+		}, nil
+	}
+	defer func() { db.Mocks.VersionContexts = db.MockVersionContexts{} }()
+
 	tcs := []struct {
 		name           string
 		searchQuery    string
@@ -619,6 +634,13 @@ func TestVersionContext(t *testing.T) {
 			"github.com/sourcegraph/foobar@v1.0.0:v1.1.0",
 			"github.com/sourcegraph/bar@e62b6218f61cc1564d6ebcae19f9dafdf1357567",
 		},
+	}, {
+		name:                      "version context from the database",
+		searchQuery:               "foo",
+		versionContext:            "db-ctx",
+		wantReposListOptionsNames: []string{"github.com/sourcegraph/foo"},
+		reposGetListNames:         []string{"github.com/sourcegraph/foo"},
+		wantResults:               []string{"github.com/sourcegraph/foo@v2.0.0"},
 	}}
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
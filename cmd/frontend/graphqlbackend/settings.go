@@ -10,6 +10,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 type settingsResolver struct {
@@ -56,7 +58,8 @@ func (o *settingsResolver) Author(ctx context.Context) (*UserResolver, error) {
 var globalSettingsAllowEdits, _ = strconv.ParseBool(env.Get("GLOBAL_SETTINGS_ALLOW_EDITS", "false", "When GLOBAL_SETTINGS_FILE is in use, allow edits in the application to be made which will be overwritten on next process restart"))
 
 // like db.Settings.CreateIfUpToDate, except it handles notifying the
-// query-runner if any saved queries have changed.
+// query-runner if any saved queries have changed, and deletes the search
+// history of a user who opts out of it.
 func settingsCreateIfUpToDate(ctx context.Context, subject *settingsSubject, lastID *int32, authorUserID int32, contents string) (latestSetting *api.Settings, err error) {
 	if os.Getenv("GLOBAL_SETTINGS_FILE") != "" && subject.site != nil && !globalSettingsAllowEdits {
 		return nil, errors.New("Updating global settings not allowed when using GLOBAL_SETTINGS_FILE")
@@ -68,11 +71,33 @@ func settingsCreateIfUpToDate(ctx context.Context, subject *settingsSubject, las
 		return nil, err
 	}
 
+	// Read whether the user already opted out of search history.
+	var oldSettings schema.Settings
+	if subject.user != nil {
+		if err := subject.readSettings(ctx, &oldSettings); err != nil {
+			return nil, err
+		}
+	}
+
 	// Update settings.
 	latestSettings, err := db.Settings.CreateIfUpToDate(ctx, subject.toSubject(), lastID, &authorUserID, contents)
 	if err != nil {
 		return nil, err
 	}
 
+	// The searches that were saved before the user opted out of search
+	// history are deleted once, when the setting changes. Only an opt-out in
+	// the user's own settings deletes them: setting "search.saveHistory" to
+	// false in organization or global settings stops saving searches (see
+	// addToSearchHistory), but leaves the existing history of the users.
+	if subject.user != nil && !searchHistoryOptedOut(&oldSettings) {
+		var newSettings schema.Settings
+		if err := jsonc.Unmarshal(contents, &newSettings); err == nil && searchHistoryOptedOut(&newSettings) {
+			if err := db.SearchHistory.DeleteByUser(ctx, subject.user.user.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return latestSettings, nil
 }
@@ -0,0 +1,219 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/db/confdb"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+)
+
+// SettingsHistory defines the Query.settingsHistory field.
+func (r *schemaResolver) SettingsHistory(ctx context.Context, args *struct {
+	Subject graphql.ID
+	First   *int32
+}) ([]*settingsResolver, error) {
+	// 🚨 SECURITY: settingsSubjectForNode checks that the viewer can view the subject's settings.
+	subject, err := r.SettingsSubject(ctx, &struct{ ID graphql.ID }{ID: args.Subject})
+	if err != nil {
+		return nil, err
+	}
+
+	var limit int
+	if args.First != nil {
+		limit = int(*args.First)
+	}
+	history, err := db.Settings.ListBySubject(ctx, subject.toSubject(), limit)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*settingsResolver, len(history))
+	for i, settings := range history {
+		resolvers[i] = &settingsResolver{subject, settings, nil}
+	}
+	return resolvers, nil
+}
+
+// SettingsDiff defines the Query.settingsDiff field.
+func (r *schemaResolver) SettingsDiff(ctx context.Context, args *struct {
+	Subject graphql.ID
+	Base    int32
+	Head    int32
+}) (*configurationDiffResolver, error) {
+	// 🚨 SECURITY: settingsSubjectForNode checks that the viewer can view the subject's settings.
+	subject, err := r.SettingsSubject(ctx, &struct{ ID graphql.ID }{ID: args.Subject})
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := db.Settings.GetByID(ctx, subject.toSubject(), args.Base)
+	if err != nil {
+		return nil, err
+	}
+	head, err := db.Settings.GetByID(ctx, subject.toSubject(), args.Head)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigurationDiffResolver(base.Contents, head.Contents)
+}
+
+// RestoreSettings overwrites the subject's settings with the contents of an
+// earlier version of its settings. The restored settings are saved as a new
+// version, so the history is preserved.
+func (r *settingsMutation) RestoreSettings(ctx context.Context, args *struct {
+	ID int32
+}) (*updateSettingsPayload, error) {
+	settings, err := db.Settings.GetByID(ctx, r.subject.toSubject(), args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := settingsCreateIfUpToDate(ctx, r.subject, r.input.LastID, actor.FromContext(ctx).UID, settings.Contents); err != nil {
+		return nil, err
+	}
+	return &updateSettingsPayload{}, nil
+}
+
+type siteConfigurationVersionResolver struct {
+	config *confdb.SiteConfig
+}
+
+func (r *siteConfigurationVersionResolver) ID() int32 { return r.config.ID }
+
+func (r *siteConfigurationVersionResolver) Contents() JSONCString {
+	return JSONCString(r.config.Contents)
+}
+
+func (r *siteConfigurationVersionResolver) CreatedAt() DateTime {
+	return DateTime{Time: r.config.CreatedAt}
+}
+
+func (r *siteResolver) ConfigurationHistory(ctx context.Context, args *struct {
+	First *int32
+}) ([]*siteConfigurationVersionResolver, error) {
+	// 🚨 SECURITY: The site configuration contains secret tokens and credentials,
+	// so only admins may view it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var limit int
+	if args.First != nil {
+		limit = int(*args.First)
+	}
+	history, err := confdb.SiteListHistory(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*siteConfigurationVersionResolver, len(history))
+	for i, config := range history {
+		resolvers[i] = &siteConfigurationVersionResolver{config: config}
+	}
+	return resolvers, nil
+}
+
+func (r *siteResolver) ConfigurationDiff(ctx context.Context, args *struct {
+	Base int32
+	Head int32
+}) (*configurationDiffResolver, error) {
+	// 🚨 SECURITY: The site configuration contains secret tokens and credentials,
+	// so only admins may view it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	base, err := siteConfigurationByID(ctx, args.Base)
+	if err != nil {
+		return nil, err
+	}
+	head, err := siteConfigurationByID(ctx, args.Head)
+	if err != nil {
+		return nil, err
+	}
+	return newConfigurationDiffResolver(base.Contents, head.Contents)
+}
+
+func (r *schemaResolver) RestoreSiteConfiguration(ctx context.Context, args *struct {
+	LastID int32
+	ID     int32
+}) (bool, error) {
+	// 🚨 SECURITY: Restoring a version overwrites the site configuration, which
+	// controls authentication, code host credentials and access to the whole
+	// site, so only admins may restore it (like updating it).
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return false, err
+	}
+
+	config, err := siteConfigurationByID(ctx, args.ID)
+	if err != nil {
+		return false, err
+	}
+	return r.UpdateSiteConfiguration(ctx, &struct {
+		LastID int32
+		Input  string
+	}{LastID: args.LastID, Input: config.Contents})
+}
+
+func siteConfigurationByID(ctx context.Context, id int32) (*confdb.SiteConfig, error) {
+	config, err := confdb.SiteGetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("site configuration not found: %d", id)
+	}
+	return config, nil
+}
+
+type configurationDiffResolver struct {
+	changes []jsonc.Change
+}
+
+func newConfigurationDiffResolver(base, head string) (*configurationDiffResolver, error) {
+	changes, err := jsonc.Diff(base, head)
+	if err != nil {
+		return nil, err
+	}
+	return &configurationDiffResolver{changes: changes}, nil
+}
+
+func (r *configurationDiffResolver) Changes() []*configurationChangeResolver {
+	resolvers := make([]*configurationChangeResolver, len(r.changes))
+	for i := range r.changes {
+		resolvers[i] = &configurationChangeResolver{change: &r.changes[i]}
+	}
+	return resolvers
+}
+
+type configurationChangeResolver struct {
+	change *jsonc.Change
+}
+
+func (r *configurationChangeResolver) Path() []string {
+	if r.change.Path == nil {
+		return []string{}
+	}
+	return r.change.Path
+}
+
+func (r *configurationChangeResolver) Kind() string {
+	return strings.ToUpper(string(r.change.Kind))
+}
+
+func (r *configurationChangeResolver) OldValue() *JSONValue {
+	if r.change.Kind == jsonc.ChangeAdded {
+		return nil
+	}
+	return &JSONValue{r.change.Old}
+}
+
+func (r *configurationChangeResolver) NewValue() *JSONValue {
+	if r.change.Kind == jsonc.ChangeRemoved {
+		return nil
+	}
+	return &JSONValue{r.change.New}
+}
@@ -0,0 +1,61 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestSettingsDiff(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByID = func(context.Context, int32) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	db.Mocks.Settings.GetByID = func(ctx context.Context, subject api.SettingsSubject, id int32) (*api.Settings, error) {
+		switch id {
+		case 1:
+			return &api.Settings{ID: 1, Contents: `{"a": 1, "b": {"c": true}}`}, nil
+		default:
+			return &api.Settings{ID: id, Contents: `{
+  // comment
+  "b": {"c": false},
+  "d": "x",
+}`}, nil
+		}
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				{
+					settingsDiff(subject: "VXNlcjox", base: 1, head: 2) {
+						changes {
+							path
+							kind
+							oldValue
+							newValue
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"settingsDiff": {
+						"changes": [
+							{"path": ["a"], "kind": "REMOVED", "oldValue": 1, "newValue": null},
+							{"path": ["b", "c"], "kind": "CHANGED", "oldValue": true, "newValue": false},
+							{"path": ["d"], "kind": "ADDED", "oldValue": null, "newValue": "x"}
+						]
+					}
+				}
+			`,
+		},
+	})
+}
@@ -102,3 +102,52 @@ func TestSettingsMutation_OverwriteSettings(t *testing.T) {
 		},
 	})
 }
+
+func TestSettingsMutation_RestoreSettings(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByID = func(context.Context, int32) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	db.Mocks.Settings.GetByID = func(ctx context.Context, subject api.SettingsSubject, id int32) (*api.Settings, error) {
+		if subject.User == nil || *subject.User != 1 || id != 1 {
+			t.Errorf("got subject %+v and ID %d, want user 1 and ID 1", subject, id)
+		}
+		return &api.Settings{ID: 1, Contents: `{"x": 1}`}, nil
+	}
+	db.Mocks.Settings.GetLatest = func(context.Context, api.SettingsSubject) (*api.Settings, error) {
+		return &api.Settings{ID: 2, Contents: "{}"}, nil
+	}
+	db.Mocks.Settings.CreateIfUpToDate = func(ctx context.Context, subject api.SettingsSubject, lastID, authorUserID *int32, contents string) (*api.Settings, error) {
+		if want := `{"x": 1}`; contents != want {
+			t.Errorf("got %q, want %q", contents, want)
+		}
+		return &api.Settings{ID: 3, Contents: contents}, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				mutation {
+					settingsMutation(input: {subject: "VXNlcjox", lastID: 2}) {
+						restoreSettings(id: 1) {
+							empty {
+								alwaysNil
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"settingsMutation": {
+						"restoreSettings": {
+							"empty": null
+						}
+					}
+				}
+			`,
+		},
+	})
+}
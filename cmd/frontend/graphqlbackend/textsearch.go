@@ -76,6 +76,14 @@ type FileMatchResolver struct {
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
 	InputRev *string
+	// OtherInputRevs are the other Git revspecs the user requested to search
+	// which contain the file with the same contents, when several revisions
+	// of the repository were searched. The file is only returned once for
+	// all of them.
+	OtherInputRevs []string
+	// JCommits are the commits containing the file returned by searcher when
+	// it searches several commits.
+	JCommits []api.CommitID `json:"Commits"`
 }
 
 func (fm *FileMatchResolver) Equal(other *FileMatchResolver) bool {
@@ -113,6 +121,16 @@ func (fm *FileMatchResolver) RevSpec() *gitRevSpec {
 	}
 }
 
+func (fm *FileMatchResolver) OtherRevSpecs() []*gitRevSpec {
+	revSpecs := make([]*gitRevSpec, 0, len(fm.OtherInputRevs))
+	for _, rev := range fm.OtherInputRevs {
+		revSpecs = append(revSpecs, &gitRevSpec{
+			expr: &gitRevSpecExpr{expr: rev, repo: fm.Repository()},
+		})
+	}
+	return revSpecs
+}
+
 func (fm *FileMatchResolver) Resource() string {
 	return fm.uri
 }
@@ -161,6 +179,8 @@ type lineMatch struct {
 	JOffsetAndLengths [][2]int32 `json:"OffsetAndLengths"`
 	JLineNumber       int32      `json:"LineNumber"`
 	JLimitHit         bool       `json:"LimitHit"`
+	JContextBefore    []string   `json:"ContextBefore"`
+	JContextAfter     []string   `json:"ContextAfter"`
 }
 
 func (lm *lineMatch) Preview() string {
@@ -183,6 +203,21 @@ func (lm *lineMatch) LimitHit() bool {
 	return lm.JLimitHit
 }
 
+func (lm *lineMatch) Context() *lineMatchContextResolver {
+	if len(lm.JContextBefore) == 0 && len(lm.JContextAfter) == 0 {
+		return nil
+	}
+	return &lineMatchContextResolver{before: lm.JContextBefore, after: lm.JContextAfter}
+}
+
+type lineMatchContextResolver struct {
+	before, after []string
+}
+
+func (r *lineMatchContextResolver) Before() []string { return r.before }
+
+func (r *lineMatchContextResolver) After() []string { return r.after }
+
 var mockTextSearch func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error)
 
 // textSearch searches repo@commit with p.
@@ -191,6 +226,51 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 	if mockTextSearch != nil {
 		return mockTextSearch(ctx, repo, commit, p, fetchTimeout)
 	}
+	return textSearchCommits(ctx, searcherURLs, repo, []api.CommitID{commit}, p, fetchTimeout)
+}
+
+var mockTextSearchCommits func(ctx context.Context, repo gitserver.Repo, commits []api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error)
+
+// textSearchCommits searches several commits of repo with p in a single
+// searcher request. A file which is the same in several of the commits is
+// only returned once, and fileMatch.JCommits lists the commits containing it
+// if more than one commit was searched.
+// Note: the returned matches do not set fileMatch.uri
+func textSearchCommits(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.Repo, commits []api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+	if mockTextSearchCommits != nil {
+		return mockTextSearchCommits(ctx, repo, commits, p, fetchTimeout)
+	}
+
+	matches, limitHit, searched, err := searcherSearch(ctx, searcherURLs, repo, commits, p, fetchTimeout)
+	if err != nil || len(commits) == 1 || len(searched) == len(commits) {
+		return matches, limitHit, err
+	}
+
+	// Searchers which don't support searching several commits in a request
+	// ignore all but the first commit (and don't report the searched
+	// commits). Search each of the other commits with a separate request.
+	for _, fm := range matches {
+		fm.JCommits = commits[:1]
+	}
+	for _, commit := range commits[1:] {
+		commitMatches, commitLimitHit, _, err := searcherSearch(ctx, searcherURLs, repo, []api.CommitID{commit}, p, fetchTimeout)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, fm := range commitMatches {
+			fm.JCommits = []api.CommitID{commit}
+		}
+		matches = append(matches, commitMatches...)
+		limitHit = limitHit || commitLimitHit
+	}
+	return matches, limitHit, nil
+}
+
+// searcherSearch sends a search request for commits of repo to searcher. It
+// returns the commits searcher reports as searched, which are only set if
+// several commits were requested and searcher supports searching them.
+func searcherSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.Repo, commits []api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, searched []api.CommitID, err error) {
+	commit := commits[0]
 
 	tr, ctx := trace.New(ctx, "searcher.client", fmt.Sprintf("%s@%s", repo.Name, commit))
 	defer func() {
@@ -199,20 +279,24 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 	}()
 
 	q := url.Values{
-		"Repo":            []string{string(repo.Name)},
-		"URL":             []string{repo.URL},
-		"Commit":          []string{string(commit)},
-		"Pattern":         []string{p.Pattern},
-		"ExcludePattern":  []string{p.ExcludePattern},
-		"IncludePatterns": p.IncludePatterns,
-		"FetchTimeout":    []string{fetchTimeout.String()},
-		"Languages":       p.Languages,
-		"CombyRule":       []string{p.CombyRule},
+		"Repo":                []string{string(repo.Name)},
+		"URL":                 []string{repo.URL},
+		"Commit":              []string{string(commit)},
+		"Pattern":             []string{p.Pattern},
+		"ExcludePattern":      []string{p.ExcludePattern},
+		"IncludePatterns":     p.IncludePatterns,
+		"FetchTimeout":        []string{fetchTimeout.String()},
+		"Languages":           p.Languages,
+		"LangIncludePatterns": p.LangIncludePatterns,
+		"CombyRule":           []string{p.CombyRule},
+	}
+	for _, commit := range commits[1:] {
+		q.Add("Commits", string(commit))
 	}
 	if deadline, ok := ctx.Deadline(); ok {
 		t, err := deadline.MarshalText()
 		if err != nil {
-			return nil, false, err
+			return nil, false, nil, err
 		}
 		q.Set("Deadline", string(t))
 	}
@@ -235,6 +319,9 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 	if p.PathPatternsAreCaseSensitive {
 		q.Set("PathPatternsAreCaseSensitive", "true")
 	}
+	if p.ContextLines > 0 {
+		q.Set("ContextLines", strconv.FormatInt(int64(p.ContextLines), 10))
+	}
 	// TEMP BACKCOMPAT: always set even if false so that searcher can distinguish new frontends that send
 	// these fields from old frontends that do not (and provide a default in the latter case).
 	q.Set("PatternMatchesContent", strconv.FormatBool(p.PatternMatchesContent))
@@ -258,7 +345,7 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 
 		searcherURL, err := searcherURLs.Get(consistentHashKey, excludedSearchURLs)
 		if err != nil {
-			return nil, false, err
+			return nil, false, nil, err
 		}
 
 		// Fallback to a bad host if nothing is left
@@ -266,25 +353,25 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 			tr.LazyPrintf("failed to find endpoint, trying again without excludes")
 			searcherURL, err = searcherURLs.Get(consistentHashKey, nil)
 			if err != nil {
-				return nil, false, err
+				return nil, false, nil, err
 			}
 		}
 
 		url := searcherURL + "?" + rawQuery
 		tr.LazyPrintf("attempt %d: %s", attempt, url)
-		matches, limitHit, err = textSearchURL(ctx, url)
+		matches, limitHit, searched, err = textSearchURL(ctx, url)
 		if err == nil || errcode.IsTimeout(err) {
-			return matches, limitHit, err
+			return matches, limitHit, searched, err
 		}
 
 		// If we are canceled, return that error.
 		if err := ctx.Err(); err != nil {
-			return nil, false, err
+			return nil, false, nil, err
 		}
 
 		// If not temporary or our last attempt then don't try again.
 		if !errcode.IsTemporary(err) || attempt == maxAttempts {
-			return nil, false, err
+			return nil, false, nil, err
 		}
 
 		tr.LazyPrintf("transient error %s", err.Error())
@@ -293,10 +380,10 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 	}
 }
 
-func textSearchURL(ctx context.Context, url string) ([]*FileMatchResolver, bool, error) {
+func textSearchURL(ctx context.Context, url string) ([]*FileMatchResolver, bool, []api.CommitID, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, false, err
+		return nil, false, nil, err
 	}
 	req = req.WithContext(ctx)
 
@@ -315,30 +402,31 @@ func textSearchURL(ctx context.Context, url string) ([]*FileMatchResolver, bool,
 		if ctx.Err() != nil {
 			err = ctx.Err()
 		}
-		return nil, false, errors.Wrap(err, "searcher request failed")
+		return nil, false, nil, errors.Wrap(err, "searcher request failed")
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != 200 {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return nil, false, err
+			return nil, false, nil, err
 		}
-		return nil, false, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
+		return nil, false, nil, errors.WithStack(&searcherError{StatusCode: resp.StatusCode, Message: string(body)})
 	}
 
 	r := struct {
 		Matches     []*FileMatchResolver
 		LimitHit    bool
 		DeadlineHit bool
+		Commits     []api.CommitID
 	}{}
 	err = json.NewDecoder(resp.Body).Decode(&r)
 	if err != nil {
-		return nil, false, errors.Wrap(err, "searcher response invalid")
+		return nil, false, nil, errors.Wrap(err, "searcher response invalid")
 	}
 	if r.DeadlineHit {
 		err = context.DeadlineExceeded
 	}
-	return r.Matches, r.LimitHit, err
+	return r.Matches, r.LimitHit, r.Commits, err
 }
 
 type searcherError struct {
@@ -398,6 +486,70 @@ func searchFilesInRepo(ctx context.Context, searcherURLs *endpoint.Map, repo *ty
 	return matches, limitHit, err
 }
 
+// searchFilesInRepoRevs searches several revisions of a repository with a
+// single searcher request. Searcher only searches the files that are the same
+// in several of the revisions once, and each of these files is returned once,
+// for the first of the revisions containing it (see
+// FileMatchResolver.OtherInputRevs).
+//
+// The errors of the revisions which could not be resolved (or checked against
+// repohasfile filters) are returned in revErrs, by revision, so that they are
+// reported like the errors of searches of single revisions. The other
+// revisions are still searched.
+func searchFilesInRepoRevs(ctx context.Context, searcherURLs *endpoint.Map, repo *types.Repo, gitserverRepo gitserver.Repo, revs []string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, revErrs map[string]error, err error) {
+	// Several revisions (e.g. a branch and a tag) may resolve to the same
+	// commit, which is only searched once.
+	var commits []api.CommitID
+	revsByCommit := map[api.CommitID][]string{}
+	revErrs = map[string]error{}
+	for _, rev := range revs {
+		// Do not trigger a repo-updater lookup (see searchFilesInRepo).
+		commit, err := git.ResolveRevision(ctx, gitserverRepo, nil, rev, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+		if err != nil {
+			revErrs[rev] = err
+			continue
+		}
+		if _, ok := revsByCommit[commit]; !ok {
+			shouldBeSearched, err := repoShouldBeSearched(ctx, searcherURLs, info, gitserverRepo, commit, fetchTimeout)
+			if err != nil {
+				revErrs[rev] = err
+				continue
+			}
+			if shouldBeSearched {
+				commits = append(commits, commit)
+			}
+		}
+		revsByCommit[commit] = append(revsByCommit[commit], rev)
+	}
+	if len(commits) == 0 {
+		return nil, false, revErrs, nil
+	}
+
+	matches, limitHit, err = textSearchCommits(ctx, searcherURLs, gitserverRepo, commits, info, fetchTimeout)
+	if err != nil {
+		return nil, false, revErrs, err
+	}
+
+	for _, fm := range matches {
+		fileCommits := fm.JCommits
+		if len(fileCommits) == 0 {
+			fileCommits = commits[:1]
+		}
+		var fileRevs []string
+		for _, commit := range fileCommits {
+			fileRevs = append(fileRevs, revsByCommit[commit]...)
+		}
+		rev := fileRevs[0]
+		fm.uri = fileMatchURI(repo.Name, rev, fm.JPath)
+		fm.Repo = repo
+		fm.CommitID = fileCommits[0]
+		fm.InputRev = &rev
+		fm.OtherInputRevs = fileRevs[1:]
+	}
+
+	return matches, limitHit, revErrs, nil
+}
+
 // repoShouldBeSearched determines whether a repository should be searched in, based on whether the repository
 // fits in the subset of repositories specified in the query's `repohasfile` and `-repohasfile` flags if they exist.
 func repoShouldBeSearched(ctx context.Context, searcherURLs *endpoint.Map, searchPattern *search.TextPatternInfo, gitserverRepo gitserver.Repo, commit api.CommitID, fetchTimeout time.Duration) (shouldBeSearched bool, err error) {
@@ -573,6 +725,41 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		}
 	}
 
+	// addRepoSearchResult records the result of searching a repository with
+	// searcher.
+	addRepoSearchResult := func(ctx context.Context, repoRev *search.RepositoryRevisions, matches []*FileMatchResolver, repoLimitHit bool, err error) {
+		if err != nil {
+			tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.Error(err), otlog.Bool("timeout", errcode.IsTimeout(err)), otlog.Bool("temporary", errcode.IsTemporary(err)))
+			log15.Warn("searchFilesInRepo failed", "error", err, "repo", repoRev.Repo.Name)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() == nil {
+			common.searched = append(common.searched, repoRev.Repo)
+		}
+		if repoLimitHit {
+			// We did not return all results in this repository.
+			common.partial[repoRev.Repo.Name] = struct{}{}
+		}
+		// non-diff search reports timeout through err, so pass false for timedOut
+		if fatalErr := handleRepoSearchResult(common, repoRev, repoLimitHit, false, err); fatalErr != nil {
+			if ctx.Err() == context.Canceled {
+				// Our request has been canceled (either because another one of searcherRepos
+				// had a fatal error, or otherwise), so we can just ignore these results. We
+				// handle this here, not in handleRepoSearchResult, because different callers of
+				// handleRepoSearchResult (for different result types) currently all need to
+				// handle cancellations differently.
+				return
+			}
+			if searchErr == nil {
+				searchErr = errors.Wrapf(err, "failed to search %s", repoRev.String())
+				tr.LazyPrintf("cancel due to error: %v", searchErr)
+				cancel()
+			}
+		}
+		addMatches(matches)
+	}
+
 	// callSearcherOverRepos calls searcher on a set of repos.
 	// searcherReposFilteredFiles is an optional map of {repo name => file list}
 	// that forces the searcher to only include the file list in the
@@ -623,6 +810,39 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 				return errMultipleRevsNotSupported
 			}
 
+			// Search all the revisions of the repository with a single
+			// searcher request, which only searches the files that are the
+			// same in several of the revisions once. Structural search
+			// still searches each revision separately.
+			if len(revSpecs) >= 2 && !args.PatternInfo.IsStructuralPat {
+				limitCtx, limitDone, acquireErr := textSearchLimiter.Acquire(ctx)
+				if acquireErr != nil {
+					break outer
+				}
+
+				repoRev := &search.RepositoryRevisions{Repo: repoAllRevs.Repo, Revs: make([]search.RevisionSpecifier, len(revSpecs))}
+				for i, rev := range revSpecs {
+					repoRev.Revs[i] = search.RevisionSpecifier{RevSpec: rev}
+				}
+
+				wg.Add(1)
+				go func(ctx context.Context, done context.CancelFunc) {
+					defer wg.Done()
+					defer done()
+
+					matches, repoLimitHit, revErrs, err := searchFilesInRepoRevs(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), revSpecs, args.PatternInfo, fetchTimeout)
+					for _, rev := range revSpecs {
+						if revErr, ok := revErrs[rev]; ok {
+							addRepoSearchResult(ctx, &search.RepositoryRevisions{Repo: repoRev.Repo, Revs: []search.RevisionSpecifier{{RevSpec: rev}}}, nil, false, revErr)
+						}
+					}
+					if len(revErrs) < len(revSpecs) {
+						addRepoSearchResult(ctx, repoRev, matches, repoLimitHit, err)
+					}
+				}(limitCtx, limitDone)
+				continue
+			}
+
 			for _, rev := range revSpecs {
 				// Only reason acquire can fail is if ctx is cancelled. So we can stop
 				// looping through searcherRepos.
@@ -651,36 +871,7 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 					defer done()
 
 					matches, repoLimitHit, err := searchFilesInRepo(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], args.PatternInfo, fetchTimeout)
-					if err != nil {
-						tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.Error(err), otlog.Bool("timeout", errcode.IsTimeout(err)), otlog.Bool("temporary", errcode.IsTemporary(err)))
-						log15.Warn("searchFilesInRepo failed", "error", err, "repo", repoRev.Repo.Name)
-					}
-					mu.Lock()
-					defer mu.Unlock()
-					if ctx.Err() == nil {
-						common.searched = append(common.searched, repoRev.Repo)
-					}
-					if repoLimitHit {
-						// We did not return all results in this repository.
-						common.partial[repoRev.Repo.Name] = struct{}{}
-					}
-					// non-diff search reports timeout through err, so pass false for timedOut
-					if fatalErr := handleRepoSearchResult(common, repoRev, repoLimitHit, false, err); fatalErr != nil {
-						if ctx.Err() == context.Canceled {
-							// Our request has been canceled (either because another one of searcherRepos
-							// had a fatal error, or otherwise), so we can just ignore these results. We
-							// handle this here, not in handleRepoSearchResult, because different callers of
-							// handleRepoSearchResult (for different result types) currently all need to
-							// handle cancellations differently.
-							return
-						}
-						if searchErr == nil {
-							searchErr = errors.Wrapf(err, "failed to search %s", repoRev.String())
-							tr.LazyPrintf("cancel due to error: %v", searchErr)
-							cancel()
-						}
-					}
-					addMatches(matches)
+					addRepoSearchResult(ctx, repoRev, matches, repoLimitHit, err)
 				}(limitCtx, limitDone) // ends the Go routine for a call to searcher for a repo
 			} // ends the for loop iterating over repo's revs
 		} // ends the for loop iterating over repos
@@ -2,6 +2,9 @@ package graphqlbackend
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
 	"testing"
@@ -111,20 +114,27 @@ func TestSearchFilesInRepos(t *testing.T) {
 }
 
 func TestSearchFilesInRepos_multipleRevsPerRepo(t *testing.T) {
-	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
-		repoName := repo.Name
-		switch repoName {
-		case "foo":
-			return []*FileMatchResolver{
-				{
-					uri: "git://" + string(repoName) + "?" + rev + "#" + "main.go",
-				},
-			}, false, nil
-		default:
+	// mybranch and branch3 are at the same commit.
+	commits := map[string]api.CommitID{"master": "c1", "mybranch": "c2", "branch3": "c2", "branch4": "c3"}
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return commits[spec], nil
+	}
+	defer git.ResetMocks()
+
+	// main.go is the same at all commits, a.go is only at c3.
+	mockTextSearchCommits = func(ctx context.Context, repo gitserver.Repo, searched []api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		if repo.Name != "foo" {
 			panic("unexpected repo")
 		}
+		if len(searched) != 3 {
+			t.Errorf("got searched commits %v, want each commit searched once", searched)
+		}
+		return []*FileMatchResolver{
+			{JPath: "main.go", JCommits: searched},
+			{JPath: "a.go", JCommits: []api.CommitID{"c3"}},
+		}, false, nil
 	}
-	defer func() { mockSearchFilesInRepo = nil }()
+	defer func() { mockTextSearchCommits = nil }()
 
 	trueVal := true
 	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
@@ -156,20 +166,107 @@ func TestSearchFilesInRepos_multipleRevsPerRepo(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	resultURIs := make([]string, len(results))
-	for i, result := range results {
-		resultURIs[i] = result.uri
+	// Each file is returned once, with all the revisions containing it.
+	got := map[string][]string{}
+	for _, result := range results {
+		if want := "git://foo?" + *result.InputRev + "#" + result.JPath; result.uri != want {
+			t.Errorf("got uri %q, want %q", result.uri, want)
+		}
+		if result.CommitID != commits[*result.InputRev] {
+			t.Errorf("got commit %q for rev %q, want %q", result.CommitID, *result.InputRev, commits[*result.InputRev])
+		}
+		revs := append([]string{*result.InputRev}, result.OtherInputRevs...)
+		sort.Strings(revs)
+		got[result.JPath] = revs
+	}
+	want := map[string][]string{
+		"main.go": {"branch3", "branch4", "master", "mybranch"},
+		"a.go":    {"branch4"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSearchFilesInRepos_multipleRevsPerRepoErrors(t *testing.T) {
+	// Resolving the revision "slow" times out, which must not prevent
+	// master from being searched.
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec == "slow" {
+			return "", context.DeadlineExceeded
+		}
+		return "c1", nil
+	}
+	defer git.ResetMocks()
+
+	mockTextSearchCommits = func(ctx context.Context, repo gitserver.Repo, searched []api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		if !reflect.DeepEqual(searched, []api.CommitID{"c1"}) {
+			t.Errorf("got searched commits %v, want [c1]", searched)
+		}
+		return []*FileMatchResolver{{JPath: "main.go"}}, false, nil
+	}
+	defer func() { mockTextSearchCommits = nil }()
+
+	trueVal := true
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{SearchMultipleRevisionsPerRepository: &trueVal},
+	}})
+	defer conf.Mock(nil)
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.TextParameters{
+		PatternInfo: &search.TextPatternInfo{
+			FileMatchLimit: defaultMaxSearchResults,
+			Pattern:        "foo",
+		},
+		Repos:        makeRepositoryRevisions("foo@master:slow"),
+		Query:        q,
+		Zoekt:        &searchbackend.Zoekt{Client: &fakeSearcher{repos: &zoekt.RepoList{}}},
+		SearcherURLs: endpoint.Static("test"),
+	}
+	results, common, err := searchFilesInRepos(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || *results[0].InputRev != "master" {
+		t.Errorf("got %d results, want main.go at master", len(results))
+	}
+	if v := toRepoNames(common.timedout); !reflect.DeepEqual(v, []api.RepoName{"foo"}) {
+		t.Errorf("unexpected timedout: %v", v)
 	}
-	sort.Strings(resultURIs)
+}
 
-	wantResultURIs := []string{
-		"git://foo?branch3#main.go",
-		"git://foo?branch4#main.go",
-		"git://foo?master#main.go",
-		"git://foo?mybranch#main.go",
+func TestTextSearchCommits_singleCommitSearcher(t *testing.T) {
+	// A searcher which doesn't support searching several commits only
+	// searches Commit and ignores Commits.
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commit := r.URL.Query().Get("Commit")
+		requested = append(requested, commit)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"Matches": []map[string]interface{}{{"Path": commit + ".go"}},
+		})
+	}))
+	defer ts.Close()
+
+	commits := []api.CommitID{"c1", "c2"}
+	matches, _, err := textSearchCommits(context.Background(), endpoint.Static(ts.URL), gitserver.Repo{Name: "foo"}, commits, &search.TextPatternInfo{Pattern: "foo"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c1", "c2"}; !reflect.DeepEqual(requested, want) {
+		t.Errorf("got requests for commits %q, want %q", requested, want)
+	}
+	got := map[string][]api.CommitID{}
+	for _, fm := range matches {
+		got[fm.JPath] = fm.JCommits
 	}
-	if !reflect.DeepEqual(resultURIs, wantResultURIs) {
-		t.Errorf("got %v, want %v", resultURIs, wantResultURIs)
+	want := map[string][]api.CommitID{"c1.go": {"c1"}, "c2.go": {"c2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
 	}
 }
 
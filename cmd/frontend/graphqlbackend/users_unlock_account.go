@@ -0,0 +1,34 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/auth/userpasswd"
+)
+
+func (*schemaResolver) UnlockUserAccount(ctx context.Context, args *struct {
+	User graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Only site admins can unlock user accounts.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userID, err := UnmarshalUserID(args.User)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the user exists so that unlocking a nonexistent account is reported as an error.
+	if _, err := db.Users.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if err := userpasswd.UnlockAccount(userID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
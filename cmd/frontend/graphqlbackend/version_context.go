@@ -4,16 +4,53 @@ import (
 	"context"
 
 	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
+// versionContextResolver resolves a version context stored in the database, or
+// (if vc.ID is zero) one defined in the experimentalFeatures.versionContexts
+// site configuration.
 type versionContextResolver struct {
-	vc *schema.VersionContext
+	vc *types.VersionContext
 }
 
 func (v *versionContextResolver) ID() graphql.ID {
-	return graphql.ID(v.vc.Name)
+	if v.vc.ID == 0 {
+		// Version contexts from the site configuration are identified by name.
+		return graphql.ID(v.vc.Name)
+	}
+	return marshalVersionContextID(v.vc.ID)
+}
+
+func marshalVersionContextID(id int32) graphql.ID { return relay.MarshalID("VersionContext", id) }
+
+func unmarshalVersionContextID(id graphql.ID) (vcID int32, err error) {
+	err = relay.UnmarshalSpec(id, &vcID)
+	return
+}
+
+func versionContextByID(ctx context.Context, id graphql.ID) (*versionContextResolver, error) {
+	vcID, err := unmarshalVersionContextID(id)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := db.VersionContexts.GetByID(ctx, vcID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCanViewVersionContext(ctx, vc.OrgID); err != nil {
+		return nil, err
+	}
+	return &versionContextResolver{vc: vc}, nil
 }
 
 func (v *versionContextResolver) Name() string {
@@ -24,18 +61,279 @@ func (v *versionContextResolver) Description() string {
 	return v.vc.Description
 }
 
+func (v *versionContextResolver) Organization(ctx context.Context) (*OrgResolver, error) {
+	if v.vc.OrgID == 0 {
+		return nil, nil
+	}
+	return OrgByIDInt32(ctx, v.vc.OrgID)
+}
+
+func (v *versionContextResolver) Revisions(ctx context.Context) ([]*versionContextRevisionResolver, error) {
+	resolvers := make([]*versionContextRevisionResolver, 0, len(v.vc.Revisions))
+	for _, rev := range v.vc.Revisions {
+		// 🚨 SECURITY: Only return the revisions of repositories the viewer has access
+		// to, so that the names of other repositories aren't revealed. The DB layer
+		// enforces repository permissions.
+		if _, err := db.Repos.GetByName(ctx, api.RepoName(rev.Repo)); err != nil {
+			if errcode.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		resolvers = append(resolvers, &versionContextRevisionResolver{rev: rev})
+	}
+	return resolvers, nil
+}
+
+func (v *versionContextResolver) ViewerCanAdminister(ctx context.Context) (bool, error) {
+	if v.vc.ID == 0 {
+		// Version contexts from the site configuration can only be changed by editing it.
+		return false, nil
+	}
+	if err := checkCanAdministerVersionContext(ctx, v.vc.OrgID); err == backend.ErrNotAuthenticated || err == backend.ErrMustBeSiteAdmin || err == backend.ErrNotAnOrgMember {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type versionContextRevisionResolver struct {
+	rev *types.VersionContextRevision
+}
+
+func (r *versionContextRevisionResolver) Repo() string { return r.rev.Repo }
+
+func (r *versionContextRevisionResolver) Rev() string { return r.rev.Rev }
+
 func NewVersionContextResolver(vc *schema.VersionContext) *versionContextResolver {
 	return &versionContextResolver{
-		vc: vc,
+		vc: versionContextFromConfig(vc),
+	}
+}
+
+func versionContextFromConfig(vc *schema.VersionContext) *types.VersionContext {
+	revisions := make([]*types.VersionContextRevision, len(vc.Revisions))
+	for i, rev := range vc.Revisions {
+		revisions[i] = &types.VersionContextRevision{Repo: rev.Repo, Rev: rev.Rev}
+	}
+	return &types.VersionContext{
+		Name:        vc.Name,
+		Description: vc.Description,
+		Revisions:   revisions,
 	}
 }
 
 func (r *schemaResolver) VersionContexts(ctx context.Context) ([]*versionContextResolver, error) {
 	var versionContexts []*versionContextResolver
 
+	vcs, err := db.VersionContexts.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(vcs))
+	for _, vc := range vcs {
+		names[vc.Name] = struct{}{}
+		if ok, err := canViewVersionContext(ctx, vc.OrgID); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		versionContexts = append(versionContexts, &versionContextResolver{vc: vc})
+	}
+
 	for _, vc := range conf.Get().ExperimentalFeatures.VersionContexts {
+		// Version contexts in the database take precedence over those with the
+		// same name in the site configuration.
+		if _, ok := names[vc.Name]; ok {
+			continue
+		}
 		versionContexts = append(versionContexts, NewVersionContextResolver(vc))
 	}
 
 	return versionContexts, nil
 }
+
+// NOTE: This function is not called if the version context is not used
+func resolveVersionContext(ctx context.Context, versionContext string) (*types.VersionContext, error) {
+	vc, err := db.VersionContexts.GetByName(ctx, versionContext)
+	if err == nil {
+		if ok, err := canViewVersionContext(ctx, vc.OrgID); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("version context not found")
+		}
+		return vc, nil
+	}
+	if !errcode.IsNotFound(err) {
+		return nil, err
+	}
+
+	for _, vc := range conf.Get().ExperimentalFeatures.VersionContexts {
+		if vc.Name == versionContext {
+			return versionContextFromConfig(vc), nil
+		}
+	}
+
+	return nil, errors.New("version context not found")
+}
+
+// checkCanAdministerVersionContext returns an error if the current user may not
+// create, update or delete the version contexts owned by the organization (or
+// the site, if orgID is zero).
+func checkCanAdministerVersionContext(ctx context.Context, orgID int32) error {
+	if orgID == 0 {
+		// 🚨 SECURITY: Only site admins may manage site-wide version contexts.
+		return backend.CheckCurrentUserIsSiteAdmin(ctx)
+	}
+	// 🚨 SECURITY: Only org members (and site admins) may manage the org's version contexts.
+	return backend.CheckOrgAccess(ctx, orgID)
+}
+
+// checkCanViewVersionContext returns an error if the current user may not view
+// or search the version contexts owned by the organization. Site-wide version
+// contexts (orgID zero) can be viewed by everyone.
+func checkCanViewVersionContext(ctx context.Context, orgID int32) error {
+	if orgID == 0 {
+		return nil
+	}
+	// 🚨 SECURITY: Only org members (and site admins) may view the org's version contexts.
+	return backend.CheckOrgAccess(ctx, orgID)
+}
+
+// canViewVersionContext is like checkCanViewVersionContext, but it reports a
+// denied access as false instead of an error.
+func canViewVersionContext(ctx context.Context, orgID int32) (bool, error) {
+	err := checkCanViewVersionContext(ctx, orgID)
+	if err == backend.ErrNotAuthenticated || err == backend.ErrNotAnOrgMember {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// validateVersionContextRevisions checks that every revision exists in its
+// repository on gitserver.
+func validateVersionContextRevisions(ctx context.Context, revisions []*types.VersionContextRevision) error {
+	for _, rev := range revisions {
+		repo, err := backend.Repos.GetByName(ctx, api.RepoName(rev.Repo))
+		if err != nil {
+			return errors.Wrapf(err, "repository %q", rev.Repo)
+		}
+		cachedRepo, err := backend.CachedGitRepo(ctx, repo)
+		if err != nil {
+			return err
+		}
+		if _, err := git.ResolveRevision(ctx, *cachedRepo, nil, rev.Rev, nil); err != nil {
+			return errors.Wrapf(err, "revision %q of repository %q", rev.Rev, rev.Repo)
+		}
+	}
+	return nil
+}
+
+type versionContextRevisionInput struct {
+	Repo string
+	Rev  string
+}
+
+func toVersionContextRevisions(inputs []versionContextRevisionInput) []*types.VersionContextRevision {
+	revisions := make([]*types.VersionContextRevision, len(inputs))
+	for i, input := range inputs {
+		revisions[i] = &types.VersionContextRevision{Repo: input.Repo, Rev: input.Rev}
+	}
+	return revisions
+}
+
+func (*schemaResolver) CreateVersionContext(ctx context.Context, args *struct {
+	Name         string
+	Description  *string
+	Organization *graphql.ID
+	Revisions    []versionContextRevisionInput
+}) (*versionContextResolver, error) {
+	vc := &types.VersionContext{
+		Name:      args.Name,
+		Revisions: toVersionContextRevisions(args.Revisions),
+	}
+	if args.Description != nil {
+		vc.Description = *args.Description
+	}
+	if args.Organization != nil {
+		orgID, err := UnmarshalOrgID(*args.Organization)
+		if err != nil {
+			return nil, err
+		}
+		vc.OrgID = orgID
+	}
+
+	if err := checkCanAdministerVersionContext(ctx, vc.OrgID); err != nil {
+		return nil, err
+	}
+
+	if err := validateVersionContextRevisions(ctx, vc.Revisions); err != nil {
+		return nil, err
+	}
+	created, err := db.VersionContexts.Create(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	return &versionContextResolver{vc: created}, nil
+}
+
+func (*schemaResolver) UpdateVersionContext(ctx context.Context, args *struct {
+	ID          graphql.ID
+	Name        *string
+	Description *string
+	Revisions   *[]versionContextRevisionInput
+}) (*versionContextResolver, error) {
+	vcID, err := unmarshalVersionContextID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := db.VersionContexts.GetByID(ctx, vcID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCanAdministerVersionContext(ctx, vc.OrgID); err != nil {
+		return nil, err
+	}
+
+	if args.Name != nil {
+		vc.Name = *args.Name
+	}
+	if args.Description != nil {
+		vc.Description = *args.Description
+	}
+	if args.Revisions != nil {
+		vc.Revisions = toVersionContextRevisions(*args.Revisions)
+		if err := validateVersionContextRevisions(ctx, vc.Revisions); err != nil {
+			return nil, err
+		}
+	}
+	updated, err := db.VersionContexts.Update(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	return &versionContextResolver{vc: updated}, nil
+}
+
+func (*schemaResolver) DeleteVersionContext(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	vcID, err := unmarshalVersionContextID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := db.VersionContexts.GetByID(ctx, vcID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCanAdministerVersionContext(ctx, vc.OrgID); err != nil {
+		return nil, err
+	}
+
+	if err := db.VersionContexts.Delete(ctx, vc.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
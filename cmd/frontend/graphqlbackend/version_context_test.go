@@ -0,0 +1,165 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestCreateVersionContext(t *testing.T) {
+	const userID, orgID = 1, 2
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: userID}, nil
+	}
+	db.Mocks.OrgMembers.GetByOrgIDAndUserID = func(ctx context.Context, orgID, userID int32) (*types.OrgMembership, error) {
+		return &types.OrgMembership{OrgID: orgID, UserID: userID}, nil
+	}
+	var created *types.VersionContext
+	db.Mocks.VersionContexts.Create = func(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+		created = vc
+		return vc, nil
+	}
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		return &types.Repo{Name: name}, nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != "v1.0.0" {
+			return "", errors.New("revision not found")
+		}
+		return "deadbeef", nil
+	}
+	defer func() {
+		db.Mocks = db.MockStores{}
+		backend.Mocks = backend.MockServices{}
+		git.ResetMocks()
+	}()
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(userID))
+	org := marshalOrgID(orgID)
+	create := func(org *graphql.ID, rev string) error {
+		created = nil
+		_, err := (&schemaResolver{}).CreateVersionContext(ctx, &struct {
+			Name         string
+			Description  *string
+			Organization *graphql.ID
+			Revisions    []versionContextRevisionInput
+		}{
+			Name:         "release",
+			Organization: org,
+			Revisions:    []versionContextRevisionInput{{Repo: "github.com/sourcegraph/foo", Rev: rev}},
+		})
+		return err
+	}
+
+	t.Run("org member", func(t *testing.T) {
+		if err := create(&org, "v1.0.0"); err != nil {
+			t.Fatal(err)
+		}
+		if created == nil || created.OrgID != orgID || len(created.Revisions) != 1 {
+			t.Errorf("got created version context %+v", created)
+		}
+	})
+
+	t.Run("missing revision", func(t *testing.T) {
+		if err := create(&org, "v9.9.9"); err == nil {
+			t.Fatal("got nil error for missing revision")
+		}
+		if created != nil {
+			t.Error("version context with missing revision was created")
+		}
+	})
+
+	t.Run("site-wide as non-admin", func(t *testing.T) {
+		if err := create(nil, "v1.0.0"); err != backend.ErrMustBeSiteAdmin {
+			t.Errorf("got error %v, want %v", err, backend.ErrMustBeSiteAdmin)
+		}
+	})
+}
+
+func TestResolveVersionContext_notFound(t *testing.T) {
+	db.Mocks.VersionContexts.GetByName = func(ctx context.Context, name string) (*types.VersionContext, error) {
+		return nil, &errcode.Mock{Message: "version context not found", IsNotFound: true}
+	}
+	defer func() { db.Mocks.VersionContexts = db.MockVersionContexts{} }()
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{},
+	}})
+	defer conf.Mock(nil)
+
+	if _, err := resolveVersionContext(context.Background(), "nonexistent"); err == nil {
+		t.Error("got nil error for nonexistent version context")
+	}
+}
+
+func TestVersionContext_access(t *testing.T) {
+	const userID, orgID = 1, 2
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{ID: userID}, nil
+	}
+	db.Mocks.OrgMembers.GetByOrgIDAndUserID = func(ctx context.Context, orgID, userID int32) (*types.OrgMembership, error) {
+		return nil, &errcode.Mock{Message: "not a member", IsNotFound: true}
+	}
+	db.Mocks.VersionContexts.GetByName = func(ctx context.Context, name string) (*types.VersionContext, error) {
+		return &types.VersionContext{ID: 1, Name: name, OrgID: orgID}, nil
+	}
+	db.Mocks.VersionContexts.List = func(ctx context.Context) ([]*types.VersionContext, error) {
+		return []*types.VersionContext{
+			{ID: 1, Name: "org", OrgID: orgID},
+			{ID: 2, Name: "site"},
+		}, nil
+	}
+	db.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		if name != "github.com/sourcegraph/public" {
+			return nil, &errcode.Mock{Message: "repo not found", IsNotFound: true}
+		}
+		return &types.Repo{Name: name}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{},
+	}})
+	defer conf.Mock(nil)
+
+	ctx := actor.WithActor(context.Background(), actor.FromUser(userID))
+
+	t.Run("list", func(t *testing.T) {
+		vcs, err := (&schemaResolver{}).VersionContexts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(vcs) != 1 || vcs[0].Name() != "site" {
+			t.Errorf("got %d version contexts, want only the site-wide one", len(vcs))
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		if _, err := resolveVersionContext(ctx, "org"); err == nil {
+			t.Error("got nil error for version context of another org")
+		}
+	})
+
+	t.Run("revisions", func(t *testing.T) {
+		r := &versionContextResolver{vc: &types.VersionContext{Revisions: []*types.VersionContextRevision{
+			{Repo: "github.com/sourcegraph/public", Rev: "v1"},
+			{Repo: "github.com/sourcegraph/private", Rev: "v1"},
+		}}}
+		revs, err := r.Revisions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(revs) != 1 || revs[0].Repo() != "github.com/sourcegraph/public" {
+			t.Errorf("got %d revisions, want only the accessible repository", len(revs))
+		}
+	})
+}
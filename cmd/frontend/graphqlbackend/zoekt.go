@@ -13,6 +13,7 @@ import (
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
 	"github.com/pkg/errors"
+	searcherprotocol "github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gituri"
 	"github.com/sourcegraph/sourcegraph/internal/search"
@@ -55,6 +56,11 @@ func zoektSearchOpts(k int, query *search.TextPatternInfo) zoekt.SearchOptions {
 		MaxDocDisplayCount:     2 * defaultMaxSearchResults,
 	}
 
+	// The lines of context are computed from the whole file contents.
+	if query.ContextLines > 0 {
+		searchOpts.Whole = true
+	}
+
 	// We want zoekt to return more than FileMatchLimit results since we use
 	// the extra results to populate reposLimitHit. Additionally the defaults
 	// are very low, so we always want to return at least 2000.
@@ -247,6 +253,9 @@ func zoektSearchHEAD(ctx context.Context, args *search.TextParameters, repos []*
 				}
 			}
 		}
+		if contextLines := int(args.PatternInfo.ContextLines); contextLines > 0 {
+			addZoektContext(file.Content, lines, contextLines)
+		}
 		matches[i] = &FileMatchResolver{
 			JPath:        file.FileName,
 			JLineMatches: lines,
@@ -262,6 +271,20 @@ func zoektSearchHEAD(ctx context.Context, args *search.TextParameters, repos []*
 	return matches, limitHit, reposLimitHit, nil
 }
 
+// addZoektContext sets the lines of context of the line matches in the file
+// content.
+func addZoektContext(content []byte, lines []*lineMatch, contextLines int) {
+	lineNumbers := make([]int, len(lines))
+	for i, l := range lines {
+		lineNumbers[i] = int(l.JLineNumber)
+	}
+	before, after := searcherprotocol.LineContext(content, lineNumbers, contextLines)
+	for i, l := range lines {
+		l.JContextBefore = before[i]
+		l.JContextAfter = after[i]
+	}
+}
+
 // createNewRepoSetWithRepoHasFileInputs mutates repoSet such that it accounts
 // for the `repohasfile` and `-repohasfile` flags that may have been passed in
 // the query. As a convenience it returns the mutated RepoSet.
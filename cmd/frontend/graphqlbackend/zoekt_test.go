@@ -249,6 +249,53 @@ func TestZoektSearchHEAD(t *testing.T) {
 	}
 }
 
+func TestZoektSearchHEAD_contextLines(t *testing.T) {
+	if opts := zoektSearchOpts(1, &search.TextPatternInfo{ContextLines: 2}); !opts.Whole {
+		t.Error("expected whole files to be requested to compute lines of context")
+	}
+
+	searcher := &fakeSearcher{
+		result: &zoekt.SearchResult{
+			Files: []zoekt.FileMatch{{
+				Repository: "foo/bar",
+				FileName:   "baz.go",
+				Content:    []byte("a\nb\nc\nd\ne\n"),
+				LineMatches: []zoekt.LineMatch{
+					{Line: []byte("b"), LineNumber: 2, LineFragments: []zoekt.LineFragmentMatch{{MatchLength: 1}}},
+					{Line: []byte("d"), LineNumber: 4, LineFragments: []zoekt.LineFragmentMatch{{MatchLength: 1}}},
+				},
+			}},
+		},
+	}
+	args := &search.TextParameters{
+		PatternInfo: &search.TextPatternInfo{PathPatternsAreRegExps: true, FileMatchLimit: 100, ContextLines: 2},
+		Zoekt:       &searchbackend.Zoekt{Client: searcher},
+	}
+	fm, _, _, err := zoektSearchHEAD(context.Background(), args, makeRepositoryRevisions("foo/bar@master"), false, func(time.Time) time.Duration { return 0 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fm) != 1 || len(fm[0].JLineMatches) != 2 {
+		t.Fatalf("got file matches %+v, want 1 file match with 2 line matches", fm)
+	}
+	type context struct{ Before, After []string }
+	var got []*context
+	for _, lm := range fm[0].JLineMatches {
+		if c := lm.Context(); c != nil {
+			got = append(got, &context{c.Before(), c.After()})
+		} else {
+			got = append(got, nil)
+		}
+	}
+	want := []*context{
+		{Before: []string{"a"}, After: []string{"c"}},
+		{Before: nil, After: []string{"e"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got context %+v, want %+v", got, want)
+	}
+}
+
 func TestZoektIndexedRepos(t *testing.T) {
 	repos := makeRepositoryRevisions(
 		"foo/indexed-one@",
@@ -1,6 +1,7 @@
 package userpasswd
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/inconshreveable/log15"
@@ -52,6 +53,13 @@ func validateConfig(c conf.Unified) (problems conf.Problems) {
 	for _, p := range c.AuthProviders {
 		if p.Builtin != nil {
 			builtinAuthProviders++
+			if p.Builtin.Lockout != nil {
+				for _, proxy := range p.Builtin.Lockout.TrustedProxies {
+					if _, err := parseTrustedProxy(proxy); err != nil {
+						problems = append(problems, conf.NewSiteProblem(fmt.Sprintf("builtin auth provider lockout.trustedProxies: %s", err)))
+					}
+				}
+			}
 		}
 	}
 	if builtinAuthProviders >= 2 {
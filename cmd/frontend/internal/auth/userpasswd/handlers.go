@@ -213,12 +213,33 @@ func HandleSignIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 🚨 SECURITY: reject attempts from client IP addresses with too many failed attempts.
+	ip := clientIP(r)
+	if handleIPLockedOutCheck(w, ip) {
+		return
+	}
+
 	// Validate user. Allow login by both email and username (for convenience).
 	usr, err := getByEmailOrUsername(ctx, creds.Email)
 	if err != nil {
+		if errcode.IsNotFound(err) {
+			recordFailedAttempt(0, ip)
+		}
 		httpLogAndError(w, "Authentication failed", http.StatusUnauthorized, "err", err)
 		return
 	}
+	// 🚨 SECURITY: reject attempts for accounts that are locked out, even with the correct
+	// password, so that the password can't be brute-forced. The response is the same as for
+	// an unknown user or a wrong password, so that lockouts don't reveal which accounts exist.
+	lockedOut, err := lockouts.IsLockedOut(usr.ID)
+	if err != nil {
+		httpLogAndError(w, "Error checking account lockout", http.StatusInternalServerError, "err", err)
+		return
+	}
+	if lockedOut {
+		httpLogAndError(w, "Authentication failed", http.StatusUnauthorized, "err", "account is locked out", "userID", usr.ID, "ip", ip)
+		return
+	}
 	// 🚨 SECURITY: check password
 	correct, err := db.Users.IsPassword(ctx, usr.ID, creds.Password)
 	if err != nil {
@@ -226,9 +247,13 @@ func HandleSignIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if !correct {
+		recordFailedAttempt(usr.ID, ip)
 		httpLogAndError(w, "Authentication failed", http.StatusUnauthorized)
 		return
 	}
+	if err := lockouts.Reset(usr.ID); err != nil {
+		log15.Error("Failed to reset failed sign-in attempts.", "userID", usr.ID, "error", err)
+	}
 	actor := &actor.Actor{UID: usr.ID}
 
 	// Write the session cookie
@@ -238,6 +263,21 @@ func HandleSignIn(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleIPLockedOutCheck responds with an error if the client IP address is locked out because of
+// too many failed attempts.
+func handleIPLockedOutCheck(w http.ResponseWriter, ip string) (handled bool) {
+	lockedOut, err := lockouts.IsIPLockedOut(ip)
+	if err != nil {
+		httpLogAndError(w, "Error checking lockout", http.StatusInternalServerError, "err", err)
+		return true
+	}
+	if lockedOut {
+		httpLogAndError(w, "Too many failed attempts. Try again later.", http.StatusTooManyRequests, "ip", ip)
+		return true
+	}
+	return false
+}
+
 func httpLogAndError(w http.ResponseWriter, msg string, code int, errArgs ...interface{}) {
 	log15.Error(msg, errArgs...)
 	http.Error(w, msg, code)
@@ -0,0 +1,287 @@
+package userpasswd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/usagestats"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Default values for the builtin auth provider "lockout" site configuration.
+const (
+	defaultFailedAttemptThreshold   = 5
+	defaultIPFailedAttemptThreshold = 50
+	defaultConsecutivePeriod        = time.Hour
+	defaultLockoutPeriod            = 30 * time.Minute
+)
+
+// lockoutOptions are the effective brute-force protection settings.
+type lockoutOptions struct {
+	failedAttemptThreshold   int
+	ipFailedAttemptThreshold int
+	consecutivePeriod        time.Duration
+	lockoutPeriod            time.Duration
+}
+
+// getLockoutOptions returns the lockout settings from the builtin auth provider
+// config, falling back to the defaults for unset values.
+func getLockoutOptions() lockoutOptions {
+	opts := lockoutOptions{
+		failedAttemptThreshold:   defaultFailedAttemptThreshold,
+		ipFailedAttemptThreshold: defaultIPFailedAttemptThreshold,
+		consecutivePeriod:        defaultConsecutivePeriod,
+		lockoutPeriod:            defaultLockoutPeriod,
+	}
+
+	var c *schema.BuiltinAuthLockout
+	if pc, _ := getProviderConfig(); pc != nil {
+		c = pc.Lockout
+	}
+	if c == nil {
+		return opts
+	}
+	if c.FailedAttemptThreshold > 0 {
+		opts.failedAttemptThreshold = c.FailedAttemptThreshold
+	}
+	if c.IpFailedAttemptThreshold > 0 {
+		opts.ipFailedAttemptThreshold = c.IpFailedAttemptThreshold
+	}
+	if c.ConsecutivePeriod > 0 {
+		opts.consecutivePeriod = time.Duration(c.ConsecutivePeriod) * time.Second
+	}
+	if c.LockoutPeriod > 0 {
+		opts.lockoutPeriod = time.Duration(c.LockoutPeriod) * time.Second
+	}
+	return opts
+}
+
+// lockoutStore tracks failed authentication attempts per account and per client
+// IP address, and reports whether either is temporarily locked out.
+type lockoutStore interface {
+	// IsLockedOut reports whether the account is locked out. A userID of 0 is
+	// never locked out.
+	IsLockedOut(userID int32) (bool, error)
+	// IsIPLockedOut reports whether the client IP address is locked out.
+	IsIPLockedOut(ip string) (bool, error)
+	// IncreaseFailedAttempt records a failed attempt for the account (if userID
+	// is non-zero) and the client IP address. It reports whether the account or
+	// the IP address became locked out as a result.
+	IncreaseFailedAttempt(userID int32, ip string, opts lockoutOptions) (accountLockedOut, ipLockedOut bool, err error)
+	// Reset clears the failed attempt count and any lockout of the account.
+	Reset(userID int32) error
+}
+
+// lockouts is the store used by the handlers in this package. Tests may
+// override it.
+var lockouts lockoutStore = &redisLockoutStore{pool: redispool.Store}
+
+// redisLockoutStore is a lockoutStore backed by Redis. Attempt counters expire
+// after the consecutive period and lockouts expire after the lockout period, so
+// no background cleanup is needed.
+type redisLockoutStore struct {
+	pool *redis.Pool
+}
+
+const lockoutKeyPrefix = "account_lockout:"
+
+func userLockoutKey(userID int32) string { return fmt.Sprintf("%suser:%d", lockoutKeyPrefix, userID) }
+func ipLockoutKey(ip string) string      { return lockoutKeyPrefix + "ip:" + ip }
+
+func (s *redisLockoutStore) IsLockedOut(userID int32) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	return s.isLocked(userLockoutKey(userID))
+}
+
+func (s *redisLockoutStore) IsIPLockedOut(ip string) (bool, error) {
+	if ip == "" {
+		return false, nil
+	}
+	return s.isLocked(ipLockoutKey(ip))
+}
+
+func (s *redisLockoutStore) isLocked(key string) (bool, error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	return redis.Bool(c.Do("EXISTS", key+":locked"))
+}
+
+func (s *redisLockoutStore) IncreaseFailedAttempt(userID int32, ip string, opts lockoutOptions) (accountLockedOut, ipLockedOut bool, err error) {
+	c := s.pool.Get()
+	defer c.Close()
+
+	if userID != 0 {
+		accountLockedOut, err = s.increase(c, userLockoutKey(userID), opts.failedAttemptThreshold, opts)
+		if err != nil {
+			return false, false, err
+		}
+	}
+	if ip != "" {
+		ipLockedOut, err = s.increase(c, ipLockoutKey(ip), opts.ipFailedAttemptThreshold, opts)
+		if err != nil {
+			return false, false, err
+		}
+	}
+	return accountLockedOut, ipLockedOut, nil
+}
+
+// increaseScript increments the attempt counter at KEYS[1], refreshes its
+// expiry (ARGV[2] seconds) and locks the key out for ARGV[3] seconds once the
+// counter reaches the threshold ARGV[1]. It returns 1 if it caused the lockout.
+// A script is used so that the counter can't be left without an expiry.
+var increaseScript = redis.NewScript(1, `
+local count = redis.call("INCR", KEYS[1])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+if count < tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SETEX", KEYS[1] .. ":locked", ARGV[3], ARGV[4])
+-- Start counting afresh once the lockout expires.
+redis.call("DEL", KEYS[1])
+return 1
+`)
+
+// increase increments the attempt counter at key and locks the key out once
+// the counter reaches threshold. It reports whether this call caused the
+// lockout.
+func (s *redisLockoutStore) increase(c redis.Conn, key string, threshold int, opts lockoutOptions) (bool, error) {
+	return redis.Bool(increaseScript.Do(c, key,
+		threshold,
+		int(opts.consecutivePeriod/time.Second),
+		int(opts.lockoutPeriod/time.Second),
+		time.Now().UTC().Format(time.RFC3339),
+	))
+}
+
+func (s *redisLockoutStore) Reset(userID int32) error {
+	c := s.pool.Get()
+	defer c.Close()
+
+	key := userLockoutKey(userID)
+	_, err := c.Do("DEL", key, key+":locked")
+	return err
+}
+
+// UnlockAccount clears the lockout and the failed attempt count of the account.
+// It is used by site admins to unlock an account before its lockout expires.
+func UnlockAccount(userID int32) error {
+	if err := lockouts.Reset(userID); err != nil {
+		return err
+	}
+	logLockoutEvent("AccountUnlocked", userID, "")
+	return nil
+}
+
+// recordFailedAttempt records a failed authentication attempt and audits any
+// resulting lockout. Errors are logged rather than returned because they must
+// not prevent the caller from responding to the request.
+func recordFailedAttempt(userID int32, ip string) {
+	accountLockedOut, ipLockedOut, err := lockouts.IncreaseFailedAttempt(userID, ip, getLockoutOptions())
+	if err != nil {
+		log15.Error("Failed to record failed authentication attempt.", "userID", userID, "ip", ip, "error", err)
+		return
+	}
+	if accountLockedOut {
+		logLockoutEvent("AccountLockedOut", userID, ip)
+	}
+	if ipLockedOut {
+		logLockoutEvent("IPLockedOut", 0, ip)
+	}
+}
+
+// logLockoutEvent writes an audit record of a lockout change to the log and to
+// the event logs.
+func logLockoutEvent(eventName string, userID int32, ip string) {
+	log15.Warn("Authentication lockout: "+eventName, "userID", userID, "ip", ip)
+
+	argument, err := json.Marshal(struct {
+		IP string `json:"ip,omitempty"`
+	}{IP: ip})
+	if err != nil {
+		log15.Error("Failed to marshal lockout event argument.", "error", err)
+		return
+	}
+	if err := usagestats.LogBackendEvent(userID, eventName, argument); err != nil {
+		log15.Error("Failed to log lockout event.", "event", eventName, "userID", userID, "error", err)
+	}
+}
+
+// clientIP returns the IP address of the client that sent r. The
+// X-Forwarded-For header can be set to any value by clients, so it is only
+// used if the request comes from a trusted proxy. In that case the address
+// closest to Sourcegraph which is not a trusted proxy is used.
+func clientIP(r *http.Request) string {
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+
+	trusted := trustedProxies()
+	if !trusted(ip) {
+		return ip
+	}
+	forwarded := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+		ip = addr
+		if !trusted(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// trustedProxies returns a function that reports whether an IP address is one
+// of the trusted proxies in the builtin auth provider config.
+func trustedProxies() func(ip string) bool {
+	var nets []*net.IPNet
+	if pc, _ := getProviderConfig(); pc != nil && pc.Lockout != nil {
+		for _, p := range pc.Lockout.TrustedProxies {
+			if n, err := parseTrustedProxy(p); err == nil {
+				nets = append(nets, n)
+			}
+		}
+	}
+	return func(ip string) bool {
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return false
+		}
+		for _, n := range nets {
+			if n.Contains(parsed) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parseTrustedProxy parses an IP address or CIDR range of a trusted proxy.
+func parseTrustedProxy(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %q", s)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, n, err := net.ParseCIDR(s)
+	return n, err
+}
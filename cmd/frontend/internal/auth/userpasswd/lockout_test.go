@@ -0,0 +1,322 @@
+package userpasswd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// mockLockoutStore is an in-memory lockoutStore that ignores expiry.
+type mockLockoutStore struct {
+	attempts map[string]int
+	locked   map[string]bool
+}
+
+func newMockLockoutStore() *mockLockoutStore {
+	return &mockLockoutStore{attempts: map[string]int{}, locked: map[string]bool{}}
+}
+
+func (s *mockLockoutStore) IsLockedOut(userID int32) (bool, error) {
+	return s.locked[userLockoutKey(userID)], nil
+}
+
+func (s *mockLockoutStore) IsIPLockedOut(ip string) (bool, error) {
+	return s.locked[ipLockoutKey(ip)], nil
+}
+
+func (s *mockLockoutStore) IncreaseFailedAttempt(userID int32, ip string, opts lockoutOptions) (accountLockedOut, ipLockedOut bool, err error) {
+	increase := func(key string, threshold int) bool {
+		s.attempts[key]++
+		if s.attempts[key] < threshold {
+			return false
+		}
+		s.locked[key] = true
+		delete(s.attempts, key)
+		return true
+	}
+	if userID != 0 {
+		accountLockedOut = increase(userLockoutKey(userID), opts.failedAttemptThreshold)
+	}
+	if ip != "" {
+		ipLockedOut = increase(ipLockoutKey(ip), opts.ipFailedAttemptThreshold)
+	}
+	return accountLockedOut, ipLockedOut, nil
+}
+
+func (s *mockLockoutStore) Reset(userID int32) error {
+	delete(s.attempts, userLockoutKey(userID))
+	delete(s.locked, userLockoutKey(userID))
+	return nil
+}
+
+func TestHandleSignIn_Lockout(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		AuthProviders: []schema.AuthProviders{{Builtin: &schema.BuiltinAuthProvider{
+			Type: "builtin",
+			Lockout: &schema.BuiltinAuthLockout{
+				FailedAttemptThreshold:   3,
+				IpFailedAttemptThreshold: 5,
+			},
+		}}},
+		// Avoid writing lockout events to the DB.
+		ExperimentalFeatures: &schema.ExperimentalFeatures{EventLogging: "disabled"},
+	}})
+	defer conf.Mock(nil)
+
+	store := newMockLockoutStore()
+	orig := lockouts
+	lockouts = store
+	defer func() { lockouts = orig }()
+
+	db.Mocks.Users.GetByUsername = func(ctx context.Context, username string) (*types.User, error) {
+		if username != "alice" {
+			return nil, &errcode.Mock{Message: "user not found", IsNotFound: true}
+		}
+		return &types.User{ID: 1, Username: "alice"}, nil
+	}
+	db.Mocks.Users.IsPassword = func(ctx context.Context, id int32, password string) (bool, error) {
+		return password == "correct", nil
+	}
+	defer func() { db.Mocks.Users = db.MockUsers{} }()
+
+	signIn := func(username, password, ip string) int {
+		body := fmt.Sprintf(`{"email":%q,"password":%q}`, username, password)
+		req := httptest.NewRequest("POST", "/-/sign-in", strings.NewReader(body))
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		HandleSignIn(rec, req)
+		return rec.Code
+	}
+
+	t.Run("account", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if code := signIn("alice", "wrong", "10.0.0.1"); code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d: got status %d, want %d", i, code, http.StatusUnauthorized)
+			}
+		}
+		// The correct password must be rejected while the account is locked out, in the
+		// same way as a wrong password or an unknown user.
+		if code := signIn("alice", "correct", "10.0.0.2"); code != http.StatusUnauthorized {
+			t.Fatalf("got status %d, want %d", code, http.StatusUnauthorized)
+		}
+
+		if err := UnlockAccount(1); err != nil {
+			t.Fatal(err)
+		}
+		if locked, _ := store.IsLockedOut(1); locked {
+			t.Fatal("account still locked out after unlock")
+		}
+	})
+
+	t.Run("ip", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			if code := signIn(fmt.Sprintf("user%d", i), "wrong", "10.0.0.3"); code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d: got status %d, want %d", i, code, http.StatusUnauthorized)
+			}
+		}
+		if code := signIn("alice", "correct", "10.0.0.3"); code != http.StatusTooManyRequests {
+			t.Fatalf("got status %d, want %d", code, http.StatusTooManyRequests)
+		}
+		// Other client IP addresses are unaffected.
+		if locked, _ := store.IsIPLockedOut("10.0.0.4"); locked {
+			t.Fatal("unrelated IP address is locked out")
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		AuthProviders: []schema.AuthProviders{{Builtin: &schema.BuiltinAuthProvider{
+			Type:    "builtin",
+			Lockout: &schema.BuiltinAuthLockout{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}},
+		}}},
+	}})
+	defer conf.Mock(nil)
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		xForwardedFor string
+		want          string
+	}{
+		{name: "no header", remoteAddr: "203.0.113.1:4321", want: "203.0.113.1"},
+		{name: "untrusted client", remoteAddr: "203.0.113.1:4321", xForwardedFor: "198.51.100.1", want: "203.0.113.1"},
+		{name: "trusted proxy without header", remoteAddr: "192.168.1.1:4321", want: "192.168.1.1"},
+		{name: "trusted proxy", remoteAddr: "192.168.1.1:4321", xForwardedFor: "203.0.113.7", want: "203.0.113.7"},
+		{name: "spoofed header", remoteAddr: "192.168.1.1:4321", xForwardedFor: "198.51.100.1, 203.0.113.7, 10.1.2.3", want: "203.0.113.7"},
+		{name: "only trusted proxies", remoteAddr: "10.0.0.1:4321", xForwardedFor: "10.0.0.2", want: "10.0.0.2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.xForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", test.xForwardedFor)
+			}
+			if got := clientIP(req); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// newTestRedisLockoutStore returns a redisLockoutStore backed by a local
+// Redis, with the keys of userID and ip cleared. Like rcache.SetupForTest, it
+// skips the test if Redis is not available outside of CI.
+func newTestRedisLockoutStore(t *testing.T, userID int32, ip string) *redisLockoutStore {
+	t.Helper()
+	pool := &redis.Pool{
+		MaxIdle:     3,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", "127.0.0.1:6379")
+		},
+	}
+	c := pool.Get()
+	defer c.Close()
+	if _, err := c.Do("PING"); err != nil {
+		if os.Getenv("CI") == "" {
+			t.Skip("could not connect to redis", err)
+		}
+		t.Fatal(err)
+	}
+	if _, err := c.Do("DEL", userLockoutKey(userID), userLockoutKey(userID)+":locked", ipLockoutKey(ip), ipLockoutKey(ip)+":locked"); err != nil {
+		t.Fatal(err)
+	}
+	return &redisLockoutStore{pool: pool}
+}
+
+// redisInt returns the result of a Redis command returning an integer.
+func redisInt(t *testing.T, s *redisLockoutStore, cmd string, args ...interface{}) int {
+	t.Helper()
+	c := s.pool.Get()
+	defer c.Close()
+	n, err := redis.Int(c.Do(cmd, args...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRedisLockoutStore(t *testing.T) {
+	const userID, ip = 424242, "198.51.100.1"
+	opts := lockoutOptions{
+		failedAttemptThreshold:   3,
+		ipFailedAttemptThreshold: 10,
+		consecutivePeriod:        time.Minute,
+		lockoutPeriod:            2 * time.Minute,
+	}
+
+	t.Run("increment", func(t *testing.T) {
+		s := newTestRedisLockoutStore(t, userID, ip)
+		for i, want := range []bool{false, false, true} {
+			accountLockedOut, ipLockedOut, err := s.IncreaseFailedAttempt(userID, ip, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accountLockedOut != want || ipLockedOut {
+				t.Fatalf("attempt %d: got (%v, %v), want (%v, false)", i, accountLockedOut, ipLockedOut, want)
+			}
+			if i < 2 {
+				if n := redisInt(t, s, "GET", userLockoutKey(userID)); n != i+1 {
+					t.Errorf("attempt %d: got account counter %d, want %d", i, n, i+1)
+				}
+			}
+		}
+		if n := redisInt(t, s, "GET", ipLockoutKey(ip)); n != 3 {
+			t.Errorf("got IP counter %d, want 3", n)
+		}
+		// The account counter starts afresh once the account is locked out.
+		if n := redisInt(t, s, "EXISTS", userLockoutKey(userID)); n != 0 {
+			t.Error("account counter still exists after the lockout")
+		}
+		if locked, err := s.IsLockedOut(userID); err != nil || !locked {
+			t.Fatalf("got (%v, %v), want locked", locked, err)
+		}
+		if locked, err := s.IsIPLockedOut(ip); err != nil || locked {
+			t.Fatalf("got (%v, %v), want IP not locked", locked, err)
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		s := newTestRedisLockoutStore(t, userID, ip)
+		if _, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil {
+			t.Fatal(err)
+		}
+		if ttl := redisInt(t, s, "TTL", userLockoutKey(userID)); ttl <= 0 || ttl > 60 {
+			t.Errorf("got account counter TTL %ds, want within the consecutive period of 60s", ttl)
+		}
+		if ttl := redisInt(t, s, "TTL", ipLockoutKey(ip)); ttl <= 0 || ttl > 60 {
+			t.Errorf("got IP counter TTL %ds, want within the consecutive period of 60s", ttl)
+		}
+		for i := 0; i < 2; i++ {
+			if _, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if ttl := redisInt(t, s, "TTL", userLockoutKey(userID)+":locked"); ttl <= 60 || ttl > 120 {
+			t.Errorf("got lockout TTL %ds, want within the lockout period of 120s", ttl)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		s := newTestRedisLockoutStore(t, userID, ip)
+		opts := opts
+		opts.failedAttemptThreshold = 2
+		opts.consecutivePeriod = time.Second
+		opts.lockoutPeriod = time.Second
+
+		if _, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(1500 * time.Millisecond)
+		// The first attempt is not consecutive anymore.
+		if accountLockedOut, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil || accountLockedOut {
+			t.Fatalf("got (%v, %v) after the counter expired, want not locked out", accountLockedOut, err)
+		}
+		if accountLockedOut, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil || !accountLockedOut {
+			t.Fatalf("got (%v, %v), want locked out", accountLockedOut, err)
+		}
+		time.Sleep(1500 * time.Millisecond)
+		if locked, err := s.IsLockedOut(userID); err != nil || locked {
+			t.Fatalf("got (%v, %v) after the lockout expired, want not locked", locked, err)
+		}
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		s := newTestRedisLockoutStore(t, userID, ip)
+		for i := 0; i < 3; i++ {
+			if _, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, _, err := s.IncreaseFailedAttempt(userID, ip, opts); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Reset(userID); err != nil {
+			t.Fatal(err)
+		}
+		if locked, err := s.IsLockedOut(userID); err != nil || locked {
+			t.Fatalf("got (%v, %v), want not locked after reset", locked, err)
+		}
+		if n := redisInt(t, s, "EXISTS", userLockoutKey(userID), userLockoutKey(userID)+":locked"); n != 0 {
+			t.Errorf("got %d account keys after reset, want none", n)
+		}
+		// The IP address is not reset with the account.
+		if n := redisInt(t, s, "GET", ipLockoutKey(ip)); n != 4 {
+			t.Errorf("got IP counter %d after reset, want 4", n)
+		}
+	})
+}
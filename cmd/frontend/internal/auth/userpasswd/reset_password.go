@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/inconshreveable/log15"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
@@ -27,6 +28,12 @@ func HandleResetPasswordInit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 🚨 SECURITY: reject requests from client IP addresses that probed too many emails.
+	ip := clientIP(r)
+	if handleIPLockedOutCheck(w, ip) {
+		return
+	}
+
 	ctx := r.Context()
 	var formData struct {
 		Email string `json:"email"`
@@ -47,7 +54,9 @@ func HandleResetPasswordInit(w http.ResponseWriter, r *http.Request) {
 		// as to not leak the existence of a given e-mail address in the database.
 		if !errcode.IsNotFound(err) {
 			httpLogAndError(w, "Failed to lookup user", http.StatusInternalServerError)
+			return
 		}
+		recordFailedAttempt(0, ip)
 		return
 	}
 
@@ -104,6 +113,11 @@ func HandleResetPasswordCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := clientIP(r)
+	if handleIPLockedOutCheck(w, ip) {
+		return
+	}
+
 	ctx := r.Context()
 	var params struct {
 		UserID   int32  `json:"userID"`
@@ -122,9 +136,15 @@ func HandleResetPasswordCode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !success {
+		recordFailedAttempt(0, ip)
 		httpLogAndError(w, "Password reset failed", http.StatusUnauthorized)
 		return
 	}
+
+	// The user proved ownership of the account, so any lockout no longer applies.
+	if err := lockouts.Reset(params.UserID); err != nil {
+		log15.Error("Failed to reset failed sign-in attempts.", "userID", params.UserID, "error", err)
+	}
 }
 
 func handleNotAuthenticatedCheck(w http.ResponseWriter, r *http.Request) (handled bool) {
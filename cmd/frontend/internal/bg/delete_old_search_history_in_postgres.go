@@ -0,0 +1,29 @@
+package bg
+
+import (
+	"context"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+)
+
+// DeleteOldSearchHistoryInPostgres periodically deletes the searches which
+// are older than the search history retention period of the site
+// configuration.
+func DeleteOldSearchHistoryInPostgres(ctx context.Context) {
+	for {
+		if err := deleteOldSearchHistory(ctx, time.Now()); err != nil {
+			log15.Error("deleting expired rows from search_history table", "error", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func deleteOldSearchHistory(ctx context.Context, now time.Time) error {
+	// If the retention period is 0, search history is not saved and all of
+	// it is deleted.
+	_, err := db.SearchHistory.DeleteOlderThan(ctx, now.AddDate(0, 0, -conf.SearchHistoryRetentionDays()))
+	return err
+}
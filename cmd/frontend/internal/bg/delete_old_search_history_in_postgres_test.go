@@ -0,0 +1,44 @@
+package bg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestDeleteOldSearchHistory(t *testing.T) {
+	now := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	var deletedBefore time.Time
+	db.Mocks.SearchHistory.DeleteOlderThan = func(ctx context.Context, t time.Time) (int, error) {
+		deletedBefore = t
+		return 0, nil
+	}
+	defer func() { db.Mocks.SearchHistory = db.MockSearchHistory{} }()
+	defer conf.Mock(nil)
+
+	days := func(n int) *int { return &n }
+	tests := map[string]struct {
+		retentionDays *int
+		want          time.Time
+	}{
+		"default":  {retentionDays: nil, want: now.AddDate(0, 0, -90)},
+		"custom":   {retentionDays: days(7), want: now.AddDate(0, 0, -7)},
+		"disabled": {retentionDays: days(0), want: now},
+		"negative": {retentionDays: days(-1), want: now.AddDate(0, 0, -90)},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{SearchHistoryRetentionDays: test.retentionDays}})
+			if err := deleteOldSearchHistory(context.Background(), now); err != nil {
+				t.Fatal(err)
+			}
+			if !deletedBefore.Equal(test.want) {
+				t.Errorf("got searches deleted before %v, want %v", deletedBefore, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package globalsymbols maintains the global symbol index: the symbols of the
+// default branch of all repositories, stored in the database so that symbol
+// searches don't need to query the symbols service for each repository.
+//
+// A background worker periodically resolves the default branch of each
+// repository and, when it moved since the repository was last indexed, lists
+// the symbols of the new commit from the symbols service and replaces the
+// indexed symbols of the repository with them. Only one frontend replica
+// updates the index at a time.
+package globalsymbols
+
+import (
+	"context"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// Store is the subset of db.GlobalSymbols used by the Worker.
+type Store interface {
+	ListIndexes(ctx context.Context, repoIDs []api.RepoID) ([]*types.GlobalSymbolsIndex, error)
+	Replace(ctx context.Context, index *types.GlobalSymbolsIndex, symbols []*types.GlobalSymbol) error
+	TryLockUpdate(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+const (
+	// symbolsPageSize is the number of symbols requested from the symbols
+	// service at once, which is the maximum it returns.
+	symbolsPageSize = 500
+
+	// maxSymbolsPerRepo is the maximum number of symbols indexed per
+	// repository. The index of repositories with more symbols is marked as
+	// limit hit, and they are searched with the symbols service instead.
+	maxSymbolsPerRepo = 100000
+
+	// listIndexesBatchSize is the number of repositories whose index state
+	// is read at once.
+	listIndexesBatchSize = 1000
+)
+
+// Worker keeps the global symbol index up to date with the default branches
+// of repositories.
+type Worker struct {
+	Store Store
+
+	// ListRepos returns the repositories to index.
+	ListRepos func(ctx context.Context) ([]*types.Repo, error)
+
+	// ResolveHEAD returns the commit of the default branch of a repository.
+	ResolveHEAD func(ctx context.Context, repo api.RepoName) (api.CommitID, error)
+
+	// ListSymbols returns the symbols of a commit matching the arguments.
+	ListSymbols func(ctx context.Context, args search.SymbolsParameters) ([]protocol.Symbol, error)
+
+	// PollInterval is how long the worker waits between updates of the
+	// index.
+	PollInterval time.Duration
+
+	// Concurrency is the maximum number of repositories updated at once.
+	Concurrency int
+}
+
+// NewWorker returns a Worker which stores the index in the database and
+// lists symbols with the symbols service.
+func NewWorker() *Worker {
+	return &Worker{
+		Store: db.GlobalSymbols,
+		ListRepos: func(ctx context.Context) ([]*types.Repo, error) {
+			return db.Repos.List(ctx, db.ReposListOptions{})
+		},
+		ResolveHEAD: func(ctx context.Context, repo api.RepoName) (api.CommitID, error) {
+			return git.ResolveRevision(ctx, gitserver.Repo{Name: repo}, nil, "HEAD", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+		},
+		ListSymbols:  backend.Symbols.ListTags,
+		PollInterval: 5 * time.Minute,
+		Concurrency:  8,
+	}
+}
+
+// Run runs the worker until ctx is canceled. The index is only updated while
+// the globalSymbolIndex experimental feature is enabled.
+func (w *Worker) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if conf.GlobalSymbolIndexEnabled() {
+			if err := w.Update(ctx); err != nil {
+				log15.Error("globalsymbols: updating the index failed", "error", err)
+			}
+		}
+
+		select {
+		case <-time.After(w.PollInterval):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Update reindexes the repositories whose default branch moved since they
+// were last indexed, or which were never indexed. Failures to index a
+// repository are logged and don't stop the update. If another frontend
+// replica is updating the index, Update returns without doing anything.
+func (w *Worker) Update(ctx context.Context) error {
+	unlock, ok, err := w.Store.TryLockUpdate(ctx)
+	if err != nil {
+		return errors.Wrap(err, "acquiring the update lock")
+	}
+	if !ok {
+		log15.Debug("globalsymbols: another replica is updating the index")
+		return nil
+	}
+	defer unlock()
+
+	repos, err := w.ListRepos(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing repositories")
+	}
+
+	concurrency := w.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	for len(repos) > 0 && ctx.Err() == nil {
+		batch := repos
+		if len(batch) > listIndexesBatchSize {
+			batch = batch[:listIndexesBatchSize]
+		}
+		repos = repos[len(batch):]
+
+		repoIDs := make([]api.RepoID, len(batch))
+		for i, repo := range batch {
+			repoIDs[i] = repo.ID
+		}
+		indexes, err := w.Store.ListIndexes(ctx, repoIDs)
+		if err != nil {
+			return errors.Wrap(err, "listing indexes")
+		}
+		indexedCommits := make(map[api.RepoID]api.CommitID, len(indexes))
+		for _, index := range indexes {
+			indexedCommits[index.RepoID] = index.Commit
+		}
+
+		run := parallel.NewRun(concurrency)
+		for _, repo := range batch {
+			if ctx.Err() != nil {
+				break
+			}
+			repo := repo
+			run.Acquire()
+			go func() {
+				defer run.Release()
+				w.updateRepo(ctx, repo, indexedCommits[repo.ID])
+			}()
+		}
+		_ = run.Wait()
+	}
+	return ctx.Err()
+}
+
+// updateRepo reindexes a repository if its default branch is not at the
+// indexed commit (which is empty if the repository was never indexed).
+func (w *Worker) updateRepo(ctx context.Context, repo *types.Repo, indexed api.CommitID) {
+	head, err := w.ResolveHEAD(ctx, repo.Name)
+	if err != nil {
+		// The repository may be empty or not cloned yet.
+		log15.Debug("globalsymbols: resolving the default branch failed", "repo", repo.Name, "error", err)
+		return
+	}
+	if indexed == head {
+		return
+	}
+
+	start := time.Now()
+	if err := w.Index(ctx, repo, head); err != nil {
+		reposIndexed.WithLabelValues("error").Inc()
+		log15.Warn("globalsymbols: indexing repository failed", "repo", repo.Name, "commit", head, "error", err)
+		return
+	}
+	reposIndexed.WithLabelValues("success").Inc()
+	indexDuration.Observe(time.Since(start).Seconds())
+}
+
+// Index replaces the indexed symbols of a repository with the symbols of the
+// given commit. At most maxSymbolsPerRepo symbols are indexed.
+func (w *Worker) Index(ctx context.Context, repo *types.Repo, commit api.CommitID) error {
+	var (
+		symbols  []*types.GlobalSymbol
+		limitHit = true
+	)
+	for len(symbols) < maxSymbolsPerRepo {
+		page, err := w.ListSymbols(ctx, search.SymbolsParameters{
+			Repo:     repo.Name,
+			CommitID: commit,
+			First:    symbolsPageSize,
+			Offset:   len(symbols),
+		})
+		if err != nil {
+			return err
+		}
+		for _, s := range page {
+			symbols = append(symbols, &types.GlobalSymbol{
+				Name:       s.Name,
+				Kind:       s.Kind,
+				Path:       s.Path,
+				Line:       s.Line,
+				Language:   s.Language,
+				Parent:     s.Parent,
+				ParentKind: s.ParentKind,
+				Signature:  s.Signature,
+				Pattern:    s.Pattern,
+			})
+		}
+		if len(page) < symbolsPageSize {
+			limitHit = false
+			break
+		}
+	}
+	if len(symbols) > maxSymbolsPerRepo {
+		symbols = symbols[:maxSymbolsPerRepo]
+	}
+
+	return w.Store.Replace(ctx, &types.GlobalSymbolsIndex{
+		RepoID:   repo.ID,
+		Commit:   commit,
+		LimitHit: limitHit,
+	}, symbols)
+}
+
+var (
+	reposIndexed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_global_symbols_repos_indexed_total",
+		Help: "Number of repositories indexed in the global symbol index, by result.",
+	}, []string{"result"})
+
+	indexDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "src_global_symbols_index_duration_seconds",
+		Help:    "Time spent indexing the symbols of a repository in the global symbol index.",
+		Buckets: prometheus.ExponentialBuckets(.1, 4, 7), // 100ms -> 6.8min
+	})
+)
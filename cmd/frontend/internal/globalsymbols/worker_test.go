@@ -0,0 +1,252 @@
+package globalsymbols
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu       sync.Mutex
+	locked   bool
+	indexes  map[api.RepoID]*types.GlobalSymbolsIndex
+	symbols  map[api.RepoID][]*types.GlobalSymbol
+	replaced []api.RepoID
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		indexes: map[api.RepoID]*types.GlobalSymbolsIndex{},
+		symbols: map[api.RepoID][]*types.GlobalSymbol{},
+	}
+}
+
+func (s *memStore) ListIndexes(ctx context.Context, repoIDs []api.RepoID) ([]*types.GlobalSymbolsIndex, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var indexes []*types.GlobalSymbolsIndex
+	for _, id := range repoIDs {
+		if index, ok := s.indexes[id]; ok {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes, nil
+}
+
+func (s *memStore) Replace(ctx context.Context, index *types.GlobalSymbolsIndex, symbols []*types.GlobalSymbol) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indexes[index.RepoID] = index
+	s.symbols[index.RepoID] = symbols
+	s.replaced = append(s.replaced, index.RepoID)
+	sort.Slice(s.replaced, func(i, j int) bool { return s.replaced[i] < s.replaced[j] })
+	return nil
+}
+
+func (s *memStore) TryLockUpdate(ctx context.Context) (unlock func(), ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return nil, false, nil
+	}
+	s.locked = true
+	return func() {
+		s.mu.Lock()
+		s.locked = false
+		s.mu.Unlock()
+	}, true, nil
+}
+
+// names returns the names of the indexed symbols of a repository.
+func (s *memStore) names(repoID api.RepoID) []string {
+	var names []string
+	for _, sym := range s.symbols[repoID] {
+		names = append(names, sym.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fakeRepos are repositories with a default branch and the symbols of their
+// commits.
+type fakeRepos struct {
+	repos   []*types.Repo
+	heads   map[api.RepoName]api.CommitID
+	symbols map[api.CommitID][]string
+}
+
+func (f *fakeRepos) worker(store Store) *Worker {
+	return &Worker{
+		Store: store,
+		ListRepos: func(ctx context.Context) ([]*types.Repo, error) {
+			return f.repos, nil
+		},
+		ResolveHEAD: func(ctx context.Context, repo api.RepoName) (api.CommitID, error) {
+			head, ok := f.heads[repo]
+			if !ok {
+				return "", errors.New("empty repository")
+			}
+			return head, nil
+		},
+		ListSymbols: func(ctx context.Context, args search.SymbolsParameters) ([]protocol.Symbol, error) {
+			names := f.symbols[args.CommitID]
+			if args.Offset >= len(names) {
+				return nil, nil
+			}
+			names = names[args.Offset:]
+			if len(names) > args.First {
+				names = names[:args.First]
+			}
+			symbols := make([]protocol.Symbol, len(names))
+			for i, name := range names {
+				symbols[i] = protocol.Symbol{Name: name, Path: "a.go", Kind: "func", Language: "Go"}
+			}
+			return symbols, nil
+		},
+	}
+}
+
+func TestWorker_Update(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeRepos{
+		repos: []*types.Repo{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "empty"}},
+		heads: map[api.RepoName]api.CommitID{"a": "a1", "b": "b1"},
+		symbols: map[api.CommitID][]string{
+			"a1": {"Foo", "Bar"},
+			"a2": {"Foo", "Baz"},
+			"b1": {"Qux"},
+		},
+	}
+	store := newMemStore()
+	w := f.worker(store)
+	w.Concurrency = 2
+
+	if err := w.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.RepoID{1, 2}; !reflect.DeepEqual(store.replaced, want) {
+		t.Errorf("got indexed repos %v, want %v", store.replaced, want)
+	}
+	if got, want := store.names(1), []string{"Bar", "Foo"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got symbols %q, want %q", got, want)
+	}
+	if index := store.indexes[1]; index.Commit != "a1" || index.LimitHit {
+		t.Errorf("got index %+v, want commit a1", index)
+	}
+
+	t.Run("default branch unchanged", func(t *testing.T) {
+		store.replaced = nil
+		if err := w.Update(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(store.replaced) != 0 {
+			t.Errorf("got reindexed repos %v, want none", store.replaced)
+		}
+	})
+
+	t.Run("default branch moved", func(t *testing.T) {
+		store.replaced = nil
+		f.heads["a"] = "a2"
+		if err := w.Update(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if want := []api.RepoID{1}; !reflect.DeepEqual(store.replaced, want) {
+			t.Errorf("got reindexed repos %v, want %v", store.replaced, want)
+		}
+		if index := store.indexes[1]; index.Commit != "a2" {
+			t.Errorf("got index %+v, want commit a2", index)
+		}
+		// The symbols of the previous commit are replaced.
+		if got, want := store.names(1), []string{"Baz", "Foo"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got symbols %q, want %q", got, want)
+		}
+		if got, want := store.names(2), []string{"Qux"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got symbols of other repo %q, want %q", got, want)
+		}
+	})
+
+	t.Run("repository cloned", func(t *testing.T) {
+		store.replaced = nil
+		f.heads["empty"] = "e1"
+		if err := w.Update(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if want := []api.RepoID{3}; !reflect.DeepEqual(store.replaced, want) {
+			t.Errorf("got reindexed repos %v, want %v", store.replaced, want)
+		}
+	})
+
+	t.Run("locked by another replica", func(t *testing.T) {
+		store.replaced = nil
+		f.heads["a"] = "a1"
+		unlock, ok, _ := store.TryLockUpdate(ctx)
+		if !ok {
+			t.Fatal("update lock is held")
+		}
+		if err := w.Update(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(store.replaced) != 0 {
+			t.Errorf("got reindexed repos %v while locked, want none", store.replaced)
+		}
+
+		unlock()
+		if err := w.Update(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if want := []api.RepoID{1}; !reflect.DeepEqual(store.replaced, want) {
+			t.Errorf("got reindexed repos %v, want %v", store.replaced, want)
+		}
+	})
+}
+
+func TestWorker_Index(t *testing.T) {
+	ctx := context.Background()
+	names := func(n int) []string {
+		names := make([]string, n)
+		for i := range names {
+			names[i] = fmt.Sprintf("s%d", i)
+		}
+		return names
+	}
+	tests := map[string]struct {
+		numSymbols   int
+		wantIndexed  int
+		wantLimitHit bool
+	}{
+		"no symbols":     {numSymbols: 0, wantIndexed: 0},
+		"one page":       {numSymbols: symbolsPageSize - 1, wantIndexed: symbolsPageSize - 1},
+		"full page":      {numSymbols: symbolsPageSize, wantIndexed: symbolsPageSize},
+		"multiple pages": {numSymbols: 3*symbolsPageSize + 1, wantIndexed: 3*symbolsPageSize + 1},
+		"limit hit":      {numSymbols: maxSymbolsPerRepo + 1, wantIndexed: maxSymbolsPerRepo, wantLimitHit: true},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			f := &fakeRepos{symbols: map[api.CommitID][]string{"c": names(test.numSymbols)}}
+			store := newMemStore()
+			repo := &types.Repo{ID: 1, Name: "r"}
+			if err := f.worker(store).Index(ctx, repo, "c"); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(store.symbols[1]); got != test.wantIndexed {
+				t.Errorf("got %d indexed symbols, want %d", got, test.wantIndexed)
+			}
+			if index := store.indexes[1]; index.LimitHit != test.wantLimitHit {
+				t.Errorf("got limitHit %v, want %v", index.LimitHit, test.wantLimitHit)
+			}
+			if n := len(store.symbols[1]); n > 0 && store.symbols[1][n-1].Name != fmt.Sprintf("s%d", n-1) {
+				t.Errorf("got last symbol %q, want s%d", store.symbols[1][n-1].Name, n-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+)
+
+// gitMirrorHandler serves read-only clones and fetches of repositories over the
+// smart Git HTTP protocol, by proxying them to the gitserver shard that stores
+// the repository. This lets users and CI use Sourcegraph as a Git cache close
+// to them instead of the code host.
+//
+// Unlike the internal gitServiceHandler, which redirects to gitserver, it
+// requires an authenticated user who has access to the repository.
+type gitMirrorHandler struct {
+	Gitserver interface {
+		AddrForRepo(context.Context, api.RepoName) string
+		IsRepoCloned(context.Context, api.RepoName) (bool, error)
+	}
+
+	// Proxy proxies the requests to gitserver.
+	Proxy *gitserver.ReverseProxy
+}
+
+// maxConcurrentGitMirrorRequests is the maximum number of concurrent clones and
+// fetches proxied to gitserver. Clones of large repositories take a long time,
+// so they don't share the limit of the other gitserver requests.
+const maxConcurrentGitMirrorRequests = 50
+
+func newGitMirrorHandler() *gitMirrorHandler {
+	return &gitMirrorHandler{
+		Gitserver: gitserver.DefaultClient,
+		Proxy:     gitserver.NewReverseProxy(nil, parallel.NewRun(maxConcurrentGitMirrorRequests)),
+	}
+}
+
+func (h *gitMirrorHandler) serveInfoRefs(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "/info/refs")
+}
+
+func (h *gitMirrorHandler) serveGitUploadPack(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, "/git-upload-pack")
+}
+
+func (h *gitMirrorHandler) serve(w http.ResponseWriter, r *http.Request, gitPath string) {
+	// Only clones and fetches are supported.
+	if svc := r.URL.Query().Get("service"); svc != "" && svc != "git-upload-pack" {
+		http.Error(w, "only service git-upload-pack is supported", http.StatusForbidden)
+		return
+	}
+
+	// 🚨 SECURITY: Anonymous clones are not allowed, even on public
+	// instances. We ask Git clients for credentials, which are access tokens
+	// passed as the username.
+	if !actor.FromContext(r.Context()).IsAuthenticated() {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Sourcegraph"`)
+		http.Error(w, "Cloning requires an access token.", http.StatusUnauthorized)
+		return
+	}
+
+	// 🚨 SECURITY: Repository permissions are enforced when looking up the
+	// repository. We report repositories the user can't access as not found.
+	repo, err := h.getRepo(r.Context(), mux.Vars(r)["RepoName"])
+	if err != nil {
+		if _, ok := err.(backend.ErrRepoSeeOther); ok || errcode.IsNotFound(err) {
+			http.Error(w, "repository not found", http.StatusNotFound)
+			return
+		}
+		log15.Error("Git mirror: failed to look up repository", "repo", mux.Vars(r)["RepoName"], "error", err)
+		http.Error(w, "failed to look up repository", http.StatusInternalServerError)
+		return
+	}
+
+	cloned, err := h.Gitserver.IsRepoCloned(r.Context(), repo.Name)
+	if err != nil {
+		log15.Error("Git mirror: failed to check whether repository is cloned", "repo", repo.Name, "error", err)
+		http.Error(w, "failed to check whether repository is cloned", http.StatusBadGateway)
+		return
+	}
+	if !cloned {
+		if gitserverRepo, err := backend.GitRepo(r.Context(), repo); err == nil {
+			_, _ = repoupdater.DefaultClient.EnqueueRepoUpdate(r.Context(), gitserverRepo)
+		}
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "repository is being cloned, try again later", http.StatusServiceUnavailable)
+		return
+	}
+
+	addr := h.Gitserver.AddrForRepo(r.Context(), repo.Name)
+	director := func(req *http.Request) {
+		req.URL.Scheme = "http"
+		req.URL.Host = addr
+		req.URL.Path = path.Join("/git", string(repo.Name), gitPath)
+		// gitserver must not see the user's credentials.
+		req.Header.Del("Authorization")
+		req.Header.Del("Cookie")
+	}
+	h.Proxy.ServeHTTP(repo.Name, r.Method, "git"+gitPath, director, w, r)
+}
+
+// getRepo returns the repository with the given name, which may have the
+// ".git" suffix of clone URLs.
+func (h *gitMirrorHandler) getRepo(ctx context.Context, name string) (*types.Repo, error) {
+	repo, err := backend.Repos.GetByName(ctx, api.RepoName(name))
+	if errcode.IsNotFound(err) && strings.HasSuffix(name, ".git") {
+		repo, err = backend.Repos.GetByName(ctx, api.RepoName(strings.TrimSuffix(name, ".git")))
+	}
+	return repo, err
+}
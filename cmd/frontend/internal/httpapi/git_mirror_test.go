@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi/router"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+func TestGitMirror(t *testing.T) {
+	root, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// gitserver stores repositories in $ReposDir/$name/.git.
+	worktree := filepath.Join(root, "repos", "github.com", "foo", "bar")
+	for _, args := range [][]string{
+		{"init", worktree},
+		{"-C", worktree, "commit", "--allow-empty", "-m", "hello"},
+	} {
+		runGit(t, args...)
+	}
+
+	gs := httptest.NewServer((&server.Server{ReposDir: filepath.Join(root, "repos")}).Handler())
+	defer gs.Close()
+	cli := gitserver.NewClient(&http.Client{})
+	cli.Addrs = func(context.Context) []string {
+		u, _ := url.Parse(gs.URL)
+		return []string{u.Host}
+	}
+
+	h := &gitMirrorHandler{Gitserver: cli, Proxy: gitserver.NewReverseProxy(nil, nil)}
+	m := router.New(mux.NewRouter().PathPrefix("/.api/").Subrouter())
+	m.Get(router.GitMirrorInfoRefs).HandlerFunc(h.serveInfoRefs)
+	m.Get(router.GitMirrorUploadPack).HandlerFunc(h.serveGitUploadPack)
+	ts := httptest.NewServer(AccessTokenAuthMiddleware(m))
+	defer ts.Close()
+
+	db.Mocks.AccessTokens.Lookup = func(tokenHexEncoded, requiredScope string) (int32, error) {
+		if tokenHexEncoded != "secret" {
+			return 0, errors.New("invalid token")
+		}
+		return 1, nil
+	}
+	// The user only has access to github.com/foo/bar.
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		if name != "github.com/foo/bar" {
+			return nil, &errcode.Mock{Message: "repo not found", IsNotFound: true}
+		}
+		return &types.Repo{ID: 1, Name: name}, nil
+	}
+	defer func() {
+		db.Mocks = db.MockStores{}
+		backend.Mocks = backend.MockServices{}
+	}()
+
+	u, _ := url.Parse(ts.URL)
+	cloneURL := func(user, repo string) string {
+		u := *u
+		if user != "" {
+			u.User = url.UserPassword(user, "")
+		}
+		u.Path = "/.api/git/" + repo
+		return u.String()
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{name: "clone", url: cloneURL("secret", "github.com/foo/bar")},
+		{name: "clone with .git suffix", url: cloneURL("secret", "github.com/foo/bar.git")},
+		{name: "anonymous", url: cloneURL("", "github.com/foo/bar"), wantErr: "could not read Username"},
+		{name: "invalid token", url: cloneURL("wrong", "github.com/foo/bar"), wantErr: "Invalid access token"},
+		{name: "no access", url: cloneURL("secret", "github.com/foo/secret"), wantErr: "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "git-mirror-clone")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			cmd := exec.Command("git", "clone", test.url, filepath.Join(dir, "clone"))
+			cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=")
+			out, err := cmd.CombinedOutput()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("clone failed: %s\n%s", err, out)
+				}
+				log := runGit(t, "-C", filepath.Join(dir, "clone"), "log", "--format=%s")
+				if log != "hello" {
+					t.Errorf("got log %q, want %q", log, "hello")
+				}
+				return
+			}
+			if err == nil || !bytes.Contains(out, []byte(test.wantErr)) {
+				t.Fatalf("expected clone to fail with %q, got error %v\n%s", test.wantErr, err, out)
+			}
+		})
+	}
+}
+
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com",
+		"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
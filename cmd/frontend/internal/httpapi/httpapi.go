@@ -57,6 +57,12 @@ func NewHandler(m *mux.Router, schema *graphql.Schema, githubWebhook, bitbucketS
 		m.Get(apirouter.BitbucketServerWebhooks).Handler(trace.TraceRoute(bitbucketServerWebhook))
 	}
 
+	m.Get(apirouter.PushWebhooks).Handler(trace.TraceRoute(http.HandlerFunc(servePushWebhook)))
+
+	gitMirror := newGitMirrorHandler()
+	m.Get(apirouter.GitMirrorInfoRefs).Handler(trace.TraceRoute(http.HandlerFunc(gitMirror.serveInfoRefs)))
+	m.Get(apirouter.GitMirrorUploadPack).Handler(trace.TraceRoute(http.HandlerFunc(gitMirror.serveGitUploadPack)))
+
 	if newCodeIntelUploadHandler != nil {
 		m.Get(apirouter.LSIFUpload).Handler(trace.TraceRoute(newCodeIntelUploadHandler(false)))
 	}
@@ -71,6 +77,8 @@ func NewHandler(m *mux.Router, schema *graphql.Schema, githubWebhook, bitbucketS
 	m.Get(apirouter.SrcCliVersion).Handler(trace.TraceRoute(handler(srcCliVersionServe)))
 	m.Get(apirouter.SrcCliDownload).Handler(trace.TraceRoute(handler(srcCliDownloadServe)))
 
+	m.Get(apirouter.RegistryExport).Handler(trace.TraceRoute(handler(registry.HandleRegistryExport)))
+	m.Get(apirouter.RegistryImport).Handler(trace.TraceRoute(handler(registry.HandleRegistryImport)))
 	m.Get(apirouter.Registry).Handler(trace.TraceRoute(handler(registry.HandleRegistry)))
 
 	m.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
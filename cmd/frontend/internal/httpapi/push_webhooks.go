@@ -0,0 +1,26 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/internal/repoupdater"
+)
+
+// servePushWebhook forwards push webhooks from code hosts to repo-updater, which
+// authenticates them and updates the pushed repositories.
+func servePushWebhook(w http.ResponseWriter, r *http.Request) {
+	resp, err := repoupdater.DefaultClient.PushWebhook(r.Context(), mux.Vars(r)["codeHost"], r)
+	if err != nil {
+		log15.Error("Forwarding push webhook to repo-updater failed", "error", err)
+		http.Error(w, "forwarding push webhook failed", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
@@ -13,7 +13,9 @@ const (
 	SrcCliVersion  = "src-cli.version"
 	SrcCliDownload = "src-cli.download"
 
-	Registry = "registry"
+	Registry       = "registry"
+	RegistryExport = "registry.export"
+	RegistryImport = "registry.import"
 
 	RepoShield  = "repo.shield"
 	RepoRefresh = "repo.refresh"
@@ -21,6 +23,10 @@ const (
 
 	GitHubWebhooks          = "github.webhooks"
 	BitbucketServerWebhooks = "bitbucketServer.webhooks"
+	PushWebhooks            = "push.webhooks"
+
+	GitMirrorInfoRefs   = "git.info-refs"
+	GitMirrorUploadPack = "git.upload-pack"
 
 	SavedQueriesListAll    = "internal.saved-queries.list-all"
 	SavedQueriesGetInfo    = "internal.saved-queries.get-info"
@@ -62,13 +68,20 @@ func New(base *mux.Router) *mux.Router {
 
 	base.StrictSlash(true)
 
+	// The registry export and import routes must be registered before the registry route, whose
+	// path prefix also matches them.
+	base.Path("/registry/export").Methods("GET").Name(RegistryExport)
+	base.Path("/registry/import").Methods("POST").Name(RegistryImport)
 	addRegistryRoute(base)
 	addGraphQLRoute(base)
 	base.Path("/github-webhooks").Methods("POST").Name(GitHubWebhooks)
 	base.Path("/bitbucket-server-webhooks").Methods("POST").Name(BitbucketServerWebhooks)
+	base.Path("/push-webhooks/{codeHost}").Methods("POST").Name(PushWebhooks)
 	base.Path("/lsif/upload").Methods("POST").Name(LSIFUpload)
 	base.Path("/src-cli/version").Methods("GET").Name(SrcCliVersion)
 	base.Path("/src-cli/{rest:.*}").Methods("GET").Name(SrcCliDownload)
+	base.Path("/git/{RepoName:.*}/info/refs").Methods("GET").Name(GitMirrorInfoRefs)
+	base.Path("/git/{RepoName:.*}/git-upload-pack").Methods("POST").Name(GitMirrorUploadPack)
 
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
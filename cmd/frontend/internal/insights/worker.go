@@ -0,0 +1,276 @@
+// Package insights computes the time series of code insights: the match
+// counts of saved searches in repositories over time.
+//
+// The points of a series are enqueued in the database as the time passes and
+// computed by a background worker, which searches the last commit of a
+// repository before the time of a point. Because the queue is stored in the
+// database, the computation is resumed after restarts and can be shared by
+// multiple frontend processes.
+package insights
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// Store is the subset of db.InsightSeries used by the Worker.
+type Store interface {
+	List(ctx context.Context, opt db.InsightSeriesListOptions) ([]*types.InsightSeries, error)
+	LastPointTimes(ctx context.Context, seriesID int32) (map[api.RepoID]time.Time, error)
+	EnqueuePoints(ctx context.Context, seriesID int32, repoIDs []api.RepoID, times []time.Time) error
+	Dequeue(ctx context.Context) (*types.InsightSeriesPoint, bool, error)
+	Complete(ctx context.Context, id int64, commit api.CommitID, matchCount int32, limitHit bool) error
+	Fail(ctx context.Context, id int64, failureMessage string) error
+	ResetStalled(ctx context.Context, startedBefore time.Time) (int, error)
+	CompletedPointByCommit(ctx context.Context, seriesID int32, repoID api.RepoID, commit api.CommitID) (*types.InsightSeriesPoint, bool, error)
+}
+
+// CountFunc runs a search query as the given user and returns the number of
+// matches, and whether the match limit was hit.
+type CountFunc func(ctx context.Context, userID int32, query string) (matchCount int32, limitHit bool, err error)
+
+const (
+	// maxPointsPerSchedule is the maximum number of points of a series
+	// enqueued at once per repository. Series with an early start time are
+	// backfilled over multiple schedules.
+	maxPointsPerSchedule = 100
+
+	// stalledTimeout is the time after which a point that is still
+	// processing is assumed to be abandoned by its worker.
+	stalledTimeout = 10 * time.Minute
+
+	// maxMatchCount is the match limit of the searches of points whose
+	// query doesn't specify a count.
+	maxMatchCount = 10000
+)
+
+// Worker enqueues the points of insight series as time passes and computes
+// their match counts.
+type Worker struct {
+	Store Store
+	Count CountFunc
+
+	// Now returns the current time. It defaults to time.Now.
+	Now func() time.Time
+
+	// PollInterval is how long the worker waits when there is nothing to do.
+	PollInterval time.Duration
+}
+
+// NewWorker returns a Worker which stores the series in the database.
+func NewWorker(count CountFunc) *Worker {
+	return &Worker{
+		Store:        db.InsightSeries,
+		Count:        count,
+		PollInterval: time.Minute,
+	}
+}
+
+// Run runs the worker until ctx is canceled.
+func (w *Worker) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := w.runOnce(ctx); err != nil {
+			log15.Error("insights: worker failed", "error", err)
+		}
+
+		select {
+		case <-time.After(w.PollInterval):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// runOnce requeues stalled points, enqueues new points and processes all
+// queued points.
+func (w *Worker) runOnce(ctx context.Context) error {
+	if n, err := w.Store.ResetStalled(ctx, w.now().Add(-stalledTimeout)); err != nil {
+		return errors.Wrap(err, "resetting stalled points")
+	} else if n > 0 {
+		log15.Info("insights: requeued stalled points", "count", n)
+	}
+	if err := w.Schedule(ctx); err != nil {
+		return errors.Wrap(err, "scheduling points")
+	}
+	for {
+		ok, err := w.ProcessNext(ctx)
+		if err != nil {
+			return errors.Wrap(err, "processing point")
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+func (w *Worker) now() time.Time {
+	if w.Now != nil {
+		return w.Now()
+	}
+	return time.Now()
+}
+
+// Schedule enqueues the points of all series which are due, i.e. the points
+// at StartTime plus a multiple of the interval that are not after the
+// current time and were not enqueued yet. The points are scheduled per
+// repository, so that a repository added to a series is backfilled from the
+// start time of the series.
+func (w *Worker) Schedule(ctx context.Context) error {
+	series, err := w.Store.List(ctx, db.InsightSeriesListOptions{})
+	if err != nil {
+		return err
+	}
+	now := w.now()
+	for _, s := range series {
+		lastTimes, err := w.Store.LastPointTimes(ctx, s.ID)
+		if err != nil {
+			return err
+		}
+
+		// The repositories with the same last point are enqueued together,
+		// which usually are all the repositories of the series.
+		type group struct {
+			last    time.Time
+			hasLast bool
+			repoIDs []api.RepoID
+		}
+		var groups []*group
+		byLast := map[int64]*group{}
+		for _, repoID := range s.RepoIDs {
+			last, ok := lastTimes[repoID]
+			g := byLast[last.Unix()]
+			if g == nil {
+				g = &group{last: last, hasLast: ok}
+				groups = append(groups, g)
+				byLast[last.Unix()] = g
+			}
+			g.repoIDs = append(g.repoIDs, repoID)
+		}
+
+		for _, g := range groups {
+			times := PointTimes(s, g.last, g.hasLast, now)
+			if len(times) == 0 {
+				continue
+			}
+			if err := w.Store.EnqueuePoints(ctx, s.ID, g.repoIDs, times); err != nil {
+				return err
+			}
+			pointsEnqueued.Add(float64(len(times) * len(g.repoIDs)))
+		}
+	}
+	return nil
+}
+
+// PointTimes returns the times of the points of the series which are due at
+// now, after the last enqueued point of a repository (if hasLast is true). At
+// most maxPointsPerSchedule times are returned.
+func PointTimes(s *types.InsightSeries, last time.Time, hasLast bool, now time.Time) []time.Time {
+	var times []time.Time
+	for i := 0; len(times) < maxPointsPerSchedule; i++ {
+		t := s.StartTime.AddDate(0, 0, i*int(s.IntervalDays))
+		if t.After(now) {
+			break
+		}
+		if hasLast && !t.After(last) {
+			continue
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// ProcessNext computes the next queued point. ok is false if no point is
+// queued. Failures to compute the point are recorded on the point and are
+// not returned.
+func (w *Worker) ProcessNext(ctx context.Context) (ok bool, err error) {
+	p, ok, err := w.Store.Dequeue(ctx)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	start := time.Now()
+	commit, matchCount, limitHit, err := w.compute(ctx, p)
+	if err != nil {
+		pointsProcessed.WithLabelValues("error").Inc()
+		log15.Warn("insights: computing point failed", "series", p.SeriesID, "repo", p.RepoName, "time", p.Time, "error", err)
+		return true, w.Store.Fail(ctx, p.ID, err.Error())
+	}
+	pointsProcessed.WithLabelValues("success").Inc()
+	pointDuration.Observe(time.Since(start).Seconds())
+	return true, w.Store.Complete(ctx, p.ID, commit, matchCount, limitHit)
+}
+
+// compute computes the match count of the point at the last commit of the
+// repository's default branch before the time of the point. The search runs
+// as the creator of the series, so that the count only includes the matches
+// the creator can see.
+func (w *Worker) compute(ctx context.Context, p *types.InsightSeriesPoint) (commit api.CommitID, matchCount int32, limitHit bool, err error) {
+	commits, err := git.Commits(ctx, gitserver.Repo{Name: p.RepoName}, git.CommitsOptions{
+		Range:  "HEAD",
+		N:      1,
+		Before: p.Time.Format(time.RFC3339),
+	})
+	if err != nil && !gitserver.IsRevisionNotFound(errors.Cause(err)) {
+		return "", 0, false, err
+	}
+	if len(commits) == 0 {
+		// The repository has no commits yet at the time of the point.
+		return "", 0, false, nil
+	}
+	commit = commits[0].ID
+
+	// The count doesn't change while the repository doesn't change, so
+	// there's no need to search the same commit again.
+	if prev, ok, err := w.Store.CompletedPointByCommit(ctx, p.SeriesID, p.RepoID, commit); err != nil {
+		return "", 0, false, err
+	} else if ok {
+		return commit, prev.MatchCount, prev.LimitHit, nil
+	}
+
+	matchCount, limitHit, err = w.Count(ctx, p.CreatorUserID, PointQuery(p.Query, p.RepoName, commit))
+	if err != nil {
+		return "", 0, false, err
+	}
+	return commit, matchCount, limitHit, nil
+}
+
+var countFieldPattern = regexp.MustCompile(`(^|\s)count:`)
+
+// PointQuery returns the query which searches the given commit of a
+// repository with the query of a series.
+func PointQuery(query string, repo api.RepoName, commit api.CommitID) string {
+	q := fmt.Sprintf("%s repo:^%s$@%s", query, regexp.QuoteMeta(string(repo)), commit)
+	if !countFieldPattern.MatchString(query) {
+		q += fmt.Sprintf(" count:%d", maxMatchCount)
+	}
+	return q
+}
+
+var (
+	pointsEnqueued = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_insights_points_enqueued_total",
+		Help: "Number of insight series points enqueued for computation.",
+	})
+
+	pointsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_insights_points_processed_total",
+		Help: "Number of insight series points processed, by result.",
+	}, []string{"result"})
+
+	pointDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "src_insights_point_duration_seconds",
+		Help:    "Time spent computing the match count of an insight series point.",
+		Buckets: prometheus.ExponentialBuckets(.01, 4, 7), // 10ms -> 40s
+	})
+)
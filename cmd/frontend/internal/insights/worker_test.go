@@ -0,0 +1,354 @@
+package insights
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+// memStore is an in-memory Store.
+type memStore struct {
+	mu     sync.Mutex
+	series []*types.InsightSeries
+	points []*types.InsightSeriesPoint
+	repos  map[api.RepoID]api.RepoName
+	query  string
+
+	// retry is whether failed points are dequeued again, i.e. whether
+	// their retry delay passed.
+	retry bool
+}
+
+func (s *memStore) List(ctx context.Context, opt db.InsightSeriesListOptions) ([]*types.InsightSeries, error) {
+	return s.series, nil
+}
+
+func (s *memStore) LastPointTimes(ctx context.Context, seriesID int32) (map[api.RepoID]time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := map[api.RepoID]time.Time{}
+	for _, p := range s.points {
+		if p.SeriesID == seriesID && p.Time.After(last[p.RepoID]) {
+			last[p.RepoID] = p.Time
+		}
+	}
+	return last, nil
+}
+
+func (s *memStore) EnqueuePoints(ctx context.Context, seriesID int32, repoIDs []api.RepoID, times []time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range times {
+		for _, repoID := range repoIDs {
+			s.points = append(s.points, &types.InsightSeriesPoint{
+				ID:       int64(len(s.points) + 1),
+				SeriesID: seriesID,
+				RepoID:   repoID,
+				Time:     t,
+				State:    "queued",
+			})
+		}
+	}
+	return nil
+}
+
+func (s *memStore) Dequeue(ctx context.Context) (*types.InsightSeriesPoint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.points {
+		if p.State == "queued" && (p.NumFailures == 0 || s.retry) {
+			p.State = "processing"
+			dequeued := *p
+			dequeued.RepoName = s.repos[p.RepoID]
+			dequeued.Query = s.query
+			for _, series := range s.series {
+				if series.ID == p.SeriesID {
+					dequeued.CreatorUserID = series.CreatorUserID
+				}
+			}
+			return &dequeued, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (s *memStore) Complete(ctx context.Context, id int64, commit api.CommitID, matchCount int32, limitHit bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.points[id-1]
+	p.State, p.Commit, p.MatchCount, p.LimitHit = "completed", commit, matchCount, limitHit
+	return nil
+}
+
+func (s *memStore) Fail(ctx context.Context, id int64, failureMessage string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.points[id-1]
+	p.NumFailures++
+	p.FailureMessage = failureMessage
+	p.State = "queued"
+	if p.NumFailures >= db.InsightSeriesMaxFailures {
+		p.State = "errored"
+	}
+	return nil
+}
+
+func (s *memStore) ResetStalled(ctx context.Context, startedBefore time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *memStore) CompletedPointByCommit(ctx context.Context, seriesID int32, repoID api.RepoID, commit api.CommitID) (*types.InsightSeriesPoint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.points {
+		if p.SeriesID == seriesID && p.RepoID == repoID && p.Commit == commit && p.State == "completed" {
+			return p, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// setupGitserver starts a gitserver with a repository "repo" whose commits
+// are at the given dates, and sets the default gitserver client to use it.
+// It returns the IDs of the commits.
+func setupGitserver(t *testing.T, dates ...string) (commits []string, cleanup func()) {
+	root, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// gitserver stores repositories in $ReposDir/$name/.git.
+	worktree := filepath.Join(root, "repos", "repo")
+	runGit(t, root, nil, "init", worktree)
+	for _, date := range dates {
+		runGit(t, worktree, []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}, "commit", "--allow-empty", "-m", date)
+	}
+	commits = strings.Fields(runGit(t, worktree, nil, "log", "--format=%H", "--reverse"))
+
+	gs := httptest.NewServer((&server.Server{ReposDir: filepath.Join(root, "repos")}).Handler())
+	oldAddrs := gitserver.DefaultClient.Addrs
+	gitserver.DefaultClient.Addrs = func(context.Context) []string {
+		u, _ := url.Parse(gs.URL)
+		return []string{u.Host}
+	}
+	return commits, func() {
+		gitserver.DefaultClient.Addrs = oldAddrs
+		gs.Close()
+		os.RemoveAll(root)
+	}
+}
+
+func runGit(t *testing.T, dir string, env []string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_NOSYSTEM=1", "HOME=/dev/null", "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com")
+	cmd.Env = append(cmd.Env, env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func TestWorker(t *testing.T) {
+	commits, cleanup := setupGitserver(t, "2020-01-02T00:00:00Z", "2020-01-03T00:00:00Z", "2020-01-10T00:00:00Z")
+	defer cleanup()
+
+	store := &memStore{
+		series: []*types.InsightSeries{{
+			ID:            1,
+			RepoIDs:       []api.RepoID{1},
+			IntervalDays:  7,
+			StartTime:     time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatorUserID: 2,
+		}},
+		repos: map[api.RepoID]api.RepoName{1: "repo"},
+		query: "foo",
+	}
+
+	var mu sync.Mutex
+	var queries []string
+	now := time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC)
+	w := &Worker{
+		Store: store,
+		Count: func(ctx context.Context, userID int32, query string) (int32, bool, error) {
+			if userID != 2 {
+				t.Errorf("got search as user %d, want as the creator of the series", userID)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			queries = append(queries, query)
+			if strings.Contains(query, commits[2]) {
+				return 0, false, errors.New("search failed")
+			}
+			return int32(len(queries)), false, nil
+		},
+		Now: func() time.Time { return now },
+	}
+
+	ctx := context.Background()
+	if err := w.runOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The points on Jan 1, 8 and 15 use no commit, the commit on Jan 3 and
+	// the commit on Jan 10.
+	type point struct {
+		Time       string
+		State      string
+		Commit     api.CommitID
+		MatchCount int32
+	}
+	var got []point
+	for _, p := range store.points {
+		got = append(got, point{p.Time.Format("Jan 2"), p.State, p.Commit, p.MatchCount})
+	}
+	want := []point{
+		{"Jan 1", "completed", "", 0},
+		{"Jan 8", "completed", api.CommitID(commits[1]), 1},
+		{"Jan 15", "queued", "", 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got points %+v, want %+v", got, want)
+	}
+	if p := store.points[2]; p.NumFailures != 1 || p.FailureMessage != "search failed" {
+		t.Errorf("expected the failure of the last point to be recorded, got %+v", p)
+	}
+
+	// The series is extended as time passes, failed points are retried and
+	// the count of a commit that was already searched is reused.
+	now = now.AddDate(0, 0, 7)
+	store.retry = true
+	w.Count = func(ctx context.Context, userID int32, query string) (int32, bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		queries = append(queries, query)
+		return 7, true, nil
+	}
+	if err := w.runOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var states []string
+	for _, p := range store.points {
+		states = append(states, p.Time.Format("Jan 2")+" "+p.State)
+	}
+	if want := []string{"Jan 1 completed", "Jan 8 completed", "Jan 15 completed", "Jan 22 completed"}; !reflect.DeepEqual(states, want) {
+		t.Errorf("got points %q, want %q", states, want)
+	}
+	if p := store.points[3]; p.Commit != api.CommitID(commits[2]) || p.MatchCount != 7 || !p.LimitHit {
+		t.Errorf("expected the count of the Jan 15 point to be reused, got %+v", p)
+	}
+
+	wantQueries := []string{
+		"foo repo:^repo$@" + commits[1] + " count:10000",
+		"foo repo:^repo$@" + commits[2] + " count:10000",
+		"foo repo:^repo$@" + commits[2] + " count:10000",
+	}
+	sort.Strings(queries)
+	sort.Strings(wantQueries)
+	if !reflect.DeepEqual(queries, wantQueries) {
+		t.Errorf("got queries %q, want %q", queries, wantQueries)
+	}
+}
+
+func TestWorker_Schedule_addedRepo(t *testing.T) {
+	store := &memStore{
+		series: []*types.InsightSeries{{
+			ID:           1,
+			RepoIDs:      []api.RepoID{1},
+			IntervalDays: 7,
+			StartTime:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		}},
+	}
+	now := time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC)
+	w := &Worker{Store: store, Now: func() time.Time { return now }}
+	points := func() []string {
+		var points []string
+		for _, p := range store.points {
+			points = append(points, fmt.Sprintf("%d %s", p.RepoID, p.Time.Format("Jan 2")))
+		}
+		return points
+	}
+
+	ctx := context.Background()
+	if err := w.Schedule(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := points(), []string{"1 Jan 1", "1 Jan 8", "1 Jan 15"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got points %q, want %q", got, want)
+	}
+
+	// A repository added to the series is backfilled, while the points of
+	// the other repository are only extended as time passes.
+	store.series[0].RepoIDs = []api.RepoID{1, 2}
+	now = now.AddDate(0, 0, 7)
+	if err := w.Schedule(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"1 Jan 1", "1 Jan 8", "1 Jan 15",
+		"1 Jan 22",
+		"2 Jan 1", "2 Jan 8", "2 Jan 15", "2 Jan 22",
+	}
+	if got := points(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got points %q, want %q", got, want)
+	}
+}
+
+func TestPointTimes(t *testing.T) {
+	s := &types.InsightSeries{IntervalDays: 7, StartTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	format := func(times []time.Time) []string {
+		var s []string
+		for _, t := range times {
+			s = append(s, t.Format("Jan 2"))
+		}
+		return s
+	}
+
+	now := time.Date(2020, 1, 22, 0, 0, 0, 0, time.UTC)
+	if got, want := format(PointTimes(s, time.Time{}, false, now)), []string{"Jan 1", "Jan 8", "Jan 15", "Jan 22"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := format(PointTimes(s, s.StartTime.AddDate(0, 0, 7), true, now)), []string{"Jan 15", "Jan 22"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := PointTimes(s, time.Time{}, false, s.StartTime.AddDate(0, 0, -1)); len(got) != 0 {
+		t.Errorf("got %q before the start time, want none", format(got))
+	}
+	if got := PointTimes(s, time.Time{}, false, s.StartTime.AddDate(100, 0, 0)); len(got) != maxPointsPerSchedule {
+		t.Errorf("got %d times, want %d", len(got), maxPointsPerSchedule)
+	}
+}
+
+func TestPointQuery(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"foo", "foo repo:^github\\.com/a/b$@c count:10000"},
+		{"foo count:5", "foo count:5 repo:^github\\.com/a/b$@c"},
+	}
+	for _, test := range tests {
+		if got := PointQuery(test.query, "github.com/a/b", "c"); got != test.want {
+			t.Errorf("PointQuery(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
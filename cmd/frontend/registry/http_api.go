@@ -8,3 +8,17 @@ var HandleRegistry = func(w http.ResponseWriter, r *http.Request) error {
 	http.Error(w, "no local extension registry exists", http.StatusNotFound)
 	return nil
 }
+
+// HandleRegistryExport is called to handle HTTP requests to export the local extension registry to
+// an archive. If there is no local extension registry, it returns an HTTP error response.
+var HandleRegistryExport = func(w http.ResponseWriter, r *http.Request) error {
+	http.Error(w, "no local extension registry exists", http.StatusNotFound)
+	return nil
+}
+
+// HandleRegistryImport is called to handle HTTP requests to import extensions into the local
+// extension registry. If there is no local extension registry, it returns an HTTP error response.
+var HandleRegistryImport = func(w http.ResponseWriter, r *http.Request) error {
+	http.Error(w, "no local extension registry exists", http.StatusNotFound)
+	return nil
+}
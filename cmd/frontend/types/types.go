@@ -95,6 +95,124 @@ type Org struct {
 	UpdatedAt   time.Time
 }
 
+// OrgRepositorySet is a named set of repositories owned by an organization. A
+// repository belongs to the set if its name is listed in RepositoryNames or
+// matches one of the NamePatterns.
+type OrgRepositorySet struct {
+	ID              int32
+	OrgID           int32
+	Name            string
+	Description     string
+	RepositoryNames []string
+	NamePatterns    []string
+	// IsDefault is whether the set is the default search scope for members of
+	// the organization. At most one set per organization is the default.
+	IsDefault bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// VersionContext is a named set of repository revisions that searches can be
+// scoped to. It is owned by an organization, or by the site if OrgID is zero.
+type VersionContext struct {
+	ID          int32
+	Name        string
+	Description string
+	OrgID       int32
+	Revisions   []*VersionContextRevision
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// VersionContextRevision is a revision of a repository in a version context.
+type VersionContextRevision struct {
+	Repo string `json:"repo"`
+	Rev  string `json:"rev"`
+}
+
+// InsightSeries is a time series of the match counts of a saved search in a
+// set of repositories. The series has a point for every repository every
+// IntervalDays days since StartTime.
+type InsightSeries struct {
+	ID            int32
+	SavedSearchID int32
+	RepoIDs       []api.RepoID
+	IntervalDays  int32
+	StartTime     time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// CreatorUserID is the user who created the series. The points of the
+	// series are computed with the permissions of this user.
+	CreatorUserID int32
+}
+
+// InsightSeriesPoint is the match count of the query of an insight series in
+// a repository at a point in time. The count is computed at Commit, the last
+// commit of the repository's default branch before Time, by a background
+// worker.
+type InsightSeriesPoint struct {
+	ID       int64
+	SeriesID int32
+	RepoID   api.RepoID
+	Time     time.Time
+	State    string // "queued", "processing", "completed" or "errored"
+
+	// Commit is empty if the repository has no commits before Time, in which
+	// case MatchCount is zero.
+	Commit     api.CommitID
+	MatchCount int32
+	LimitHit   bool
+
+	NumFailures    int32
+	FailureMessage string
+
+	// RepoName, Query and CreatorUserID are the name of the repository, the
+	// query of the saved search and the creator of the series. They are only
+	// set for points returned by db.InsightSeries.Dequeue.
+	RepoName      api.RepoName
+	Query         string
+	CreatorUserID int32
+}
+
+// GlobalSymbolsIndex records that the symbols of the default branch of a
+// repository are stored in the global symbol index, as they were at Commit.
+type GlobalSymbolsIndex struct {
+	RepoID    api.RepoID
+	Commit    api.CommitID
+	LimitHit  bool // only some of the symbols of Commit are stored
+	IndexedAt time.Time
+}
+
+// GlobalSymbol is a symbol of the default branch of a repository in the
+// global symbol index. Commit is the commit at which the symbol was indexed.
+type GlobalSymbol struct {
+	RepoID     api.RepoID
+	Commit     api.CommitID
+	Name       string
+	Kind       string
+	Path       string
+	Line       int
+	Language   string
+	Parent     string
+	ParentKind string
+	Signature  string
+	Pattern    string
+}
+
+// SearchHistoryEntry is a search run by a user, which is listed in their
+// search history.
+type SearchHistoryEntry struct {
+	ID             int64
+	UserID         int32
+	Query          string
+	PatternType    string // "literal", "regexp" or "structural"
+	VersionContext string
+	ResultCount    int32
+	Duration       time.Duration
+	CreatedAt      time.Time
+}
+
 type OrgMembership struct {
 	ID        int32
 	OrgID     int32
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
+)
+
+// archiveFormats are the archive formats handleArchive supports.
+var archiveFormats = map[string]bool{
+	"tar":    true,
+	"tar.gz": true,
+	"tgz":    true,
+	"zip":    true,
+}
+
+// archiveFilter selects the files of an archive, and replaces Git LFS pointers
+// with their objects. A filter with only dir set keeps the
+// archive as is.
+type archiveFilter struct {
+	// dir is the git dir of the archived repository.
+	dir GitDir
+
+	// lfs is true if Git LFS objects replace their pointers.
+	lfs bool
+
+	// include and exclude select files by path. If include is empty, all
+	// files are included.
+	include, exclude []glob.Glob
+
+	// maxFileSize, if positive, is the size in bytes above which files are
+	// included without their contents.
+	maxFileSize int64
+
+	// base, if set, is the commit against which files must have been added
+	// or modified in treeish to be included.
+	base, treeish string
+
+	// paths are the files changed since base, if set.
+	paths map[string]struct{}
+}
+
+// compileArchiveGlobs compiles the include or exclude patterns of an archive
+// request. "*" matches within a path component, and "**" matches any number
+// of path components, so "**/*.go" matches all Go files.
+func compileArchiveGlobs(patterns []string) ([]glob.Glob, error) {
+	var globs []glob.Glob
+	for _, pattern := range patterns {
+		alternatives := []string{pattern}
+		// "**/" also matches no directory at all.
+		if strings.HasPrefix(pattern, "**/") {
+			alternatives = append(alternatives, strings.TrimPrefix(pattern, "**/"))
+		}
+		for _, p := range alternatives {
+			g, err := glob.Compile(p, '/')
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid pattern %q", pattern)
+			}
+			globs = append(globs, g)
+		}
+	}
+	return globs, nil
+}
+
+func matchAny(globs []glob.Glob, name string) bool {
+	for _, g := range globs {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// keep returns true if the archive entry with the given header is included in
+// the archive. Directories are kept unless only changed files are included.
+func (f *archiveFilter) keep(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeDir || hdr.Typeflag == tar.TypeXGlobalHeader {
+		return f.base == ""
+	}
+	name := hdr.Name
+	if f.base != "" {
+		if _, ok := f.paths[name]; !ok {
+			return false
+		}
+	}
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return false
+	}
+	return !matchAny(f.exclude, name)
+}
+
+// changedFiles returns the paths of the files which were added or modified
+// between base and treeish.
+func changedFiles(ctx context.Context, dir GitDir, base, treeish string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff-tree", "-r", "-z", "--name-only", "--no-renames", "--diff-filter=d", base, treeish, "--")
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(wrapCmdError(cmd, err), "failed to list changed files")
+	}
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// archiveWriter converts a tar archive written to it to an archive in the
+// given format written to w, applying a filter.
+type archiveWriter struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func newArchiveWriter(ctx context.Context, filter *archiveFilter, format string, w io.Writer) io.WriteCloser {
+	pr, pw := io.Pipe()
+	a := &archiveWriter{pw: pw, done: make(chan error, 1)}
+	go func() {
+		err := convertArchive(ctx, filter, format, tar.NewReader(pr), w)
+		if err == nil {
+			// Discard the padding after the end of the tar archive.
+			_, err = io.Copy(ioutil.Discard, pr)
+		}
+		// Unblock writes if we stopped reading early.
+		_ = pr.CloseWithError(err)
+		a.done <- err
+	}()
+	return a
+}
+
+func (a *archiveWriter) Write(b []byte) (int, error) {
+	return a.pw.Write(b)
+}
+
+func (a *archiveWriter) Close() error {
+	_ = a.pw.Close()
+	return <-a.done
+}
+
+func convertArchive(ctx context.Context, filter *archiveFilter, format string, tr *tar.Reader, w io.Writer) error {
+	if filter.base != "" && filter.paths == nil {
+		changed, err := changedFiles(ctx, filter.dir, filter.base, filter.treeish)
+		if err != nil {
+			return err
+		}
+		filter.paths = make(map[string]struct{}, len(changed))
+		for _, p := range changed {
+			filter.paths[p] = struct{}{}
+		}
+	}
+
+	var (
+		tw *tar.Writer
+		zw *zip.Writer
+		gw *gzip.Writer
+	)
+	switch format {
+	case "zip":
+		zw = zip.NewWriter(w)
+	case "tar.gz", "tgz":
+		gw = gzip.NewWriter(w)
+		tw = tar.NewWriter(gw)
+	default:
+		tw = tar.NewWriter(w)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if !filter.keep(hdr) {
+			continue
+		}
+
+		var (
+			contents io.Reader = tr
+			obj      *os.File
+		)
+		if filter.maxFileSize > 0 && hdr.Typeflag == tar.TypeReg && hdr.Size > filter.maxFileSize {
+			contents, hdr.Size = bytes.NewReader(nil), 0
+		} else if filter.lfs && hdr.Typeflag == tar.TypeReg && hdr.Size <= lfs.MaxPointerSize {
+			buf, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			contents = bytes.NewReader(buf)
+			if f, p := openLFSObject(filter.dir, buf); f != nil {
+				if filter.maxFileSize > 0 && p.Size > filter.maxFileSize {
+					f.Close()
+					contents, hdr.Size = bytes.NewReader(nil), 0
+				} else {
+					obj, contents, hdr.Size = f, f, p.Size
+				}
+			}
+		}
+
+		err = writeArchiveEntry(tw, zw, hdr, contents)
+		if obj != nil {
+			obj.Close()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if zw != nil {
+		return zw.Close()
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gw != nil {
+		return gw.Close()
+	}
+	return nil
+}
+
+// writeArchiveEntry writes the tar entry with the given header and contents to
+// either tw or zw.
+func writeArchiveEntry(tw *tar.Writer, zw *zip.Writer, hdr *tar.Header, contents io.Reader) error {
+	if tw != nil {
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := io.Copy(tw, contents)
+		return err
+	}
+
+	// The global header contains the commit ID, which git archive stores in
+	// a comment of zip archives. We leave it out.
+	if hdr.Typeflag == tar.TypeXGlobalHeader {
+		return nil
+	}
+	fh, err := zip.FileInfoHeader(hdr.FileInfo())
+	if err != nil {
+		return err
+	}
+	// Like git archive -0, we don't compress.
+	fh.Name, fh.Method = hdr.Name, zip.Store
+	zf, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	if hdr.Typeflag == tar.TypeSymlink {
+		contents = strings.NewReader(hdr.Linkname)
+	}
+	_, err = io.Copy(zf, contents)
+	return err
+}
+
+// parseMaxFileSize parses the maxFileSize parameter of an archive request.
+func parseMaxFileSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid maxFileSize %q", s)
+	}
+	return n, nil
+}
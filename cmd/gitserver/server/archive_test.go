@@ -0,0 +1,163 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gobwas/glob"
+)
+
+func TestCompileArchiveGlobs(t *testing.T) {
+	globs, err := compileArchiveGlobs([]string{"**/*.go", "docs/*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"main.go":          true,
+		"cmd/foo/main.go":  true,
+		"main.gox":         false,
+		"docs/index.md":    true,
+		"docs/sub/doc.md":  false,
+		"other/docs/a.txt": false,
+	} {
+		if got := matchAny(globs, name); got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+
+	if _, err := compileArchiveGlobs([]string{"[a-"}); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestArchiveWriter(t *testing.T) {
+	root := tmpDir(t)
+	worktree := filepath.Join(root, "repo")
+	if err := os.MkdirAll(filepath.Join(worktree, "lib"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, worktree, name, arg...)
+	}
+	cmd("git", "init", ".")
+	writeFile(t, filepath.Join(worktree, "main.go"), []byte("package main\n"))
+	writeFile(t, filepath.Join(worktree, "data.json"), []byte("{}\n"))
+	writeFile(t, filepath.Join(worktree, "big.txt"), []byte(strings.Repeat("x", 100)))
+	writeFile(t, filepath.Join(worktree, "lib", "lib.go"), []byte("package lib\n"))
+	cmd("git", "add", ".")
+	cmd("git", "commit", "-m", "base")
+	writeFile(t, filepath.Join(worktree, "lib", "lib.go"), []byte("package lib // changed\n"))
+	writeFile(t, filepath.Join(worktree, "lib", "new.go"), []byte("package lib\n"))
+	cmd("git", "rm", "data.json")
+	cmd("git", "add", ".")
+	cmd("git", "commit", "-m", "head")
+	dir := GitDir(filepath.Join(worktree, ".git"))
+
+	tests := []struct {
+		name   string
+		filter archiveFilter
+		want   map[string]string
+	}{
+		{
+			name:   "unfiltered",
+			filter: archiveFilter{dir: dir},
+			want: map[string]string{
+				"main.go":    "package main\n",
+				"big.txt":    strings.Repeat("x", 100),
+				"lib/lib.go": "package lib // changed\n",
+				"lib/new.go": "package lib\n",
+			},
+		},
+		{
+			name:   "include and exclude",
+			filter: archiveFilter{dir: dir, include: mustCompileArchiveGlobs(t, "**/*.go"), exclude: mustCompileArchiveGlobs(t, "lib/new.go")},
+			want: map[string]string{
+				"main.go":    "package main\n",
+				"lib/lib.go": "package lib // changed\n",
+			},
+		},
+		{
+			name:   "max file size",
+			filter: archiveFilter{dir: dir, maxFileSize: 50},
+			want: map[string]string{
+				"main.go":    "package main\n",
+				"big.txt":    "",
+				"lib/lib.go": "package lib // changed\n",
+				"lib/new.go": "package lib\n",
+			},
+		},
+		{
+			name:   "changed files",
+			filter: archiveFilter{dir: dir, base: "HEAD~1", treeish: "HEAD"},
+			want: map[string]string{
+				"lib/lib.go": "package lib // changed\n",
+				"lib/new.go": "package lib\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		for _, format := range []string{"tar", "tar.gz"} {
+			t.Run(test.name+"/"+format, func(t *testing.T) {
+				filter := test.filter
+				var buf bytes.Buffer
+				w := newArchiveWriter(context.Background(), &filter, format, &buf)
+				cmd := exec.Command("git", "archive", "--format=tar", "HEAD")
+				cmd.Dir = string(dir)
+				cmd.Stdout = w
+				if err := cmd.Run(); err != nil {
+					t.Fatal(err)
+				}
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+
+				var r io.Reader = &buf
+				if format == "tar.gz" {
+					gr, err := gzip.NewReader(r)
+					if err != nil {
+						t.Fatal(err)
+					}
+					r = gr
+				}
+				got := map[string]string{}
+				tr := tar.NewReader(r)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					} else if err != nil {
+						t.Fatal(err)
+					}
+					if hdr.Typeflag != tar.TypeReg {
+						continue
+					}
+					b, _ := ioutil.ReadAll(tr)
+					got[hdr.Name] = string(b)
+				}
+				if !reflect.DeepEqual(got, test.want) {
+					t.Errorf("got %v, want %v", got, test.want)
+				}
+			})
+		}
+	}
+}
+
+func mustCompileArchiveGlobs(t *testing.T, patterns ...string) []glob.Glob {
+	t.Helper()
+	globs, err := compileArchiveGlobs(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return globs
+}
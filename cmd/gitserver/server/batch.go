@@ -0,0 +1,432 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// maxBatchCommands is the maximum number of commands in a batch request.
+const maxBatchCommands = 1000
+
+// maxBatchResponseBytes is the maximum total size of the outputs in a batch
+// response. Commands whose output doesn't fit are not run in the batch, so
+// that large outputs are streamed by exec requests instead of being held in
+// memory and encoded in a single JSON response.
+var maxBatchResponseBytes = 16 * 1024 * 1024
+
+// errNotRunInBatch is the error of the commands which were not run in a batch.
+const errNotRunInBatch = "command not run in batch"
+
+// handleBatch runs many read-only git commands in a repository and returns
+// all their results in a single response. Object lookups and file reads are
+// served by long-lived "git cat-file --batch" processes instead of a git
+// process per command. Other commands are run like in handleExec.
+//
+// The repository is never updated during a batch request. Commands whose
+// revision doesn't exist yet are not run, so that the client runs them with
+// an exec request, which updates the repository.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req protocol.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Commands) > maxBatchCommands {
+		http.Error(w, fmt.Sprintf("too many commands in batch: %d (max %d)", len(req.Commands), maxBatchCommands), http.StatusBadRequest)
+		return
+	}
+	for _, c := range req.Commands {
+		if !protocol.IsBatchCommand(c.Args) {
+			http.Error(w, fmt.Sprintf("command not supported in batch: %q", c.Args), http.StatusBadRequest)
+			return
+		}
+	}
+
+	req.Repo = protocol.NormalizeRepo(req.Repo)
+	ctx := r.Context()
+
+	start := time.Now()
+	status := "ok"
+	tr, ctx := trace.New(ctx, "batch", string(req.Repo))
+	defer func() {
+		tr.Finish()
+		batchDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+	}()
+
+	dir := s.dir(req.Repo)
+	if !repoCloned(dir) {
+		status = s.writeRepoNotCloned(ctx, w, req.Repo, req.URL)
+		return
+	}
+
+	b := &batch{repo: req.Repo, dir: dir, lfs: hasLFSObjects(dir)}
+	defer b.Close()
+
+	resp := protocol.BatchResponse{Results: make([]protocol.BatchResult, len(req.Commands))}
+	size := 0
+	for i, c := range req.Commands {
+		if !b.hasRevision(ctx, c.EnsureRevision) {
+			resp.Results[i] = notRunInBatch
+			continue
+		}
+		// The output of the command is limited to what is left of
+		// maxBatchResponseBytes, so that large outputs are not read in vain.
+		res := b.run(ctx, c.Args, maxBatchResponseBytes-size)
+		if res.NotRun || size+len(res.Stdout) > maxBatchResponseBytes {
+			resp.Results[i] = notRunInBatch
+			continue
+		}
+		size += len(res.Stdout)
+		resp.Results[i] = res
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(&resp); err != nil {
+		log15.Error("failed to write batch response", "repo", req.Repo, "error", err)
+	}
+}
+
+// batch runs the commands of a batch request in the repository at dir.
+type batch struct {
+	repo api.RepoName
+	dir  GitDir
+	lfs  bool // whether the repository has Git LFS objects
+
+	// check and contents are started on first use.
+	check    *catFile
+	contents *catFile
+
+	// revisions caches whether the revisions to ensure exist.
+	revisions map[string]bool
+}
+
+// notRunInBatch is the result of a command which has to be run on its own.
+var notRunInBatch = protocol.BatchResult{Error: errNotRunInBatch, NotRun: true}
+
+// hasRevision reports whether the revision rev, which a command wants to be
+// ensured, exists in the repository. Unlike Server.ensureRevision, it doesn't
+// update the repository, which would block the other commands of the batch.
+func (b *batch) hasRevision(ctx context.Context, rev string) bool {
+	if rev == "" || rev == "HEAD" {
+		return true
+	}
+	if exists, ok := b.revisions[rev]; ok {
+		return exists
+	}
+
+	name := rev
+	if isAbsoluteRevision(rev) {
+		// An OID is only checked for existence with ^0.
+		name = rev + "^0"
+	}
+	info, ok := b.lookup(ctx, false, name, 0)
+	// If the lookup failed, the command is run on its own as well.
+	exists := ok && !info.missing
+	if b.revisions == nil {
+		b.revisions = map[string]bool{}
+	}
+	b.revisions[rev] = exists
+	return exists
+}
+
+// run runs the git command with the given args. If its output is larger than
+// maxSize bytes, it is not run in the batch, and notRunInBatch is returned.
+func (b *batch) run(ctx context.Context, args []string, maxSize int) protocol.BatchResult {
+	switch {
+	case len(args) == 2 && args[0] == "rev-parse" && isBatchObjectName(args[1]):
+		if args[1] == "HEAD" {
+			if resolved, err := quickRevParseHead(b.dir); err == nil && isAbsoluteRevision(resolved) {
+				batchCommands.WithLabelValues("cat-file").Inc()
+				return protocol.BatchResult{Stdout: []byte(resolved)}
+			}
+		}
+		if info, ok := b.lookup(ctx, false, args[1], 0); ok && !info.missing {
+			batchCommands.WithLabelValues("cat-file").Inc()
+			return protocol.BatchResult{Stdout: []byte(info.oid + "\n")}
+		}
+
+	case len(args) >= 3 && args[0] == "cat-file" && args[1] == "-t" && catFileObjectName(args[2:]) != "":
+		if info, ok := b.lookup(ctx, false, catFileObjectName(args[2:]), 0); ok && !info.missing {
+			batchCommands.WithLabelValues("cat-file").Inc()
+			return protocol.BatchResult{Stdout: []byte(info.typ + "\n")}
+		}
+
+	case len(args) >= 3 && args[0] == "cat-file" && args[1] == "blob" && catFileObjectName(args[2:]) != "":
+		if info, ok := b.lookup(ctx, true, catFileObjectName(args[2:]), maxSize); ok && !info.missing && info.typ == "blob" {
+			if info.tooLarge {
+				return notRunInBatch
+			}
+			batchCommands.WithLabelValues("cat-file").Inc()
+			return protocol.BatchResult{Stdout: info.contents}
+		}
+
+	case isBlobRead(args) && isBatchObjectName(args[1]):
+		if info, ok := b.lookup(ctx, true, args[1], maxSize); ok && !info.missing && info.typ == "blob" {
+			if info.tooLarge {
+				return notRunInBatch
+			}
+			batchCommands.WithLabelValues("cat-file").Inc()
+			if !b.lfs {
+				return protocol.BatchResult{Stdout: info.contents}
+			}
+			// Show the contents of files stored in Git LFS instead of their
+			// pointers, like handleExec.
+			var buf bytes.Buffer
+			lw := newLFSBlobWriter(b.dir, &buf)
+			_, err := lw.Write(info.contents)
+			if err == nil {
+				err = lw.Close()
+			}
+			return protocol.BatchResult{Stdout: buf.Bytes(), Error: errorString(err)}
+		}
+	}
+
+	// Missing objects and all other commands are run as a git process, so
+	// that the output and errors are the same as for handleExec.
+	batchCommands.WithLabelValues("exec").Inc()
+	return b.exec(ctx, args, maxSize)
+}
+
+// exec runs the git command with the given args as a git process. The command
+// is aborted if it writes more than maxSize bytes to stdout.
+func (b *batch) exec(ctx context.Context, args []string, maxSize int) protocol.BatchResult {
+	ctx, cancel := context.WithTimeout(ctx, shortGitCommandTimeout(args))
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	limited := &batchOutputWriter{W: &stdout, N: maxSize, cancel: cancel}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = string(b.dir)
+	cmd.Stdout = limited
+	cmd.Stderr = &limitWriter{W: &stderr, N: 1024}
+
+	var filtered io.WriteCloser
+	if b.lfs && isBlobRead(args) {
+		filtered = newLFSBlobWriter(b.dir, limited)
+		cmd.Stdout = filtered
+	}
+
+	exitStatus, err := runCommand(ctx, cmd)
+	if filtered != nil {
+		if closeErr := filtered.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	if limited.exceeded {
+		return notRunInBatch
+	}
+	checkMaybeCorruptRepo(b.repo, b.dir, stderr.String())
+
+	return protocol.BatchResult{
+		Stdout:     stdout.Bytes(),
+		Stderr:     stderr.String(),
+		ExitStatus: exitStatus,
+		Error:      errorString(err),
+	}
+}
+
+// batchOutputWriter writes to W until more than N bytes are written to it. It
+// then fails all writes and calls cancel, which aborts the command writing to
+// it.
+type batchOutputWriter struct {
+	W      io.Writer
+	N      int
+	cancel context.CancelFunc
+
+	exceeded bool
+}
+
+var errBatchOutputTooLarge = errors.New("output too large for batch")
+
+func (w *batchOutputWriter) Write(p []byte) (int, error) {
+	if w.exceeded || len(p) > w.N {
+		w.exceeded = true
+		w.cancel()
+		return 0, errBatchOutputTooLarge
+	}
+	w.N -= len(p)
+	return w.W.Write(p)
+}
+
+// lookup looks up the object with the given name. If withContents is true,
+// the contents of the object are returned as well, unless they are larger
+// than maxSize bytes (see objectInfo.tooLarge). ok is false if the cat-file
+// process failed, in which case the command should be run as a git process.
+func (b *batch) lookup(ctx context.Context, withContents bool, name string, maxSize int) (info objectInfo, ok bool) {
+	c := &b.check
+	if withContents {
+		c = &b.contents
+	}
+	if *c == nil {
+		cf, err := startCatFile(ctx, b.dir, withContents)
+		if err != nil {
+			log15.Warn("failed to start git cat-file", "repo", b.repo, "error", err)
+			return info, false
+		}
+		*c = cf
+	}
+	info, err := (*c).lookup(name, int64(maxSize))
+	if err != nil {
+		log15.Warn("git cat-file failed", "repo", b.repo, "error", err)
+		(*c).Close()
+		*c = nil
+		return info, false
+	}
+	return info, true
+}
+
+// Close stops the cat-file processes of the batch.
+func (b *batch) Close() {
+	for _, c := range []**catFile{&b.check, &b.contents} {
+		if *c != nil {
+			(*c).Close()
+			*c = nil
+		}
+	}
+}
+
+// catFile is a running "git cat-file --batch" or "git cat-file --batch-check"
+// process, which looks up the object names written to its stdin.
+type catFile struct {
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	stdout       *bufio.Reader
+	withContents bool
+}
+
+func startCatFile(ctx context.Context, dir GitDir, withContents bool) (*catFile, error) {
+	mode := "--batch-check"
+	if withContents {
+		mode = "--batch"
+	}
+	cmd := exec.CommandContext(ctx, "git", "cat-file", mode)
+	cmd.Dir = string(dir)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "failed to start git cat-file")
+	}
+	return &catFile{
+		cmd:          cmd,
+		stdin:        stdin,
+		stdout:       bufio.NewReader(stdout),
+		withContents: withContents,
+	}, nil
+}
+
+// objectInfo describes an object looked up by catFile.
+type objectInfo struct {
+	oid      string
+	typ      string
+	size     int64
+	contents []byte // only set by "git cat-file --batch"
+
+	// tooLarge is true if the contents were not read because they are
+	// larger than the maximum size of the lookup.
+	tooLarge bool
+
+	// missing is true if the object doesn't exist or the name is ambiguous.
+	missing bool
+}
+
+// lookup looks up the object with the given name. The contents of objects
+// larger than maxSize bytes are skipped instead of read into memory.
+func (c *catFile) lookup(name string, maxSize int64) (objectInfo, error) {
+	if _, err := io.WriteString(c.stdin, name+"\n"); err != nil {
+		return objectInfo{}, err
+	}
+	line, err := c.stdout.ReadString('\n')
+	if err != nil {
+		return objectInfo{}, err
+	}
+	if strings.HasSuffix(line, " missing\n") || strings.HasSuffix(line, " ambiguous\n") {
+		return objectInfo{missing: true}, nil
+	}
+
+	// The header of an object is "<oid> <type> <size>".
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return objectInfo{}, fmt.Errorf("unexpected git cat-file output %q", line)
+	}
+	size, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return objectInfo{}, fmt.Errorf("unexpected git cat-file output %q", line)
+	}
+	info := objectInfo{oid: fields[0], typ: fields[1], size: size}
+	if c.withContents && size > maxSize {
+		// Skip the contents and the newline which follows them, so that the
+		// next object can be looked up.
+		if _, err := io.CopyN(ioutil.Discard, c.stdout, size+1); err != nil {
+			return objectInfo{}, err
+		}
+		info.tooLarge = true
+	} else if c.withContents {
+		// The contents are followed by a newline.
+		buf := make([]byte, size+1)
+		if _, err := io.ReadFull(c.stdout, buf); err != nil {
+			return objectInfo{}, err
+		}
+		info.contents = buf[:size]
+	}
+	return info, nil
+}
+
+func (c *catFile) Close() {
+	c.stdin.Close()
+	_ = c.cmd.Wait()
+}
+
+// isBatchObjectName returns true if name can be looked up by catFile.
+func isBatchObjectName(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "-") && !strings.ContainsAny(name, "\n")
+}
+
+// catFileObjectName returns the object name of the arguments of "git cat-file
+// -t" or "git cat-file blob" after the first, which may be preceded by "--".
+// It returns "" if there is no single object name.
+func catFileObjectName(args []string) string {
+	if len(args) == 2 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) != 1 || !isBatchObjectName(args[0]) {
+		return ""
+	}
+	return args[0]
+}
+
+var (
+	batchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "src_gitserver_batch_duration_seconds",
+		Help:    "A histogram of latencies for batch requests.",
+		Buckets: prometheus.ExponentialBuckets(.01, 4, 6), // 10ms -> 10s
+	}, []string{"status"})
+
+	batchCommands = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "src_gitserver_batch_commands_total",
+		Help: "Number of commands of batch requests, by whether they were served by git cat-file or a git process.",
+	}, []string{"served_by"})
+)
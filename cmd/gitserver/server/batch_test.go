@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+func TestHandleBatch(t *testing.T) {
+	root := tmpDir(t)
+	worktree := filepath.Join(root, "repo")
+	runCmd(t, root, "git", "init", worktree)
+	mkFiles(t, worktree, "dir/file")
+	writeFile(t, filepath.Join(worktree, "README"), []byte("hello world\n"))
+	writeFile(t, filepath.Join(worktree, "dir", "file"), []byte("file\n"))
+	runCmd(t, worktree, "git", "add", ".")
+	runCmd(t, worktree, "git", "commit", "-m", "hello")
+	runCmd(t, worktree, "git", "tag", "-a", "-m", "v1", "v1")
+	head := strings.TrimSpace(runCmd(t, worktree, "git", "rev-parse", "HEAD"))
+
+	commands := [][]string{
+		{"rev-parse", "HEAD"},
+		{"rev-parse", "v1"},
+		{"rev-parse", "v1^0"},
+		{"rev-parse", "missing^0"},
+		{"cat-file", "-t", "--", head},
+		{"cat-file", "-t", "--", strings.Repeat("a", 40)},
+		{"cat-file", "blob", head + ":README"},
+		{"show", head + ":README"},
+		{"show", head + ":dir"},
+		{"show", head + ":missing"},
+		{"ls-tree", "--full-name", "-z", head},
+		{"log", "-n", "1", "--format=%H %s"},
+	}
+
+	s := &Server{ReposDir: root}
+	req := protocol.BatchRequest{Repo: "repo"}
+	for _, args := range commands {
+		req.Commands = append(req.Commands, protocol.BatchCommand{Args: args})
+	}
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	s.handleBatch(w, httptest.NewRequest("POST", "/batch", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	var resp protocol.BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != len(commands) {
+		t.Fatalf("got %d results, want %d", len(resp.Results), len(commands))
+	}
+
+	// The results are the same as running the commands in the repository,
+	// except for the newline quickRevParseHead omits like handleExec.
+	dir := filepath.Join(worktree, ".git")
+	for i, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		exitStatus, err := runCommand(context.Background(), cmd)
+		want := protocol.BatchResult{Stdout: stdout.Bytes(), Stderr: stderr.String(), ExitStatus: exitStatus, Error: errorString(err)}
+		got := resp.Results[i]
+		if i == 0 {
+			got.Stdout = append(got.Stdout, '\n')
+		}
+		if got.Stdout == nil {
+			got.Stdout = []byte{}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("git %s: got %+v, want %+v", strings.Join(args, " "), got, want)
+		}
+	}
+}
+
+func TestHandleBatch_notRun(t *testing.T) {
+	root := tmpDir(t)
+	worktree := filepath.Join(root, "repo")
+	runCmd(t, root, "git", "init", worktree)
+	writeFile(t, filepath.Join(worktree, "a"), []byte("aaaa\n"))
+	writeFile(t, filepath.Join(worktree, "b"), []byte("bbbb\n"))
+	runCmd(t, worktree, "git", "add", ".")
+	runCmd(t, worktree, "git", "commit", "-m", "hello")
+	head := strings.TrimSpace(runCmd(t, worktree, "git", "rev-parse", "HEAD"))
+	missing := strings.Repeat("a", 40)
+
+	defer func(n int) { maxBatchResponseBytes = n }(maxBatchResponseBytes)
+	maxBatchResponseBytes = 8
+
+	s := &Server{ReposDir: root}
+	body, _ := json.Marshal(protocol.BatchRequest{Repo: "repo", Commands: []protocol.BatchCommand{
+		{EnsureRevision: head, Args: []string{"show", head + ":a"}},
+		// The repository is not updated to fetch a missing revision.
+		{EnsureRevision: missing, Args: []string{"show", missing + ":a"}},
+		// The output doesn't fit in the response anymore.
+		{EnsureRevision: head, Args: []string{"show", head + ":b"}},
+	}})
+	w := httptest.NewRecorder()
+	s.handleBatch(w, httptest.NewRequest("POST", "/batch", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	var resp protocol.BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	var notRun []bool
+	for _, res := range resp.Results {
+		notRun = append(notRun, res.NotRun)
+	}
+	if want := []bool{false, true, true}; !reflect.DeepEqual(notRun, want) {
+		t.Errorf("got commands not run %v, want %v", notRun, want)
+	}
+	if got := string(resp.Results[0].Stdout); got != "aaaa\n" {
+		t.Errorf("got stdout %q, want %q", got, "aaaa\n")
+	}
+}
+
+func TestHandleBatch_largeBlob(t *testing.T) {
+	root := tmpDir(t)
+	worktree := filepath.Join(root, "repo")
+	runCmd(t, root, "git", "init", worktree)
+	writeFile(t, filepath.Join(worktree, "a"), []byte("aaaa\n"))
+	writeFile(t, filepath.Join(worktree, "big"), bytes.Repeat([]byte("b"), 1000))
+	writeFile(t, filepath.Join(worktree, "c"), []byte("c\n"))
+	runCmd(t, worktree, "git", "add", ".")
+	runCmd(t, worktree, "git", "commit", "-m", "hello")
+	head := strings.TrimSpace(runCmd(t, worktree, "git", "rev-parse", "HEAD"))
+
+	defer func(n int) { maxBatchResponseBytes = n }(maxBatchResponseBytes)
+	maxBatchResponseBytes = 8
+
+	s := &Server{ReposDir: root}
+	body, _ := json.Marshal(protocol.BatchRequest{Repo: "repo", Commands: []protocol.BatchCommand{
+		{EnsureRevision: head, Args: []string{"show", head + ":a"}},
+		// The blobs are larger than the remaining budget, so their contents
+		// are skipped by cat-file.
+		{EnsureRevision: head, Args: []string{"show", head + ":big"}},
+		{EnsureRevision: head, Args: []string{"cat-file", "blob", head + ":big"}},
+		// The contents of the next blob are still read after skipping.
+		{EnsureRevision: head, Args: []string{"show", head + ":c"}},
+		// The git process is aborted once its output exceeds the budget.
+		{EnsureRevision: head, Args: []string{"ls-tree", head}},
+	}})
+	w := httptest.NewRecorder()
+	s.handleBatch(w, httptest.NewRequest("POST", "/batch", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	var resp protocol.BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	var notRun []bool
+	for _, res := range resp.Results {
+		notRun = append(notRun, res.NotRun)
+	}
+	if want := []bool{false, true, true, false, true}; !reflect.DeepEqual(notRun, want) {
+		t.Errorf("got commands not run %v, want %v", notRun, want)
+	}
+	if got := string(resp.Results[0].Stdout); got != "aaaa\n" {
+		t.Errorf("got stdout %q, want %q", got, "aaaa\n")
+	}
+	if got := string(resp.Results[3].Stdout); got != "c\n" {
+		t.Errorf("got stdout %q, want %q", got, "c\n")
+	}
+}
+
+func TestBatchOutputWriter(t *testing.T) {
+	var buf bytes.Buffer
+	canceled := false
+	w := &batchOutputWriter{W: &buf, N: 4, cancel: func() { canceled = true }}
+	if _, err := w.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("de")); err != errBatchOutputTooLarge {
+		t.Fatalf("got error %v, want %v", err, errBatchOutputTooLarge)
+	}
+	if !w.exceeded || !canceled {
+		t.Errorf("got exceeded %v and canceled %v, want both", w.exceeded, canceled)
+	}
+	if got := buf.String(); got != "abc" {
+		t.Errorf("got %q, want %q", got, "abc")
+	}
+}
+
+func TestHandleBatch_unsupportedCommand(t *testing.T) {
+	s := &Server{ReposDir: tmpDir(t)}
+	body, _ := json.Marshal(protocol.BatchRequest{
+		Repo:     "repo",
+		Commands: []protocol.BatchCommand{{Args: []string{"fetch", "origin"}}},
+	})
+	w := httptest.NewRecorder()
+	s.handleBatch(w, httptest.NewRequest("POST", "/batch", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -1,7 +1,6 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"hash/fnv"
@@ -27,14 +26,6 @@ import (
 	"github.com/inconshreveable/log15"
 )
 
-const (
-	// repoTTL is how often we should reclone a repository
-	repoTTL = time.Hour * 24 * 45
-	// repoTTLGC is how often we should reclone a repository once it is
-	// reporting git gc issues.
-	repoTTLGC = time.Hour * 24 * 2
-)
-
 var (
 	reposRemoved = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "src_gitserver_repos_removed",
@@ -42,7 +33,7 @@ var (
 	})
 	reposRecloned = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "src_gitserver_repos_recloned",
-		Help: "number of repos removed and recloned due to age",
+		Help: "number of repos removed and recloned due to corruption",
 	})
 	reposRemovedDiskPressure = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "src_gitserver_repos_removed_disk_pressure",
@@ -55,7 +46,8 @@ var (
 // 1. Remove corrupt repos.
 // 2. Remove stale lock files.
 // 3. Remove inactive repos on sourcegraph.com
-// 4. Reclone repos after a while. (simulate git gc)
+// 4. Reclone repos which git found to be corrupt.
+// 5. Run scheduled git maintenance tasks (see maintenanceTasks).
 func (s *Server) cleanupRepos() {
 	bCtx, bCancel := s.serverContext()
 	defer bCancel()
@@ -80,46 +72,20 @@ func (s *Server) cleanupRepos() {
 		return false, setGitAttributes(dir)
 	}
 
-	maybeReclone := func(dir GitDir) (done bool, err error) {
-		recloneTime, err := getRecloneTime(dir)
-		if err != nil {
+	maybeRecloneCorrupt := func(dir GitDir) (done bool, err error) {
+		maybeCorrupt, err := gitConfigGet(dir, "sourcegraph.maybeCorruptRepo")
+		if err != nil || maybeCorrupt == "" {
 			return false, err
 		}
-
-		// Add a jitter to spread out recloning of repos cloned at the same
-		// time.
-		var reason string
-		if maybeCorrupt, _ := gitConfigGet(dir, "sourcegraph.maybeCorruptRepo"); maybeCorrupt != "" {
-			reason = "maybeCorrupt"
-			// unset flag to stop constantly recloning if it fails.
-			_ = gitConfigUnset(dir, "sourcegraph.maybeCorruptRepo")
-		}
-		if time.Since(recloneTime) > repoTTL+jitterDuration(string(dir), repoTTL/4) {
-			reason = "old"
-		}
-		if time.Since(recloneTime) > repoTTLGC+jitterDuration(string(dir), repoTTLGC/4) {
-			if gclog, err := ioutil.ReadFile(dir.Path("gc.log")); err == nil && len(gclog) > 0 {
-				reason = fmt.Sprintf("git gc %s", string(bytes.TrimSpace(gclog)))
-			}
-		}
-		if reason == "" {
-			return false, nil
-		}
+		// unset flag to stop constantly recloning if it fails.
+		_ = gitConfigUnset(dir, "sourcegraph.maybeCorruptRepo")
 
 		ctx, cancel := context.WithTimeout(bCtx, longGitCommandTimeout)
 		defer cancel()
 
 		// name is the relative path to ReposDir, but without the .git suffix.
 		repo := s.name(dir)
-		log15.Info("recloning expired repo", "repo", repo, "cloned", recloneTime, "reason", reason)
-
-		// update the reclone time so that we don't constantly reclone if
-		// cloning fails. For example if a repo fails to clone due to being
-		// large, we will constantly be doing a clone which uses up lots of
-		// resources.
-		if err := setRecloneTime(dir, recloneTime.Add(time.Since(recloneTime)/2)); err != nil {
-			log15.Warn("setting backed off reclone time failed", "repo", repo, "cloned", recloneTime, "reason", reason, "error", err)
-		}
+		log15.Info("recloning corrupt repo", "repo", repo)
 
 		remoteURL, err := repoRemoteURL(ctx, dir)
 		if err != nil {
@@ -133,6 +99,14 @@ func (s *Server) cleanupRepos() {
 		return true, nil
 	}
 
+	maintain := func(dir GitDir) (done bool, err error) {
+		ctx, cancel := context.WithTimeout(bCtx, longGitCommandTimeout)
+		defer cancel()
+
+		_, err = s.runMaintenance(ctx, dir, time.Now())
+		return false, err
+	}
+
 	removeStaleLocks := func(dir GitDir) (done bool, err error) {
 		gitDir := string(dir)
 
@@ -179,11 +153,14 @@ func (s *Server) cleanupRepos() {
 		// We always want to have the same git attributes file at
 		// info/attributes.
 		{"ensure git attributes", ensureGitAttributes},
-		// Old git clones accumulate loose git objects that waste space and
-		// slow down git operations. Periodically do a fresh clone to avoid
-		// these problems. git gc is slow and resource intensive. It is
-		// cheaper and faster to just reclone the repository.
-		{"maybe reclone", maybeReclone},
+		// Git commands may have found the repository to be corrupt. A
+		// fresh clone is the only reliable fix.
+		{"maybe reclone corrupt", maybeRecloneCorrupt},
+		// Old git clones accumulate loose git objects and packs that waste
+		// space and slow down git operations. git gc is slow and resource
+		// intensive on large repositories, so we run smaller incremental
+		// maintenance tasks on a schedule instead.
+		{"run maintenance", maintain},
 	}
 
 	err := bestEffortWalk(s.ReposDir, func(dir string, fi os.FileInfo) error {
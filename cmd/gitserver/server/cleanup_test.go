@@ -52,7 +52,7 @@ func TestCleanupInactive(t *testing.T) {
 	}
 }
 
-func TestCleanupExpired(t *testing.T) {
+func TestCleanupMaybeCorrupt(t *testing.T) {
 	root, err := ioutil.TempDir("", "gitserver-test-")
 	if err != nil {
 		t.Fatal(err)
@@ -61,12 +61,11 @@ func TestCleanupExpired(t *testing.T) {
 
 	repoNew := path.Join(root, "repo-new", ".git")
 	repoOld := path.Join(root, "repo-old", ".git")
-	repoGCNew := path.Join(root, "repo-gc-new", ".git")
-	repoGCOld := path.Join(root, "repo-gc-old", ".git")
+	repoGC := path.Join(root, "repo-gc", ".git")
 	repoBoom := path.Join(root, "repo-boom", ".git")
 	repoCorrupt := path.Join(root, "repo-corrupt", ".git")
 	remote := path.Join(root, "remote", ".git")
-	for _, path := range []string{repoNew, repoOld, repoGCNew, repoGCOld, repoBoom, repoCorrupt, remote} {
+	for _, path := range []string{repoNew, repoOld, repoGC, repoBoom, repoCorrupt, remote} {
 		cmd := exec.Command("git", "--bare", "init", path)
 		if err := cmd.Run(); err != nil {
 			t.Fatal(err)
@@ -90,23 +89,14 @@ func TestCleanupExpired(t *testing.T) {
 		}
 		return fi.ModTime()
 	}
-	recloneTime := func(path string) time.Time {
-		t.Helper()
-		ts, err := getRecloneTime(GitDir(path))
-		if err != nil {
-			t.Fatal(err)
-		}
-		return ts
-	}
 
-	writeFile(t, filepath.Join(repoGCNew, "gc.log"), []byte("warning: There are too many unreachable loose objects; run 'git prune' to remove them."))
-	writeFile(t, filepath.Join(repoGCOld, "gc.log"), []byte("warning: There are too many unreachable loose objects; run 'git prune' to remove them."))
+	writeFile(t, filepath.Join(repoGC, "gc.log"), []byte("warning: There are too many unreachable loose objects; run 'git prune' to remove them."))
 
 	for path, delta := range map[string]time.Duration{
-		repoOld:     2 * repoTTL,
-		repoGCOld:   2 * repoTTLGC,
-		repoBoom:    2 * repoTTL,
-		repoCorrupt: repoTTLGC / 2, // should only trigger corrupt, not old
+		repoOld:     90 * 24 * time.Hour,
+		repoGC:      7 * 24 * time.Hour,
+		repoBoom:    time.Hour,
+		repoCorrupt: time.Hour,
 	} {
 		ts := time.Now().Add(-delta)
 		if err := setRecloneTime(GitDir(path), ts); err != nil {
@@ -116,51 +106,45 @@ func TestCleanupExpired(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	if err := gitConfigSet(GitDir(repoCorrupt), "sourcegraph.maybeCorruptRepo", "1"); err != nil {
-		t.Fatal(err)
+	for _, path := range []string{repoBoom, repoCorrupt} {
+		if err := gitConfigSet(GitDir(path), "sourcegraph.maybeCorruptRepo", "1"); err != nil {
+			t.Fatal(err)
+		}
 	}
 
-	now := time.Now()
 	repoNewTime := modTime(repoNew)
 	repoOldTime := modTime(repoOld)
-	repoGCNewTime := modTime(repoGCNew)
-	repoGCOldTime := modTime(repoGCOld)
-	repoCorruptTime := modTime(repoBoom)
+	repoGCTime := modTime(repoGC)
+	repoCorruptTime := modTime(repoCorrupt)
 	repoBoomTime := modTime(repoBoom)
-	repoBoomRecloneTime := recloneTime(repoBoom)
 
 	s := &Server{ReposDir: root}
 	s.Handler() // Handler as a side-effect sets up Server
 	s.cleanupRepos()
 
-	// repos that shouldn't be recloned
+	// repos that shouldn't be recloned. Old repos and repos with gc
+	// problems are maintained instead.
 	if repoNewTime.Before(modTime(repoNew)) {
 		t.Error("expected repoNew to not be modified")
 	}
-	if repoGCNewTime.Before(modTime(repoGCNew)) {
-		t.Error("expected repoGCNew to not be modified")
+	if repoOldTime.Before(modTime(repoOld)) {
+		t.Error("expected repoOld to not be recloned")
+	}
+	if repoGCTime.Before(modTime(repoGC)) {
+		t.Error("expected repoGC to not be recloned")
 	}
 
 	// repos that should be recloned
-	if !repoOldTime.Before(modTime(repoOld)) {
-		t.Error("expected repoOld to be recloned during clean up")
-	}
-	if !repoGCOldTime.Before(modTime(repoGCOld)) {
-		t.Error("expected repoGCOld to be recloned during clean up")
-	}
 	if !repoCorruptTime.Before(modTime(repoCorrupt)) {
 		t.Error("expected repoCorrupt to be recloned during clean up")
 	}
 
-	// repos that fail to clone need to have recloneTime updated
+	// repos that fail to clone should not be recloned again
 	if repoBoomTime.Before(modTime(repoBoom)) {
 		t.Fatal("expected repoBoom to fail to reclone due to hardcoding getRemoteURL failure")
 	}
-	if !repoBoomRecloneTime.Before(recloneTime(repoBoom)) {
-		t.Error("expected repoBoom reclone time to be updated")
-	}
-	if !now.After(recloneTime(repoBoom)) {
-		t.Error("expected repoBoom reclone time to be updated to not now")
+	if v, _ := gitConfigGet(GitDir(repoBoom), "sourcegraph.maybeCorruptRepo"); v != "" {
+		t.Error("expected repoBoom to not be marked as corrupt anymore")
 	}
 }
 
@@ -613,8 +597,8 @@ error: Could not read d24d09b8bc5d1ea2c3aa24455f4578db6aa3afda`,
 
 func TestJitterDuration(t *testing.T) {
 	f := func(key string) bool {
-		d := jitterDuration(key, repoTTLGC/4)
-		return 0 <= d && d < repoTTLGC/4
+		d := jitterDuration(key, 12*time.Hour)
+		return 0 <= d && d < 12*time.Hour
 	}
 	if err := quick.Check(f, nil); err != nil {
 		t.Error(err)
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"compress/gzip"
 	"io"
 	"net/http"
 	"os"
@@ -20,7 +21,8 @@ var uploadPackArgs = []string{
 	"-c", "uploadpack.allowFilter=true",
 
 	// Can fetch any object. Used in case of race between a resolve ref and a
-	// fetch of a commit. Safe to do, since this is only used internally.
+	// fetch of a commit. Safe to do, since users can only clone through the
+	// frontend if they have access to the whole repository.
 	"-c", "uploadpack.allowAnySHA1InWant=true",
 
 	"upload-pack",
@@ -95,6 +97,17 @@ func (s *gitServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	body := r.Body
 	defer body.Close()
 
+	// Git clients compress large requests.
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gzipBody, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(w, "malformed gzip request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer gzipBody.Close()
+		body = gzipBody
+	}
+
 	env := os.Environ()
 	if protocol := r.Header.Get("Git-Protocol"); protocol != "" {
 		env = append(env, "GIT_PROTOCOL="+protocol)
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
+)
+
+// Git LFS objects of a repository are stored in $GIT_DIR/lfs/objects, in the
+// same layout as the git-lfs client uses. We only fetch the objects of the
+// default branch, and show the Git LFS pointer files of other files.
+
+var lfsObjectsFetched = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "src_gitserver_lfs_objects_fetched",
+	Help: "number of Git LFS objects fetched",
+})
+
+// lfsClient downloads Git LFS objects. It is a variable so tests can replace
+// it.
+var lfsClient = func() *lfs.Client {
+	doer, err := httpcli.NewFactory(
+		httpcli.NewMiddleware(httpcli.ContextErrorMiddleware),
+		httpcli.ExternalTransportOpt,
+		httpcli.TracedTransportOpt,
+	).Doer()
+	if err != nil {
+		panic(err)
+	}
+	return &lfs.Client{Doer: doer}
+}()
+
+// lfsObjectPath returns the path at which the Git LFS object with the given
+// oid is stored.
+func lfsObjectPath(dir GitDir, oid string) string {
+	return dir.Path("lfs", "objects", oid[0:2], oid[2:4], oid)
+}
+
+// setLFSOptions records in the git config of the repository at dir whether,
+// and up to which size, to fetch Git LFS objects.
+func setLFSOptions(dir GitDir, opts *protocol.LFSOptions) error {
+	if !opts.Enabled {
+		return gitConfigUnset(dir, "sourcegraph.lfsMaxFileSize")
+	}
+	return gitConfigSet(dir, "sourcegraph.lfsMaxFileSize", strconv.FormatInt(opts.MaxFileSize, 10))
+}
+
+// getLFSOptions returns the Git LFS options recorded by setLFSOptions.
+func getLFSOptions(dir GitDir) (*protocol.LFSOptions, error) {
+	value, err := gitConfigGet(dir, "sourcegraph.lfsMaxFileSize")
+	if err != nil || value == "" {
+		return &protocol.LFSOptions{}, err
+	}
+	maxFileSize, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid sourcegraph.lfsMaxFileSize")
+	}
+	return &protocol.LFSOptions{Enabled: true, MaxFileSize: maxFileSize}, nil
+}
+
+// fetchLFSObjects fetches the Git LFS objects of the files at HEAD of the
+// repository at dir, if enabled with setLFSOptions. Objects which are already
+// stored are not fetched again.
+func (s *Server) fetchLFSObjects(ctx context.Context, dir GitDir) error {
+	opts, err := getLFSOptions(dir)
+	if err != nil || !opts.Enabled {
+		return err
+	}
+
+	pointers, err := lfsPointers(ctx, dir, "HEAD")
+	if err != nil {
+		return err
+	}
+
+	var missing []lfs.Pointer
+	for _, p := range pointers {
+		if opts.MaxFileSize > 0 && p.Size > opts.MaxFileSize {
+			continue
+		}
+		if _, err := os.Stat(lfsObjectPath(dir, p.OID)); os.IsNotExist(err) {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	remoteURL, err := repoRemoteURL(ctx, dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to get remote URL")
+	}
+
+	tmp, err := s.tempDir("lfs-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	log15.Debug("fetching Git LFS objects", "repo", s.name(dir), "count", len(missing))
+	err = lfsClient.Download(ctx, remoteURL, missing, func(p lfs.Pointer, r io.Reader) error {
+		f, err := ioutil.TempFile(tmp, p.OID)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if _, err := io.Copy(f, r); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		path := lfsObjectPath(dir, p.OID)
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.Rename(f.Name(), path); err != nil {
+			return err
+		}
+		lfsObjectsFetched.Inc()
+		return nil
+	})
+	// The remote URL may contain credentials.
+	return errors.Wrap(redactErr(remoteURL, err), "failed to fetch Git LFS objects")
+}
+
+func redactErr(remoteURL string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(newURLRedactor(remoteURL).redact(err.Error()))
+}
+
+// lfsPointers returns the Git LFS pointers of the files in the tree of
+// treeish.
+func lfsPointers(ctx context.Context, dir GitDir, treeish string) ([]lfs.Pointer, error) {
+	// List the blobs which are small enough to be pointers.
+	cmd := exec.CommandContext(ctx, "git", "ls-tree", "-r", "-l", "-z", treeish)
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(wrapCmdError(cmd, err), "failed to list files")
+	}
+
+	var candidates bytes.Buffer
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <file>
+		tab := bytes.IndexByte(entry, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(string(entry[:tab]))
+		if len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.Atoi(fields[3]); err != nil || size > lfs.MaxPointerSize {
+			continue
+		}
+		candidates.WriteString(fields[2])
+		candidates.WriteByte('\n')
+	}
+	if candidates.Len() == 0 {
+		return nil, nil
+	}
+
+	// Read the candidates and parse the pointers.
+	cmd = exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = string(dir)
+	cmd.Stdin = &candidates
+	out, err = cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(wrapCmdError(cmd, err), "failed to read files")
+	}
+
+	var (
+		pointers []lfs.Pointer
+		seen     = map[string]bool{}
+		r        = bufio.NewReader(bytes.NewReader(out))
+	)
+	for {
+		// <object> SP <type> SP <size> LF <contents> LF
+		header, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("unexpected git cat-file output %q", header)
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Errorf("unexpected git cat-file output %q", header)
+		}
+		contents := make([]byte, size+1)
+		if _, err := io.ReadFull(r, contents); err != nil {
+			return nil, err
+		}
+
+		if p, ok := lfs.ParsePointer(contents[:size]); ok && !seen[p.OID] {
+			seen[p.OID] = true
+			pointers = append(pointers, *p)
+		}
+	}
+	return pointers, nil
+}
+
+// openLFSObject opens the Git LFS object which the pointer file with the given
+// contents points to. It returns nil if the contents aren't a pointer or the
+// object isn't stored.
+func openLFSObject(dir GitDir, contents []byte) (*os.File, *lfs.Pointer) {
+	p, ok := lfs.ParsePointer(contents)
+	if !ok {
+		return nil, nil
+	}
+	f, err := os.Open(lfsObjectPath(dir, p.OID))
+	if err != nil {
+		return nil, nil
+	}
+	return f, p
+}
+
+// hasLFSObjects returns true if Git LFS objects are stored for the repository
+// at dir.
+func hasLFSObjects(dir GitDir) bool {
+	_, err := os.Stat(dir.Path("lfs", "objects"))
+	return err == nil
+}
+
+// isBlobRead returns true if args read the contents of a file with
+// "git show <rev>:<path>".
+func isBlobRead(args []string) bool {
+	return len(args) == 2 && args[0] == "show" && !strings.HasPrefix(args[1], "-") && strings.Contains(args[1], ":")
+}
+
+// lfsBlobWriter writes the contents of a file to w. If the contents are a Git
+// LFS pointer and the object is stored, it writes the object instead.
+type lfsBlobWriter struct {
+	dir GitDir
+	w   io.Writer
+	buf []byte // the contents until it's clear whether they are a pointer
+}
+
+func newLFSBlobWriter(dir GitDir, w io.Writer) io.WriteCloser {
+	return &lfsBlobWriter{dir: dir, w: w, buf: []byte{}}
+}
+
+func (l *lfsBlobWriter) Write(b []byte) (int, error) {
+	if l.buf == nil {
+		return l.w.Write(b)
+	}
+
+	l.buf = append(l.buf, b...)
+	if len(l.buf) <= lfs.MaxPointerSize {
+		return len(b), nil
+	}
+
+	// Too large to be a pointer.
+	buf := l.buf
+	l.buf = nil
+	if _, err := l.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (l *lfsBlobWriter) Close() error {
+	if l.buf == nil {
+		return nil
+	}
+
+	buf := l.buf
+	l.buf = nil
+	if f, _ := openLFSObject(l.dir, buf); f != nil {
+		defer f.Close()
+		_, err := io.Copy(l.w, f)
+		return err
+	}
+	_, err := l.w.Write(buf)
+	return err
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
+)
+
+// lfsServer is a Git LFS server stand-in serving the given objects.
+func lfsServer(objects ...string) *httptest.Server {
+	byOID := map[string]string{}
+	for _, obj := range objects {
+		byOID[lfsOID(obj)] = obj
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/info/lfs/objects/batch") {
+			body, _ := ioutil.ReadAll(r.Body)
+			var resp []string
+			for oid := range byOID {
+				if bytes.Contains(body, []byte(oid)) {
+					resp = append(resp, fmt.Sprintf(`{"oid":%q,"size":%d,"actions":{"download":{"href":"%s/objects/%s"}}}`, oid, len(byOID[oid]), srv.URL, oid))
+				}
+			}
+			fmt.Fprintf(w, `{"objects":[%s]}`, strings.Join(resp, ","))
+			return
+		}
+		if obj, ok := byOID[strings.TrimPrefix(r.URL.Path, "/objects/")]; ok {
+			_, _ = io.WriteString(w, obj)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	return srv
+}
+
+func lfsOID(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func lfsPointerFile(contents string) string {
+	return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", lfsOID(contents), len(contents))
+}
+
+// makeLFSRepo creates a repository with the files big.bin and huge.bin
+// stored in Git LFS, and returns its git dir.
+func makeLFSRepo(t *testing.T, root, remoteURL string) GitDir {
+	t.Helper()
+
+	dir := GitDir(filepath.Join(root, "repo", ".git"))
+	if err := os.MkdirAll(string(dir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Dir(string(dir))
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, worktree, name, arg...)
+	}
+	cmd("git", "init", ".")
+	cmd("git", "remote", "add", "origin", remoteURL)
+	writeFile(t, filepath.Join(worktree, "big.bin"), []byte(lfsPointerFile("big file contents")))
+	writeFile(t, filepath.Join(worktree, "huge.bin"), []byte(lfsPointerFile("huge file contents, larger than the limit")))
+	writeFile(t, filepath.Join(worktree, "README"), []byte("hello world\n"))
+	cmd("git", "add", ".")
+	cmd("git", "commit", "-m", "lfs")
+	return dir
+}
+
+func TestFetchLFSObjects(t *testing.T) {
+	srv := lfsServer("big file contents", "huge file contents, larger than the limit")
+	defer srv.Close()
+
+	old := lfsClient
+	lfsClient = &lfs.Client{Doer: http.DefaultClient}
+	defer func() { lfsClient = old }()
+
+	root := tmpDir(t)
+	dir := makeLFSRepo(t, root, srv.URL+"/foo/bar")
+	s := &Server{ReposDir: root}
+	ctx := context.Background()
+
+	// Git LFS is disabled by default.
+	if err := s.fetchLFSObjects(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	if hasLFSObjects(dir) {
+		t.Fatal("expected no Git LFS objects to be fetched")
+	}
+
+	if err := setLFSOptions(dir, &protocol.LFSOptions{Enabled: true, MaxFileSize: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.fetchLFSObjects(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(lfsObjectPath(dir, lfsOID("big file contents")))
+	if err != nil || string(b) != "big file contents" {
+		t.Errorf("got stored object %q (error %v), want %q", b, err, "big file contents")
+	}
+	if _, err := os.Stat(lfsObjectPath(dir, lfsOID("huge file contents, larger than the limit"))); !os.IsNotExist(err) {
+		t.Errorf("expected object over the size limit to not be fetched, got %v", err)
+	}
+
+	// Reading a file returns the object if it is stored, and otherwise the
+	// pointer.
+	for path, want := range map[string]string{
+		"big.bin":  "big file contents",
+		"huge.bin": lfsPointerFile("huge file contents, larger than the limit"),
+		"README":   "hello world\n",
+	} {
+		args := []string{"show", "HEAD:" + path}
+		if !isBlobRead(args) {
+			t.Fatalf("expected %v to be a blob read", args)
+		}
+		var buf bytes.Buffer
+		w := newLFSBlobWriter(dir, &buf)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = string(dir)
+		cmd.Stdout = w
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: got %q, want %q", path, buf.String(), want)
+		}
+	}
+
+	// Disabling Git LFS keeps the stored objects, but fetches no new ones.
+	if err := setLFSOptions(dir, &protocol.LFSOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if opts, err := getLFSOptions(dir); err != nil || opts.Enabled {
+		t.Errorf("got options %+v (error %v), want Git LFS to be disabled", opts, err)
+	}
+}
+
+func TestLFSArchiveWriter(t *testing.T) {
+	srv := lfsServer("big file contents")
+	defer srv.Close()
+
+	old := lfsClient
+	lfsClient = &lfs.Client{Doer: http.DefaultClient}
+	defer func() { lfsClient = old }()
+
+	root := tmpDir(t)
+	dir := makeLFSRepo(t, root, srv.URL+"/foo/bar")
+	s := &Server{ReposDir: root}
+	if err := setLFSOptions(dir, &protocol.LFSOptions{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	// The huge.bin object is missing on the server.
+	if err := s.fetchLFSObjects(context.Background(), dir); err == nil {
+		t.Fatal("expected error for missing object")
+	}
+
+	want := map[string]string{
+		"big.bin":  "big file contents",
+		"huge.bin": lfsPointerFile("huge file contents, larger than the limit"),
+		"README":   "hello world\n",
+	}
+	for _, format := range []string{"tar", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newArchiveWriter(context.Background(), &archiveFilter{dir: dir, lfs: true}, format, &buf)
+			cmd := exec.Command("git", "archive", "--format=tar", "HEAD")
+			cmd.Dir = string(dir)
+			cmd.Stdout = w
+			if err := cmd.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			if format == "zip" {
+				zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, f := range zr.File {
+					rc, err := f.Open()
+					if err != nil {
+						t.Fatal(err)
+					}
+					b, _ := ioutil.ReadAll(rc)
+					rc.Close()
+					got[f.Name] = string(b)
+				}
+			} else {
+				tr := tar.NewReader(&buf)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					} else if err != nil {
+						t.Fatal(err)
+					}
+					if hdr.Typeflag != tar.TypeReg {
+						continue
+					}
+					b, _ := ioutil.ReadAll(tr)
+					got[hdr.Name] = string(b)
+				}
+			}
+
+			if len(got) != len(want) {
+				t.Errorf("got files %v, want %v", got, want)
+			}
+			for name, contents := range want {
+				if got[name] != contents {
+					t.Errorf("%s: got %q, want %q", name, got[name], contents)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+// maintenanceTask is an incremental git maintenance job which the janitor
+// runs periodically on every repository. Together they replace git gc, which
+// is slow and resource intensive on large repositories.
+type maintenanceTask struct {
+	// Name identifies the task in git config, logs and metrics.
+	Name string
+	// Interval is how often the task runs on a repository.
+	Interval time.Duration
+	// Cmds are the git commands (without the leading "git") the task runs
+	// in order.
+	Cmds [][]string
+}
+
+// maintenanceTasks are the maintenance tasks in the order the janitor
+// considers them. At most one task runs per repository and janitor run, so
+// a repository is never busy with maintenance for long.
+var maintenanceTasks = []maintenanceTask{
+	{
+		// Fetches leave loose objects behind. Pack them so git does not
+		// have to look at thousands of small files.
+		Name:     "repack-loose",
+		Interval: 24 * time.Hour,
+		Cmds:     [][]string{{"repack", "-d", "-l"}},
+	},
+	{
+		// Speed up history traversal (git log, blame, merge base
+		// computations) with a commit-graph. --split only writes the
+		// commits which are new since the last run.
+		Name:     "commit-graph",
+		Interval: 24 * time.Hour,
+		Cmds:     [][]string{{"commit-graph", "write", "--reachable", "--split"}},
+	},
+	{
+		// Index all packs in a multi-pack-index, and combine small packs
+		// without rewriting the large ones.
+		Name:     "multi-pack-index",
+		Interval: 24 * time.Hour,
+		Cmds: [][]string{
+			{"multi-pack-index", "write"},
+			{"multi-pack-index", "expire"},
+			{"multi-pack-index", "repack", "--batch-size=2g"},
+		},
+	},
+	{
+		// Write all objects into a single pack with a reachability bitmap,
+		// which makes counting objects for fetches and archives cheap.
+		// -A keeps unreachable objects around as loose objects until
+		// prune removes them, so concurrent fetches are not affected.
+		Name:     "repack",
+		Interval: 7 * 24 * time.Hour,
+		Cmds:     [][]string{{"repack", "-A", "-d", "-l", "--write-bitmap-index"}},
+	},
+	{
+		// Remove unreachable objects, e.g. of force pushed branches. The
+		// expiry protects objects of fetches which are in progress.
+		Name:     "prune",
+		Interval: 7 * 24 * time.Hour,
+		Cmds:     [][]string{{"prune", "--expire=2.weeks.ago"}},
+	},
+}
+
+var maintenanceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "src_gitserver_maintenance_duration_seconds",
+	Help:    "Time spent running a git maintenance task on a repository.",
+	Buckets: []float64{0.1, 1, 10, 60, 300, 900, 1800, 3600},
+}, []string{"task", "success"})
+
+// maintenanceConfigPrefix is the git config prefix of the per task maintenance
+// state. sourcegraph.maintenance.<task> is the time the task last ran, and
+// sourcegraph.maintenance.<task>-error the time it last failed, if the last
+// run failed.
+const maintenanceConfigPrefix = "sourcegraph.maintenance."
+
+// maintenanceState is the maintenance state of a repository, as recorded in
+// its git config.
+type maintenanceState struct {
+	LastRun map[string]time.Time // by task name
+	Failed  map[string]time.Time // by task name
+}
+
+// getMaintenanceState reads the maintenance state of the repository at dir.
+func getMaintenanceState(dir GitDir) (*maintenanceState, error) {
+	cmd := exec.Command("git", "config", "--get-regexp", `^sourcegraph\.maintenance\.`)
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit code 1 means no key matched.
+		if ee, ok := err.(*exec.ExitError); !ok || ee.Sys().(syscall.WaitStatus).ExitStatus() != 1 {
+			return nil, errors.Wrap(wrapCmdError(cmd, err), "failed to get maintenance state")
+		}
+	}
+
+	state := &maintenanceState{
+		LastRun: map[string]time.Time{},
+		Failed:  map[string]time.Time{},
+	}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		sec, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimPrefix(fields[0], maintenanceConfigPrefix)
+		if task := strings.TrimSuffix(key, "-error"); task != key {
+			state.Failed[task] = time.Unix(sec, 0)
+		} else {
+			state.LastRun[key] = time.Unix(sec, 0)
+		}
+	}
+	return state, sc.Err()
+}
+
+// nextRun returns when task is next due on the repository at dir. Tasks which
+// never ran are due one interval after the repository was cloned. A jitter
+// spreads out the maintenance of repositories cloned at the same time.
+func (st *maintenanceState) nextRun(dir GitDir, task maintenanceTask, cloned time.Time) time.Time {
+	last, ok := st.LastRun[task.Name]
+	if !ok {
+		last = cloned
+	}
+	return last.Add(task.Interval + jitterDuration(string(dir)+task.Name, task.Interval/4))
+}
+
+// maintenanceStatus returns the status of the maintenance tasks of the
+// repository at dir. It is a variable so tests can mock it.
+var maintenanceStatus = func(dir GitDir) ([]protocol.MaintenanceTaskStatus, error) {
+	state, err := getMaintenanceState(dir)
+	if err != nil {
+		return nil, err
+	}
+	cloned, err := getRecloneTime(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]protocol.MaintenanceTaskStatus, 0, len(maintenanceTasks))
+	for _, task := range maintenanceTasks {
+		ts := protocol.MaintenanceTaskStatus{
+			Task:    task.Name,
+			NextRun: state.nextRun(dir, task, cloned),
+		}
+		if last, ok := state.LastRun[task.Name]; ok {
+			ts.LastRun = &last
+		}
+		_, ts.Failed = state.Failed[task.Name]
+		status = append(status, ts)
+	}
+	return status, nil
+}
+
+// runMaintenance runs the first maintenance task which is due on the
+// repository at dir. It returns the name of the task it ran, or "" if no task
+// was due.
+//
+// The task runs even if it fails, so a repository where a task keeps failing
+// does not occupy the janitor. If git reports corruption, the repository is
+// marked to be recloned.
+func (s *Server) runMaintenance(ctx context.Context, dir GitDir, now time.Time) (string, error) {
+	state, err := getMaintenanceState(dir)
+	if err != nil {
+		return "", err
+	}
+	cloned, err := getRecloneTime(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, task := range maintenanceTasks {
+		if now.Before(state.nextRun(dir, task, cloned)) {
+			continue
+		}
+
+		repo := s.name(dir)
+		start := time.Now()
+		runErr := runMaintenanceTask(ctx, repo, dir, task)
+		maintenanceDuration.WithLabelValues(task.Name, strconv.FormatBool(runErr == nil)).Observe(time.Since(start).Seconds())
+
+		ts := strconv.FormatInt(now.Unix(), 10)
+		if err := gitConfigSet(dir, maintenanceConfigPrefix+task.Name, ts); err != nil {
+			return task.Name, err
+		}
+		if runErr != nil {
+			if err := gitConfigSet(dir, maintenanceConfigPrefix+task.Name+"-error", ts); err != nil {
+				log15.Warn("failed to record maintenance failure", "repo", repo, "task", task.Name, "error", err)
+			}
+			return task.Name, runErr
+		}
+		if err := gitConfigUnset(dir, maintenanceConfigPrefix+task.Name+"-error"); err != nil {
+			return task.Name, err
+		}
+
+		log15.Debug("ran git maintenance task", "repo", repo, "task", task.Name, "duration", time.Since(start))
+		return task.Name, nil
+	}
+	return "", nil
+}
+
+func runMaintenanceTask(ctx context.Context, repo api.RepoName, dir GitDir, task maintenanceTask) error {
+	for _, args := range task.Cmds {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = string(dir)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if _, err := runCommand(ctx, cmd); err != nil {
+			checkMaybeCorruptRepo(repo, dir, stderr.String())
+			return errors.Wrapf(err, "git %s failed with stderr: %s", strings.Join(args, " "), stderr.String())
+		}
+	}
+
+	// Once unreachable objects are pruned, a warning left behind by an
+	// automatic git gc is stale. git refuses to gc automatically while the
+	// warning is present.
+	if task.Name == "prune" {
+		if err := os.Remove(dir.Path("gc.log")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
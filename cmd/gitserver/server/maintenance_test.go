@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestRunMaintenance(t *testing.T) {
+	root := tmpDir(t)
+	dir := GitDir(filepath.Join(root, "repo", ".git"))
+
+	cmd := func(name string, arg ...string) string {
+		t.Helper()
+		return runCmd(t, filepath.Dir(string(dir)), name, arg...)
+	}
+	if err := os.MkdirAll(string(dir), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	cmd("git", "init", ".")
+	cmd("sh", "-c", "echo hello world > hello.txt")
+	cmd("git", "add", "hello.txt")
+	cmd("git", "commit", "-m", "hello")
+	writeFile(t, dir.Path("gc.log"), []byte("warning: There are too many unreachable loose objects; run 'git prune' to remove them."))
+
+	cloned := time.Now().Add(-30 * 24 * time.Hour)
+	if err := setRecloneTime(dir, cloned); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{ReposDir: root}
+	ctx := context.Background()
+	now := time.Now()
+
+	// All tasks are due, and they run one at a time.
+	for _, task := range maintenanceTasks {
+		ran, err := s.runMaintenance(ctx, dir, now)
+		if err != nil {
+			t.Fatalf("running %s: %s", task.Name, err)
+		}
+		if ran != task.Name {
+			t.Fatalf("ran task %q, want %q", ran, task.Name)
+		}
+	}
+	if ran, err := s.runMaintenance(ctx, dir, now); err != nil || ran != "" {
+		t.Fatalf("ran task %q (error %v), want no task to be due", ran, err)
+	}
+
+	if _, err := os.Stat(dir.Path("objects", "info", "commit-graphs", "commit-graph-chain")); err != nil {
+		t.Errorf("expected commit-graph to be written: %s", err)
+	}
+	if _, err := os.Stat(dir.Path("gc.log")); !os.IsNotExist(err) {
+		t.Errorf("expected gc.log to be removed by prune, got %v", err)
+	}
+
+	status, err := maintenanceStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != len(maintenanceTasks) {
+		t.Fatalf("got %d task statuses, want %d", len(status), len(maintenanceTasks))
+	}
+	for i, ts := range status {
+		task := maintenanceTasks[i]
+		if ts.Task != task.Name {
+			t.Errorf("got status of task %q, want %q", ts.Task, task.Name)
+		}
+		if ts.LastRun == nil || ts.LastRun.Unix() != now.Unix() {
+			t.Errorf("%s: got last run %v, want %v", task.Name, ts.LastRun, now)
+		}
+		if ts.Failed {
+			t.Errorf("%s: expected task to not have failed", task.Name)
+		}
+		if min, max := now.Add(task.Interval), now.Add(task.Interval*5/4); ts.NextRun.Before(min) || ts.NextRun.After(max) {
+			t.Errorf("%s: got next run %s, want between %s and %s", task.Name, ts.NextRun, min, max)
+		}
+	}
+
+	// A failing task is recorded, and marks the repository for recloning
+	// if git reports corruption.
+	runCommandMock = func(ctx context.Context, cmd *exec.Cmd) (int, error) {
+		_, _ = io.WriteString(cmd.Stderr, "error: packfile .git/objects/pack/pack-1.pack does not match index")
+		return 128, errors.New("exit status 128")
+	}
+	defer func() { runCommandMock = nil }()
+
+	later := now.Add(30 * 24 * time.Hour)
+	if ran, err := s.runMaintenance(ctx, dir, later); err == nil || ran != maintenanceTasks[0].Name {
+		t.Fatalf("ran task %q (error %v), want %q to fail", ran, err, maintenanceTasks[0].Name)
+	}
+
+	status, err = maintenanceStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status[0].Failed || status[0].LastRun.Unix() != later.Unix() {
+		t.Errorf("got status %+v, want failed run at %s", status[0], later)
+	}
+	if v, _ := gitConfigGet(dir, "sourcegraph.maybeCorruptRepo"); v == "" {
+		t.Error("expected repo to be marked as corrupt")
+	}
+}
@@ -51,6 +51,9 @@ func refspecOverridesCloneCmd(ctx context.Context, url, tmpPath string) (*exec.C
 
 // HACK(keegancsmith) workaround to experiment with cloning less in a large
 // monorepo. https://github.com/sourcegraph/customer/issues/19
-func refspecOverridesFetchCmd(ctx context.Context, url string) *exec.Cmd {
-	return exec.CommandContext(ctx, "git", append([]string{"fetch", "--prune", url}, refspecOverrides...)...)
+//
+// extraRefspecs are the additional refspecs configured for the repository.
+func refspecOverridesFetchCmd(ctx context.Context, url string, extraRefspecs ...string) *exec.Cmd {
+	args := append([]string{"fetch", "--prune", url}, refspecOverrides...)
+	return exec.CommandContext(ctx, "git", append(args, extraRefspecs...)...)
 }
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// Additional refspecs and remotes of a repository are configured by its code
+// host connection. The refspecs are stored as sourcegraph.fetchRefspec in the
+// git config of the repository. Additional remotes are regular git remotes,
+// whose names are stored as sourcegraph.remote so that we can remove them
+// again.
+
+// validRemoteName matches the names of additional remotes we accept.
+var validRemoteName = lazyregexp.New(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// validRefspec matches the additional refspecs we accept, which must have
+// both a source and destination under refs/.
+var validRefspec = lazyregexp.New(`^\+?refs/[^: ]+:refs/[^: ]+$`)
+
+// setFetchOptions records the additional refspecs and remotes to fetch in the
+// git config of the repository at dir. Remotes which are no longer configured
+// are removed, along with their refs.
+func setFetchOptions(ctx context.Context, dir GitDir, opts *protocol.FetchOptions) error {
+	if err := gitConfigUnset(dir, "sourcegraph.fetchRefspec"); err != nil {
+		return err
+	}
+	for _, refspec := range opts.Refspecs {
+		if !validRefspec.MatchString(refspec) {
+			log15.Warn("Ignoring invalid fetch refspec", "refspec", refspec)
+			continue
+		}
+		if err := gitConfigAdd(dir, "sourcegraph.fetchRefspec", refspec); err != nil {
+			return err
+		}
+	}
+
+	wanted := make(map[string]string, len(opts.Remotes))
+	for _, r := range opts.Remotes {
+		if !validRemoteName.MatchString(r.Name) || r.Name == "origin" || r.URL == "" {
+			log15.Warn("Ignoring invalid remote", "name", r.Name)
+			continue
+		}
+		wanted[r.Name] = r.URL
+	}
+
+	current, err := remoteNames(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range current {
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, "git", "remote", "remove", name)
+		cmd.Dir = string(dir)
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(wrapCmdError(cmd, err), "failed to remove remote %s", name)
+		}
+		// git remote remove keeps the refs of the remote, since they are
+		// also matched by the refspec of origin in a mirror.
+		if err := deleteRefs(ctx, dir, "refs/remotes/"+name+"/"); err != nil {
+			return err
+		}
+	}
+	if err := gitConfigUnset(dir, "sourcegraph.remote"); err != nil {
+		return err
+	}
+
+	for name, url := range wanted {
+		for _, kv := range [][2]string{
+			{"remote." + name + ".url", url},
+			{"remote." + name + ".fetch", "+refs/heads/*:refs/remotes/" + name + "/*"},
+			{"remote." + name + ".tagOpt", "--no-tags"},
+		} {
+			if err := gitConfigSet(dir, kv[0], kv[1]); err != nil {
+				return err
+			}
+		}
+		if err := gitConfigAdd(dir, "sourcegraph.remote", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchRefspecs returns the additional refspecs recorded by setFetchOptions.
+func fetchRefspecs(dir GitDir) ([]string, error) {
+	return gitConfigGetAll(dir, "sourcegraph.fetchRefspec")
+}
+
+// remoteNames returns the names of the additional remotes recorded by
+// setFetchOptions.
+func remoteNames(dir GitDir) ([]string, error) {
+	return gitConfigGetAll(dir, "sourcegraph.remote")
+}
+
+// fetchRemotes fetches the branches of the additional remotes of the
+// repository at dir. It tries to fetch all remotes, and returns the first
+// error.
+func fetchRemotes(ctx context.Context, dir GitDir) error {
+	names, err := remoteNames(dir)
+	if err != nil {
+		return err
+	}
+	var firstErr error
+	for _, name := range names {
+		cmd := exec.CommandContext(ctx, "git", "fetch", "--prune", name)
+		cmd.Dir = string(dir)
+		if _, err := runWithRemoteOpts(ctx, cmd, nil); err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "failed to fetch remote %s", name)
+		}
+	}
+	return firstErr
+}
+
+// deleteRefs deletes all refs with the given prefix.
+func deleteRefs(ctx context.Context, dir GitDir, prefix string) error {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=delete %(refname)", prefix)
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		return errors.Wrapf(wrapCmdError(cmd, err), "failed to list refs %s", prefix)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	cmd = exec.CommandContext(ctx, "git", "update-ref", "--stdin")
+	cmd.Dir = string(dir)
+	cmd.Stdin = bytes.NewReader(out)
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(wrapCmdError(cmd, err), "failed to delete refs %s", prefix)
+	}
+	return nil
+}
+
+func gitConfigAdd(dir GitDir, key, value string) error {
+	cmd := exec.Command("git", "config", "--add", key, value)
+	cmd.Dir = string(dir)
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(wrapCmdError(cmd, err), "failed to add git config %s", key)
+	}
+	return nil
+}
+
+func gitConfigGetAll(dir GitDir, key string) ([]string, error) {
+	cmd := exec.Command("git", "config", "--get-all", key)
+	cmd.Dir = string(dir)
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit code 1 means the key is not set.
+		if ee, ok := err.(*exec.ExitError); ok && ee.Sys().(syscall.WaitStatus).ExitStatus() == 1 {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(wrapCmdError(cmd, err), "failed to get git config %s", key)
+	}
+	return strings.Fields(string(out)), nil
+}
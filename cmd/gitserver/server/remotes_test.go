@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+func TestSetFetchOptions(t *testing.T) {
+	root := tmpDir(t)
+	upstream := filepath.Join(root, "upstream")
+	fork := filepath.Join(root, "fork")
+	dir := GitDir(filepath.Join(root, "repo", ".git"))
+
+	runCmd(t, root, "git", "init", upstream)
+	runCmd(t, upstream, "git", "commit", "--allow-empty", "-m", "hello")
+	runCmd(t, root, "git", "clone", upstream, fork)
+	runCmd(t, fork, "git", "checkout", "-b", "feature")
+	runCmd(t, fork, "git", "commit", "--allow-empty", "-m", "feature")
+	runCmd(t, root, "git", "clone", "--mirror", upstream, string(dir))
+
+	ctx := context.Background()
+	err := setFetchOptions(ctx, dir, &protocol.FetchOptions{
+		Refspecs: []string{"+refs/review/*:refs/review/*", "refs/heads/*"},
+		Remotes: []protocol.Remote{
+			{Name: "alice", URL: fork},
+			// Invalid remotes are ignored.
+			{Name: "origin", URL: fork},
+			{Name: "-bob", URL: fork},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refspecs, err := fetchRefspecs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"+refs/review/*:refs/review/*"}; !reflect.DeepEqual(refspecs, want) {
+		t.Errorf("got refspecs %q, want %q", refspecs, want)
+	}
+	names, err := remoteNames(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"alice"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got remotes %q, want %q", names, want)
+	}
+
+	if err := fetchRemotes(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	refs := runCmd(t, string(dir), "git", "for-each-ref", "--format=%(refname)")
+	if !strings.Contains(refs, "refs/remotes/alice/feature\n") {
+		t.Errorf("expected refs/remotes/alice/feature to be fetched, got refs:\n%s", refs)
+	}
+
+	// Unsetting the options removes the remote and its refs.
+	if err := setFetchOptions(ctx, dir, &protocol.FetchOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if refspecs, err := fetchRefspecs(dir); err != nil || len(refspecs) != 0 {
+		t.Errorf("got refspecs %q (error %v), want none", refspecs, err)
+	}
+	if names, err := remoteNames(dir); err != nil || len(names) != 0 {
+		t.Errorf("got remotes %q (error %v), want none", names, err)
+	}
+	refs = runCmd(t, string(dir), "git", "for-each-ref", "--format=%(refname)")
+	if strings.Contains(refs, "refs/remotes/alice/") {
+		t.Errorf("expected refs of remote alice to be removed, got refs:\n%s", refs)
+	}
+}
+
+func TestRefspecOverridesFetchCmd(t *testing.T) {
+	cmd := refspecOverridesFetchCmd(context.Background(), "https://example.com/foo", "+refs/review/*:refs/review/*")
+	if got := cmd.Args[len(cmd.Args)-1]; got != "+refs/review/*:refs/review/*" {
+		t.Errorf("got last argument %q, want the additional refspec", got)
+	}
+}
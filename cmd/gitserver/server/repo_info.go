@@ -47,6 +47,12 @@ func (s *Server) repoInfo(ctx context.Context, repo api.RepoName) (*protocol.Rep
 		} else {
 			resp.LastChanged = &lastChanged
 		}
+
+		if maintenance, err := maintenanceStatus(dir); err != nil {
+			log15.Warn("error getting maintenance status", "repo", repo, "err", err)
+		} else {
+			resp.Maintenance = maintenance
+		}
 	}
 	return &resp, nil
 }
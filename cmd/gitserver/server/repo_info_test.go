@@ -92,6 +92,14 @@ func TestServer_handleRepoInfo(t *testing.T) {
 		repoRemoteURL = func(context.Context, GitDir) (string, error) { return "u", nil }
 		defer func() { repoRemoteURL = origRepoRemoteURL }()
 
+		maintenance := []protocol.MaintenanceTaskStatus{
+			{Task: "commit-graph", LastRun: &lastFetched, NextRun: lastFetched.Add(24 * time.Hour)},
+			{Task: "prune", NextRun: lastChanged, Failed: true},
+		}
+		origMaintenanceStatus := maintenanceStatus
+		maintenanceStatus = func(dir GitDir) ([]protocol.MaintenanceTaskStatus, error) { return maintenance, nil }
+		defer func() { maintenanceStatus = origMaintenanceStatus }()
+
 		want := protocol.RepoInfoResponse{
 			Results: map[api.RepoName]*protocol.RepoInfo{
 				"x": {
@@ -99,6 +107,7 @@ func TestServer_handleRepoInfo(t *testing.T) {
 					LastFetched: &lastFetched,
 					LastChanged: &lastChanged,
 					URL:         "u",
+					Maintenance: maintenance,
 				},
 			},
 		}
@@ -225,6 +225,7 @@ func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/archive", s.handleArchive)
 	mux.HandleFunc("/exec", s.handleExec)
+	mux.HandleFunc("/batch", s.handleBatch)
 	mux.HandleFunc("/list", s.handleList)
 	mux.HandleFunc("/list-gitolite", s.handleListGitolite)
 	mux.HandleFunc("/is-repo-cloneable", s.handleIsRepoCloneable)
@@ -413,11 +414,39 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 		if err != nil {
 			log15.Warn("error cloning repo", "repo", req.Repo, "err", err)
 			resp.Error = err.Error()
+		} else if repoCloned(dir) {
+			if req.LFS != nil {
+				if err := setLFSOptions(dir, req.LFS); err != nil {
+					log15.Warn("failed to set Git LFS options", "repo", req.Repo, "error", err)
+				} else if err := s.fetchLFSObjects(ctx, dir); err != nil {
+					log15.Warn("failed to fetch Git LFS objects", "repo", req.Repo, "error", err)
+				}
+			}
+			// The clone fetched all refs of the remote URL, so only the
+			// additional remotes need to be fetched.
+			if req.Fetch != nil {
+				if err := setFetchOptions(ctx, dir, req.Fetch); err != nil {
+					log15.Warn("failed to set fetch options", "repo", req.Repo, "error", err)
+				} else if err := fetchRemotes(ctx, dir); err != nil {
+					log15.Warn("failed to fetch additional remotes", "repo", req.Repo, "error", err)
+				}
+			}
 		}
 	} else {
 		resp.Cloned = true
 		var statusErr, updateErr error
 
+		if req.LFS != nil {
+			if err := setLFSOptions(dir, req.LFS); err != nil {
+				log15.Warn("failed to set Git LFS options", "repo", req.Repo, "error", err)
+			}
+		}
+		if req.Fetch != nil {
+			if err := setFetchOptions(ctx, dir, req.Fetch); err != nil {
+				log15.Warn("failed to set fetch options", "repo", req.Repo, "error", err)
+			}
+		}
+
 		if debounce(req.Repo, req.Since) {
 			updateErr = s.doRepoUpdate(ctx, req.Repo, req.URL)
 		}
@@ -462,6 +491,7 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 		repo    = q.Get("repo")
 		format  = q.Get("format")
 		paths   = q["path"]
+		base    = q.Get("base")
 	)
 
 	if err := checkSpecArgSafety(treeish); err != nil {
@@ -469,12 +499,37 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 		log15.Error("gitserver.archive.CheckSpecArgSafety", "error", err)
 		return
 	}
+	if err := checkSpecArgSafety(base); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log15.Error("gitserver.archive.CheckSpecArgSafety", "error", err)
+		return
+	}
 
 	if repo == "" || format == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		log15.Error("gitserver.archive", "error", "empty repo or format")
 		return
 	}
+	if !archiveFormats[format] {
+		http.Error(w, fmt.Sprintf("unsupported archive format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	include, err := compileArchiveGlobs(q["include"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	exclude, err := compileArchiveGlobs(q["exclude"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxFileSize, err := parseMaxFileSize(q.Get("maxFileSize"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	req := &protocol.ExecRequest{
 		Repo: api.RepoName(repo),
@@ -492,7 +547,26 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	if format == "zip" {
+	// If files are filtered or the repository has Git LFS objects, we
+	// convert a tar archive to the requested format while filtering it.
+	// Pathspecs matching no files are fatal for git archive, so we can't
+	// pass the include and exclude patterns to it.
+	var filter stdoutFilter
+	filtered := len(include) > 0 || len(exclude) > 0 || maxFileSize > 0 || base != ""
+	if filtered || hasLFSObjects(s.dir(protocol.NormalizeRepo(req.Repo))) {
+		req.Args[len(req.Args)-1] = "--format=tar"
+		filter = func(ctx context.Context, dir GitDir, w io.Writer) io.WriteCloser {
+			return newArchiveWriter(ctx, &archiveFilter{
+				dir:         dir,
+				lfs:         hasLFSObjects(dir),
+				include:     include,
+				exclude:     exclude,
+				maxFileSize: maxFileSize,
+				base:        base,
+				treeish:     treeish,
+			}, format, w)
+		}
+	} else if format == "zip" {
 		// Compression level of 0 (no compression) seems to perform the
 		// best overall on fast network links, but this has not been tuned
 		// thoroughly.
@@ -502,7 +576,7 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 	req.Args = append(req.Args, treeish, "--")
 	req.Args = append(req.Args, paths...)
 
-	s.exec(w, r, req)
+	s.exec(w, r, req, filter)
 }
 
 func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
@@ -511,10 +585,27 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	s.exec(w, r, &req)
+
+	// Show the contents of files stored in Git LFS instead of their pointers.
+	var filter stdoutFilter
+	if isBlobRead(req.Args) {
+		filter = func(ctx context.Context, dir GitDir, w io.Writer) io.WriteCloser {
+			if !hasLFSObjects(dir) {
+				return nil
+			}
+			return newLFSBlobWriter(dir, w)
+		}
+	}
+
+	s.exec(w, r, &req, filter)
 }
 
-func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.ExecRequest) {
+// A stdoutFilter returns a writer which transforms the stdout of a git command
+// and writes it to w, or nil if the output is written unchanged. The writer is
+// closed after the command finished.
+type stdoutFilter func(ctx context.Context, dir GitDir, w io.Writer) io.WriteCloser
+
+func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.ExecRequest, filter stdoutFilter) {
 	// Flush writes more aggressively than standard net/http so that clients
 	// with a context deadline see as much partial response body as possible.
 	if fw := newFlushingResponseWriter(w); fw != nil {
@@ -615,37 +706,7 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 
 	dir := s.dir(req.Repo)
 	if !repoCloned(dir) {
-		cloneProgress, cloneInProgress := s.locker.Status(dir)
-		if cloneInProgress {
-			status = "clone-in-progress"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-				CloneInProgress: true,
-				CloneProgress:   cloneProgress,
-			})
-			return
-		}
-
-		if req.URL == "" {
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		cloneProgress, err := s.cloneRepo(ctx, req.Repo, req.URL, nil)
-		if err != nil {
-			log15.Debug("error cloning repo", "repo", req.Repo, "err", err)
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		status = "clone-in-progress"
-		w.WriteHeader(http.StatusNotFound)
-		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-			CloneInProgress: true,
-			CloneProgress:   cloneProgress,
-		})
+		status = s.writeRepoNotCloned(ctx, w, req.Repo, req.URL)
 		return
 	}
 
@@ -684,7 +745,19 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	cmd.Stdout = stdoutW
 	cmd.Stderr = stderrW
 
+	var filtered io.WriteCloser
+	if filter != nil {
+		if filtered = filter(ctx, dir, stdoutW); filtered != nil {
+			cmd.Stdout = filtered
+		}
+	}
+
 	exitStatus, execErr = runCommand(ctx, cmd)
+	if filtered != nil {
+		if err := filtered.Close(); err != nil && execErr == nil {
+			execErr = err
+		}
+	}
 
 	status = strconv.Itoa(exitStatus)
 	stdoutN = stdoutW.n
@@ -699,6 +772,41 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	w.Header().Set("X-Exec-Stderr", stderr)
 }
 
+// writeRepoNotCloned writes the not found response for requests to a repo
+// which isn't cloned. It starts cloning the repo if url is set. It returns
+// the status of the request for instrumentation.
+func (s *Server) writeRepoNotCloned(ctx context.Context, w http.ResponseWriter, repo api.RepoName, url string) (status string) {
+	dir := s.dir(repo)
+	cloneProgress, cloneInProgress := s.locker.Status(dir)
+	if cloneInProgress {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+			CloneInProgress: true,
+			CloneProgress:   cloneProgress,
+		})
+		return "clone-in-progress"
+	}
+
+	if url == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+		return "repo-not-found"
+	}
+	cloneProgress, err := s.cloneRepo(ctx, repo, url, nil)
+	if err != nil {
+		log15.Debug("error cloning repo", "repo", repo, "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+		return "repo-not-found"
+	}
+	w.WriteHeader(http.StatusNotFound)
+	_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+		CloneInProgress: true,
+		CloneProgress:   cloneProgress,
+	})
+	return "clone-in-progress"
+}
+
 // setGitAttributes writes our global gitattributes to
 // gitDir/info/attributes. This will override .gitattributes inside of
 // repositories. It is used to unset attributes such as export-ignore.
@@ -1304,20 +1412,28 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	if customCmd := customFetchCmd(ctx, url); customCmd != nil {
 		cmd = customCmd
 		configRemoteOpts = false
-	} else if useRefspecOverrides() {
-		cmd = refspecOverridesFetchCmd(ctx, url)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "fetch", "--prune", url,
-			// Normal git refs
-			"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
-			// GitHub pull requests
-			"+refs/pull/*:refs/pull/*",
-			// GitLab merge requests
-			"+refs/merge-requests/*:refs/merge-requests/*",
-			// Bitbucket pull requests
-			"+refs/pull-requests/*:refs/pull-requests/*",
-			// Possibly deprecated refs for sourcegraph zap experiment?
-			"+refs/sourcegraph/*:refs/sourcegraph/*")
+		// Additional refspecs configured for the repository.
+		extraRefspecs, err := fetchRefspecs(dir)
+		if err != nil {
+			log15.Warn("Failed to read additional fetch refspecs", "repo", repo, "error", err)
+		}
+		if useRefspecOverrides() {
+			cmd = refspecOverridesFetchCmd(ctx, url, extraRefspecs...)
+		} else {
+			args := []string{"fetch", "--prune", url,
+				// Normal git refs
+				"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
+				// GitHub pull requests
+				"+refs/pull/*:refs/pull/*",
+				// GitLab merge requests
+				"+refs/merge-requests/*:refs/merge-requests/*",
+				// Bitbucket pull requests
+				"+refs/pull-requests/*:refs/pull-requests/*",
+				// Possibly deprecated refs for sourcegraph zap experiment?
+				"+refs/sourcegraph/*:refs/sourcegraph/*"}
+			cmd = exec.CommandContext(ctx, "git", append(args, extraRefspecs...)...)
+		}
 	}
 	cmd.Dir = string(dir)
 
@@ -1383,6 +1499,18 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		log15.Error("Failed to set HEAD", "repo", repo, "error", err, "output", string(output))
 		return errors.Wrap(err, "Failed to set HEAD")
 	}
+
+	// Failing to fetch additional remotes does not fail the update of the
+	// repository itself.
+	if err := fetchRemotes(ctx, dir); err != nil {
+		log15.Warn("Failed to fetch additional remotes", "repo", repo, "error", err)
+	}
+
+	// Failing to fetch Git LFS objects does not fail the update, since the
+	// pointer files are still shown.
+	if err := s.fetchLFSObjects(ctx, dir); err != nil {
+		log15.Warn("Failed to fetch Git LFS objects", "repo", repo, "error", err)
+	}
 	return nil
 }
 
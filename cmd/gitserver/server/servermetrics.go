@@ -30,6 +30,8 @@ func (s *Server) RegisterMetrics() {
 		}
 	}()
 
+	prometheus.MustRegister(maintenanceDuration)
+
 	// report the size of the repos dir
 	if s.ReposDir == "" {
 		log15.Error("ReposDir is not set, cannot export disk_space_available metric.")
@@ -48,8 +48,8 @@ func main() {
 	}
 
 	store := store.Store{
-		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
-			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar"})
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Filter: filter})
 		},
 		Path:              filepath.Join(cacheDir, "replacer-archives"),
 		MaxCacheSizeBytes: cacheSizeBytes,
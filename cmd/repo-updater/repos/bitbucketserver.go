@@ -14,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
@@ -298,13 +299,14 @@ func (s BitbucketServerSource) makeRepo(repo *bitbucketserver.Repo, isArchived b
 
 	urn := s.svc.URN()
 
+	name := string(reposource.BitbucketServerRepoName(
+		s.config.RepositoryPathPattern,
+		host.Hostname(),
+		project,
+		repo.Slug,
+	))
 	return &Repo{
-		Name: string(reposource.BitbucketServerRepoName(
-			s.config.RepositoryPathPattern,
-			host.Hostname(),
-			project,
-			repo.Slug,
-		)),
+		Name: name,
 		URI: string(reposource.BitbucketServerRepoName(
 			"",
 			host.Hostname(),
@@ -324,12 +326,36 @@ func (s BitbucketServerSource) makeRepo(repo *bitbucketserver.Repo, isArchived b
 			urn: {
 				ID:       urn,
 				CloneURL: cloneURL,
+				LFS:      s.lfsOptions(cloneURL),
+				Fetch:    s.fetchOptions(name, cloneURL),
 			},
 		},
 		Metadata: repo,
 	}
 }
 
+// lfsOptions returns the options for fetching the Git LFS files of a repo
+// with the given clone URL.
+func (s *BitbucketServerSource) lfsOptions(cloneURL string) *gitserverprotocol.LFSOptions {
+	if s.config.GitLFS == nil {
+		return nil
+	}
+	return lfsOptions(cloneURL, s.config.GitLFS.Enabled, s.config.GitLFS.MaxFileSize)
+}
+
+// fetchOptions returns the additional refspecs and remotes to fetch into
+// the repo with the given name and clone URL.
+func (s *BitbucketServerSource) fetchOptions(name, cloneURL string) *gitserverprotocol.FetchOptions {
+	if s.config.GitFetch == nil {
+		return nil
+	}
+	remotes := make([]gitRemote, 0, len(s.config.GitFetch.Remotes))
+	for _, r := range s.config.GitFetch.Remotes {
+		remotes = append(remotes, gitRemote{Repository: r.Repository, Name: r.Name, URL: r.Url})
+	}
+	return fetchOptions(name, cloneURL, s.config.GitFetch.Refspecs, remotes)
+}
+
 func (s *BitbucketServerSource) excludes(r *bitbucketserver.Repo) bool {
 	name := r.Slug
 	if r.Project != nil {
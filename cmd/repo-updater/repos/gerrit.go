@@ -0,0 +1,242 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// gerritPageSize is the number of projects requested per page when listing projects.
+const gerritPageSize = 100
+
+// A GerritSource yields repositories from a single Gerrit connection configured
+// in Sourcegraph via the external services configuration.
+type GerritSource struct {
+	svc     *ExternalService
+	config  *schema.GerritConnection
+	exclude excludeFunc
+	baseURL *url.URL
+	client  *gerrit.Client
+}
+
+// NewGerritSource returns a new GerritSource from the given external service.
+func NewGerritSource(svc *ExternalService, cf *httpcli.Factory) (*GerritSource, error) {
+	var c schema.GerritConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGerritSource(svc, &c, cf)
+}
+
+func newGerritSource(svc *ExternalService, c *schema.GerritConnection, cf *httpcli.Factory) (*GerritSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var eb excludeBuilder
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		eb.Pattern(r.Pattern)
+	}
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gerrit.NewClient(baseURL, cli)
+	client.Username = c.Username
+	client.Password = c.Password
+
+	return &GerritSource{
+		svc:     svc,
+		config:  c,
+		exclude: exclude,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+// ListRepos returns all Gerrit projects accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s GerritSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	s.listAllRepos(ctx, results)
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s GerritSource) ExternalServices() ExternalServices {
+	return ExternalServices{s.svc}
+}
+
+func (s GerritSource) makeRepo(p *gerrit.Project) *Repo {
+	urn := s.svc.URN()
+	cloneURL := s.authenticatedRemoteURL(p)
+	name := string(reposource.GerritRepoName(
+		s.config.RepositoryPathPattern,
+		s.baseURL.Hostname(),
+		p.Name,
+	))
+	return &Repo{
+		Name: name,
+		URI: string(reposource.GerritRepoName(
+			"",
+			s.baseURL.Hostname(),
+			p.Name,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          p.ID,
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: p.Description,
+		Archived:    p.State == gerrit.ProjectStateReadOnly,
+		Sources: map[string]*SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: cloneURL,
+				Fetch:    s.fetchOptions(name, cloneURL),
+			},
+		},
+		Metadata: p,
+	}
+}
+
+// gerritChangesRefspec fetches the patch sets of Gerrit changes, such as
+// refs/changes/34/1234/2 for patch set 2 of change 1234.
+const gerritChangesRefspec = "+refs/changes/*:refs/changes/*"
+
+// fetchOptions returns the additional refspecs and remotes to fetch into
+// the repo with the given name and clone URL. The refs of Gerrit changes are
+// always fetched.
+func (s *GerritSource) fetchOptions(name, cloneURL string) *gitserverprotocol.FetchOptions {
+	refspecs := []string{gerritChangesRefspec}
+	var remotes []gitRemote
+	if s.config.GitFetch != nil {
+		refspecs = append(refspecs, s.config.GitFetch.Refspecs...)
+		for _, r := range s.config.GitFetch.Remotes {
+			remotes = append(remotes, gitRemote{Repository: r.Repository, Name: r.Name, URL: r.Url})
+		}
+	}
+	return fetchOptions(name, cloneURL, refspecs, remotes)
+}
+
+// authenticatedRemoteURL returns the project's Git remote URL with the configured
+// Gerrit username and HTTP password inserted in the URL userinfo.
+func (s *GerritSource) authenticatedRemoteURL(p *gerrit.Project) string {
+	if s.config.Username == "" {
+		return s.baseURL.String() + p.Name
+	}
+
+	// Authenticated Git requests must be prefixed with "/a/", like REST API requests.
+	u := *s.baseURL
+	u.Path += "a/" + p.Name
+	u.User = url.UserPassword(s.config.Username, s.config.Password)
+	return u.String()
+}
+
+func (s *GerritSource) excludes(p *gerrit.Project) bool {
+	// Hidden projects are only visible to their owners, and the "All-Projects" and
+	// "All-Users" projects only hold Gerrit's own configuration and account data.
+	if p.State == gerrit.ProjectStateHidden || p.Name == "All-Projects" || p.Name == "All-Users" {
+		return true
+	}
+	return s.exclude(p.Name)
+}
+
+type gerritResult struct {
+	project *gerrit.Project
+	err     error
+}
+
+func (s *GerritSource) listAllRepos(ctx context.Context, results chan SourceResult) {
+	ch := make(chan gerritResult)
+
+	go func() {
+		defer close(ch)
+
+		for _, prefix := range s.config.ProjectPrefixes {
+			s.listPrefix(ctx, prefix, ch)
+		}
+
+		s.listProjects(ctx, s.config.Projects, ch)
+	}()
+
+	seen := make(map[string]bool)
+	for r := range ch {
+		if r.err != nil {
+			results <- SourceResult{Source: s, Err: r.err}
+			continue
+		}
+
+		if !seen[r.project.Name] && !s.excludes(r.project) {
+			results <- SourceResult{Source: s, Repo: s.makeRepo(r.project)}
+			seen[r.project.Name] = true
+		}
+	}
+}
+
+// listPrefix sends all projects whose names start with prefix to ch.
+func (s *GerritSource) listPrefix(ctx context.Context, prefix string, ch chan gerritResult) {
+	args := gerrit.ListProjectsArgs{Prefix: prefix, Limit: gerritPageSize}
+	for {
+		projects, more, err := s.client.ListProjects(ctx, args)
+		if err != nil {
+			ch <- gerritResult{err: errors.Wrapf(err, "gerrit: listing projects with prefix %q, skip %d", prefix, args.Skip)}
+			return
+		}
+
+		for _, p := range projects {
+			ch <- gerritResult{project: p}
+		}
+
+		if !more || len(projects) == 0 {
+			return
+		}
+		args.Skip += len(projects)
+	}
+}
+
+// listProjects sends the projects with the given names to ch. Projects that don't exist
+// (anymore) are skipped.
+func (s *GerritSource) listProjects(ctx context.Context, names []string, ch chan gerritResult) {
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			ch <- gerritResult{err: err}
+			return
+		}
+
+		p, err := s.client.GetProject(ctx, name)
+		if err != nil {
+			if errcode.IsNotFound(err) {
+				log15.Warn("skipping missing gerrit.projects entry:", "name", name, "err", err)
+				continue
+			}
+			ch <- gerritResult{err: errors.Wrapf(err, "gerrit: getting project %s", name)}
+			continue
+		}
+
+		ch <- gerritResult{project: p}
+	}
+}
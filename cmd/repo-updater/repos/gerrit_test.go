@@ -0,0 +1,164 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGerritSource_ListRepos(t *testing.T) {
+	assertAllReposListed := func(want []string) ReposAssertion {
+		return func(t testing.TB, rs Repos) {
+			t.Helper()
+
+			have := rs.Names()
+			sort.Strings(have)
+			sort.Strings(want)
+
+			if !reflect.DeepEqual(have, want) {
+				t.Error(cmp.Diff(have, want))
+			}
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		assert ReposAssertion
+		conf   *schema.GerritConnection
+		err    string
+	}{
+		{
+			name: "all projects",
+			assert: assertAllReposListed([]string{
+				"gerrit-review.googlesource.com/attic/gerrit-old",
+				"gerrit-review.googlesource.com/gerrit",
+				"gerrit-review.googlesource.com/plugins/gitiles",
+			}),
+			conf: &schema.GerritConnection{
+				Url:             "https://gerrit-review.googlesource.com",
+				ProjectPrefixes: []string{""},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "projects",
+			assert: assertAllReposListed([]string{
+				"gerrit-review.googlesource.com/gerrit",
+			}),
+			conf: &schema.GerritConnection{
+				Url:      "https://gerrit-review.googlesource.com",
+				Projects: []string{"gerrit", "missing"},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "exclude",
+			assert: assertAllReposListed([]string{
+				"gerrit-review.googlesource.com/gerrit",
+			}),
+			conf: &schema.GerritConnection{
+				Url:             "https://gerrit-review.googlesource.com",
+				ProjectPrefixes: []string{"plugins/"},
+				Projects:        []string{"gerrit"},
+				Exclude: []*schema.ExcludedGerritProject{
+					{Pattern: "^plugins/"},
+				},
+			},
+			err: "<nil>",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "GERRIT-LIST-REPOS/" + tc.name
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			svc := &ExternalService{
+				Kind:   extsvc.KindGerrit,
+				Config: marshalJSON(t, tc.conf),
+			}
+
+			src, err := newGerritSource(svc, tc.conf, cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if tc.assert != nil {
+				tc.assert(t, repos)
+			}
+		})
+	}
+}
+
+func TestGerritSource_makeRepo(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "gerrit-projects.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var projects []*gerrit.Project
+	if err := json.Unmarshal(b, &projects); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := ExternalService{ID: 1, Kind: extsvc.KindGerrit}
+
+	tests := []struct {
+		name   string
+		schmea *schema.GerritConnection
+	}{
+		{
+			name: "anonymous",
+			schmea: &schema.GerritConnection{
+				Url: "https://gerrit-review.googlesource.com",
+			},
+		}, {
+			name: "authenticated",
+			schmea: &schema.GerritConnection{
+				Url:      "https://review.mycorp.com/gerrit",
+				Username: "alice",
+				Password: "secret",
+			},
+		}, {
+			name: "path-pattern",
+			schmea: &schema.GerritConnection{
+				Url:                   "https://gerrit-review.googlesource.com",
+				RepositoryPathPattern: "gerrit/{name}",
+			},
+		},
+	}
+	for _, test := range tests {
+		test.name = "GerritSource_makeRepo_" + test.name
+		t.Run(test.name, func(t *testing.T) {
+			s, err := newGerritSource(&svc, test.schmea, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*Repo
+			for _, p := range projects {
+				got = append(got, s.makeRepo(p))
+			}
+
+			testutil.AssertGolden(t, "testdata/golden/"+test.name, update(test.name), got)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A GiteaSource yields repositories from a single Gitea (or Gogs) connection configured
+// in Sourcegraph via the external services configuration.
+type GiteaSource struct {
+	svc             *ExternalService
+	config          *schema.GiteaConnection
+	exclude         excludeFunc
+	excludeArchived bool
+	excludeForks    bool
+	baseURL         *url.URL
+	client          *gitea.Client
+}
+
+// NewGiteaSource returns a new GiteaSource from the given external service.
+func NewGiteaSource(svc *ExternalService, cf *httpcli.Factory) (*GiteaSource, error) {
+	var c schema.GiteaConnection
+	if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+		return nil, fmt.Errorf("external service id=%d config error: %s", svc.ID, err)
+	}
+	return newGiteaSource(svc, &c, cf)
+}
+
+func newGiteaSource(svc *ExternalService, c *schema.GiteaConnection, cf *httpcli.Factory) (*GiteaSource, error) {
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	if cf == nil {
+		cf = httpcli.NewExternalHTTPClientFactory()
+	}
+
+	cli, err := cf.Doer()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		eb              excludeBuilder
+		excludeArchived bool
+		excludeForks    bool
+	)
+
+	for _, r := range c.Exclude {
+		eb.Exact(r.Name)
+		if r.Id != 0 {
+			eb.Exact(strconv.Itoa(r.Id))
+		}
+		eb.Pattern(r.Pattern)
+
+		if r.Archived {
+			excludeArchived = true
+		}
+
+		if r.Forks {
+			excludeForks = true
+		}
+	}
+
+	exclude, err := eb.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := gitea.NewClient(baseURL, cli)
+	client.Token = c.Token
+
+	return &GiteaSource{
+		svc:             svc,
+		config:          c,
+		exclude:         exclude,
+		excludeArchived: excludeArchived,
+		excludeForks:    excludeForks,
+		baseURL:         baseURL,
+		client:          client,
+	}, nil
+}
+
+// ListRepos returns all Gitea repositories accessible to all connections configured
+// in Sourcegraph via the external services configuration.
+func (s GiteaSource) ListRepos(ctx context.Context, results chan SourceResult) {
+	s.listAllRepos(ctx, results)
+}
+
+// ExternalServices returns a singleton slice containing the external service.
+func (s GiteaSource) ExternalServices() ExternalServices {
+	return ExternalServices{s.svc}
+}
+
+func (s GiteaSource) makeRepo(r *gitea.Repo) *Repo {
+	urn := s.svc.URN()
+	cloneURL := s.authenticatedRemoteURL(r)
+	name := string(reposource.GiteaRepoName(
+		s.config.RepositoryPathPattern,
+		s.baseURL.Hostname(),
+		r.FullName,
+	))
+	return &Repo{
+		Name: name,
+		URI: string(reposource.GiteaRepoName(
+			"",
+			s.baseURL.Hostname(),
+			r.FullName,
+		)),
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          strconv.FormatInt(r.ID, 10),
+			ServiceType: gitea.ServiceType,
+			ServiceID:   s.baseURL.String(),
+		},
+		Description: r.Description,
+		Fork:        r.Fork,
+		Archived:    r.Archived,
+		Private:     r.Private,
+		Sources: map[string]*SourceInfo{
+			urn: {
+				ID:       urn,
+				CloneURL: cloneURL,
+				LFS:      s.lfsOptions(cloneURL),
+				Fetch:    s.fetchOptions(name, cloneURL),
+			},
+		},
+		Metadata: r,
+	}
+}
+
+// lfsOptions returns the options for fetching the Git LFS files of a repo
+// with the given clone URL.
+func (s *GiteaSource) lfsOptions(cloneURL string) *gitserverprotocol.LFSOptions {
+	if s.config.GitLFS == nil {
+		return nil
+	}
+	return lfsOptions(cloneURL, s.config.GitLFS.Enabled, s.config.GitLFS.MaxFileSize)
+}
+
+// fetchOptions returns the additional refspecs and remotes to fetch into
+// the repo with the given name and clone URL.
+func (s *GiteaSource) fetchOptions(name, cloneURL string) *gitserverprotocol.FetchOptions {
+	if s.config.GitFetch == nil {
+		return nil
+	}
+	remotes := make([]gitRemote, 0, len(s.config.GitFetch.Remotes))
+	for _, r := range s.config.GitFetch.Remotes {
+		remotes = append(remotes, gitRemote{Repository: r.Repository, Name: r.Name, URL: r.Url})
+	}
+	return fetchOptions(name, cloneURL, s.config.GitFetch.Refspecs, remotes)
+}
+
+// authenticatedRemoteURL returns the repository's Git remote URL with the configured
+// Gitea token inserted in the URL userinfo.
+func (s *GiteaSource) authenticatedRemoteURL(repo *gitea.Repo) string {
+	if s.config.GitURLType == "ssh" {
+		return repo.SSHURL
+	}
+
+	u, err := url.Parse(repo.CloneURL)
+	if err != nil {
+		log15.Warn("Error adding authentication to Gitea repository Git remote URL.", "url", repo.CloneURL, "error", err)
+		return repo.CloneURL
+	}
+
+	u.User = url.User(s.config.Token)
+	return u.String()
+}
+
+func (s *GiteaSource) excludes(r *gitea.Repo) bool {
+	if s.exclude(r.FullName) || s.exclude(strconv.FormatInt(r.ID, 10)) {
+		return true
+	}
+
+	if s.excludeArchived && r.Archived {
+		return true
+	}
+
+	if s.excludeForks && r.Fork {
+		return true
+	}
+
+	return false
+}
+
+// giteaPager returns the repositories on the given page (starting at 1) and whether
+// there may be more pages.
+type giteaPager func(ctx context.Context, page int) ([]*gitea.Repo, bool, error)
+
+type giteaResult struct {
+	repo *gitea.Repo
+	err  error
+}
+
+func (s *GiteaSource) listAllRepos(ctx context.Context, results chan SourceResult) {
+	ch := make(chan giteaResult)
+
+	go func() {
+		defer close(ch)
+
+		for _, org := range s.config.Orgs {
+			org := org
+			s.paginate(ctx, "org "+org, ch, func(ctx context.Context, page int) ([]*gitea.Repo, bool, error) {
+				return s.client.OrgRepos(ctx, org, page)
+			})
+		}
+
+		for _, user := range s.config.Users {
+			user := user
+			s.paginate(ctx, "user "+user, ch, func(ctx context.Context, page int) ([]*gitea.Repo, bool, error) {
+				return s.client.UserRepos(ctx, user, page)
+			})
+		}
+
+		for _, query := range s.config.RepositoryQuery {
+			query := query
+			s.paginate(ctx, "query "+strconv.Quote(query), ch, func(ctx context.Context, page int) ([]*gitea.Repo, bool, error) {
+				return s.client.SearchRepos(ctx, query, page)
+			})
+		}
+
+		s.listRepos(ctx, s.config.Repos, ch)
+	}()
+
+	seen := make(map[int64]bool)
+	for r := range ch {
+		if r.err != nil {
+			results <- SourceResult{Source: s, Err: r.err}
+			continue
+		}
+
+		if !seen[r.repo.ID] && !s.excludes(r.repo) {
+			results <- SourceResult{Source: s, Repo: s.makeRepo(r.repo)}
+			seen[r.repo.ID] = true
+		}
+	}
+}
+
+// paginate sends the repositories of all pages returned by pager to ch. It stops at the first
+// error.
+func (s *GiteaSource) paginate(ctx context.Context, item string, ch chan giteaResult, pager giteaPager) {
+	for page := 1; ; page++ {
+		repos, more, err := pager(ctx, page)
+		if err != nil {
+			ch <- giteaResult{err: errors.Wrapf(err, "gitea: listing repositories of %s, page %d", item, page)}
+			return
+		}
+
+		for _, r := range repos {
+			ch <- giteaResult{repo: r}
+		}
+
+		if !more || len(repos) == 0 {
+			return
+		}
+	}
+}
+
+// listRepos sends the repositories with the given "owner/name" to ch. Repositories that
+// don't exist (anymore) are skipped.
+func (s *GiteaSource) listRepos(ctx context.Context, repos []string, ch chan giteaResult) {
+	for _, nameWithOwner := range repos {
+		if err := ctx.Err(); err != nil {
+			ch <- giteaResult{err: err}
+			return
+		}
+
+		parts := strings.SplitN(nameWithOwner, "/", 2)
+		if len(parts) != 2 {
+			ch <- giteaResult{err: errors.Errorf("invalid Gitea repository: nameWithOwner=%s", nameWithOwner)}
+			continue
+		}
+
+		repo, err := s.client.Repo(ctx, parts[0], parts[1])
+		if err != nil {
+			if errcode.IsNotFound(err) {
+				log15.Warn("skipping missing gitea.repos entry:", "name", nameWithOwner, "err", err)
+				continue
+			}
+			ch <- giteaResult{err: errors.Wrapf(err, "gitea: getting repository %s", nameWithOwner)}
+			continue
+		}
+
+		ch <- giteaResult{repo: repo}
+	}
+}
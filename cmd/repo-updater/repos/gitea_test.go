@@ -0,0 +1,249 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGiteaSource_ListRepos(t *testing.T) {
+	assertAllReposListed := func(want []string) ReposAssertion {
+		return func(t testing.TB, rs Repos) {
+			t.Helper()
+
+			have := rs.Names()
+			sort.Strings(have)
+			sort.Strings(want)
+
+			if !reflect.DeepEqual(have, want) {
+				t.Error(cmp.Diff(have, want))
+			}
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		assert ReposAssertion
+		conf   *schema.GiteaConnection
+		err    string
+	}{
+		{
+			name: "orgs and users",
+			assert: assertAllReposListed([]string{
+				"try.gitea.io/sourcegraph/jsonrpc2",
+				"try.gitea.io/sourcegraph/go-diff",
+				"try.gitea.io/alice/dotfiles",
+				"try.gitea.io/alice/old-notes",
+			}),
+			conf: &schema.GiteaConnection{
+				Url:   "https://try.gitea.io",
+				Token: "secret",
+				Orgs:  []string{"sourcegraph"},
+				Users: []string{"alice"},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "query and repos",
+			assert: assertAllReposListed([]string{
+				"try.gitea.io/sourcegraph/go-diff",
+				"try.gitea.io/alice/dotfiles",
+			}),
+			conf: &schema.GiteaConnection{
+				Url:             "https://try.gitea.io",
+				Token:           "secret",
+				RepositoryQuery: []string{"diff"},
+				Repos:           []string{"alice/dotfiles", "alice/missing"},
+			},
+			err: "<nil>",
+		},
+		{
+			name: "exclude forks and archived",
+			assert: assertAllReposListed([]string{
+				"try.gitea.io/sourcegraph/jsonrpc2",
+				"try.gitea.io/alice/dotfiles",
+			}),
+			conf: &schema.GiteaConnection{
+				Url:   "https://try.gitea.io",
+				Token: "secret",
+				Orgs:  []string{"sourcegraph"},
+				Users: []string{"alice"},
+				Exclude: []*schema.ExcludedGiteaRepo{
+					{Forks: true},
+					{Archived: true},
+				},
+			},
+			err: "<nil>",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		tc.name = "GITEA-LIST-REPOS/" + tc.name
+		t.Run(tc.name, func(t *testing.T) {
+			cf, save := newClientFactory(t, tc.name)
+			defer save(t)
+
+			svc := &ExternalService{
+				Kind:   extsvc.KindGitea,
+				Config: marshalJSON(t, tc.conf),
+			}
+
+			src, err := newGiteaSource(svc, tc.conf, cf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repos, err := listAll(context.Background(), src)
+
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if tc.assert != nil {
+				tc.assert(t, repos)
+			}
+		})
+	}
+}
+
+func TestGiteaSource_makeRepo(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "gitea-repos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var repos []*gitea.Repo
+	if err := json.Unmarshal(b, &repos); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := ExternalService{ID: 1, Kind: extsvc.KindGitea}
+
+	tests := []struct {
+		name   string
+		schmea *schema.GiteaConnection
+	}{
+		{
+			name: "simple",
+			schmea: &schema.GiteaConnection{
+				Url:   "https://try.gitea.io",
+				Token: "secret",
+			},
+		}, {
+			name: "ssh",
+			schmea: &schema.GiteaConnection{
+				Url:        "https://try.gitea.io",
+				Token:      "secret",
+				GitURLType: "ssh",
+			},
+		}, {
+			name: "path-pattern",
+			schmea: &schema.GiteaConnection{
+				Url:                   "https://try.gitea.io",
+				Token:                 "secret",
+				RepositoryPathPattern: "gitea/{nameWithOwner}",
+			},
+		},
+	}
+	for _, test := range tests {
+		test.name = "GiteaSource_makeRepo_" + test.name
+		t.Run(test.name, func(t *testing.T) {
+			s, err := newGiteaSource(&svc, test.schmea, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []*Repo
+			for _, r := range repos {
+				got = append(got, s.makeRepo(r))
+			}
+
+			testutil.AssertGolden(t, "testdata/golden/"+test.name, update(test.name), got)
+		})
+	}
+}
+
+func TestGiteaSource_Exclude(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "gitea-repos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var repos []*gitea.Repo
+	if err := json.Unmarshal(b, &repos); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]*schema.GiteaConnection{
+		"none": {
+			Url:   "https://try.gitea.io",
+			Token: "secret",
+		},
+		"name": {
+			Url:   "https://try.gitea.io",
+			Token: "secret",
+			Exclude: []*schema.ExcludedGiteaRepo{
+				{Name: "Sourcegraph/JSONRPC2"},
+			},
+		},
+		"id": {
+			Url:   "https://try.gitea.io",
+			Token: "secret",
+			Exclude: []*schema.ExcludedGiteaRepo{
+				{Id: 2001},
+			},
+		},
+		"pattern": {
+			Url:   "https://try.gitea.io",
+			Token: "secret",
+			Exclude: []*schema.ExcludedGiteaRepo{
+				{Pattern: "^alice/"},
+			},
+		},
+		"forks-and-archived": {
+			Url:   "https://try.gitea.io",
+			Token: "secret",
+			Exclude: []*schema.ExcludedGiteaRepo{
+				{Forks: true},
+				{Archived: true},
+			},
+		},
+	}
+
+	svc := ExternalService{ID: 1, Kind: extsvc.KindGitea}
+
+	for name, config := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := newGiteaSource(&svc, config, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			type output struct {
+				Include []string
+				Exclude []string
+			}
+			var got output
+			for _, r := range repos {
+				if s.excludes(r) {
+					got.Exclude = append(got.Exclude, r.FullName)
+				} else {
+					got.Include = append(got.Include, r.FullName)
+				}
+			}
+
+			path := filepath.Join("testdata", "gitea-repos-exclude-"+name+".golden")
+			testutil.AssertGolden(t, path, update(name), got)
+		})
+	}
+}
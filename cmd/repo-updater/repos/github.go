@@ -15,6 +15,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
@@ -295,12 +296,14 @@ func (s GithubSource) GetRepo(ctx context.Context, nameWithOwner string) (*Repo,
 
 func (s GithubSource) makeRepo(r *github.Repository) *Repo {
 	urn := s.svc.URN()
+	cloneURL := s.authenticatedRemoteURL(r)
+	name := string(reposource.GitHubRepoName(
+		s.config.RepositoryPathPattern,
+		s.originalHostname,
+		r.NameWithOwner,
+	))
 	return &Repo{
-		Name: string(reposource.GitHubRepoName(
-			s.config.RepositoryPathPattern,
-			s.originalHostname,
-			r.NameWithOwner,
-		)),
+		Name: name,
 		URI: string(reposource.GitHubRepoName(
 			"",
 			s.originalHostname,
@@ -314,13 +317,37 @@ func (s GithubSource) makeRepo(r *github.Repository) *Repo {
 		Sources: map[string]*SourceInfo{
 			urn: {
 				ID:       urn,
-				CloneURL: s.authenticatedRemoteURL(r),
+				CloneURL: cloneURL,
+				LFS:      s.lfsOptions(cloneURL),
+				Fetch:    s.fetchOptions(name, cloneURL),
 			},
 		},
 		Metadata: r,
 	}
 }
 
+// lfsOptions returns the options for fetching the Git LFS files of a repo
+// with the given clone URL.
+func (s *GithubSource) lfsOptions(cloneURL string) *gitserverprotocol.LFSOptions {
+	if s.config.GitLFS == nil {
+		return nil
+	}
+	return lfsOptions(cloneURL, s.config.GitLFS.Enabled, s.config.GitLFS.MaxFileSize)
+}
+
+// fetchOptions returns the additional refspecs and remotes to fetch into
+// the repo with the given name and clone URL.
+func (s *GithubSource) fetchOptions(name, cloneURL string) *gitserverprotocol.FetchOptions {
+	if s.config.GitFetch == nil {
+		return nil
+	}
+	remotes := make([]gitRemote, 0, len(s.config.GitFetch.Remotes))
+	for _, r := range s.config.GitFetch.Remotes {
+		remotes = append(remotes, gitRemote{Repository: r.Repository, Name: r.Name, URL: r.Url})
+	}
+	return fetchOptions(name, cloneURL, s.config.GitFetch.Refspecs, remotes)
+}
+
 // authenticatedRemoteURL returns the repository's Git remote URL with the configured
 // GitHub personal access token inserted in the URL userinfo.
 func (s *GithubSource) authenticatedRemoteURL(repo *github.Repository) string {
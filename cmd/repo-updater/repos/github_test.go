@@ -477,6 +477,19 @@ func TestGithubSource_makeRepo(t *testing.T) {
 				Url:                   "https://github.com",
 				RepositoryPathPattern: "gh/{nameWithOwner}",
 			},
+		}, {
+			name: "lfs",
+			schmea: &schema.GitHubConnection{
+				Url:    "https://github.com",
+				GitLFS: &schema.GitHubGitLFS{Enabled: true},
+			},
+		}, {
+			name: "lfs-ssh",
+			schmea: &schema.GitHubConnection{
+				Url:        "https://github.com",
+				GitURLType: "ssh",
+				GitLFS:     &schema.GitHubGitLFS{Enabled: true, MaxFileSize: 1024},
+			},
 		},
 	}
 	for _, test := range tests {
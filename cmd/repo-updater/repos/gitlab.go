@@ -14,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -113,13 +114,15 @@ func (s GitLabSource) ExternalServices() ExternalServices {
 
 func (s GitLabSource) makeRepo(proj *gitlab.Project) *Repo {
 	urn := s.svc.URN()
+	cloneURL := s.authenticatedRemoteURL(proj)
+	name := string(reposource.GitLabRepoName(
+		s.config.RepositoryPathPattern,
+		s.baseURL.Hostname(),
+		proj.PathWithNamespace,
+		s.nameTransformations,
+	))
 	return &Repo{
-		Name: string(reposource.GitLabRepoName(
-			s.config.RepositoryPathPattern,
-			s.baseURL.Hostname(),
-			proj.PathWithNamespace,
-			s.nameTransformations,
-		)),
+		Name: name,
 		URI: string(reposource.GitLabRepoName(
 			"",
 			s.baseURL.Hostname(),
@@ -134,13 +137,37 @@ func (s GitLabSource) makeRepo(proj *gitlab.Project) *Repo {
 		Sources: map[string]*SourceInfo{
 			urn: {
 				ID:       urn,
-				CloneURL: s.authenticatedRemoteURL(proj),
+				CloneURL: cloneURL,
+				LFS:      s.lfsOptions(cloneURL),
+				Fetch:    s.fetchOptions(name, cloneURL),
 			},
 		},
 		Metadata: proj,
 	}
 }
 
+// lfsOptions returns the options for fetching the Git LFS files of a repo
+// with the given clone URL.
+func (s *GitLabSource) lfsOptions(cloneURL string) *gitserverprotocol.LFSOptions {
+	if s.config.GitLFS == nil {
+		return nil
+	}
+	return lfsOptions(cloneURL, s.config.GitLFS.Enabled, s.config.GitLFS.MaxFileSize)
+}
+
+// fetchOptions returns the additional refspecs and remotes to fetch into
+// the repo with the given name and clone URL.
+func (s *GitLabSource) fetchOptions(name, cloneURL string) *gitserverprotocol.FetchOptions {
+	if s.config.GitFetch == nil {
+		return nil
+	}
+	remotes := make([]gitRemote, 0, len(s.config.GitFetch.Remotes))
+	for _, r := range s.config.GitFetch.Remotes {
+		remotes = append(remotes, gitRemote{Repository: r.Repository, Name: r.Name, URL: r.Url})
+	}
+	return fetchOptions(name, cloneURL, s.config.GitFetch.Refspecs, remotes)
+}
+
 // authenticatedRemoteURL returns the GitLab projects's Git remote URL with the configured GitLab personal access
 // token inserted in the URL userinfo, for repositories needing authentication.
 func (s *GitLabSource) authenticatedRemoteURL(proj *gitlab.Project) string {
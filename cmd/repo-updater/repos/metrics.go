@@ -56,6 +56,15 @@ var (
 		Name: "src_repoupdater_sched_manual_fetch",
 		Help: "Incremented each time the scheduler updates a repository due to user traffic.",
 	})
+	schedWebhooks = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_repoupdater_sched_webhooks",
+		Help: "Incremented each time the scheduler updates a repository due to a push webhook.",
+	})
+	schedWebhookToGitserverUpdateDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "src_repoupdater_sched_webhook_to_gitserver_update_seconds",
+		Help:    "Time from receiving a push webhook for a repository until it is updated on gitserver. It doesn't include the time until the new commits are indexed for search.",
+		Buckets: []float64{1, 2, 5, 10, 30, 60, 120, 300, 600, 1800},
+	})
 	schedKnownRepos = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "src_repoupdater_sched_known_repos",
 		Help: "The number of repositories that are managed by the scheduler.",
@@ -77,6 +77,10 @@ const (
 
 	// maxDelay is the maximum amount of time between scheduled updates for a single repository.
 	maxDelay = 8 * time.Hour
+
+	// webhookTimeout is how long after the last webhook for a repository it is polled
+	// as a repository that receives webhooks.
+	webhookTimeout = 7 * 24 * time.Hour
 )
 
 // updateScheduler schedules repo update (or clone) requests to gitserver.
@@ -93,11 +97,21 @@ const (
 //
 // A worker continuously dequeues repos and sends updates to gitserver, but its concurrency
 // is limited by the gitMaxConcurrentClones site configuration.
+//
+// Repos are also updated when a code host notifies us of a push with a webhook. Repos that
+// receive webhooks reliably are only polled every maxDelay, as a safety net for missed
+// webhooks. Webhooks are considered unreliable for a repo once polling finds changes that
+// no webhook announced.
 type updateScheduler struct {
 	mu sync.Mutex
 
 	updateQueue *updateQueue
 	schedule    *schedule
+
+	// webhooks holds the time of the earliest push webhook received for each repo
+	// that hasn't been followed by an update yet.
+	webhooksMu sync.Mutex
+	webhooks   map[api.RepoID]time.Time
 }
 
 // A configuredRepo2 represents the configuration data for a given repo from
@@ -107,6 +121,12 @@ type configuredRepo2 struct {
 	URL  string
 	ID   api.RepoID
 	Name api.RepoName
+	// LFS configures fetching the repo's Git LFS files. It is nil if it's
+	// unknown, e.g. for updates requested by other services.
+	LFS *gitserverprotocol.LFSOptions
+	// Fetch configures the additional refspecs and remotes fetched into the
+	// repo. It is nil if it's unknown.
+	Fetch *gitserverprotocol.FetchOptions
 }
 
 // notifyChanBuffer controls the buffer size of notification channels.
@@ -125,6 +145,7 @@ func NewUpdateScheduler() *updateScheduler {
 			index:  make(map[api.RepoID]*scheduledRepoUpdate),
 			wakeup: make(chan struct{}, notifyChanBuffer),
 		},
+		webhooks: make(map[api.RepoID]time.Time),
 	}
 }
 
@@ -188,14 +209,37 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 
 			go func(ctx context.Context, repo configuredRepo2, cancel context.CancelFunc) {
 				defer cancel()
-				defer s.updateQueue.remove(repo, true)
+
+				start := timeNow()
+				defer func() {
+					s.updateQueue.remove(repo, true)
+					// A webhook that was received during the update may announce commits
+					// that the update didn't fetch, so we update the repo again.
+					if _, ok := s.pendingWebhook(repo.ID); ok {
+						s.updateQueue.enqueue(repo, priorityHigh)
+					}
+				}()
 
 				resp, err := requestRepoUpdate(ctx, repo, 1*time.Second)
 				if err != nil {
 					schedError.Inc()
 					log15.Warn("error requesting repo update", "uri", repo.Name, "err", err)
 				}
+
+				webhookAt, fromWebhook := s.pendingWebhook(repo.ID)
+				if fromWebhook && webhookAt.Before(start) {
+					s.removePendingWebhook(repo.ID)
+					if err == nil {
+						schedWebhookToGitserverUpdateDuration.Observe(timeNow().Sub(webhookAt).Seconds())
+					}
+				}
+
 				if resp != nil && resp.LastFetched != nil && resp.LastChanged != nil {
+					if !fromWebhook && !resp.LastChanged.Before(start) {
+						// This update found changes that no webhook announced.
+						s.schedule.webhookMissed(repo)
+					}
+
 					// This is the heuristic that is described in the updateScheduler documentation.
 					// Update that documentation if you update this logic.
 					interval := resp.LastFetched.Sub(*resp.LastChanged) / 2
@@ -208,7 +252,7 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 
 // requestRepoUpdate sends a request to gitserver to request an update.
 var requestRepoUpdate = func(ctx context.Context, repo configuredRepo2, since time.Duration) (*gitserverprotocol.RepoUpdateResponse, error) {
-	return gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repo.Name, URL: repo.URL}, since)
+	return gitserver.DefaultClient.RequestRepoUpdate(ctx, gitserver.Repo{Name: repo.Name, URL: repo.URL}, since, &gitserver.RepoUpdateOptions{LFS: repo.LFS, Fetch: repo.Fetch})
 }
 
 // configuredLimiter returns a mutable limiter that is
@@ -287,8 +331,24 @@ func configuredRepo2FromRepo(r *Repo) configuredRepo2 {
 		Name: api.RepoName(r.Name),
 	}
 
-	if urls := r.CloneURLs(); len(urls) > 0 {
-		repo.URL = urls[0]
+	for _, src := range r.Sources {
+		if src == nil || src.CloneURL == "" {
+			continue
+		}
+		repo.URL = src.CloneURL
+		// Always send LFS options for known repos, so that gitserver stops
+		// fetching Git LFS files once they are disabled.
+		repo.LFS = src.LFS
+		if repo.LFS == nil {
+			repo.LFS = &gitserverprotocol.LFSOptions{}
+		}
+		// Likewise, gitserver removes refspecs and remotes which are no
+		// longer configured.
+		repo.Fetch = src.Fetch
+		if repo.Fetch == nil {
+			repo.Fetch = &gitserverprotocol.FetchOptions{}
+		}
+		break
 	}
 
 	return repo
@@ -306,6 +366,38 @@ func (s *updateScheduler) UpdateOnce(id api.RepoID, name api.RepoName, url strin
 	s.updateQueue.enqueue(repo, priorityHigh)
 }
 
+// UpdateFromWebhook causes a single update of the given repository because a
+// code host notified us of a push to it. It marks the repo as receiving
+// webhooks, so that it is polled less often.
+func (s *updateScheduler) UpdateFromWebhook(id api.RepoID, name api.RepoName, url string) {
+	now := timeNow()
+
+	s.webhooksMu.Lock()
+	if _, ok := s.webhooks[id]; !ok {
+		s.webhooks[id] = now
+	}
+	s.webhooksMu.Unlock()
+
+	schedWebhooks.Inc()
+	s.schedule.webhookReceived(configuredRepo2{ID: id, Name: name, URL: url}, now)
+	s.UpdateOnce(id, name, url)
+}
+
+// pendingWebhook returns the time of the earliest webhook received for the
+// repo since its last update, if any.
+func (s *updateScheduler) pendingWebhook(id api.RepoID) (time.Time, bool) {
+	s.webhooksMu.Lock()
+	defer s.webhooksMu.Unlock()
+	at, ok := s.webhooks[id]
+	return at, ok
+}
+
+func (s *updateScheduler) removePendingWebhook(id api.RepoID) {
+	s.webhooksMu.Lock()
+	delete(s.webhooks, id)
+	s.webhooksMu.Unlock()
+}
+
 // DebugDump returns the state of the update scheduler for debugging.
 func (s *updateScheduler) DebugDump() interface{} {
 	data := struct {
@@ -575,10 +667,11 @@ type schedule struct {
 
 // scheduledRepoUpdate is the update schedule for a single repo.
 type scheduledRepoUpdate struct {
-	Repo     configuredRepo2 // the repo to update
-	Interval time.Duration   // how regularly the repo is updated
-	Due      time.Time       // the next time that the repo will be enqueued for a update
-	Index    int             `json:"-"` // the index in the heap
+	Repo      configuredRepo2 // the repo to update
+	Interval  time.Duration   // how regularly the repo is updated
+	Due       time.Time       // the next time that the repo will be enqueued for a update
+	WebhookAt time.Time       // the last time a webhook was received for the repo, if webhooks are reliable
+	Index     int             `json:"-"` // the index in the heap
 }
 
 // upsert inserts or updates a repo in the schedule.
@@ -616,7 +709,7 @@ func (s *schedule) updateInterval(repo configuredRepo2, interval time.Duration)
 	s.mu.Lock()
 	if update := s.index[repo.ID]; update != nil {
 		switch {
-		case interval > maxDelay:
+		case interval > maxDelay, update.receivesWebhooks():
 			update.Interval = maxDelay
 		case interval < minDelay:
 			update.Interval = minDelay
@@ -631,6 +724,41 @@ func (s *schedule) updateInterval(repo configuredRepo2, interval time.Duration)
 	s.mu.Unlock()
 }
 
+// webhookReceived records that a webhook was received for a repo in the schedule.
+// It does nothing if the repo is not in the schedule.
+func (s *schedule) webhookReceived(repo configuredRepo2, at time.Time) {
+	if repo.ID == 0 {
+		panic("repo.id is zero")
+	}
+
+	s.mu.Lock()
+	if update := s.index[repo.ID]; update != nil {
+		update.WebhookAt = at
+	}
+	s.mu.Unlock()
+}
+
+// webhookMissed records that an update of a repo in the schedule found changes that
+// weren't announced by a webhook, so that the repo is polled as if it received none.
+// It does nothing if the repo is not in the schedule.
+func (s *schedule) webhookMissed(repo configuredRepo2) {
+	if repo.ID == 0 {
+		panic("repo.id is zero")
+	}
+
+	s.mu.Lock()
+	if update := s.index[repo.ID]; update != nil && !update.WebhookAt.IsZero() {
+		log15.Debug("changes not announced by webhook", "repo", repo.Name)
+		update.WebhookAt = time.Time{}
+	}
+	s.mu.Unlock()
+}
+
+// receivesWebhooks reports whether the repo has reliably received webhooks recently.
+func (u *scheduledRepoUpdate) receivesWebhooks() bool {
+	return !u.WebhookAt.IsZero() && timeNow().Sub(u.WebhookAt) < webhookTimeout
+}
+
 // remove removes a repo from the schedule.
 func (s *schedule) remove(repo configuredRepo2) (removed bool) {
 	if repo.ID == 0 {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
 )
@@ -556,8 +557,11 @@ func verifyQueue(t *testing.T, s *updateScheduler, expected []*repoUpdate) {
 }
 
 func Test_updateScheduler_UpdateFromDiff(t *testing.T) {
-	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
-	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com"}
+	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com",
+		LFS:   &gitserverprotocol.LFSOptions{Enabled: true, MaxFileSize: 1024},
+		Fetch: &gitserverprotocol.FetchOptions{Refspecs: []string{"+refs/notes/*:refs/notes/*"}},
+	}
+	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com", LFS: &gitserverprotocol.LFSOptions{}, Fetch: &gitserverprotocol.FetchOptions{}}
 
 	tests := []struct {
 		name            string
@@ -589,7 +593,7 @@ func Test_updateScheduler_UpdateFromDiff(t *testing.T) {
 						ID:   a.ID,
 						Name: string(a.Name),
 						Sources: map[string]*SourceInfo{
-							string(a.Name): {CloneURL: a.URL},
+							string(a.Name): {CloneURL: a.URL, LFS: a.LFS, Fetch: a.Fetch},
 						},
 					},
 				},
@@ -659,6 +663,38 @@ func Test_updateScheduler_UpdateFromDiff(t *testing.T) {
 	}
 }
 
+func Test_updateScheduler_UpdateFromWebhook(t *testing.T) {
+	_, stop := startRecording()
+	defer stop()
+
+	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
+	b := configuredRepo2{ID: 2, Name: "b", URL: "b.com"}
+
+	s := NewUpdateScheduler()
+	setupInitialSchedule(s, []*scheduledRepoUpdate{
+		{Repo: a, Interval: maxDelay, Due: defaultTime.Add(time.Hour)},
+	})
+
+	s.UpdateFromWebhook(a.ID, a.Name, a.URL)
+	mockTime(defaultTime.Add(time.Second))
+	s.UpdateFromWebhook(a.ID, a.Name, a.URL)
+	s.UpdateFromWebhook(b.ID, b.Name, b.URL)
+
+	verifySchedule(t, s, []*scheduledRepoUpdate{
+		{Repo: a, Interval: maxDelay, Due: defaultTime.Add(time.Hour), WebhookAt: defaultTime.Add(time.Second)},
+	})
+	verifyQueue(t, s, []*repoUpdate{
+		{Repo: a, Seq: 1, Priority: priorityHigh},
+		{Repo: b, Seq: 2, Priority: priorityHigh},
+	})
+
+	// The pending webhooks keep the time of the first webhook since the last update.
+	want := map[api.RepoID]time.Time{a.ID: defaultTime, b.ID: defaultTime.Add(time.Second)}
+	if diff := cmp.Diff(want, s.webhooks); diff != "" {
+		t.Fatalf("unexpected pending webhooks (-want +got):\n%s", diff)
+	}
+}
+
 func TestSchedule_upsert(t *testing.T) {
 	a := configuredRepo2{ID: 1, Name: "a", URL: "a.com"}
 	a2 := configuredRepo2{ID: 1, Name: "a2", URL: "a2.com"}
@@ -938,6 +974,62 @@ func TestSchedule_updateInterval(t *testing.T) {
 			timeAfterFuncDelays: []time.Duration{123 * time.Minute},
 			wakeupNotifications: 1,
 		},
+		{
+			name: "maximum interval if repo receives webhooks",
+			initialSchedule: []*scheduledRepoUpdate{
+				{
+					Repo:      a,
+					Interval:  minDelay,
+					Due:       defaultTime.Add(minDelay),
+					WebhookAt: defaultTime.Add(-time.Hour),
+				},
+			},
+			updateCalls: []*updateCall{
+				{
+					repo:     a,
+					time:     defaultTime,
+					interval: 10 * time.Minute,
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{
+					Repo:      a,
+					Interval:  maxDelay,
+					Due:       defaultTime.Add(maxDelay),
+					WebhookAt: defaultTime.Add(-time.Hour),
+				},
+			},
+			timeAfterFuncDelays: []time.Duration{maxDelay},
+			wakeupNotifications: 1,
+		},
+		{
+			name: "normal interval if last webhook is too old",
+			initialSchedule: []*scheduledRepoUpdate{
+				{
+					Repo:      a,
+					Interval:  minDelay,
+					Due:       defaultTime.Add(minDelay),
+					WebhookAt: defaultTime.Add(-webhookTimeout),
+				},
+			},
+			updateCalls: []*updateCall{
+				{
+					repo:     a,
+					time:     defaultTime,
+					interval: 10 * time.Minute,
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{
+					Repo:      a,
+					Interval:  10 * time.Minute,
+					Due:       defaultTime.Add(10 * time.Minute),
+					WebhookAt: defaultTime.Add(-webhookTimeout),
+				},
+			},
+			timeAfterFuncDelays: []time.Duration{10 * time.Minute},
+			wakeupNotifications: 1,
+		},
 		{
 			name: "heap reorders correctly",
 			initialSchedule: []*scheduledRepoUpdate{
@@ -1260,9 +1352,11 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 		gitMaxConcurrentClones int
 		initialSchedule        []*scheduledRepoUpdate
 		initialQueue           []*repoUpdate
+		initialWebhooks        map[api.RepoID]time.Time
 		mockRequestRepoUpdates []*mockRequestRepoUpdate
 		finalSchedule          []*scheduledRepoUpdate
 		finalQueue             []*repoUpdate
+		finalWebhooks          map[api.RepoID]time.Time
 		timeAfterFuncDelays    []time.Duration
 		expectedNotifications  func(s *updateScheduler) []chan struct{}
 	}{
@@ -1326,6 +1420,59 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 				return []chan struct{}{s.schedule.wakeup}
 			},
 		},
+		{
+			name:                   "webhook update",
+			gitMaxConcurrentClones: 1,
+			initialSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour), WebhookAt: defaultTime.Add(-time.Minute)},
+			},
+			initialQueue: []*repoUpdate{
+				{Repo: a, Seq: 1, Priority: priorityHigh},
+			},
+			initialWebhooks: map[api.RepoID]time.Time{a.ID: defaultTime.Add(-time.Minute)},
+			mockRequestRepoUpdates: []*mockRequestRepoUpdate{
+				{
+					repo: a,
+					resp: &gitserverprotocol.RepoUpdateResponse{
+						LastFetched: timePtr(defaultTime),
+						LastChanged: timePtr(defaultTime),
+					},
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: maxDelay, Due: defaultTime.Add(maxDelay), WebhookAt: defaultTime.Add(-time.Minute)},
+			},
+			timeAfterFuncDelays: []time.Duration{maxDelay},
+			expectedNotifications: func(s *updateScheduler) []chan struct{} {
+				return []chan struct{}{s.schedule.wakeup}
+			},
+		},
+		{
+			name:                   "polling finds changes not announced by webhook",
+			gitMaxConcurrentClones: 1,
+			initialSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: maxDelay, Due: defaultTime.Add(maxDelay), WebhookAt: defaultTime.Add(-time.Hour)},
+			},
+			initialQueue: []*repoUpdate{
+				{Repo: a, Seq: 1},
+			},
+			mockRequestRepoUpdates: []*mockRequestRepoUpdate{
+				{
+					repo: a,
+					resp: &gitserverprotocol.RepoUpdateResponse{
+						LastFetched: timePtr(defaultTime.Add(2 * time.Minute)),
+						LastChanged: timePtr(defaultTime),
+					},
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: time.Minute, Due: defaultTime.Add(time.Minute)},
+			},
+			timeAfterFuncDelays: []time.Duration{time.Minute},
+			expectedNotifications: func(s *updateScheduler) []chan struct{} {
+				return []chan struct{}{s.schedule.wakeup}
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -1369,6 +1516,9 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 
 			setupInitialSchedule(s, test.initialSchedule)
 			setupInitialQueue(s, test.initialQueue)
+			for id, at := range test.initialWebhooks {
+				s.webhooks[id] = at
+			}
 
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
@@ -1393,6 +1543,11 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 			verifySchedule(t, s, test.finalSchedule)
 			verifyQueue(t, s, test.finalQueue)
 			verifyRecording(t, s, test.timeAfterFuncDelays, test.expectedNotifications, r)
+			if len(s.webhooks) != 0 || len(test.finalWebhooks) != 0 {
+				if diff := cmp.Diff(test.finalWebhooks, s.webhooks); diff != "" {
+					t.Fatalf("unexpected pending webhooks (-want +got):\n%s", diff)
+				}
+			}
 
 			// Cancel the context.
 			cancel()
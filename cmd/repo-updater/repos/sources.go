@@ -80,6 +80,10 @@ func newSource(svc *ExternalService, cf *httpcli.Factory, rl *rate.Limiter) (Sou
 		return NewBitbucketServerSource(svc, cf, rl)
 	case extsvc.KindBitbucketCloud:
 		return NewBitbucketCloudSource(svc, cf)
+	case extsvc.KindGerrit:
+		return NewGerritSource(svc, cf)
+	case extsvc.KindGitea:
+		return NewGiteaSource(svc, cf)
 	case extsvc.KindGitolite:
 		return NewGitoliteSource(svc, cf)
 	case extsvc.KindPhabricator:
@@ -19,9 +19,12 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/schema"
 	"github.com/xeipuuv/gojsonschema"
@@ -108,6 +111,10 @@ func (e ExternalService) BaseURL() (*url.URL, error) {
 		return nil, errors.New("BaseURL unavailable for AWSCodeCommit")
 	case *schema.BitbucketServerConnection:
 		rawURL = c.Url
+	case *schema.GerritConnection:
+		rawURL = c.Url
+	case *schema.GiteaConnection:
+		rawURL = c.Url
 	case *schema.GitHubConnection:
 		rawURL = c.Url
 	case *schema.GitLabConnection:
@@ -140,6 +147,10 @@ func (e *ExternalService) Exclude(rs ...*Repo) error {
 		return e.excludeGitLabRepos(rs...)
 	case extsvc.KindBitbucketServer:
 		return e.excludeBitbucketServerRepos(rs...)
+	case extsvc.KindGerrit:
+		return e.excludeGerritProjects(rs...)
+	case extsvc.KindGitea:
+		return e.excludeGiteaRepos(rs...)
 	case extsvc.KindAWSCodeCommit:
 		return e.excludeAWSCodeCommitRepos(rs...)
 	case extsvc.KindGitolite:
@@ -318,6 +329,80 @@ func (e *ExternalService) excludeBitbucketServerRepos(rs ...*Repo) error {
 	})
 }
 
+// excludeGerritProjects changes the configuration of a Gerrit external service to exclude the
+// given projects from being synced.
+func (e *ExternalService) excludeGerritProjects(rs ...*Repo) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return e.config("gerrit", func(v interface{}) (string, interface{}, error) {
+		c := v.(*schema.GerritConnection)
+		set := make(map[string]bool, len(c.Exclude))
+		for _, ex := range c.Exclude {
+			if ex.Name != "" {
+				set[strings.ToLower(ex.Name)] = true
+			}
+		}
+
+		for _, r := range rs {
+			p, ok := r.Metadata.(*gerrit.Project)
+			if ok && p.Name != "" && !set[strings.ToLower(p.Name)] {
+				c.Exclude = append(c.Exclude, &schema.ExcludedGerritProject{Name: p.Name})
+				set[strings.ToLower(p.Name)] = true
+			}
+		}
+
+		return "exclude", c.Exclude, nil
+	})
+}
+
+// excludeGiteaRepos changes the configuration of a Gitea external service to exclude the
+// given repos from being synced.
+func (e *ExternalService) excludeGiteaRepos(rs ...*Repo) error {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return e.config("gitea", func(v interface{}) (string, interface{}, error) {
+		c := v.(*schema.GiteaConnection)
+		set := make(map[string]bool, len(c.Exclude)*2)
+		for _, ex := range c.Exclude {
+			if ex.Id != 0 {
+				set[strconv.Itoa(ex.Id)] = true
+			}
+
+			if ex.Name != "" {
+				set[strings.ToLower(ex.Name)] = true
+			}
+		}
+
+		for _, r := range rs {
+			repo, ok := r.Metadata.(*gitea.Repo)
+			if !ok {
+				continue
+			}
+
+			id := strconv.FormatInt(repo.ID, 10)
+			name := repo.FullName
+
+			if !set[strings.ToLower(name)] && !set[id] {
+				c.Exclude = append(c.Exclude, &schema.ExcludedGiteaRepo{
+					Name: name,
+					Id:   int(repo.ID),
+				})
+
+				set[id] = true
+				if name != "" {
+					set[strings.ToLower(name)] = true
+				}
+			}
+		}
+
+		return "exclude", c.Exclude, nil
+	})
+}
+
 // excludeGitoliteRepos changes the configuration of a Gitolite external service to exclude the
 // given repos from being synced.
 func (e *ExternalService) excludeGitoliteRepos(rs ...*Repo) error {
@@ -484,6 +569,10 @@ func (e ExternalService) schema() string {
 		return schema.AWSCodeCommitSchemaJSON
 	case extsvc.KindBitbucketServer:
 		return schema.BitbucketServerSchemaJSON
+	case extsvc.KindGerrit:
+		return schema.GerritSchemaJSON
+	case extsvc.KindGitea:
+		return schema.GiteaSchemaJSON
 	case extsvc.KindGitHub:
 		return schema.GitHubSchemaJSON
 	case extsvc.KindGitLab:
@@ -592,6 +681,77 @@ type Repo struct {
 type SourceInfo struct {
 	ID       string
 	CloneURL string
+	// LFS configures fetching the Git LFS files of the repo. It is nil if
+	// Git LFS files aren't fetched.
+	LFS *gitserverprotocol.LFSOptions `json:",omitempty"`
+	// Fetch configures the additional refspecs and remotes fetched into the
+	// repo. It is nil if only the default refs are fetched.
+	Fetch *gitserverprotocol.FetchOptions `json:",omitempty"`
+}
+
+// defaultLFSMaxFileSize is the maximum size of Git LFS files fetched if
+// the gitLFS setting of a code host connection doesn't set one.
+const defaultLFSMaxFileSize = 100 * 1024 * 1024
+
+// lfsOptions returns the options for fetching the Git LFS files of a repo
+// from the gitLFS setting of its code host connection. It returns nil if Git
+// LFS files aren't fetched, which is also the case for SSH clone URLs since
+// Git LFS is only supported over HTTP(S).
+func lfsOptions(cloneURL string, enabled bool, maxFileSize int) *gitserverprotocol.LFSOptions {
+	if !enabled || !(strings.HasPrefix(cloneURL, "http://") || strings.HasPrefix(cloneURL, "https://")) {
+		return nil
+	}
+	if maxFileSize == 0 {
+		maxFileSize = defaultLFSMaxFileSize
+	}
+	return &gitserverprotocol.LFSOptions{Enabled: true, MaxFileSize: int64(maxFileSize)}
+}
+
+// gitRemote is an additional remote of the gitFetch setting of a code host
+// connection.
+type gitRemote struct {
+	Repository string
+	Name       string
+	URL        string
+}
+
+// fetchOptions returns the additional refspecs and remotes to fetch into the
+// repo with the given name and clone URL, from the gitFetch setting of its
+// code host connection. It returns nil if there is nothing to fetch besides
+// the default refs.
+//
+// Remotes on the same host as the clone URL are fetched with the credentials
+// of the clone URL.
+func fetchOptions(repoName, cloneURL string, refspecs []string, remotes []gitRemote) *gitserverprotocol.FetchOptions {
+	opts := gitserverprotocol.FetchOptions{Refspecs: refspecs}
+	for _, r := range remotes {
+		if r.Repository != repoName {
+			continue
+		}
+		opts.Remotes = append(opts.Remotes, gitserverprotocol.Remote{
+			Name: r.Name,
+			URL:  remoteURLWithCredentials(r.URL, cloneURL),
+		})
+	}
+	if len(opts.Refspecs) == 0 && len(opts.Remotes) == 0 {
+		return nil
+	}
+	return &opts
+}
+
+// remoteURLWithCredentials returns remoteURL with the userinfo of cloneURL if
+// both are HTTP(S) URLs on the same host and remoteURL has no userinfo.
+func remoteURLWithCredentials(remoteURL, cloneURL string) string {
+	remote, err := url.Parse(remoteURL)
+	if err != nil || remote.User != nil || (remote.Scheme != "http" && remote.Scheme != "https") {
+		return remoteURL
+	}
+	clone, err := url.Parse(cloneURL)
+	if err != nil || clone.User == nil || clone.Host != remote.Host {
+		return remoteURL
+	}
+	remote.User = clone.User
+	return remote.String()
 }
 
 // ExternalServiceID returns the ID of the external service this
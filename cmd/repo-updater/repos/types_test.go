@@ -3,6 +3,7 @@ package repos
 import (
 	"context"
 	"encoding/json"
+	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -11,9 +12,12 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitolite"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/schema"
 	"golang.org/x/time/rate"
@@ -69,6 +73,29 @@ func TestExternalService_Exclude(t *testing.T) {
 		UpdatedAt: now,
 	}
 
+	gerritService := ExternalService{
+		Kind:        extsvc.KindGerrit,
+		DisplayName: "Gerrit",
+		Config: `{
+			// Some comment
+			"url": "https://gerrit.mycorp.com"
+		}`,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	giteaService := ExternalService{
+		Kind:        extsvc.KindGitea,
+		DisplayName: "Gitea",
+		Config: `{
+			// Some comment
+			"url": "https://gitea.mycorp.com",
+			"token": "secret"
+		}`,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
 	awsCodeCommitService := ExternalService{
 		ID:          9,
 		Kind:        extsvc.KindAWSCodeCommit,
@@ -150,6 +177,23 @@ func TestExternalService_Exclude(t *testing.T) {
 				},
 			},
 		},
+		{
+			Metadata: &gerrit.Project{Name: "org/foo"},
+		},
+		{
+			Metadata: &gerrit.Project{Name: "org/baz"},
+		},
+		{
+			Metadata: &gitea.Repo{
+				ID:       1,
+				FullName: "org/foo",
+			},
+		},
+		{
+			Metadata: &gitea.Repo{
+				FullName: "org/baz",
+			},
+		},
 		{
 			Metadata: &awscodecommit.Repository{
 				ID:   "f001337a-3450-46fd-b7d2-650c0EXAMPLE",
@@ -225,6 +269,29 @@ func TestExternalService_Exclude(t *testing.T) {
 					]
 				}`)
 			}),
+			gerritService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gerrit.mycorp.com",
+					"exclude": [
+						{"name": "org/foo"},
+						{"name": "org/BAZ"}
+					]
+				}`)
+			}),
+			giteaService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gitea.mycorp.com",
+					"token": "secret",
+					"exclude": [
+						{"id": 1},
+						{"name": "org/baz"}
+					]
+				}`)
+			}),
 			awsCodeCommitService.With(func(e *ExternalService) {
 				e.Config = formatJSON(t, `
 				{
@@ -299,6 +366,27 @@ func TestExternalService_Exclude(t *testing.T) {
 					]
 				}`)
 			}),
+			gerritService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gerrit.mycorp.com",
+					"exclude": [
+						{"name": "org/boo"},
+					]
+				}`)
+			}),
+			giteaService.With(func(e *ExternalService) {
+				e.Config = formatJSON(t, `
+				{
+					// Some comment
+					"url": "https://gitea.mycorp.com",
+					"token": "secret",
+					"exclude": [
+						{"name": "org/boo"},
+					]
+				}`)
+			}),
 			awsCodeCommitService.With(func(e *ExternalService) {
 				e.Config = formatJSON(t, `
 				{
@@ -384,6 +472,31 @@ func TestExternalService_Exclude(t *testing.T) {
 						]
 					}`)
 				}),
+				gerritService.With(func(e *ExternalService) {
+					e.Config = formatJSON(t, `
+					{
+						// Some comment
+						"url": "https://gerrit.mycorp.com",
+						"exclude": [
+							{"name": "org/boo"},
+							{"name": "org/foo"},
+							{"name": "org/baz"}
+						]
+					}`)
+				}),
+				giteaService.With(func(e *ExternalService) {
+					e.Config = formatJSON(t, `
+					{
+						// Some comment
+						"url": "https://gitea.mycorp.com",
+						"token": "secret",
+						"exclude": [
+							{"name": "org/boo"},
+							{"id": 1, "name": "org/foo"},
+							{"name": "org/baz"}
+						]
+					}`)
+				}),
 				awsCodeCommitService.With(func(e *ExternalService) {
 					e.Config = formatJSON(t, `
 					{
@@ -499,6 +612,56 @@ func TestPick(t *testing.T) {
 	}
 }
 
+func TestFetchOptions(t *testing.T) {
+	remotes := []gitRemote{
+		{Repository: "github.com/foo/bar", Name: "alice", URL: "https://github.com/alice/bar.git"},
+		{Repository: "github.com/foo/bar", Name: "bob", URL: "https://example.com/bob/bar.git"},
+		{Repository: "github.com/foo/baz", Name: "carol", URL: "https://github.com/carol/baz.git"},
+	}
+	refspecs := []string{"+refs/notes/*:refs/notes/*"}
+
+	for _, tc := range []struct {
+		name     string
+		repo     string
+		cloneURL string
+		refspecs []string
+		want     *gitserverprotocol.FetchOptions
+	}{{
+		name:     "remotes get credentials for the same host",
+		repo:     "github.com/foo/bar",
+		cloneURL: "https://token@github.com/foo/bar",
+		refspecs: refspecs,
+		want: &gitserverprotocol.FetchOptions{
+			Refspecs: refspecs,
+			Remotes: []gitserverprotocol.Remote{
+				{Name: "alice", URL: "https://token@github.com/alice/bar.git"},
+				{Name: "bob", URL: "https://example.com/bob/bar.git"},
+			},
+		},
+	}, {
+		name:     "only remotes of the repo",
+		repo:     "github.com/foo/baz",
+		cloneURL: "git@github.com:foo/baz.git",
+		want: &gitserverprotocol.FetchOptions{
+			Remotes: []gitserverprotocol.Remote{
+				{Name: "carol", URL: "https://github.com/carol/baz.git"},
+			},
+		},
+	}, {
+		name:     "nothing to fetch",
+		repo:     "github.com/foo/qux",
+		cloneURL: "https://github.com/foo/qux",
+		want:     nil,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fetchOptions(tc.repo, tc.cloneURL, tc.refspecs, remotes)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
 func formatJSON(t testing.TB, s string) string {
 	formatted, err := jsonc.Format(s, nil)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
@@ -39,6 +42,7 @@ type Server struct {
 	}
 	Scheduler interface {
 		UpdateOnce(id api.RepoID, name api.RepoName, url string)
+		UpdateFromWebhook(id api.RepoID, name api.RepoName, url string)
 		ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult
 	}
 	GitserverClient interface {
@@ -80,6 +84,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/status-messages", s.handleStatusMessages)
 	mux.HandleFunc("/enqueue-changeset-sync", s.handleEnqueueChangesetSync)
 	mux.HandleFunc("/schedule-perms-sync", s.handleSchedulePermsSync)
+	mux.HandleFunc("/push-webhooks/", s.handlePushWebhook)
 	return mux
 }
 
@@ -701,6 +706,37 @@ func newRepoInfo(r *repos.Repo) (*protocol.RepoInfo, error) {
 			Blob:   pathAppend(root, "/browse/{path}?at={rev}"),
 			Commit: pathAppend(root, "/commits/{commit}"),
 		}
+	case gitea.ServiceType:
+		repo := r.Metadata.(*gitea.Repo)
+		if repo.HTMLURL == "" {
+			break
+		}
+
+		// Gitea redirects these Gogs-style URLs to the branch, tag or commit named by rev.
+		info.Links = &protocol.RepoLinks{
+			Root:   repo.HTMLURL,
+			Tree:   pathAppend(repo.HTMLURL, "/src/{rev}/{path}"),
+			Blob:   pathAppend(repo.HTMLURL, "/src/{rev}/{path}"),
+			Commit: pathAppend(repo.HTMLURL, "/commit/{commit}"),
+		}
+	case gerrit.ServiceType:
+		proj := r.Metadata.(*gerrit.Project)
+		baseURL, err := url.Parse(r.ExternalRepo.ServiceID)
+		if err != nil {
+			break
+		}
+
+		// Gerrit itself only shows changes, so the project links to its changes and a commit
+		// links to the change that introduced it. Files are browsed in Gitiles, if installed.
+		info.Links = &protocol.RepoLinks{
+			Root:   pathAppend(baseURL.String(), "/q/project:"+proj.Name),
+			Commit: pathAppend(baseURL.String(), "/q/{commit}"),
+		}
+		if gitiles := proj.GitilesURL(baseURL); gitiles != "" {
+			info.Links.Root = gitiles
+			info.Links.Tree = pathAppend(gitiles, "/+/{rev}/{path}")
+			info.Links.Blob = pathAppend(gitiles, "/+/{rev}/{path}")
+		}
 	case "awscodecommit":
 		repo := r.Metadata.(*awscodecommit.Repository)
 		if repo.ARN == "" {
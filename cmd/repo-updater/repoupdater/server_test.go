@@ -24,6 +24,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/awscodecommit"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gerrit"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitea"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
@@ -814,6 +816,55 @@ func TestRepoLookup(t *testing.T) {
 		},
 	}
 
+	giteaRepository := &repos.Repo{
+		Name:        "gitea.mycorp.com/foo/bar",
+		Description: "The description",
+		CreatedAt:   now,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "42",
+			ServiceType: gitea.ServiceType,
+			ServiceID:   "https://gitea.mycorp.com/",
+		},
+		Sources: map[string]*repos.SourceInfo{
+			"extsvc:gitea:789": {
+				ID:       "extsvc:gitea:789",
+				CloneURL: "https://gitea.mycorp.com/foo/bar.git",
+			},
+		},
+		Metadata: &gitea.Repo{
+			ID:          42,
+			Name:        "bar",
+			FullName:    "foo/bar",
+			Description: "The description",
+			HTMLURL:     "https://gitea.mycorp.com/foo/bar",
+			CloneURL:    "https://gitea.mycorp.com/foo/bar.git",
+		},
+	}
+
+	gerritRepository := &repos.Repo{
+		Name:        "gerrit.mycorp.com/platform/build",
+		Description: "The description",
+		CreatedAt:   now,
+		ExternalRepo: api.ExternalRepoSpec{
+			ID:          "platform%2Fbuild",
+			ServiceType: gerrit.ServiceType,
+			ServiceID:   "https://gerrit.mycorp.com/",
+		},
+		Sources: map[string]*repos.SourceInfo{
+			"extsvc:gerrit:123": {
+				ID:       "extsvc:gerrit:123",
+				CloneURL: "https://gerrit.mycorp.com/platform/build",
+			},
+		},
+		Metadata: &gerrit.Project{
+			ID:          "platform%2Fbuild",
+			Name:        "platform/build",
+			Description: "The description",
+			State:       gerrit.ProjectStateActive,
+			WebLinks:    []gerrit.WebLink{{Name: "gitiles", URL: "/plugins/gitiles/platform/build"}},
+		},
+	}
+
 	gitlabRepository := &repos.Repo{
 		Name:        "gitlab.com/gitlab-org/gitaly",
 		Description: "Gitaly is a Git RPC service for handling all the git calls made by GitLab",
@@ -909,6 +960,52 @@ func TestRepoLookup(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "found - Gitea",
+			args: protocol.RepoLookupArgs{
+				Repo: api.RepoName("gitea.mycorp.com/foo/bar"),
+			},
+			stored: []*repos.Repo{giteaRepository},
+			result: &protocol.RepoLookupResult{Repo: &protocol.RepoInfo{
+				ExternalRepo: api.ExternalRepoSpec{
+					ID:          "42",
+					ServiceType: gitea.ServiceType,
+					ServiceID:   "https://gitea.mycorp.com/",
+				},
+				Name:        "gitea.mycorp.com/foo/bar",
+				Description: "The description",
+				VCS:         protocol.VCSInfo{URL: "https://gitea.mycorp.com/foo/bar.git"},
+				Links: &protocol.RepoLinks{
+					Root:   "https://gitea.mycorp.com/foo/bar",
+					Tree:   "https://gitea.mycorp.com/foo/bar/src/{rev}/{path}",
+					Blob:   "https://gitea.mycorp.com/foo/bar/src/{rev}/{path}",
+					Commit: "https://gitea.mycorp.com/foo/bar/commit/{commit}",
+				},
+			}},
+		},
+		{
+			name: "found - Gerrit",
+			args: protocol.RepoLookupArgs{
+				Repo: api.RepoName("gerrit.mycorp.com/platform/build"),
+			},
+			stored: []*repos.Repo{gerritRepository},
+			result: &protocol.RepoLookupResult{Repo: &protocol.RepoInfo{
+				ExternalRepo: api.ExternalRepoSpec{
+					ID:          "platform%2Fbuild",
+					ServiceType: gerrit.ServiceType,
+					ServiceID:   "https://gerrit.mycorp.com/",
+				},
+				Name:        "gerrit.mycorp.com/platform/build",
+				Description: "The description",
+				VCS:         protocol.VCSInfo{URL: "https://gerrit.mycorp.com/platform/build"},
+				Links: &protocol.RepoLinks{
+					Root:   "https://gerrit.mycorp.com/plugins/gitiles/platform/build",
+					Tree:   "https://gerrit.mycorp.com/plugins/gitiles/platform/build/+/{rev}/{path}",
+					Blob:   "https://gerrit.mycorp.com/plugins/gitiles/platform/build/+/{rev}/{path}",
+					Commit: "https://gerrit.mycorp.com/q/{commit}",
+				},
+			}},
+		},
 		{
 			name: "found - GitHub.com on Sourcegraph.com",
 			args: protocol.RepoLookupArgs{
@@ -1137,7 +1234,8 @@ func (s *fakeRepoSource) GetRepo(context.Context, string) (*repos.Repo, error) {
 
 type fakeScheduler struct{}
 
-func (s *fakeScheduler) UpdateOnce(_ api.RepoID, _ api.RepoName, _ string) {}
+func (s *fakeScheduler) UpdateOnce(_ api.RepoID, _ api.RepoName, _ string)        {}
+func (s *fakeScheduler) UpdateFromWebhook(_ api.RepoID, _ api.RepoName, _ string) {}
 func (s *fakeScheduler) ScheduleInfo(id api.RepoID) *protocol.RepoUpdateSchedulerInfoResult {
 	return &protocol.RepoUpdateSchedulerInfoResult{}
 }
@@ -0,0 +1,229 @@
+package repoupdater
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	gh "github.com/google/go-github/v28/github"
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// A pushEventParser authenticates a push webhook request with the configuration of
+// an external service and returns the external repo that was pushed to. It returns
+// errWebhookUnauthorized if the request isn't authenticated with one of the external
+// service's webhook secrets, and a nil repo if the event isn't a push event.
+type pushEventParser func(r *http.Request, payload []byte, config interface{}) (*api.ExternalRepoSpec, error)
+
+// pushWebhooks maps the last path element of push webhook URLs
+// (/push-webhooks/{name}) to the external service kind that sends them.
+var pushWebhooks = map[string]struct {
+	kind  string
+	parse pushEventParser
+}{
+	"github":           {extsvc.KindGitHub, parseGitHubPushEvent},
+	"gitlab":           {extsvc.KindGitLab, parseGitLabPushEvent},
+	"bitbucket-server": {extsvc.KindBitbucketServer, parseBitbucketServerPushEvent},
+}
+
+var errWebhookUnauthorized = errors.New("webhook request is not authenticated with a configured secret")
+
+// handlePushWebhook updates the repository that a code host notified us of a push to.
+func (s *Server) handlePushWebhook(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/push-webhooks/")
+	hook, ok := pushWebhooks[name]
+	if !ok {
+		respond(w, http.StatusNotFound, errors.Errorf("no push webhooks for %q", name))
+		return
+	}
+
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	args := repos.StoreListExternalServicesArgs{Kinds: []string{hook.kind}}
+	if rawID := r.URL.Query().Get(extsvc.IDParam); rawID != "" {
+		id, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			respond(w, http.StatusBadRequest, errors.Wrap(err, "invalid external service id"))
+			return
+		}
+		args.IDs = []int64{id}
+	}
+
+	es, err := s.Store.ListExternalServices(r.Context(), args)
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// 🚨 SECURITY: Only trust the request if it is authenticated with the webhook secret of
+	// one of the external services. Since there are usually few external services of a
+	// kind, it's ok to try all of them.
+	var (
+		spec          *api.ExternalRepoSpec
+		authenticated bool
+	)
+	for _, e := range es {
+		c, err := e.Configuration()
+		if err != nil {
+			continue
+		}
+
+		spec, err = hook.parse(r, payload, c)
+		if err == errWebhookUnauthorized {
+			continue
+		} else if err != nil {
+			respond(w, http.StatusBadRequest, err)
+			return
+		}
+		authenticated = true
+		break
+	}
+
+	if !authenticated {
+		respond(w, http.StatusUnauthorized, errWebhookUnauthorized)
+		return
+	}
+
+	if spec == nil {
+		respond(w, http.StatusOK, nil) // Not a push event
+		return
+	}
+
+	rs, err := s.Store.ListRepos(r.Context(), repos.StoreListReposArgs{
+		ExternalRepos: []api.ExternalRepoSpec{*spec},
+	})
+	if err != nil {
+		respond(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(rs) == 0 {
+		log15.Debug("push webhook for unknown repo", "externalRepo", spec)
+		respond(w, http.StatusOK, nil)
+		return
+	}
+
+	repo := rs[0]
+	var cloneURL string
+	if urls := repo.CloneURLs(); len(urls) > 0 {
+		cloneURL = urls[0]
+	}
+	s.Scheduler.UpdateFromWebhook(repo.ID, api.RepoName(repo.Name), cloneURL)
+
+	respond(w, http.StatusOK, nil)
+}
+
+func parseGitHubPushEvent(r *http.Request, payload []byte, config interface{}) (*api.ExternalRepoSpec, error) {
+	c := config.(*schema.GitHubConnection)
+
+	sig := r.Header.Get("X-Hub-Signature")
+	authenticated := false
+	for _, hook := range c.Webhooks {
+		if hook.Secret != "" && gh.ValidateSignature(sig, payload, []byte(hook.Secret)) == nil {
+			authenticated = true
+			break
+		}
+	}
+	if !authenticated {
+		return nil, errWebhookUnauthorized
+	}
+
+	if gh.WebHookType(r) != "push" {
+		return nil, nil
+	}
+
+	e, err := gh.ParseWebHook("push", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	id := e.(*gh.PushEvent).GetRepo().GetNodeID()
+	if id == "" {
+		return nil, errors.New("push event has no repository ID")
+	}
+	return externalRepoSpec(id, github.ServiceType, c.Url)
+}
+
+func parseGitLabPushEvent(r *http.Request, payload []byte, config interface{}) (*api.ExternalRepoSpec, error) {
+	c := config.(*schema.GitLabConnection)
+
+	token := gitlab.WebhookToken(r)
+	authenticated := false
+	for _, hook := range c.Webhooks {
+		if hook.Secret != "" && subtle.ConstantTimeCompare([]byte(token), []byte(hook.Secret)) == 1 {
+			authenticated = true
+			break
+		}
+	}
+	if !authenticated {
+		return nil, errWebhookUnauthorized
+	}
+
+	switch gitlab.WebhookEventType(r) {
+	case "Push Hook", "Tag Push Hook":
+	default:
+		return nil, nil
+	}
+
+	e, err := gitlab.ParseWebhookEvent(gitlab.WebhookEventType(r), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	id := e.(*gitlab.PushEvent).ProjectID
+	if id == 0 {
+		return nil, errors.New("push event has no project ID")
+	}
+	return externalRepoSpec(strconv.Itoa(id), gitlab.ServiceType, c.Url)
+}
+
+func parseBitbucketServerPushEvent(r *http.Request, payload []byte, config interface{}) (*api.ExternalRepoSpec, error) {
+	c := config.(*schema.BitbucketServerConnection)
+
+	secret := c.WebhookSecret()
+	if secret == "" || gh.ValidateSignature(r.Header.Get("X-Hub-Signature"), payload, []byte(secret)) != nil {
+		return nil, errWebhookUnauthorized
+	}
+
+	if bitbucketserver.WebhookEventType(r) != "repo:refs_changed" {
+		return nil, nil
+	}
+
+	e, err := bitbucketserver.ParseWebhookEvent("repo:refs_changed", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	id := e.(*bitbucketserver.RefsChangedEvent).Repository.ID
+	if id == 0 {
+		return nil, errors.New("push event has no repository ID")
+	}
+	return externalRepoSpec(strconv.Itoa(id), bitbucketserver.ServiceType, c.Url)
+}
+
+func externalRepoSpec(id, serviceType, rawURL string) (*api.ExternalRepoSpec, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid code host URL %q", rawURL)
+	}
+	return &api.ExternalRepoSpec{
+		ID:          id,
+		ServiceType: serviceType,
+		ServiceID:   extsvc.NormalizeBaseURL(u).String(),
+	}, nil
+}
@@ -0,0 +1,187 @@
+package repoupdater
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/repo-updater/repos"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc/gitlab"
+)
+
+func TestServer_handlePushWebhook(t *testing.T) {
+	ctx := context.Background()
+
+	store := new(repos.FakeStore)
+	if err := store.UpsertExternalServices(ctx,
+		&repos.ExternalService{
+			ID:     1,
+			Kind:   extsvc.KindGitHub,
+			Config: `{"url": "https://github.com", "token": "abc", "webhooks": [{"org": "sourcegraph", "secret": "github-secret"}]}`,
+		},
+		&repos.ExternalService{
+			ID:     2,
+			Kind:   extsvc.KindGitLab,
+			Config: `{"url": "https://gitlab.com", "token": "abc", "webhooks": [{"secret": "gitlab-secret"}]}`,
+		},
+		&repos.ExternalService{
+			ID:     3,
+			Kind:   extsvc.KindBitbucketServer,
+			Config: `{"url": "https://bitbucket.mycorp.com", "token": "abc", "plugin": {"webhooks": {"secret": "bbs-secret"}}}`,
+		},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	newRepo := func(name, id, serviceType, serviceID string) *repos.Repo {
+		return &repos.Repo{
+			Name: name,
+			ExternalRepo: api.ExternalRepoSpec{
+				ID:          id,
+				ServiceType: serviceType,
+				ServiceID:   serviceID,
+			},
+			Sources: map[string]*repos.SourceInfo{
+				"extsvc:" + serviceType + ":1": {
+					ID:       "extsvc:" + serviceType + ":1",
+					CloneURL: "https://" + name + ".git",
+				},
+			},
+		}
+	}
+	if err := store.UpsertRepos(ctx,
+		newRepo("github.com/sourcegraph/sourcegraph", "MDEwOlJlcG9zaXRvcnk0MTI4ODcwOA==", github.ServiceType, "https://github.com/"),
+		newRepo("gitlab.com/gitlab-org/gitaly", "2009901", gitlab.ServiceType, "https://gitlab.com/"),
+		newRepo("bitbucket.mycorp.com/sg/sourcegraph", "10", bitbucketserver.ServiceType, "https://bitbucket.mycorp.com/"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(hash string, secret, payload string) string {
+		h := hmac.New(sha1.New, []byte(secret))
+		if hash == "sha256" {
+			h = hmac.New(sha256.New, []byte(secret))
+		}
+		h.Write([]byte(payload))
+		return hash + "=" + hex.EncodeToString(h.Sum(nil))
+	}
+
+	githubPush := `{"ref": "refs/heads/master", "repository": {"id": 41288708, "node_id": "MDEwOlJlcG9zaXRvcnk0MTI4ODcwOA=="}}`
+	githubUnknownRepoPush := `{"ref": "refs/heads/master", "repository": {"id": 1, "node_id": "MDEwOlJlcG9zaXRvcnkx"}}`
+	gitlabPush := `{"object_kind": "push", "ref": "refs/heads/master", "project_id": 2009901, "project": {"id": 2009901}}`
+	bbsPush := `{"eventKey": "repo:refs_changed", "repository": {"id": 10, "slug": "sourcegraph"}, "changes": []}`
+
+	for _, tc := range []struct {
+		name    string
+		path    string
+		header  map[string]string
+		payload string
+		code    int
+		updated []api.RepoName
+	}{
+		{
+			name:    "github push",
+			path:    "/push-webhooks/github",
+			header:  map[string]string{"X-Github-Event": "push", "X-Hub-Signature": sign("sha1", "github-secret", githubPush)},
+			payload: githubPush,
+			code:    http.StatusOK,
+			updated: []api.RepoName{"github.com/sourcegraph/sourcegraph"},
+		},
+		{
+			name:    "github push with wrong signature",
+			path:    "/push-webhooks/github",
+			header:  map[string]string{"X-Github-Event": "push", "X-Hub-Signature": sign("sha1", "other-secret", githubPush)},
+			payload: githubPush,
+			code:    http.StatusUnauthorized,
+		},
+		{
+			name:    "github push to unknown repo",
+			path:    "/push-webhooks/github",
+			header:  map[string]string{"X-Github-Event": "push", "X-Hub-Signature": sign("sha1", "github-secret", githubUnknownRepoPush)},
+			payload: githubUnknownRepoPush,
+			code:    http.StatusOK,
+		},
+		{
+			name:    "github ping",
+			path:    "/push-webhooks/github",
+			header:  map[string]string{"X-Github-Event": "ping", "X-Hub-Signature": sign("sha1", "github-secret", `{}`)},
+			payload: `{}`,
+			code:    http.StatusOK,
+		},
+		{
+			name:    "github push for other external service",
+			path:    "/push-webhooks/github?externalServiceID=2",
+			header:  map[string]string{"X-Github-Event": "push", "X-Hub-Signature": sign("sha1", "github-secret", githubPush)},
+			payload: githubPush,
+			code:    http.StatusUnauthorized,
+		},
+		{
+			name:    "gitlab push",
+			path:    "/push-webhooks/gitlab",
+			header:  map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "gitlab-secret"},
+			payload: gitlabPush,
+			code:    http.StatusOK,
+			updated: []api.RepoName{"gitlab.com/gitlab-org/gitaly"},
+		},
+		{
+			name:    "gitlab push with wrong token",
+			path:    "/push-webhooks/gitlab",
+			header:  map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "github-secret"},
+			payload: gitlabPush,
+			code:    http.StatusUnauthorized,
+		},
+		{
+			name:    "bitbucket server push",
+			path:    "/push-webhooks/bitbucket-server",
+			header:  map[string]string{"X-Event-Key": "repo:refs_changed", "X-Hub-Signature": sign("sha256", "bbs-secret", bbsPush)},
+			payload: bbsPush,
+			code:    http.StatusOK,
+			updated: []api.RepoName{"bitbucket.mycorp.com/sg/sourcegraph"},
+		},
+		{
+			name:    "unknown code host",
+			path:    "/push-webhooks/gitea",
+			payload: `{}`,
+			code:    http.StatusNotFound,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sched := &recordingScheduler{}
+			s := &Server{Store: store, Scheduler: sched}
+
+			req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.payload))
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tc.code {
+				t.Errorf("got status %d, want %d (body %q)", rec.Code, tc.code, rec.Body.String())
+			}
+			if diff := cmp.Diff(tc.updated, sched.updated); diff != "" {
+				t.Errorf("unexpected repo updates (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+type recordingScheduler struct {
+	fakeScheduler
+	updated []api.RepoName
+}
+
+func (s *recordingScheduler) UpdateFromWebhook(_ api.RepoID, name api.RepoName, _ string) {
+	s.updated = append(s.updated, name)
+}
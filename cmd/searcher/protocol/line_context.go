@@ -0,0 +1,71 @@
+package protocol
+
+import (
+	"sort"
+	"strings"
+)
+
+// LineContext returns at most n lines of content before and after each of the
+// given 0-based line numbers.
+//
+// The context of nearby matched lines is merged: a line is only returned once,
+// and matched lines are not returned as context of other matched lines. So the
+// context before a matched line, the matched line and the context after it
+// form consecutive ranges of the file that don't overlap with the ranges of
+// other matched lines. The context is truncated at the start and end of the
+// file.
+func LineContext(content []byte, lineNumbers []int, n int) (before, after [][]string) {
+	before = make([][]string, len(lineNumbers))
+	after = make([][]string, len(lineNumbers))
+	if n <= 0 || len(lineNumbers) == 0 {
+		return before, after
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		// The content ends with a newline (or is empty).
+		lines = lines[:len(lines)-1]
+	}
+
+	// Line numbers are usually sorted, except for structural search results.
+	order := make([]int, len(lineNumbers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return lineNumbers[order[i]] < lineNumbers[order[j]] })
+
+	covered := -1 // the last line that is a matched line or returned as context
+	for k, i := range order {
+		line := lineNumbers[i]
+		if line >= len(lines) {
+			continue
+		}
+
+		start := max(line-n, covered+1, 0)
+		end := line + n + 1 // exclusive
+		if k+1 < len(order) && lineNumbers[order[k+1]] < end {
+			end = lineNumbers[order[k+1]]
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		if start < line {
+			before[i] = lines[start:line]
+		}
+		if line+1 < end {
+			after[i] = lines[line+1 : end]
+		}
+		covered = max(covered, line, end-1)
+	}
+	return before, after
+}
+
+func max(x int, ys ...int) int {
+	for _, y := range ys {
+		if y > x {
+			x = y
+		}
+	}
+	return x
+}
@@ -0,0 +1,89 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineContext(t *testing.T) {
+	content := []byte("0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+
+	tests := []struct {
+		name        string
+		lineNumbers []int
+		n           int
+		wantBefore  [][]string
+		wantAfter   [][]string
+	}{
+		{
+			name:        "no context",
+			lineNumbers: []int{4},
+			n:           0,
+			wantBefore:  [][]string{nil},
+			wantAfter:   [][]string{nil},
+		},
+		{
+			name:        "single match",
+			lineNumbers: []int{4},
+			n:           2,
+			wantBefore:  [][]string{{"2", "3"}},
+			wantAfter:   [][]string{{"5", "6"}},
+		},
+		{
+			name:        "start and end of file",
+			lineNumbers: []int{1, 9},
+			n:           3,
+			wantBefore:  [][]string{{"0"}, {"6", "7", "8"}},
+			wantAfter:   [][]string{{"2", "3", "4"}, nil},
+		},
+		{
+			name:        "overlapping context is merged",
+			lineNumbers: []int{3, 6},
+			n:           3,
+			wantBefore:  [][]string{{"0", "1", "2"}, nil},
+			wantAfter:   [][]string{{"4", "5"}, {"7", "8", "9"}},
+		},
+		{
+			name:        "adjacent matches",
+			lineNumbers: []int{4, 5},
+			n:           1,
+			wantBefore:  [][]string{{"3"}, nil},
+			wantAfter:   [][]string{nil, {"6"}},
+		},
+		{
+			name:        "multiple matches on a line",
+			lineNumbers: []int{4, 4},
+			n:           1,
+			wantBefore:  [][]string{{"3"}, nil},
+			wantAfter:   [][]string{nil, {"5"}},
+		},
+		{
+			name:        "unsorted",
+			lineNumbers: []int{6, 2},
+			n:           2,
+			wantBefore:  [][]string{{"5"}, {"0", "1"}},
+			wantAfter:   [][]string{{"7", "8"}, {"3", "4"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before, after := LineContext(content, test.lineNumbers, test.n)
+			if !reflect.DeepEqual(before, test.wantBefore) {
+				t.Errorf("got before %q, want %q", before, test.wantBefore)
+			}
+			if !reflect.DeepEqual(after, test.wantAfter) {
+				t.Errorf("got after %q, want %q", after, test.wantAfter)
+			}
+		})
+	}
+
+	t.Run("no trailing newline", func(t *testing.T) {
+		before, after := LineContext([]byte("a\nb\nc"), []int{1}, 5)
+		if want := [][]string{{"a"}}; !reflect.DeepEqual(before, want) {
+			t.Errorf("got before %q, want %q", before, want)
+		}
+		if want := [][]string{{"c"}}; !reflect.DeepEqual(after, want) {
+			t.Errorf("got after %q, want %q", after, want)
+		}
+	})
+}
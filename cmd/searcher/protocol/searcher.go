@@ -23,6 +23,13 @@ type Request struct {
 	// "599cba5e7b6137d46ddf58fb1765f5d928e69604"
 	Commit api.CommitID
 
+	// Commits are additional commits of the repository to search with
+	// Commit. Files that are the same in several of the commits are only
+	// searched once, and their matches list all the commits that contain
+	// them in FileMatch.Commits. They are required to be resolved, like
+	// Commit.
+	Commits []api.CommitID
+
 	PatternInfo
 
 	// The amount of time to wait for a repo archive to fetch.
@@ -103,8 +110,17 @@ type PatternInfo struct {
 	// Languages is the languages passed via the lang filters (e.g., "lang:c")
 	Languages []string
 
+	// LangIncludePatterns are the patterns of IncludePatterns which come from
+	// the lang filters. A file which doesn't match them is still searched if
+	// the language detected from its contents is one of Languages.
+	LangIncludePatterns []string
+
 	// CombyRule is a rule that constrains matching for structural search. It only applies when IsStructuralPat is true.
 	CombyRule string
+
+	// ContextLines is the number of lines before and after each matched line
+	// that are returned with the line match.
+	ContextLines int
 }
 
 func (p *PatternInfo) String() string {
@@ -137,6 +153,9 @@ func (p *PatternInfo) String() string {
 	for _, lang := range p.Languages {
 		args = append(args, fmt.Sprintf("lang:%s", lang))
 	}
+	if p.ContextLines > 0 {
+		args = append(args, fmt.Sprintf("context:%d", p.ContextLines))
+	}
 
 	path := "glob"
 	if p.PathPatternsAreRegExps {
@@ -164,6 +183,11 @@ type Response struct {
 
 	// DeadlineHit is true if Matches may not include all FileMatches because a deadline was hit.
 	DeadlineHit bool
+
+	// Commits are the commits that were searched, if Request.Commits was
+	// set. Older searchers ignore Request.Commits and only search
+	// Request.Commit, which clients detect by the absence of this field.
+	Commits []api.CommitID `json:",omitempty"`
 }
 
 // FileMatch is the struct used by vscode to receive search results
@@ -175,6 +199,17 @@ type FileMatch struct {
 
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
+
+	// Commits are the commits that contain the file with the same
+	// contents. It is only set if several commits were searched (see
+	// Request.Commits).
+	Commits []api.CommitID `json:",omitempty"`
+
+	// Encoding is the original text encoding of the file if it isn't UTF-8,
+	// e.g. "UTF-16LE". The file is searched after it is transcoded to UTF-8,
+	// and LineMatches are in the transcoded file: line numbers and character
+	// offsets are the same as in the original file.
+	Encoding string `json:",omitempty"`
 }
 
 // LineMatch is the struct used by vscode to receive search results for a line.
@@ -193,4 +228,9 @@ type LineMatch struct {
 
 	// LimitHit is true if OffsetAndLengths may not include all OffsetAndLengths.
 	LimitHit bool
+
+	// ContextBefore and ContextAfter are the lines before and after the
+	// matched line, if context lines were requested. See LineContext.
+	ContextBefore []string `json:",omitempty"`
+	ContextAfter  []string `json:",omitempty"`
 }
@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
+	"github.com/src-d/enry/v2"
+)
+
+// maxLangDetectBytes is the number of bytes at the start of a file which are
+// used to detect its language.
+const maxLangDetectBytes = 16 * 1024
+
+// langMatcher reports whether a file is in the languages of the lang: filters
+// of a search. Unlike the include patterns of the lang: filters, which only
+// match the file extensions of the languages, it also detects the languages
+// of files from their names and contents (e.g., a Dockerfile or a script
+// without file extension).
+//
+// Indexed search only matches the include patterns, so it doesn't find the
+// files whose languages are only detected from their contents.
+type langMatcher struct {
+	// matchPath is compiled from the include patterns of the lang: filters.
+	matchPath pathmatch.PathMatcher
+
+	// languages are the names of the languages of the lang: filters.
+	languages []string
+}
+
+// compileLangMatcher returns a langMatcher for the lang: filters of p, or nil
+// if p has no LangIncludePatterns.
+func compileLangMatcher(p *protocol.PatternInfo, options pathmatch.CompileOptions) (*langMatcher, error) {
+	if len(p.LangIncludePatterns) == 0 {
+		return nil, nil
+	}
+	matchPath, err := pathmatch.CompilePathPatterns(p.LangIncludePatterns, "", options)
+	if err != nil {
+		return nil, err
+	}
+	languages := make([]string, 0, len(p.Languages))
+	for _, alias := range p.Languages {
+		language, ok := enry.GetLanguageByAlias(alias)
+		if !ok {
+			return nil, fmt.Errorf("unknown language: %q", alias)
+		}
+		languages = append(languages, language)
+	}
+	return &langMatcher{matchPath: matchPath, languages: languages}, nil
+}
+
+// match reports whether the named file with the given contents is in all the
+// languages of the lang: filters. The languages detected from the contents
+// are cached by the commit and the name of the file, which identify the
+// contents, so that searches of the same commit don't detect them again.
+func (m *langMatcher) match(commit api.CommitID, name string, content []byte) bool {
+	if m.matchPath.MatchPath(name) {
+		return true
+	}
+
+	language, safe := inventory.GetLanguageByFilename(name)
+	if !safe {
+		if len(enry.GetLanguagesByExtension(name, nil, nil)) > 0 {
+			// The include patterns already match all the languages of the
+			// file extension, so there is no need to look at the contents.
+			return false
+		}
+		if len(content) > maxLangDetectBytes {
+			content = content[:maxLangDetectBytes]
+		}
+		var contentID string
+		if commit != "" {
+			contentID = string(commit) + ":" + name
+		}
+		language = inventory.GetLanguageCached(contentID, name, content)
+	}
+	if language == "" {
+		return false
+	}
+	for _, l := range m.languages {
+		if l != language {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutPatterns returns the patterns which are not in remove.
+func withoutPatterns(patterns, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, p := range remove {
+		removed[p] = true
+	}
+	var kept []string
+	for _, p := range patterns {
+		if !removed[p] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
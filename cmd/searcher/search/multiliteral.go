@@ -0,0 +1,225 @@
+package search
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxLiteralSetSize is the maximum number of literals in the set of
+	// literals a regexp matches (see literalSet).
+	maxLiteralSetSize = 10000
+
+	// maxLiteralSetCharClass is the maximum number of characters of a
+	// character class expanded in the set of literals.
+	maxLiteralSetCharClass = 10
+)
+
+// literalSet returns the finite set of strings re matches, if it is small
+// enough. For example it returns {"foo", "fob", "bar"} for foo|fob|bar (which
+// the regexp parser factors into fo[ob]|bar). Zero-width assertions (like ^
+// and \b) are ignored: every match of re is one of the strings, but a string
+// does not necessarily match re.
+//
+// ok is false if re matches an infinite or too large set of strings, or if it
+// matches case-insensitively.
+func literalSet(re *syntax.Regexp) (set []string, ok bool) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+
+	case syntax.OpCharClass:
+		for i := 0; i < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if len(set) == maxLiteralSetCharClass {
+					return nil, false
+				}
+				set = append(set, string(r))
+			}
+		}
+		return set, true
+
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return []string{""}, true
+
+	case syntax.OpCapture:
+		return literalSet(re.Sub[0])
+
+	case syntax.OpQuest:
+		set, ok := literalSet(re.Sub[0])
+		if !ok || len(set) == maxLiteralSetSize {
+			return nil, false
+		}
+		return append(set, ""), true
+
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			subSet, ok := literalSet(sub)
+			if !ok || len(set)+len(subSet) > maxLiteralSetSize {
+				return nil, false
+			}
+			set = append(set, subSet...)
+		}
+		return set, true
+
+	case syntax.OpConcat:
+		set = []string{""}
+		for _, sub := range re.Sub {
+			subSet, ok := literalSet(sub)
+			if !ok || len(set)*len(subSet) > maxLiteralSetSize {
+				return nil, false
+			}
+			product := make([]string, 0, len(set)*len(subSet))
+			for _, prefix := range set {
+				for _, suffix := range subSet {
+					product = append(product, prefix+suffix)
+				}
+			}
+			set = product
+		}
+		return set, true
+	}
+	return nil, false
+}
+
+// multiLiteralMatcher reports whether a text contains any of a set of
+// literals. It is an Aho-Corasick automaton, which finds all the literals in
+// a single pass over the text.
+//
+// The automaton is a DFA whose transitions are indexed by equivalence classes
+// of bytes rather than by bytes: all the bytes which don't appear in any of
+// the literals are in the same class, which keeps the transition table small.
+type multiLiteralMatcher struct {
+	// classes maps a byte to its equivalence class.
+	classes [256]uint8
+
+	// numClasses is the number of equivalence classes.
+	numClasses int
+
+	// next is the transition table: the next state from the state s on a
+	// byte of class c is next[s*numClasses+c]. The start state is 0.
+	next []int32
+
+	// final reports whether one of the literals ends at a state.
+	final []bool
+}
+
+// newMultiLiteralMatcher returns a matcher for literals. It returns nil if
+// there are less than two literals or if one of them is empty, in which case
+// a matcher isn't useful.
+func newMultiLiteralMatcher(literals []string) *multiLiteralMatcher {
+	if len(literals) < 2 {
+		return nil
+	}
+	for _, lit := range literals {
+		if lit == "" {
+			return nil
+		}
+	}
+	// Sort the literals so the automaton doesn't depend on their order.
+	literals = append([]string(nil), literals...)
+	sort.Strings(literals)
+
+	m := &multiLiteralMatcher{}
+
+	// Bytes which appear in the literals each get their own class. All
+	// the others are in class 0.
+	for _, lit := range literals {
+		for i := 0; i < len(lit); i++ {
+			if m.classes[lit[i]] == 0 {
+				m.numClasses++
+				m.classes[lit[i]] = uint8(m.numClasses)
+			}
+		}
+	}
+	m.numClasses++
+
+	// Build the trie of the literals. -1 is a missing transition.
+	m.next = make([]int32, m.numClasses)
+	m.final = []bool{false}
+	for i := range m.next {
+		m.next[i] = -1
+	}
+	for _, lit := range literals {
+		s := int32(0)
+		for i := 0; i < len(lit); i++ {
+			t := &m.next[int(s)*m.numClasses+int(m.classes[lit[i]])]
+			if *t == -1 {
+				*t = int32(len(m.final))
+				m.final = append(m.final, false)
+				for c := 0; c < m.numClasses; c++ {
+					m.next = append(m.next, -1)
+				}
+				// m.next may have been reallocated.
+				t = &m.next[int(s)*m.numClasses+int(m.classes[lit[i]])]
+			}
+			s = *t
+		}
+		m.final[s] = true
+	}
+
+	// Turn the trie into a DFA: a missing transition goes where the
+	// transition from the failure state (the state of the longest proper
+	// suffix of the current state which is in the trie) goes. Visiting the
+	// states in breadth-first order ensures that the transitions of the
+	// failure states are computed first.
+	fail := make([]int32, len(m.final))
+	queue := make([]int32, 0, len(m.final))
+	for c := 0; c < m.numClasses; c++ {
+		if t := m.next[c]; t == -1 {
+			m.next[c] = 0
+		} else {
+			queue = append(queue, t)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for c := 0; c < m.numClasses; c++ {
+			i := int(s)*m.numClasses + c
+			f := m.next[int(fail[s])*m.numClasses+c]
+			if t := m.next[i]; t == -1 {
+				m.next[i] = f
+			} else {
+				fail[t] = f
+				m.final[t] = m.final[t] || m.final[f]
+				queue = append(queue, t)
+			}
+		}
+	}
+	return m
+}
+
+// Match reports whether text contains any of the literals of m.
+func (m *multiLiteralMatcher) Match(text []byte) bool {
+	s := 0
+	for _, b := range text {
+		s = int(m.next[s*m.numClasses+int(m.classes[b])])
+		if m.final[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// compileMultiLiteral returns a matcher for the literals of re if re is an
+// alternation of literals (see literalSet), or nil.
+func compileMultiLiteral(re *syntax.Regexp) *multiLiteralMatcher {
+	set, ok := literalSet(re)
+	if !ok {
+		return nil
+	}
+	// The regexp matches U+FFFD against any invalid UTF-8 byte of the
+	// text, which a literal can't.
+	for _, lit := range set {
+		if strings.ContainsRune(lit, utf8.RuneError) {
+			return nil
+		}
+	}
+	return newMultiLiteralMatcher(set)
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/inconshreveable/log15"
 
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	nettrace "golang.org/x/net/trace"
@@ -123,6 +125,9 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		LimitHit:    limitHit,
 		DeadlineHit: deadlineHit,
 	}
+	if len(p.Commits) > 0 {
+		resp.Commits = append([]api.CommitID{p.Commit}, p.Commits...)
+	}
 	// The only reasonable error is the client going away now since we know we
 	// can encode resp. This happens relatively often due to our
 	// graphqlbackend regularly cancelling in-flight requests. We can't send
@@ -203,18 +208,15 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	prepareCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
 	defer cancel()
 
-	getZf := func() (string, *store.ZipFile, error) {
-		path, err := s.Store.PrepareZip(prepareCtx, p.GitserverRepo(), p.Commit)
-		if err != nil {
-			return "", nil, err
-		}
-		zf, err := s.Store.ZipCache.Get(path)
-		return path, zf, err
+	if len(p.Commits) > 0 {
+		span.SetTag("commits", len(p.Commits)+1)
+		matches, limitHit, err = s.searchCommits(ctx, prepareCtx, p, rg)
+		return matches, limitHit, false, err
 	}
 
-	zipPath, zf, err := store.GetZipFileWithRetry(getZf)
+	zipPath, zf, err := s.getZipFile(prepareCtx, p.GitserverRepo(), p.Commit)
 	if err != nil {
-		return nil, false, false, errors.Wrap(err, "failed to get archive")
+		return nil, false, false, err
 	}
 	defer zf.Close()
 
@@ -229,12 +231,36 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 
 	if p.IsStructuralPat {
 		matches, limitHit, err = structuralSearch(ctx, zipPath, p.Pattern, p.CombyRule, p.Languages, p.IncludePatterns, p.Repo)
+		if err == nil {
+			addStructuralContext(zf, matches, p.ContextLines)
+		}
 	} else {
+		rg.commit = p.Commit
 		matches, limitHit, err = regexSearch(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath)
 	}
 	return matches, limitHit, false, err
 }
 
+// getZipFile returns the archive of repo at commit. It waits at most until
+// prepareCtx is done for the archive to be fetched. The caller must close the
+// returned ZipFile.
+func (s *Service) getZipFile(prepareCtx context.Context, repo gitserver.Repo, commit api.CommitID) (string, *store.ZipFile, error) {
+	getZf := func() (string, *store.ZipFile, error) {
+		path, err := s.Store.PrepareZip(prepareCtx, repo, commit)
+		if err != nil {
+			return "", nil, err
+		}
+		zf, err := s.Store.ZipCache.Get(path)
+		return path, zf, err
+	}
+
+	zipPath, zf, err := store.GetZipFileWithRetry(getZf)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get archive")
+	}
+	return zipPath, zf, nil
+}
+
 func validateParams(p *protocol.Request) error {
 	if p.Repo == "" {
 		return errors.New("Repo must be non-empty")
@@ -243,6 +269,14 @@ func validateParams(p *protocol.Request) error {
 	if len(p.Commit) != 40 {
 		return errors.Errorf("Commit must be resolved (Commit=%q)", p.Commit)
 	}
+	for _, commit := range p.Commits {
+		if len(commit) != 40 {
+			return errors.Errorf("Commits must be resolved (Commit=%q)", commit)
+		}
+	}
+	if len(p.Commits) > 0 && p.IsStructuralPat {
+		return errors.New("Structural search of several commits is not supported")
+	}
 	if p.Pattern == "" && p.ExcludePattern == "" && len(p.IncludePatterns) == 0 {
 		return errors.New("At least one of pattern and include/exclude pattners must be non-empty")
 	}
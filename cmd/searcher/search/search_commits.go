@@ -0,0 +1,149 @@
+package search
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/store"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// searchCommits searches p.Commit and p.Commits. A file which is the same in
+// several of the commits (it has the same path and git blob object ID, as
+// listed by gitserver) is only searched once, and its match lists all the
+// commits that contain it.
+func (s *Service) searchCommits(ctx, prepareCtx context.Context, p *protocol.Request, rg *readerGrep) (matches []protocol.FileMatch, limitHit bool, err error) {
+	commits := append([]api.CommitID{p.Commit}, p.Commits...)
+	zfs := make([]*store.ZipFile, 0, len(commits))
+	oids := make([]map[string]git.OID, 0, len(commits))
+	defer func() {
+		for _, zf := range zfs {
+			zf.Close()
+		}
+	}()
+	for _, commit := range commits {
+		_, zf, err := s.getZipFile(prepareCtx, p.GitserverRepo(), commit)
+		if err != nil {
+			return nil, false, err
+		}
+		zfs = append(zfs, zf)
+
+		commitOIDs, err := blobObjectIDs(prepareCtx, p.GitserverRepo(), commit)
+		if err != nil {
+			return nil, false, errors.Wrap(err, "failed to list files")
+		}
+		oids = append(oids, commitOIDs)
+
+		archiveFiles.Observe(float64(len(zf.Files)))
+		archiveSize.Observe(float64(len(zf.Data)))
+	}
+
+	fileMatchLimit := p.FileMatchLimit
+	if fileMatchLimit > maxFileMatches || fileMatchLimit <= 0 {
+		fileMatchLimit = maxFileMatches
+	}
+
+	matches = []protocol.FileMatch{}
+	for i, groups := range groupFiles(zfs, oids) {
+		if len(groups) == 0 {
+			continue
+		}
+		if len(matches) >= fileMatchLimit {
+			limitHit = true
+			break
+		}
+
+		// Only search the files of the archive which weren't in the
+		// archives searched before.
+		zf := &store.ZipFile{Data: zfs[i].Data, MaxLen: zfs[i].MaxLen, Encodings: zfs[i].Encodings, Files: make([]store.SrcFile, len(groups))}
+		byPath := make(map[string]*fileGroup, len(groups))
+		for j, g := range groups {
+			zf.Files[j] = *g.file
+			byPath[g.file.Name] = g
+		}
+
+		rg.commit = commits[i]
+		fms, fmsLimitHit, err := regexSearch(ctx, rg, zf, fileMatchLimit-len(matches), p.PatternMatchesContent, p.PatternMatchesPath)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, fm := range fms {
+			g := byPath[fm.Path]
+			fm.Commits = make([]api.CommitID, len(g.archives))
+			for j, archive := range g.archives {
+				fm.Commits[j] = commits[archive]
+			}
+			matches = append(matches, fm)
+		}
+		if fmsLimitHit {
+			limitHit = true
+			break
+		}
+	}
+	return matches, limitHit, nil
+}
+
+// fileGroup is a file which is the same in one or more archives.
+type fileGroup struct {
+	// file is the file in the first archive which contains it. It is the
+	// copy of the file which is searched.
+	file *store.SrcFile
+
+	// archives are the indexes of the archives which contain the file.
+	archives []int
+}
+
+// blobObjectIDs returns the git blob object IDs of the files of repo at
+// commit, by path.
+func blobObjectIDs(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (map[string]git.OID, error) {
+	fis, err := git.ReadDir(ctx, repo, commit, "", true)
+	if err != nil {
+		return nil, err
+	}
+	oids := make(map[string]git.OID, len(fis))
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		if info, ok := fi.Sys().(git.ObjectInfo); ok {
+			oids[fi.Name()] = info.OID()
+		}
+	}
+	return oids, nil
+}
+
+// groupFiles groups the files of the archives which have the same path and
+// git blob object ID, according to oids (the object IDs of the files of each
+// archive by path). Files without object ID are not grouped. For each
+// archive, it returns the groups of the files which are not in the archives
+// before it.
+func groupFiles(zfs []*store.ZipFile, oids []map[string]git.OID) [][]*fileGroup {
+	type key struct {
+		path string
+		oid  git.OID
+	}
+	groups := map[key]*fileGroup{}
+	newGroups := make([][]*fileGroup, len(zfs))
+	for i, zf := range zfs {
+		for j := range zf.Files {
+			file := &zf.Files[j]
+			oid, ok := oids[i][file.Name]
+			if ok {
+				if group := groups[key{file.Name, oid}]; group != nil {
+					group.archives = append(group.archives, i)
+					continue
+				}
+			}
+
+			group := &fileGroup{file: file, archives: []int{i}}
+			if ok {
+				groups[key{file.Name, oid}] = group
+			}
+			newGroups[i] = append(newGroups[i], group)
+		}
+	}
+	return newGroups
+}
@@ -0,0 +1,38 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/store"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestGroupFiles(t *testing.T) {
+	zfs := []*store.ZipFile{
+		{Files: []store.SrcFile{{Name: "a"}, {Name: "b"}, {Name: "c"}}},
+		{Files: []store.SrcFile{{Name: "a"}, {Name: "b"}, {Name: "c"}}},
+	}
+	oids := []map[string]git.OID{
+		{"a": {1}, "b": {2}},
+		{"a": {1}, "b": {3}}, // c has no object ID, so it is never grouped
+	}
+
+	var got []string
+	for i, groups := range groupFiles(zfs, oids) {
+		for _, g := range groups {
+			got = append(got, fmt.Sprintf("%d:%s %v", i, g.file.Name, g.archives))
+		}
+	}
+	want := []string{
+		"0:a [0 1]",
+		"0:b [0]",
+		"0:c [0]",
+		"1:b [1]",
+		"1:c [1]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
 	"github.com/sourcegraph/sourcegraph/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
@@ -56,11 +57,28 @@ type readerGrep struct {
 	// whether a file path matches (and should be searched).
 	matchPath pathmatch.PathMatcher
 
+	// matchLang reports whether a file is in the languages of the lang:
+	// filters, or is nil if there are none.
+	matchLang *langMatcher
+
+	// commit is the commit of the files searched, which identifies their
+	// contents for matchLang. It is empty if it is unknown.
+	commit api.CommitID
+
 	// literalSubstring is used to test if a file is worth considering for
 	// matches. literalSubstring is guaranteed to appear in any match found by
 	// re. It is the output of the longestLiteral function. It is only set if
 	// the regex has an empty LiteralPrefix.
 	literalSubstring []byte
+
+	// multiLiteral is used like literalSubstring if re is an alternation of
+	// literals (e.g. foo|bar|baz), which have no common substring: any
+	// match found by re is one of the literals. It is nil otherwise.
+	multiLiteral *multiLiteralMatcher
+
+	// contextLines is the number of lines of context returned around each
+	// line match.
+	contextLines int
 }
 
 // compile returns a readerGrep for matching p.
@@ -68,6 +86,7 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 	var (
 		re               *regexp.Regexp
 		literalSubstring []byte
+		multiLiteral     *multiLiteralMatcher
 	)
 	if p.Pattern != "" {
 		expr := p.Pattern
@@ -109,6 +128,9 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 			}
 			ast = ast.Simplify()
 			literalSubstring = []byte(longestLiteral(ast))
+			if len(literalSubstring) == 0 {
+				multiLiteral = compileMultiLiteral(ast)
+			}
 		}
 	}
 
@@ -116,7 +138,16 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 		RegExp:        p.PathPatternsAreRegExps,
 		CaseSensitive: p.PathPatternsAreCaseSensitive,
 	}
-	matchPath, err := pathmatch.CompilePathPatterns(p.IncludePatterns, p.ExcludePattern, pathOptions)
+	matchLang, err := compileLangMatcher(p, pathOptions)
+	if err != nil {
+		return nil, err
+	}
+	includePatterns := p.IncludePatterns
+	if matchLang != nil {
+		// The include patterns of the lang: filters are matched by matchLang.
+		includePatterns = withoutPatterns(includePatterns, p.LangIncludePatterns)
+	}
+	matchPath, err := pathmatch.CompilePathPatterns(includePatterns, p.ExcludePattern, pathOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +156,10 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 		re:               re,
 		ignoreCase:       !p.IsCaseSensitive,
 		matchPath:        matchPath,
+		matchLang:        matchLang,
 		literalSubstring: literalSubstring,
+		multiLiteral:     multiLiteral,
+		contextLines:     p.ContextLines,
 	}, nil
 }
 
@@ -136,7 +170,11 @@ func (rg *readerGrep) Copy() *readerGrep {
 		re:               rg.re,
 		ignoreCase:       rg.ignoreCase,
 		matchPath:        rg.matchPath,
+		matchLang:        rg.matchLang,
+		commit:           rg.commit,
 		literalSubstring: rg.literalSubstring,
+		multiLiteral:     rg.multiLiteral,
+		contextLines:     rg.contextLines,
 	}
 }
 
@@ -184,6 +222,12 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []proto
 	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
 		return nil, false, nil
 	}
+	// Similarly, when the pattern is an alternation of many literals the
+	// regex engine has to try each of them at every position, while
+	// multiLiteral finds all of them in a single pass.
+	if rg.multiLiteral != nil && !rg.multiLiteral.Match(fileMatchBuf) {
+		return nil, false, nil
+	}
 
 	locs := rg.re.FindAllIndex(fileMatchBuf, maxLineMatches+1)
 	lastStart := 0
@@ -224,6 +268,7 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []proto
 			break
 		}
 	}
+	addContext(fileBuf, matches, rg.contextLines)
 	return matches, limitHit, nil
 }
 
@@ -283,6 +328,32 @@ func appendMatches(matches []protocol.LineMatch, fileBuf []byte, matchLineBuf []
 	return matches
 }
 
+// addContext sets the lines of context of the line matches in the file
+// content.
+func addContext(fileBuf []byte, matches []protocol.LineMatch, contextLines int) {
+	if contextLines <= 0 || len(matches) == 0 {
+		return
+	}
+	lineNumbers := make([]int, len(matches))
+	for i, m := range matches {
+		lineNumbers[i] = m.LineNumber
+	}
+	before, after := protocol.LineContext(fileBuf, lineNumbers, contextLines)
+	for i := range matches {
+		matches[i].ContextBefore = before[i]
+		matches[i].ContextAfter = after[i]
+	}
+}
+
+// matchFile reports whether f should be searched, according to the
+// include/exclude path patterns and the lang: filters.
+func (rg *readerGrep) matchFile(zf *store.ZipFile, f *store.SrcFile) bool {
+	if !rg.matchPath.MatchPath(f.Name) {
+		return false
+	}
+	return rg.matchLang == nil || rg.matchLang.match(rg.commit, f.Name, zf.DataFor(f))
+}
+
 // FindZip is a convenience function to run Find on f.
 func (rg *readerGrep) FindZip(zf *store.ZipFile, f *store.SrcFile) (protocol.FileMatch, error) {
 	lm, limitHit, err := rg.Find(zf, f)
@@ -341,7 +412,7 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
 		for _, f := range files {
-			if rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) {
+			if rg.matchFile(zf, &f) && rg.matchString(f.Name) {
 				if len(matches) < fileMatchLimit {
 					matches = append(matches, protocol.FileMatch{Path: f.Name})
 				} else {
@@ -387,7 +458,7 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 				filesmu.Unlock()
 
 				// decide whether to process, record that decision
-				if !rg.matchPath.MatchPath(f.Name) {
+				if !rg.matchFile(zf, f) {
 					atomic.AddUint32(&filesSkipped, 1)
 					continue
 				}
@@ -412,6 +483,7 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 					}
 				}
 				if match {
+					fm.Encoding = zf.Encodings[f.Name]
 					matchesmu.Lock()
 					if len(matches) < fileMatchLimit {
 						matches = append(matches, fm)
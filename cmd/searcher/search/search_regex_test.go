@@ -4,12 +4,14 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"math/rand"
 	"os"
 	"reflect"
 	"regexp"
 	"regexp/syntax"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
 	"testing/iotest"
 	"testing/quick"
@@ -157,6 +159,17 @@ func BenchmarkSearchRegex_large_re_anchor(b *testing.B) {
 	})
 }
 
+func BenchmarkSearchRegex_large_multiliteral(b *testing.B) {
+	benchSearchRegex(b, &protocol.Request{
+		Repo:   "github.com/golang/go",
+		Commit: "0ebaca6ba27534add5930a95acffa9acff182e2b",
+		PatternInfo: protocol.PatternInfo{
+			Pattern:  multiLiteralPattern,
+			IsRegExp: true,
+		},
+	})
+}
+
 func BenchmarkSearchRegex_large_path(b *testing.B) {
 	do := func(b *testing.B, content, path bool) {
 		benchSearchRegex(b, &protocol.Request{
@@ -232,6 +245,26 @@ func BenchmarkSearchRegex_small_re_anchor(b *testing.B) {
 	})
 }
 
+func BenchmarkSearchRegex_small_multiliteral(b *testing.B) {
+	benchSearchRegex(b, &protocol.Request{
+		Repo:   "github.com/sourcegraph/go-langserver",
+		Commit: "4193810334683f87b8ed5d896aa4753f0dfcdf20",
+		PatternInfo: protocol.PatternInfo{
+			Pattern:  multiLiteralPattern,
+			IsRegExp: true,
+		},
+	})
+}
+
+// multiLiteralPattern is an alternation of literals, like the pattern of a
+// query with many OR operands.
+var multiLiteralPattern = strings.Join([]string{
+	"AddSuballocation", "DecodeRuneInString", "ErrUnexpectedEOF",
+	"FieldsFunc", "LastIndexByte", "MustCompile", "NewReplacer",
+	"ParseDuration", "QuoteToASCII", "ReadDirNames", "SetDeadline",
+	"TrimLeftFunc", "UnquoteChar", "ValidString", "WriteHeader",
+}, "|")
+
 func benchSearchRegex(b *testing.B, p *protocol.Request) {
 	if testing.Short() {
 		b.Skip("")
@@ -370,6 +403,173 @@ func TestLongestLiteral(t *testing.T) {
 	}
 }
 
+func TestLiteralSet(t *testing.T) {
+	cases := map[string][]string{
+		"foo":                       {"foo"},
+		"foo|bar":                   {"bar", "foo"},
+		"(foo|bar)":                 {"bar", "foo"},
+		"foo|fob|bar":               {"bar", "fob", "foo"},
+		"(?m:^foo|bar$)":            {"bar", "foo"},
+		`\bfoo\b|\bbar\b`:           {"bar", "foo"},
+		"a[bc]d|e":                  {"abd", "acd", "e"},
+		"(a|b)(c|d)":                {"ac", "ad", "bc", "bd"},
+		"foo(bar)?":                 {"foo", "foobar"},
+		"世界|hello":                  {"hello", "世界"},
+		"foo|bar.":                  nil,
+		"foo|bar+":                  nil,
+		"foo|bar*":                  nil,
+		"(?i)foo|bar":               nil,
+		"foo|[a-z]":                 nil,
+		`foo|\w`:                    nil,
+		"[a-z][a-z][a-z][a-z][a-z]": nil,
+	}
+	for expr, want := range cases {
+		re, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			t.Fatal(expr, err)
+		}
+		re = re.Simplify()
+		got, ok := literalSet(re)
+		if ok != (want != nil) {
+			t.Errorf("literalSet(%q) ok == %v", expr, ok)
+			continue
+		}
+		sort.Strings(got)
+		if ok && !reflect.DeepEqual(got, want) {
+			t.Errorf("literalSet(%q) == %q != %q", expr, got, want)
+		}
+	}
+}
+
+func TestMultiLiteralMatcher(t *testing.T) {
+	if m := newMultiLiteralMatcher([]string{"foo"}); m != nil {
+		t.Error("expected no matcher for a single literal")
+	}
+	if m := newMultiLiteralMatcher([]string{"foo", ""}); m != nil {
+		t.Error("expected no matcher with an empty literal")
+	}
+
+	// Compare against a naive implementation on a small alphabet, so that
+	// literals overlap and share prefixes and suffixes.
+	alphabet := []byte("abc\n")
+	randString := func(r *rand.Rand, maxLen int) string {
+		b := make([]byte, 1+r.Intn(maxLen))
+		for i := range b {
+			b[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		literals := make([]string, 2+r.Intn(5))
+		for j := range literals {
+			literals[j] = randString(r, 5)
+		}
+		m := newMultiLiteralMatcher(literals)
+		for j := 0; j < 10; j++ {
+			text := randString(r, 30)
+			want := false
+			for _, lit := range literals {
+				if strings.Contains(text, lit) {
+					want = true
+				}
+			}
+			if got := m.Match([]byte(text)); got != want {
+				t.Fatalf("Match(%q) with literals %q == %v != %v", text, literals, got, want)
+			}
+		}
+	}
+}
+
+func TestRegexSearch_multiLiteral(t *testing.T) {
+	files := map[string]string{
+		"a.go":       "package a\n\nfunc Foo() {}\n",
+		"b.go":       "package b\n\n// FOO and bar\nfunc Bar() {}\n",
+		"c.go":       "package c\n\nfunc Baz() { foobar() }\n",
+		"d.txt":      "nothing to see here\n",
+		"unicode.md": "こんにちは世界\nhello\n",
+	}
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, name := range []string{"a.go", "b.go", "c.go", "d.txt", "unicode.md"} {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf, err := store.MockZipFile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patterns := []protocol.PatternInfo{
+		{Pattern: "foo|bar", IsRegExp: true},
+		{Pattern: "foo|bar", IsRegExp: true, IsCaseSensitive: true},
+		{Pattern: "Foo|Bar|Baz", IsRegExp: true, IsCaseSensitive: true},
+		{Pattern: `(Foo\(|Baz\()`, IsRegExp: true},
+		{Pattern: "^package a$|^func Baz", IsRegExp: true},
+		{Pattern: "世界|hello", IsRegExp: true},
+		{Pattern: "foo|bar", IsRegExp: true, IsWordMatch: true},
+	}
+	for _, p := range patterns {
+		rg, err := compile(&p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rg.multiLiteral == nil {
+			t.Fatalf("%+v: expected a multi-literal matcher", p)
+		}
+		got, _, err := regexSearch(context.Background(), rg, zf, 0, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rg.multiLiteral = nil
+		want, _, err := regexSearch(context.Background(), rg, zf, 0, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(want) == 0 {
+			t.Fatalf("%+v: expected matches", p)
+		}
+		sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+		sort.Slice(want, func(i, j int) bool { return want[i].Path < want[j].Path })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v: got %+v, want %+v", p, got, want)
+		}
+	}
+}
+
+func BenchmarkMultiLiteralMatcher(b *testing.B) {
+	literals := strings.Split(multiLiteralPattern, "|")
+	text := bytes.Repeat([]byte("func (s *Service) search(ctx context.Context, p *protocol.Request) error {\n"), 1<<10)
+
+	b.Run("regexp", func(b *testing.B) {
+		re := regexp.MustCompile(multiLiteralPattern)
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			if re.Match(text) {
+				b.Fatal("unexpected match")
+			}
+		}
+	})
+	b.Run("multiLiteral", func(b *testing.B) {
+		m := newMultiLiteralMatcher(literals)
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			if m.Match(text) {
+				b.Fatal("unexpected match")
+			}
+		}
+	})
+}
+
 func TestReadAll(t *testing.T) {
 	input := []byte("Hello World")
 
@@ -595,3 +795,32 @@ func TestRegexSearch(t *testing.T) {
 		})
 	}
 }
+
+func TestLangMatcher_cache(t *testing.T) {
+	m, err := compileLangMatcher(&protocol.PatternInfo{
+		Languages:           []string{"shell"},
+		LangIncludePatterns: []string{`\.sh$`},
+	}, pathmatch.CompileOptions{RegExp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shell, python := []byte("#!/bin/sh\necho hi\n"), []byte("#!/usr/bin/env python\nprint('hi')\n")
+	if !m.match("c1", "bin/run", shell) {
+		t.Error("shell script at c1 didn't match")
+	}
+	// The commit and the name of the file identify its contents, so the
+	// language detected before is used.
+	if !m.match("c1", "bin/run", python) {
+		t.Error("cached shell script at c1 didn't match")
+	}
+	if m.match("c2", "bin/run", python) {
+		t.Error("python script at c2 matched")
+	}
+	if m.match("c1", "other/run", python) {
+		t.Error("python script in another directory at c1 matched")
+	}
+	if m.match("", "bin/run", python) {
+		t.Error("python script without commit matched")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/comby"
+	"github.com/sourcegraph/sourcegraph/internal/store"
 )
 
 // The Sourcegraph frontend and interface only allow LineMatches (matches on a
@@ -82,6 +83,23 @@ func ToFileMatch(combyMatches []comby.FileMatch) (matches []protocol.FileMatch)
 	return matches
 }
 
+// addStructuralContext sets the lines of context of the line matches of
+// structural search, which doesn't return the content of the matched files.
+func addStructuralContext(zf *store.ZipFile, matches []protocol.FileMatch, contextLines int) {
+	if contextLines <= 0 || len(matches) == 0 {
+		return
+	}
+	files := make(map[string]*store.SrcFile, len(zf.Files))
+	for i := range zf.Files {
+		files[zf.Files[i].Name] = &zf.Files[i]
+	}
+	for _, m := range matches {
+		if f, ok := files[m.Path]; ok {
+			addContext(zf.DataFor(f), m.LineMatches, contextLines)
+		}
+	}
+}
+
 // lookupMatcher looks up a key for specifying -matcher in comby. Comby accepts
 // a representative file extension to set a language, so this lookup does not
 // need to consider all possible file extensions for a language. There is a generic
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha1"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,6 +26,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/store"
 	"github.com/sourcegraph/sourcegraph/internal/testutil"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/util"
 )
 
 func TestSearch(t *testing.T) {
@@ -180,6 +184,24 @@ main.go:7:}
 `},
 
 		{protocol.PatternInfo{Pattern: "^$", IsRegExp: true}, ``},
+
+		{protocol.PatternInfo{Pattern: "world", ContextLines: 1}, `
+README.md:1:# Hello World
+README.md-2-
+README.md:3:Hello world example in go
+main.go-5-func main() {
+main.go:6:	fmt.Println("Hello world")
+main.go-7-}
+`},
+		{protocol.PatternInfo{Pattern: "import", ContextLines: 10}, `
+main.go-1-package main
+main.go-2-
+main.go:3:import "fmt"
+main.go-4-
+main.go-5-func main() {
+main.go-6-	fmt.Println("Hello world")
+main.go-7-}
+`},
 	}
 
 	store, cleanup, err := newStore(files)
@@ -344,6 +366,207 @@ func TestSearch_badrequest(t *testing.T) {
 	}
 }
 
+func TestSearch_encodings(t *testing.T) {
+	// "héllo wörld\n" in UTF-16LE with a byte order mark and in Latin-1.
+	utf16 := []byte{0xFF, 0xFE}
+	for _, r := range "héllo wörld\n" {
+		utf16 = append(utf16, byte(r), 0)
+	}
+	store, cleanup, err := newStore(map[string]string{
+		"utf16.txt":  string(utf16),
+		"latin1.txt": "h\xe9llo w\xf6rld\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	req := protocol.Request{
+		Repo:         "foo",
+		URL:          "u",
+		Commit:       "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+		PatternInfo:  protocol.PatternInfo{Pattern: "wörld", PatternMatchesContent: true},
+		FetchTimeout: "2000ms",
+	}
+	m, err := doSearch(ts.URL, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Sort(sortByPath(m))
+
+	var got []string
+	for _, fm := range m {
+		for _, lm := range fm.LineMatches {
+			got = append(got, fmt.Sprintf("%s (%s): %q %v", fm.Path, fm.Encoding, lm.Preview, lm.OffsetAndLengths))
+		}
+	}
+	want := []string{
+		`latin1.txt (windows-1252): "héllo wörld" [[6 5]]`,
+		`utf16.txt (UTF-16LE): "héllo wörld" [[6 5]]`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSearch_commits(t *testing.T) {
+	const (
+		c1 = api.CommitID("1111111111111111111111111111111111111111")
+		c2 = api.CommitID("2222222222222222222222222222222222222222")
+		c3 = api.CommitID("3333333333333333333333333333333333333333")
+	)
+	commits := map[api.CommitID]map[string]string{
+		c1: {"a.go": "hello world\n", "b.go": "hello\n"},
+		c2: {"a.go": "hello world\n", "b.go": "hello there\n", "c.go": "no match\n"},
+		c3: {"a.go": "hello world\n", "c.go": "hello again\n"},
+	}
+	store, cleanup, err := newCommitsStore(commits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	// Files are deduplicated by the blob object IDs listed by gitserver.
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		var fis []os.FileInfo
+		for path, body := range commits[commit] {
+			fis = append(fis, &util.FileInfo{Name_: path, Size_: int64(len(body)), Sys_: blobInfo(sha1.Sum([]byte(body)))})
+		}
+		return fis, nil
+	}
+	defer git.ResetMocks()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	req := protocol.Request{
+		Repo:         "foo",
+		URL:          "u",
+		Commit:       c1,
+		Commits:      []api.CommitID{c2, c3},
+		PatternInfo:  protocol.PatternInfo{Pattern: "hello", PatternMatchesContent: true},
+		FetchTimeout: "2000ms",
+	}
+	m, err := doSearch(ts.URL, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each distinct file is returned once, with all the commits containing it.
+	var got []string
+	for _, fm := range m {
+		got = append(got, fmt.Sprintf("%s:%d %v", fm.Path, fm.MatchCount, fm.Commits))
+	}
+	sort.Strings(got)
+	want := []string{
+		fmt.Sprintf("a.go:1 %v", []api.CommitID{c1, c2, c3}),
+		fmt.Sprintf("b.go:1 %v", []api.CommitID{c1}),
+		fmt.Sprintf("b.go:1 %v", []api.CommitID{c2}),
+		fmt.Sprintf("c.go:1 %v", []api.CommitID{c3}),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Structural search of several commits is not supported.
+	req.IsStructuralPat = true
+	if _, err := doSearch(ts.URL, &req); err == nil || !strings.HasPrefix(err.Error(), "non-200 response: code=400 ") {
+		t.Errorf("expected structural search of several commits to fail with HTTP 400, got %v", err)
+	}
+}
+
+func TestSearch_lang(t *testing.T) {
+	store, cleanup, err := newStore(map[string]string{
+		"main.py":               "print('hello')\n",
+		"main.go":               "package main // hello\n",
+		"bin/hello":             "#!/usr/bin/env python3\nprint('hello')\n",
+		"bin/hello-sh":          "#!/bin/sh\necho hello\n",
+		"notes":                 "hello\n",
+		"docker/Dockerfile.dev": "RUN echo hello\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	cases := []struct {
+		name string
+		arg  protocol.PatternInfo
+		want []string
+	}{
+		{
+			name: "python",
+			arg: protocol.PatternInfo{
+				Languages:           []string{"python"},
+				IncludePatterns:     []string{`\.py$`},
+				LangIncludePatterns: []string{`\.py$`},
+			},
+			want: []string{"bin/hello", "main.py"},
+		},
+		{
+			name: "dockerfile",
+			arg: protocol.PatternInfo{
+				Languages:           []string{"dockerfile"},
+				IncludePatterns:     []string{`\.dockerfile$`},
+				LangIncludePatterns: []string{`\.dockerfile$`},
+			},
+			want: []string{"docker/Dockerfile.dev"},
+		},
+		{
+			name: "python and file filter",
+			arg: protocol.PatternInfo{
+				Languages:           []string{"python"},
+				IncludePatterns:     []string{`^bin/`, `\.py$`},
+				LangIncludePatterns: []string{`\.py$`},
+			},
+			want: []string{"bin/hello"},
+		},
+		{
+			// Old frontends only send the include patterns.
+			name: "python without lang include patterns",
+			arg: protocol.PatternInfo{
+				Languages:       []string{"python"},
+				IncludePatterns: []string{`\.py$`},
+			},
+			want: []string{"main.py"},
+		},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			test.arg.Pattern = "hello"
+			test.arg.PathPatternsAreRegExps = true
+			test.arg.PatternMatchesContent = true
+			req := protocol.Request{
+				Repo:         "foo",
+				URL:          "u",
+				Commit:       "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+				PatternInfo:  test.arg,
+				FetchTimeout: "2000ms",
+			}
+			m, err := doSearch(ts.URL, &req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, fm := range m {
+				got = append(got, fm.Path)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// blobInfo is the git.ObjectInfo of a blob.
+type blobInfo git.OID
+
+func (b blobInfo) OID() git.OID { return git.OID(b) }
+
 func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	form := url.Values{
 		"Repo":            []string{string(p.Repo)},
@@ -378,6 +601,18 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	if p.PatternMatchesPath {
 		form.Set("PatternMatchesPath", "true")
 	}
+	if p.ContextLines > 0 {
+		form.Set("ContextLines", strconv.Itoa(p.ContextLines))
+	}
+	for _, commit := range p.Commits {
+		form.Add("Commits", string(commit))
+	}
+	for _, lang := range p.Languages {
+		form.Add("Languages", lang)
+	}
+	for _, pattern := range p.LangIncludePatterns {
+		form.Add("LangIncludePatterns", pattern)
+	}
 	resp, err := http.PostForm(u, form)
 	if err != nil {
 		return nil, err
@@ -400,6 +635,49 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 }
 
 func newStore(files map[string]string) (*store.Store, func(), error) {
+	tarBytes, err := tarArchive(files)
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := ioutil.TempDir("", "search_test")
+	if err != nil {
+		return nil, nil, err
+	}
+	return &store.Store{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(tarBytes)), nil
+		},
+		Path: d,
+	}, func() { os.RemoveAll(d) }, nil
+}
+
+// newCommitsStore is like newStore, with different files at each commit.
+func newCommitsStore(commits map[api.CommitID]map[string]string) (*store.Store, func(), error) {
+	tars := map[api.CommitID][]byte{}
+	for commit, files := range commits {
+		tarBytes, err := tarArchive(files)
+		if err != nil {
+			return nil, nil, err
+		}
+		tars[commit] = tarBytes
+	}
+	d, err := ioutil.TempDir("", "search_test")
+	if err != nil {
+		return nil, nil, err
+	}
+	return &store.Store{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
+			tarBytes, ok := tars[commit]
+			if !ok {
+				return nil, fmt.Errorf("unknown commit %s", commit)
+			}
+			return ioutil.NopCloser(bytes.NewReader(tarBytes)), nil
+		},
+		Path: d,
+	}, func() { os.RemoveAll(d) }, nil
+}
+
+func tarArchive(files map[string]string) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	w := tar.NewWriter(buf)
 	for name, body := range files {
@@ -409,33 +687,23 @@ func newStore(files map[string]string) (*store.Store, func(), error) {
 			Size: int64(len(body)),
 		}
 		if err := w.WriteHeader(hdr); err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		if _, err := w.Write([]byte(body)); err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 	}
 	// git-archive usually includes a pax header we should ignore.
 	// use a body which matches a test case. Ensures we don't return this
 	// false entry as a result.
 	if err := addpaxheader(w, "Hello world\n"); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	err := w.Close()
-	if err != nil {
-		return nil, nil, err
-	}
-	d, err := ioutil.TempDir("", "search_test")
-	if err != nil {
-		return nil, nil, err
+	if err := w.Close(); err != nil {
+		return nil, err
 	}
-	return &store.Store{
-		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
-			return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
-		},
-		Path: d,
-	}, func() { os.RemoveAll(d) }, nil
+	return buf.Bytes(), nil
 }
 
 func toString(m []protocol.FileMatch) string {
@@ -446,18 +714,30 @@ func toString(m []protocol.FileMatch) string {
 			buf.WriteByte('\n')
 		}
 		for _, l := range f.LineMatches {
-			buf.WriteString(f.Path)
-			buf.WriteByte(':')
-			buf.WriteString(strconv.Itoa(l.LineNumber + 1))
-			buf.WriteByte(':')
-			buf.WriteString(l.Preview)
-			buf.WriteByte('\n')
+			// Like grep, context lines are separated from the line
+			// number with '-' instead of ':'.
+			for i, line := range l.ContextBefore {
+				writeLine(buf, f.Path, l.LineNumber-len(l.ContextBefore)+i, '-', line)
+			}
+			writeLine(buf, f.Path, l.LineNumber, ':', l.Preview)
+			for i, line := range l.ContextAfter {
+				writeLine(buf, f.Path, l.LineNumber+1+i, '-', line)
+			}
 		}
 
 	}
 	return buf.String()
 }
 
+func writeLine(buf *bytes.Buffer, path string, lineNumber int, sep byte, line string) {
+	buf.WriteString(path)
+	buf.WriteByte(sep)
+	buf.WriteString(strconv.Itoa(lineNumber + 1))
+	buf.WriteByte(sep)
+	buf.WriteString(line)
+	buf.WriteByte('\n')
+}
+
 func sanityCheckSorted(m []protocol.FileMatch) error {
 	if !sort.IsSorted(sortByPath(m)) {
 		return errors.New("unsorted file matches, please sortByPath")
@@ -59,7 +59,10 @@ func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName,
 		span.Finish()
 	}
 
-	r, err := s.FetchTar(ctx, gitserver.Repo{Name: repo}, commitID)
+	r, err := s.FetchTar(ctx, gitserver.Repo{Name: repo}, commitID, gitserver.ArchiveFilter{
+		Exclude:     []string{"**/*.json"},
+		MaxFileSize: maxFileSize,
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -190,11 +190,17 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 	}
 	conditions = append(conditions, negateAll(makeCondition("path", args.ExcludePattern))...)
 
+	if args.Offset < 0 {
+		args.Offset = 0
+	}
+
+	// Order by rowid (the insertion order) so that pages requested with
+	// Offset are consistent.
 	var sqlQuery *sqlf.Query
 	if len(conditions) == 0 {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols LIMIT %s", args.First)
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols ORDER BY rowid LIMIT %s OFFSET %s", args.First, args.Offset)
 	} else {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s ORDER BY rowid LIMIT %s OFFSET %s", sqlf.Join(conditions, "AND"), args.First, args.Offset)
 	}
 
 	var symbolsInDB []symbolInDB
@@ -19,8 +19,9 @@ import (
 type Service struct {
 	// FetchTar returns an io.ReadCloser to a tar archive of a repository at the specified Git
 	// remote URL and commit ID. If the error implements "BadRequest() bool", it will be used to
-	// determine if the error is a bad request (eg invalid repo).
-	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
+	// determine if the error is a bad request (eg invalid repo). The archive may include files
+	// which don't pass the filter, so callers must still check the files they read.
+	FetchTar func(context.Context, gitserver.Repo, api.CommitID, gitserver.ArchiveFilter) (io.ReadCloser, error)
 
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
@@ -67,7 +67,7 @@ func TestService(t *testing.T) {
 
 	files := map[string]string{"a.js": "var x = 1"}
 	service := Service{
-		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
 			return createTar(files)
 		},
 		NewParser: func() (ctags.Parser, error) {
@@ -93,6 +93,10 @@ func TestService(t *testing.T) {
 			args: search.SymbolsParameters{First: 10},
 			want: protocol.SearchResult{Symbols: []protocol.Symbol{x, y}},
 		},
+		"offset": {
+			args: search.SymbolsParameters{First: 10, Offset: 1},
+			want: protocol.SearchResult{Symbols: []protocol.Symbol{y}},
+		},
 		"onematch": {
 			args: search.SymbolsParameters{Query: "x", First: 10},
 			want: protocol.SearchResult{Symbols: []protocol.Symbol{x}},
@@ -371,4 +371,6 @@ type mockExtensions struct {
 	GetByExtensionID func(extensionID string) (*dbExtension, error)
 	Update           func(id int32, name *string) error
 	Delete           func(id int32) error
+
+	GetPublisher func(name string) (*dbPublisher, error)
 }
@@ -0,0 +1,355 @@
+package registry
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	frontendregistry "github.com/sourcegraph/sourcegraph/cmd/frontend/registry"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/licensing"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/registry"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+func init() {
+	frontendregistry.HandleRegistryExport = handleRegistryExport
+	frontendregistry.HandleRegistryImport = handleRegistryImport
+}
+
+// registryArchive is an export of the extensions in a local extension registry, with all of their
+// releases. It is used to mirror extensions to instances that can't reach a remote registry (such
+// as air-gapped instances). It is stored as gzipped JSON.
+type registryArchive struct {
+	Extensions []*archivedExtension `json:"extensions"`
+}
+
+type archivedExtension struct {
+	Publisher string             `json:"publisher"`
+	Name      string             `json:"name"`
+	Releases  []*archivedRelease `json:"releases"` // oldest first
+}
+
+type archivedRelease struct {
+	ReleaseVersion *string   `json:"releaseVersion,omitempty"`
+	ReleaseTag     string    `json:"releaseTag"`
+	Manifest       string    `json:"manifest"`
+	Bundle         *string   `json:"bundle,omitempty"`
+	SourceMap      *string   `json:"sourceMap,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// registryImportResult describes the changes made to the local registry by an import.
+type registryImportResult struct {
+	CreatedExtensions int `json:"createdExtensions"`
+	CreatedReleases   int `json:"createdReleases"`
+
+	// SkippedExtensions are the IDs of the extensions that were not imported because their
+	// publisher doesn't exist in the local registry.
+	SkippedExtensions []string `json:"skippedExtensions,omitempty"`
+}
+
+// registryImportOptions configures an import into the local registry.
+type registryImportOptions struct {
+	// CreatorUserID is the user that the imported releases are recorded as created by.
+	CreatorUserID int32
+
+	// CreatePublishers is whether to create an organization for each publisher that has no user
+	// or organization with its name. If false, the extensions of such publishers are skipped.
+	CreatePublishers bool
+}
+
+// handleRegistryExport serves an archive of all extensions in the local registry.
+func handleRegistryExport(w http.ResponseWriter, r *http.Request) error {
+	if err := checkCanMirrorRegistry(r.Context()); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="extensions.json.gz"`)
+	return exportRegistry(r.Context(), w)
+}
+
+// handleRegistryImport imports the extensions in the archive in the request body into the local
+// registry. If the "remote" query parameter is present, the latest releases of the extensions on
+// the configured remote registry are imported instead. Its value must be empty or the URL of the
+// configured remote registry, so that the server can't be made to send requests to other URLs.
+//
+// Publishers that don't exist in the local registry are only created if the "createPublishers"
+// query parameter is "true".
+func handleRegistryImport(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	if err := checkCanMirrorRegistry(ctx); err != nil {
+		return err
+	}
+
+	opt := registryImportOptions{CreatorUserID: actor.FromContext(ctx).UID}
+	opt.CreatePublishers, _ = strconv.ParseBool(r.URL.Query().Get("createPublishers"))
+
+	var (
+		result *registryImportResult
+		err    error
+	)
+	if values, ok := r.URL.Query()["remote"]; ok {
+		remoteURL := conf.Extensions().RemoteRegistryURL
+		if remoteURL == "" {
+			http.Error(w, "no remote registry is configured", http.StatusBadRequest)
+			return nil
+		}
+		if values[0] != "" && values[0] != remoteURL {
+			http.Error(w, "the remote registry must be the configured extensions.remoteRegistry", http.StatusBadRequest)
+			return nil
+		}
+		remote, err2 := url.Parse(remoteURL)
+		if err2 != nil {
+			http.Error(w, fmt.Sprintf("invalid remote registry URL: %s", err2), http.StatusBadRequest)
+			return nil
+		}
+		result, err = syncRemoteRegistry(ctx, remote, opt)
+	} else {
+		result, err = importRegistry(ctx, r.Body, opt)
+	}
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(result)
+}
+
+func checkCanMirrorRegistry(ctx context.Context) error {
+	if conf.Extensions() == nil {
+		return graphqlbackend.ErrExtensionsDisabled
+	}
+	if err := licensing.CheckFeature(licensing.FeatureExtensionRegistry); err != nil {
+		return err
+	}
+	// 🚨 SECURITY: Only site admins may export and import the registry's extensions. Imported
+	// extensions are published on behalf of publishers that the site admin may not belong to.
+	return backend.CheckCurrentUserIsSiteAdmin(ctx)
+}
+
+// exportRegistry writes an archive of all extensions in the local registry, with all of their
+// releases, to w.
+func exportRegistry(ctx context.Context, w io.Writer) error {
+	xs, err := dbExtensions{}.List(ctx, dbExtensionsListOptions{})
+	if err != nil {
+		return err
+	}
+
+	archive := registryArchive{Extensions: make([]*archivedExtension, 0, len(xs))}
+	for _, x := range xs {
+		releases, err := dbReleases{}.ListByExtension(ctx, x.ID, true)
+		if err != nil {
+			return err
+		}
+		ax := &archivedExtension{
+			Publisher: x.Publisher.NonCanonicalName,
+			Name:      x.Name,
+			Releases:  make([]*archivedRelease, len(releases)),
+		}
+		for i, r := range releases {
+			ax.Releases[i] = &archivedRelease{
+				ReleaseVersion: r.ReleaseVersion,
+				ReleaseTag:     r.ReleaseTag,
+				Manifest:       r.Manifest,
+				Bundle:         r.Bundle,
+				SourceMap:      r.SourceMap,
+				CreatedAt:      r.CreatedAt,
+			}
+		}
+		archive.Extensions = append(archive.Extensions, ax)
+	}
+
+	gzw := gzip.NewWriter(w)
+	if err := json.NewEncoder(gzw).Encode(archive); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// importRegistry imports the extensions in the archive read from r into the local registry.
+// Extensions and releases that already exist in the local registry are skipped, so importing the
+// same archive again is a no-op.
+func importRegistry(ctx context.Context, r io.Reader, opt registryImportOptions) (*registryImportResult, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading extension registry archive")
+	}
+	defer gzr.Close()
+	var archive registryArchive
+	if err := json.NewDecoder(gzr).Decode(&archive); err != nil {
+		return nil, errors.Wrap(err, "reading extension registry archive")
+	}
+
+	var result registryImportResult
+	for _, x := range archive.Extensions {
+		if err := importExtension(ctx, x, opt, &result); err != nil {
+			return &result, errors.Wrapf(err, "importing extension %s/%s", x.Publisher, x.Name)
+		}
+	}
+	return &result, nil
+}
+
+// syncRemoteRegistry imports the latest release of each extension on the remote registry (that is
+// allowed by the site configuration) into the local registry. The remote registry API only exposes
+// the latest release, so the local release history grows with each sync.
+func syncRemoteRegistry(ctx context.Context, remote *url.URL, opt registryImportOptions) (*registryImportResult, error) {
+	xs, err := registry.List(ctx, remote, "")
+	if err != nil {
+		return nil, err
+	}
+	xs = frontendregistry.FilterRemoteExtensions(xs)
+
+	var result registryImportResult
+	for _, x := range xs {
+		if x.Manifest == nil {
+			// The extension has no releases.
+			continue
+		}
+		release, err := fetchRemoteRelease(ctx, x)
+		if err != nil {
+			return &result, errors.Wrapf(err, "fetching extension %s", x.ExtensionID)
+		}
+		ax := &archivedExtension{
+			Publisher: x.Publisher.Name,
+			Name:      x.Name,
+			Releases:  []*archivedRelease{release},
+		}
+		if err := importExtension(ctx, ax, opt, &result); err != nil {
+			return &result, errors.Wrapf(err, "importing extension %s", x.ExtensionID)
+		}
+	}
+	return &result, nil
+}
+
+// fetchRemoteRelease fetches the bundle of the latest release of an extension on a remote
+// registry. The bundle URL is removed from the release's manifest, so that the local registry
+// serves its own copy of the bundle.
+func fetchRemoteRelease(ctx context.Context, x *registry.Extension) (*archivedRelease, error) {
+	var manifest map[string]interface{}
+	if err := json.Unmarshal([]byte(*x.Manifest), &manifest); err != nil {
+		return nil, errors.Wrap(err, "parsing extension manifest")
+	}
+	bundleURL, _ := manifest["url"].(string)
+	if bundleURL == "" {
+		return nil, errors.New("extension manifest has no bundle URL")
+	}
+	delete(manifest, "url")
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ctxhttp.Get(ctx, registry.HTTPClient, bundleURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching extension bundle %s: HTTP error %d", bundleURL, resp.StatusCode)
+	}
+	bundle, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &archivedRelease{
+		ReleaseTag: "release",
+		Manifest:   string(manifestJSON),
+		Bundle:     strptr(string(bundle)),
+		CreatedAt:  x.PublishedAt,
+	}, nil
+}
+
+// importExtension creates the extension (and, if needed and allowed by opt, its publisher) in the
+// local registry and creates its releases that don't already exist.
+func importExtension(ctx context.Context, x *archivedExtension, opt registryImportOptions, result *registryImportResult) error {
+	var (
+		registryExtensionID int32
+		existing            []*dbRelease
+	)
+	local, err := dbExtensions{}.GetByExtensionID(ctx, x.Publisher+"/"+x.Name)
+	if errcode.IsNotFound(err) {
+		publisher, err := getOrCreateImportedPublisher(ctx, x.Publisher, opt.CreatePublishers)
+		if errcode.IsNotFound(err) {
+			result.SkippedExtensions = append(result.SkippedExtensions, x.Publisher+"/"+x.Name)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		registryExtensionID, err = dbExtensions{}.Create(ctx, publisher.UserID, publisher.OrgID, x.Name)
+		if err != nil {
+			return err
+		}
+		result.CreatedExtensions++
+	} else if err != nil {
+		return err
+	} else {
+		registryExtensionID = local.ID
+		existing, err = dbReleases{}.ListByExtension(ctx, registryExtensionID, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, r := range x.Releases {
+		if hasRelease(existing, r) {
+			continue
+		}
+		if _, err := (dbReleases{}).Create(ctx, &dbRelease{
+			RegistryExtensionID: registryExtensionID,
+			CreatorUserID:       opt.CreatorUserID,
+			ReleaseVersion:      r.ReleaseVersion,
+			ReleaseTag:          r.ReleaseTag,
+			Manifest:            r.Manifest,
+			Bundle:              r.Bundle,
+			SourceMap:           r.SourceMap,
+			CreatedAt:           r.CreatedAt,
+		}); err != nil {
+			return err
+		}
+		result.CreatedReleases++
+	}
+	return nil
+}
+
+// hasRelease reports whether the archived release r is among the existing releases. Imported
+// releases keep their original creation date, which identifies them along with the release tag.
+func hasRelease(existing []*dbRelease, r *archivedRelease) bool {
+	for _, e := range existing {
+		if r.ReleaseVersion != nil && e.ReleaseVersion != nil && *r.ReleaseVersion == *e.ReleaseVersion {
+			return true
+		}
+		if e.ReleaseTag == r.ReleaseTag && e.CreatedAt.Equal(r.CreatedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// getOrCreateImportedPublisher returns the local user or organization with the given name. If there
+// is none and create is true, an organization with that name is created, so that imported
+// extensions keep their extension IDs. Otherwise a not found error is returned.
+func getOrCreateImportedPublisher(ctx context.Context, name string, create bool) (*dbPublisher, error) {
+	publisher, err := dbExtensions{}.GetPublisher(ctx, name)
+	if err == nil || !errcode.IsNotFound(err) || !create {
+		return publisher, err
+	}
+	org, err := db.Orgs.Create(ctx, name, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating organization %q for extension publisher", name)
+	}
+	return &dbPublisher{OrgID: org.ID, NonCanonicalName: org.Name}, nil
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/licensing"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/redispool"
+)
+
+// defaultRemoteRegistrySyncInterval is the interval between syncs of the remote registry if
+// extensions.remoteRegistrySync.intervalMinutes is not set.
+const defaultRemoteRegistrySyncInterval = 24 * time.Hour
+
+// remoteRegistrySyncKey is the Redis key that is set while an interval between syncs of the remote
+// registry hasn't elapsed yet. Setting it is how a frontend replica claims the next sync, so only
+// one of the replicas syncs per interval.
+const remoteRegistrySyncKey = "registry:remote-sync"
+
+// StartRemoteRegistrySync periodically imports the latest releases of the extensions on the remote
+// registry into the local registry, if extensions.remoteRegistrySync is set in the site
+// configuration. It blocks forever, so it should be called in a goroutine.
+func StartRemoteRegistrySync() {
+	ctx := context.Background()
+	for range time.NewTicker(time.Minute).C {
+		if err := maybeSyncRemoteRegistry(ctx); err != nil {
+			log15.Error("Syncing the remote extension registry failed.", "error", err)
+		}
+	}
+}
+
+// maybeSyncRemoteRegistry syncs the remote registry if it is enabled and no frontend replica synced
+// it within the configured interval.
+func maybeSyncRemoteRegistry(ctx context.Context) error {
+	x := conf.Get().Extensions
+	if x == nil || x.RemoteRegistrySync == nil || conf.Extensions() == nil || conf.Extensions().RemoteRegistryURL == "" {
+		return nil
+	}
+	if licensing.CheckFeature(licensing.FeatureExtensionRegistry) != nil {
+		return nil
+	}
+	interval := defaultRemoteRegistrySyncInterval
+	if x.RemoteRegistrySync.IntervalMinutes > 0 {
+		interval = time.Duration(x.RemoteRegistrySync.IntervalMinutes) * time.Minute
+	}
+
+	if ok, err := claimRemoteRegistrySync(interval); err != nil || !ok {
+		return err
+	}
+
+	remote, err := url.Parse(conf.Extensions().RemoteRegistryURL)
+	if err != nil {
+		return err
+	}
+	creatorUserID, err := firstSiteAdminID(ctx)
+	if err != nil {
+		return err
+	}
+	result, err := syncRemoteRegistry(ctx, remote, registryImportOptions{
+		CreatorUserID:    creatorUserID,
+		CreatePublishers: x.RemoteRegistrySync.CreatePublishers,
+	})
+	if err != nil {
+		return err
+	}
+	log15.Info("Synced the remote extension registry.", "createdExtensions", result.CreatedExtensions, "createdReleases", result.CreatedReleases, "skippedExtensions", result.SkippedExtensions)
+	return nil
+}
+
+// claimRemoteRegistrySync reports whether this frontend replica may sync the remote registry now.
+// The first replica to call it in each interval claims the sync.
+func claimRemoteRegistrySync(interval time.Duration) (bool, error) {
+	c := redispool.Store.Get()
+	defer c.Close()
+
+	_, err := redis.String(c.Do("SET", remoteRegistrySyncKey, time.Now().UTC().Format(time.RFC3339), "NX", "EX", int(interval/time.Second)))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// firstSiteAdminID returns the ID of the site admin that releases imported by the background sync
+// are recorded as created by.
+func firstSiteAdminID(ctx context.Context) (int32, error) {
+	var id int32
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT id FROM users WHERE site_admin AND deleted_at IS NULL ORDER BY id LIMIT 1").Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("there is no site admin to record imported extension releases as published by")
+	}
+	return id, err
+}
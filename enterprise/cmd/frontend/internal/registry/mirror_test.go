@@ -0,0 +1,165 @@
+package registry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/registry"
+)
+
+func TestSyncRemoteRegistry(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	publishedAt := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	var remote *httptest.Server
+	remote = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/registry/extensions":
+			w.Header().Set(registry.MediaTypeHeaderName, registry.MediaType)
+			_ = json.NewEncoder(w).Encode([]*registry.Extension{
+				{
+					ExtensionID: "sourcegraph/codecov",
+					Publisher:   registry.Publisher{Name: "sourcegraph"},
+					Name:        "codecov",
+					Manifest:    strptr(`{"description": "d", "url": "` + remote.URL + `/bundles/codecov.js"}`),
+					PublishedAt: publishedAt,
+				},
+				{
+					ExtensionID: "alice/unreleased",
+					Publisher:   registry.Publisher{Name: "alice"},
+					Name:        "unreleased",
+				},
+			})
+		case "/bundles/codecov.js":
+			_, _ = w.Write([]byte("bundle"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer remote.Close()
+
+	mocks.extensions.GetByExtensionID = func(extensionID string) (*dbExtension, error) {
+		return nil, extensionNotFoundError{[]interface{}{extensionID}}
+	}
+	mocks.extensions.GetPublisher = func(name string) (*dbPublisher, error) {
+		if name != "sourcegraph" {
+			t.Errorf("got publisher %q, want %q", name, "sourcegraph")
+		}
+		return &dbPublisher{OrgID: 1, NonCanonicalName: name}, nil
+	}
+	mocks.extensions.Create = func(publisherUserID, publisherOrgID int32, name string) (int32, error) {
+		if publisherOrgID != 1 || name != "codecov" {
+			t.Errorf("got extension %d/%q, want 1/%q", publisherOrgID, name, "codecov")
+		}
+		return 10, nil
+	}
+	var created []*dbRelease
+	mocks.releases.Create = func(release *dbRelease) (int64, error) {
+		created = append(created, release)
+		return 1, nil
+	}
+
+	remoteURL, _ := url.Parse(remote.URL + "/registry")
+	result, err := syncRemoteRegistry(context.Background(), remoteURL, registryImportOptions{CreatorUserID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (registryImportResult{CreatedExtensions: 1, CreatedReleases: 1}); !reflect.DeepEqual(*result, want) {
+		t.Errorf("got result %+v, want %+v", *result, want)
+	}
+	if len(created) != 1 {
+		t.Fatalf("got %d releases, want 1", len(created))
+	}
+	r := created[0]
+	if r.RegistryExtensionID != 10 || r.CreatorUserID != 2 || r.Bundle == nil || *r.Bundle != "bundle" || !r.CreatedAt.Equal(publishedAt) {
+		t.Errorf("got release %+v", r)
+	}
+	if want := "{\n  \"description\": \"d\"\n}"; r.Manifest != want {
+		t.Errorf("got manifest %q, want %q (without the remote bundle URL)", r.Manifest, want)
+	}
+}
+
+func TestImportRegistry(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	t1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	archive := registryArchive{Extensions: []*archivedExtension{{
+		Publisher: "sourcegraph",
+		Name:      "codecov",
+		Releases: []*archivedRelease{
+			{ReleaseTag: "release", Manifest: `{}`, Bundle: strptr("b1"), CreatedAt: t1},
+			{ReleaseTag: "release", Manifest: `{}`, Bundle: strptr("b2"), CreatedAt: t2},
+		},
+	}}}
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gzw).Encode(archive); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mocks.extensions.GetByExtensionID = func(extensionID string) (*dbExtension, error) {
+		if extensionID != "sourcegraph/codecov" {
+			t.Errorf("got extension ID %q, want %q", extensionID, "sourcegraph/codecov")
+		}
+		return &dbExtension{ID: 10}, nil
+	}
+	mocks.releases.ListByExtension = func(registryExtensionID int32, includeArtifacts bool) ([]*dbRelease, error) {
+		// The first release was imported before.
+		return []*dbRelease{{ID: 1, RegistryExtensionID: 10, ReleaseTag: "release", CreatedAt: t1}}, nil
+	}
+	var created []*dbRelease
+	mocks.releases.Create = func(release *dbRelease) (int64, error) {
+		created = append(created, release)
+		return 2, nil
+	}
+
+	result, err := importRegistry(context.Background(), &buf, registryImportOptions{CreatorUserID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (registryImportResult{CreatedReleases: 1}); !reflect.DeepEqual(*result, want) {
+		t.Errorf("got result %+v, want %+v", *result, want)
+	}
+	if len(created) != 1 || *created[0].Bundle != "b2" || !created[0].CreatedAt.Equal(t2) {
+		t.Errorf("got releases %+v, want only the 2nd release", created)
+	}
+}
+
+func TestImportExtension_missingPublisher(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	mocks.extensions.GetByExtensionID = func(extensionID string) (*dbExtension, error) {
+		return nil, extensionNotFoundError{[]interface{}{extensionID}}
+	}
+	mocks.extensions.GetPublisher = func(name string) (*dbPublisher, error) {
+		return nil, publisherNotFoundError{[]interface{}{name}}
+	}
+	mocks.extensions.Create = func(publisherUserID, publisherOrgID int32, name string) (int32, error) {
+		t.Error("extension of a missing publisher was created")
+		return 0, nil
+	}
+
+	x := &archivedExtension{Publisher: "alice", Name: "x", Releases: []*archivedRelease{{ReleaseTag: "release", Manifest: `{}`}}}
+	var result registryImportResult
+	if err := importExtension(context.Background(), x, registryImportOptions{CreatorUserID: 2}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.SkippedExtensions) != 1 || result.SkippedExtensions[0] != "alice/x" || result.CreatedReleases != 0 {
+		t.Errorf("got result %+v, want the extension skipped", result)
+	}
+}
@@ -112,6 +112,10 @@ func (s dbExtensions) CountPublishers(ctx context.Context, opt dbPublishersListO
 
 // GePublisher gets the registry publisher with the given name.
 func (s dbExtensions) GetPublisher(ctx context.Context, name string) (*dbPublisher, error) {
+	if mocks.extensions.GetPublisher != nil {
+		return mocks.extensions.GetPublisher(name)
+	}
+
 	var userID, orgID sql.NullInt64
 	var p dbPublisher
 	q := sqlf.Sprintf(`
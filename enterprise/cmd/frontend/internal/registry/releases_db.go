@@ -42,20 +42,25 @@ func (err releaseNotFoundError) Error() string {
 
 var errInvalidJSONInManifest = errors.New("invalid syntax in extension manifest JSON")
 
-// Create creates a new release of an extension in the extension registry. The release.ID and
-// release.CreatedAt fields are ignored (they are populated automatically by the database).
+// Create creates a new release of an extension in the extension registry. The release.ID field is
+// ignored and, if release.CreatedAt is zero, it is populated automatically by the database. (A
+// nonzero release.CreatedAt is only used when importing releases published elsewhere.)
 func (dbReleases) Create(ctx context.Context, release *dbRelease) (id int64, err error) {
 	if mocks.releases.Create != nil {
 		return mocks.releases.Create(release)
 	}
 
+	var createdAt *time.Time
+	if !release.CreatedAt.IsZero() {
+		createdAt = &release.CreatedAt
+	}
 	if err := dbconn.Global.QueryRowContext(ctx,
 		`
-INSERT INTO registry_extension_releases(registry_extension_id, creator_user_id, release_version, release_tag, manifest, bundle, source_map)
-VALUES($1, $2, $3, $4, $5, $6, $7)
+INSERT INTO registry_extension_releases(registry_extension_id, creator_user_id, release_version, release_tag, manifest, bundle, source_map, created_at)
+VALUES($1, $2, $3, $4, $5, $6, $7, COALESCE($8, now()))
 RETURNING id
 `,
-		release.RegistryExtensionID, release.CreatorUserID, release.ReleaseVersion, release.ReleaseTag, release.Manifest, release.Bundle, release.SourceMap,
+		release.RegistryExtensionID, release.CreatorUserID, release.ReleaseVersion, release.ReleaseTag, release.Manifest, release.Bundle, release.SourceMap, createdAt,
 	).Scan(&id); err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Message == "invalid input syntax for type json" {
@@ -139,6 +144,38 @@ ORDER BY rer.registry_extension_id, rer.created_at DESC
 	return releases, nil
 }
 
+// ListByExtension lists all releases (with any release tag) of the extension, oldest first. If
+// includeArtifacts is true, it populates the (*dbRelease).{Bundle,SourceMap} fields, which may be
+// large.
+func (dbReleases) ListByExtension(ctx context.Context, registryExtensionID int32, includeArtifacts bool) ([]*dbRelease, error) {
+	if mocks.releases.ListByExtension != nil {
+		return mocks.releases.ListByExtension(registryExtensionID, includeArtifacts)
+	}
+
+	q := sqlf.Sprintf(`
+SELECT id, registry_extension_id, creator_user_id, release_version, release_tag, manifest, CASE WHEN %v::boolean THEN bundle ELSE null END AS bundle, CASE WHEN %v::boolean THEN source_map ELSE null END AS source_map, created_at
+FROM registry_extension_releases
+WHERE registry_extension_id=%d AND deleted_at IS NULL
+ORDER BY created_at ASC, id ASC`, includeArtifacts, includeArtifacts, registryExtensionID)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []*dbRelease
+	for rows.Next() {
+		var r dbRelease
+		err := rows.Scan(&r.ID, &r.RegistryExtensionID, &r.CreatorUserID, &r.ReleaseVersion, &r.ReleaseTag, &r.Manifest, &r.Bundle, &r.SourceMap, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, &r)
+	}
+	return releases, rows.Err()
+}
+
 // GetArtifacts gets the bundled JavaScript source file contents and the source map for a release
 // (by ID).
 func (dbReleases) GetArtifacts(ctx context.Context, id int64) (bundle, sourcemap []byte, err error) {
@@ -163,4 +200,6 @@ type mockReleases struct {
 	Create         func(release *dbRelease) (int64, error)
 	GetLatest      func(registryExtensionID int32, releaseTag string, includeArtifacts bool) (*dbRelease, error)
 	GetLatestBatch func(registryExtensionIDs []int32, releaseTag string, includeArtifacts bool) ([]*dbRelease, error)
+
+	ListByExtension func(registryExtensionID int32, includeArtifacts bool) ([]*dbRelease, error)
 }
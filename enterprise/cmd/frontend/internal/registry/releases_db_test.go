@@ -195,4 +195,25 @@ func TestRegistryExtensionReleases(t *testing.T) {
 			t.Error("sourcemap != nil")
 		}
 	})
+
+	t.Run("Create with creation date and ListByExtension", func(t *testing.T) {
+		createdAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := (dbReleases{}).Create(ctx, &dbRelease{
+			RegistryExtensionID: yExtensionID,
+			CreatorUserID:       user.ID,
+			ReleaseTag:          "release",
+			Manifest:            `{"m0": true}`,
+			CreatedAt:           createdAt,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		releases, err := dbReleases{}.ListByExtension(ctx, yExtensionID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(releases) != 2 || !releases[0].CreatedAt.Equal(createdAt) || releases[1].Bundle != nil {
+			t.Errorf("got %+v, want the imported release first and no artifacts", releases)
+		}
+	})
 }
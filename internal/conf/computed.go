@@ -281,6 +281,19 @@ func BrandName() string {
 	return branding.BrandName
 }
 
+// SearchHistoryRetentionDays returns the number of days that the searches of
+// users are kept in their search history: 90, or the site config
+// "search.history.retentionDays" value if configured. Search history is not
+// saved if it is 0. Negative values are invalid and treated as unset, so
+// that they don't delete all search history.
+func SearchHistoryRetentionDays() int {
+	val := Get().SearchHistoryRetentionDays
+	if val == nil || *val < 0 {
+		return 90
+	}
+	return *val
+}
+
 // SearchSymbolsParallelism returns 20, or the site config
 // "debug.search.symbolsParallelism" value if configured.
 func SearchSymbolsParallelism() int {
@@ -328,6 +341,14 @@ func AndOrQueryEnabled() bool {
 	return e.AndOrQuery == "enabled"
 }
 
+func GlobalSymbolIndexEnabled() bool {
+	e := Get().ExperimentalFeatures
+	if e == nil {
+		return false
+	}
+	return e.GlobalSymbolIndex == "enabled"
+}
+
 func SearchMultipleRevisionsPerRepository() bool {
 	x := ExperimentalFeatures()
 	return x.SearchMultipleRevisionsPerRepository != nil && *x.SearchMultipleRevisionsPerRepository
@@ -0,0 +1,50 @@
+package reposource
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGerrit_cloneURLToRepoName(t *testing.T) {
+	tests := []struct {
+		conn schema.GerritConnection
+		urls []urlToRepoName
+	}{
+		{
+			conn: schema.GerritConnection{
+				Url: "https://gerrit.example.com",
+			},
+			urls: []urlToRepoName{
+				{"https://gerrit.example.com/platform/build", "gerrit.example.com/platform/build"},
+				{"https://gerrit.example.com/platform/build.git", "gerrit.example.com/platform/build"},
+				{"https://alice@gerrit.example.com/a/platform/build", "gerrit.example.com/platform/build"},
+				{"ssh://alice@gerrit.example.com:29418/platform/build", "gerrit.example.com/platform/build"},
+
+				{"https://asdf.com/platform/build", ""},
+			},
+		},
+		{
+			conn: schema.GerritConnection{
+				Url:                   "https://review.example.com/gerrit",
+				RepositoryPathPattern: "gerrit/{name}",
+			},
+			urls: []urlToRepoName{
+				{"https://review.example.com/gerrit/tools/repo", "gerrit/tools/repo"},
+				{"https://review.example.com/gerrit/a/tools/repo", "gerrit/tools/repo"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for _, u := range test.urls {
+			repoName, err := Gerrit{&test.conn}.CloneURLToRepoName(u.cloneURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.repoName != string(repoName) {
+				t.Errorf("expected %q but got %q for clone URL %q (connection: %+v)", u.repoName, repoName, u.cloneURL, test.conn)
+			}
+		}
+	}
+}
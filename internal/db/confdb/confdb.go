@@ -132,6 +132,53 @@ func CriticalGetLatest(ctx context.Context) (latest *CriticalConfig, err error)
 	return (*CriticalConfig)(critical), err
 }
 
+// SiteListHistory returns the site configs that were saved to the database,
+// newest first. If limit is positive, at most limit configs are returned.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func SiteListHistory(ctx context.Context, limit int) ([]*SiteConfig, error) {
+	limitQuery := sqlf.Sprintf("")
+	if limit > 0 {
+		limitQuery = sqlf.Sprintf("LIMIT %d", limit)
+	}
+	q := sqlf.Sprintf("SELECT s.id, s.type, s.contents, s.created_at, s.updated_at FROM critical_and_site_config s WHERE type=%s ORDER BY id DESC %s", typeSite, limitQuery)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := parseQueryRows(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*SiteConfig, len(versions))
+	for i, v := range versions {
+		history[i] = (*SiteConfig)(v)
+	}
+	return history, nil
+}
+
+// SiteGetByID returns the site config with the given ID that was saved to the
+// database. This returns nil, nil if there is no such site config.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func SiteGetByID(ctx context.Context, id int32) (*SiteConfig, error) {
+	q := sqlf.Sprintf("SELECT s.id, s.type, s.contents, s.created_at, s.updated_at FROM critical_and_site_config s WHERE type=%s AND id=%d", typeSite, id)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := parseQueryRows(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) != 1 {
+		return nil, nil
+	}
+	return (*SiteConfig)(versions[0]), nil
+}
+
 func newTransaction(ctx context.Context) (tx queryable, done func(), err error) {
 	rtx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
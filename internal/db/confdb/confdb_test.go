@@ -198,3 +198,49 @@ func TestCriticalCreateIfUpToDate(t *testing.T) {
 		})
 	}
 }
+
+func TestSiteListHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	latest, err := SiteGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := SiteCreateIfUpToDate(ctx, &latest.ID, `{"a": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := SiteCreateIfUpToDate(ctx, &first.ID, `{"a": 2}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := SiteListHistory(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].ID != second.ID || history[1].ID != first.ID {
+		t.Errorf("got history %+v, want [%d %d]", history, second.ID, first.ID)
+	}
+
+	got, err := SiteGetByID(ctx, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Contents != `{"a": 1}` {
+		t.Errorf("got %+v, want contents %q", got, `{"a": 1}`)
+	}
+
+	critical, err := CriticalGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := SiteGetByID(ctx, critical.ID); err != nil || got != nil {
+		t.Errorf("got (%+v, %v), want (nil, nil) for critical config ID", got, err)
+	}
+}
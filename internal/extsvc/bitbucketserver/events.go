@@ -28,6 +28,9 @@ func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err err
 	case "pr:participant:status":
 		e = &PullRequestParticipantStatusEvent{}
 		return e, json.Unmarshal(payload, e)
+	case "repo:refs_changed":
+		e = &RefsChangedEvent{}
+		return e, json.Unmarshal(payload, e)
 	default:
 		return nil, fmt.Errorf("unknown webhook event type: %q", eventType)
 	}
@@ -35,6 +38,27 @@ func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err err
 
 type PingEvent struct{}
 
+// RefsChangedEvent is sent by Bitbucket Server's built-in webhooks when
+// refs of a repository are pushed.
+type RefsChangedEvent struct {
+	Date       time.Time   `json:"date"`
+	Actor      User        `json:"actor"`
+	Repository Repo        `json:"repository"`
+	Changes    []RefChange `json:"changes"`
+}
+
+type RefChange struct {
+	Ref struct {
+		ID        string `json:"id"`
+		DisplayID string `json:"displayId"`
+		Type      string `json:"type"`
+	} `json:"ref"`
+	RefID    string `json:"refId"`
+	FromHash string `json:"fromHash"`
+	ToHash   string `json:"toHash"`
+	Type     string `json:"type"`
+}
+
 type PullRequestActivityEvent struct {
 	Date        time.Time      `json:"date"`
 	Actor       User           `json:"actor"`
@@ -0,0 +1,236 @@
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gerrit_requests_count", "Total number of requests sent to the Gerrit API.")
+
+// xssiPrefix is prepended by Gerrit to all JSON responses to prevent them from being
+// included as scripts. See https://gerrit-review.googlesource.com/Documentation/rest-api.html#output.
+var xssiPrefix = []byte(")]}'")
+
+// Client access a Gerrit instance via the REST API.
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gerrit instance. It must end with a slash.
+	URL *url.URL
+
+	// The username and HTTP password of the Gerrit account used for accessing the API. If
+	// Username is empty, the API is accessed anonymously.
+	Username, Password string
+}
+
+// NewClient creates a new Gerrit API client with the given base URL. If a nil httpClient
+// is provided, http.DefaultClient will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The first component of the path after the optional "/a/" authentication prefix
+		// maps to the type of API request we are making.
+		path := strings.TrimPrefix(u.Path, baseURL.Path)
+		path = strings.TrimPrefix(path, "a/")
+		return strings.SplitN(path, "/", 2)[0]
+	})
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+	}
+}
+
+// Project is a Gerrit project (a Git repository).
+type Project struct {
+	// ID is the URL-encoded project name.
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Parent      string    `json:"parent,omitempty"`
+	Description string    `json:"description,omitempty"`
+	State       string    `json:"state,omitempty"`
+	WebLinks    []WebLink `json:"web_links,omitempty"`
+}
+
+// Project states.
+const (
+	ProjectStateActive   = "ACTIVE"
+	ProjectStateReadOnly = "READ_ONLY"
+	ProjectStateHidden   = "HIDDEN"
+)
+
+// WebLink is a link to a project in an external web browser (such as Gitiles), configured
+// by a Gerrit plugin.
+type WebLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// GitilesURL returns the URL of the project in Gitiles, resolved against the Gerrit base URL,
+// or the empty string if the project has no web link to Gitiles. Gitiles is the repository
+// browser that ships as a Gerrit plugin, whose web link is named "gitiles" by default (and
+// "browse" on googlesource.com).
+func (p *Project) GitilesURL(baseURL *url.URL) string {
+	for _, l := range p.WebLinks {
+		if l.Name != "gitiles" && l.Name != "browse" {
+			continue
+		}
+		u, err := baseURL.Parse(l.URL)
+		if err != nil {
+			continue
+		}
+		return strings.TrimSuffix(u.String(), "/")
+	}
+	return ""
+}
+
+// ListProjectsArgs are the arguments to ListProjects.
+type ListProjectsArgs struct {
+	// Prefix limits the results to projects whose names start with it.
+	Prefix string
+	// Skip is the number of projects to skip, for pagination.
+	Skip int
+	// Limit is the maximum number of projects to return.
+	Limit int
+}
+
+// ListProjects returns the projects visible to the client's account, sorted by name. The
+// returned bool is true if there are more projects after the returned ones.
+func (c *Client) ListProjects(ctx context.Context, args ListProjectsArgs) ([]*Project, bool, error) {
+	qry := url.Values{}
+	if args.Prefix != "" {
+		qry.Set("p", args.Prefix)
+	}
+	if args.Skip > 0 {
+		qry.Set("S", strconv.Itoa(args.Skip))
+	}
+	if args.Limit > 0 {
+		qry.Set("n", strconv.Itoa(args.Limit))
+	}
+
+	var result map[string]*struct {
+		Project
+		MoreProjects bool `json:"_more_projects"`
+	}
+	// The "d" flag includes project descriptions.
+	rawQuery := "d"
+	if len(qry) > 0 {
+		rawQuery += "&" + qry.Encode()
+	}
+	if err := c.get(ctx, "projects/", rawQuery, &result); err != nil {
+		return nil, false, err
+	}
+
+	projects := make([]*Project, 0, len(result))
+	more := false
+	for name, p := range result {
+		p.Project.Name = name
+		projects = append(projects, &p.Project)
+		more = more || p.MoreProjects
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	return projects, more, nil
+}
+
+// GetProject returns the project with the given name.
+func (c *Client) GetProject(ctx context.Context, name string) (*Project, error) {
+	var p Project
+	if err := c.get(ctx, "projects/"+url.PathEscape(name), "", &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (c *Client) get(ctx context.Context, path, rawQuery string, result interface{}) error {
+	if c.Username != "" {
+		// Authenticated requests must be prefixed with "/a/".
+		path = "a/" + path
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	u.RawQuery = rawQuery
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, result)
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gerrit"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if result != nil {
+		return json.Unmarshal(bytes.TrimPrefix(bs, xssiPrefix), result)
+	}
+
+	return nil
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gerrit API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
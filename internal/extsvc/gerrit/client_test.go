@@ -0,0 +1,193 @@
+package gerrit
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update testdata")
+
+var testProjects = map[string]*Project{
+	"gerrit": {
+		ID:          "gerrit",
+		Name:        "gerrit",
+		Parent:      "All-Projects",
+		Description: "Gerrit Code Review",
+		State:       ProjectStateActive,
+		WebLinks:    []WebLink{{Name: "browse", URL: "https://gerrit.googlesource.com/gerrit/"}},
+	},
+	"plugins/codemirror-editor": {
+		ID:          "plugins%2Fcodemirror-editor",
+		Name:        "plugins/codemirror-editor",
+		Parent:      "Public-Plugins",
+		Description: "CodeMirror editor for PolyGerrit",
+		State:       ProjectStateActive,
+		WebLinks:    []WebLink{{Name: "browse", URL: "https://gerrit.googlesource.com/plugins/codemirror-editor/"}},
+	},
+	"plugins/gitiles": {
+		ID:          "plugins%2Fgitiles",
+		Name:        "plugins/gitiles",
+		Parent:      "Public-Plugins",
+		Description: "Plugin running Gitiles alongside a Gerrit server",
+		State:       ProjectStateActive,
+		WebLinks:    []WebLink{{Name: "browse", URL: "https://gerrit.googlesource.com/plugins/gitiles/"}},
+	},
+	"plugins/replication": {
+		ID:          "plugins%2Freplication",
+		Name:        "plugins/replication",
+		Parent:      "Public-Plugins",
+		Description: "Copies to other servers using the Git protocol",
+		State:       ProjectStateActive,
+		WebLinks:    []WebLink{{Name: "browse", URL: "https://gerrit.googlesource.com/plugins/replication/"}},
+	},
+}
+
+func TestClient_ListProjects(t *testing.T) {
+	cli, save := NewTestClient(t, "ListProjects", *update, &url.URL{Scheme: "https", Host: "gerrit-review.googlesource.com", Path: "/"})
+	defer save()
+
+	for _, tc := range []struct {
+		name     string
+		args     ListProjectsArgs
+		projects []*Project
+		more     bool
+	}{
+		{
+			name:     "first page",
+			args:     ListProjectsArgs{Prefix: "plugins/", Limit: 2},
+			projects: []*Project{testProjects["plugins/codemirror-editor"], testProjects["plugins/gitiles"]},
+			more:     true,
+		},
+		{
+			name:     "last page",
+			args:     ListProjectsArgs{Prefix: "plugins/", Skip: 2, Limit: 2},
+			projects: []*Project{testProjects["plugins/replication"]},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			projects, more, err := cli.ListProjects(context.Background(), tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if more != tc.more {
+				t.Errorf("got more %v, want %v", more, tc.more)
+			}
+
+			if have, want := projects, tc.projects; !reflect.DeepEqual(have, want) {
+				t.Error(cmp.Diff(have, want))
+			}
+		})
+	}
+}
+
+func TestClient_GetProject(t *testing.T) {
+	cli, save := NewTestClient(t, "GetProject", *update, &url.URL{Scheme: "https", Host: "gerrit-review.googlesource.com", Path: "/"})
+	defer save()
+
+	for _, tc := range []struct {
+		name    string
+		project *Project
+		err     string
+	}{
+		{
+			name:    "gerrit",
+			project: testProjects["gerrit"],
+		},
+		{
+			name:    "plugins/gitiles",
+			project: testProjects["plugins/gitiles"],
+		},
+		{
+			name: "missing",
+			err:  `Gerrit API HTTP error: code=404 url="https://gerrit-review.googlesource.com/projects/missing" body="Not found: missing\n"`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			project, err := cli.GetProject(context.Background(), tc.name)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if tc.project == nil {
+				return
+			}
+			if have, want := project, tc.project; !reflect.DeepEqual(have, want) {
+				t.Error(cmp.Diff(have, want))
+			}
+		})
+	}
+}
+
+func TestClient_authenticated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.EscapedPath() != "/gerrit/a/projects/tools%2Frepo" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, ")]}'\n{\"id\": \"tools%2Frepo\", \"name\": \"tools/repo\"}")
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL + "/gerrit/")
+	cli := NewClient(u, nil)
+	cli.Username, cli.Password = "alice", "secret"
+
+	project, err := cli.GetProject(context.Background(), "tools/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Project{ID: "tools%2Frepo", Name: "tools/repo"}); !reflect.DeepEqual(project, want) {
+		t.Error(cmp.Diff(project, want))
+	}
+}
+
+func TestProject_GitilesURL(t *testing.T) {
+	baseURL, _ := url.Parse("https://review.mycorp.com/gerrit/")
+	for _, tc := range []struct {
+		name     string
+		webLinks []WebLink
+		want     string
+	}{
+		{name: "none"},
+		{
+			name:     "other plugin",
+			webLinks: []WebLink{{Name: "gitweb", URL: "https://git.mycorp.com/?p=tools/repo.git"}},
+		},
+		{
+			name:     "absolute",
+			webLinks: []WebLink{{Name: "browse", URL: "https://gerrit.googlesource.com/tools/repo/"}},
+			want:     "https://gerrit.googlesource.com/tools/repo",
+		},
+		{
+			name:     "relative",
+			webLinks: []WebLink{{Name: "gitiles", URL: "/gerrit/plugins/gitiles/tools/repo"}},
+			want:     "https://review.mycorp.com/gerrit/plugins/gitiles/tools/repo",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Project{Name: "tools/repo", WebLinks: tc.webLinks}
+			if have := p.GitilesURL(baseURL); have != tc.want {
+				t.Errorf("have %q, want %q", have, tc.want)
+			}
+		})
+	}
+}
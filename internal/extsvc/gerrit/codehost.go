@@ -0,0 +1,5 @@
+package gerrit
+
+// ServiceType is the (api.ExternalRepoSpec).ServiceType value for Gerrit projects. The ServiceID
+// value is the base URL to the Gerrit instance.
+const ServiceType = "gerrit"
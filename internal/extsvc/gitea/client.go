@@ -0,0 +1,205 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/metrics"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+var requestCounter = metrics.NewRequestMeter("gitea_requests_count", "Total number of requests sent to the Gitea API.")
+
+// PerPage is the number of repositories requested per page. Gitea caps page sizes to its
+// MAX_RESPONSE_ITEMS setting (50 by default), so larger values would be truncated anyway.
+const PerPage = 50
+
+// Client access a Gitea instance via the REST API v1. Gogs exposes a compatible subset of
+// the same API, so the client works with Gogs instances too.
+type Client struct {
+	// HTTP Client used to communicate with the API
+	httpClient httpcli.Doer
+
+	// URL is the base URL of the Gitea instance.
+	URL *url.URL
+
+	// Token is the access token used to authenticate requests.
+	Token string
+}
+
+// NewClient creates a new Gitea API client with the given base URL. If a nil httpClient
+// is provided, http.DefaultClient will be used.
+func NewClient(baseURL *url.URL, httpClient httpcli.Doer) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpClient = requestCounter.Doer(httpClient, func(u *url.URL) string {
+		// The third component of the Path (after "/api/v1/") mostly maps to the type of API
+		// request we are making.
+		var category string
+		if parts := strings.SplitN(u.Path, "/", 5); len(parts) > 3 {
+			category = parts[3]
+		}
+		return category
+	})
+
+	return &Client{
+		httpClient: httpClient,
+		URL:        baseURL,
+	}
+}
+
+// Repo is a Gitea repository.
+type Repo struct {
+	ID            int64  `json:"id"`
+	Owner         User   `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	Private       bool   `json:"private"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived"`
+	HTMLURL       string `json:"html_url"`
+	SSHURL        string `json:"ssh_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// OrgRepos returns the given page (starting at 1) of the repositories of an organization. The
+// returned bool is true if there may be more pages.
+func (c *Client) OrgRepos(ctx context.Context, org string, page int) ([]*Repo, bool, error) {
+	return c.listRepos(ctx, "/api/v1/orgs/"+url.PathEscape(org)+"/repos", nil, page)
+}
+
+// UserRepos returns the given page (starting at 1) of the repositories owned by a user. The
+// returned bool is true if there may be more pages.
+func (c *Client) UserRepos(ctx context.Context, user string, page int) ([]*Repo, bool, error) {
+	return c.listRepos(ctx, "/api/v1/users/"+url.PathEscape(user)+"/repos", nil, page)
+}
+
+// SearchRepos returns the given page (starting at 1) of the repositories matching query that
+// are visible to the token's user. The returned bool is true if there may be more pages.
+func (c *Client) SearchRepos(ctx context.Context, query string, page int) ([]*Repo, bool, error) {
+	var result struct {
+		OK   bool    `json:"ok"`
+		Data []*Repo `json:"data"`
+	}
+	qry := url.Values{"q": []string{query}}
+	if err := c.get(ctx, "/api/v1/repos/search", pageValues(qry, page), &result); err != nil {
+		return nil, false, err
+	}
+	if !result.OK {
+		return nil, false, errors.Errorf("Gitea repository search for %q failed", query)
+	}
+	return result.Data, len(result.Data) >= PerPage, nil
+}
+
+// Repo returns the repository with the given owner and name.
+func (c *Client) Repo(ctx context.Context, owner, name string) (*Repo, error) {
+	var repo Repo
+	path := "/api/v1/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name)
+	if err := c.get(ctx, path, nil, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+func (c *Client) listRepos(ctx context.Context, path string, qry url.Values, page int) ([]*Repo, bool, error) {
+	var repos []*Repo
+	if err := c.get(ctx, path, pageValues(qry, page), &repos); err != nil {
+		return nil, false, err
+	}
+	return repos, len(repos) >= PerPage, nil
+}
+
+func pageValues(qry url.Values, page int) url.Values {
+	if qry == nil {
+		qry = make(url.Values)
+	}
+	qry.Set("page", strconv.Itoa(page))
+	qry.Set("limit", strconv.Itoa(PerPage))
+	return qry
+}
+
+func (c *Client) get(ctx context.Context, path string, qry url.Values, result interface{}) error {
+	u := url.URL{Path: path, RawQuery: qry.Encode()}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, req, result)
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	req, ht := nethttp.TraceRequest(ot.GetTracer(ctx),
+		req.WithContext(ctx),
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return errors.WithStack(&httpError{
+			URL:        req.URL,
+			StatusCode: resp.StatusCode,
+			Body:       bs,
+		})
+	}
+
+	if result != nil {
+		return json.Unmarshal(bs, result)
+	}
+
+	return nil
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("Gitea API HTTP error: code=%d url=%q body=%q", e.StatusCode, e.URL, e.Body)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,99 @@
+package gitea
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update testdata")
+
+var testRepos = map[string]*Repo{
+	"jsonrpc2": {
+		ID:            1016,
+		Owner:         User{ID: 1187, Login: "sourcegraph"},
+		Name:          "jsonrpc2",
+		FullName:      "sourcegraph/jsonrpc2",
+		Description:   "Package jsonrpc2 provides a client and server implementation of JSON-RPC 2.0",
+		HTMLURL:       "https://try.gitea.io/sourcegraph/jsonrpc2",
+		SSHURL:        "git@try.gitea.io:sourcegraph/jsonrpc2.git",
+		CloneURL:      "https://try.gitea.io/sourcegraph/jsonrpc2.git",
+		DefaultBranch: "master",
+	},
+	"go-diff": {
+		ID:            1017,
+		Owner:         User{ID: 1187, Login: "sourcegraph"},
+		Name:          "go-diff",
+		FullName:      "sourcegraph/go-diff",
+		Description:   "Unified diff parser and printer for Go",
+		Fork:          true,
+		HTMLURL:       "https://try.gitea.io/sourcegraph/go-diff",
+		SSHURL:        "git@try.gitea.io:sourcegraph/go-diff.git",
+		CloneURL:      "https://try.gitea.io/sourcegraph/go-diff.git",
+		DefaultBranch: "master",
+	},
+}
+
+func TestClient_OrgRepos(t *testing.T) {
+	cli, save := NewTestClient(t, "OrgRepos", *update, &url.URL{Scheme: "https", Host: "try.gitea.io"})
+	defer save()
+
+	for _, tc := range []struct {
+		name  string
+		org   string
+		repos []*Repo
+		err   string
+	}{
+		{
+			name:  "found",
+			org:   "sourcegraph",
+			repos: []*Repo{testRepos["jsonrpc2"], testRepos["go-diff"]},
+		},
+		{
+			name: "not found",
+			org:  "missing",
+			err:  `Gitea API HTTP error: code=404 url="https://try.gitea.io/api/v1/orgs/missing/repos?limit=50&page=1" body="{\"message\":\"Not Found\",\"url\":\"https://try.gitea.io/api/swagger\"}"`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.err == "" {
+				tc.err = "<nil>"
+			}
+
+			repos, more, err := cli.OrgRepos(context.Background(), tc.org, 1)
+			if have, want := fmt.Sprint(err), tc.err; have != want {
+				t.Errorf("error:\nhave: %q\nwant: %q", have, want)
+			}
+
+			if more {
+				t.Error("got more pages, want none")
+			}
+
+			if have, want := repos, tc.repos; !reflect.DeepEqual(have, want) {
+				t.Error(cmp.Diff(have, want))
+			}
+		})
+	}
+}
+
+func TestClient_SearchRepos(t *testing.T) {
+	cli, save := NewTestClient(t, "SearchRepos", *update, &url.URL{Scheme: "https", Host: "try.gitea.io"})
+	defer save()
+
+	repos, more, err := cli.SearchRepos(context.Background(), "diff", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if more {
+		t.Error("got more pages, want none")
+	}
+	if have, want := repos, []*Repo{testRepos["go-diff"]}; !reflect.DeepEqual(have, want) {
+		t.Error(cmp.Diff(have, want))
+	}
+}
@@ -0,0 +1,48 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	eventTypeHeader = "X-Gitlab-Event"
+	tokenHeader     = "X-Gitlab-Token"
+)
+
+// WebhookEventType returns the type of the GitLab webhook event in the request.
+func WebhookEventType(r *http.Request) string {
+	return r.Header.Get(eventTypeHeader)
+}
+
+// WebhookToken returns the secret token that GitLab sends with webhook
+// requests to authenticate them.
+func WebhookToken(r *http.Request) string {
+	return r.Header.Get(tokenHeader)
+}
+
+// ParseWebhookEvent parses the payload of a GitLab webhook event of the given type.
+func ParseWebhookEvent(eventType string, payload []byte) (e interface{}, err error) {
+	switch eventType {
+	case "Push Hook", "Tag Push Hook":
+		e = &PushEvent{}
+		return e, json.Unmarshal(payload, e)
+	default:
+		return nil, fmt.Errorf("unknown webhook event type: %q", eventType)
+	}
+}
+
+// PushEvent is sent when commits or tags are pushed to a GitLab project.
+type PushEvent struct {
+	ObjectKind string `json:"object_kind"`
+	Ref        string `json:"ref"`
+	Before     string `json:"before"`
+	After      string `json:"after"`
+	ProjectID  int    `json:"project_id"`
+	Project    struct {
+		ID                int    `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+	} `json:"project"`
+}
@@ -0,0 +1,179 @@
+package gitserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+var defaultBatchDelay = func() time.Duration {
+	d, err := time.ParseDuration(env.Get("SRC_GITSERVER_BATCH_DELAY", "0", "how long git commands wait to be sent to gitserver in a batch with concurrent commands for the same repository, such as 1ms (0 disables batching)"))
+	if err != nil {
+		return 0
+	}
+	return d
+}()
+
+// maxBatchSize is the maximum number of commands sent in one batch request.
+const maxBatchSize = 100
+
+// batchTimeout is the timeout of batch requests. The commands waiting for a
+// batch are still canceled with their own contexts.
+const batchTimeout = 2 * time.Minute
+
+// errBatchUnsupported is returned by Batch if gitserver doesn't support batch
+// requests, e.g. during an upgrade.
+var errBatchUnsupported = errors.New("gitserver does not support batch requests")
+
+// errNotBatched is returned to a command which was the only command of its
+// batch. It is executed on its own instead.
+var errNotBatched = errors.New("command was not batched")
+
+// Batch runs the git commands in the repository in a single request to
+// gitserver, which serves object lookups and file reads from a single git
+// process. The commands must be read-only, see protocol.IsBatchCommand. It
+// returns a result for every command and sets the ExitStatus of the commands.
+func (c *Client) Batch(ctx context.Context, repo Repo, cmds ...*Cmd) ([]protocol.BatchResult, error) {
+	repoName := protocol.NormalizeRepo(repo.Name)
+	req := &protocol.BatchRequest{
+		Repo:     repoName,
+		URL:      repo.URL,
+		Commands: make([]protocol.BatchCommand, len(cmds)),
+	}
+	for i, cmd := range cmds {
+		req.Commands[i] = protocol.BatchCommand{EnsureRevision: cmd.EnsureRevision, Args: cmd.Args[1:]}
+	}
+
+	resp, err := c.httpPost(ctx, repoName, "batch", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// Older gitservers respond with a plain 404 page.
+		var payload protocol.NotFoundPayload
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			return nil, errBatchUnsupported
+		}
+		return nil, &vcs.RepoNotExistError{Repo: repoName, CloneInProgress: payload.CloneInProgress, CloneProgress: payload.CloneProgress}
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("batch: unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	var res protocol.BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	if len(res.Results) != len(cmds) {
+		return nil, fmt.Errorf("batch: got %d results for %d commands", len(res.Results), len(cmds))
+	}
+	for i, cmd := range cmds {
+		cmd.ExitStatus = res.Results[i].ExitStatus
+	}
+	return res.Results, nil
+}
+
+// pendingBatch collects the commands which are run in the same batch
+// request.
+type pendingBatch struct {
+	repo Repo
+	cmds []*Cmd
+
+	once    sync.Once
+	done    chan struct{} // closed once results or err are set
+	results []protocol.BatchResult
+	err     error
+}
+
+// runBatched runs cmd in a batch with the other commands for the same
+// repository which are run within the batch delay. ok is false if the
+// command has to be run on its own, e.g. because gitserver has to fetch its
+// revision first.
+func (c *Cmd) runBatched(ctx context.Context) (res protocol.BatchResult, ok bool, err error) {
+	client := c.client
+	key := protocol.NormalizeRepo(c.Repo.Name)
+
+	client.batchMu.Lock()
+	if client.batches == nil {
+		client.batches = map[api.RepoName]*pendingBatch{}
+	}
+	b := client.batches[key]
+	if b == nil {
+		b = &pendingBatch{repo: c.Repo, done: make(chan struct{})}
+		client.batches[key] = b
+		time.AfterFunc(client.BatchDelay, func() { client.sendBatch(key, b) })
+	}
+	i := len(b.cmds)
+	b.cmds = append(b.cmds, c)
+	if b.repo.URL == "" {
+		b.repo.URL = c.Repo.URL
+	}
+	full := len(b.cmds) >= maxBatchSize
+	client.batchMu.Unlock()
+
+	if full {
+		go client.sendBatch(key, b)
+	}
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return res, true, ctx.Err()
+	}
+	if b.err == errNotBatched || b.err == errBatchUnsupported {
+		return res, false, nil
+	}
+	if b.err != nil {
+		return res, true, b.err
+	}
+	if b.results[i].NotRun {
+		return res, false, nil
+	}
+	return b.results[i], true, nil
+}
+
+// sendBatch sends the batch b for the repository key, unless it was sent
+// already.
+func (c *Client) sendBatch(key api.RepoName, b *pendingBatch) {
+	b.once.Do(func() {
+		c.batchMu.Lock()
+		if c.batches[key] == b {
+			delete(c.batches, key)
+		}
+		cmds := b.cmds
+		c.batchMu.Unlock()
+
+		defer close(b.done)
+		batchSize.Observe(float64(len(cmds)))
+		if len(cmds) == 1 {
+			b.err = errNotBatched
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), batchTimeout)
+		defer cancel()
+		b.results, b.err = c.Batch(ctx, b.repo, cmds...)
+	})
+}
+
+var batchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "src_gitserver_client_batch_size",
+	Help:    "Number of git commands collected in a batch. Batches of one command are sent to exec.",
+	Buckets: []float64{1, 2, 5, 10, 20, 50, 100},
+})
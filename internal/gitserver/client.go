@@ -69,6 +69,10 @@ func NewClient(cli httpcli.Doer) *Client {
 	}
 }
 
+func init() {
+	DefaultClient.BatchDelay = defaultBatchDelay
+}
+
 // Client is a gitserver client.
 type Client struct {
 	// HTTP client to use
@@ -85,6 +89,16 @@ type Client struct {
 	// UserAgent is a string identifing who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
+
+	// BatchDelay is how long read-only commands wait to be sent in a batch
+	// request with concurrent commands for the same repository. Commands
+	// are only batched if they are run with DividedOutput, Output,
+	// CombinedOutput or Run. Zero (the default) disables batching; the
+	// DefaultClient uses $SRC_GITSERVER_BATCH_DELAY.
+	BatchDelay time.Duration
+
+	batchMu sync.Mutex                     // protects batches
+	batches map[api.RepoName]*pendingBatch // the batches waiting to be sent
 }
 
 // AddrForRepo returns the gitserver address to use for the given repo name.
@@ -112,8 +126,29 @@ func addrForKey(addrs []string, key string) string {
 // ArchiveOptions contains options for the Archive func.
 type ArchiveOptions struct {
 	Treeish string   // the tree or commit to produce an archive for
-	Format  string   // format of the resulting archive (usually "tar" or "zip")
+	Format  string   // format of the resulting archive ("tar", "tar.gz", "tgz" or "zip")
 	Paths   []string // if nonempty, only include these paths
+
+	// Base, if set, is a commit. Only the files added or modified between
+	// Base and Treeish are included.
+	Base string
+
+	Filter ArchiveFilter
+}
+
+// ArchiveFilter selects the files included in an archive by gitserver, so
+// that callers don't need to download files they skip anyway.
+type ArchiveFilter struct {
+	// Include and Exclude are glob patterns matched against file paths. If
+	// Include is nonempty, only files matching one of its patterns are
+	// included. Files matching one of the Exclude patterns are left out.
+	// "*" matches within a path component and "**" matches any number of
+	// path components, so "**/*.json" matches all JSON files.
+	Include, Exclude []string
+
+	// MaxFileSize, if positive, is the size in bytes above which files are
+	// included with empty contents.
+	MaxFileSize int64
 }
 
 // archiveReader wraps the StdoutReader yielded by gitserver's
@@ -153,6 +188,18 @@ func (c *Client) ArchiveURL(ctx context.Context, repo Repo, opt ArchiveOptions)
 	for _, path := range opt.Paths {
 		q.Add("path", path)
 	}
+	if opt.Base != "" {
+		q.Set("base", opt.Base)
+	}
+	for _, pattern := range opt.Filter.Include {
+		q.Add("include", pattern)
+	}
+	for _, pattern := range opt.Filter.Exclude {
+		q.Add("exclude", pattern)
+	}
+	if opt.Filter.MaxFileSize > 0 {
+		q.Set("maxFileSize", strconv.FormatInt(opt.Filter.MaxFileSize, 10))
+	}
 
 	return &url.URL{
 		Scheme:   "http",
@@ -317,6 +364,20 @@ func (c *Client) Command(name string, arg ...string) *Cmd {
 
 // DividedOutput runs the command and returns its standard output and standard error.
 func (c *Cmd) DividedOutput(ctx context.Context) ([]byte, []byte, error) {
+	if c.client.BatchDelay > 0 && protocol.IsBatchCommand(c.Args[1:]) {
+		res, ok, err := c.runBatched(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			stderr := []byte(res.Stderr)
+			if res.Error != "" {
+				return res.Stdout, stderr, errors.New(res.Error)
+			}
+			return res.Stdout, stderr, nil
+		}
+	}
+
 	rc, trailer, err := c.sendExec(ctx)
 	if err != nil {
 		return nil, nil, err
@@ -559,12 +620,18 @@ func (c *Client) doListOne(ctx context.Context, urlSuffix, addr string) ([]strin
 // Repo updates are not guaranteed to occur. If a repo has been updated
 // recently (within the Since duration specified in the request), the
 // update won't happen.
-func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Duration) (*protocol.RepoUpdateResponse, error) {
+//
+// If opts is non-nil, it configures how gitserver fetches the repo.
+func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Duration, opts *RepoUpdateOptions) (*protocol.RepoUpdateResponse, error) {
 	req := &protocol.RepoUpdateRequest{
 		Repo:  repo.Name,
 		URL:   repo.URL,
 		Since: since,
 	}
+	if opts != nil {
+		req.LFS = opts.LFS
+		req.Fetch = opts.Fetch
+	}
 	resp, err := c.httpPost(ctx, repo.Name, "repo-update", req)
 	if err != nil {
 		return nil, err
@@ -580,6 +647,17 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Du
 	return info, err
 }
 
+// RepoUpdateOptions are the options of RequestRepoUpdate.
+type RepoUpdateOptions struct {
+	// LFS configures fetching the repository's Git LFS files. If nil,
+	// gitserver keeps fetching them as it did before.
+	LFS *protocol.LFSOptions
+
+	// Fetch configures additional refs to fetch. If nil, gitserver keeps
+	// fetching the refs it fetched before.
+	Fetch *protocol.FetchOptions
+}
+
 // MockIsRepoCloneable mocks (*Client).IsRepoCloneable for tests.
 var MockIsRepoCloneable func(Repo) error
 
@@ -14,7 +14,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -57,6 +59,34 @@ func TestClient_ListCloned(t *testing.T) {
 	}
 }
 
+func TestClient_ArchiveURL(t *testing.T) {
+	cli := &gitserver.Client{
+		Addrs: func(ctx context.Context) []string { return []string{"gitserver-0"} },
+	}
+	u := cli.ArchiveURL(context.Background(), gitserver.Repo{Name: "github.com/foo/bar"}, gitserver.ArchiveOptions{
+		Treeish: "HEAD",
+		Format:  "tar",
+		Base:    "HEAD~1",
+		Filter: gitserver.ArchiveFilter{
+			Include:     []string{"**/*.go"},
+			Exclude:     []string{"vendor/**", "**/*.json"},
+			MaxFileSize: 1024,
+		},
+	})
+	want := url.Values{
+		"repo":        {"github.com/foo/bar"},
+		"treeish":     {"HEAD"},
+		"format":      {"tar"},
+		"base":        {"HEAD~1"},
+		"include":     {"**/*.go"},
+		"exclude":     {"vendor/**", "**/*.json"},
+		"maxFileSize": {"1024"},
+	}
+	if got := u.Query(); !cmp.Equal(want, got) {
+		t.Errorf("mismatch for (-want +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
 func TestClient_Archive(t *testing.T) {
 	root, err := ioutil.TempDir("", t.Name())
 	if err != nil {
@@ -101,7 +131,7 @@ func TestClient_Archive(t *testing.T) {
 	for name, test := range tests {
 		t.Run(string(name), func(t *testing.T) {
 			if test.remote != "" {
-				if _, err := cli.RequestRepoUpdate(ctx, gitserver.Repo{Name: name, URL: test.remote}, 0); err != nil {
+				if _, err := cli.RequestRepoUpdate(ctx, gitserver.Repo{Name: name, URL: test.remote}, 0, nil); err != nil {
 					t.Fatal(err)
 				}
 			}
@@ -240,3 +270,41 @@ func createSimpleGitRepo(t *testing.T, root string) string {
 
 	return dir
 }
+
+func TestClient_Batch_unsupported(t *testing.T) {
+	// Older gitservers only support exec.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/exec", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Exec-Error, X-Exec-Exit-Status, X-Exec-Stderr")
+		_, _ = w.Write([]byte("deadbeef\n"))
+		w.Header().Set("X-Exec-Exit-Status", "0")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cli := gitserver.NewClient(&http.Client{})
+	cli.Addrs = func(ctx context.Context) []string {
+		u, _ := url.Parse(srv.URL)
+		return []string{u.Host}
+	}
+	cli.BatchDelay = 10 * time.Millisecond
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := cli.Command("git", "rev-parse", "HEAD")
+			cmd.Repo = gitserver.Repo{Name: "github.com/foo/bar"}
+			out, err := cmd.Output(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(out) != "deadbeef\n" {
+				t.Errorf("got output %q, want %q", out, "deadbeef\n")
+			}
+		}()
+	}
+	wg.Wait()
+}
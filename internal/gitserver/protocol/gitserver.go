@@ -24,6 +24,59 @@ type ExecRequest struct {
 	Opt            *RemoteOpts `json:"opt"`
 }
 
+// BatchRequest is a request to execute many read-only git commands inside a
+// git repository in a single round trip.
+type BatchRequest struct {
+	Repo api.RepoName `json:"repo"`
+
+	// URL is the repository's Git remote URL. See ExecRequest.URL.
+	URL string `json:"url,omitempty"`
+
+	Commands []BatchCommand `json:"commands"`
+}
+
+// batchCommands are the read-only git commands which can be run in a
+// BatchRequest.
+var batchCommands = map[string]bool{
+	"cat-file":  true,
+	"log":       true,
+	"ls-tree":   true,
+	"rev-parse": true,
+	"show":      true,
+}
+
+// IsBatchCommand returns true if the git command with the given args can be
+// run in a BatchRequest.
+func IsBatchCommand(args []string) bool {
+	return len(args) > 0 && batchCommands[args[0]]
+}
+
+// BatchCommand is a git command of a BatchRequest.
+type BatchCommand struct {
+	EnsureRevision string   `json:"ensureRevision"`
+	Args           []string `json:"args"`
+}
+
+// BatchResponse is the response to a BatchRequest. It contains a result for
+// every command, in the same order.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the result of a BatchCommand. The fields correspond to the
+// output and trailers of an ExecRequest.
+type BatchResult struct {
+	Stdout     []byte `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitStatus int    `json:"exitStatus"`
+	Error      string `json:"error,omitempty"`
+
+	// NotRun is true if the command was not run in the batch, because its
+	// revision has to be fetched first or its output would make the response
+	// too large. The command must be run on its own with an ExecRequest.
+	NotRun bool `json:"notRun,omitempty"`
+}
+
 // RemoteOpts configures interactions with a remote repository.
 type RemoteOpts struct {
 	SSH   *SSHConfig   `json:"ssh"`   // SSH configuration for communication with the remote
@@ -48,6 +101,34 @@ type RepoUpdateRequest struct {
 	Repo  api.RepoName  `json:"repo"`  // identifying URL for repo
 	URL   string        `json:"url"`   // repo's remote URL
 	Since time.Duration `json:"since"` // debounce interval for queries, used only with request-repo-update
+
+	// LFS configures fetching the Git LFS files of the repo. If nil, the
+	// configuration of the previous update is kept.
+	LFS *LFSOptions `json:"lfs,omitempty"`
+
+	// Fetch configures additional refs to fetch for the repo. If nil, the
+	// configuration of the previous update is kept.
+	Fetch *FetchOptions `json:"fetch,omitempty"`
+}
+
+// LFSOptions configures fetching the Git LFS files of a repository.
+type LFSOptions struct {
+	Enabled     bool  `json:"enabled"`     // whether to fetch Git LFS files
+	MaxFileSize int64 `json:"maxFileSize"` // the maximum size of a Git LFS file to fetch, 0 for no limit
+}
+
+// FetchOptions configures additional refs fetched for a repository, besides
+// the branches, tags and code review refs fetched by default.
+type FetchOptions struct {
+	Refspecs []string `json:"refspecs,omitempty"` // additional refspecs to fetch from the repo's remote URL
+	Remotes  []Remote `json:"remotes,omitempty"`  // additional remotes, such as forks, whose branches are fetched
+}
+
+// Remote is an additional remote of a repository. Its branches are fetched
+// into refs/remotes/<Name>/.
+type Remote struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
 }
 
 // RepoUpdateResponse returns meta information of the repo enqueued for
@@ -122,6 +203,20 @@ type RepoInfo struct {
 	// recloned automatically, so this time is likely to move forward
 	// periodically.
 	CloneTime *time.Time
+
+	// Maintenance is the status of the scheduled git maintenance tasks of
+	// the repository.
+	Maintenance []MaintenanceTaskStatus
+}
+
+// MaintenanceTaskStatus is the status of a git maintenance task (e.g. repacking
+// or writing the commit-graph) which gitserver runs periodically on a
+// repository.
+type MaintenanceTaskStatus struct {
+	Task    string     // the name of the task, e.g. "commit-graph"
+	LastRun *time.Time // when the task last ran, nil if it never ran
+	NextRun time.Time  // when the task is next due
+	Failed  bool       // whether the last run of the task failed
 }
 
 // RepoInfoResponse is the response to a repository information request
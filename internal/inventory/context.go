@@ -21,4 +21,15 @@ type Context struct {
 
 	// CacheSet, if set, stores the inventory in the cache for the given tree.
 	CacheSet func(os.FileInfo, Inventory)
+
+	// BlobID, if set, returns the ID of the Git blob of the given file, or "" if it is unknown. The
+	// languages detected from the contents of files are cached by blob ID.
+	BlobID func(os.FileInfo) string
+}
+
+func (c *Context) blobID(file os.FileInfo) string {
+	if c.BlobID == nil {
+		return ""
+	}
+	return c.BlobID(file)
 }
@@ -0,0 +1,161 @@
+package inventory
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/src-d/enry/v2"
+	"github.com/src-d/enry/v2/data"
+)
+
+// strategies are the strategies GetLanguage applies in order. They are those of
+// enry.DefaultStrategies, with better detection from file names and shebangs.
+var strategies = []enry.Strategy{
+	enry.GetLanguagesByModeline,
+	getLanguagesByFilename,
+	getLanguagesByShebang,
+	enry.GetLanguagesByExtension,
+	enry.GetLanguagesByContent,
+	enry.GetLanguagesByClassifier,
+}
+
+// GetLanguage returns the language of the named file with the given contents (which may be
+// truncated), or "" if it is unknown. If the file name alone is not conclusive, the language is
+// detected from the contents: a modeline, a shebang (for scripts without file extensions), and
+// finally heuristics and a classifier to choose between the languages of the file extension.
+func GetLanguage(name string, content []byte) string {
+	if language, safe := GetLanguageByFilename(name); safe || len(content) == 0 {
+		return language
+	}
+	if enry.IsBinary(content) {
+		return ""
+	}
+
+	var languages []string
+	candidates := []string{}
+	for _, strategy := range strategies {
+		languages = strategy(name, content, candidates)
+		if len(languages) == 1 {
+			return languages[0]
+		}
+		candidates = append(candidates, languages...)
+	}
+	if len(languages) == 0 {
+		return ""
+	}
+	return languages[0]
+}
+
+// getLanguagesByFilename is like enry.GetLanguagesByFilename, but it also
+// recognizes variants of well-known file names, such as "Dockerfile.dev".
+func getLanguagesByFilename(name string, _ []byte, _ []string) []string {
+	if languages := enry.GetLanguagesByFilename(name, nil, nil); len(languages) > 0 {
+		return languages
+	}
+	if base := filepath.Base(name); strings.HasPrefix(base, "Dockerfile.") {
+		if _, ok := data.LanguagesByExtension[strings.ToLower(filepath.Ext(base))]; !ok {
+			return []string{"Dockerfile"} // but not e.g. Dockerfile.md
+		}
+	}
+	return nil
+}
+
+// interpreterAliases maps the names of interpreters which enry doesn't know
+// to the names it knows.
+var interpreterAliases = map[string]string{
+	"nodejs": "node",
+}
+
+// getLanguagesByShebang is like enry.GetLanguagesByShebang, but it also
+// recognizes the shebangs that pass options or environment variables to env
+// (e.g. "#!/usr/bin/env -S node --harmony") and versioned interpreters (e.g.
+// "#!/usr/bin/ruby2.7").
+func getLanguagesByShebang(_ string, content []byte, _ []string) []string {
+	if languages := enry.GetLanguagesByShebang("", content, nil); len(languages) > 0 {
+		return languages
+	}
+	interpreter := shebangInterpreter(content)
+	if alias, ok := interpreterAliases[interpreter]; ok {
+		interpreter = alias
+	}
+	if languages := data.LanguagesByInterpreter[interpreter]; len(languages) > 0 {
+		return languages
+	}
+	return data.LanguagesByInterpreter[strings.TrimRight(interpreter, "0123456789.")]
+}
+
+// shebangInterpreter returns the name of the interpreter in the shebang line
+// of content, or "" if there is none.
+func shebangInterpreter(content []byte) string {
+	line := content
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if !bytes.HasPrefix(line, []byte("#!")) {
+		return ""
+	}
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for i := 1; i < len(fields); i++ {
+			field := fields[i]
+			if field == "-u" {
+				i++ // skip the name of the unset variable
+				continue
+			}
+			if strings.HasPrefix(field, "-") || strings.Contains(field, "=") {
+				continue
+			}
+			interpreter = filepath.Base(field)
+			break
+		}
+	}
+	return interpreter
+}
+
+// languageCacheSize is the maximum number of detected languages in languageCache.
+const languageCacheSize = 10000
+
+var (
+	// languageCache caches the languages detected from the contents of files by
+	// IDs which identify their contents. The key includes the file name because
+	// the language of the same contents can be different with another file name.
+	languageCacheMu sync.Mutex
+	languageCache   = lru.New(languageCacheSize)
+)
+
+type languageCacheKey struct {
+	contentID string
+	name      string
+}
+
+// GetLanguageCached is like GetLanguage, but it caches the language of the file
+// by contentID if it is not empty. The contentID must identify the contents of
+// the file, such as the ID of its Git blob, or a commit and the path of the
+// file in it.
+func GetLanguageCached(contentID, name string, content []byte) string {
+	if contentID == "" {
+		return GetLanguage(name, content)
+	}
+	key := languageCacheKey{contentID: contentID, name: filepath.Base(name)}
+
+	languageCacheMu.Lock()
+	v, ok := languageCache.Get(key)
+	languageCacheMu.Unlock()
+	if ok {
+		return v.(string)
+	}
+
+	language := GetLanguage(name, content)
+	languageCacheMu.Lock()
+	languageCache.Add(key, language)
+	languageCacheMu.Unlock()
+	return language
+}
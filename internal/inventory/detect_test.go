@@ -0,0 +1,122 @@
+package inventory
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGetLanguage(t *testing.T) {
+	// The files in testdata/detect are named like the files they imitate, so
+	// their paths are passed to GetLanguage as is.
+	want := map[string]string{
+		"NOTES":                 "",
+		"README.md":             "Markdown",
+		"binary":                "",
+		"docker/Dockerfile":     "Dockerfile",
+		"docker/Dockerfile.dev": "Dockerfile",
+		"docker/Dockerfile.md":  "Markdown",
+		"deploy.py":             "Python", // the file extension wins over the shebang
+		"scripts/build":         "Shell",
+		"scripts/check":         "Python",
+		"scripts/env.cgi":       "Python",
+		"scripts/manage":        "Python",
+		"scripts/migrate":       "Ruby",
+		"scripts/release":       "Ruby",
+		"scripts/serve":         "JavaScript",
+		"scripts/settings":      "Python",
+		"scripts/start":         "JavaScript",
+		"scripts/tasks":         "Ruby",
+		"view.m":                "Objective-C",
+	}
+
+	const dir = "testdata/detect"
+	got := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		got[filepath.ToSlash(name)] = GetLanguage(name, content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("languages mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetLanguageByFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantLang string
+		wantSafe bool
+	}{
+		{name: "a.go", wantLang: "Go", wantSafe: true},
+		{name: "a.md", wantLang: "Markdown", wantSafe: false},
+		{name: "Dockerfile", wantLang: "Dockerfile", wantSafe: true},
+		{name: "docker/Dockerfile.prod", wantLang: "Dockerfile", wantSafe: true},
+		{name: "CMakeLists.txt", wantLang: "CMake", wantSafe: true},
+		{name: "bin/deploy", wantLang: "", wantSafe: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lang, safe := GetLanguageByFilename(test.name)
+			if lang != test.wantLang || safe != test.wantSafe {
+				t.Errorf("got (%q, %v), want (%q, %v)", lang, safe, test.wantLang, test.wantSafe)
+			}
+		})
+	}
+}
+
+func TestShebangInterpreter(t *testing.T) {
+	tests := map[string]string{
+		"#!/bin/sh\n":                          "sh",
+		"#! /usr/bin/perl -w\n":                "perl",
+		"#!/usr/bin/env python3":               "python3",
+		"#!/usr/bin/env -S node --harmony\n":   "node",
+		"#!/usr/bin/env -u HOME LANG=C ruby\n": "ruby",
+		"#!/usr/bin/env\n":                     "",
+		"#!\n":                                 "",
+		"echo '#!/bin/sh'\n":                   "",
+	}
+	for content, want := range tests {
+		if got := shebangInterpreter([]byte(content)); got != want {
+			t.Errorf("%q: got interpreter %q, want %q", content, got, want)
+		}
+	}
+}
+
+func TestGetLanguageCached(t *testing.T) {
+	languageCache.Clear()
+	defer languageCache.Clear()
+
+	if got := GetLanguageCached("b1", "bin/run", []byte("#!/bin/sh\n")); got != "Shell" {
+		t.Fatalf("got %q, want Shell", got)
+	}
+	// The content ID identifies the contents, so a cache hit doesn't look at them.
+	if got := GetLanguageCached("b1", "other/run", []byte("#!/usr/bin/env python\n")); got != "Shell" {
+		t.Errorf("got %q for the cached blob, want Shell", got)
+	}
+	if got := GetLanguageCached("b2", "bin/run", []byte("#!/usr/bin/env python\n")); got != "Python" {
+		t.Errorf("got %q for another blob, want Python", got)
+	}
+	if got := GetLanguageCached("", "bin/run", []byte("#!/usr/bin/env python\n")); got != "Python" {
+		t.Errorf("got %q without content ID, want Python", got)
+	}
+	if n := languageCache.Len(); n != 2 {
+		t.Errorf("got %d cached languages, want 2", n)
+	}
+}
@@ -71,7 +71,7 @@ func (c *Context) tree(ctx context.Context, tree os.FileInfo, buf []byte) (inv I
 			// Don't individually cache files that we found during tree traversal. The hit rate for
 			// those cache entries is likely to be much lower than cache entries for files whose
 			// inventory was directly requested.
-			lang, err := getLang(ctx, e, buf, c.NewFileReader)
+			lang, err := getLang(ctx, e, buf, c.NewFileReader, c.blobID(e))
 			if err != nil {
 				return Inventory{}, errors.Wrapf(err, "inventory file %q", e.Name())
 			}
@@ -107,7 +107,7 @@ func (c *Context) file(ctx context.Context, file os.FileInfo, buf []byte) (inv I
 		}()
 	}
 
-	lang, err := getLang(ctx, file, buf, c.NewFileReader)
+	lang, err := getLang(ctx, file, buf, c.NewFileReader, c.blobID(file))
 	if err != nil {
 		return Inventory{}, errors.Wrapf(err, "inventory file %q", file.Name())
 	}
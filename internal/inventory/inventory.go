@@ -36,7 +36,7 @@ type Lang struct {
 
 var newLine = []byte{'\n'}
 
-func getLang(ctx context.Context, file os.FileInfo, buf []byte, getFileReader func(ctx context.Context, path string) (io.ReadCloser, error)) (Lang, error) {
+func getLang(ctx context.Context, file os.FileInfo, buf []byte, getFileReader func(ctx context.Context, path string) (io.ReadCloser, error), blobID string) (Lang, error) {
 	if file == nil {
 		return Lang{}, nil
 	}
@@ -72,7 +72,7 @@ func getLang(ctx context.Context, file os.FileInfo, buf []byte, getFileReader fu
 		if err != nil && err != io.ErrUnexpectedEOF {
 			return lang, errors.Wrap(err, "reading initial file data")
 		}
-		matchedLang = enry.GetLanguage(file.Name(), buf[:n])
+		matchedLang = GetLanguageCached(blobID, file.Name(), buf[:n])
 		lang.TotalBytes += uint64(n)
 		lang.TotalLines += uint64(bytes.Count(buf[:n], newLine))
 		lang.Name = matchedLang
@@ -125,8 +125,12 @@ func countLines(r io.Reader, buf []byte) (lineCount int, byteCount int, err erro
 }
 
 // GetLanguageByFilename returns the guessed language for the named file (and safe == true if this
-// is very likely to be correct).
+// is very likely to be correct). Use GetLanguage to detect the language of files whose names are
+// not conclusive (e.g., scripts without file extensions) from their contents.
 func GetLanguageByFilename(name string) (language string, safe bool) {
+	if languages := getLanguagesByFilename(name, nil, nil); len(languages) > 0 {
+		return languages[0], len(languages) == 1
+	}
 	language, safe = enry.GetLanguageByExtension(name)
 	if language == "GCC Machine Description" && filepath.Ext(name) == ".md" {
 		language = "Markdown" // override detection for .md
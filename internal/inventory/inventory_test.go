@@ -65,7 +65,7 @@ func TestGetLang_language(t *testing.T) {
 			lang, err := getLang(context.Background(),
 				test.file,
 				make([]byte, fileReadBufferSize),
-				makeFileReader(context.Background(), test.file.Path, test.file.Contents))
+				makeFileReader(context.Background(), test.file.Path, test.file.Contents), "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -119,6 +119,9 @@ func TestGet_readFile(t *testing.T) {
 		{file: fi{"a.java", "aaaaaaaaa"}, want: "Java"},
 		{file: fi{"b.md", "# Hello"}, want: "Markdown"},
 
+		// Scripts without file extensions are detected by their shebang.
+		{file: fi{"bin/deploy", "#!/usr/bin/env bash\nset -e\n"}, want: "Shell"},
+
 		// The .m extension is used by many languages, but this code is obviously Objective-C. This
 		// test checks that this file is detected correctly as Objective-C.
 		{
@@ -129,7 +132,7 @@ func TestGet_readFile(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.file.Name(), func(t *testing.T) {
 			fr := makeFileReader(context.Background(), test.file.(fi).Path, test.file.(fi).Contents)
-			lang, err := getLang(context.Background(), test.file, make([]byte, fileReadBufferSize), fr)
+			lang, err := getLang(context.Background(), test.file, make([]byte, fileReadBufferSize), fr, "")
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -164,7 +167,7 @@ func BenchmarkGetLang(b *testing.B) {
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
 		for _, file := range files {
-			_, err = getLang(context.Background(), file, buf, fr)
+			_, err = getLang(context.Background(), file, buf, fr, "")
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -0,0 +1 @@
+@interface X:NSObject { double x; } @property(nonatomic, readwrite) double foo;
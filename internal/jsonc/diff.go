@@ -0,0 +1,81 @@
+package jsonc
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ChangeKind describes how a JSON value differs between two documents.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// Change is a difference between two JSON documents at a single key path.
+type Change struct {
+	// Path is the path of object property names to the value. Arrays are
+	// compared as a whole, so the path never descends into an array.
+	Path []string
+	Kind ChangeKind
+	// Old is the value in the base document (nil if the value was added).
+	Old interface{}
+	// New is the value in the head document (nil if the value was removed).
+	New interface{}
+}
+
+// Diff returns the structural differences between the JSONC documents base and
+// head, ordered by key path. Differences in comments, trailing commas and
+// formatting are ignored.
+func Diff(base, head string) ([]Change, error) {
+	var baseValue, headValue interface{}
+	if err := Unmarshal(base, &baseValue); err != nil {
+		return nil, err
+	}
+	if err := Unmarshal(head, &headValue); err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	diffValues(nil, baseValue, headValue, &changes)
+	return changes, nil
+}
+
+func diffValues(path []string, base, head interface{}, changes *[]Change) {
+	baseObject, baseIsObject := base.(map[string]interface{})
+	headObject, headIsObject := head.(map[string]interface{})
+	if !baseIsObject || !headIsObject {
+		if !reflect.DeepEqual(base, head) {
+			*changes = append(*changes, Change{Path: path, Kind: ChangeChanged, Old: base, New: head})
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(baseObject)+len(headObject))
+	for k := range baseObject {
+		keys = append(keys, k)
+	}
+	for k := range headObject {
+		if _, ok := baseObject[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		// Copy the path so that sibling changes don't share a backing array.
+		keyPath := append(append(make([]string, 0, len(path)+1), path...), k)
+		baseValue, inBase := baseObject[k]
+		headValue, inHead := headObject[k]
+		switch {
+		case !inBase:
+			*changes = append(*changes, Change{Path: keyPath, Kind: ChangeAdded, New: headValue})
+		case !inHead:
+			*changes = append(*changes, Change{Path: keyPath, Kind: ChangeRemoved, Old: baseValue})
+		default:
+			diffValues(keyPath, baseValue, headValue, changes)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package jsonc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		base, head string
+		want       []Change
+	}{
+		"comments and formatting only": {
+			base: `{"a": 1}`,
+			head: `{
+  // comment
+  "a": 1,
+}`,
+			want: nil,
+		},
+		"added, removed and changed": {
+			base: `{"a": 1, "b": {"c": true, "d": "x"}, "e": [1, 2]}`,
+			head: `{"b": {"c": false, "d": "x", "f": null}, "e": [1, 2, 3], "g": {}}`,
+			want: []Change{
+				{Path: []string{"a"}, Kind: ChangeRemoved, Old: float64(1)},
+				{Path: []string{"b", "c"}, Kind: ChangeChanged, Old: true, New: false},
+				{Path: []string{"b", "f"}, Kind: ChangeAdded, New: nil},
+				{Path: []string{"e"}, Kind: ChangeChanged, Old: []interface{}{float64(1), float64(2)}, New: []interface{}{float64(1), float64(2), float64(3)}},
+				{Path: []string{"g"}, Kind: ChangeAdded, New: map[string]interface{}{}},
+			},
+		},
+		"object replaced by scalar": {
+			base: `{"a": {"b": 1}}`,
+			head: `{"a": "b"}`,
+			want: []Change{
+				{Path: []string{"a"}, Kind: ChangeChanged, Old: map[string]interface{}{"b": float64(1)}, New: "b"},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Diff(test.base, test.head)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+
+	if _, err := Diff(`{"a": `, `{}`); err == nil {
+		t.Error("got nil error for invalid JSON")
+	}
+}
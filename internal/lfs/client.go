@@ -0,0 +1,229 @@
+package lfs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+)
+
+const mediaType = "application/vnd.git-lfs+json"
+
+// batchSize is the maximum number of objects requested in one batch API
+// request.
+const batchSize = 100
+
+// Client downloads objects from Git LFS servers.
+type Client struct {
+	// Doer is used for the HTTP requests to Git LFS servers.
+	Doer httpcli.Doer
+}
+
+// Endpoint returns the URL of the Git LFS server of the Git remote with the
+// given HTTP(S) URL, without the userinfo of the remote URL. Git LFS servers
+// are located at <remote>.git/info/lfs.
+func Endpoint(remoteURL string) (*url.URL, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("Git LFS is not supported for %s remotes", u.Scheme)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(u.Path, ".git") {
+		u.Path += ".git"
+	}
+	u.Path += "/info/lfs"
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	return u, nil
+}
+
+// Download downloads the given objects from the Git LFS server of the Git
+// remote with the given HTTP(S) URL, authenticated with the userinfo of the
+// remote URL. It calls store with each object's contents. The reader passed
+// to store returns an error at EOF if the contents don't match the pointer,
+// so store must read it until EOF before storing the object.
+//
+// Download tries to download all objects, and returns the errors of all
+// objects that failed.
+func (c *Client) Download(ctx context.Context, remoteURL string, objects []Pointer, store func(Pointer, io.Reader) error) error {
+	endpoint, err := Endpoint(remoteURL)
+	if err != nil {
+		return err
+	}
+	u, _ := url.Parse(remoteURL)
+
+	var errs error
+	for len(objects) > 0 {
+		batch := objects
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		objects = objects[len(batch):]
+
+		resp, err := c.batch(ctx, endpoint, u.User, batch)
+		if err != nil {
+			return err
+		}
+
+		requested := make(map[string]Pointer, len(batch))
+		for _, p := range batch {
+			requested[p.OID] = p
+		}
+		for _, obj := range resp.Objects {
+			p, ok := requested[obj.OID]
+			if !ok {
+				continue
+			}
+			delete(requested, obj.OID)
+			if err := c.download(ctx, p, obj, store); err != nil {
+				errs = multierror.Append(errs, errors.Wrapf(err, "object %s", p.OID))
+			}
+		}
+		for oid := range requested {
+			errs = multierror.Append(errs, errors.Errorf("object %s: not returned by Git LFS server", oid))
+		}
+	}
+	return errs
+}
+
+type batchRequest struct {
+	Operation string        `json:"operation"`
+	Transfers []string      `json:"transfers"`
+	Objects   []batchObject `json:"objects"`
+}
+
+type batchObject struct {
+	OID     string `json:"oid"`
+	Size    int64  `json:"size"`
+	Actions *struct {
+		Download *struct {
+			Href   string            `json:"href"`
+			Header map[string]string `json:"header"`
+		} `json:"download"`
+	} `json:"actions,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Objects []batchObject `json:"objects"`
+}
+
+func (c *Client) batch(ctx context.Context, endpoint *url.URL, user *url.Userinfo, objects []Pointer) (*batchResponse, error) {
+	breq := batchRequest{
+		Operation: "download",
+		Transfers: []string{"basic"},
+		Objects:   make([]batchObject, 0, len(objects)),
+	}
+	for _, p := range objects {
+		breq.Objects = append(breq.Objects, batchObject{OID: p.OID, Size: p.Size})
+	}
+	body, err := json.Marshal(breq)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", endpoint.String()+"/objects/batch", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", mediaType)
+	req.Header.Set("Content-Type", mediaType)
+	if user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+
+	resp, err := c.Doer.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, errors.Errorf("Git LFS batch request failed with status %d: %s", resp.StatusCode, msg)
+	}
+
+	var bresp batchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bresp); err != nil {
+		return nil, errors.Wrap(err, "invalid Git LFS batch response")
+	}
+	return &bresp, nil
+}
+
+func (c *Client) download(ctx context.Context, p Pointer, obj batchObject, store func(Pointer, io.Reader) error) error {
+	if obj.Error != nil {
+		return errors.Errorf("Git LFS server error %d: %s", obj.Error.Code, obj.Error.Message)
+	}
+	if obj.Actions == nil || obj.Actions.Download == nil {
+		return errors.New("Git LFS server returned no download action")
+	}
+
+	// 🚨 SECURITY: The download URL may be on a different host than the Git
+	// LFS server, so we only send the headers that the server told us to.
+	req, err := http.NewRequest("GET", obj.Actions.Download.Href, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range obj.Actions.Download.Header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.Doer.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("download failed with status %d", resp.StatusCode)
+	}
+
+	return store(p, &verifyingReader{
+		r:    io.LimitReader(resp.Body, p.Size+1),
+		want: p,
+		hash: sha256.New(),
+	})
+}
+
+// verifyingReader returns an error at EOF if the contents read don't match
+// the pointer.
+type verifyingReader struct {
+	r    io.Reader
+	want Pointer
+	hash hash.Hash
+	n    int64
+}
+
+func (v *verifyingReader) Read(b []byte) (int, error) {
+	n, err := v.r.Read(b)
+	v.n += int64(n)
+	v.hash.Write(b[:n])
+	if err == io.EOF {
+		if v.n != v.want.Size {
+			return n, fmt.Errorf("got %d bytes, want %d", v.n, v.want.Size)
+		}
+		if oid := hex.EncodeToString(v.hash.Sum(nil)); oid != v.want.OID {
+			return n, fmt.Errorf("got contents with SHA-256 hash %s", oid)
+		}
+	}
+	return n, err
+}
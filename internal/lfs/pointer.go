@@ -0,0 +1,84 @@
+// Package lfs implements the parts of Git LFS that are needed to show the
+// contents of files stored in Git LFS: parsing pointer files and downloading
+// objects with the Git LFS batch API.
+//
+// See https://github.com/git-lfs/git-lfs/blob/master/docs/spec.md.
+package lfs
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// MaxPointerSize is the maximum size of a Git LFS pointer file in bytes.
+const MaxPointerSize = 1024
+
+const pointerVersion = "https://git-lfs.github.com/spec/v1"
+
+// A Pointer identifies a file stored in Git LFS. Git stores pointers in place
+// of the file contents.
+type Pointer struct {
+	OID  string // the hex encoded SHA-256 hash of the file contents
+	Size int64  // the size of the file in bytes
+}
+
+// ParsePointer parses the contents of a Git LFS pointer file. It returns
+// false if b is not a pointer file.
+func ParsePointer(b []byte) (*Pointer, bool) {
+	if len(b) > MaxPointerSize || !bytes.HasSuffix(b, []byte("\n")) {
+		return nil, false
+	}
+
+	var (
+		p       Pointer
+		hasSize bool
+	)
+	for i, line := range strings.Split(string(b[:len(b)-1]), "\n") {
+		kv := strings.SplitN(line, " ", 2)
+		if len(kv) != 2 {
+			return nil, false
+		}
+		key, value := kv[0], kv[1]
+
+		// The version must be the first key.
+		if i == 0 {
+			if key != "version" || value != pointerVersion {
+				return nil, false
+			}
+			continue
+		}
+
+		switch key {
+		case "oid":
+			oid := strings.TrimPrefix(value, "sha256:")
+			if oid == value || !isSHA256(oid) {
+				return nil, false
+			}
+			p.OID = oid
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || size < 0 {
+				return nil, false
+			}
+			p.Size, hasSize = size, true
+		}
+	}
+
+	if p.OID == "" || !hasSize {
+		return nil, false
+	}
+	return &p, true
+}
+
+func isSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
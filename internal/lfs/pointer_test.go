@@ -0,0 +1,56 @@
+package lfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePointer(t *testing.T) {
+	const oid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	tests := map[string]struct {
+		contents string
+		want     *Pointer
+	}{
+		"pointer": {
+			contents: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345\n",
+			want:     &Pointer{OID: oid, Size: 12345},
+		},
+		"unknown keys": {
+			contents: "version https://git-lfs.github.com/spec/v1\next-0-foo sha256:" + oid + "\noid sha256:" + oid + "\nsize 0\n",
+			want:     &Pointer{OID: oid, Size: 0},
+		},
+		"empty": {},
+		"no trailing newline": {
+			contents: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize 12345",
+		},
+		"version not first": {
+			contents: "oid sha256:" + oid + "\nversion https://git-lfs.github.com/spec/v1\nsize 12345\n",
+		},
+		"unknown version": {
+			contents: "version https://git-lfs.github.com/spec/v2\noid sha256:" + oid + "\nsize 12345\n",
+		},
+		"missing size": {
+			contents: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\n",
+		},
+		"invalid oid": {
+			contents: "version https://git-lfs.github.com/spec/v1\noid sha256:abc\nsize 12345\n",
+		},
+		"invalid size": {
+			contents: "version https://git-lfs.github.com/spec/v1\noid sha256:" + oid + "\nsize -1\n",
+		},
+		"text file": {
+			contents: "hello world\n",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := ParsePointer([]byte(test.contents))
+			if ok != (test.want != nil) {
+				t.Fatalf("got ok %v, want %v", ok, test.want != nil)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -348,6 +348,30 @@ func (c *Client) StatusMessages(ctx context.Context) (*protocol.StatusMessagesRe
 	return &res, nil
 }
 
+// PushWebhook forwards a push webhook request that a code host sent to the frontend to
+// repo-updater, which authenticates it and updates the pushed repository. The codeHost is
+// the name of the code host's push webhook endpoint, such as "github". The caller must
+// close the response body.
+func (c *Client) PushWebhook(ctx context.Context, codeHost string, r *http.Request) (*http.Response, error) {
+	u := c.URL + "/push-webhooks/" + url.PathEscape(codeHost)
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+
+	req, err := http.NewRequest("POST", u, r.Body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.Header {
+		if k == "Cookie" || k == "Authorization" {
+			continue
+		}
+		req.Header[k] = v
+	}
+
+	return c.do(ctx, req)
+}
+
 func (c *Client) httpPost(ctx context.Context, method string, payload interface{}) (resp *http.Response, err error) {
 	reqBody, err := json.Marshal(payload)
 	if err != nil {
@@ -8,16 +8,20 @@ var allFields = map[string]struct{}{
 	"r":                     empty,
 	FieldRepoGroup:          empty,
 	"g":                     empty,
+	FieldRepoSet:            empty,
 	FieldFile:               empty,
 	"f":                     empty,
 	FieldFork:               empty,
 	FieldArchived:           empty,
+	FieldSubmodules:         empty,
 	FieldLang:               empty,
 	"l":                     empty,
 	"language":              empty,
 	FieldType:               empty,
 	FieldPatternType:        empty,
 	FieldContent:            empty,
+	FieldContext:            empty,
+	FieldRev:                empty,
 	FieldRepoHasFile:        empty,
 	FieldRepoHasCommitAfter: empty,
 	FieldBefore:             empty,
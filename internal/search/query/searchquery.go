@@ -4,6 +4,8 @@ package query
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/sourcegraph/sourcegraph/internal/search/query/syntax"
@@ -16,9 +18,11 @@ const (
 	FieldCase               = "case"
 	FieldRepo               = "repo"
 	FieldRepoGroup          = "repogroup"
+	FieldRepoSet            = "reposet"
 	FieldFile               = "file"
 	FieldFork               = "fork"
 	FieldArchived           = "archived"
+	FieldSubmodules         = "submodules"
 	FieldLang               = "lang"
 	FieldType               = "type"
 	FieldRepoHasFile        = "repohasfile"
@@ -26,6 +30,8 @@ const (
 	FieldPatternType        = "patterntype"
 	FieldContent            = "content"
 	FieldVisibility         = "visibility"
+	FieldContext            = "context" // Number of lines of context returned around matched lines.
+	FieldRev                = "rev"     // Comparison of two revisions (base...head) whose changed lines are searched.
 
 	// For diff and commit search only:
 	FieldBefore    = "before"
@@ -54,14 +60,18 @@ var (
 			FieldCase:        {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
 			FieldRepo:        regexpNegatableFieldType,
 			FieldRepoGroup:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldRepoSet:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldFile:        regexpNegatableFieldType,
 			FieldFork:        {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldArchived:    {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldSubmodules:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldLang:        {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
 			FieldType:        stringFieldType,
 			FieldPatternType: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldContent:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldVisibility:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldContext:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldRev:         {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 
 			FieldRepoHasFile:        regexpNegatableFieldType,
 			FieldRepoHasCommitAfter: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
@@ -173,10 +183,52 @@ func Validate(q QueryInfo, searchType SearchType) error {
 		if q.Fields()[FieldType] != nil && processSearchPattern(q) != "" {
 			return errors.New(`the parameter "type:" is not valid for structural search, search is always performed on file content`)
 		}
+		if q.Fields()[FieldRev] != nil {
+			return errors.New(`the parameter "rev:" is not valid for structural search, only the files of a single revision can be searched`)
+		}
+	}
+	if value, _ := q.StringValue(FieldContext); value != "" {
+		if _, err := ParseContextLines(value); err != nil {
+			return err
+		}
+	}
+	if value, _ := q.StringValue(FieldRev); value != "" {
+		if _, _, err := ParseRevisionRange(value); err != nil {
+			return err
+		}
+		if q.Fields()[FieldType] != nil {
+			return errTypeWithRev
+		}
 	}
 	return nil
 }
 
+var errTypeWithRev = errors.New(`the parameter "type:" is not valid with "rev:", only the lines changed between the revisions are searched`)
+
+// MaxContextLines is the maximum value of the "context:" field.
+const MaxContextLines = 20
+
+// ParseContextLines parses the value of the "context:" field, the number of
+// lines before and after each matched line that are returned.
+func ParseContextLines(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > MaxContextLines {
+		return 0, fmt.Errorf("field %s requires a number between 0 and %d", FieldContext, MaxContextLines)
+	}
+	return n, nil
+}
+
+// ParseRevisionRange parses the value of the "rev:" field, a comparison of
+// two revisions written base...head. As in the comparison view, the changed
+// lines are the ones between the merge base of base and head, and head.
+func ParseRevisionRange(value string) (base, head string, err error) {
+	parts := strings.Split(value, "...")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || strings.HasPrefix(parts[0], "-") || strings.HasPrefix(parts[1], "-") {
+		return "", "", fmt.Errorf("field %s requires a comparison of two revisions, such as %s:main...my-branch", FieldRev, FieldRev)
+	}
+	return parts[0], parts[1], nil
+}
+
 // Process is a top level convenience function for processing a raw string into
 // a validated and type checked query, and the parse tree of the raw string.
 func Process(queryString string, searchType SearchType) (QueryInfo, error) {
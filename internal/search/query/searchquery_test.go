@@ -163,6 +163,36 @@ func TestQuery_Validate(t *testing.T) {
 			SearchType: SearchTypeStructural,
 			Want:       "",
 		},
+		{
+			Name:       `Context lines must be in range`,
+			Query:      `foo context:21`,
+			SearchType: SearchTypeLiteral,
+			Want:       "field context requires a number between 0 and 20",
+		},
+		{
+			Name:       `Rev must be a comparison`,
+			Query:      `foo rev:main`,
+			SearchType: SearchTypeLiteral,
+			Want:       "field rev requires a comparison of two revisions, such as rev:main...my-branch",
+		},
+		{
+			Name:       `Rev comparison`,
+			Query:      `foo rev:v1.0...v2.0`,
+			SearchType: SearchTypeLiteral,
+			Want:       "",
+		},
+		{
+			Name:       `Rev incompatible with "type:"`,
+			Query:      `foo rev:v1.0...v2.0 type:diff`,
+			SearchType: SearchTypeLiteral,
+			Want:       `the parameter "type:" is not valid with "rev:", only the lines changed between the revisions are searched`,
+		},
+		{
+			Name:       `Structural search incompatible with rev`,
+			Query:      `foo rev:v1.0...v2.0`,
+			SearchType: SearchTypeStructural,
+			Want:       `the parameter "rev:" is not valid for structural search, only the files of a single revision can be searched`,
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.Name, func(t *testing.T) {
@@ -203,7 +203,8 @@ func (q AndOrQuery) valueToTypedValue(field, value string, label labels) []*type
 		return []*types.Value{{Regexp: parseRegexpOrPanic(field, value)}}
 
 	case
-		FieldRepoGroup, "g":
+		FieldRepoGroup, "g",
+		FieldRepoSet:
 		return []*types.Value{{String: &value}}
 
 	case
@@ -213,10 +214,13 @@ func (q AndOrQuery) valueToTypedValue(field, value string, label labels) []*type
 	case
 		FieldFork,
 		FieldArchived,
+		FieldSubmodules,
 		FieldLang, "l", "language",
 		FieldType,
 		FieldPatternType,
-		FieldContent:
+		FieldContent,
+		FieldContext,
+		FieldRev:
 		return []*types.Value{{String: &value}}
 
 	case FieldRepoHasFile:
@@ -219,6 +219,16 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		return nil
 	}
 
+	isContextLines := func() error {
+		_, err := ParseContextLines(value)
+		return err
+	}
+
+	isRevisionRange := func() error {
+		_, _, err := ParseRevisionRange(value)
+		return err
+	}
+
 	isLanguage := func() error {
 		_, ok := enry.GetLanguageByAlias(value)
 		if !ok {
@@ -251,14 +261,16 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldRepo:
 		return satisfies(isValidRegexp)
 	case
-		FieldRepoGroup:
+		FieldRepoGroup,
+		FieldRepoSet:
 		return satisfies(isSingular, isNotNegated)
 	case
 		FieldFile:
 		return satisfies(isValidRegexp)
 	case
 		FieldFork,
-		FieldArchived:
+		FieldArchived,
+		FieldSubmodules:
 		return satisfies(isSingular, isNotNegated)
 	case
 		FieldLang:
@@ -270,6 +282,12 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldPatternType,
 		FieldContent:
 		return satisfies(isSingular, isNotNegated)
+	case
+		FieldContext:
+		return satisfies(isSingular, isContextLines, isNotNegated)
+	case
+		FieldRev:
+		return satisfies(isSingular, isRevisionRange, isNotNegated)
 	case
 		FieldRepoHasFile:
 		return satisfies(isValidRegexp)
@@ -316,5 +334,13 @@ func validate(nodes []Node) error {
 		err = validateField(field, value, negated, seen)
 		seen[field] = struct{}{}
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	_, hasType := seen[FieldType]
+	_, hasRev := seen[FieldRev]
+	if hasType && hasRev {
+		return errTypeWithRev
+	}
+	return nil
 }
@@ -21,6 +21,18 @@ func TestAndOrQuery_Validation(t *testing.T) {
 			input: "repo:[",
 			want:  "error parsing regexp: missing closing ]: `[`",
 		},
+		{
+			input: "reposet:a/b reposet:a/c",
+			want:  `field "reposet" may not be used more than once`,
+		},
+		{
+			input: "-reposet:a/b",
+			want:  `field "reposet" does not support negation`,
+		},
+		{
+			input: "-submodules:yes",
+			want:  `field "submodules" does not support negation`,
+		},
 		{
 			input: "-index:yes",
 			want:  `field "index" does not support negation`,
@@ -45,6 +57,26 @@ func TestAndOrQuery_Validation(t *testing.T) {
 			input: "count:-1",
 			want:  "field count requires a positive number",
 		},
+		{
+			input: "context:many",
+			want:  "field context requires a number between 0 and 20",
+		},
+		{
+			input: "context:1 context:2",
+			want:  `field "context" may not be used more than once`,
+		},
+		{
+			input: "rev:main..dev",
+			want:  "field rev requires a comparison of two revisions, such as rev:main...my-branch",
+		},
+		{
+			input: "-rev:main...dev",
+			want:  `field "rev" does not support negation`,
+		},
+		{
+			input: "foo rev:main...dev type:commit",
+			want:  `the parameter "type:" is not valid with "rev:", only the lines changed between the revisions are searched`,
+		},
 	}
 	for _, c := range cases {
 		t.Run("validate and/or query", func(t *testing.T) {
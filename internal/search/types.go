@@ -66,6 +66,10 @@ type SymbolsParameters struct {
 
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// Offset is the number of symbols to skip before the first returned
+	// symbol. It is used to page through all the symbols of a commit.
+	Offset int
 }
 
 // TextParameters are the parameters passed to a search backend. It contains the Pattern
@@ -129,6 +133,16 @@ type TextPatternInfo struct {
 	PatternMatchesPath    bool
 
 	Languages []string
+
+	// LangIncludePatterns are the patterns of IncludePatterns which come from
+	// the lang: filters. Searcher also searches the files which don't match
+	// them if the language detected from their contents is one of Languages
+	// (e.g., scripts without file extensions).
+	LangIncludePatterns []string
+
+	// ContextLines is the number of lines of context returned around each
+	// matched line.
+	ContextLines int32
 }
 
 func (p *TextPatternInfo) String() string {
@@ -161,6 +175,9 @@ func (p *TextPatternInfo) String() string {
 	for _, lang := range p.Languages {
 		args = append(args, fmt.Sprintf("lang:%s", lang))
 	}
+	if p.ContextLines > 0 {
+		args = append(args, fmt.Sprintf("context:%d", p.ContextLines))
+	}
 
 	for _, inc := range p.FilePatternsReposMustInclude {
 		args = append(args, fmt.Sprintf("repositoryPathPattern:%s", inc))
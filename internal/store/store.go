@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -23,6 +24,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
 	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
+	"github.com/sourcegraph/sourcegraph/internal/textencoding"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 
 	"github.com/opentracing/opentracing-go/ext"
@@ -34,6 +36,11 @@ import (
 // than this are searched.
 const maxFileSize = 1 << 20 // 1MB; match https://sourcegraph.com/search?q=repo:%5Egithub%5C.com/sourcegraph/zoekt%24+%22-file_limit%22
 
+// archiveVersion is part of the cache key of archives. It is incremented when
+// the contents of the archives change, so that archives from older versions
+// of the store are not used.
+const archiveVersion = 2
+
 // Store manages the fetching and storing of git archives. Its main purpose is
 // keeping a local disk cache of the fetched archives to help speed up future
 // requests for the same archive. As a performance optimization, it is also
@@ -53,8 +60,9 @@ const maxFileSize = 1 << 20 // 1MB; match https://sourcegraph.com/search?q=repo:
 type Store struct {
 	// FetchTar returns an io.ReadCloser to a tar archive of a repository at the specified Git
 	// remote URL and commit ID. If the error implements "BadRequest() bool", it will be used to
-	// determine if the error is a bad request (eg invalid repo).
-	FetchTar func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error)
+	// determine if the error is a bad request (eg invalid repo). The archive may include files
+	// which don't pass the filter, so callers must still check the files they read.
+	FetchTar func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error)
 
 	// Path is the directory to store the cache
 	Path string
@@ -136,7 +144,7 @@ func (s *Store) PrepareZip(ctx context.Context, repo gitserver.Repo, commit api.
 	largeFilePatterns := conf.Get().SearchLargeFiles
 
 	// key is a sha256 hash since we want to use it for the disk name
-	h := sha256.Sum256([]byte(fmt.Sprintf("%q %q %q", repo.Name, commit, largeFilePatterns)))
+	h := sha256.Sum256([]byte(fmt.Sprintf("%q %q %q %d", repo.Name, commit, largeFilePatterns, archiveVersion)))
 	key := hex.EncodeToString(h[:])
 	span.LogKV("key", key)
 
@@ -223,7 +231,13 @@ func (s *Store) fetch(ctx context.Context, repo gitserver.Repo, commit api.Commi
 		}
 	}()
 
-	r, err := s.FetchTar(ctx, repo, commit)
+	// Large files are kept with empty contents, so we can still search their
+	// names without downloading them.
+	var filter gitserver.ArchiveFilter
+	if len(largeFilePatterns) == 0 {
+		filter.MaxFileSize = maxFileSize
+	}
+	r, err := s.FetchTar(ctx, repo, commit, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -255,6 +269,11 @@ func (s *Store) fetch(ctx context.Context, repo gitserver.Repo, commit api.Commi
 // copySearchable copies searchable files from tr to zw. A searchable file is
 // any file that is a candidate for being searched (under size limit and
 // non-binary).
+//
+// Files in a text encoding other than UTF-8 are transcoded to UTF-8, and
+// their zip header comment is the name of their original encoding (see
+// ZipFile.Encodings). Line numbers and character offsets in the transcoded
+// file are the same as in the original file.
 func copySearchable(tr *tar.Reader, zw *zip.Writer, largeFilePatterns []string) error {
 	// 32*1024 is the same size used by io.Copy
 	buf := make([]byte, 32*1024)
@@ -279,35 +298,56 @@ func copySearchable(tr *tar.Reader, zw *zip.Writer, largeFilePatterns []string)
 			continue
 		}
 
-		// We are happy with the file, so we can write it to zw.
-		w, err := zw.CreateHeader(&zip.FileHeader{
-			Name:   hdr.Name,
-			Method: zip.Store,
-		})
-		if err != nil {
-			return err
-		}
-
 		n, err := tr.Read(buf)
-		switch err {
-		case io.EOF:
-			if n == 0 {
-				continue
-			}
-		case nil:
-		default:
+		if err != nil && err != io.EOF {
 			return err
 		}
 
 		// We do not search the content of large files unless they are
 		// whitelisted.
-		if hdr.Size > maxFileSize && !ignoreSizeMax(hdr.Name, largeFilePatterns) {
+		searchContent := n > 0 && (hdr.Size <= maxFileSize || ignoreSizeMax(hdr.Name, largeFilePatterns))
+
+		// Heuristic: Detect the encoding of the file from its first bytes
+		// (up to 32KB). Files with a NUL byte in them and no byte order mark
+		// are binary. We only search names of binary files, and of files
+		// which can't be transcoded.
+		var enc string
+		if searchContent {
+			enc = textencoding.Detect(buf[:n])
+			searchContent = enc != ""
+		}
+		transcode := searchContent && enc != textencoding.UTF8
+
+		var transcoded []byte
+		if transcode {
+			content, err := ioutil.ReadAll(io.MultiReader(bytes.NewReader(buf[:n]), tr))
+			if err != nil {
+				return err
+			}
+			transcoded, err = textencoding.ToUTF8(content, enc)
+			searchContent = err == nil
+		}
+
+		// We are happy with the file, so we can write it to zw.
+		fh := &zip.FileHeader{
+			Name:   hdr.Name,
+			Method: zip.Store,
+		}
+		if transcode && searchContent {
+			fh.Comment = enc
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		if !searchContent {
 			continue
 		}
 
-		// Heuristic: Assume file is binary if first 256 bytes contain a
-		// 0x00. Best effort, so ignore err. We only search names of binary files.
-		if n > 0 && bytes.IndexByte(buf[:n], 0x00) >= 0 {
+		if transcode {
+			if _, err := w.Write(transcoded); err != nil {
+				return err
+			}
 			continue
 		}
 
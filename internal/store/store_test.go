@@ -2,11 +2,14 @@ package store
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"context"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -26,12 +29,14 @@ func TestPrepareZip(t *testing.T) {
 	returnFetch := make(chan struct{})
 	var gotRepo gitserver.Repo
 	var gotCommit api.CommitID
+	var gotFilter gitserver.ArchiveFilter
 	var fetchZipCalled int64
-	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
 		<-returnFetch
 		atomic.AddInt64(&fetchZipCalled, 1)
 		gotRepo = repo
 		gotCommit = commit
+		gotFilter = filter
 		return emptyTar(t), nil
 	}
 
@@ -60,6 +65,9 @@ func TestPrepareZip(t *testing.T) {
 	if gotRepo != wantRepo {
 		t.Errorf("fetched wrong repo. got=%v want=%v", gotRepo, wantRepo)
 	}
+	if gotFilter.MaxFileSize != maxFileSize {
+		t.Errorf("fetched with wrong max file size. got=%v want=%v", gotFilter.MaxFileSize, maxFileSize)
+	}
 
 	// Wait for item to appear on disk cache, then test again to ensure we
 	// use the disk cache.
@@ -85,7 +93,7 @@ func TestPrepareZip_fetchTarFail(t *testing.T) {
 	fetchErr := errors.New("test")
 	s, cleanup := tmpStore(t)
 	defer cleanup()
-	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
 		return nil, fetchErr
 	}
 	_, err := s.PrepareZip(context.Background(), gitserver.Repo{Name: "foo"}, "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef")
@@ -97,7 +105,7 @@ func TestPrepareZip_fetchTarFail(t *testing.T) {
 func TestPrepareZip_errHeader(t *testing.T) {
 	s, cleanup := tmpStore(t)
 	defer cleanup()
-	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
 		buf := new(bytes.Buffer)
 		w := tar.NewWriter(buf)
 		w.Flush()
@@ -148,6 +156,66 @@ func TestIngoreSizeMax(t *testing.T) {
 	}
 }
 
+func TestCopySearchable_encodings(t *testing.T) {
+	const text = "// こんにちは世界\nfunc main() {\n\tprintln(\"héllo wörld\")\n}\n"
+	fixtures := []string{"utf8.txt", "utf16le-bom.txt", "utf16be-bom.txt", "utf16be.txt", "windows-1252.txt", "binary.bin"}
+
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, name := range fixtures {
+		content, err := ioutil.ReadFile(filepath.Join("..", "textencoding", "testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zipBuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zipBuf)
+	if err := copySearchable(tar.NewReader(buf), zw, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf, err := MockZipFile(zipBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := map[string]string{}
+	for i := range zf.Files {
+		contents[zf.Files[i].Name] = string(zf.DataFor(&zf.Files[i]))
+	}
+	wantContents := map[string]string{
+		"utf8.txt":         text,
+		"utf16le-bom.txt":  text,
+		"utf16be-bom.txt":  text,
+		"utf16be.txt":      "", // binary, since it has NUL bytes and no byte order mark
+		"windows-1252.txt": "// Grüße aus Köln\nfunc main() {\n\tprintln(\"héllo wörld\")\n}\n",
+		"binary.bin":       "",
+	}
+	if !reflect.DeepEqual(contents, wantContents) {
+		t.Errorf("got contents %q, want %q", contents, wantContents)
+	}
+	wantEncodings := map[string]string{
+		"utf16le-bom.txt":  "UTF-16LE",
+		"utf16be-bom.txt":  "UTF-16BE",
+		"windows-1252.txt": "windows-1252",
+	}
+	if !reflect.DeepEqual(zf.Encodings, wantEncodings) {
+		t.Errorf("got encodings %v, want %v", zf.Encodings, wantEncodings)
+	}
+}
+
 func tmpStore(t *testing.T) (*Store, func()) {
 	d, err := ioutil.TempDir("", "store_test")
 	if err != nil {
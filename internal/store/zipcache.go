@@ -100,8 +100,11 @@ type ZipFile struct {
 	Files  []SrcFile
 	MaxLen int
 	Data   []byte
-	f      *os.File
-	wg     sync.WaitGroup // ensures underlying file is not munmap'd or closed while in use
+	// Encodings maps the names of the files which were transcoded to UTF-8
+	// to their original encoding. It is nil if all files are UTF-8.
+	Encodings map[string]string
+	f         *os.File
+	wg        sync.WaitGroup // ensures underlying file is not munmap'd or closed while in use
 }
 
 func readZipFile(path string) (*ZipFile, error) {
@@ -153,6 +156,12 @@ func (f *ZipFile) PopulateFiles(r *zip.Reader) error {
 			return errors.Errorf("file %s has size > 2gb: %v", file.Name, size)
 		}
 		f.Files[i] = SrcFile{Name: file.Name, Off: off, Len: int32(size)}
+		if file.Comment != "" {
+			if f.Encodings == nil {
+				f.Encodings = map[string]string{}
+			}
+			f.Encodings[file.Name] = file.Comment
+		}
 		if size > f.MaxLen {
 			f.MaxLen = size
 		}
@@ -16,7 +16,7 @@ func TestZipCacheDelete(t *testing.T) {
 	s, cleanup := tmpStore(t)
 	defer cleanup()
 
-	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, filter gitserver.ArchiveFilter) (io.ReadCloser, error) {
 		return emptyTar(t), nil
 	}
 
@@ -35,6 +35,10 @@ type SearchArgs struct {
 
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// Offset is the number of symbols to skip before the first returned
+	// symbol. It is used to page through all the symbols of a commit.
+	Offset int
 }
 
 // SearchResult is the result of a search on the symbols service.
@@ -16,7 +16,7 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 )
 
-func FetchTarFromGithub(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+func FetchTarFromGithub(ctx context.Context, repo gitserver.Repo, commit api.CommitID, _ gitserver.ArchiveFilter) (io.ReadCloser, error) {
 	// key is a sha256 hash since we want to use it for the disk name
 	h := sha256.Sum256([]byte(string(repo.Name) + " " + string(commit)))
 	key := hex.EncodeToString(h[:])
@@ -0,0 +1,180 @@
+// Package textencoding detects the encoding of text files and transcodes
+// them to UTF-8.
+package textencoding
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// The names of the encodings Detect returns.
+const (
+	UTF8     = "UTF-8"
+	UTF16LE  = "UTF-16LE"
+	UTF16BE  = "UTF-16BE"
+	ShiftJIS = "Shift_JIS"
+	// Windows1252 is a superset of Latin-1 (ISO-8859-1), which is used
+	// for text which isn't valid in any of the other encodings.
+	Windows1252 = "windows-1252"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// binarySniffLen is the number of bytes at the start of content in which a
+// NUL byte makes Detect consider content binary.
+const binarySniffLen = 32 * 1024
+
+// Detect returns the encoding of content, based on its byte order mark or on
+// heuristics. It returns "" if content is binary.
+//
+// Content is only detected as an encoding other than UTF-8 if it has a byte
+// order mark, or if it is mostly invalid UTF-8. Text which is mostly UTF-8
+// (e.g. a UTF-8 file with a stray Latin-1 character) is detected as UTF-8, so
+// that it isn't transcoded. Content without byte order mark which has a NUL
+// byte in its first 32KB is binary.
+//
+// content may be a prefix of a file: a rune cut at the end of content
+// doesn't make it invalid.
+func Detect(content []byte) string {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return UTF8
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return UTF16BE
+	}
+
+	sniff := content
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+	if bytes.IndexByte(sniff, 0x00) >= 0 {
+		return ""
+	}
+	if mostlyUTF8(content) {
+		return UTF8
+	}
+	if hasBinaryBytes(content) {
+		return ""
+	}
+	if isShiftJIS(content) {
+		return ShiftJIS
+	}
+	return Windows1252
+}
+
+// ToUTF8 transcodes content from the encoding enc (as returned by Detect) to
+// UTF-8. A byte order mark is removed.
+func ToUTF8(content []byte, enc string) ([]byte, error) {
+	var e encoding.Encoding
+	switch enc {
+	case UTF8:
+		return bytes.TrimPrefix(content, bomUTF8), nil
+	case UTF16LE:
+		e = unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case UTF16BE:
+		e = unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case ShiftJIS:
+		e = japanese.ShiftJIS
+	case Windows1252:
+		e = charmap.Windows1252
+	default:
+		return nil, errors.Errorf("unsupported encoding %q", enc)
+	}
+	decoded, err := e.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", enc)
+	}
+	return decoded, nil
+}
+
+// Decode detects the encoding of content and transcodes it to UTF-8. It
+// returns content unchanged and an empty encoding if content is binary or
+// can't be decoded.
+func Decode(content []byte) (decoded []byte, enc string) {
+	enc = Detect(content)
+	if enc == "" {
+		return content, ""
+	}
+	decoded, err := ToUTF8(content, enc)
+	if err != nil {
+		return content, ""
+	}
+	return decoded, enc
+}
+
+// mostlyUTF8 reports whether content is valid UTF-8, or UTF-8 with a few
+// invalid bytes: it has at least as many (valid) multi-byte runes as invalid
+// bytes. An incomplete rune at the end of content is ignored.
+func mostlyUTF8(content []byte) bool {
+	var multiByte, invalid int
+	for len(content) > 0 {
+		r, size := utf8.DecodeRune(content)
+		if r == utf8.RuneError && size == 1 {
+			if !utf8.FullRune(content) {
+				break
+			}
+			invalid++
+		} else if size > 1 {
+			multiByte++
+		}
+		content = content[size:]
+	}
+	return invalid == 0 || multiByte >= invalid
+}
+
+// hasBinaryBytes reports whether content contains control characters which
+// don't appear in text files. They are the same as the ones
+// http.DetectContentType looks for.
+func hasBinaryBytes(content []byte) bool {
+	for _, b := range content {
+		switch {
+		case b <= 0x08, b == 0x0B, 0x0E <= b && b <= 0x1A, 0x1C <= b && b <= 0x1F:
+			return true
+		}
+	}
+	return false
+}
+
+// isShiftJIS reports whether content is valid Shift_JIS text which looks
+// Japanese. The trailing byte of most Japanese characters (e.g. of all
+// hiragana) is not ASCII, while Latin-1 text which happens to be valid
+// Shift_JIS has mostly letters after its non-ASCII characters.
+func isShiftJIS(content []byte) bool {
+	var double, nonASCIITrail int
+	for i := 0; i < len(content); i++ {
+		b := content[i]
+		switch {
+		case b < 0x80, 0xA1 <= b && b <= 0xDF:
+			// ASCII or half-width katakana.
+		case 0x81 <= b && b <= 0x9F, 0xE0 <= b && b <= 0xFC:
+			if i+1 == len(content) {
+				// A character cut at the end of content.
+				break
+			}
+			i++
+			t := content[i]
+			if t < 0x40 || t == 0x7F || t > 0xFC {
+				return false
+			}
+			double++
+			if t >= 0x80 {
+				nonASCIITrail++
+			}
+		default:
+			return false
+		}
+	}
+	return double > 0 && nonASCIITrail*2 > double
+}
@@ -0,0 +1,83 @@
+package textencoding
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	const (
+		text     = "// こんにちは世界\nfunc main() {\n\tprintln(\"héllo wörld\")\n}\n"
+		japanese = "// こんにちは世界\nfunc main() {\n\tprintln(\"ハロー\")\n}\n"
+		latin    = "// Grüße aus Köln\nfunc main() {\n\tprintln(\"héllo wörld\")\n}\n"
+	)
+	tests := []struct {
+		file     string
+		wantEnc  string
+		wantText string
+	}{
+		{"utf8.txt", UTF8, text},
+		{"utf8-bom.txt", UTF8, text},
+		{"utf16le-bom.txt", UTF16LE, text},
+		{"utf16be-bom.txt", UTF16BE, text},
+		{"utf16le.txt", "", ""}, // UTF-16 without byte order mark has NUL bytes
+		{"utf16be.txt", "", ""},
+		{"shift_jis.txt", ShiftJIS, japanese},
+		{"windows-1252.txt", Windows1252, latin},
+		{"binary.bin", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			content, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, enc := Decode(content)
+			if enc != test.wantEnc {
+				t.Errorf("got encoding %q, want %q", enc, test.wantEnc)
+			}
+			if enc == "" {
+				if string(decoded) != string(content) {
+					t.Error("binary content was modified")
+				}
+				return
+			}
+			if string(decoded) != test.wantText {
+				t.Errorf("got text %q, want %q", decoded, test.wantText)
+			}
+		})
+	}
+}
+
+func TestDetect_prefix(t *testing.T) {
+	// A rune cut at the end of a prefix of UTF-8 text.
+	content := []byte("hello 世界")
+	if enc := Detect(content[:len(content)-1]); enc != UTF8 {
+		t.Errorf("got encoding %q, want %q", enc, UTF8)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]struct {
+		content []byte
+		want    string
+	}{
+		"ASCII":                  {[]byte("hello\n"), UTF8},
+		"mostly UTF-8":           {[]byte("// héllo wörld\n// caf\xe9\n"), UTF8},
+		"Latin-1":                {[]byte("// Gr\xfc\xdfe aus K\xf6ln\n"), Windows1252},
+		"NUL":                    {[]byte("hello\x00world"), ""},
+		"NUL after 32KB":         {append(bytes.Repeat([]byte("a"), binarySniffLen), 0x00), UTF8},
+		"UTF-16 with BOM":        {[]byte{0xFF, 0xFE, 'h', 0x00, 'i', 0x00}, UTF16LE},
+		"control characters":     {[]byte("\x01\x02\xff\xfe\x03"), ""},
+		"incomplete rune at end": {[]byte("hello \xe4\xb8"), UTF8},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Detect(test.content); got != test.want {
+				t.Errorf("got encoding %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+// withBatchDelay sets the batch delay of the default gitserver client.
+func withBatchDelay(d time.Duration) func() {
+	old := gitserver.DefaultClient.BatchDelay
+	gitserver.DefaultClient.BatchDelay = d
+	return func() { gitserver.DefaultClient.BatchDelay = old }
+}
+
+// batchTestResult is the result of the commands run by runBatchTestCommands
+// for a file.
+type batchTestResult struct {
+	Commit   api.CommitID
+	Contents string
+	Err      string
+	Type     ObjectType
+}
+
+// runBatchTestCommands concurrently resolves a revision, reads the given
+// files and looks up their objects.
+func runBatchTestCommands(t testing.TB, repo gitserver.Repo, files []string) []batchTestResult {
+	ctx := context.Background()
+	results := make([]batchTestResult, len(files))
+	var wg sync.WaitGroup
+	for i, name := range files {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			commit, err := ResolveRevision(ctx, repo, nil, "master", nil)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res := batchTestResult{Commit: commit}
+			contents, err := ReadFile(ctx, repo, commit, name, 0)
+			if err != nil {
+				res.Err = fmt.Sprintf("%T %v", err, os.IsNotExist(err))
+			}
+			res.Contents = string(contents)
+			if _, typ, err := GetObject(ctx, repo, string(commit)+":"+name); err == nil {
+				res.Type = typ
+			}
+			results[i] = res
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+func makeBatchTestRepo(t testing.TB, n int) (gitserver.Repo, []string) {
+	cmds := []string{"mkdir dir"}
+	var files []string
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("dir/file%d", i)
+		cmds = append(cmds, fmt.Sprintf("echo contents%d > %s", i, name))
+		files = append(files, name)
+	}
+	cmds = append(cmds, "git add .", "GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z")
+	return MakeGitRepository(t, cmds...), files
+}
+
+func TestBatch(t *testing.T) {
+	repo, files := makeBatchTestRepo(t, 20)
+	files = append(files, "dir", "missing")
+
+	defer withBatchDelay(0)()
+	want := runBatchTestCommands(t, repo, files)
+
+	// A long delay ensures that the commands are batched.
+	gitserver.DefaultClient.BatchDelay = 50 * time.Millisecond
+	got := runBatchTestCommands(t, repo, files)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("batched results differ from exec results\ngot:  %+v\nwant: %+v", got, want)
+	}
+	if want[0].Contents != "contents0\n" || want[0].Type != ObjectTypeBlob {
+		t.Errorf("unexpected result for %s: %+v", files[0], want[0])
+	}
+	if last := want[len(want)-1]; last.Err == "" {
+		t.Errorf("expected an error reading a missing file, got %+v", last)
+	}
+}
+
+func BenchmarkGitCommands(b *testing.B) {
+	repo, files := makeBatchTestRepo(b, 50)
+
+	for _, bc := range []struct {
+		name  string
+		delay time.Duration
+	}{
+		{name: "exec", delay: 0},
+		{name: "batch", delay: time.Millisecond},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			defer withBatchDelay(bc.delay)()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				runBatchTestCommands(b, repo, files)
+			}
+		})
+	}
+}
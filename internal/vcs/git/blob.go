@@ -12,6 +12,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/util"
 )
 
@@ -58,6 +59,12 @@ func NewFileReader(ctx context.Context, repo gitserver.Repo, commit api.CommitID
 }
 
 func readFileBytes(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 && gitserver.DefaultClient.BatchDelay > 0 {
+		// Whole files are read with a buffered command, so that reads of
+		// many files are batched.
+		return readWholeFile(ctx, repo, commit, name)
+	}
+
 	br, err := newBlobReader(ctx, repo, commit, name)
 	if err != nil {
 		return nil, err
@@ -75,6 +82,26 @@ func readFileBytes(ctx context.Context, repo gitserver.Repo, commit api.CommitID
 	return data, nil
 }
 
+func readWholeFile(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string) ([]byte, error) {
+	if err := ensureAbsoluteCommit(commit); err != nil {
+		return nil, err
+	}
+
+	cmd := gitserver.DefaultClient.Command("git", "show", string(commit)+":"+name)
+	cmd.Repo = repo
+	stdout, stderr, err := cmd.DividedOutput(ctx)
+	if err != nil {
+		if vcs.IsRepoNotExist(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		// Convert the error like for a blobReader, which reports the
+		// stderr with the error.
+		br := &blobReader{ctx: ctx, repo: repo, commit: commit, name: name, cmd: cmd}
+		return nil, br.convertError(fmt.Errorf("%s (stderr: %q)", err, stderr))
+	}
+	return stdout, nil
+}
+
 // blobReader, which should be created using newBlobReader, is a struct that allows
 // us to get a ReadCloser to a specific named file at a specific commit
 type blobReader struct {
@@ -0,0 +1,134 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// ComparisonDiffSearchOptions specifies options to ComparisonDiffSearch.
+type ComparisonDiffSearchOptions struct {
+	// Base and Head are the compared commits. As in the comparison view, the
+	// diff is computed between the merge base of Base and Head, and Head
+	// ("Base...Head").
+	Base, Head api.CommitID
+
+	// Query specifies the search query to find in the added and removed
+	// lines. If its pattern is empty, all the changed lines match.
+	Query TextSearchOptions
+
+	// Paths specifies the paths to include/exclude.
+	Paths PathOptions
+
+	// MaxFiles is the maximum number of files returned. If it is 0, all the
+	// matching files are returned.
+	MaxFiles int
+}
+
+// ComparisonFileMatch describes a file of a comparison whose changed lines
+// match the query of ComparisonDiffSearch.
+type ComparisonFileMatch struct {
+	// FileDiff is the diff of the file, with only the matching changed lines
+	// (and a line of context around them) in its hunks.
+	FileDiff *diff.FileDiff
+
+	// Raw is the raw diff of FileDiff.
+	Raw string
+
+	// Highlights are the query matches in Raw.
+	Highlights []Highlight
+}
+
+// ComparisonDiffSearch searches the lines added and removed between two
+// commits. It returns the files with changed lines that match the query, in
+// the order of the diff.
+func ComparisonDiffSearch(ctx context.Context, repo gitserver.Repo, opt ComparisonDiffSearchOptions) (results []*ComparisonFileMatch, limitHit bool, err error) {
+	if Mocks.ComparisonDiffSearch != nil {
+		return Mocks.ComparisonDiffSearch(opt)
+	}
+
+	tr, ctx := trace.New(ctx, "Git: ComparisonDiffSearch", fmt.Sprintf("%+v", opt))
+	defer func() {
+		tr.LazyPrintf("%d results, limitHit=%v", len(results), limitHit)
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	const matchContextLines = 1
+
+	rangeSpec := string(opt.Base) + "..." + string(opt.Head)
+	if strings.HasPrefix(rangeSpec, "-") || strings.HasPrefix(rangeSpec, ".") {
+		// Base and Head are expected to be commit IDs, but make sure they
+		// can't be interpreted as `git diff` flags.
+		return nil, false, fmt.Errorf("invalid diff range argument: %q", rangeSpec)
+	}
+
+	query, err := compileQuery(opt.Query)
+	if err != nil {
+		return nil, false, err
+	}
+	pathMatcher, err := compilePathMatcher(opt.Paths)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rdr, err := ExecReader(ctx, repo, []string{
+		"diff",
+		"--find-renames",
+		"--full-index",
+		"--no-prefix",
+		rangeSpec,
+		"--",
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	defer rdr.Close()
+
+	dr := diff.NewMultiFileDiffReader(rdr)
+	for {
+		fileDiff, err := dr.ReadFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		origNameMatches := fileDiff.OrigName != "/dev/null" && pathMatcher.MatchPath(fileDiff.OrigName)
+		newNameMatches := fileDiff.NewName != "/dev/null" && pathMatcher.MatchPath(fileDiff.NewName)
+		if !origNameMatches && !newNameMatches {
+			continue
+		}
+
+		// splitHunkMatches doesn't adjust the "no newline" offset of the
+		// hunks it creates (see filterAndHighlightDiff).
+		for _, hunk := range fileDiff.Hunks {
+			hunk.OrigNoNewlineAt = 0
+		}
+		fileDiff.Hunks = splitHunkMatches(fileDiff.Hunks, query, matchContextLines, 0)
+		if len(fileDiff.Hunks) == 0 {
+			continue
+		}
+
+		if opt.MaxFiles > 0 && len(results) == opt.MaxFiles {
+			limitHit = true
+			break
+		}
+		raw, err := diff.PrintFileDiff(fileDiff)
+		if err != nil {
+			return nil, false, err
+		}
+		results = append(results, &ComparisonFileMatch{
+			FileDiff:   fileDiff,
+			Raw:        string(raw),
+			Highlights: highlightDiff(raw, query),
+		})
+	}
+	return results, limitHit, nil
+}
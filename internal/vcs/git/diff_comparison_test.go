@@ -0,0 +1,130 @@
+package git
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+func TestComparisonDiffSearch(t *testing.T) {
+	const rawDiff = `diff --git a.go a.go
+index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf89b74af8 100644
+--- a.go
++++ a.go
+@@ -1,6 +1,6 @@
+ package a
+
+-func a() { exec("ls") }
++func a() { run("ls") }
+ func b() {}
+ func c() {}
+-func d() {}
++func d() { exec("rm") }
+diff --git b.go b.go
+index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf89b74af8 100644
+--- b.go
++++ b.go
+@@ -1,1 +1,2 @@
+ package b
++func b() {}
+`
+	var gotArgs []string
+	Mocks.ExecReader = func(args []string) (io.ReadCloser, error) {
+		gotArgs = args
+		return ioutil.NopCloser(strings.NewReader(rawDiff)), nil
+	}
+	defer ResetMocks()
+
+	tests := map[string]struct {
+		opt          ComparisonDiffSearchOptions
+		wantFiles    []string
+		wantRaw      string
+		wantLimitHit bool
+	}{
+		"no matches": {
+			opt: ComparisonDiffSearchOptions{Query: TextSearchOptions{Pattern: "system"}},
+		},
+		"only changed lines match": {
+			opt:       ComparisonDiffSearchOptions{Query: TextSearchOptions{Pattern: "package"}},
+			wantFiles: nil,
+		},
+		"matching hunks": {
+			opt:       ComparisonDiffSearchOptions{Query: TextSearchOptions{Pattern: "exec"}},
+			wantFiles: []string{"a.go"},
+			wantRaw: `diff --git a.go a.go
+index a29bdeb434d874c9b1d8969c40c42161b03fafdc..c0d0fb45c382919737f8d0c20aaf57cf89b74af8 100644
+--- a.go
++++ a.go
+@@ -2,2 +2,2 @@
+
+-func a() { exec("ls") }
++func a() { run("ls") }
+@@ -6,1 +6,1 @@
+-func d() {}
++func d() { exec("rm") }
+`,
+		},
+		"empty pattern": {
+			opt:       ComparisonDiffSearchOptions{},
+			wantFiles: []string{"a.go", "b.go"},
+		},
+		"path patterns": {
+			opt:       ComparisonDiffSearchOptions{Paths: PathOptions{IncludePatterns: []string{`^b\.go$`}, IsRegExp: true}},
+			wantFiles: []string{"b.go"},
+		},
+		"max files": {
+			opt:          ComparisonDiffSearchOptions{MaxFiles: 1},
+			wantFiles:    []string{"a.go"},
+			wantLimitHit: true,
+		},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			test.opt.Base = "a"
+			test.opt.Head = "b"
+			results, limitHit, err := ComparisonDiffSearch(context.Background(), gitserver.Repo{Name: "r"}, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"diff", "--find-renames", "--full-index", "--no-prefix", "a...b", "--"}; !reflect.DeepEqual(gotArgs, want) {
+				t.Errorf("got args %q, want %q", gotArgs, want)
+			}
+			var files []string
+			for _, r := range results {
+				files = append(files, r.FileDiff.NewName)
+			}
+			if !reflect.DeepEqual(files, test.wantFiles) {
+				t.Errorf("got files %q, want %q", files, test.wantFiles)
+			}
+			if test.wantRaw != "" && results[0].Raw != test.wantRaw {
+				t.Errorf("got raw diff\n%s\nwant\n%s", results[0].Raw, test.wantRaw)
+			}
+			if limitHit != test.wantLimitHit {
+				t.Errorf("got limitHit %v, want %v", limitHit, test.wantLimitHit)
+			}
+		})
+	}
+
+	t.Run("highlights", func(t *testing.T) {
+		results, _, err := ComparisonDiffSearch(context.Background(), gitserver.Repo{Name: "r"}, ComparisonDiffSearchOptions{
+			Base:  "a",
+			Head:  "b",
+			Query: TextSearchOptions{Pattern: "exec"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []Highlight{
+			{Line: 7, Character: 12, Length: 4},
+			{Line: 11, Character: 12, Length: 4},
+		}
+		if !reflect.DeepEqual(results[0].Highlights, want) {
+			t.Errorf("got highlights %+v, want %+v", results[0].Highlights, want)
+		}
+	})
+}
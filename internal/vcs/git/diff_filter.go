@@ -19,6 +19,22 @@ func compilePathMatcher(options PathOptions) (pathmatch.PathMatcher, error) {
 	)
 }
 
+// compileQuery compiles the text search query into a regexp. It returns nil
+// if the query has no pattern.
+func compileQuery(query TextSearchOptions) (*regexp.Regexp, error) {
+	pattern := query.Pattern
+	if pattern == "" {
+		return nil, nil
+	}
+	if !query.IsRegExp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !query.IsCaseSensitive {
+		pattern = "(?i:" + pattern + ")"
+	}
+	return regexp.Compile(pattern)
+}
+
 // filterAndHighlightDiff returns the raw diff with query matches highlighted
 // and only hunks that satisfy the query (if onlyMatchingHunks) and path matcher.
 func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHunks bool, pathMatcher pathmatch.PathMatcher) ([]byte, []Highlight, error) {
@@ -27,7 +43,6 @@ func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHu
 		maxHunksPerFile   = 3
 		matchContextLines = 1
 		maxLinesPerHunk   = 5
-		maxMatchesPerLine = 100
 		maxCharsPerLine   = 200
 	)
 
@@ -85,7 +100,13 @@ func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHu
 		return nil, nil, err
 	}
 
-	// Highlight query matches in raw diff.
+	return rawDiff, highlightDiff(rawDiff, query), nil
+}
+
+// highlightDiff returns the query matches in the hunk bodies of rawDiff.
+func highlightDiff(rawDiff []byte, query *regexp.Regexp) []Highlight {
+	const maxMatchesPerLine = 100
+
 	var highlights []Highlight
 	ignoreUntilAfterAtAt := false
 	for i, line := range bytes.Split(rawDiff, []byte("\n")) {
@@ -121,7 +142,7 @@ func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHu
 		}
 	}
 
-	return rawDiff, highlights, nil
+	return highlights
 }
 
 func truncateLongLines(data []byte, maxCharsPerLine int) []byte {
@@ -5,7 +5,6 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"regexp"
 	"sort"
 	"strings"
 	"sync"
@@ -294,19 +293,9 @@ func RawLogDiffSearch(ctx context.Context, repo gitserver.Repo, opt RawLogDiffSe
 	// Even though we've already searched using the query, we need to
 	// search the returned diff again to filter to only matching hunks
 	// and to highlight matches.
-	var query *regexp.Regexp
-	if pattern := opt.Query.Pattern; pattern != "" {
-		if !opt.Query.IsRegExp {
-			pattern = regexp.QuoteMeta(pattern)
-		}
-		if !opt.Query.IsCaseSensitive {
-			pattern = "(?i:" + pattern + ")"
-		}
-		var err error
-		query, err = regexp.Compile(pattern)
-		if err != nil {
-			return nil, false, err
-		}
+	query, err := compileQuery(opt.Query)
+	if err != nil {
+		return nil, false, err
 	}
 
 	pathMatcher, err := compilePathMatcher(opt.Paths)
@@ -0,0 +1,42 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/lfs"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/util"
+)
+
+// ReadLFSPointer returns the Git LFS pointer stored in git for the named file
+// at commit, or nil if the file is not stored in Git LFS.
+//
+// Unlike ReadFile, which returns the contents of files stored in Git LFS if
+// gitserver fetched them, it reads the blob stored in git.
+func ReadLFSPointer(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string) (*lfs.Pointer, error) {
+	if Mocks.ReadLFSPointer != nil {
+		return Mocks.ReadLFSPointer(commit, name)
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: ReadLFSPointer")
+	span.SetTag("Name", name)
+	defer span.Finish()
+
+	if err := ensureAbsoluteCommit(commit); err != nil {
+		return nil, err
+	}
+
+	cmd := gitserver.DefaultClient.Command("git", "cat-file", "blob", string(commit)+":"+util.Rel(name))
+	cmd.Repo = repo
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+
+	p, _ := lfs.ParsePointer(out)
+	return p, nil
+}
@@ -121,7 +121,7 @@ func MakeGitRepository(t testing.TB, cmds ...string) gitserver.Repo {
 	t.Helper()
 	dir := InitGitRepository(t, cmds...)
 	repo := gitserver.Repo{Name: api.RepoName(filepath.Base(dir)), URL: dir}
-	if _, err := gitserver.DefaultClient.RequestRepoUpdate(context.Background(), repo, 0); err != nil {
+	if _, err := gitserver.DefaultClient.RequestRepoUpdate(context.Background(), repo, 0, nil); err != nil {
 		t.Fatal(err)
 	}
 	return repo
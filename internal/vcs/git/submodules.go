@@ -0,0 +1,104 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/config"
+)
+
+// Submodules returns the submodules of the repository at commit, with their
+// URL as configured in .gitmodules.
+func Submodules(ctx context.Context, repo gitserver.Repo, commit api.CommitID) ([]Submodule, error) {
+	if Mocks.Submodules != nil {
+		return Mocks.Submodules(commit)
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: Submodules")
+	defer span.Finish()
+
+	if err := ensureAbsoluteCommit(commit); err != nil {
+		return nil, err
+	}
+
+	cmd := gitserver.DefaultClient.Command("git", "ls-tree", "-r", "-z", "--full-name", string(commit))
+	cmd.Repo = repo
+	out, err := cmd.CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+
+	var submodules []Submodule
+	for _, line := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> TAB <file>
+		tab := strings.IndexByte(line, '\t')
+		if tab < 0 {
+			continue
+		}
+		info := strings.Fields(line[:tab])
+		if len(info) != 3 || info[1] != "commit" {
+			continue
+		}
+		submodules = append(submodules, Submodule{
+			Path:     line[tab+1:],
+			CommitID: api.CommitID(info[2]),
+		})
+	}
+	if len(submodules) == 0 {
+		return nil, nil
+	}
+
+	gitmodules, err := readGitmodules(ctx, repo, commit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range submodules {
+		submodules[i].URL = gitmodules[submodules[i].Path]
+	}
+	return submodules, nil
+}
+
+// readGitmodules returns the submodule URLs configured in the .gitmodules
+// file at commit, by submodule path. A missing .gitmodules file is not an
+// error.
+func readGitmodules(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (map[string]string, error) {
+	cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
+	cmd.Repo = repo
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return map[string]string{}, nil
+	}
+	return parseGitmodules(out)
+}
+
+// parseGitmodules parses the contents of a .gitmodules file and returns the
+// submodule URLs by submodule path. The name of a submodule is its path
+// unless the path is set explicitly.
+func parseGitmodules(data []byte) (map[string]string, error) {
+	var cfg config.Config
+	if err := config.NewDecoder(bytes.NewReader(data)).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
+	}
+
+	urls := map[string]string{}
+	for _, s := range cfg.Section("submodule").Subsections {
+		path := s.Option("path")
+		if path == "" {
+			path = s.Name
+		}
+		urls[path] = s.Option("url")
+	}
+	return urls, nil
+}
+
+// IsRelativeSubmoduleURL returns true if the submodule URL is relative to the
+// URL of the superproject, such as "../other.git".
+func IsRelativeSubmoduleURL(url string) bool {
+	return strings.HasPrefix(url, "./") || strings.HasPrefix(url, "../")
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestParseGitmodules(t *testing.T) {
+	got, err := parseGitmodules([]byte(`[submodule "vendor/lib"]
+	path = vendor/lib
+	url = ../lib.git
+[submodule "renamed"]
+	path = third_party/sdk
+	url = https://github.com/foo/sdk
+[submodule "nopath"]
+	url = git@github.com:foo/nopath.git
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"vendor/lib":      "../lib.git",
+		"third_party/sdk": "https://github.com/foo/sdk",
+		"nopath":          "git@github.com:foo/nopath.git",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSubmodules(t *testing.T) {
+	t.Parallel()
+
+	const (
+		libCommit = "94aa9078934ce2776ccbb589569eca5ef575f12e"
+		sdkCommit = "3bbf9e2e6dcd1f8a3ab5bd2eb3d98d57c9ec0b9e"
+	)
+	repo := MakeGitRepository(t,
+		"printf '[submodule \"lib\"]\\n\\tpath = vendor/lib\\n\\turl = ../lib.git\\n[submodule \"sdk\"]\\n\\tpath = sdk\\n\\turl = https://example.com/sdk\\n' > .gitmodules",
+		"git add .gitmodules",
+		"git update-index --add --cacheinfo 160000,"+libCommit+",vendor/lib",
+		"git update-index --add --cacheinfo 160000,"+sdkCommit+",sdk",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m 'add submodules' --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+	commitID, err := ResolveRevision(ctx, repo, nil, "master", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Submodules(ctx, repo, commitID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Submodule{
+		{URL: "https://example.com/sdk", Path: "sdk", CommitID: api.CommitID(sdkCommit)},
+		{URL: "../lib.git", Path: "vendor/lib", CommitID: api.CommitID(libCommit)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Stat finds the submodule by path, even though its name differs.
+	fi, err := Stat(ctx, repo, commitID, "vendor/lib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm, ok := fi.Sys().(Submodule); !ok || !reflect.DeepEqual(sm, want[1]) {
+		t.Errorf("got Stat Sys %+v, want %+v", fi.Sys(), want[1])
+	}
+}
@@ -12,8 +12,6 @@ import (
 	"sync"
 	"time"
 
-	"gopkg.in/src-d/go-git.v4/plumbing/format/config"
-
 	"github.com/golang/groupcache/lru"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -209,6 +207,9 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 		return nil, &os.PathError{Op: "git ls-tree", Path: path, Err: os.ErrNotExist}
 	}
 
+	// The .gitmodules file is read when the first submodule is listed.
+	var gitmodules map[string]string
+
 	trimPath := strings.TrimPrefix(path, "./")
 	lines := strings.Split(string(out), "\x00")
 	fis := make([]os.FileInfo, len(lines)-1)
@@ -270,21 +271,17 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 			}
 		case "commit":
 			mode = mode | ModeSubmodule
-			cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
-			cmd.Repo = repo
-			var submodule Submodule
-			if out, err := cmd.Output(ctx); err == nil {
-
-				var cfg config.Config
-				err := config.NewDecoder(bytes.NewBuffer(out)).Decode(&cfg)
+			if gitmodules == nil {
+				gitmodules, err = readGitmodules(ctx, repo, commit)
 				if err != nil {
-					return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
+					return nil, err
 				}
-
-				submodule.Path = cfg.Section("submodule").Subsection(name).Option("path")
-				submodule.URL = cfg.Section("submodule").Subsection(name).Option("url")
 			}
-			submodule.CommitID = api.CommitID(oid.String())
+			submodule := Submodule{CommitID: api.CommitID(oid.String())}
+			if url, ok := gitmodules[name]; ok {
+				submodule.Path = name
+				submodule.URL = url
+			}
 			sys = submodule
 		case "tree":
 			mode = mode | os.ModeDir
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS org_repository_sets (
+    id SERIAL PRIMARY KEY,
+    org_id integer NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+    name citext NOT NULL,
+    description text NOT NULL DEFAULT '',
+    repository_names text[] NOT NULL DEFAULT '{}',
+    name_patterns text[] NOT NULL DEFAULT '{}',
+    is_default boolean NOT NULL DEFAULT false,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT org_repository_sets_name_valid_chars CHECK (name ~ '^[a-zA-Z0-9](?:[a-zA-Z0-9]|[-_.](?=[a-zA-Z0-9]))*$'::citext),
+    CONSTRAINT org_repository_sets_name_max_length CHECK (char_length(name::text) <= 255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS org_repository_sets_org_id_name ON org_repository_sets(org_id, name);
+CREATE UNIQUE INDEX IF NOT EXISTS org_repository_sets_org_id_is_default ON org_repository_sets(org_id) WHERE is_default;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS version_contexts (
+    id SERIAL PRIMARY KEY,
+    name citext NOT NULL,
+    description text NOT NULL DEFAULT '',
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    revisions jsonb NOT NULL DEFAULT '[]',
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT version_contexts_name_not_empty CHECK (name <> ''::citext),
+    CONSTRAINT version_contexts_name_max_length CHECK (char_length(name::text) <= 255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS version_contexts_name ON version_contexts(name);
+CREATE INDEX IF NOT EXISTS version_contexts_org_id ON version_contexts(org_id);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS insight_series_points;
+DROP TABLE IF EXISTS insight_series;
+
+COMMIT;
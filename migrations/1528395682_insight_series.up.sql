@@ -0,0 +1,43 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS insight_series (
+    id SERIAL PRIMARY KEY,
+    saved_search_id integer NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    -- The points of a series are computed with the permissions of the user
+    -- who created it.
+    creator_user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    repo_ids integer[] NOT NULL,
+    interval_days integer NOT NULL,
+    start_time timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT insight_series_interval_days_positive CHECK (interval_days > 0)
+);
+
+CREATE INDEX IF NOT EXISTS insight_series_saved_search_id ON insight_series(saved_search_id);
+
+-- The points of a series are also the queue of the background worker that
+-- computes them. Points are enqueued in the 'queued' state and are
+-- 'completed' once their match count is stored. Failed points are queued
+-- again with a process_after time, until they are 'errored'.
+CREATE TABLE IF NOT EXISTS insight_series_points (
+    id BIGSERIAL PRIMARY KEY,
+    series_id integer NOT NULL REFERENCES insight_series(id) ON DELETE CASCADE,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    "time" timestamp with time zone NOT NULL,
+    state text NOT NULL DEFAULT 'queued',
+    commit_id text,
+    match_count integer,
+    limit_hit boolean NOT NULL DEFAULT false,
+    num_failures integer NOT NULL DEFAULT 0,
+    failure_message text,
+    process_after timestamp with time zone,
+    started_at timestamp with time zone,
+    finished_at timestamp with time zone,
+    CONSTRAINT insight_series_points_state_check CHECK (state IN ('queued', 'processing', 'completed', 'errored'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS insight_series_points_series_id_repo_id_time ON insight_series_points(series_id, repo_id, "time");
+CREATE INDEX IF NOT EXISTS insight_series_points_state ON insight_series_points(state);
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- The global symbol index has the symbols of the default branch of
+-- repositories, so that symbol searches don't need to query the symbols
+-- service of each repository. global_symbols_repos records the commit at
+-- which the symbols of each indexed repository were computed.
+CREATE TABLE IF NOT EXISTS global_symbols_repos (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE,
+    commit_id text NOT NULL,
+    limit_hit boolean NOT NULL DEFAULT false,
+    indexed_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS global_symbols (
+    id BIGSERIAL PRIMARY KEY,
+    repo_id integer NOT NULL REFERENCES global_symbols_repos(repo_id) ON DELETE CASCADE,
+    name text NOT NULL,
+    kind text NOT NULL,
+    path text NOT NULL,
+    line integer NOT NULL,
+    language text NOT NULL,
+    parent text NOT NULL,
+    parent_kind text NOT NULL,
+    signature text NOT NULL,
+    pattern text NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS global_symbols_repo_id ON global_symbols(repo_id);
+CREATE INDEX IF NOT EXISTS global_symbols_name ON global_symbols(name);
+CREATE INDEX IF NOT EXISTS global_symbols_name_trgm ON global_symbols USING gin (lower(name) gin_trgm_ops);
+
+COMMIT;
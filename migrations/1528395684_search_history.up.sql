@@ -0,0 +1,20 @@
+BEGIN;
+
+-- The searches run by users, which are listed as their recent searches. Rows
+-- older than the retention period of the site configuration are deleted by a
+-- background job.
+CREATE TABLE IF NOT EXISTS search_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    query text NOT NULL,
+    pattern_type text NOT NULL,
+    version_context text,
+    result_count integer NOT NULL,
+    duration_ms integer NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS search_history_user_id_created_at ON search_history(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS search_history_created_at ON search_history(created_at);
+
+COMMIT;
@@ -60,6 +60,16 @@
 // 1528395678_lsif_auto_index.up.sql (868B)
 // 1528395679_change_error_index_on_changeset_jobs.down.sql (132B)
 // 1528395679_change_error_index_on_changeset_jobs.up.sql (146B)
+// 1528395680_org_repository_sets.down.sql (59B)
+// 1528395680_org_repository_sets.up.sql (950B)
+// 1528395681_version_contexts.down.sql (56B)
+// 1528395681_version_contexts.up.sql (716B)
+// 1528395682_insight_series.down.sql (98B)
+// 1528395682_insight_series.up.sql (2kB)
+// 1528395683_global_symbols.down.sql (97B)
+// 1528395683_global_symbols.up.sql (1.222kB)
+// 1528395684_search_history.down.sql (54B)
+// 1528395684_search_history.up.sql (744B)
 
 package migrations
 
@@ -128,7 +138,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __1528395650_squashed_migrationsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3c\xcc\xcd\x0a\x82\x40\x14\xc5\xf1\xfd\x3c\xc5\x59\x16\xf4\x06\xae\xa6\xf1\x46\x92\x5f\xcc\x4c\x90\xab\x50\x13\xbb\xa0\x33\xa1\x16\xf4\xf6\x91\x31\x6d\xef\xfd\xfd\x4f\xac\x8b\x12\x46\x1d\x29\x93\x48\x0e\xa0\x4b\x62\xac\xc1\xe3\xd9\x0c\xdc\x42\x49\xa3\x64\x4c\x91\x50\x9a\xa4\xa5\xe0\x7e\xdf\x48\x84\xb3\x95\xfb\x94\xbe\x75\x5e\xd8\xb0\x30\xb7\xf7\x6e\xac\xaf\x23\xf7\x53\xbd\xb0\x77\x33\x36\x02\x00\x5e\xdd\x34\xb3\x77\x68\xb8\x67\xb7\xac\x45\x7e\x4e\x53\x94\x3a\xc9\xa4\xae\x70\xa2\x6a\xb7\xc2\x1b\x4f\xcb\x1b\x8d\xf7\x43\x57\xbb\xbf\x13\xdb\x48\x7c\x02\x00\x00\xff\xff\x2a\x5a\x7a\xd1\xb3\x00\x00\x00")
+var __1528395650_squashed_migrationsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x3c\xcc\xcd\x0a\x82\x40\x14\xc5\xf1\xfd\x3c\xc5\x59\x16\xf4\x06\xae\xa6\xf1\x46\x92\x5f\xcc\x4c\x90\xab\x50\x13\xbb\xa0\x33\xa1\x16\xf4\xf6\x91\x31\x6d\xef\xfd\xfd\x4f\xac\x8b\x12\x46\x1d\x29\x93\x48\x0e\xa0\x4b\x62\xac\xc1\xe3\xd9\x0c\xdc\x42\x49\xa3\x64\x4c\x91\x50\x9a\xa4\xa5\xe0\x7e\xdf\x48\x84\xb3\x95\xfb\x94\xbe\x75\x5e\xd8\xb0\x30\xb7\xf7\x6e\xac\xaf\x23\xf7\x53\xbd\xb0\x77\x33\x36\x02\x00\x5e\xdd\x34\xb3\x77\x68\xb8\x67\xb7\xac\x45\x7e\x4e\x53\x94\x3a\xc9\xa4\xae\x70\xa2\x6a\xb7\xc2\x1b\x4f\xcb\x1b\x8d\xf7\x43\x57\xbb\xbf\x13\xdb\x48\x7c\x06\x00\x2a\x5a\x7a\xd1\xb3\x00\x00\x00")
 
 func _1528395650_squashed_migrationsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -148,7 +158,7 @@ func _1528395650_squashed_migrationsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395650_squashed_migrationsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x7d\x6b\x73\x1c\xb7\xb1\xf6\x77\xfe\x8a\x79\x53\x4e\x71\x99\xa2\x54\x96\x13\xc7\xef\x91\xac\xb8\xd6\xcb\x91\xcc\x0a\xb5\x54\x96\xcb\x48\x3e\xa9\x9c\xa9\xe1\x2e\xb8\x9c\x70\x76\x66\x35\x17\x4a\x74\x4e\xf2\xdb\x4f\x0d\x6e\x83\x4b\xe3\x36\x7b\xa1\x93\x0f\xb1\xb8\xd3\x78\xba\x01\x34\x80\xee\x46\x03\xf8\x31\x7e\x7b\x3e\x7d\x75\x74\x34\xbe\x98\xc7\xb3\x68\x3e\xfe\xf1\x22\x8e\xce\xdf\x44\xf1\xc7\xf3\xab\xf9\x55\x74\x39\xbd\xf8\x39\x6a\x6b\x54\x25\xe8\x4b\x83\xaa\x22\xcd\x93\x74\xb1\x28\xdb\xa2\xa9\xa3\xb3\xd9\xe5\xfb\x68\x72\x39\xbd\x9a\xcf\xc6\xe7\xd3\xb9\x50\x0a\x2e\x90\xe0\x9f\xb3\x65\x72\x7b\x8f\x1e\x5f\xb9\x19\xae\xd3\x2c\x77\x73\xc1\x54\xfe\xd0\x75\x5b\x3d\xa0\xc7\xa4\x42\xf5\xa6\x2c\x6a\x64\xc3\x57\x49\x03\x98\xa0\xa6\xc9\x8a\x95\x15\x9c\x92\xec\x07\xb4\x42\xb7\xa8\x42\xc5\x02\xd5\x49\x59\xad\xea\x9d\xe1\xa6\x6d\x73\x57\x56\x01\x32\xa7\x0f\x68\x99\xd4\x28\xad\x16\x77\xf6\xb6\x96\x08\xf7\xcf\xa0\xac\x56\x5e\xf8\x15\x5a\x65\x75\x53\x3d\x62\x6d\x2e\xea\xac\x2c\x6c\x4c\x00\xea\x64\xd3\xde\xe4\x59\x7d\x87\x02\x5a\x6d\x97\x4c\x87\x57\x34\xa9\x50\x8e\x52\xfb\x10\xb1\x94\x4a\x80\x6f\x4f\x23\xc8\xa2\x42\x69\x13\xa2\xb5\x9b\xaa\x5c\xb6\x8b\x26\xa9\xdb\x9b\x7a\x51\x65\x9b\xc6\xd1\x03\x20\x7d\x38\xbb\x3c\x5b\x20\xc7\x94\xa4\x92\x26\x10\x6b\x2f\xa6\x9d\x5e\xac\xd1\xfa\x06\x55\x36\x7e\x02\x95\x7f\x7d\xc2\xa1\x43\xa6\x2b\xac\xd0\xc5\x43\xd6\xa4\xae\x6e\x51\x28\x93\x1a\x15\xcb\x90\x61\x38\x9c\x53\x85\x16\xd9\x26\x43\x45\x73\x08\x66\xbe\x23\xbc\x48\xd7\x56\xdd\xc2\xdf\xfd\x05\xf6\x83\xf3\x15\x2e\xaf\xb3\x5b\x41\x0d\x2c\xb8\x0a\x65\xb2\x6c\xd7\x1b\x7f\x16\x9b\x74\x71\x9f\xae\xdc\x0c\x18\x9d\x3f\xfc\x32\xab\x17\x6d\x8d\x67\x9e\xe6\xae\x42\xe9\xb2\x4e\x9a\xb4\x5a\xa1\x26\xa9\xd0\xa6\xb4\xf0\xb3\x17\xa4\xbf\x3d\xa9\x08\xf8\xff\x86\x09\x30\x9c\x29\xe6\xb7\x57\x6e\xa1\xc6\x8c\x00\xd1\x19\x9d\x9d\x98\xf9\x63\xd2\x94\xf7\xc8\x3a\x5e\x6d\xc5\x9e\x96\xf9\x30\xd5\x5a\x94\xeb\x35\xb2\xdb\xff\x00\xf5\x61\x99\x05\x77\x2d\xba\x4d\xdb\x9c\x68\x9e\x95\x97\x48\xe7\x3f\x2e\x16\x77\x69\xb1\x42\x35\xb2\xd6\xa3\x27\x1a\x00\x9c\xfc\xa3\xbc\xf1\x02\xc7\x84\x49\xff\xe7\x5e\xb9\xa4\xeb\x4d\x9a\xad\x8a\xee\xaf\xc3\x30\x0a\x63\x82\x1e\x1c\xaa\xa5\x92\x86\xb6\x1b\x15\xcb\xca\x82\xd1\x24\x78\xc1\xdc\xa4\x0b\xe4\xaf\xb6\x43\x19\xf8\xae\xca\x61\xf8\xbc\x17\x36\x79\xea\xd9\x15\x41\xf8\x74\x50\x87\x00\x63\x51\x7c\xd0\x09\x61\x78\xcb\x3b\x15\x55\xa4\x0b\x18\xd7\x83\xe0\xc3\x3b\x20\x5d\x2c\x50\x5d\xbb\x97\x11\x89\xae\x73\x36\xfe\x81\x16\x01\xc6\xed\x30\x36\xb0\xdf\x86\x4b\xcf\x67\xe7\x6f\xdf\xc6\x33\xa1\x68\x53\x65\xab\xe4\x21\xcd\xb3\x65\xda\x20\xb5\x25\xea\xe4\x36\x2b\x3a\x7f\x78\x19\x5d\x4e\x7b\x8d\xb2\x83\x2d\x51\x8e\x3a\x28\x3e\xe4\xb9\xd1\x99\x74\x4b\x0d\x57\xdd\x0e\x91\x4f\xdd\x7e\x90\x4c\x3a\x19\xb1\x5f\x24\x00\x21\xcf\xa7\x67\xf1\x47\x25\xf8\x45\xf4\xb5\x1b\xd6\x56\xa2\x9b\x2c\xcf\xb3\x62\x95\x2c\xda\xba\x29\xd7\xb8\x2d\x2d\xf4\x40\xdc\x8e\xfe\xc3\x50\x88\x44\x56\x3e\xb5\xa8\xca\x50\x8d\xff\xfb\x98\xb4\x45\xf6\xa9\x35\x49\x85\x47\x41\x5b\x65\x49\xb6\xfc\x62\x23\xa9\xcb\xb6\xea\x2c\xfc\x55\x56\xb8\x48\xbb\x36\x48\x9a\x6a\xb5\xb6\x11\xad\x51\x93\x2e\xd3\x26\xf5\x01\xe4\x8d\x40\x6a\x62\x25\xd7\xc3\x2f\x6d\x6b\x6c\x62\x7b\xb4\xc6\xd2\x97\xb6\x08\xc7\x03\xaa\xba\x1f\x06\x94\x04\x83\x34\x06\x9c\xce\x35\xb7\x49\xa8\xf9\xdb\x59\xb1\xca\xd1\x6d\x9e\xad\xee\x4c\xba\xe3\x74\x9c\x3d\xcb\x91\x05\xcd\x40\x8c\x1d\xba\x76\x93\x97\x9d\xd1\xff\x90\xd5\xd9\x4d\x8e\x88\x05\x97\x35\x65\xf5\xd8\x4d\x2d\x9d\xe1\x98\x99\x84\x94\xca\x93\xff\xa2\x65\x92\x7a\x91\xd7\x4d\xda\x98\xda\x4b\x22\x84\xe4\x49\xaa\xb2\xb4\x72\x11\xdc\x60\xea\xb0\xda\xa8\xb9\x4f\x4b\xff\x61\x1f\xa5\xb8\x08\x91\x43\x95\x6e\x93\x56\x5d\x17\xb9\x1b\x0d\x2e\x4e\x2b\x27\xa1\xd8\x65\xc1\xd6\x57\x92\x97\x7d\xb8\xdc\x4d\xd8\x64\x6b\x54\x37\xe9\x7a\x93\xa4\x4d\xd2\x36\x8b\x80\x12\x6e\x52\x32\x35\xb9\xe9\x2c\xa3\xc5\xd3\xe7\xde\xa4\xcd\x9d\x65\xf6\x71\xbb\xb5\x5e\x65\xcd\x8e\x69\xef\xb6\x79\x01\x01\xee\x5e\x58\xb9\xae\xde\x55\xb7\xe6\x54\x55\xfa\x98\xe4\xa8\x58\xf9\xd6\xdf\xe4\xfc\x99\x0b\x2f\xaa\xac\xc9\x16\xdd\x4a\x57\x2c\x93\x3a\xeb\x56\xe6\xb2\xb8\xcd\x56\x76\x65\x54\x7c\x8f\xba\x49\xab\xc6\x36\x1f\x28\xf4\xcc\x12\xf1\x2e\x80\xaa\xaa\xac\xfc\x48\x15\x87\xcb\x54\xc8\xec\x72\x04\x94\xb0\x4e\xb9\x82\x53\x20\x78\x4c\xae\xa5\x5c\xb6\x66\xdd\x0d\x2b\x91\x7b\xb4\xab\xd5\x58\x36\x14\x92\x4d\xd2\xbc\x2c\xef\xdb\x8d\xd5\xd6\xa5\x6b\xb1\xcd\xcc\x65\x24\xc9\xc6\x67\x23\xd4\xb5\x05\xea\x09\x93\x6c\x50\xb5\xce\x6a\x97\x6c\x2a\x29\xfe\x77\x52\x12\x83\x7f\x53\x95\x0f\x19\x8e\xa1\xd3\x01\xe2\xe6\x59\x2c\x3b\xd3\x33\x88\xb7\x56\x44\x92\xc1\x97\xf5\x0e\xf6\xab\xfd\xda\x35\x6c\x9f\x9a\x98\x93\x0f\xa8\xca\x6e\x33\xb4\x24\x3f\xef\x98\x47\x51\x26\xcb\x76\x93\x67\x8b\xb4\x41\xb8\xed\xf0\xe8\xde\xdb\xae\xb8\xb3\x95\x42\x36\x98\xdd\x60\x8b\x3b\xb4\x4e\x93\x75\xb6\xaa\x9c\x3b\x25\x1a\xad\x07\xfc\xc0\xfd\x64\x27\xb0\x23\x02\x8f\x97\x7b\x2f\x10\xcf\xb1\xa4\x92\x92\x31\x14\x32\x80\x29\x42\xc8\x00\x36\x15\x21\xcc\x3d\x79\xba\xf0\xb1\xaf\xe7\x85\xb5\xf5\x2e\xfa\xe1\xf7\xaa\x9d\x2c\x77\xb3\x37\xed\xcd\x66\xd0\x9e\xb4\x13\xfc\x2e\xbd\xa9\xba\xe9\xa9\xac\x9c\x11\x77\x8d\x36\xe9\x95\x60\xbf\x7c\x9c\xd5\xe8\xfc\x61\xfb\x3e\xad\x1f\x48\xe0\x66\xf9\x1e\x20\x69\x3c\x98\x59\xcc\xfb\xdb\xab\x76\xca\xee\xb7\xab\xec\x84\x11\x5d\x6b\xd7\x56\x2f\xf3\xc0\xfd\x40\x07\xed\x51\xfb\x41\x07\xef\x4d\xfb\xc1\x52\x27\xdc\x85\xca\x7c\x75\x27\xe8\x2a\x2f\x6f\xd2\x9c\x44\x36\x2c\xa0\x22\x99\x1b\x94\x1b\x5f\x35\xaa\x1e\x32\x7b\xeb\x6a\xb4\x1e\xf0\xdc\x25\xb7\xe1\xf6\x7e\xbb\x13\x70\x5f\x5b\xed\x03\x18\x87\x31\x0b\x61\xb0\xb3\x8d\xe6\x10\xa6\x03\x77\x7c\xdd\x2c\x06\x6d\xf1\x3a\x61\x4d\x31\x04\xdb\xa6\x8d\x29\xec\xe0\x66\x36\x64\x1f\x99\x0f\x97\x6e\x86\x77\x9b\x4d\xa1\x3c\xfc\x85\x0e\xdc\xd4\x0d\x91\x35\x10\x3a\x40\xe6\xf0\x3d\xe2\xbd\x82\x4b\x1b\xd0\xf7\x59\x81\x17\x6b\xaf\xa6\x0a\xda\x6a\x75\xd7\x61\xe0\x06\xab\x3f\x70\xd0\xce\xe7\x9e\x60\xe5\x18\x11\x8d\xcb\x3e\xf8\x34\xf7\xb0\x1d\xcf\x87\x34\x6f\x51\x52\xdf\xa5\xdf\x7c\xfb\x47\xa7\x15\x36\x8c\x85\xad\xa5\x48\xb4\x89\x7c\x9b\x5c\x5e\x5c\xbf\x9b\x46\xd9\x92\x20\x9f\xc5\x6f\xc6\xd7\x17\x73\x5b\x49\xd0\x57\x1c\x0c\xa6\x87\x6f\x86\x40\x69\x71\x8d\x41\x20\x2c\x7e\x31\xa8\xb0\x1c\x50\x18\x02\x81\x2d\x8a\x61\x05\x75\x0f\x78\x37\x38\xbd\x9b\x3b\x04\x4f\xf7\xcb\x86\xa0\x60\xf7\x6b\x60\x41\xee\x1f\x0d\x2d\x2f\xba\x3f\x43\x30\x24\x07\x65\x30\x80\xe0\x8c\x0c\xc6\xe0\x5e\xc7\x60\x04\xe6\x60\x0c\x01\xd0\xed\xfe\x41\x28\xbd\x79\x3f\xa4\xb8\xc3\x98\xdf\x0d\xe4\xb6\x30\xdc\x2e\x1e\x82\x63\xb4\x52\x07\x81\xf5\x16\xe2\x56\xc5\xc9\x5a\xbc\x1d\x04\xb5\xa0\x06\x81\x70\x8b\x68\x9b\xd2\xd4\x00\xda\x0a\x62\x70\x3b\xc8\x26\x80\x1d\x01\xff\xa5\x02\xb0\x9d\x28\xfa\xf9\x2a\xfe\xcb\x75\x3c\x9d\x68\x36\x41\x67\x6c\xd6\xe8\x93\x01\x04\x93\x58\xbe\x89\x06\x81\x9d\x11\x18\x3b\x76\xf2\x86\x4a\x39\xf8\xe8\x7b\x3d\x6e\x2e\x5a\x19\x2b\x31\xde\x8a\x31\x4b\xa1\xed\xa2\x58\xf9\xab\xd4\x94\xec\xaf\xe7\xf1\x07\x91\xaa\x1f\xd7\x16\xc6\x6c\xa7\xc5\xce\x90\x51\xdd\xdc\xb7\x9b\xe4\xc5\xb7\x2f\xfe\xf0\xdd\xd7\xdf\x7c\xf7\xdd\x1f\x1d\xe4\xa6\xcf\xea\x3e\x8c\x45\x3c\x79\x5b\xc5\x2e\xa4\x44\x6b\x25\xa2\xd9\x69\x06\x1a\x75\xaf\xc4\x4e\x16\xa0\x6b\x2c\x89\xc3\x5c\x87\x8e\xc2\x56\x5e\xdf\x94\x70\xc0\x69\x05\x42\xd0\xfb\x7d\x88\x40\x2e\xbc\xa0\xa1\x04\xc9\x0a\xac\xda\xa2\x40\x95\x94\x13\xa5\x99\x86\xd0\x76\x85\x83\x96\x6d\x3c\x98\x6b\xaa\x07\xf7\xad\xf5\xd3\xc8\xcd\xc8\x38\xc8\x6f\x05\x23\x07\xda\x2c\xe5\x79\x14\xde\x05\xc3\x09\xf9\xa0\xfc\xf6\xf7\x7f\xfc\xee\xf7\x2f\xdc\x25\xec\xfc\xc5\x00\xbd\x53\x06\x81\xd8\x40\x85\x43\xf4\x66\x8e\x6a\x6c\xdc\xca\x51\x21\x76\xa0\xf2\xc0\xb8\x1b\x93\x91\x3a\x10\x97\xed\x7a\xa3\xc0\x29\x93\x6e\x4f\x66\x63\x47\x2d\x7d\x07\x37\x16\x82\x77\x8b\x4f\x29\x0d\x24\x62\xe8\xdd\xcc\x52\x0f\xa3\x5b\xf9\x6a\xe4\x16\xe4\x3e\x90\x6e\x87\xe4\x74\x66\x2c\xf7\x29\x31\x33\xbe\xbd\x6c\x10\xcf\x40\x3e\x6e\x42\x2d\x48\xee\x25\x0e\x0f\x77\xfb\xca\xc3\x0a\x98\x28\xc5\x60\xb7\x59\x02\x63\xc4\xda\x2a\x86\xa9\x94\x85\x4f\x1f\x56\xb6\x23\xab\xf9\xf6\x36\x2c\x12\xca\xf3\xc3\xc3\xb4\x3e\x98\x34\x1c\xeb\x89\x4a\xa8\x2d\xb8\x3c\xf0\x6a\x07\x54\xce\x04\x58\x90\x68\xb8\xd5\x0b\x8e\xd0\x7a\x60\x7a\x34\xa4\x48\x6a\x46\x94\x43\x92\x56\x44\x89\x94\xd2\xbc\xb9\x9e\x4e\xe6\xe7\x97\x53\xd1\x91\x71\x9e\xfc\x18\x9d\x98\x4b\x7b\x1e\xf5\xf0\x81\x70\x1c\xed\xe0\x10\xf3\x9f\xdf\x1b\x56\x09\xd9\x3e\x92\xc9\xf8\x90\x2a\x2b\x3c\xa2\x5e\x1d\x1d\x4d\x66\xf1\x78\x1e\x47\xf1\xc7\x79\x3c\xbd\xa2\x32\x4d\x2f\xe7\xbc\x44\xd6\xa0\x2f\x8d\x9b\xee\xae\x6e\xca\xca\x03\x6f\xb3\xa2\xe7\x2c\x18\x21\x96\x50\x95\x2b\x1a\x5f\x45\xf1\xf4\xfa\x5d\x34\x3a\x8a\xa2\x28\x3a\x66\xdf\x8f\x4f\xc9\xdf\x1d\xcd\xf1\xd1\x89\x82\xa2\x35\x82\x0a\xf3\xa9\x45\x2d\x5a\x32\x90\x4d\x55\x76\xba\x91\x15\x2b\xf6\xcb\xa2\x5c\x6f\xba\x6e\xe0\x24\x38\x77\x16\x2d\x25\x56\xbc\xf3\xbc\xbb\x2c\x9a\xc5\xf3\xeb\xd9\x94\x1c\xe2\x59\xa1\x0a\x63\x5f\x8c\xa7\x6f\xaf\xc7\x6f\xe3\x68\x93\x6f\x56\xf5\xa7\x1c\xff\x38\xbe\x8a\xbe\xfa\x0a\xff\x0b\x5f\xab\x83\xff\xd5\xfd\xef\xfa\xfd\xd9\x78\x1e\xf3\x3f\x23\x61\x06\xe3\x3f\x5e\xc5\x73\x91\xa0\x3f\xd2\x58\x47\xaf\x05\xfa\xe7\xd2\x97\x67\xd1\xe5\xc5\xd9\xf3\x6c\xf9\xf2\x65\xd7\xcd\xbc\xfc\x87\x9f\xe2\x19\xcc\x4e\x2e\xfe\x83\x54\xfc\xd5\x11\x2f\x42\x6a\xdc\x7d\x7d\x85\x7f\x8b\xa7\x67\xaf\x8e\xbe\xfa\xca\xd2\x8a\xce\xb1\xb3\x8f\x56\x64\xe8\xa6\x46\x14\x93\x8f\xbb\x56\x64\xf4\xcf\xe5\x2f\xbe\xad\x68\x28\xbe\x83\x56\xf4\x99\xbf\x42\x1b\xf0\x2c\x9e\x5c\x8c\x71\x05\x3a\xc7\x2b\x2b\x56\x51\x56\x34\xaf\x8e\x58\x9b\xb2\x1f\x5f\xbe\xa6\xc3\xeb\x2a\xbe\x88\x27\xf3\x68\x72\x79\x3d\x9d\x8f\x7e\x77\x12\xbd\x99\x5d\xbe\x93\x67\xf4\x23\xde\x2a\x91\x9a\x3e\x1d\xbd\x8e\xa6\xf1\x87\xe7\xea\xcf\x44\x9c\xe9\x59\x24\xa4\x67\x47\xe7\x57\xd1\xf4\xfa\xe2\xe2\x28\x8a\x4e\x70\x63\x9d\xbf\x89\x46\x4c\x9a\xff\xf7\x3a\xfa\xfa\x24\x9a\xff\x14\x93\x6e\x9f\x8d\xcf\xaf\xba\xc9\x68\x12\xbf\xc7\xed\x74\x3c\xa1\x0c\xde\xe7\x69\xf1\xcf\xf3\xb3\x97\xd1\x6f\xff\x15\xdd\xa5\x75\xf4\xdb\xa8\x2d\xf8\x21\xbf\x4e\x56\x3a\x05\x44\xa0\x58\xa7\xac\xf6\x5d\xbf\xc4\xd3\xb3\xe8\xfc\x0d\x96\x84\x76\xd8\x34\xfe\xf0\xea\x48\xeb\x2c\xb2\x34\xc9\x31\x37\xd2\x72\xd9\x32\xba\xc9\x56\x59\xd1\xe0\x99\xb2\xab\x1b\x61\xae\x1c\x94\xec\xda\x1f\xad\x50\xa5\x50\x89\xbb\x7e\xd1\xcd\x63\x83\x52\x85\xa0\x28\x1b\x14\x75\xaa\xa5\xfc\x8e\x0f\x48\x92\x26\xe5\x67\x57\xa2\xcf\x59\x73\x87\xff\x8c\x7e\x29\x0b\xc4\xe2\x7f\x51\x51\x7e\x1e\x9d\x28\xe5\xf3\xb4\xc6\xb2\x59\x11\x08\x29\x19\xe5\x1e\x84\xca\xa1\x4d\x43\x95\xeb\x45\xb9\x41\x35\xae\xd3\xdf\xfe\xce\xbf\x89\xb3\x34\xb7\x1a\x20\x5b\x81\xa8\xeb\x7c\x3c\x9b\x47\x1f\xce\xe7\x3f\x45\x2f\xf0\x0f\xe7\xd3\xc9\x2c\x7e\x17\x4f\xe7\xd1\x8f\x3f\xd3\x9f\xa6\x97\xd1\xbb\xf3\xe9\x5f\xc7\x17\xd7\x31\xff\x7b\xfc\xb1\xff\x7b\x32\x9e\xfc\x14\x47\x2f\xf8\xf5\x67\x56\xae\xd1\xe5\x87\x69\x7c\xd6\x81\x4b\x5f\x9f\x67\x4b\x55\x47\xe4\xc8\xae\x5d\x47\xb4\x81\x04\x52\x51\x67\xc7\xf4\xf1\x01\x52\x8f\x65\x76\x7b\x0b\xfd\x8e\x17\x45\xe8\x43\x7f\xe2\xc2\xd1\xc9\xe2\x60\xf6\x50\x87\x2d\x54\xb4\xdd\x2c\xb7\x2a\x7f\x93\xd6\xa8\x5b\x91\xc0\xf6\x41\x3c\xc4\x84\x3f\x93\x5f\x85\x3d\x74\xd9\xf0\x65\x50\xc9\xe2\x0e\x2d\xee\xa3\xc9\x4f\xf1\xe4\xcf\xd1\x68\xc4\x39\x7c\xff\xa7\xe8\xf8\x98\x2c\x01\x27\x27\xa0\x2a\x43\x86\xf4\xfe\x55\x19\xe2\xda\xab\xb2\xf4\xd5\xa6\xca\x64\x9f\xc3\x53\x97\x9b\xc7\xcd\x5e\x66\xac\x6d\xd5\x21\xad\x56\x2d\xd9\x45\x83\x84\x4b\x8b\x05\xca\x7d\xb4\xda\x3e\xb9\x41\x1a\x44\xdc\x31\xa9\x7d\x14\x3d\x92\xdb\x2e\x40\x99\x44\x4f\xef\x80\xda\x24\xb2\x05\xd4\x09\x7f\xb6\xe8\x93\x4b\x95\x8a\x74\x0d\x6a\x10\x3c\x68\xf9\xd5\x10\x86\x2e\xd1\x0e\xba\x31\x3a\xf5\x33\xc9\x84\x96\xbf\x3e\xb5\xd2\xca\xc6\xe6\x3f\xea\xb2\xb8\xe1\xf4\xc7\xff\xfc\xd7\xf1\xcb\x97\xe4\x37\xc7\xba\x22\xd7\x29\x2f\xbd\xd6\xfd\x9b\x2a\x2d\x16\x77\xf6\xe9\x51\x3d\xe4\x27\xeb\x35\x96\x0d\x2b\x75\x79\x3b\x92\x08\x4f\xa2\xd7\xd1\x31\x39\xd5\xc5\x55\xdd\xc2\xe4\x2e\xad\x93\x17\xfd\x01\x44\xce\x60\xa4\x77\x2e\x35\x30\x4f\xba\x61\x34\xd2\x3a\x97\x7d\xb5\x72\xc3\x07\x0c\x8a\xb2\x49\x6e\xf2\xb4\xe8\x6b\x83\xd5\xd2\x7f\x70\x1e\x7e\x5c\x9a\x87\x24\x38\x1a\xd5\x8d\x74\xc7\xfc\x2e\x74\x1f\x4c\x71\x9f\x15\x4b\x68\xd8\xde\xa3\xc7\x7d\xac\x07\xec\x4a\x07\xef\x51\xb1\xed\x58\x14\x75\x45\x0d\x06\xde\xa3\x47\x45\xf7\xbb\x5a\x4b\xca\xe2\x01\x92\x15\x4b\x15\xa5\x6b\xd3\x40\x18\x7e\xd7\x85\x65\x30\x32\x1a\x68\x1c\xc2\x5a\x0d\x87\x3f\x0f\xa0\xdc\x30\x63\x41\xc7\x15\x02\xab\xaa\x87\x18\xe5\x4e\x02\x72\xd2\xda\x7b\xb8\xa8\x16\xf8\xaf\x63\x85\xd9\x83\xe1\x2f\x2c\x1c\x0e\x5d\x3a\xac\x31\x0c\xb1\x85\xf4\xc8\x64\x0e\xf7\xb9\x4f\xde\x1a\xe4\xbf\x66\x33\x2f\x0f\x36\x62\x9e\x5a\x4b\x82\x27\x5b\xe1\xe4\x01\xe8\x87\x2a\x3b\x8a\x46\xaf\x81\x13\x7a\x47\x21\x78\x09\xab\xfd\xd2\xef\x36\x89\xbd\x65\xb5\x5f\x04\x3a\x3f\xf3\xa5\xe7\x21\x9e\xc3\x90\x59\x88\xed\xe4\x39\xcf\x0b\x70\x62\xeb\x01\x16\x0b\xdc\xca\xde\x6c\xf6\xbb\x90\x3c\xc1\xc0\xb7\x0c\x7a\x70\xc0\x9b\x32\x27\xf9\xf0\x87\x07\x2b\x6e\x65\x6d\x5b\x44\x19\xd0\x65\xd1\x18\x9d\xd1\x27\x1b\xec\x70\x8f\xd9\x77\x80\x0f\xd0\x7f\x76\x01\x84\xde\x34\x10\x02\x7d\x2b\x1f\x09\x1b\x59\xa7\x60\x69\xb3\x8a\x94\x06\x72\x73\xed\x6b\x02\xbf\x13\x07\xfe\x2c\xdf\x5b\x63\x5a\x04\x7e\x95\x3a\x13\x18\x24\xa6\x97\xfc\xb0\xf8\xaf\xbc\x92\xf8\x04\x85\xcd\x29\x11\xfb\xd7\x44\x33\xef\x5e\x09\x01\x1a\x48\xff\x6c\x47\x2d\x89\x2a\xe1\x3f\xa0\xbe\xb6\x6b\x89\x43\xd3\x3c\x3a\xca\xaa\xef\x2c\xa5\xdd\xae\xee\x5e\xfa\xdc\x64\x4d\x8e\x84\xf5\x59\x4e\xee\x91\xac\xe6\x6d\xd5\x3b\xad\x16\x77\xd9\x83\x57\x7c\x6f\xff\x03\xc1\xa5\xd6\x72\xe2\xd1\x41\xb5\x5a\x66\x0d\x2a\x35\x25\xb1\xeb\x34\x74\x96\x62\xab\x09\xd2\x6e\x1e\x6f\xd2\x46\x34\xf4\x34\xcb\xaf\x42\x0f\x59\x2d\x87\x0d\xb1\xdf\x93\xe4\x59\x81\xe4\xe8\x18\x2a\x96\xc0\xaf\x84\x7a\x71\x97\x56\xe9\xa2\x41\x95\x5e\xc4\xf0\xa9\x43\xaa\x93\x1b\x74\x5b\x56\xa2\xa6\xe3\x9f\xd5\xbf\x93\xf4\xb6\x2b\x6f\x74\x9b\xbc\xd2\xe1\x9e\x44\x5b\x74\x31\x6c\x9a\x23\x92\x03\x5a\x24\x1c\xe8\x19\x16\x26\x6e\xab\x3c\x7c\xd6\x4c\x8b\xb2\x78\x5c\x97\x2d\xbf\xfd\x0e\xdc\x24\xc3\x57\xff\x41\x5f\xd8\xe6\x42\x04\xf9\x42\xf4\xa4\x05\x54\xee\x37\x7c\x8e\xf8\x8d\x79\xba\x31\x86\xa1\x84\x64\x4b\x6c\xa2\xe3\x38\x69\x27\x7f\x1f\x20\x1d\xb1\xea\xe0\xbd\xf5\xf1\xf4\x2c\x1a\xe9\x55\x95\x3c\x82\xe8\x72\x16\xf5\xc5\xbe\xff\x93\xa5\xdc\xeb\x61\xc5\x24\x76\x80\x0b\xa2\x55\x8b\x07\x64\xd1\x7a\xd3\x3c\x5a\x02\xb2\x6e\x28\xd2\x85\x00\x18\xed\xdb\x40\xb8\xb6\xca\x01\xac\x4e\x03\x03\x81\xa8\x8e\x00\x60\x4c\x7b\xdc\x91\x67\x2d\xf7\x76\xff\x93\x81\xc6\xb2\x1f\xf8\xfd\x27\x68\x90\x6b\x67\xff\xec\x63\xdd\x14\x5b\x5e\x66\xf5\x26\x4f\x1f\x13\xd3\x5c\x40\x1d\xb6\xff\x64\x83\x59\xf2\xcd\xf1\x68\x28\x0b\xa2\x22\xcc\x1d\xe5\xdb\xd1\x4d\x95\xad\x47\xe4\xc7\x13\x1f\x7d\x31\x64\x81\x1f\x40\x6d\x0c\x9c\x05\xed\x51\x29\x00\x25\x92\x6e\xa3\x21\xfa\x83\x9d\xbe\x6c\x19\xb5\x6d\xb6\x54\x1a\x3c\x2b\xb2\x26\x4b\xf3\xec\x17\xb4\x8c\x6e\xca\x32\x47\x69\xc1\x3b\xe8\x36\xcd\x6b\x75\x9e\x5d\xaf\xd6\x4d\xb2\x49\xeb\xfa\x73\x59\x2d\x93\x4d\x9e\x76\x0b\xc7\x97\x86\xe8\x12\x77\x5b\x68\x0b\x5b\xcb\xde\x2c\xaa\xc7\x8d\xab\xa0\x6e\x74\x4b\x07\x6c\x1d\x21\x07\x42\x06\xe9\xb9\x74\xd5\xae\x64\x12\x09\xf7\xf2\xba\x77\xb3\xa5\x43\x0a\xf4\xde\x5e\x9c\xb8\x86\x2d\x9f\xba\xdf\xca\x26\x7c\xfe\x1d\x1d\xff\xcf\xdf\xd2\x67\xbf\x7c\xfd\xec\xbf\xfe\xfe\xcf\x3f\x7c\xfd\xaf\xaf\xcc\x53\xa1\x7c\x03\x91\x74\x31\x30\xc4\x40\xae\x8f\x99\x0f\xa8\xf1\xc0\x51\x8b\xfd\x2b\x3b\xc0\xb4\xd7\x73\xf1\x23\xa0\xe2\xd2\x59\xf1\xc1\x3a\x50\x95\xa5\x9f\xde\xb2\x1b\xb2\xd3\x26\x69\xb2\x8d\xd7\x30\x11\x2e\xc5\x1e\x36\x11\xde\x66\x39\x32\x4d\xdf\x64\x68\xeb\xb9\xc1\xbc\x1a\x34\x2b\xf8\xe5\x4b\x9d\x46\xe1\x92\x66\x79\x5b\xa1\xa4\x6e\xd7\xeb\xb4\x7a\x14\x06\x02\xff\xd2\xa4\x8b\xfb\xa6\x4a\x17\x48\xf5\x12\x76\xbb\x3b\xd2\xb1\xc0\x57\xf2\x97\xc2\x7c\xa2\x3b\x3b\xa1\x63\x93\x5d\x65\xb6\xcd\xd8\x14\xc7\x0c\x3e\xeb\xd4\x9f\x70\x8a\xc6\x57\x47\x2c\x4b\xb4\x7d\x9e\x2d\x69\xe7\x3f\x27\x98\xec\xaf\x4e\xd1\xd8\xbf\x65\x5d\x62\xbf\x0a\xea\xc2\x7e\x62\x0a\xc0\xfe\xc6\xdd\xc7\x3f\xca\xfd\xa6\xfd\xcc\x3b\x4d\x28\x4d\x7b\xac\xc7\xe7\x5d\xc3\x7e\x52\xfa\x80\x8b\x2f\xb6\x3b\x50\x3c\x1a\xe3\x93\x87\x0b\x54\x93\x04\x8a\x8e\x02\x67\xca\x4a\xc3\xb4\x3d\x62\x89\xb2\x23\x5a\x99\xce\x50\xee\xf3\xd3\x01\x65\x35\xce\x55\xe2\x21\xb4\x03\xcd\x54\x22\x4b\x65\x9e\xa2\x35\x34\xcd\x53\xfc\x3a\x09\xc7\x44\x85\xcf\x04\x83\xe3\xdd\x34\x0f\x88\x1e\x14\x35\x9f\xc8\xdb\x6c\xd6\x58\xad\x5c\x2f\xe5\x84\xe0\x81\x5a\x53\xe1\xaa\x34\x28\xfb\x6a\x6a\x51\xe1\x92\x8f\x03\xb4\xe9\x6d\x96\x37\xa8\x02\xd3\x90\xc3\x9b\x5b\x3b\xe6\x79\xa0\x06\xd7\xf8\x2a\x4d\xde\x7f\x07\x1a\x9d\x5c\x41\x39\xea\xdb\x8d\x1c\xa1\xb1\x47\x12\xc8\x8f\x50\xf6\x98\x30\x47\x93\xcb\x2b\xe5\x8d\xb4\x91\x98\xb3\x44\x39\x10\x3f\x5a\xc8\x56\x62\xbf\xcb\xb3\x33\x11\x57\xbd\x8d\xc6\xee\x43\xc9\x12\xaa\xfa\x23\x3d\x90\x69\x20\xd2\x9e\xe8\xd8\xd3\x6e\x75\x51\x36\xe4\x8a\x6c\x8f\xdd\x84\x7a\x53\x16\x0e\xf3\x43\x24\xad\xe9\x26\x28\x35\x6d\x78\x78\xb0\xbc\xdf\x69\x2a\xbc\xe6\xb4\xa5\x4d\xb9\xce\x16\xfc\x1a\x87\x5e\x07\xa4\x1a\xf0\xe4\xb5\xd7\xd1\x48\x96\xd7\x96\xb8\x46\x02\x1b\xf8\xc5\x95\xae\x63\x84\xe0\x0f\x0c\x8e\xc3\x32\x06\x78\xac\x7e\x42\x7b\xf4\x7c\xc1\xa1\x0e\x9f\x21\xdf\xff\x50\x87\xf9\xf6\x43\x5d\xf9\x0e\x0c\x75\xf1\x26\x28\xc7\xdc\x6a\x1d\x38\x4f\x1d\x47\x30\x8d\x44\x78\xb6\x30\x5c\x23\xa0\xb5\x80\x79\x4e\x63\x17\x05\x97\xf9\x32\x2c\x8b\xc9\xbb\xba\x60\xbd\x8c\xda\x27\xdf\xa0\x70\x18\xcd\x93\x79\xca\x5a\x47\xbf\xc1\x1a\xe7\x54\x35\xf3\xa2\xf3\xd4\x7a\xa6\x45\xda\xe8\xba\x91\xa7\x8b\xfb\xe4\x33\xba\xb9\x2b\x4b\x1c\x0e\x15\x6d\xb4\x01\xb3\x25\xbe\x54\x43\xe4\x95\xac\xd3\x2f\xf4\x2d\x98\xde\x91\xb9\x4b\x2b\xfa\xdb\x48\xa4\x3d\x89\xbe\x7f\x1d\x7d\xf3\xed\xb7\xc0\x24\xc9\x1f\xb0\x72\xe1\xe1\xa8\xf2\x09\xf5\x88\x7c\xf0\x20\x4f\x0b\x37\xd2\xe5\xfb\x78\x36\x9e\x5f\xce\x46\xff\x3e\xa1\x1e\xd7\xf8\xd9\x7f\x77\x4e\xd7\xe8\x87\x97\xc2\x5f\xff\xfb\xb7\x67\xcf\xff\x3e\xfa\xe1\xb5\xf0\xd3\xc9\xc9\xef\x9e\xfd\xd0\xb9\x65\x44\x13\xcc\x73\xef\x41\xd5\x1e\xd4\x77\x48\xd1\xf5\x3b\xfe\x5c\x91\x8b\x34\xcf\xeb\x6c\x55\xc0\x9a\xcf\x2f\x7d\xff\x95\x0e\x0c\x5f\x2d\xe7\xfb\x52\x5e\x31\x40\xde\xf2\xa6\x0b\x6c\xf6\xdf\xe7\x26\xce\xbd\x02\x68\x14\x90\x36\xa8\xcf\x09\x70\x65\x00\x82\xb4\x86\x37\xed\x21\x52\x8a\x97\xec\x3c\xbd\x5c\x5f\x39\xe1\x87\x17\x6c\xf5\xb0\xdb\xc7\xec\x55\x46\xb5\x96\xbf\x9e\xac\x60\x9f\x7c\x09\xbd\x9d\xf4\xab\x9e\x68\x23\xe1\x0f\xe2\x7e\x77\x5a\x37\x09\xfa\x82\x16\x6d\x83\x96\x8e\x61\x93\xa7\x0d\xaa\x9b\xce\x72\x6e\x73\x77\xee\x27\x5a\x24\xcb\x96\x5c\x35\x96\x14\x35\x75\x84\x74\x49\x6d\xd7\x9a\xda\xbd\x28\xf0\xd5\x44\x9b\x6d\xe8\x4c\x83\xa1\x8c\xd9\x16\x20\x9d\xe5\xe4\x6f\x4d\xba\x82\x67\xbf\x75\x5a\x64\xb7\xa8\x86\x37\x9e\x6f\xda\x62\x29\x65\xd8\x6c\xab\x56\xde\x73\x1d\xdd\x66\x5d\xa7\x1b\x73\x3e\x83\xfb\x0e\xb2\xfd\x4f\x71\x6e\x19\xfa\xc9\xce\x42\x0b\x4c\x7b\xd0\x05\xbc\x8e\x65\x10\xcf\x23\xd0\xa4\xc8\xdf\x08\x05\x23\x0f\xfd\x67\xc8\x5e\x37\x2f\x9c\x5c\x75\x7e\x3d\xd3\xce\x10\x8b\x11\xba\x28\x0f\x1b\x66\x8a\x91\x67\xb1\xf2\xfa\x64\x05\xab\x25\xf8\xe2\x9b\xff\x0f\xf9\xdf\x46\x01\xb6\xb7\x0c\x93\xe7\xcf\x74\xd3\x50\x34\x0c\xbd\x84\xa1\xa1\x01\xae\x27\x7d\x93\xe8\x9a\x25\x1d\x9f\xd3\x34\xcb\x11\x0d\x30\xdf\x59\xf8\x14\x23\xd9\x67\x04\xc3\x23\x57\x4c\x56\x0b\xf5\xd3\xe0\x43\xaa\x79\x5a\xac\xda\x74\x25\xce\xc5\xb7\x65\x75\x2f\x87\xa1\x0e\x30\xfa\xd8\x32\x29\x1f\xce\x50\x7e\xd5\xce\x64\x18\xbe\xcb\xa5\x8b\xf4\x26\x07\xb6\xd6\x9b\xaa\x55\xf7\xe2\x98\x71\xe1\xb7\xbf\x58\x65\xd2\x8a\x18\xb8\x04\xf9\x1f\xc0\x09\x3e\xe2\xa2\x27\x66\x90\x34\xa5\x02\xc8\x52\xe2\x23\xfe\xfb\x3f\x9d\x60\xbb\xdf\x32\x80\xc5\xe7\xaa\x07\x9e\xbc\x80\x51\xd9\xa3\xda\x16\x50\x4a\xe2\x7d\x9a\xe3\xa0\x99\x87\x60\x7e\xa1\x21\x8b\xd0\xf8\x82\x9d\x3d\xd5\x9f\xae\xa8\x9c\xde\x92\x48\x58\x83\x1b\x25\x3e\x2b\xa0\xd9\xd1\xd0\x1e\xf9\xdb\xb3\xb4\xec\x4d\x40\xb0\xe0\x56\x35\x91\xee\xde\xd5\xbc\x00\xd5\x95\xf3\x72\x07\xb4\x42\x3e\x7e\x81\x76\x9c\x0c\x74\x10\x9c\x15\xe1\x8f\x38\x38\xd6\x05\x75\xf6\x57\x3e\x1b\x5b\xe0\xa9\x0d\x2f\xbc\xe3\xf2\x98\x94\x9f\x0b\x54\xf1\x69\x19\x24\xc1\x01\x3f\x03\x89\xf7\xc6\x98\x2d\x6a\x28\xcc\x5a\x18\xaf\x64\xd6\x07\xce\x8d\x2c\xda\x3c\x07\xb2\x5b\xa5\x1d\x34\x6c\xc9\xa9\x16\x0b\xc9\x4f\x05\x36\xd6\x08\xb9\x6a\xff\x98\x4c\x1c\xf0\x92\xea\xfd\x4f\x7d\x20\xdb\x7e\x12\x94\x3f\x03\xd3\xa1\xfe\x74\x2a\x51\x64\xe6\x73\xc2\x47\x45\xb2\xaa\x79\xd4\xba\x1a\x18\x22\xec\x91\x94\xa0\x7d\x14\xaa\xf8\xe2\x99\xa6\x1d\x8d\x05\x48\x0b\xe1\x03\x29\xe6\xba\xa8\xb7\x9f\x07\xec\x90\x28\x6f\x90\x2b\x1b\x25\x83\xea\xbb\x55\xc5\x7a\x25\x92\xef\x7f\x3f\x80\xd6\xca\x0c\x05\x7d\xa5\x1f\x24\x4d\xc5\x19\x47\xd2\x03\x15\x7d\xca\x91\x98\xea\xf9\x9c\x26\x79\x92\xfa\x4b\x5f\x84\x1c\xcf\x23\x96\x9b\x23\xdf\x88\xac\xf4\xb6\xfa\x46\x90\x3d\xf6\x03\x76\x00\xbe\xee\x5f\xdc\x11\x59\x94\x15\x32\x86\x7a\xd2\x5a\xf2\x0b\x6e\x50\xc3\xce\x7c\xec\x32\x6c\xd9\xf7\x00\xfc\xd6\xc0\x01\xba\x1e\x66\x2c\xa8\x80\x42\x00\x4c\x5a\xe2\xf3\xd7\x23\x8f\xb0\x2a\xe9\x89\xbd\x6c\x12\x90\x47\xbb\x17\xc4\x6c\x58\x94\x4b\xd1\x2f\xe2\x0f\x7a\x3b\x07\x30\xb6\x73\x24\xa8\x1a\x15\x4d\x58\x8c\xd5\xf0\x42\x96\x2b\xb4\x64\xbf\x92\xcf\x71\x08\x5f\x71\xf8\x54\x77\x8e\xc8\x60\xfa\xda\x36\x77\x49\xef\x57\xc9\x45\xd4\xdf\x9f\xda\x0c\xf2\xbf\xea\x30\xc7\x69\x30\x6a\x95\xc1\x31\x68\x7d\x75\x64\xff\x23\xd1\xca\xbe\x1f\x8f\x30\x99\x69\x54\x9a\x3d\x2b\xcb\xa6\x47\xb1\x34\xe8\x88\xdd\x83\xa1\x8f\xff\x9b\x94\x93\x7e\xde\xb5\x43\x26\xb7\x9f\xf9\x71\x9a\x03\x75\xa0\x59\x00\xa5\x0b\x01\x42\x73\x27\xaa\x9d\x67\x9f\x26\x7e\x2d\xdd\x64\xf3\x5d\xe1\x09\xd3\x6b\x7e\xb4\xc4\xf4\xe0\xdc\x87\xf4\x21\x6d\xd2\x4a\x71\x5f\xfe\x63\xa6\x30\x9c\x1e\x85\x92\x4f\x6d\xd9\xa4\xbc\x55\x18\xf2\x8b\x6f\xb5\x63\x24\x75\xfd\x59\x34\x64\xc9\x0f\xdd\xfa\x8d\x1a\x75\x45\x94\xbe\x61\xb6\x8e\x50\x5d\x27\x48\xba\x5c\x67\x85\x57\x48\x70\x93\xae\x50\xf2\x90\xa1\xcf\xb5\x26\xf7\xd7\xda\xca\xd5\xb9\x44\x3c\x0e\xe1\x22\x6f\xd2\x95\xf5\x66\x02\x79\xfb\x76\xd1\xd6\x4d\xb9\xee\x4f\x8b\x82\xfe\xeb\x5e\xb2\x59\x08\x30\x53\x5a\x67\x4a\x0b\x23\x74\xa7\xb5\x28\xc0\xd0\x0e\x06\x1f\x2a\xfb\xce\x6f\x11\x9f\x1f\x3b\xcc\x2c\x0b\x4f\xa9\xd0\xfc\xc9\x1e\x4f\x63\xc7\xce\x88\x81\xe4\x4a\xb3\xde\xd9\x50\xc7\xed\x25\xbe\x0b\x07\xbc\x0a\xab\x3e\x09\x77\x15\xcf\x7b\x34\xf4\xa5\x79\x48\xf3\xd1\x31\x74\xd7\xf1\xf1\xcb\x97\x15\x5a\x2d\xf2\xb4\xae\x41\x36\xf6\xb7\xeb\x40\x36\xd0\x3d\xb4\xde\x6c\xe0\x57\xf6\xec\x7c\xc4\x1b\x4a\x7d\x19\x05\xf2\xf0\x86\x77\x3d\x58\x08\x73\x81\xaf\xbd\xf3\x67\x16\xd0\x39\xd0\xbd\x68\xde\x8c\x42\x99\x78\x33\xf0\x7d\xb6\x12\x66\x67\xbf\x32\xc8\xc5\xdc\xe7\xed\x4d\x90\xaf\xf9\x82\x98\x00\x96\xa6\x57\x43\x5d\x1c\xe5\xcb\x3b\xc2\x19\x5a\x5f\x3e\xf5\x65\xae\xdf\x05\xe1\x12\xc4\xf2\x82\x2b\xc8\x54\x3b\x73\xee\x64\xe0\x7c\x68\x16\xe6\x63\x38\xa4\xec\x62\x67\x7d\x18\x17\xe4\x04\x9c\x10\xf5\x62\x62\x7c\xbf\xd7\xcc\x45\x39\x8f\xe4\xc5\xc6\xf2\xd4\xb0\x99\x91\x76\x0e\xc7\x8b\x95\xe9\x59\x64\x33\x1f\xf1\xb8\x9a\x8b\x85\xeb\xf5\x66\x90\x0b\x7c\xd4\xc0\x87\x95\xe9\xa1\x69\x23\x1b\x39\xaf\xdc\x83\x85\x3f\xb6\x2f\xa8\xfb\x7d\x6e\x90\x83\x29\x55\xd4\xc5\x2e\xe4\x79\x71\x90\xb1\x3b\x81\x2b\x5c\x84\xc1\xac\xfd\x59\xfa\x4e\xb0\x01\xd3\xa8\xe3\xb5\x79\x10\x1e\xdc\x56\x72\x32\x32\xbd\x89\x0f\xb3\x90\xf7\x00\x9c\xe0\xae\x57\xfb\x61\x26\x70\xb4\xd9\xc5\xcc\x10\x4b\xf5\x62\x69\x0d\xab\x79\x31\x86\x62\x68\xfe\xac\xcd\x01\x21\x1f\xe6\x01\x9c\x7c\x61\x25\x57\x02\xfb\x37\xe3\xb3\x33\xd1\xb7\x94\x7d\x8d\xcd\x3d\x7a\x8c\xde\xcf\xce\xdf\x8d\x67\x3f\x47\x7f\x8e\x7f\x8e\x46\xd9\x72\x17\xb8\xe2\x8b\x3a\x38\x37\xfc\x7a\x7a\xfe\x97\xeb\x38\x1a\x89\x1f\xdc\x7e\x0d\xc4\x48\xf6\x62\xe4\x4b\xf6\xf1\x40\xad\xd0\x43\xd2\x16\xd9\xa7\x16\x71\xae\xc0\xeb\x43\x64\x48\x77\xff\x78\x38\xe9\x9a\x3c\x9e\xcd\x3a\x21\x76\x20\x92\x77\x9b\xca\x7e\x92\x15\x98\x78\x52\xc1\xc8\x56\xd0\x10\x3c\xc5\x0f\x02\x61\x55\x5f\x49\xbc\x7c\x9b\xdc\xaa\x7e\x8f\x1e\xb5\x9e\x11\x88\x4e\xf1\x05\x43\xa7\xd1\x3d\x7a\xdc\x9d\x14\x03\xea\x68\xec\x66\xd9\x45\xdb\x1f\xb2\x49\x7b\xbb\x26\x52\xee\x3d\x77\xb8\x85\x56\x66\x43\xaa\xe0\x42\xc4\xe3\x4a\xbc\x68\x59\xa9\x0b\x1f\x77\x02\x4d\x90\xe7\x09\xf2\x37\xf9\x99\xde\xf5\x93\x2e\xa2\x85\x58\x48\x04\x00\x2e\xad\x97\xaf\x1f\x0b\xb2\x00\xbc\x56\xff\x0a\x58\x6e\x32\x75\x30\xd3\xe8\x01\xae\xf8\x83\xa7\x03\xeb\x60\xc7\x5c\xd4\x21\x55\x03\xdc\x5b\x4f\x6e\xa2\x43\xec\xcd\xb9\xf7\x71\x21\x2e\x82\x07\xec\x8f\xa8\x7a\xb3\x20\xb0\xe6\xf2\x7a\xe3\x8b\x69\x16\x10\xb4\xf8\x1d\x40\xa5\x39\x1d\x4e\x87\x1a\x82\x96\x6f\x90\xf2\x15\x58\x72\x87\x8d\xb0\xdc\x5f\x0e\xc3\xed\xbd\x5f\x23\xb2\xe0\x20\x87\x61\x53\x1f\xd9\x08\xcc\x6e\x02\xf2\x46\xc5\x77\x53\x40\x70\xe4\xd2\x0a\x1d\x07\xef\x25\x78\x38\xd8\x10\xa6\xea\x4e\x7b\x4b\x29\x38\xc8\x26\x5c\xe6\x3f\xd3\x7c\x41\x96\x81\x25\x5a\x81\xe4\xd3\x29\xdb\xe9\xdc\x96\x53\x88\xf4\x26\xb0\x00\x14\xcd\xcd\x86\x20\x75\x5f\x7c\xdf\xf8\xfc\x84\xae\xd4\xd4\xfc\x57\x98\x95\x72\x2c\x14\xe4\xa4\xd0\x04\x54\x04\x3a\xb1\x69\x63\x21\x11\xfa\xf3\xb1\x84\x1f\x20\x6e\xb6\x68\xc5\x16\x3c\x3d\x79\x05\xf1\x80\xd7\xb7\xbe\xa7\x15\xeb\x8a\x6c\x58\xda\xbd\x19\x53\x4e\xbe\x91\x11\xe4\xe3\x76\xff\x36\x9a\x76\x3d\xa1\xb1\x56\x9e\x8c\x15\x86\x2c\xd9\xc0\x83\xf3\x29\xcf\x4c\x08\x6f\x59\xef\xfe\x91\x03\x37\x10\x9e\x12\xda\xf1\x47\x56\x33\x85\x41\x70\x95\x08\xc0\xa7\x1b\x9f\xd6\x10\x12\x88\xcd\x02\x46\xfe\x22\x2b\xd1\x1f\x10\x55\x8d\x10\x79\xa3\x0b\x59\x88\x10\xb0\xf0\x39\x29\xca\x64\xd9\x6e\xf2\x6e\x6e\x44\x58\x71\xc8\x65\xcc\x4c\x59\xe8\x9a\x73\x4a\x12\x15\xb7\x65\x46\x34\x31\xe1\xd9\x87\x24\xfb\x31\xfe\x38\xb9\xb8\x3e\x8b\xa3\xeb\xab\xf3\xe9\xdb\xe8\xa6\xa9\x10\x8a\x46\xe4\x13\xde\x38\xe7\x9b\xf6\xaf\x4f\x4e\xd8\x2d\x75\x23\x31\x83\x51\xba\x73\xca\x3f\x68\x66\x96\x56\x0b\x91\x85\xb5\xbc\xe7\x7c\x61\x8c\x89\xe1\xe1\x4b\x93\x8f\x94\xc1\x4b\x33\xd2\xe4\xc1\x2b\xa4\x31\xd9\x84\xf2\x11\x06\x16\xc2\x34\x95\x70\xed\x30\x48\xe3\x98\x57\x70\xa8\xce\x24\x4c\x40\xa3\xb3\xcc\x06\x08\x8a\x7d\x83\xec\x77\xe2\x30\x08\x79\x1c\xe7\xd3\xb3\xf8\xa3\x12\x9c\xcb\xcb\xf2\xbe\xdd\x44\x97\x53\x25\x57\x81\x68\xeb\x5d\x5a\xdf\x29\x71\x3a\xa6\xa2\x42\x56\x13\x3b\xa1\xa1\x72\xb2\x45\xe7\xb2\x65\xc7\x53\x4e\x5c\x90\x46\x88\x4a\xee\x40\x17\xef\x84\xb4\x03\x0b\x94\x0e\x4c\xe1\xaa\x51\x3b\x64\x4f\x68\x44\x54\x9f\xe5\x5c\x65\x5d\xa5\xbe\x88\xc0\x0c\x74\x95\x15\x91\xf2\x36\xa7\x19\x55\x7b\x4f\x13\x00\xa4\x52\xaa\xa4\x5e\xa8\x2c\xff\xd0\x07\x56\xb0\xe0\x65\x5c\x39\xa2\xa5\xbe\xd6\xd7\x41\xcb\x49\x12\xb0\x22\xf4\x41\x2e\x1b\x3a\x79\xcd\xcf\x81\x89\x89\x5c\x48\xaa\x4a\x59\xf0\xac\x3a\x25\xa3\x2a\x4a\x65\x01\x05\xb5\x8a\xce\x4d\x14\xda\x14\xeb\xa2\x13\x59\xc7\xc0\x94\xb4\x21\xb1\xea\x26\x39\x36\xbf\x4a\xc2\x43\x71\x28\xe5\x34\x0d\xd5\x62\x28\x41\x43\xe2\x21\x17\xf3\xe2\x44\x5f\x26\x4a\xd2\xaa\x4a\x1f\x69\xee\x9c\x3f\x3f\xa1\xd0\x88\x22\x9d\x46\x2f\x4e\xbc\x38\xf3\xb7\x4f\xbc\xd9\xf1\x12\x36\x7c\x3d\xbc\xc6\x5a\xd1\xc6\x50\x7f\x8e\x48\xe2\xcc\x97\x29\x2f\x11\x58\x10\xcc\xd9\x8b\x2c\xe7\x65\x60\x27\x42\xc1\x36\xb6\x77\xba\x49\xc1\x7e\x84\x92\x5e\x24\xee\xbd\x6d\x9f\x36\x77\x1a\x73\x21\x06\x47\xb2\x21\xa7\x62\x22\x8b\x36\x67\xd9\xca\xd3\xa7\x27\x2c\x08\x84\xc2\x86\xd1\x27\x10\x5a\x60\x84\xd7\x46\xbc\xb0\x92\xb4\x49\xda\x66\x61\x83\x5c\xa6\x0d\x1a\x75\x05\x7e\x29\x0b\x34\x3a\xbe\x9e\x4f\x68\xb2\xec\xa9\xf8\xb6\xc9\x89\x3e\x0e\x04\x7e\xc2\xac\x6f\x62\xa3\xab\x80\x34\x35\x49\x11\x40\xe9\x16\x67\x76\x1f\xb6\x7c\xb1\x7c\x3f\x63\x49\xc9\x3a\x5a\xf7\xf7\x97\x41\xd3\xdb\xd5\x4f\xe5\xfb\xf4\xb5\x5a\x59\x04\x91\x2f\xae\x0f\x61\x6d\x62\xa9\x37\x41\x1f\xad\x24\xff\x50\x2b\xca\x13\x86\x64\xed\xc2\x77\x07\x9f\xe2\x70\xdf\x69\x24\xf8\x6d\x7a\xd5\xc4\xa0\x25\xc1\xe2\xd8\x42\x96\x50\x10\xba\x5e\x0b\x16\xc0\x04\x3b\x12\xdf\x65\xcf\x78\xb2\x74\x21\x9f\x8e\xeb\x0a\x72\x7b\x32\xf8\x5a\x6e\x40\x3c\x82\x61\x15\xc5\x03\x45\xbc\x3f\xdf\x8a\x25\x10\xda\x11\xd9\xc5\xeb\x50\xf3\x0d\x68\x39\xd6\x66\xf2\x7d\xee\xaa\x04\x6a\x58\xb7\xb7\x0f\xd5\xa4\x2b\x89\x23\xb5\x0d\x03\xcc\x7c\x95\x91\x7e\x27\xb1\x8b\xa7\x56\xc2\x8f\xbd\xa4\xa7\xaa\x14\xe4\x8a\x9d\xdb\x3c\x5b\xdd\x35\x9e\x95\x3e\xd5\xaf\x53\xee\x3d\x72\xeb\xc5\xbd\xda\xe5\xbc\xe4\x03\x20\xbd\x45\xfc\x7e\xd9\xc2\x79\x64\xfa\x82\x15\xd0\x27\xb6\xb0\x26\x7c\x5f\xda\xe5\xd4\x9a\xfa\xa5\xf4\x17\x80\x70\x2a\x5e\x8d\x76\x2a\x9e\xba\x39\x8b\xaf\x26\x03\x7a\xd4\x56\x07\x96\xc3\xbf\x43\xa9\xd9\x3c\xc8\x04\x55\x2f\x81\x13\xc3\x30\xde\x12\xd7\xfd\x1d\x4f\xbc\x77\xa1\xf4\x36\x49\xd0\xd1\xe4\x72\x7c\x11\x5f\x4d\x62\xfd\x36\xa8\xd3\xe8\xeb\x93\x93\xd3\x08\xa2\x60\x3a\x8c\x09\xfc\xf5\xc5\x59\x01\x7c\xfd\x99\x8f\xd8\x1d\xa1\x19\x58\x4c\x05\xa0\x81\x32\x6a\x01\xea\x76\x1e\x78\xef\xd1\x29\x74\xdd\x91\x96\x3b\xa0\x8c\x02\xf1\xe6\x1e\xc1\xe5\x16\x58\x62\x6f\x9b\xdf\xdc\x03\x22\xe0\xe8\x7a\x53\xad\xd6\x50\xd1\xbc\xfc\x8c\x2a\xe5\x66\xb2\x8e\x53\x47\x9f\x94\x1b\xdd\x77\x97\x2e\xfe\xb1\xc8\xc4\x2e\xfe\x01\xcb\xb7\x55\x66\x6e\xbe\xb6\xca\x4c\xfd\x20\x5d\x02\x93\x90\xdb\x21\x7b\x8b\x44\xbe\x22\x46\xc2\xc4\xa4\x26\x54\x43\x58\x91\xfe\xa3\x43\x36\xe4\xf5\xc9\x4b\xb3\xd4\xd9\x62\x1f\xf3\xb3\xbd\xa7\xc2\xd1\xe6\x01\xea\x4d\x02\x6f\xd0\x61\x31\x2a\xa2\x22\x11\x40\x39\x98\x6b\x7f\x48\x0b\x64\xc5\x0f\x84\x79\xe1\xcf\x67\xe7\x6f\xdf\xc6\xb3\xa8\xa9\xb2\x15\x7d\x87\xbd\x0f\xab\x70\xb3\x2f\xe9\x9c\x55\xe1\x54\xc8\x9b\x79\x3c\x8b\xce\xe2\x8b\x78\x1e\xcb\xf1\x9c\x37\x97\xb3\x28\x1e\x4f\x7e\x8a\x66\x97\x1f\xa2\xf8\x63\x3c\xb9\x9e\xc7\xd1\xfb\xd9\xe5\x24\x3e\xbb\x9e\xc5\x91\x27\x83\x91\x4b\x40\x1e\xeb\x90\x01\xfa\xc3\x3e\xaa\x80\xbd\xec\x9e\x12\x3a\x38\x18\x25\xc4\xa7\xea\x52\xb1\x8e\x24\xea\xd8\x07\x81\xa2\x1f\xe3\x37\x97\xb3\xae\x4b\xaf\xe2\xd9\x3c\xba\x9c\x45\xd7\xef\xcf\xc6\x81\x0d\xe9\x66\x33\xda\x45\xc2\xa5\x72\xc9\x69\x72\x7b\x8f\x1e\x3b\xd1\xe2\xf3\xb7\x53\x12\x1b\x56\x28\x4e\xa2\x59\xfc\x26\x9e\xc5\xd3\x49\x7c\x45\x94\x73\x47\xa9\x9f\x75\x4b\xc3\xfc\x46\x49\x14\x0a\x7f\x49\xb6\xca\x0d\x85\x1b\x45\x8d\x38\x8b\xb2\xc8\xd9\x97\x9d\x50\x5d\xcf\x53\x5d\x9d\x8c\xaf\x26\xe3\xb3\x78\xb7\xa9\xa3\x2c\x58\xa2\x0b\xca\xf2\xcb\x44\xf9\xba\xdf\xb6\x93\xca\x37\xf1\xd4\xdc\x97\xd6\x3e\xf4\x14\xc3\x91\xa5\x4a\xe3\x4f\x20\x7b\xfe\xcd\x20\xc0\xd0\x96\x71\x88\xb4\x07\xcd\xda\x89\x5c\x6a\xb8\x1f\x90\x4b\xdb\x11\x10\xe5\xea\x9c\x90\x7d\xb6\x9b\xb6\x6f\x60\x15\xd0\xae\x5b\xc1\x22\x6e\x9d\xc3\x0c\x74\xb2\xf0\x59\xee\xe0\x7e\x85\xdc\x4e\x56\xcf\x8c\x61\x21\x45\xd8\xa6\x8b\x06\x35\x3c\xb4\x90\x64\x57\xc7\x26\x28\xdd\xf7\x01\xc7\x4c\x87\x75\x20\x81\x9f\xb8\xf7\xbd\x92\xad\xf7\xbf\x5c\x04\x26\x47\xef\x4a\xaa\x1d\xa7\x52\x2b\x9b\x1f\xc6\xb5\xc4\x77\xd6\x99\xc5\x57\xf3\xd9\xf9\x64\xbe\x63\x31\xfb\x4d\x21\x5d\xc2\x7e\xb3\x47\x14\x4e\xdf\x4d\x19\xd4\x9e\xdb\xa7\x8d\xff\x07\x8b\x3e\xd4\xc0\x09\x56\x87\xb0\x1c\xf9\x27\xd2\xd9\x30\x21\x95\xb3\xe6\xb7\xf7\x4a\xcf\x4b\x9f\x1d\xdd\x2f\x82\x0d\x52\x85\xdd\x9c\x10\x38\xd0\x14\xb6\x1b\x61\x0f\x3b\xec\x02\x13\xf6\xe9\x93\x95\x80\x68\xf4\x8b\x24\x98\xb8\x29\x12\x28\x52\x58\xae\xff\x21\xc4\x72\x24\xf4\x1b\x2d\x74\x87\x5d\x4e\x63\x10\x6c\xe9\xde\x85\x28\x5b\xcc\x7e\x43\xa4\x19\x70\x3e\x61\x48\x63\xed\x88\xb5\xb6\x3f\x04\xce\x0a\xda\x1e\x92\x6f\x4c\x63\x80\x44\xf2\x2b\xa1\x50\x80\x45\x22\x08\x93\xc5\xf3\xc8\x85\x30\x96\xf0\xce\x55\x88\x06\x7b\x2c\x46\x01\x92\xec\x77\xe9\x1e\x76\x40\x02\x7e\x3f\x0b\x10\xd1\x40\x29\x89\x0c\x1e\x8e\xd8\xd3\x91\x8b\x81\x8d\xb9\xe7\x43\x19\x7b\x0c\x6e\xee\x50\x4a\x70\xaf\x11\x9c\x2c\x00\x3a\xc5\xa0\xd0\xb6\xdd\xb6\x99\x6f\xb7\x39\xaa\xa2\x6c\x35\x42\x2a\xac\x90\x78\xcf\xc5\x3b\x11\xcb\xac\x12\x1a\x8d\xbf\x52\x04\x9f\xeb\xd8\xd5\xf2\x14\xcc\x78\x87\xe3\xd5\xeb\x34\xc8\x21\x1d\x12\x2f\x81\xf6\xbc\x0e\x79\xc9\xb0\xdf\x15\x68\xd0\xb9\x9a\x1d\xea\x45\xc8\x61\x98\x5d\x73\xdd\xe2\x7c\xcb\x70\x59\x26\x97\xef\xde\x9d\xcf\x5f\x1d\xfd\x5f\x00\x00\x00\xff\xff\x9d\x1b\x4f\xc0\x98\xf7\x00\x00")
+var __1528395650_squashed_migrationsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xd4\x7d\xfb\x73\xdc\xb6\xb5\xff\xef\xfa\x2b\xf0\xed\xa4\xa3\xdd\x8e\xec\x89\xd3\xa6\xf9\x5e\x3b\x6e\x66\xb3\xa2\x1d\x4d\xe5\x5d\x77\xb5\xaa\xe3\xdb\xe9\xe5\x50\x4b\x68\xc5\x8a\x4b\xae\xf9\x90\xad\xf6\xb6\x7f\xfb\x1d\x80\x00\x08\x80\x07\x0f\x72\x1f\x4a\x66\x32\xb1\x96\x38\xf8\x7c\x0e\x80\x03\x10\xe7\xe0\xc1\x1f\x83\xb7\x17\xb3\x57\x27\x27\x93\xcb\x65\xb0\x40\xcb\xc9\x8f\x97\x01\xba\x78\x83\x82\x9f\x2f\xae\x96\x57\x68\x3e\xbb\xfc\x88\xea\x12\x17\x21\xfe\x52\xe1\x22\x8b\xd2\x30\x5a\xad\xf2\x3a\xab\x4a\x74\xbe\x98\xbf\x47\xd3\xf9\xec\x6a\xb9\x98\x5c\xcc\x96\x52\x2e\x38\x43\x48\x1f\x27\x71\x78\x7b\x8f\x1f\x5f\xb9\x09\x37\x51\x92\xba\x59\xa8\x94\x3f\x74\x59\x17\x0f\xf8\x31\x2c\x70\xb9\xcd\xb3\x12\xdb\xf0\x75\xd1\x1e\x24\xb8\xaa\x92\x6c\x6d\x05\x67\x22\x87\x01\x2d\xf0\x2d\x2e\x70\xb6\xc2\x65\x98\x17\xeb\x72\x6f\xb8\x51\x5d\xdd\xe5\x45\x0f\x9d\xa3\x07\x1c\x87\x25\x8e\x8a\xd5\x9d\xbd\xae\x15\xc1\xc3\x13\xe4\xc5\xda\x0b\xbf\xc0\xeb\xa4\xac\x8a\x47\x6a\xcd\x59\x99\xe4\x99\x8d\x04\x90\x0e\xb7\xf5\x4d\x9a\x94\x77\xb8\x47\xad\xed\x93\x74\x78\x41\xc3\x02\xa7\x38\x2a\x71\x3f\x6e\x91\x2b\x04\xd2\x9e\x46\x91\x55\x81\xa3\xaa\x8f\xd5\x6e\x8b\x3c\xae\x57\x55\x58\xd6\x37\xe5\xaa\x48\xb6\x95\xa3\x05\x40\xf9\xfe\x74\x69\xb2\xc2\x8e\x21\x49\x17\x0d\x21\x6a\x2f\x52\x62\x17\x1b\xbc\xb9\xc1\x85\x8d\x4f\x92\xf2\x2f\x4f\x7f\xe8\x3e\xc3\x15\xc9\x97\x64\x0f\x49\x15\xb9\x9a\x45\x93\x0c\x4b\x9c\xc5\x7d\xba\xe1\x70\xa6\x02\xaf\x92\x6d\x82\xb3\xea\x18\x64\xbe\x3d\x3c\x8b\x36\x56\xdb\xa2\xe9\xfe\x0a\xfb\xc1\xf9\x2a\x97\x96\xc9\xad\x64\x06\x16\x5c\x4d\x32\x8c\xeb\xcd\xd6\x9f\x62\x1b\xad\xee\xa3\xb5\x9b\x80\xcb\xf9\xc3\xc7\x49\xb9\xaa\x4b\x3a\xf2\x54\x77\x05\x8e\xe2\x32\xac\xa2\x62\x8d\xab\xb0\xc0\xdb\xdc\xc2\x67\xcf\xc8\x9e\x3d\xa9\x0a\xf4\x7f\xc3\x14\x18\x4e\x4a\xf9\x0e\xca\xd6\x77\x32\x23\x41\x90\x49\x27\x51\x33\x7d\x0c\xab\xfc\x1e\x67\x9e\xcc\x9d\x6c\x4f\x4b\x3e\xcc\xb4\x56\xf9\x66\x83\xb3\xca\x93\x95\x4b\x1f\x97\xac\x77\xd3\xe2\xdb\xa8\x4e\x1b\x73\xb7\x72\xc9\x72\x92\x9d\x3a\xe0\x57\x77\x51\xb6\xc6\x25\xb6\x96\xa3\x15\x1a\x00\x1c\xfe\x23\xbf\xf1\x02\xa7\x82\x61\xfb\xf3\xa0\x2c\xd1\x66\x1b\x25\xeb\x8c\xfc\x3a\x0e\x51\x3f\x12\xfc\xe0\x30\x2d\x5d\xb4\x6f\xbd\x31\xb5\xac\x14\x5c\x26\xa4\x2f\xcc\x6d\xb4\xc2\xfe\x66\x3b\x94\xc0\xf7\xad\xdc\x0f\x9f\xff\x15\x6e\xd3\xc8\xb3\x29\x7a\xe1\xb3\x4e\xdd\x07\x98\xaa\xe2\x83\xde\x08\xf6\xaf\x79\xa7\xa1\xca\x72\x3d\xfa\xf5\x20\xf8\xfe\x0d\x10\xad\x56\xb8\x2c\xdd\xaf\x11\x45\x8e\xf8\x45\xff\xc0\xab\x1e\x93\xdb\x61\x34\xb0\xdf\x46\x73\x2f\x17\x17\x6f\xdf\x06\x0b\x29\x6b\x55\x24\xeb\xf0\x21\x4a\x93\x38\xaa\xb0\x6e\x8a\x65\x78\x9b\x64\xc4\x1f\x8e\xd1\x7c\x26\x2a\xad\xb4\x83\xc5\x38\xc5\x15\x96\xba\xbc\x98\x74\x86\x79\x26\x18\x4a\x8a\xc8\x65\x3c\x21\x59\xde\x76\x1a\x4b\x11\x05\x08\xa4\xe4\xc5\xec\x3c\xf8\x59\xc2\x23\x95\x52\xd2\x16\x20\xdd\xda\x2a\x74\x93\xa4\x69\x92\xad\xc3\x55\x5d\x56\xf9\x86\xd6\xa5\x45\x1e\x88\xdb\xb1\x3f\x0c\x99\x9a\x10\xcc\xa7\x1a\x17\x09\x2e\xe9\xbf\x8f\x61\x9d\x25\x9f\x6a\x93\x56\xb4\x17\xd4\x45\x12\x26\xf1\x17\x9b\x48\x99\xd7\x05\x99\xe1\xaf\x93\xcc\x25\x4a\xea\x20\xac\x8a\xf5\xc6\x26\xb4\xc1\x55\x14\x47\x55\xe4\x03\x28\x2a\xa1\x29\x89\x55\x5c\x8f\x3c\x94\x61\x5d\x27\x71\x0f\xf1\x36\x5a\x63\x69\x4b\x5b\x84\xe3\x01\x17\xe4\xc1\x80\x9c\x40\x9a\x51\x75\x12\x49\xb4\x69\xa8\x3b\xa6\x65\x92\xad\x53\x7c\x9b\x26\xeb\xbb\xca\x33\x4b\xc7\x71\xf6\xcc\x47\xa9\x4d\x7a\x53\x8f\xb1\xde\xa6\x39\x71\x31\x1e\x92\x32\xb9\x49\x31\x1d\x8a\xcb\xa4\xca\x8b\x47\x32\xb4\x90\x59\x6a\x52\xf9\xe4\x6f\x70\x70\x1c\x46\x5e\xe2\x65\x15\x55\xd8\x47\x10\xd2\x27\x2c\xf2\xdc\xca\x22\xc6\x8f\x92\x3b\xac\x36\x69\x26\x22\x64\xed\xbd\x94\x12\x34\x7a\xe8\xda\x6d\xa3\x82\x34\x91\xbb\xd2\xe0\xec\xac\x70\x0a\x8a\x5d\x17\x3a\xfb\x0a\xd3\xbc\x0d\x97\xbb\x05\xab\x64\x83\xcb\x2a\xda\x6c\xc3\xa8\x0a\xeb\x6a\xd5\x23\x87\x5b\xb4\x19\x9a\xdc\x72\x96\xde\x02\x38\xa4\x90\xcf\xbd\x8d\xaa\x3b\xcb\xe8\x03\xa0\x68\xbe\x8f\x57\xde\xae\x6f\xc8\xb3\xb7\x6e\x9b\x17\x10\xe0\xee\xf5\xcb\x47\xca\x5d\x90\x77\x4e\x51\x44\x8f\x61\x8a\xb3\x75\x75\xd7\x13\xc2\xbb\x02\x56\x45\x52\x25\x2b\xb2\xa4\x95\xc5\x61\x99\x90\x97\x7d\x9e\xdd\x26\x6b\xbb\x31\xb6\x13\x02\x3a\xe9\x2a\xab\xa8\xa8\x6c\xe3\x81\x26\xcf\x67\x22\xde\x19\x70\x51\xe4\x85\x9f\xa8\xe6\x70\x99\x32\x01\x1e\x01\xab\xac\x1e\x39\xac\x43\x6e\x9b\xa1\xd5\x30\x89\x5d\xaf\x72\x9e\xcb\xb7\x62\x15\x71\x8f\x7a\x55\xe4\xf5\xc9\xb2\x21\x93\x3a\x25\x4d\xf3\xfc\xbe\xde\x5a\xe7\xba\xec\x5d\x6c\x9b\xe6\x72\x91\x70\xeb\x9a\x38\x93\x66\xb1\x21\xd1\x74\x3f\x98\x70\x8b\x8b\x4d\x52\xba\x74\xd3\x45\xe9\xdf\x61\xde\x4c\xf8\xb7\x45\xfe\x90\xd0\x18\x3a\xeb\x20\x6e\xce\x2c\x26\x53\xcf\x5e\xdc\x9d\x2c\x8a\x0e\xbe\xd4\x9d\x69\xac\x8b\xb8\x93\x21\xdc\x1e\x60\x9d\x9a\x6a\x1f\x3e\xe0\x22\xb9\x4d\x70\xdc\x3c\xde\x33\x47\x96\x87\x71\xbd\x4d\x93\x55\x54\x91\x15\x22\xb6\xe4\x71\xb0\x55\x71\x67\x2d\xf5\x59\x60\x76\x83\xad\xee\xf0\x26\x0a\x37\xc9\xba\x70\xae\x94\x74\x64\xc3\xed\xa1\xd6\x93\x9d\xc0\x8e\x08\x3c\x49\xf6\x03\xf1\xec\x4b\xba\x28\xfd\xbb\x57\x07\x66\x08\x7d\x3a\xb0\x29\x0b\xfd\xdb\x97\xd3\x85\x4f\xe6\x54\x7e\x58\xba\x6b\x63\x57\xbd\x23\xed\xd3\x1e\x46\xcf\xaa\x17\x55\xeb\x8f\x39\x29\xa1\x05\x5f\x5b\xb9\x40\xf9\x70\x7b\xd0\x35\x69\x27\xf8\x5d\x74\x53\x90\xe1\x29\x2f\x9c\x11\xf7\x8e\x6c\xd8\x1a\xc1\x61\x79\x9c\x75\x44\xfc\x61\x0b\x22\x49\x0e\xb7\xfb\x5f\x2c\x3f\x00\x24\x8b\x07\xf3\x19\xb3\x0f\x81\xe4\x7d\x3b\x48\x64\x3f\x7d\xbb\x9f\x55\x65\x27\x8c\xec\x5a\x5b\xd0\x14\x0f\xdc\x0f\xb4\x75\xb9\x5d\xb8\xb2\x73\xee\x05\xcd\xdc\x72\x27\x30\x97\xf3\x84\x65\x4e\xb8\x0b\x95\xfb\xea\x4e\xd0\x75\x9a\xdf\x44\x69\x13\xd9\xb0\x80\xca\x62\x6e\x50\x31\xf9\x2a\x71\xf1\x90\xd8\x6b\xb7\x23\xeb\x01\x2f\x5c\x72\x1b\x6e\xeb\xb7\x3b\x01\x01\xbf\x5b\xf2\xde\x2d\x24\xf6\x8c\x43\x88\xfb\x91\xf5\x21\xe8\x44\x05\xfc\xa8\xba\xc1\x84\x3e\xa4\xdc\x8d\xf7\xe3\x12\x4e\xff\xf6\x30\x4b\xbc\x4e\x58\x53\x0c\xc1\xc2\x60\x0c\x3b\xb8\xc9\xb8\x33\x6d\x2b\x40\x2b\x14\xaa\xa1\xec\x24\xf6\x99\x36\xf5\xe5\xf0\x57\xda\xb9\x98\xa5\x08\xf6\xd2\xb5\x27\xf4\xf6\x90\x6b\xc4\x07\x05\x6f\x1f\x24\x71\x78\x9f\x64\xf4\x65\xed\x55\x55\x2c\xdc\x61\x65\xe3\x32\x1e\x8d\x3a\x70\x81\xd5\x1f\xd8\xd5\xa2\xb2\xdc\xa1\x60\xc5\x2f\x52\x4a\x1e\x97\x7d\xf0\xa9\x6e\x25\x6c\x64\xe1\x53\xc3\x4b\x0f\x51\x5a\xe3\xb0\xbc\x8b\xbe\xf9\xf6\x8f\xce\x59\xd8\x30\x0a\x5b\x4d\x91\xf9\x5f\x89\x9a\xb4\xe9\xfc\xf2\xfa\xdd\x0c\x25\x71\xa3\xfc\x79\xf0\x66\x72\x7d\xb9\xb4\xe5\x04\x7d\xc5\xc1\x60\x9d\x68\xcc\x20\xbd\x3a\x71\x8d\x41\x20\x3c\x7e\x31\x28\xb3\x1a\x50\x18\x02\x41\xec\x6e\x60\x46\xdd\xd1\x2c\xf7\x84\xd3\xba\xb9\x43\xf0\xba\x7e\xd9\x10\x14\xe2\x5f\x0d\xcd\xc8\x3d\x9f\xc1\xf9\x65\xf7\x67\x08\x86\xe2\xa0\x0c\x06\x90\x9c\x91\xc1\x18\xdc\x9b\x18\x5e\x0c\xee\x60\x0c\x01\xe8\xcc\xe5\x87\xa1\xb4\xd3\xfb\x21\xd9\x81\x69\xb2\x3c\x99\xdf\x0f\xe4\xae\x30\x62\x5e\x3c\x04\xc7\x38\x4b\x1d\x04\xc6\xe7\x20\xe5\x6e\xd9\xe9\x3b\x76\x47\x08\x36\x83\x1a\x04\xc2\x5e\xee\xbb\xe5\x66\x13\xa0\x9d\x20\x06\xd7\x83\x3a\x05\xb0\x23\xd0\x5f\x3a\x00\x5f\x89\x62\xc9\x57\xc1\x5f\xae\x83\xd9\x54\x96\x20\x2f\xe3\x92\x04\x85\x4a\xfc\xc9\x00\x42\x45\x2c\x69\xf2\x84\xc0\x4e\x04\xc6\x8e\x9d\xdc\x50\x2e\x07\x4f\x67\x76\xe1\x2e\x61\x77\x7d\xc8\x2a\x4c\x9c\x60\x8b\x16\x9d\x55\x14\x2b\xbf\x2e\xcd\xc4\xfe\x7a\x11\x7c\x90\xa5\xda\x7e\x6d\x21\xe6\x2b\x2d\x76\x42\x2e\x75\x73\x5f\x6f\xc3\x17\xdf\xbe\xf8\xc3\x77\x5f\x7f\xf3\xdd\x77\x7f\x74\x88\x9b\x92\xf5\x75\x18\x8b\x7a\xea\xb2\x8a\x5d\x49\x45\xd6\x2a\xc4\x76\xa7\x19\x64\xd8\xb2\x85\x6e\x3c\x06\xb1\x1e\xb6\xc6\x37\x71\x98\xcb\x40\x24\x6c\xf9\xf5\x89\x97\xa3\x4a\x80\x0c\x7d\xd0\xdb\x75\x88\x9e\x2c\x22\xa3\x21\x07\xa9\xff\xc7\xb0\xa8\xb3\x0c\x17\xca\x9e\x28\x5d\x10\x5c\xae\x70\xc8\xf2\x85\x07\x73\x49\xbb\xc1\x7d\x6b\xf9\x3a\xe2\x66\x64\x32\x09\xb5\x83\x11\x09\x6b\x7e\x3e\x17\x75\xc2\x08\x41\xd1\x29\xbf\xfd\xfd\x1f\xbf\xfb\xfd\x0b\x77\x0e\x3b\xbf\x1c\xa0\x77\xea\x20\x09\x1b\xa4\xc8\xd2\x8c\x85\x51\x8f\x8d\x5b\x19\x35\x61\x07\x2a\x9f\xca\x7a\x60\x72\x51\x07\x22\x39\xfd\xa5\xc1\x69\x83\x6e\x2b\x66\xa3\x63\x33\x7d\x07\x1b\x0f\xc1\xbb\xd5\x67\x92\x06\x11\x39\xf4\x6e\xa6\xec\x4c\xbd\xed\xbc\x1d\x71\x0b\x72\x1b\x48\xb7\x43\x0a\x39\x33\x96\x23\x5e\x6e\xc5\xb7\xe7\xed\xc5\xd9\x93\xc7\x2d\xd8\x09\x92\x7b\xa9\x23\xc2\xdd\xbe\xfa\xf0\x0c\x26\x49\x39\xd8\x6d\xd6\xc0\x18\xb1\xb6\xaa\x61\xca\x65\xe1\xe1\xf3\x7a\x47\x01\xc5\xfc\xbf\xf4\xc0\xa2\x6e\x86\x27\x1e\x95\xf5\xc1\x6c\xfc\x0e\x5f\xd4\x46\xda\x82\xcb\x3c\x01\x17\x20\x17\x73\x23\xb1\x70\xab\x17\x5c\x23\xeb\x81\xe9\x51\x91\xb2\xa8\x19\x51\x0d\x49\x5a\x11\x15\x51\x26\xf3\xe6\x7a\x36\x5d\x5e\xcc\x67\x92\x98\xfb\xe4\xc7\x68\x6c\xce\xed\x79\xd4\xc3\x07\x82\xd3\xab\x08\x1c\xb8\x85\x58\x7e\x7c\x6f\x78\x4b\xa8\xf3\xa3\x8f\xef\xc1\x2e\x95\x17\xb4\x47\xbd\x3a\x39\x99\x2e\x82\xc9\x32\x40\xc1\xcf\xcb\x60\x76\xc5\x74\x9a\xcd\x97\x22\x47\x52\xe1\x2f\x95\x5b\xee\xae\xac\xf2\x02\xbb\xe5\xb6\x6b\x76\xce\x82\x0b\xd2\x82\xe8\x7a\xa1\xc9\x15\x0a\x66\xd7\xef\xd0\xe8\x04\x21\x84\x4e\x79\xfa\xe9\x59\xf3\x9b\xc8\x9c\x9e\x8c\x35\x94\x4e\x25\xe8\x30\x9f\x6a\x5c\xe3\x98\x83\x6c\x8b\x9c\xd8\x46\x92\xad\xf9\x93\x55\xbe\xd9\x92\x66\x10\x22\x74\xef\x2c\x8e\x15\x2a\xd1\x78\xde\x4d\x86\x16\xc1\xf2\x7a\x31\x6b\x0e\xf1\xac\x71\x41\xc9\x2e\x27\xb3\xb7\xd7\x93\xb7\x01\xda\xa6\xdb\x75\xf9\x29\xa5\x0f\x27\x57\xe8\xab\xaf\xe8\x5f\xf4\x5a\x1d\xfa\x17\xf9\xef\xfa\xfd\xf9\x64\x19\x88\x9f\xa8\x1d\x1b\x4a\xf1\xf0\x2a\x58\xca\x02\x5c\xab\x24\x2e\xd1\x6b\x49\xfe\xb9\x92\xf2\x0c\xcd\x2f\xcf\x9f\x27\xf1\xcb\x97\xa4\x99\x45\xfe\x0f\x3f\x05\x0b\x98\x4e\xcd\xfe\x83\x92\xfd\xd5\x89\xc8\xd2\x94\x98\xa4\xbe\xa2\xcf\x82\xd9\xf9\xab\x93\xaf\xbe\xb2\xd4\x22\x67\xd0\xaa\x91\xb1\x1d\xa8\x16\x39\xba\xa9\x12\x85\x52\xac\x16\xb9\xfc\x73\x35\xc5\xb7\x16\x0d\xd9\xf7\x50\x8b\x3e\xe3\x57\xdf\x0a\x3c\x0f\xa6\x97\x13\x5a\x00\xe2\x78\x25\xd9\x1a\x25\x59\xf5\xea\x84\xd7\x29\x7f\xf8\xf2\x35\xeb\x5e\x57\xc1\x65\x30\x5d\xa2\xe9\xfc\x7a\xb6\x1c\xfd\x6e\x8c\xde\x2c\xe6\xef\x44\x99\xe9\x9b\xf1\x44\xd4\x0a\xd2\xb7\x4f\xa3\xd7\x68\x16\x7c\x78\xae\x3f\xa6\x39\x26\xb3\x73\xc4\x8b\x11\x46\x15\xba\xb8\x42\xb3\xeb\xcb\xcb\x13\x84\x48\xb7\x44\x64\x18\x1c\x71\x6d\xfe\xdf\x6b\xf4\xf5\x18\x2d\x7f\x0a\x88\x8a\x08\x2d\x26\x17\x57\x64\x30\x9a\x06\xef\x69\x3d\x9d\x4e\x19\xc1\xfb\x34\xca\xfe\x75\x71\xfe\x12\xfd\xf6\xdf\xe8\x2e\x2a\xd1\x6f\x51\x9d\x71\x0e\x44\x74\x65\x43\x00\x02\xd5\x3a\xe3\xa5\x27\xd6\x1d\xcc\xce\xd1\xc5\x1b\xaa\x09\x6b\xb0\x59\xf0\xe1\xd5\x49\xa7\xb1\x9a\x97\x9d\xf2\x42\x62\x35\x97\xc4\xe8\x26\x59\x27\x59\x45\x47\x4a\x52\xb6\x86\x5c\x3b\x28\x49\xea\x1f\xaf\x71\xa1\x49\xc9\xab\x7e\xe8\xe6\xb1\xc2\x91\x26\x90\xe5\x15\x46\xc4\xb4\xb4\xe7\xf4\x80\x64\x53\xa5\xe2\xec\x0a\xfa\x9c\x54\x77\xf4\x27\xfa\x67\x9e\x61\x1e\xff\x43\x59\xfe\x79\x34\xd6\xf2\xa7\x51\x49\x75\xb3\x22\x34\x25\x69\xc6\x4a\x0f\x41\xed\xd0\xa6\xa1\xc8\xe5\x2a\xdf\xe2\x92\x96\xe9\x6f\x7f\x17\x69\xf2\x28\x2d\x66\x0d\xd0\x5c\x81\x62\x5c\x2d\x27\x8b\x25\xfa\x70\xb1\xfc\x09\xbd\xa0\x0f\x2e\x66\xd3\x45\xf0\x2e\x98\x2d\xd1\x8f\x1f\xd9\xa3\xd9\x1c\xbd\xbb\x98\xfd\x75\x72\x79\x1d\x88\xdf\x93\x9f\xdb\xdf\xd3\xc9\xf4\xa7\x00\xbd\x10\xd7\x9f\x59\x59\xd1\xfc\xc3\x2c\x38\x27\xe0\x4a\xea\xf3\x24\x6e\xd5\x6e\x6c\x44\x98\x1b\x31\x44\x87\x8d\xe8\xa6\x09\x4b\x31\x67\xc7\x94\xf8\x00\x99\x47\x9c\xdc\xde\x42\xcf\xe9\x4b\x11\x4a\x68\x4f\x5c\x38\x1a\x59\xee\xcc\x1e\xe6\xb0\x83\x89\xd6\xdb\x78\xa7\xfc\x37\x51\x49\xce\xfa\x81\xf5\x10\x63\x11\x62\xa2\xc9\x4d\x0e\x69\x0d\x5d\x69\xc6\x90\x43\x85\xab\x3b\xbc\xba\x47\xd3\x9f\x82\xe9\x9f\xd1\x68\x24\x18\xbe\xff\x13\x3a\x3d\x6d\x5e\x01\xe3\x31\x68\xca\x2a\xde\xb1\x4c\x19\x62\x6d\x4d\x59\x49\xb5\x99\x32\x19\x39\xbd\x6d\xb9\x7a\xdc\x1e\x64\xc4\xda\xd5\x1c\xa2\x62\x5d\xd3\x73\x66\xa0\x72\x51\xb6\xc2\xa9\x1d\x9d\xa9\x61\x1d\xdc\x20\x0b\xa2\xb5\xd7\xbe\xdc\x49\xfd\x68\x76\xa4\xa4\xf5\x31\x26\xd9\xd3\x3b\xa2\x35\xc9\xb4\x80\x39\xd1\x64\x8b\x3d\xb9\x4c\x89\x44\x11\xfd\x3b\x2d\x3b\xf2\x67\x6c\x92\xce\x41\x37\x2e\xa7\x27\xd3\x78\xa8\x40\xf9\x65\x18\xad\x3a\xd9\xfc\x47\x99\x67\x37\x42\xfe\xf4\x5f\xff\x3e\x7d\xf9\xb2\x79\xa6\xe5\x92\x5b\xa2\x5b\xa6\x34\xf7\x7a\xef\xdf\x14\x51\xb6\xba\xb3\x0f\x8f\xfa\x21\x3f\xd5\xae\xa9\x6e\xd4\xa8\xf3\xdb\x91\x22\x38\x46\xaf\xd1\x69\x73\xaa\x4b\x98\xba\x85\xe4\x2e\x2a\xc3\x17\xed\x01\x44\x41\x30\xea\x36\x2e\x9b\x60\x8e\x49\x37\x1a\x75\x1a\x97\xa7\x5a\xd9\x48\xae\x30\xcb\xab\xf0\x26\x8d\xb2\xb6\x34\xe4\x71\x8f\xce\x79\xfc\x7e\x69\xee\x92\x60\x6f\xd4\x17\xd2\x1d\xe3\xbb\xd4\x7c\xb0\x04\xd9\x92\x08\x75\xdb\x7b\xfc\x78\x88\xf7\x01\xbf\xd2\xc1\xbb\x57\xec\xda\x17\x65\x5b\xd1\xea\x8e\x6c\xd8\xd3\x6c\x9f\x94\x5a\x31\x16\x0f\x10\xb2\xa7\x53\x43\x21\x75\xda\x13\x46\xdc\x75\x61\xe9\x8c\x5c\x06\xea\x87\xb0\x55\xeb\x2c\x47\x33\x6e\x98\x58\xb2\x71\x4d\xc0\x6a\xea\x7d\x26\xe5\x4e\x81\xe6\x6a\x2b\x83\x90\xa0\x14\x12\xfa\x0c\x7c\x3f\xdd\x60\x57\xab\x3e\xc0\xc4\x5f\x7a\x71\x38\x6c\xe9\xb8\x93\x61\x88\x16\xb2\x23\xd3\x74\x98\x0b\xf4\xb0\x20\xff\x77\x36\xf7\xf2\xd8\xab\x7a\xcf\x83\xe5\xae\x56\xd2\x7b\xb0\x15\x4b\x86\x09\xf8\x4e\xd0\x57\x14\x8d\x5e\x83\x10\xf4\x8e\x42\x88\x1c\xd6\xf9\x8b\x68\xcb\x76\x62\xee\x9a\xbf\x48\x72\x7e\xd3\x97\x96\x43\xe8\x94\xe8\x03\xbc\x94\xe2\x3b\xce\x97\x21\x58\x7b\xc0\x8c\x05\x94\xf3\xa7\xe1\x8d\x6e\xab\x95\xe1\x2f\x92\x27\xe8\xf8\x96\x4e\x0f\x76\x78\xc3\x22\x66\xdb\xfd\xe1\xce\x4a\x0c\xa6\xb3\x5c\xa3\xc9\xac\xf2\xac\x32\x3a\xa3\x4f\xd6\xd9\xe1\x16\x33\x54\xc3\xf1\xda\xcf\xae\x80\xd4\x9a\x06\x41\xa0\x6d\x95\xd5\x6f\x34\xb2\x0e\xc1\x27\xe3\x4e\xee\xee\x2a\xbb\xe3\x9d\x20\xee\xc4\x81\x93\xd5\x7b\x6b\x0c\x76\xf5\xcb\xb4\x99\x9e\x41\x62\x76\xc9\x0f\x8f\xff\x72\xcc\xe6\xc5\xe8\x13\x14\x36\x6f\x89\x38\xbc\x25\x9a\xb9\x5b\x23\x04\x64\x20\xfb\xb3\x6c\x07\x61\xa6\x44\x03\xd0\x50\x5b\xdb\xad\xc4\x61\x69\x1e\x0d\x65\xb5\x77\xb6\xcb\xc5\x61\xee\x5e\xf6\x5c\x25\x55\x8a\xa5\xf7\xb3\xba\xb9\x87\x01\xef\xc7\xbc\xc9\x9d\x1e\xc9\x83\x1d\xe0\x68\x1d\xc1\x65\xd6\xac\x8a\x9f\xc2\xaa\x55\x6a\xd0\xa8\x99\x88\xdd\xa6\x81\x3d\x57\xbb\x0d\x90\xa6\xb1\xb9\x49\x25\x37\xa5\x49\x86\xd4\x99\xf9\x15\x98\xdc\xfd\xa7\x84\x0d\xa9\xdf\x13\xa6\x49\x86\x39\x26\x9b\x3e\x66\x31\xf0\xb4\x91\x5e\xdd\x45\x45\xb4\xaa\x70\xd1\xcd\x62\x48\x22\x48\x65\x78\x83\x6f\xf3\x42\xb6\x74\xfa\x58\xff\x1d\x46\xb7\x24\xbf\xd1\x6d\xb2\x57\xef\x53\x5a\x4b\x57\x0d\x9b\xe5\xc8\xe2\x80\x15\x49\x07\x7a\x86\x85\x89\xeb\x22\xed\x3f\x6a\x46\x59\x9e\x3d\x6e\xf2\x5a\xdc\x7e\x07\x41\x34\x57\xff\x41\x29\x7c\x71\x81\xf9\x47\x6a\x22\x3b\x69\x01\xe5\xfb\x8d\x18\x23\x7e\x63\x1e\x6e\xd4\x2c\xd2\x5c\xbd\xad\xaa\x66\x8a\x4e\xe3\xa4\x44\x7f\x31\x57\x1f\x8d\x78\x71\xe8\xda\x3a\x59\x8d\x1f\x75\x8b\xaa\x78\x04\x68\xbe\x40\x6d\xb6\xef\xff\x64\xc9\xf7\x7a\x58\x36\x85\x6e\x3c\x76\x17\x4b\x04\x64\xf1\x66\x5b\x3d\x5a\x02\xb2\x6e\xa8\xa6\x09\x01\x30\xd6\xb6\x3d\xe1\xea\x22\x05\xb0\x88\x05\xf6\x04\x62\x36\x02\x80\x71\xeb\x51\x00\xc1\x01\x42\x02\x3d\xd6\x60\xd0\xa1\x6c\x3b\x7e\x9b\x04\x75\x72\xcd\x33\x75\xf5\x75\x53\x6c\x39\x4e\xca\x6d\x1a\x3d\x86\xa6\xb1\x80\x39\x6c\xbf\xe6\x09\xb3\x64\x35\xac\x37\xe4\x59\x63\x22\xcc\xbb\x11\x96\x72\x53\x15\xc9\x66\xd4\x3c\x1c\xfb\xd8\x8b\xde\x08\xc7\x33\x1b\x03\xb3\x64\x3d\xba\x04\x60\x44\xf2\x5e\x77\x66\x3f\xd4\xe9\x4b\x62\x44\x6e\x61\xd6\x2a\x3c\xc9\x92\x2a\x89\xd2\xe4\x9f\x38\x46\x37\x79\x9e\xe2\x28\x13\x0d\x74\x1b\xa5\xa5\x3e\xce\x6e\xd6\x9b\x2a\xdc\x46\x65\xf9\x39\x2f\x62\xb2\x43\x83\xbc\x38\xbe\x54\x8d\x15\xf2\x8c\xbc\x86\xad\x79\x6f\x56\xc5\xe3\xd6\x95\x51\x6e\xa1\x66\xd2\x2d\xef\xf6\x6f\xbb\x07\xfc\xfa\x22\x5e\x68\x52\x21\x05\x91\x4f\x8d\xa4\xab\x76\xa5\x29\x87\x72\x2f\xaf\x01\x55\x32\x3d\x59\x19\xf6\x6f\x73\xe5\x3a\x59\x00\x2c\x4a\x61\x83\x8c\xe7\x3f\xe8\xf4\x7f\xfe\x16\x3d\xfb\xe7\xd7\xcf\xfe\xeb\xef\xff\xfa\xc3\xd7\xff\xfe\xca\x3c\x14\x2a\xc8\xea\xc5\xc0\x10\x81\x5a\x1e\x33\x0f\x68\xf1\x0a\xd7\xb1\x8c\x1d\x20\x6d\xed\x5c\x4e\x04\x4c\x5c\xda\x16\xbb\x83\x0d\x14\x79\xee\x32\x3f\x36\x53\x61\x37\x64\x47\x55\x58\x25\x5b\xaf\x6e\x22\x5d\x8a\x3d\x6c\x20\xbc\x4d\x52\x6c\x1a\xbe\x9b\x9d\xc0\xdd\xbd\xc1\x1c\x8f\xef\x0a\x7e\xf9\xb2\x2b\xa3\xb1\x44\x49\x5a\x17\x38\x2c\xeb\xcd\x26\x2a\x1e\xa5\x8e\x20\x52\xaa\x68\x75\x5f\x15\x11\x9b\xe0\x1d\x6c\x75\x84\x50\x90\x53\x84\xab\x5c\x1a\x4f\x54\x65\x87\xf4\x4d\x66\x24\xb6\xae\xd3\xab\xcf\xd0\xb3\x4e\xed\x09\x27\x34\xb9\x3a\xe1\xbb\x44\xeb\xe7\x49\xcc\x1a\xff\x79\x83\xc9\x7f\x11\x43\xe3\x7f\xf3\xdb\xd6\x1b\x5b\xe2\x4f\x25\x73\xe1\x8f\xb8\x01\xf0\xdf\xb4\xf9\x44\xa2\xda\x6e\x9d\xc7\xa2\xd1\x78\x4a\xdb\x62\x42\xb6\x6d\x1a\xfe\x48\x6b\x03\xfe\x58\xa9\x77\xfe\x50\x6e\xd9\x09\x3d\xa5\x48\x36\x00\xd2\xdf\x44\x82\xee\x94\x95\x5b\x00\xd5\x27\x7c\xa3\xec\x88\x15\x06\xbd\x96\xf7\xa7\x03\xc6\x6a\x1c\xab\xe4\x43\x68\x47\x1a\xa9\x64\x4a\x6d\x9c\x62\x25\x34\x8d\x53\xfc\x60\x9d\x6b\xa0\xa2\x77\xb3\x82\xfd\xdd\x34\x0e\xf0\x39\x70\xdb\x58\xec\xdb\x6c\x1d\x06\xf3\xb0\xcf\xb5\x3b\x6e\x6d\x6a\xac\x5a\x85\xf2\x54\x53\x8d\x8a\xfd\xf4\x47\xa9\xd3\xdb\x24\x25\x01\x08\x68\x1b\x72\xff\xea\x6e\x55\x3f\x6e\x85\x77\x78\xb5\x2a\x6f\xd3\x81\x4a\x27\xf6\xc7\xab\x9a\xfc\xcd\x8e\xd0\x68\x95\xc1\xfd\x58\x56\x19\x4d\x0d\x41\xbb\xc7\xa4\x31\x9a\xa0\x31\x5f\x4f\x8c\xc7\xc2\x5b\xbe\xb8\x12\x0c\x8d\x1f\x2d\xed\x56\xe2\xcf\xd5\xd1\xb9\x99\x21\x6a\x87\x72\x1d\x3e\x94\xaa\xa1\x96\xa8\x7e\x20\xd3\x20\xd4\xf9\x44\x87\x41\x6e\x57\xdf\x2a\xcb\xab\xe6\x8a\x6c\xe7\xcb\xb4\xb9\x16\x21\xf6\x17\x2d\xd9\x22\x28\x9b\xda\xf0\xa4\x87\xfc\xde\x07\x63\xb8\xd3\x16\x55\xf9\x26\x59\x89\x6b\x1c\x5a\x1b\x50\x4a\x20\x36\xaf\xbd\x46\x23\x55\x5f\xdb\xc6\x35\xea\x15\x36\x5f\x5c\x09\x6b\x19\xdb\x00\x4e\xa3\x39\x06\x78\x6a\x7e\x52\x7d\x5c\x5c\x41\xe6\x27\xba\x9c\x66\x81\x47\xeb\xea\x30\x6f\xdb\xd5\xb5\x74\xa0\xab\x4b\x47\xe4\xdb\x7e\x03\x9b\xb3\xb5\xe3\x3c\x75\x1c\xc1\xd4\x13\x4f\xc6\x96\x12\xb7\x77\x7b\x34\xd7\x08\x74\x6a\x00\x2a\xb8\xc2\x17\xe6\x69\x2c\xbd\x3a\x3c\x6a\xc1\xbb\xb8\x0a\x0f\x27\x37\x5a\x1f\x2f\xd1\x31\x2d\x4f\xe5\x54\xad\x8e\xa5\xc1\x16\xe7\x7c\x8d\x9b\x5f\x3a\x4f\x6d\x67\x9d\x48\x1b\x7b\x6f\xa4\xd1\xea\x3e\xfc\x8c\x6f\xee\xf2\xfc\x3e\xe4\xa1\xf7\xe1\xa3\x25\xb9\x32\x23\x94\xb9\xc2\x4d\xf4\x85\x7d\x0b\x46\x0c\x6c\xc4\xad\x61\xcf\x46\xb2\xec\x18\x7d\xff\x1a\x7d\xf3\xed\xb7\xc0\x20\x29\x3e\x60\xe5\xc2\xa3\x51\xe5\x31\xf3\x88\x7c\xf0\x20\x4f\x8b\x60\xa0\xf9\xfb\x60\x31\x59\xce\x17\xa3\xff\x8c\x99\xc7\x35\x79\xf6\xdf\x24\x50\x31\xfa\xe1\xa5\xf4\xeb\x7f\xff\xf6\xec\xf9\xdf\x47\x3f\xbc\x96\x1e\x8d\xc7\xbf\x7b\xf6\x03\x09\x65\x34\xd3\x0f\xf3\xd8\x7b\x54\xb3\x07\xed\x1d\x9a\x45\x75\xee\x50\x71\x59\xfd\x2a\x4a\xd3\x32\x59\x67\xb0\xe5\x8b\x4b\xdf\x7f\xa1\x1d\xc3\xd7\xca\xc5\xba\x94\x31\x08\x03\x36\x73\xa7\x36\x8f\xd6\xe6\x26\xe6\xd6\x00\x3a\x12\x90\x35\x68\x57\xf5\xb4\xc6\x00\x04\x69\xa1\x3b\x80\x42\x58\x94\xe1\x85\x7b\xdf\x5e\x7e\x32\x36\x15\x41\xb9\x99\xc8\x5a\x0e\xed\xed\xa5\xa5\xf2\xaf\x32\xea\xa5\x6c\x87\xce\xa7\x36\x6a\x9f\xfd\x12\xdd\x7a\xea\x5e\xf5\xc4\x2a\x89\x26\x48\xc5\xa3\xa7\x4f\xf1\x17\xbc\xaa\x2b\x1c\x1b\x09\xb8\x6c\x85\x4b\xb2\x5a\x5c\xd6\xa9\x59\x19\xb6\x12\xff\x05\xaf\xc2\xb8\x6e\x3e\xf9\x12\x66\x25\x73\x84\xba\x9a\x5a\xae\xb1\x72\x78\x51\x40\x4e\xf3\xb8\xe6\x75\x20\x96\x11\x87\xdc\x21\x6f\x46\x39\x35\xad\x8a\xd6\xf0\xe8\xb7\x89\xb2\xe4\x16\x97\xf0\xc2\xf3\x4d\x9d\xc5\x29\xde\xa3\x59\x79\xbf\xd1\xd9\x32\xeb\x26\xda\x9a\xf7\x33\x58\xda\xe0\x68\x43\x9c\x5b\x87\x76\xb0\xb3\xc8\x02\xc3\x5e\x57\xda\x39\xf9\xab\x6b\x78\x30\x69\xbf\x11\xaa\x19\x92\x9e\x0c\xcd\xd7\xcd\x2f\x4e\x61\x3a\xbf\x9c\x61\x67\xc8\x8c\x11\xa8\x68\x3a\x5b\xd0\x27\x79\x96\x59\x5e\xbb\x59\xc1\x3a\x13\x7c\xf1\xcd\xff\x87\xfc\x6f\xa3\x02\xbb\xcf\x0c\xc3\xe7\xcf\xba\x53\x43\x79\x62\xe8\xa5\x0c\x0b\x0d\x08\x3b\x69\xab\xa4\x6b\x59\xdc\xdf\x27\xcb\xc7\xa3\x8e\x65\x39\xa2\x01\x10\xf9\xd3\xf5\x64\x9f\x1e\x0c\x4d\x5f\xd5\xcd\x6a\xac\x33\x69\x86\x6a\xee\x55\xf0\x21\xd5\x34\xca\xd6\x75\xb4\x96\xc7\xe2\xdb\xbc\xb8\xe7\x2b\x6c\x47\xeb\x7d\xfc\x35\xc9\x16\xd7\x95\x39\x87\xf9\x4c\x86\x21\x5d\xcd\x9d\x45\x37\x29\xb0\xb4\x5e\x15\x35\x36\x4c\x2e\xfc\xd6\x17\x8b\x84\x55\x74\xcf\x21\xa2\x79\x05\xf9\x1f\xc0\xe1\x27\x19\xbc\x33\x74\x37\x66\x90\x51\x27\xcb\x33\x75\xff\x8e\xda\xe3\xbf\xff\xd3\x18\x9d\xda\x3b\xf0\x36\xdf\xf9\xe4\x05\x8c\xca\x6a\xc4\x06\xca\x44\xbc\x4f\x73\x1c\x75\xe7\x21\xb8\xbf\xd0\xb0\x8b\xd0\x74\x61\x2b\xeb\xd8\x0c\xca\xd0\xbb\x5b\x79\xc8\xb3\x60\x43\x65\x09\x2e\x94\x78\xf4\x41\x91\xe3\x64\x6c\xd0\xfa\x68\xda\xf2\x6f\x02\x82\x19\x77\x2a\x89\x72\xf7\x6e\xc7\x0b\xd0\xa8\xfc\xdc\x81\x4e\x26\x1f\xbf\x40\xcb\x64\x70\x10\x9c\x05\xe1\x37\x0d\xb3\x92\x18\xdb\x42\x1f\xfd\xb5\x64\x63\x0d\x3c\xf5\xc4\x8b\xae\xb8\x3c\x86\xf9\xe7\x0c\x17\x62\x58\x06\x45\x68\xc0\xcf\x20\xc2\x6c\xcd\x1d\x44\xb6\x45\x0d\xa5\x51\x8b\xe2\xe5\x7c\xf6\x41\xf7\x46\x66\x75\x9a\x8a\xa1\xcb\xb0\x82\x46\xd7\x37\xf4\x19\x0b\x5d\xd8\x80\x16\xd6\x9a\x89\x9f\x0c\x44\x9e\x9a\xa6\x38\xaa\x39\x1c\x6d\xe8\x03\x69\xdb\x41\x50\x4d\x06\x86\xc3\xce\x35\xdc\x68\xa4\x2c\x02\x83\xae\x6e\x9c\x14\xd5\x63\xa7\xa9\x81\x2e\xc2\xae\x00\x77\x75\x0e\xc8\x12\x94\x33\x4d\x7b\xea\x0b\xac\x29\x15\x1e\xf8\x40\x8a\xb9\x2c\xfa\xed\xe7\x9d\xb2\x99\x8b\xa4\x52\xe9\x0b\x25\x83\xca\xbb\x53\xc1\x5a\x23\xe2\x65\x3b\x9a\xd5\xaa\x84\x92\xbd\xb2\x04\xc5\x52\xe9\x8e\x23\xe9\xd4\x9e\xbc\xe5\x48\xde\xea\xf9\x9c\x6d\xf2\x6c\x2a\x45\x49\x91\xf6\x78\x8a\xbd\x39\xb2\x40\xa7\xb5\xb5\xcb\xf5\xdb\x56\x06\x3b\x04\xd8\x00\xf4\xcb\xcb\x52\x73\x96\x2b\x72\xd6\x83\x89\x68\x00\x05\x8e\x4a\xf6\x66\x60\x11\x1a\x5c\xf1\x33\x1f\xbb\x1a\x3f\xdc\xe4\x5a\x01\x8f\xd7\xf4\x30\xb1\x64\x02\x9a\x00\x30\x68\x49\x5f\x53\x40\x23\xa8\x01\xb4\xda\x6d\x5a\x02\xf4\xca\x86\x57\xab\xd8\x2c\x93\xdc\x92\x4f\xd1\x92\x69\xc3\x2a\x8f\x65\xbf\x48\x7c\xd0\xdb\x02\x2e\xcd\x73\x14\xa8\x92\x6c\xa6\xb0\xe4\x3b\x19\xc3\x95\xa2\x7f\x8f\xa2\x35\x5c\xb8\x6a\xec\x15\xd7\x71\xf8\x0c\xe9\x49\x0c\xa5\x32\x1d\x0c\x67\x66\xc8\x68\x18\xb6\x7e\x95\x9a\x45\x7f\xfe\xd4\xd3\x20\x6f\xe7\x72\x95\x26\x18\x28\x32\xd8\x07\xe1\x16\x3b\x5a\x4f\xb4\xd2\xb7\xfd\x11\x16\x33\xf5\x4a\xb3\x67\x65\x34\xb2\x1b\x72\xbb\x0d\x6c\x23\x76\x0f\x86\x7d\xfc\xdf\x64\x9c\x2c\x79\xdf\x0e\x99\x5a\x7f\x40\x79\x8f\xdb\x80\x66\x05\xb4\x26\x04\x04\xcd\x8d\xa8\x37\x9e\x7d\x98\xf8\xa5\x34\x93\xcd\x77\x3d\x19\x43\x45\x75\x1a\x27\x11\xb2\xc4\xf4\xa4\x3d\x06\xd2\xc0\x1f\x3d\x44\x55\x54\x68\xee\xcb\xaf\x66\x08\xa3\xdb\xa3\x70\xf8\xa9\xce\xab\x48\xd4\x0a\x47\x7e\xf1\xad\x06\x4b\x0f\xb3\xc8\x13\xd9\xe6\x01\x71\xbf\x71\xa5\xbf\x11\x95\x34\x4a\x6b\x57\x85\x4e\xe8\xa2\x78\x93\x64\xc2\xdb\xb0\x85\x04\xb7\xd1\x1a\x87\x0f\x09\xfe\x5c\x76\xf4\xfe\x5a\x13\x6d\x5c\x22\x11\x87\x70\x89\x57\xd1\x5a\xdc\x4c\x0b\xdd\x4c\xc0\x47\xb2\x66\xf9\x76\x55\x97\x55\xbe\x69\x4f\x8b\x82\xfe\xeb\x41\x76\xb3\x10\x73\x6d\xce\x53\x7a\x6d\x69\xe1\x82\xee\x6d\x2d\x1a\x30\xb4\x82\xc1\x13\x0f\xbe\xbf\x45\xfe\xfc\xd8\x71\x46\x59\x78\x48\x85\x5e\x82\xcc\x6f\xe6\xe3\x0a\x9b\x20\x41\xe3\x1e\xf7\xb0\x81\xa4\xe1\x5d\x9d\xd6\x97\xfc\x5d\x38\xe0\xab\xb0\xfa\x27\xe1\xae\x82\x65\x8b\x86\xbf\x54\x0f\x51\x3a\x3a\x55\xb2\xb0\xd2\x9f\xbe\x7c\x59\xe0\xf5\x2a\x8d\xca\x12\xa4\xb1\x7f\xbb\x0e\xa4\x51\xb2\xf4\xa5\x81\xbf\xb2\x67\xe7\x91\x6f\x28\xf5\x25\xea\xc9\xe1\x0d\xaf\xdd\x4f\xe7\xc9\xa2\xe5\xea\x4d\xd6\xa3\x71\x94\x3c\x7d\x89\xfa\x92\x78\x57\x9b\xe1\x2e\x1f\x4f\x3a\x43\x6e\x4f\x72\xe0\x0e\x17\x3f\x5e\x20\x63\x7f\x4a\x76\x4f\x42\x6f\x46\x7e\xbf\xc2\x50\x42\xf9\x62\x86\xc1\xe4\x12\x86\xa7\x22\xed\x41\x71\x3f\xd2\x56\xde\x97\x80\x7b\x35\x6c\x98\xf6\xe5\xd1\xb3\x79\xd2\xc9\x47\x3a\xfd\x98\xe4\x1c\x7d\x48\xf8\xf1\xa1\x1e\x2c\x3c\x4b\x1f\x9a\xf6\xc8\x4c\x0f\xa2\x36\x53\x1f\x2a\x7e\x86\xce\x9f\x47\x3e\xae\xe6\xa2\xd0\x4e\x05\xf8\xb1\x68\x99\x7a\x50\xb1\xad\xe0\xfe\x34\x2c\x83\x3f\x85\x67\x45\x49\x9b\x77\x5d\xa0\x9d\x1d\x9d\x7e\x0c\x9d\x6c\x9e\x74\x96\x3d\x55\x7e\xc4\x16\x80\xc1\x2a\x0c\xa6\xf6\xad\x64\xff\x01\xb6\xc7\x30\xaa\xae\x01\xf9\xc1\xab\x79\x7c\x89\x58\xf0\xde\x93\x82\x49\xfb\x82\x6b\x61\x61\x4f\x12\x2d\x97\x27\x19\x1c\xf3\xf2\xa3\x84\xf3\xf6\x21\x06\x22\x35\x3d\xa8\x81\xdc\x3d\xc8\x7b\x14\xd2\xb7\x4c\x8a\x2b\x41\xfd\x9b\xc9\xf9\xb9\xec\x5b\xaa\xbe\xc6\x96\xec\xdb\x7e\xbf\xb8\x78\x37\x59\x7c\x44\x7f\x0e\x3e\xa2\x51\x12\xef\x03\x57\xfe\xa2\x0e\xdd\x1b\x7e\x3d\xbb\xf8\xcb\x75\x80\x46\x72\x82\xd5\x0f\x68\x3f\x77\xa4\x11\x29\x02\xed\xbd\xb1\xc4\xd7\x68\xe6\x3b\x05\x7e\x08\xeb\x2c\xf9\x54\x63\xc1\xaa\x4a\x25\xf1\x19\xdf\xcc\x41\xfe\x78\x18\x93\xc6\x0d\x16\x0b\x52\x8b\x7b\x50\xc9\xbb\x4e\x45\x36\xa2\xbb\x1d\x98\x4a\xf4\x47\xb6\x82\xf6\xd1\x54\xf3\x83\x40\x58\x4d\x46\xf9\x26\x41\x48\xae\x0e\x22\x86\xd0\x69\x19\x49\xe8\x0c\x11\xa9\x33\x74\x8f\x1f\xf7\xa7\xc5\x80\x32\x1a\x9b\x59\x91\x38\x20\xb2\xc9\x7a\x89\xbd\x8a\x1f\xcd\xbd\xe7\x76\x42\x3b\xd9\x90\xca\x71\x21\xd2\x7e\x25\x06\xe4\x24\xd6\xdb\x5b\xf4\x3b\x49\xa6\x97\xe7\x09\xf2\x9b\xfc\x4c\xef\xf2\x29\x17\xd1\x42\x14\x8a\x00\x80\xcb\xca\xe5\xf2\xf1\xb8\x3b\x0a\x52\x74\xc5\x7a\x14\xc0\x72\x93\xa9\x83\xac\x23\x0f\xb0\xd2\x71\xdf\xd3\x81\x75\xd0\x71\x17\x75\x48\xd1\x00\xf7\xd6\x93\x4d\x76\x88\xbd\x99\x5b\x1f\x17\x62\x91\x3c\x60\x7f\x44\xdd\x9b\x05\x81\x75\x21\x7f\x8d\xe5\x6d\x16\x10\xb4\x9c\x0e\xa0\xb2\x3d\x1d\x4e\x87\x1a\x82\x56\xdc\x67\x6f\x85\x15\x77\xd8\x08\xcb\x05\xfc\x2b\x42\xf3\x7e\x8d\xc8\x92\x83\xdc\x0f\x9b\xf9\xc8\x46\x60\x96\xee\xaf\x31\x89\xe6\x83\xe6\x40\x13\x00\x1c\xf2\xdc\xc7\xc1\x86\x30\x75\x77\xda\x5b\x4b\xc9\x41\x36\xe1\x72\xff\x99\xed\x17\x64\xab\x99\xca\x2c\xb0\x49\x3a\xe3\x2b\x9d\xbb\x32\xf5\xd1\xde\x04\xd6\x03\xa5\xe3\x66\x43\x90\x5d\x5f\xfc\xd0\xf8\xe2\x84\xae\x52\xd5\xe2\x29\x4c\xa5\x1d\x0b\x05\x99\x34\x99\x1e\x05\x81\x4e\x6c\xda\x28\x14\x41\x7f\x1e\x4b\xf8\x01\x62\xb3\x88\xef\xc2\xe9\xc9\xd5\xc3\x10\x4c\xef\xb7\xb6\xa5\xb5\xd9\x15\x1d\x12\x1c\xde\x8c\x69\x4f\xbe\x91\x08\x90\xa5\xf9\x8c\x53\xbb\x56\xd0\x58\x2a\x4f\x62\x21\x43\xff\x0e\xf9\x66\x03\x0f\xe6\x33\xb1\x33\xa1\x7f\xcd\x7a\xb7\x8f\x1a\xb8\x81\xf0\xb4\xd0\x8e\x3f\xb2\xbe\x53\x18\x04\xd7\x85\x00\xcd\xd9\xc2\xa7\x35\x84\x04\x62\xf3\x80\x91\xbf\xca\x5a\xf4\x07\x44\xd5\x23\x44\xde\xe8\xd2\x2e\x44\x08\x58\x4a\x0e\xb3\x3c\x8c\xeb\x6d\x4a\x06\x50\x52\xe5\xec\x58\x9d\x30\x16\xf6\xce\x39\x6b\x36\x2a\xee\x4a\xd6\x58\x62\x28\x76\x1f\xd2\x5c\xe4\x73\xc9\x97\xd7\xe7\x01\xba\xbe\xba\x98\xbd\x45\x37\x55\x81\x31\x1a\x35\x49\x74\x7b\x92\x58\xb4\x7f\x3d\x1e\xf3\x5b\xea\x46\xf2\x0e\x46\x79\x6b\xbc\x45\x45\x3d\xf0\x65\xd6\x56\x97\xec\x59\xf3\x9e\xe3\x85\x31\x26\x46\xf2\x85\x6c\xf3\x91\xd6\x79\xd9\x8e\x34\xb5\xf3\x32\x51\xb2\xdb\xcc\xa6\x94\x8f\x32\x42\x46\x51\xc2\x34\x94\x08\xeb\x30\x68\xe3\x18\x57\x48\x76\xa3\x32\x3d\x2a\x9d\x75\x5b\x10\x8a\xa7\x01\x68\xcc\x61\x90\xf6\x71\x5c\xcc\xce\x83\x9f\xb5\xe0\x5c\x9a\xe7\xf7\xf5\x16\xcd\x67\xea\x73\x66\xad\x77\x51\x79\xa7\xc5\xe9\xb8\x89\x4a\xbb\x9a\xf8\x09\x0d\x9d\x49\x0e\x4f\xe8\xd1\xb9\x24\x26\x9c\x8a\x84\xda\x43\x74\x71\x07\xba\x7c\x27\xa4\x1d\x58\x92\x74\x60\x4a\x57\x8d\xda\x21\x5b\x41\x23\xa2\xfe\x59\xce\x75\x42\x0a\xf5\x45\xd6\x95\x83\xae\x93\x4c\x8d\x85\x95\x16\xd4\xce\xf7\x34\x01\x40\xa6\xa5\x2e\xea\x85\xca\xfa\x80\x17\x2c\x93\xed\xe2\x8a\xb2\xa8\x86\xc0\xbe\xd6\x37\x9f\x69\x12\x2a\xbe\x26\xee\x42\x6f\xbe\xe6\xe7\xc0\xa4\x42\x2e\x24\xdd\xa4\x2c\x78\x56\x9b\x52\x51\x35\xa3\xb2\x80\x82\x56\xc5\xc6\x26\x06\x6d\x8a\x75\xb1\x81\x6c\x3e\x33\x8a\xa8\x54\xc4\xff\xe2\xe3\xab\xa2\x3c\x14\x87\xd2\x4e\xd3\x30\x2b\x06\x24\x55\x0e\x35\x9b\x17\x13\xfb\x32\x51\x18\x15\x45\xf4\xc8\xf6\xce\xf9\xf3\x49\x99\x46\x0c\xe9\x0c\xbd\x18\x7b\x31\x8b\x6f\x9f\x78\xd3\x89\x1c\x36\xfc\x6e\x78\x8d\xd7\xa2\x8d\xb0\x93\x4b\x65\x66\x10\x67\xc8\x4b\x05\x1e\x04\x73\xb6\x22\x13\x1c\xda\x88\x50\xb0\x8d\xaf\x9d\x92\x8f\xc3\x98\x19\xe5\x2c\x2a\x3b\xcb\x7f\x86\x08\x40\x87\x5c\x8a\xc1\x91\xa1\x8e\x80\xb7\x8f\x54\x20\xee\x03\x9b\xf2\xb3\x4f\x4f\x58\x10\x1a\x09\x1b\x46\xbb\x81\xd0\x02\x23\x7d\x6d\xc4\x0b\x2b\x8c\xaa\xb0\xae\x56\x36\x48\xb2\x83\x71\x44\x32\x90\xdd\xbc\xa3\xd3\xeb\xe5\x94\x6d\x96\x3d\x93\xbf\x6d\x32\x1e\xdb\xf8\x58\xfb\xda\x68\xba\x26\xa0\x0c\x4d\x4a\x04\x50\xb9\xc5\x99\x3d\xd5\x2e\x96\x6f\x47\x2c\x39\xa7\x4a\xa9\x5e\x06\xcd\x6e\x57\x3f\x53\xef\xd3\xef\x94\xca\xa2\x88\x92\xb1\x17\xb5\x89\xb2\x5b\x05\x6d\xb4\xb2\xf9\x43\x2f\x28\x4f\x57\xe9\xe8\xe1\x50\x7c\x46\x6f\x0a\x3e\xe3\x1b\x56\xe1\xa2\xc9\x41\xcb\x06\x4b\x60\xb7\x49\xfd\xd0\xbb\xa5\xe0\x01\x4c\xb0\x21\xe9\x5d\xf6\xf3\x99\x22\x69\xad\x3d\xde\x70\x24\xa3\x70\x79\x7a\x5f\xcb\x0d\xa8\xd7\x60\x58\x55\xf1\x40\x91\xef\xcf\xb7\x62\x49\x82\x76\x44\x7e\xf1\x3a\x54\x7d\x03\x6a\x8e\xd7\x99\x7a\x9f\xbb\xae\x81\x1e\xd6\x6d\xe7\x87\x5a\x8a\xca\xc8\xe6\x86\x3d\xa6\xf9\x3a\x51\xf7\x4e\x62\x17\x67\x27\x87\x1f\xbd\x62\xa7\xba\x16\xcd\x15\x3b\xb7\x69\xb2\xbe\xab\x3c\x0b\x7d\x86\xcc\x8a\xd8\x2f\xee\xed\x5c\xce\xdb\x24\x00\xda\x5b\xd4\x6f\x5f\x5b\xe4\x87\xaa\x20\x49\xf1\xab\x94\x06\xce\x16\xd6\x04\xd2\x1a\xbb\xb0\x64\x52\xb5\x01\x11\xce\xe4\xab\xd1\xce\xe4\x83\x83\xe7\xc1\xd5\x74\x40\x8b\x5a\xd4\x11\x37\xb3\xed\x51\x6b\x3e\x0e\x72\x45\xb5\xe7\x4a\x18\xc6\x5b\xe3\x32\x6c\x6f\x6c\xe2\xad\x0b\x48\xa9\x8a\x8e\xa6\xf3\xc9\x65\x70\x35\x0d\xba\xb7\x41\x9d\xa1\xaf\xc7\xe3\x33\x04\x49\x70\x1b\xa6\x02\xfe\xf6\xe2\x2c\x00\xbd\x03\xcd\x47\x6d\x22\x68\x06\x96\xb7\x02\xb0\x40\x19\x9b\x01\x76\xe7\x79\xfa\xa2\x27\x8b\xb6\x74\x1e\x03\x7b\x07\x18\xb9\xc4\x2a\x6e\xee\x91\x5c\x6e\x89\x92\x7a\xdb\xe2\xe6\x1e\x10\x81\x54\x66\x58\x15\xeb\x0d\x94\x35\xcd\x3f\xe3\x42\xbb\x99\x8c\x30\x11\xf9\x30\xdf\x76\x7d\x77\xe5\xe2\x1f\x8b\x4e\xfc\xe2\x1f\x30\x7f\x5d\x24\xe6\xea\xab\x8b\xc4\xd4\x0e\xca\x25\x30\xf4\x10\x96\xd8\x85\x33\x9f\xa9\xa9\x2a\x26\x15\x35\xa1\x1a\xc2\x8a\xec\x0f\xa2\x24\x2c\xa1\x52\xa8\x8d\x2d\xb7\xb1\x38\xdb\x7b\x26\x1d\x6d\x1e\x60\xde\x44\x8b\x32\x84\x0e\x8b\x31\x15\x35\x8d\x00\xc9\xc1\xac\xed\x21\x2d\x90\x8a\x27\xfb\xe1\x2f\x17\x17\x6f\xdf\x06\x0b\x54\x15\xc9\x3a\x6c\xde\x32\x6d\x58\x45\x4c\xfb\x42\xe2\xac\xf2\x00\x43\x89\x26\x6f\x48\x60\xf1\x3c\xb8\x0c\x96\x81\x1a\xcf\x79\x33\x5f\xa0\x60\x32\xfd\x09\x2d\xe6\x1f\x50\xf0\x73\x30\xbd\x5e\x06\xe8\xfd\x62\x3e\x0d\xce\xaf\x17\x01\xf2\x24\x18\xb9\x14\xe4\x92\x1a\x80\x50\xa3\xa3\xa0\x80\xf6\xd5\xd0\xc1\x60\xd4\x90\x9e\xaa\x8b\xe4\x32\x36\x41\xca\x36\x08\x84\x7e\x0c\xde\xcc\x17\xc4\xe0\xaf\x82\xc5\x92\x5c\x5d\x73\xfd\xfe\x7c\xd2\xb3\x22\xdd\x34\x23\x30\x02\xac\x84\x66\xa1\x30\xb0\x22\x10\x6a\x97\x9c\x86\xb7\x24\x36\x4c\xb4\xbf\x78\x3b\x6b\x22\xcd\x9a\xc4\x18\x2d\xc8\x32\x21\x39\xe3\x77\xd5\x18\xa7\x29\x16\xdd\x53\x93\xb2\x66\x61\x7e\xa3\x26\x9a\x84\xbf\x26\xa2\x0a\x8d\xfb\xe8\x64\x01\xbd\xc6\x21\x55\x74\x11\x45\x17\x25\x91\x2a\x45\x2c\x94\xd9\xea\x74\x72\x35\x9d\x9c\x07\xfb\xdd\x3a\xca\x83\x25\x5d\x45\x59\x8a\xa2\x1f\x79\xb6\x9b\x56\xbe\x1b\x4f\x59\x4b\x01\x7a\x59\xdb\xd0\x53\x0d\xab\x06\x22\x6a\x05\xd2\x8b\x34\x83\x02\x43\x6b\xc6\xa1\x92\x6e\x36\x80\x66\xba\x88\xa2\xa0\x92\xb8\xc7\xaa\x6a\xe3\xf2\x6c\xf3\x4d\x57\x2f\x5d\x44\xd1\x8b\x38\x21\x87\xac\xb7\x96\xdc\x6c\x51\x1d\x99\x7d\x35\xed\x90\xdd\xc3\xed\x03\x50\x57\x39\x59\x51\x53\x24\xec\xaa\xab\x71\xdc\x50\x24\xda\xa1\x0e\x56\xb3\x4d\x55\xb5\x64\xcf\x8f\xad\x64\xb3\x08\x64\x53\x94\xad\xfb\x40\xca\xd2\x02\x1f\x49\x61\xf1\x13\xd6\xf6\xd0\xad\x6f\xd7\xf1\x68\xaf\x8b\x9e\x9b\xa3\xf7\xa5\x15\xa8\x4a\x77\x49\x06\x54\xa8\x2b\xa6\x2f\x7e\x18\xdf\x25\xbe\xa3\xce\x22\xb8\x5a\x2e\x2e\xa6\xcb\x3d\xab\xd9\x2e\x0a\x75\x35\x6c\x17\x7b\x64\xe5\xba\xab\x29\x83\xea\xb3\xb3\xe2\xe4\xd0\xb8\x23\xff\x6b\x56\xdd\x6c\x15\x7b\x36\x07\x56\x4c\x87\x82\x4c\xea\xa9\x6c\xb6\x9f\x92\xf2\x6a\x1f\x6d\x7b\x55\x45\x35\x59\x51\xd1\x0e\x36\xc8\x14\x00\x9c\x01\xc5\x68\xcb\xa2\x57\xf8\x5e\x87\xb0\xfd\x28\x7b\xdc\x6e\xa7\xac\x60\x41\xda\x2a\x02\x21\xfb\x64\x25\xa0\x1a\x4b\x51\x14\x93\x17\x45\x7a\xaa\x24\xbc\x7f\xd0\x6c\x35\x91\xa3\xa8\xe5\xd8\xd0\x6f\x9c\xa1\x3b\xe6\xe5\x2c\x06\xc1\x88\xf7\xa2\x8a\x79\x7c\x71\x0e\x2c\x43\xb4\xd1\x96\x65\x20\xbd\x34\x91\x41\x95\xb5\x27\xea\xce\xfa\x10\xa0\x05\xb0\x86\x04\x55\xd8\x9e\x34\x52\xbf\x12\x0a\xa8\xa3\x0a\xf4\xd3\xc5\xf3\xc8\x85\xd4\x97\x88\xd3\xe8\xdd\x28\x9e\x2f\xa3\x1e\x9a\x98\xab\xc1\x5a\x7e\x4f\x45\x86\x1d\x90\x00\x8e\x33\xc0\x2a\x1a\x24\x15\x95\x21\x19\x63\x13\x82\xc2\x36\xa5\x15\xc1\xa1\x95\x09\x69\x62\x59\xa3\x83\xf4\xb1\x88\x1f\x32\xb8\xb9\x47\x2d\x81\x34\x50\x57\x50\x4e\xd1\xb8\x2b\xb1\xd3\x78\x0b\xc0\xf9\x95\x4d\x5e\xc9\x34\x8e\xc0\xba\x08\xd8\xed\x0f\xa6\x96\xd9\x24\x3a\x32\xfe\x46\xd1\xfb\x5c\xc7\xbe\x5e\x4f\xbd\x89\xcd\xc5\xef\x5f\x68\x9f\xd3\x20\xc7\x74\x48\xbc\x14\x3a\xf0\x7b\xc8\x4b\x07\x73\x6d\xec\xa5\x1a\x86\x9c\xab\x19\xa8\xd2\xae\x87\x61\xf6\xcd\xaa\x2f\x1e\x9b\x15\xd0\x25\x77\xd0\x65\x3a\x7f\xf7\xee\x62\xf9\xea\xe4\xff\x06\x00\x9d\x1b\x4f\xc0\x98\xf7\x00\x00")
 
 func _1528395650_squashed_migrationsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -168,7 +178,7 @@ func _1528395650_squashed_migrationsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395651_event_logs_remove_empty_url_checkDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x70\x74\x71\x51\x70\xf6\xf7\x0b\x0e\x09\x72\xf4\xf4\x0b\x41\x92\x89\x4f\xce\x48\x4d\xce\x8e\x2f\x2d\xca\x89\xcf\xcb\x2f\x89\x4f\xcd\x2d\x28\xa9\x54\x70\xf6\x70\x75\xf6\x56\xd0\xd0\x28\x2d\xca\x51\xb0\xb1\x53\x50\x57\xb7\xb2\x2a\x49\xad\x28\xd1\xd4\xb4\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xe6\xfd\x73\x12\x71\x00\x00\x00")
+var __1528395651_event_logs_remove_empty_url_checkDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x71\x00\x8e\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x20\x41\x44\x44\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x63\x68\x65\x63\x6b\x5f\x75\x72\x6c\x5f\x6e\x6f\x74\x5f\x65\x6d\x70\x74\x79\x20\x43\x48\x45\x43\x4b\x20\x28\x28\x75\x72\x6c\x20\x3c\x3e\x20\x27\x27\x3a\x3a\x74\x65\x78\x74\x29\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe6\xfd\x73\x12\x71\x00\x00\x00")
 
 func _1528395651_event_logs_remove_empty_url_checkDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -188,7 +198,7 @@ func _1528395651_event_logs_remove_empty_url_checkDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395651_event_logs_remove_empty_url_checkUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x0b\x0e\x09\x72\xf4\xf4\x0b\x41\x92\x8a\x4f\xce\x48\x4d\xce\x8e\x2f\x2d\xca\x89\xcf\xcb\x2f\x89\x4f\xcd\x2d\x28\xa9\xb4\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x6f\xa1\x26\x42\x58\x00\x00\x00")
+var __1528395651_event_logs_remove_empty_url_checkUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x58\x00\xa7\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4e\x53\x54\x52\x41\x49\x4e\x54\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x63\x68\x65\x63\x6b\x5f\x75\x72\x6c\x5f\x6e\x6f\x74\x5f\x65\x6d\x70\x74\x79\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x6f\xa1\x26\x42\x58\x00\x00\x00")
 
 func _1528395651_event_logs_remove_empty_url_checkUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -208,7 +218,7 @@ func _1528395651_event_logs_remove_empty_url_checkUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395652_add_lsif_indexerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\xcb\x6a\xe3\x40\x14\x44\xf7\xfa\x8a\xc2\x1b\xcf\x0c\xb6\x3f\xc0\x62\x16\xb2\xdc\x33\x11\xe8\x91\xc8\x72\x9c\x5d\x23\xd4\xd7\xb8\x41\xea\x56\xfa\x11\x27\x7f\x1f\x2c\x91\x20\x05\xb2\xc8\xf2\xc2\xb9\xc5\xa9\xda\xb1\xff\x49\x1e\x06\xc1\x7a\x8d\x92\xac\xd3\x86\xa0\x5b\x01\xaf\xe4\xb3\x27\x48\x25\xe8\x35\xd8\x97\xc5\x3d\x92\x7c\xcf\x9e\xd0\x5a\x79\xe6\xbe\x6f\x75\x2d\x2c\x37\xd4\x6b\x2b\x9d\x36\x6f\x5c\x0a\xde\xe8\xae\x93\x8e\x1b\xad\x1d\x1f\xfe\xc8\x84\x41\x5c\xb2\xa8\x62\x38\xe6\xc9\xc3\x91\xfd\x28\x03\x45\x3e\x23\x7f\xcd\xc8\x15\x16\x23\xbb\x58\xe1\x46\xff\xc6\xe9\x8e\x95\x0c\xd6\xd5\x8e\xf0\x17\xcb\x46\x77\x7d\x4b\x8e\xc4\x72\xbb\x9d\xc4\xf0\x01\x18\xfb\xee\x8d\xee\xf1\x22\xe9\x0a\x41\x3d\x29\x41\xca\x41\x2b\x28\xba\xa2\xd1\xad\xef\xd4\x58\xfc\x31\x61\xa7\xd1\x44\xf8\xae\xb7\x93\xdf\x09\x19\xa5\x15\x2b\x51\x45\xbb\x94\xcd\xac\x31\x44\xc4\x45\x7a\xcc\x72\x7c\xae\x32\xae\xdd\x18\xba\xc9\x0e\x06\x57\xe9\x2e\x93\x3c\xa8\xba\x23\xfb\xb1\xde\x17\x03\x44\x07\x1c\x58\xca\xe2\x0a\x7e\xf3\x67\x05\xbf\x39\x4b\x25\xed\x85\x04\xaf\x1d\x6a\x8b\xde\xe8\x86\xac\x1d\xef\x7f\x65\x91\xcd\x95\xfc\xf7\x5b\x85\x41\x10\x17\x59\x96\x54\x61\xf0\x1e\x00\x00\xff\xff\x52\x38\x31\x8f\x19\x02\x00\x00")
+var __1528395652_add_lsif_indexerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\xcb\x6e\xa3\x40\x10\x45\xf7\x7c\xc5\x95\x37\x9e\x19\xd9\xfe\x00\xa3\x59\x60\xdc\x33\x41\xe2\x91\x60\x1c\x67\xd7\x42\x74\x59\x6e\x09\xba\x49\x3f\xe2\xe4\xef\x23\x83\x12\x41\xa4\x2c\xb2\x2c\xe9\xd4\xad\x53\x77\xc7\xfe\x27\x79\x18\x04\xeb\x35\x4a\xb2\x4e\x1b\x82\x6e\x05\xbc\x92\xcf\x9e\x20\x95\xa0\xd7\x60\x5f\x16\xf7\x48\xf2\x3d\x7b\x42\x6b\xe5\x99\xfb\xbe\xd5\xb5\xb0\xdc\x50\xaf\xad\x74\xda\xbc\x71\x29\x78\xa3\xbb\x4e\x3a\x6e\xb4\x76\x7c\xd8\x23\x13\x06\x71\xc9\xa2\x8a\xe1\x98\x27\x0f\x47\xf6\xa3\x0c\x14\xf9\x8c\xfc\x35\x23\x57\x58\x8c\xec\x62\x85\xdb\xc5\xdf\x38\xdd\xb1\x92\xc1\xba\xda\x11\xfe\x62\xd9\xe8\xae\x6f\xc9\x91\x58\x6e\xb7\x93\x18\x3e\x00\xe3\xbf\x7b\xa3\x7b\xbc\x48\xba\x42\x50\x4f\x4a\x90\x72\xd0\x0a\x8a\xae\x68\x74\xeb\x3b\x35\x3e\xfe\x98\xb0\xd3\x68\x22\x7c\xd7\xdb\xc9\xee\x84\x8c\xd2\x8a\x95\xa8\xa2\x5d\xca\x66\xd6\x18\x22\xe2\x22\x3d\x66\x39\x3e\x5b\xb9\x25\x94\xd4\x18\xba\xc9\x0e\x06\x57\xe9\x2e\x93\xcb\x50\x75\x47\xf6\xa3\xbd\x2f\x06\x88\x0e\x38\xb0\x94\xc5\x15\xfc\xe6\xcf\x0a\x7e\x73\x96\x4a\xda\x0b\x09\x5e\x3b\xd4\x16\xbd\xd1\x0d\x59\x3b\xce\xff\xca\x22\x9b\x2b\xf9\xef\xbb\x0a\x83\x20\x2e\xb2\x2c\xa9\xc2\xe0\x7d\x00\x52\x38\x31\x8f\x19\x02\x00\x00")
 
 func _1528395652_add_lsif_indexerDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -228,7 +238,7 @@ func _1528395652_add_lsif_indexerDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395652_add_lsif_indexerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x8f\xcd\x6e\xa3\x30\x14\x85\xf7\x7e\x8a\xab\x6c\x98\x19\x91\x3c\x40\x50\x16\x04\x3c\x2d\x12\x3f\x29\x31\x4d\x76\x16\xc2\x17\xc5\x12\x60\x8a\xed\xa6\x79\xfb\x8a\xa0\x44\x25\x52\x2b\x75\x09\xbe\xdf\x39\xdf\xd9\xd2\xa7\x28\xf5\x08\xf1\x63\x46\x73\x60\xfe\x36\xa6\xd0\x68\x59\x73\xdb\x37\xaa\x14\x1a\xfc\x30\x84\x20\x8b\x8b\x24\x05\xd9\x09\xfc\xc0\x01\x18\x3d\x32\x8f\x14\xbb\xd0\x67\x0f\xc7\x7b\xca\xee\x57\x1b\x70\x1c\xef\x87\xdc\xeb\xc3\x43\xf2\xc8\xa7\x19\x83\xb4\x88\x63\x8f\x90\xe5\x12\x72\xac\x06\x2c\x0d\xc2\xbb\xc4\x33\x9c\xa5\x39\x41\x87\x67\xa8\x54\x63\xdb\x0e\xba\xb2\x45\x4d\xc2\x3c\xdb\xc1\x6b\x44\x0f\x53\x83\xb0\x6d\xaf\x3d\x12\xe4\x74\xf4\x7b\xf8\x0f\xfe\x1e\xf6\x34\xa6\x01\x03\xbb\xfa\xe7\x82\x5d\xd5\xb2\x93\xfa\x84\x82\x97\x06\x4a\x0d\xfd\xa0\x2a\xd4\x7a\xfa\xfe\x9f\x67\xc9\x5c\xdb\xc2\xe1\x99\xe6\x14\xb4\x19\xa5\x36\xe0\x54\xaa\xed\x1b\x34\x28\x9c\x49\x38\x51\x42\xd6\x17\xb0\x9d\x7c\xb3\x38\x0d\x03\xa3\x40\x76\x55\x63\x05\xde\x97\xd6\x12\x1b\x31\x99\x47\x69\x48\x8f\xb3\x16\x3e\x60\xaf\xb4\x34\x6a\xb8\x70\x29\x78\xa5\xda\x56\x1a\x3e\x28\x65\xee\xb3\x8a\x34\x7a\x29\xe8\xaf\x58\x7e\xeb\xce\xd2\x19\xf1\x67\x46\xb8\xb0\x98\x98\x85\x0b\x23\xe5\xde\x94\xff\x7e\xbf\x7c\xbd\xfe\x92\xc7\xaf\x07\x1e\x21\x41\x96\x24\x11\xf3\xc8\x67\x00\x00\x00\xff\xff\xa1\xdf\x7e\x19\x63\x02\x00\x00")
+var __1528395652_add_lsif_indexerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\x4f\x6f\xa3\x30\x10\xc5\xef\xfe\x14\xa3\x5c\xd8\x5d\x91\x7c\x80\xa0\x1c\x08\x78\x77\x91\xf8\x93\x12\xd3\xe4\x66\x21\x3c\x28\x96\x00\x53\x6c\x37\xcd\xb7\xaf\x08\x4d\x54\x22\xb5\x52\x8f\x1e\xcf\x7b\xef\xf7\x66\x4b\xff\x45\xa9\x47\x88\x1f\x33\x9a\x03\xf3\xb7\x31\x85\x46\xcb\x9a\xdb\xbe\x51\xa5\xd0\xe0\x87\x21\x04\x59\x5c\x24\x29\xc8\x4e\xe0\x1b\x0e\xc0\xe8\x91\x79\xa4\xd8\x85\x3e\x7b\x58\xde\x53\x76\xdf\xda\x80\xe3\x78\xdf\xf8\x5e\x3f\x1e\x9c\x47\x7d\x9a\x31\x48\x8b\x38\xf6\x08\x59\x2e\x21\xc7\x6a\xc0\xd2\x20\xbc\x4a\x3c\xc3\x59\x9a\x13\x74\x78\x86\x4a\x35\xb6\xed\xa0\x2b\x5b\xd4\x24\xcc\xb3\x1d\x3c\x47\xf4\x30\x25\x08\xdb\xf6\xda\x23\x41\x4e\x47\xbe\x87\x39\xf8\x7b\xd8\xd3\x98\x06\x0c\xec\xea\x8f\x0b\x76\x55\xcb\x4e\xea\x13\x0a\x5e\x1a\x28\x35\xf4\x83\xaa\x50\xeb\xe9\xfd\x37\xcf\x92\x39\xb6\x85\xc3\x7f\x9a\x53\xd0\x66\x84\xda\x80\x53\xa9\xb6\x6f\xd0\xa0\x70\x26\xe0\x44\x09\x59\x5f\xc0\x76\xf2\xc5\xe2\x54\x0c\x8c\x02\xd9\x55\x8d\x15\x1f\x03\x1c\xa0\x96\xd8\x88\x89\x3c\x4a\x43\x7a\x9c\xa5\xf0\x01\x7b\xa5\xa5\x51\xc3\x85\x4b\xc1\x2b\xd5\xb6\xd2\xf0\x41\x29\x73\xaf\x55\xa4\xd1\x53\x41\x7f\xa4\xe5\xb7\xec\x2c\x9d\x29\x7e\xcd\x14\x2e\x2c\x26\xcd\xc2\x85\x31\xd1\xbd\x21\xff\xfe\xba\xf9\x7a\xfd\xc9\x8f\x5f\x4f\xe3\x11\x12\x64\x49\x12\x31\x8f\xbc\x0f\x00\xa1\xdf\x7e\x19\x63\x02\x00\x00")
 
 func _1528395652_add_lsif_indexerUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -248,7 +258,7 @@ func _1528395652_add_lsif_indexerUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395653_repo_normalize_visibility_metadataDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4a\x2d\xc8\x57\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x28\xca\x2c\x4b\x2c\x49\xb5\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x13\x76\x29\xa7\x41\x00\x00\x00")
+var __1528395653_repo_normalize_visibility_metadataDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x70\x72\x69\x76\x61\x74\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x13\x76\x29\xa7\x41\x00\x00\x00")
 
 func _1528395653_repo_normalize_visibility_metadataDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -268,7 +278,7 @@ func _1528395653_repo_normalize_visibility_metadataDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395653_repo_normalize_visibility_metadataUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x53\xdd\x6e\xda\x30\x14\xbe\xf7\x53\x9c\x0b\xa6\xb4\x52\xe1\x01\x9a\x81\x14\x92\x93\x35\x92\xb1\x51\x62\xb4\xdd\x21\x07\x4c\x67\xd5\x60\xe4\x38\x61\x7d\xfb\x29\x3f\x84\x49\x5b\xa7\xb6\xb9\x4a\xbe\xf8\xfb\xf1\xe7\xe3\x25\x7e\xcb\x58\x48\x48\x44\x05\xe6\x20\xa2\x25\x45\x70\xea\x6c\x21\x4a\x12\x88\x39\xdd\xac\x18\x64\x29\x30\x2e\x00\x7f\x64\x85\x28\xe0\xec\x74\x23\xbd\x82\x25\xe7\x14\x23\xd6\xfd\x62\x1b\x4a\x21\xc1\x34\xda\x50\x01\x69\x44\x0b\x0c\x09\x99\x4e\x41\xfd\xf2\x4e\xee\xfc\xc8\x39\x38\x7b\x84\xa3\xf2\x72\x2f\xbd\x9c\x41\x76\x80\x8b\x82\x83\xd4\x06\xbc\x1d\x17\x6b\x0f\xb2\xaa\xea\xa3\x6a\xdf\x74\xd5\xea\x5c\xf9\xde\x82\x6c\xac\xde\x83\x51\xf2\x45\x9f\x9e\x67\x84\x24\x1c\x26\x13\x92\x60\x4c\xa3\x1c\x09\x00\x80\xdf\xee\x6a\x57\x59\x07\xf1\x26\x2f\x78\x0e\x29\xcf\x3b\xbc\x7d\x0a\xa4\x18\x8b\xd6\x4a\xb9\x93\x34\xdb\x4a\xb9\x46\xef\xd4\xd6\xbf\x9e\xd5\xc3\x98\x0c\xd2\x9c\xaf\xba\x1a\xc2\x41\xd1\xd9\x4b\xf7\xfd\xc5\xd9\x4b\xbb\xb6\xc7\x1b\x69\xa0\xb4\xd6\x28\x79\x0a\x49\xd7\x64\x07\xa7\x3c\x1f\x28\x19\xbb\xa5\xa1\x9c\xaf\xc7\x1c\x2d\x73\x7e\x6d\xea\x0a\x66\x69\x4f\x9b\xfd\x33\x1e\xcc\x21\x78\xd6\xfe\x67\x5d\x06\x20\x9e\x90\x8d\xb4\x9b\x5e\xcc\x23\x8a\x45\x8c\x77\x77\xbd\xce\x75\x3f\xd3\xc5\x22\xc8\xaa\x75\x5f\x62\x70\xff\xf8\x38\x84\x7e\x00\xef\x6a\x75\x7f\x4b\x80\xb4\x78\x57\x08\x23\xdf\x0e\xf1\xb7\x75\xa3\x2b\x5d\x6a\xa3\xfd\x6b\x00\x5f\x17\x10\x9c\xeb\xd2\xe8\x5d\xf0\x41\xdb\x52\xfb\xb2\xde\xbd\x28\x5f\x28\xd7\x28\xf7\xa6\xff\xc9\xfa\xff\x16\x31\xb8\xff\xd9\xc2\x41\x9a\xea\xc3\x35\x8c\x79\x62\x63\xeb\xfd\xa7\xce\x44\x57\xdb\xf3\x3b\x0e\x85\x25\x90\xa5\x21\x19\x81\xcd\x3a\x89\xc4\x70\x4d\x0b\x14\xe3\xed\x98\x77\x96\xdf\x9f\x30\xc7\x76\xf6\x73\x64\x02\x78\x3a\x4e\x60\xaf\xd8\xaa\xb5\xa3\x18\x12\x64\xc9\x64\x12\x12\x12\xf3\xd5\x2a\x13\x21\xf9\x1d\x00\x00\xff\xff\xd5\xe2\xc3\xcb\x0b\x04\x00\x00")
+var __1528395653_repo_normalize_visibility_metadataUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x93\xdf\x8e\xda\x3c\x10\xc5\xef\xfd\x14\x73\xc1\xa7\x2c\xd2\xc2\x03\x6c\x3e\x90\x42\x32\xe9\x46\x32\x36\x4a\x8c\xda\x3b\xe4\x80\xd9\x5a\x6b\x30\x72\x9c\xd0\x7d\xfb\x2a\x7f\x08\x95\xda\xad\xb6\x2b\xe5\x26\x63\x9f\x39\x3f\x1f\x8f\x57\xf8\x25\x63\x21\x21\x11\x15\x98\x83\x88\x56\x14\xc1\xa9\x8b\x85\x28\x49\x20\xe6\x74\xbb\x66\x90\xa5\xc0\xb8\x00\xfc\x96\x15\xa2\x80\x8b\xd3\x8d\xf4\x0a\x56\x9c\x53\x8c\x58\xb7\xc4\xb6\x94\x42\x82\x69\xb4\xa5\x02\xd2\x88\x16\x18\x12\x32\x9b\x81\xfa\xe1\x9d\xdc\xfb\x51\x73\x74\xf6\x04\x27\xe5\xe5\x41\x7a\x39\x87\xec\x08\x57\x05\x47\xa9\x0d\x78\x3b\x6e\xd6\x1e\x64\x55\xd5\x27\x05\xda\x83\xae\xda\x3e\x37\xbd\xb7\x20\x1b\xab\x0f\x60\x94\x7c\xd5\xe7\x97\x39\x21\x09\x87\xc9\x84\x24\x18\xd3\x28\x47\x02\x00\xe0\x77\xfb\xda\x55\xd6\x41\xbc\xcd\x0b\x9e\x43\xca\xf3\xae\xde\x7e\x05\x52\x8c\x45\x6b\xa5\xdc\x59\x9a\x5d\xa5\x5c\xa3\xf7\x6a\xe7\xdf\x2e\xea\x71\x24\x83\x34\xe7\xeb\x2e\x86\x70\xe8\xe8\xec\xb5\xfb\xff\xcf\xd9\x6b\xbb\xb7\xaf\x37\xd2\x40\x69\xad\x51\xf2\x1c\x92\x2e\xc9\xae\x9c\xf2\x7c\x90\x64\xec\x4e\x43\x39\xdf\x8c\x1c\xad\x72\x71\x4b\xea\x56\xcc\xd2\x5e\x36\xff\x23\x1e\x2c\x20\x78\xd1\xfe\x7b\x5d\x06\x20\x9e\x91\x8d\xb2\x7b\xbf\x98\x47\x14\x8b\x18\x1f\x1e\xfa\x3e\xb7\xf3\xcc\x96\xcb\x20\xab\x36\x7d\x88\xc1\xf4\xe9\x69\x80\x7e\x04\xef\x6a\x35\xbd\x13\x20\x2d\x3e\x04\x61\xe4\xfb\x10\xbf\x5b\x37\xba\xd2\xa5\x36\xda\xbf\x05\xf0\xff\x12\x82\x4b\x5d\x1a\xbd\x0f\xfe\xd1\xb6\xd4\xbe\xac\xf7\xaf\xca\x17\xca\x35\xca\xbd\xeb\x7f\xb6\xfe\xaf\x41\x0c\xee\xbf\xa6\x70\x94\xa6\x52\xd3\xcf\xf2\xc4\xc6\xd6\x87\x4f\xdd\x89\xae\x76\x97\x0f\x5c\x0a\x4b\x20\x4b\x43\x32\x16\xb6\x9b\x24\x12\xc3\x33\x2d\x50\x8c\xaf\x63\xd1\x59\x7e\x7d\xc6\x1c\xdb\xd9\xcf\x91\x09\xe0\xe9\x38\x81\xfd\xf9\x90\x25\xdd\x28\x86\x04\x59\x32\x99\x84\x84\xc4\x7c\xbd\xce\x44\x48\x7e\x0e\x00\xd5\xe2\xc3\xcb\x0b\x04\x00\x00")
 
 func _1528395653_repo_normalize_visibility_metadataUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -288,7 +298,7 @@ func _1528395653_repo_normalize_visibility_metadataUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395654_add_external_updated_at_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x2d\x48\x49\x2c\x49\x4d\x89\x4f\x2c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xf2\xb5\xfc\xa4\x53\x00\x00\x00")
+var __1528395654_add_external_updated_at_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x53\x00\xac\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x75\x70\x64\x61\x74\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf2\xb5\xfc\xa4\x53\x00\x00\x00")
 
 func _1528395654_add_external_updated_at_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -308,7 +318,7 @@ func _1528395654_add_external_updated_at_to_changesetsDownSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395654_add_external_updated_at_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xce\x4d\x4e\xc3\x30\x10\x47\xf1\xfd\x9c\xe2\x7f\x00\x7a\x82\x88\x85\xdb\x18\x64\x29\x1f\x08\xbb\x12\xbb\x6a\x88\xa7\x4d\x25\xc7\x8d\xea\x31\x04\x4e\xcf\x12\x16\x2c\xdf\xea\xfd\xf6\xf6\xd9\x0d\x0d\x91\xe9\x82\x7d\x45\x30\xfb\xce\x62\x9a\x39\x5f\xa4\x88\x16\x98\xb6\xc5\x61\xec\x8e\xfd\x00\xf7\x84\x61\x0c\xb0\x6f\xce\x07\x0f\xd9\x54\xee\x99\xd3\xa9\xae\x91\x55\xe2\x89\x15\x7a\x5d\xa4\x28\x2f\xab\x7e\x37\x44\xbb\x1d\x3c\x9f\x05\x92\x6f\xf5\x32\x23\xca\x99\x6b\xd2\x07\xe8\x2c\xd0\x7b\x15\x7c\x70\xaa\x82\xcf\x6b\x4a\x78\x17\x4c\xb7\x65\xad\x2a\x11\xac\xc8\xb2\x29\xca\x57\x9e\xe8\xf8\xd2\x9a\xf0\x57\x44\xde\x86\x7f\xe7\x8f\xf8\x8d\x86\xe8\x30\xf6\xbd\x0b\x0d\xfd\x04\x00\x00\xff\xff\x07\xc2\x6f\x6e\xe0\x00\x00\x00")
+var __1528395654_add_external_updated_at_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\xce\x4d\x4e\xc3\x30\x10\x47\xf1\xfd\x9c\xe2\x7f\x00\x7a\x82\x88\x85\xdb\x18\x64\x29\x1f\x08\xbb\x12\xbb\x6a\x88\xa7\x4d\x25\xc7\x8d\xea\x31\x04\x4e\xcf\x12\x16\x2c\xdf\xea\xfd\xf6\xf6\xd9\x0d\x0d\x91\xe9\x82\x7d\x45\x30\xfb\xce\x62\x9a\x39\x5f\xa4\x88\x16\x98\xb6\xc5\x61\xec\x8e\xfd\x00\xf7\x84\x61\x0c\xb0\x6f\xce\x07\x0f\xd9\x54\xee\x99\xd3\xa9\xae\x91\x55\xe2\x89\x15\x7a\x5d\xa4\x28\x2f\xab\x7e\x37\x44\xbb\x1d\x3c\x9f\x05\x92\x6f\xf5\x32\x23\xca\x99\x6b\xd2\x07\xe8\x2c\xd0\x7b\x15\x7c\x70\xaa\x82\xcf\x6b\x4a\x78\x17\x4c\xb7\x65\xad\x2a\x11\xac\xc8\xb2\x29\xca\x57\x9e\xe8\xf8\xd2\x9a\xf0\x57\x44\xde\x86\x7f\xe7\x8f\xf8\x8d\x86\xe8\x30\xf6\xbd\x0b\x0d\xfd\x0c\x00\x07\xc2\x6f\x6e\xe0\x00\x00\x00")
 
 func _1528395654_add_external_updated_at_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -328,7 +338,7 @@ func _1528395654_add_external_updated_at_to_changesetsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395655_repo_drop_enabledDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\xc1\x09\xc3\x30\x0c\x05\xd0\xbb\xa6\xf8\x7b\xf8\x24\xdb\x6a\x31\xc8\x12\x18\x69\x80\x96\xfa\x56\x92\x90\xfd\x0f\x79\x55\xde\xc3\x0a\x11\x6b\xc8\x42\x70\x55\xc1\xbd\xaf\x13\xdc\x3b\x9a\x6b\x4e\xc3\x3e\x3e\xdf\xff\xfe\xa1\xba\xab\xb0\xc1\x3c\x60\xa9\x8a\x2e\x2f\x4e\x0d\xc4\x4a\x29\x44\xcd\xe7\x1c\x51\xe8\x09\x00\x00\xff\xff\xeb\xda\x3c\xf2\x54\x00\x00\x00")
+var __1528395655_repo_drop_enabledDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x54\x00\xab\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x65\x6e\x61\x62\x6c\x65\x64\x20\x42\x4f\x4f\x4c\x45\x41\x4e\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x54\x52\x55\x45\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xeb\xda\x3c\xf2\x54\x00\x00\x00")
 
 func _1528395655_repo_drop_enabledDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -348,7 +358,7 @@ func _1528395655_repo_drop_enabledDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395655_repo_drop_enabledUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4a\x2d\xc8\x57\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xcd\x4b\x4c\xca\x49\x4d\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xc8\x9c\xfa\x17\x41\x00\x00\x00")
+var __1528395655_repo_drop_enabledUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x6e\x61\x62\x6c\x65\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xc8\x9c\xfa\x17\x41\x00\x00\x00")
 
 func _1528395655_repo_drop_enabledUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -368,7 +378,7 @@ func _1528395655_repo_drop_enabledUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395656_add_not_null_constraint_to_campaign_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x80\x88\x3a\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\xf0\x13\xd8\xc0\x4f\x00\x00\x00")
+var __1528395656_add_not_null_constraint_to_campaign_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4f\x00\xb0\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x73\x20\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x20\x44\x52\x4f\x50\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf0\x13\xd8\xc0\x4f\x00\x00\x00")
 
 func _1528395656_add_not_null_constraint_to_campaign_descriptionDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -388,7 +398,7 @@ func _1528395656_add_not_null_constraint_to_campaign_descriptionDownSql() (*asse
 	return a, nil
 }
 
-var __1528395656_add_not_null_constraint_to_campaign_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x05\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x0f\x64\xbc\x9f\x7f\x08\x54\x33\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\x00\xa5\x8c\xe6\x8f\x00\x00\x00")
+var __1528395656_add_not_null_constraint_to_campaign_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x05\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x0f\x64\xbc\x9f\x7f\x08\x54\x33\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\x00\xa5\x8c\xe6\x8f\x00\x00\x00")
 
 func _1528395656_add_not_null_constraint_to_campaign_descriptionUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -408,7 +418,7 @@ func _1528395656_add_not_null_constraint_to_campaign_descriptionUpSql() (*asset,
 	return a, nil
 }
 
-var __1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x80\xc8\x38\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x81\x9a\x7b\xaa\x53\x00\x00\x00")
+var __1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x53\x00\xac\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x73\x20\x41\x4c\x54\x45\x52\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x73\x63\x72\x69\x70\x74\x69\x6f\x6e\x20\x44\x52\x4f\x50\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x81\x9a\x7b\xaa\x53\x00\x00\x00")
 
 func _1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -428,7 +438,7 @@ func _1528395657_add_not_null_constraint_to_campaign_job_descriptionDownSql() (*
 	return a, nil
 }
 
-var __1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x37\x09\x22\xe3\xec\xef\x13\xea\xeb\x87\xa2\x17\x64\x85\x9f\x7f\x08\xd4\x00\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\x3e\xcd\x1d\xd8\x97\x00\x00\x00")
+var __1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x37\x09\x22\xe3\xec\xef\x13\xea\xeb\x87\xa2\x17\x64\x85\x9f\x7f\x08\xd4\x00\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\x3e\xcd\x1d\xd8\x97\x00\x00\x00")
 
 func _1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -448,7 +458,7 @@ func _1528395657_add_not_null_constraint_to_campaign_job_descriptionUpSql() (*as
 	return a, nil
 }
 
-var __1528395658_perms_table_provider_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\xcd\x6e\x9b\x40\x14\x85\xf7\xf3\x14\x67\x97\x56\xc2\x79\x01\xd4\x05\xb6\x49\x85\x84\x21\x85\x41\xea\xce\xc2\xc3\x6d\x3d\x0d\x9e\xa1\xf3\x43\x94\x3e\x7d\x05\xd4\x25\xb5\xa3\x94\x28\xac\x90\xb8\xdf\x77\xcf\x3d\xac\xe3\xcf\x49\x16\x32\xb6\x5a\xe1\x4e\xb6\x2d\xa4\x42\x5f\xb7\x9e\x2c\xbe\x69\x03\xa3\x1f\x2d\x6a\x8b\xb5\x74\x07\x2f\x1e\xc8\xa1\x24\xd3\x93\x41\xed\xdd\xf1\x17\x3a\xa3\x7b\xd9\x90\x09\x06\x5c\x69\x08\x6f\x9d\x3e\x91\xb1\x78\x50\xfa\x51\xe1\x58\xf7\x04\x6f\xa9\x41\xa9\xbd\x11\xf4\xdd\xd4\xdd\xf1\x82\xc5\x13\xb9\x5b\x56\xdd\x6f\x23\x1e\x0f\xb3\x66\xdf\x91\x39\x49\x6b\xa5\x56\x16\x65\xcc\xe7\xc9\x4f\xb8\x39\x9c\x83\x4c\x39\x6e\x42\x16\xa5\x3c\x2e\xc0\xa3\x75\xfa\x02\x3e\x7d\xdc\xe4\x69\xb5\xcb\x66\xcf\x20\xcd\x72\x8e\xac\x4a\xd3\x90\x9d\x77\x1b\xea\xf4\x3b\x76\x5f\xe1\x8b\x76\xaf\x56\x28\x48\x18\xaa\x1d\xc1\x2b\xf9\xd3\x13\x84\x56\xd6\x99\x5a\x2a\x07\xa7\x21\x95\x68\x7d\x43\xb3\x40\xe8\xd6\x9f\xd4\xed\xab\x77\x33\x00\xd8\x16\xf9\x3d\x36\x79\x56\xf2\x22\x4a\x32\x8e\xe4\x0e\xf1\xd7\xa4\xe4\xe5\xd5\xf8\xf8\xbe\xd7\x87\x1f\x24\xdc\x7e\x4a\x11\xbc\x47\x71\xce\xfa\x8f\x2b\xda\x6e\x9f\xab\xde\x22\x18\xf9\xe1\xa9\xb2\xe4\x4b\x15\xe3\xc3\x08\xcb\x26\xc0\x2c\x08\xf0\x87\x75\x4f\x1d\x05\x7f\xeb\xfa\x18\xb2\x57\xff\xd2\x7f\xce\xbc\x1c\x9f\x52\x2e\xab\xe8\x65\x76\x49\x37\x8b\xc8\xab\x52\x46\xea\xb2\x94\xe7\x3d\x6c\xf2\xdd\x2e\xe1\x21\xfb\x1d\x00\x00\xff\xff\xa9\xa2\x26\xa1\xf3\x03\x00\x00")
+var __1528395658_perms_table_provider_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\xcd\x6e\x9b\x40\x14\x85\xf7\xf3\x14\x67\x97\x56\xc2\x79\x01\xd4\x05\xb6\x49\x85\x84\x21\x85\x41\xea\xce\xc2\xc3\x6d\x3d\x0d\x9e\xa1\xf3\x43\x94\x3e\x7d\x05\x2e\xa5\xb5\xa3\x94\xca\x12\x0b\x24\xee\xf7\xdd\x73\x0f\xeb\xf8\x63\x92\x85\x8c\xad\x56\x78\x90\x6d\x0b\xa9\xd0\xd7\xad\x27\x8b\x2f\xda\xc0\xe8\x67\x8b\xda\x62\x2d\xdd\xc1\x8b\x27\x72\x28\xc9\xf4\x64\x50\x7b\x77\xfc\x81\xce\xe8\x5e\x36\x64\x82\x01\x57\x1a\xc2\x5b\xa7\x4f\x64\x2c\x9e\x94\x7e\x56\x38\xd6\x3d\xc1\x5b\x6a\x50\x6a\x6f\x04\x7d\x35\x75\x77\xbc\x60\xf1\x42\xee\x9e\x55\x8f\xdb\x88\xc7\xc3\xac\xd9\x77\x64\x4e\xd2\x5a\xa9\x95\x45\x19\xf3\x79\xf2\x03\xee\x0e\x53\x90\x73\x8e\xbb\x90\x45\x29\x8f\x0b\xf0\x68\x9d\xbe\x82\x9f\x3f\x6e\xf2\xb4\xda\x65\xb3\x67\x90\x66\x39\x47\x56\xa5\x69\xc8\xa6\xdd\x86\x3a\x7d\xc3\xee\x2b\x7c\xd1\xee\xd5\x0a\x05\x09\x43\xb5\x23\x78\x25\xbf\x7b\x82\xd0\xca\x3a\x53\x4b\xe5\xe0\x34\xa4\x12\xad\x6f\x68\x16\x08\xdd\xfa\x93\xba\x7f\xf3\x6e\x06\x00\xdb\x22\x7f\xc4\x26\xcf\x4a\x5e\x44\x49\xc6\x91\x3c\x20\xfe\x9c\x94\xbc\xbc\x1a\x1f\x63\xef\xf5\xe1\x1b\x09\xb7\x3f\xa7\x08\x6e\x51\x4c\x59\xff\x72\x45\xdb\xed\x9f\xaa\xff\x11\x8c\xfc\xf0\x54\x59\xf2\xa9\x8a\xf1\x6e\x84\x65\x13\x60\x16\x04\xf8\xc5\xba\x97\x8e\x82\xdf\x75\xbd\x0f\xd9\x9b\x7f\xe9\x1f\x67\x5e\x8e\x8f\xef\x0b\x2b\x7a\x9d\x5d\xd2\xcd\x22\xf2\xaa\x94\x91\xba\x2c\x65\x82\x86\x1e\x36\xf9\x6e\x97\xf0\x90\xfd\x1c\x00\xa9\xa2\x26\xa1\xf3\x03\x00\x00")
 
 func _1528395658_perms_table_provider_nullableDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -468,7 +478,7 @@ func _1528395658_perms_table_provider_nullableDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395658_perms_table_provider_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x51\x6b\xb3\x30\x14\x86\xef\xf3\x2b\xde\xcb\xef\x83\x5a\x18\x63\x37\xf3\xca\xb6\x6e\x04\x6c\xdc\x6c\x84\xdd\x49\xab\x07\x96\xd1\x26\x2e\xc6\xb2\xfd\xfb\x51\xed\x68\xab\x22\x2d\xf3\x4a\xc8\x79\xde\x9c\x3c\xbc\xb3\xf0\x99\x0b\x9f\x31\xcf\x43\x42\xb9\xa5\xb5\x23\xd4\x5a\x7d\xd6\x84\xdc\xe8\xca\xd9\xb5\xd2\x0e\xce\x80\xbe\xf2\x6d\x5d\x10\x4a\x6b\xf6\xaa\x20\x8b\xdc\x6c\xeb\x9d\x9e\xb2\x20\x92\x61\x02\x19\xcc\xa2\x10\x75\x45\x36\x2b\xc9\xee\x54\x55\x29\xa3\x2b\x06\x00\x8b\x24\x7e\xc1\x3c\x16\x2b\x99\x04\x5c\x48\xf0\x27\x84\x6f\x7c\x25\x57\xbd\xf1\xe6\x3f\x33\x9b\x0f\xca\x5d\xf6\x7b\x51\xd6\xae\x33\xf9\x4b\xd6\x79\x44\xb0\x58\x9c\x27\x5c\xc1\x35\xd8\xe1\x4b\x05\x7f\x4d\x43\xfc\x6b\x18\x55\x4c\x70\xe2\x26\x38\x22\xee\xbb\xa4\xff\x3e\xbb\xd0\x62\xa9\x34\x37\x68\xe9\x8e\xb7\x2b\xdd\xe8\x63\x38\x64\x44\xc4\x18\xd0\x33\xd0\x0c\x5f\x1a\xe8\x3e\xba\x2b\x16\xed\xe1\x3c\x8e\xd2\xa5\x38\xd5\xa8\x79\x81\x88\x25\x44\x1a\x45\xfe\xa8\xb6\xeb\x12\x0e\x55\x96\x06\x1b\x42\x61\x34\x41\x69\xdc\x4f\xef\x1e\x1e\xdb\x86\xef\xcc\x9e\xe0\xde\x07\x6a\xec\x79\x18\xdd\xfe\x68\xfa\xe2\x6a\xbf\x4b\xf5\x36\x1e\xa6\xd8\x3c\x5e\x2e\xb9\xf4\xd9\x4f\x00\x00\x00\xff\xff\xd2\xb9\x08\xfe\x7e\x03\x00\x00")
+var __1528395658_perms_table_provider_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x41\x6b\xf2\x40\x10\x86\xef\xfb\x2b\xde\xe3\xf7\x81\x11\x4a\xe9\xa5\x39\x45\x4d\xcb\x42\xdc\xb4\x71\x03\xbd\x05\x4d\x06\xba\x45\x77\xd3\xcd\x46\xda\x7f\x5f\x5c\x2d\x6a\x0c\x41\x29\xe4\x10\x92\x79\xde\x99\x79\x98\x49\xfc\xcc\x45\xc8\x58\x10\x20\xa3\xd2\xd2\xd2\x11\x5a\xad\x3e\x5b\x42\x69\x74\xe3\xec\x52\x69\x07\x67\x40\x5f\xe5\xba\xad\x08\xb5\x35\x5b\x55\x91\x45\x69\xd6\xed\x46\x8f\x59\x94\xc8\x38\x83\x8c\x26\x49\x8c\xb6\x21\x5b\xd4\x64\x37\xaa\x69\x94\xd1\x0d\x03\x80\x59\x96\xbe\x60\x9a\x8a\x85\xcc\x22\x2e\x24\xf8\x13\xe2\x37\xbe\x90\x8b\x8b\x72\x8f\x16\x66\xf5\x41\xa5\x2b\x7e\x1b\x15\xfb\x71\x46\x7f\xc9\x3a\x8d\x88\x66\xb3\xd3\x84\x2b\x38\xdf\x79\xf7\xe4\x82\xbf\xe6\x31\xfe\x79\x46\x55\x23\x1c\xb9\x11\x0e\x88\xfb\xae\xe9\x7f\xc8\xce\xb4\x58\xaa\xcd\x0d\x5a\xba\xe5\xfe\xfd\x56\x1f\xfd\x21\x03\x22\x86\x80\x0b\x03\xbe\xf8\xdc\x40\x77\xe9\xae\x58\xec\x7f\x4e\xd3\x24\x9f\x8b\xe3\x19\xf9\x0d\x44\x2a\x21\xf2\x24\x09\x07\xb5\x5d\x97\xb0\x3b\x65\x69\xb0\x22\x54\x46\x13\x94\xc6\xfd\xf8\xee\xe1\x71\xf7\x39\xa3\x8d\xd9\x12\xdc\x7b\xcf\x19\x07\x01\x06\xa7\x3f\x98\x3e\x6b\x1d\x76\xa9\x8b\x89\xfb\x29\x36\x4d\xe7\x73\x2e\x43\xf6\x33\x00\xd2\xb9\x08\xfe\x7e\x03\x00\x00")
 
 func _1528395658_perms_table_provider_nullableUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -488,7 +498,7 @@ func _1528395658_perms_table_provider_nullableUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395659_user_pending_perms_table_add_service_type_and_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x90\xc1\x4a\xc4\x30\x14\x45\xf7\xf9\x8a\xbb\x54\xe8\x7c\x41\x56\x9d\x69\x94\x40\x9b\x6a\x9b\x82\xbb\x30\x6d\x1f\xfa\x74\x4c\x6b\x92\x0a\xfe\xbd\xcc\xe0\x60\x37\x82\x03\xf3\x56\x6f\x73\x0e\xdc\xb3\x55\xf7\xda\x48\x21\x36\x1b\x34\xd3\xe1\x80\x7e\x3f\xbc\x21\xbd\x10\x86\xc9\xc7\x14\xf6\xec\x93\xc8\x4b\xab\x1a\xd8\x7c\x5b\x2a\x2c\x91\x82\x9b\xc9\x8f\xec\x9f\xdd\x4c\xe1\x9d\x63\xe4\xc9\x47\x01\x00\x45\x53\x3f\x60\x57\x9b\xd6\x36\xb9\x36\x16\xfa\x0e\xea\x49\xb7\xb6\xfd\x13\x3b\xfd\x6e\xea\x5f\x69\x48\x2e\x52\xf8\xe4\x81\xdc\xe2\xf9\x63\xa1\xec\x1a\xca\xb5\x2a\x2f\x8a\xb5\xe9\x02\xfe\x84\x1f\xaf\x33\xfa\xb1\x53\xb8\xe9\xd9\x8f\x8e\xc7\x0c\xbf\x5c\x86\x1f\x24\x7d\xcd\x74\x2b\xc5\xbf\xaa\x9d\xe7\x95\x5d\x65\x56\xd3\xce\x21\x8e\x2a\x79\x0d\x11\x8f\x52\x88\x5d\x5d\x55\xda\x4a\xf1\x1d\x00\x00\xff\xff\x44\x01\xb1\x5e\xf3\x01\x00\x00")
+var __1528395659_user_pending_perms_table_add_service_type_and_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xd0\x41\x4b\xc3\x30\x18\xc6\xf1\x7b\x3e\xc5\x73\x54\xe8\x3e\x41\x4e\xdd\x1a\x25\xd0\xa6\xda\xa6\xe0\x2d\xac\xed\x8b\xbe\x3a\xd3\x9a\xa4\x82\xdf\x5e\x36\x1c\xf6\x22\x38\x18\xe4\xfa\xff\x91\xf7\xd9\xaa\x7b\x6d\xa4\x10\x9b\x0d\x9a\xe9\x70\x40\xbf\x1f\xde\x90\x5e\x08\xc3\xe4\x63\x0a\x7b\xf6\x49\xe4\xa5\x55\x0d\x6c\xbe\x2d\x15\x96\x48\xc1\xcd\xe4\x47\xf6\xcf\x6e\xa6\xf0\xce\x31\xf2\xe4\xa3\x00\x80\xa2\xa9\x1f\xb0\xab\x4d\x6b\x9b\x5c\x1b\x0b\x7d\x07\xf5\xa4\x5b\xdb\xfe\x99\x9d\x08\x37\xf5\xaf\x34\x24\x17\x29\x7c\xf2\x40\x6e\xf1\xfc\xb1\x50\x76\x0d\x72\x4d\xe5\x45\xb1\x96\x2e\xe8\x4f\x3f\x39\xbe\xce\xe8\xc7\x4e\xe1\xa6\x67\x3f\x3a\x1e\x33\xfc\x76\x19\x7e\x92\xf4\x35\xd3\xad\x14\xff\x5a\xed\x7c\x5e\xd9\x55\x66\x75\xda\x79\x88\x23\x25\xaf\x01\xf1\x28\x85\xd8\xd5\x55\xa5\xad\x14\xdf\x03\x00\x44\x01\xb1\x5e\xf3\x01\x00\x00")
 
 func _1528395659_user_pending_perms_table_add_service_type_and_idDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -508,7 +518,7 @@ func _1528395659_user_pending_perms_table_add_service_type_and_idDownSql() (*ass
 	return a, nil
 }
 
-var __1528395659_user_pending_perms_table_add_service_type_and_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x52\x4d\x6f\x9b\x40\x10\xbd\xf3\x2b\x46\x5c\x12\x4b\x6b\xe7\x1e\xda\x4a\xc4\xa6\x15\x12\x86\xd4\x2c\x6d\x6e\x68\xcd\x8e\xcd\x46\x78\x97\xee\x87\x93\xf4\xd7\x57\x8b\xed\x94\x54\x75\x15\xb9\xbe\x18\x86\x79\x6f\xde\x9b\x37\x77\xc9\x97\x34\x8f\x82\x60\x3a\x85\x98\x73\x90\xf8\x04\x8d\xea\xdc\x4e\x1a\xb0\x0a\x6c\x8b\xd0\xa3\xe4\x42\x6e\xa1\x47\xbd\x13\xc6\x08\xe5\x3f\xb1\x75\x87\x66\xe6\x51\xb4\x45\x28\x95\xd3\x0d\x6e\x35\xeb\x5b\x60\xce\xb6\x3f\xa1\xd7\x6a\x2f\x38\x6a\x10\x66\x20\x51\xb2\x7b\x01\x25\x11\x9c\xc1\xa1\x62\xf0\x48\x42\x3c\x89\x6d\x9d\x01\xc3\x36\xe8\x87\x1a\xb4\xc0\x71\xc3\x5c\x67\x61\xcf\x3a\x87\x06\x36\x4a\x03\x3e\x0b\x63\xbd\x10\xad\x9e\xcc\x2c\x88\x33\x9a\xac\x80\xc6\x77\x59\xe2\x49\x75\x7d\xd4\x59\x8f\x75\xc6\x8b\x05\xcc\x8b\xac\x5a\xe6\x60\x50\xef\x45\x83\xb5\x7d\xe9\x11\x68\xf2\x40\xa3\xa0\xba\x5f\xc4\xf4\x1f\xe8\x32\xa1\x6f\x61\x1f\xe1\xca\xfc\xb6\x7a\x15\xbd\x53\xc4\xd0\xf4\x37\x19\x7e\x40\x5e\x50\xc8\xab\x2c\x8b\x82\x8b\x2d\x09\x7e\x81\x21\xc1\xbd\x9d\xd6\xda\xde\xdc\xde\xdc\x8c\x6c\xcd\x1a\xb5\xbb\xf9\x2f\x6f\x82\xff\xe9\x6c\x3a\x85\x15\x36\x1a\x99\x45\x70\x52\xfc\x70\x08\x8d\x92\xc6\x6a\x26\xa4\xf5\x99\x0b\xd9\x74\x8e\xe3\xf8\xfe\x66\x03\x2c\x79\x66\xbb\xbe\x43\x10\xd2\xa0\xb6\xb7\xbe\xe4\x7f\x69\x5e\x26\x2b\x0a\x69\x4e\x8b\xb3\xe2\x4e\xbd\x00\xd7\x6b\x21\x79\x2d\x38\x19\x1d\x31\x01\xb5\x7e\xc4\xc6\x0e\x49\xbc\xbe\x08\x6e\xc8\x9b\x8c\xc8\xc8\x15\x01\xd7\x73\x66\x91\xd7\xcc\x4e\x4e\xec\xdf\xe2\xac\x4a\xca\xd1\xac\xf0\x51\x61\x48\x20\xd4\xc8\xf8\xe1\xbf\x57\x26\x24\xf0\x61\x2d\xec\x8e\xf5\xa0\x36\xe0\x4b\x90\x2e\xcc\x27\x02\xe1\x56\xd8\x8e\xad\x7d\xe3\x29\x8b\x43\x65\x88\x21\x24\x90\x17\xdf\xaf\x27\x93\xf7\xc5\x11\x78\x01\x8b\x55\x71\x0f\xf3\x22\x2f\xe9\x2a\x4e\x73\x0a\xe9\x67\x48\x1e\xd2\x92\x96\x67\x61\xc3\x73\x7d\xdc\xc0\x21\x1e\x72\x29\xd5\x69\x5b\xe7\x28\x0f\xe7\xfb\xca\x78\x01\x4f\x70\x5c\x34\x54\x79\xfa\xb5\x4a\xe0\xfa\x7c\x5c\x67\xd3\x3e\xde\xc3\x24\x0a\x82\x79\xb1\x5c\xa6\x34\x0a\x7e\x05\x00\x00\xff\xff\x74\xde\xa1\xf3\x09\x05\x00\x00")
+var __1528395659_user_pending_perms_table_add_service_type_and_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x52\xcd\x6e\xdb\x3c\x10\xbc\xeb\x29\x06\xbe\x24\x06\x64\xe7\x1e\x7d\xf9\x00\x25\x56\x0b\x01\x8a\x94\x5a\x54\x9b\x9b\x40\x8b\xeb\x88\x81\x4c\xaa\x24\x95\x9f\x3e\x7d\x41\xd9\x4e\xdc\xa2\x2e\x02\xf7\x24\x68\xb9\x33\x3b\xb3\xb3\xd7\xc9\xe7\x34\x8f\x82\x60\x36\x43\x2c\x04\x14\x3d\xa3\xd1\xdd\xb0\x51\x16\x4e\xc3\xb5\x84\x9e\x94\x90\xea\x01\x3d\x99\x8d\xb4\x56\x6a\xff\xc4\x57\x1d\xd9\xb9\x47\xb1\x96\x50\xea\xc1\x34\xf4\x60\x78\xdf\x82\x0f\xae\xfd\x81\xde\xe8\x27\x29\xc8\x40\xda\x91\x44\xab\xee\x15\x5a\x11\x06\x4b\x63\xc5\xd2\x8e\x24\xf4\x24\xae\x1d\x2c\x2c\x5f\x93\x1f\x6a\xc9\x41\xd0\x9a\x0f\x9d\xc3\x13\xef\x06\xb2\x58\x6b\x03\x7a\x91\xd6\x79\x21\x46\x3f\xdb\x79\x10\x67\x2c\x59\x82\xc5\xd7\x59\xe2\x49\x4d\xbd\xd3\x59\x1f\xea\x8c\x17\x0b\xdc\x14\x59\x75\x9b\xc3\x92\x79\x92\x0d\xd5\xee\xb5\x27\xb0\xe4\x9e\x45\x41\x75\xb7\x88\xd9\x5f\xd0\x65\xc2\x7e\x85\x5d\xe1\xcc\xbe\x5b\x3d\x8b\x3e\x28\x62\x6c\xfa\x93\x0c\x3f\x20\x2f\x18\xf2\x2a\xcb\xa2\xe0\x64\x4b\x52\x9c\x60\x48\x0a\x5c\xe1\xac\x75\xae\xb7\x97\x17\x17\x07\xb6\xe6\x8d\xde\x5c\xfc\x93\x37\x29\x7e\x77\x36\x9b\x61\x49\x8d\x21\xee\x08\x83\x92\xdf\x07\x42\xa3\x95\x75\x86\x4b\xe5\x7c\xe6\x52\x35\xdd\x20\xe8\xf0\xfe\xe6\x23\x2c\x79\xe1\x9b\xbe\x23\x48\x65\xc9\xb8\x4b\x5f\x02\x80\x34\x2f\x93\x25\x43\x9a\xb3\xe2\xa8\xb8\x7d\x2f\x70\xbe\x92\x4a\xd4\x52\x84\x07\x47\x1c\x42\xaf\x1e\xa9\x71\x63\x12\x6f\x3f\x52\xd8\xf0\xcd\xc7\xf6\xe5\xdd\x55\x88\xa1\x17\xdc\x91\xa8\xb9\x9b\xee\xd9\xbf\xc6\x59\x95\x94\x07\xb3\x26\x8f\x9a\x26\x21\x26\x86\xb8\xd8\x7e\x7b\x6d\x27\x21\xfe\x5b\x49\xb7\xe1\x3d\xf4\x1a\xbe\x84\x74\x61\xff\x0f\x31\x79\x90\xae\xe3\x2b\xdf\xb8\xcf\x62\x5b\x19\x63\x98\x84\xc8\x8b\x6f\xe7\xd3\xe9\xc7\xe2\x08\xbc\x80\xc5\xb2\xb8\xc3\x4d\x91\x97\x6c\x19\xa7\x39\x43\xfa\x09\xc9\x7d\x5a\xb2\xf2\x28\x6c\xa4\xa8\x77\x1b\xd8\xc6\x13\x9e\x4a\xb5\xdf\xd6\x31\xca\xed\xf9\xbe\x31\x9e\xc0\x13\xec\x16\x8d\x2a\x4f\xbf\x54\x09\xce\x8f\xc7\x75\x34\xed\xdd\x3d\x4c\xa3\x20\xb8\x29\x6e\x6f\x53\x16\x05\x3f\x07\x00\x74\xde\xa1\xf3\x09\x05\x00\x00")
 
 func _1528395659_user_pending_perms_table_add_service_type_and_idUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -528,7 +538,7 @@ func _1528395659_user_pending_perms_table_add_service_type_and_idUpSql() (*asset
 	return a, nil
 }
 
-var __1528395660_add_state_columns_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\xe6\x72\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x2e\x49\x2c\x49\x25\x5b\x77\x51\x6a\x59\x66\x6a\x39\x85\x86\x24\x67\xa4\x26\x67\xc3\xcd\x70\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\xf9\xf3\x46\x2e\xd7\x00\x00\x00")
+var __1528395660_add_state_columns_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\xe6\x72\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x2e\x49\x2c\x49\x25\x5b\x77\x51\x6a\x59\x66\x6a\x39\x85\x86\x24\x67\xa4\x26\x67\xc3\xcd\x70\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x0c\x00\xf9\xf3\x46\x2e\xd7\x00\x00\x00")
 
 func _1528395660_add_state_columns_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -548,7 +558,7 @@ func _1528395660_add_state_columns_to_changesetsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395660_add_state_columns_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xcb\x6e\xc2\x30\x14\x44\xf7\xfe\x8a\xd9\x65\x03\xfc\x00\x62\x11\x48\x84\xa2\x82\x83\xda\xa0\x2e\x2b\x37\x99\x90\x08\xd7\xae\xe2\x9b\x84\xfe\x7d\x45\x1f\x2a\x7d\x48\x55\x55\x2f\xad\x99\xa3\x3b\x67\x99\xae\x33\x3d\x57\x2a\xde\x14\xe9\x35\x8a\x78\xb9\x49\x51\x36\xc6\x1d\x18\x28\x41\x01\x40\x9c\x24\x58\xe5\x9b\xfd\x56\x83\x27\x61\xe7\x8c\xbd\x0b\x62\x84\x10\x9e\xe4\xef\xdd\x8e\x43\xcb\xf1\x5f\x88\xb2\x61\x79\xfc\x4c\x98\x4e\x71\x43\x41\xf0\x0f\x44\x30\x8e\xa8\x58\x9b\xde\x4a\x98\x21\xab\xe1\xbc\x4c\x30\x32\xb2\x16\x07\x0a\x6a\xd3\xda\xbe\x63\x80\x77\x90\x86\xa8\x3b\xef\x84\xae\x82\x09\x6f\xa9\xc6\x0c\x44\xeb\x06\x63\xdb\xea\xcc\x1e\x8c\xed\x19\xd0\xbe\xe6\x93\xe5\x0c\x45\xc3\xc0\xf7\xff\xb1\xb5\x16\xfd\x63\x75\x3e\xc8\x3b\x38\x9e\xe4\x63\x07\xc2\x93\x2b\xe1\x3b\x8c\xbc\x6f\xbc\x3f\xce\x94\xda\xef\x92\xb8\xb8\x9c\x8a\x73\xee\x8b\xde\x05\xa2\x7c\x97\xea\x68\xf2\xe2\xe1\xfb\xfb\xd9\xe8\x02\xd1\x2e\xd5\x49\xa6\xd7\xbf\x17\x2f\x3d\x2e\x10\xed\xf5\x95\xce\x6f\x75\x34\x57\x6a\x95\x6f\xb7\x59\x31\x57\xcf\x01\x00\x00\xff\xff\x72\xf4\x5d\xc4\x20\x02\x00\x00")
+var __1528395660_add_state_columns_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x90\xc1\x6a\xea\x40\x18\x85\xf7\xf3\x14\x67\x97\x8d\xf1\x05\xc4\x45\x34\x41\xc2\xd5\x89\xdc\x1b\xb9\xcb\x32\x4d\x4e\x4c\x70\x3a\x53\x32\x7f\x12\xfb\xf6\xa5\xb5\x45\x4b\x0b\xa5\x94\x7f\xf7\x73\xce\x81\xef\x5b\x65\x9b\x5c\x2f\x94\x4a\xb6\x65\xf6\x17\x65\xb2\xda\x66\xa8\x5a\xe3\x8e\x0c\x94\xa0\x00\x20\x49\x53\xac\x8b\xed\x61\xa7\xc1\xb3\xb0\x77\xc6\xde\x05\x31\x42\x08\xcf\xf2\xf3\x6e\xcf\xb1\xe3\xf4\xab\x89\xaa\x65\x75\xfa\xb8\x10\xc7\xf8\x47\x41\xf0\x0f\x44\x30\x8e\xa8\xd9\x98\xc1\x4a\x98\x23\x6f\xe0\xbc\xcc\x30\x31\xb2\x16\x47\x0a\x1a\xd3\xd9\xa1\x67\x80\x77\x90\x96\x68\x7a\xef\x84\xae\x86\x09\x6f\xa9\xd6\x8c\x44\xe7\x46\x63\xbb\x5a\xc5\x31\x46\x63\x07\x06\x74\x97\x7c\xba\x9a\xa3\x6c\x19\xf8\xfe\x9f\x3a\x6b\x31\x3c\xd6\x2f\x56\xbc\x83\xe3\x59\xae\x1c\x08\x4f\xae\x82\xef\x31\xf1\xbe\xf5\xfe\x34\x57\xea\xb0\x4f\x93\xf2\x16\x15\x81\x72\xe5\xbb\x90\x2d\x11\x15\xfb\x4c\x47\xb3\x57\x0f\x9f\xef\x6b\xa3\x4b\x44\xfb\x4c\xa7\xb9\xde\x7c\x5f\xbc\xf5\xb8\x44\x74\xd0\x7f\x74\xf1\x5f\x47\x0b\xa5\xd6\xc5\x6e\x97\x97\x0b\xf5\x3c\x00\x72\xf4\x5d\xc4\x20\x02\x00\x00")
 
 func _1528395660_add_state_columns_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -568,7 +578,7 @@ func _1528395660_add_state_columns_to_changesetsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395661_remove_execution_related_columns_from_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x92\x41\x8f\xda\x3e\x14\xc4\xef\xfe\x14\xf3\x97\x58\x2d\xfc\xb5\xaa\x7a\xde\x88\x43\x70\x5e\xc0\x52\xb0\x91\xe3\x08\x6e\x91\xbb\xa4\xe0\x0a\x4c\x9a\x78\xdb\xaa\x55\xbf\x7b\x85\x29\x2c\xbb\xaa\x54\x0e\x3d\xe6\x29\xf3\xf3\xbc\x79\x33\xa1\xa9\x90\x09\x63\x69\x61\x48\xc3\xa4\x93\x82\xf0\x64\xf7\xad\x75\x1b\x5f\x7f\x3a\x7c\xe8\x91\x66\x19\xb8\x2a\xaa\xb9\xc4\xba\xe9\x9f\x3a\xd7\x06\x77\xf0\x08\xcd\xb7\x00\xa9\x0c\x64\x55\x14\xc9\x6d\xfa\x3e\xd8\x2e\x34\xeb\xda\x06\x04\xb7\x6f\xfa\x60\xf7\x2d\xbe\xba\xb0\x8d\x9f\xf8\x7e\xf0\xcd\x8d\xa4\x8f\xce\xbb\x7e\xfb\x4f\x50\x4d\xd7\x1d\xba\xb7\xeb\xb0\x4c\xab\x05\x8c\x16\xd3\x29\x69\x88\x1c\xb4\x12\xa5\x29\x11\x3a\xb7\xa9\xbf\xd8\x9d\x5b\xdb\xd0\xd4\x17\x66\xbb\xb3\xbe\x76\x7d\x7d\xb6\x05\x25\x2f\x0f\xf6\xc9\x09\x96\x57\x92\x1b\xa1\xe4\x15\xed\xef\xa0\xe1\x28\x61\x8c\x6b\x4a\x0d\xbd\x00\x6e\x91\x41\x93\xa9\xb4\x3c\x39\xde\x34\x1d\x03\x80\x22\x95\xd3\x2a\x9d\x12\xda\x5d\xbb\xe9\x3f\xef\xe2\x30\x2d\x31\x18\xb0\x8c\x78\x91\x6a\x62\x40\xf7\xec\xbd\xf3\x1b\x38\x1f\x12\x16\xeb\x71\x35\x7c\x1c\x63\x18\x55\x25\x15\xc4\x0d\xb8\xaa\xa4\x19\xfe\x3f\x42\xae\xd5\xfc\x75\xc6\xf1\xb7\xe5\x8c\xf4\x55\xf6\x27\x9f\x6b\x8c\x21\x69\xf9\xee\xed\xf8\x64\x47\x66\xaf\xae\x2b\xca\x78\x12\x06\x1c\xa3\xc0\x31\xbd\xe1\xd9\xcd\x7f\x63\xbc\x1f\xc1\xcc\x48\x46\xa9\x4e\x45\x49\xa0\x15\xa7\x45\xcc\xe9\x9e\xff\x7e\x60\xb1\xb3\xfe\x87\xc8\x1e\x71\xf7\x13\x5b\xdb\xe3\x0e\xcf\xfe\x72\xaa\xa3\xd7\xfb\x87\x08\xc0\x1f\x6d\x3d\x9c\xb7\x4f\x18\x40\x32\x83\xc8\xa3\x93\x53\xc2\x47\x49\xc2\x48\x66\x09\x1b\x0c\x5e\x8e\x75\xae\xce\xad\x85\x99\x50\xae\x34\x41\xc8\x92\xb4\x81\xd2\xa8\x16\xd9\x91\x73\x5d\x24\xe4\x4a\x83\x52\x3e\x83\x56\x4b\xd0\x8a\x78\x65\x08\x0b\xad\x38\x65\x95\xa6\x9b\xeb\xa4\xe6\x73\x61\x12\xf6\x2b\x00\x00\xff\xff\xd1\xd6\x11\x49\xfb\x03\x00\x00")
+var __1528395661_remove_execution_related_columns_from_campaign_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x92\x51\x6f\xd3\x30\x14\x85\xdf\xfd\x2b\x0e\x52\xa7\x75\x68\x42\x3c\x2f\xda\x83\xe7\xdc\xb4\x96\x52\xbb\x72\x1c\xb5\x6f\x51\x58\x43\x6b\xd4\xba\x21\xf1\x00\x81\xf8\xef\x28\x2e\xd9\xca\x84\x44\x1f\x78\xcc\x55\xce\x77\xcf\x3d\x3e\x0f\x34\x93\x2a\x61\x8c\xe7\x96\x0c\x2c\x7f\xc8\x09\x8f\xf5\xa1\xad\xdd\xd6\x57\x9f\x8e\x1f\x7a\xf0\x34\x85\xd0\x79\xb9\x50\xd8\x34\xfd\x63\xe7\xda\xe0\x8e\x1e\xa1\xf9\x16\xa0\xb4\x85\x2a\xf3\x3c\xb9\x4c\xdf\x87\xba\x0b\xcd\xa6\xaa\x03\x82\x3b\x34\x7d\xa8\x0f\x2d\xbe\xba\xb0\x8b\x9f\xf8\x7e\xf4\xcd\x85\xa4\x8f\xce\xbb\x7e\xf7\x5f\x50\x4d\xd7\x1d\xbb\xd7\xe7\xb0\xd4\xe8\x25\xac\x91\xb3\x19\x19\xc8\x0c\xb4\x96\x85\x2d\x10\x3a\xb7\xad\xbe\xd4\x7b\xb7\xa9\x43\x53\x3d\x33\xdb\x7d\xed\x2b\xd7\x57\xa3\x2d\x68\xf5\xbc\xb0\x4f\x4e\xb0\xac\x54\xc2\x4a\xad\xce\x68\xff\x06\x4d\x6f\x12\xc6\x84\x21\x6e\xe9\x05\x70\x89\x0c\x86\x6c\x69\xd4\xc9\xf1\xb6\xe9\x18\x00\xe4\x5c\xcd\x4a\x3e\x23\xb4\xfb\x76\xdb\x7f\xde\xc7\x21\x2f\x30\x99\xb0\x94\x44\xce\x0d\x31\xa0\x7b\xf2\xde\xf9\x2d\x9c\x0f\x09\x8b\xf5\x38\x1b\xde\xdd\x63\x1a\x55\x05\xe5\x24\x2c\x84\x2e\x95\x9d\xbe\xbd\x41\x66\xf4\xe2\xcf\x8c\xe3\x6f\xab\x39\x99\xb3\xec\x4f\x3e\x37\xb8\x87\xa2\xd5\xbb\xd7\xe3\xa8\xe0\x2a\xc5\x78\xc6\xf0\xba\xb2\x88\x0d\x63\xc0\x10\x05\x86\xf4\xa6\xa3\x9b\x37\xf7\x78\x7f\x03\x3b\x27\x15\xa5\x86\xcb\x82\x40\x6b\x41\xcb\x18\xf4\xb5\xf8\xbd\x60\xb9\xaf\xfd\x0f\x99\xde\xe1\xea\x27\x76\x75\x8f\x2b\x3c\xf9\x71\x07\x06\xaf\xd7\xb7\x11\x80\xbf\xda\xba\x1d\xaf\x4f\x18\x40\x2a\x85\xcc\xa2\x93\x53\xc2\x83\x24\x61\xa4\xd2\x84\x4d\x26\x2f\x8f\x35\x56\xe7\xd2\xc2\x3c\x50\xa6\x0d\x41\xaa\x82\x8c\x85\x36\x28\x97\xe9\xc0\x39\x2f\x12\x32\x6d\x40\x5c\xcc\x61\xf4\x0a\xb4\x26\x51\x5a\xc2\xd2\x68\x41\x69\x69\xe8\xa2\x5e\x24\x8c\x09\xbd\x58\x48\x9b\xb0\x5f\x03\x00\xd1\xd6\x11\x49\xfb\x03\x00\x00")
 
 func _1528395661_remove_execution_related_columns_from_campaign_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -588,7 +598,7 @@ func _1528395661_remove_execution_related_columns_from_campaign_jobsDownSql() (*
 	return a, nil
 }
 
-var __1528395661_remove_execution_related_columns_from_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xce\x41\x6a\x85\x30\x14\x85\xe1\xf9\x5d\xc5\x1d\xb6\x6b\xc8\x48\x6d\x94\x80\x26\x25\x46\xe8\x2c\xa4\x26\xb5\xb7\xd8\x28\x49\xe8\xfa\x0b\x2d\x3e\x9c\x3d\x7c\xe3\xc3\xff\x71\x6a\xde\x09\xc9\x00\x5e\xb4\x7a\x45\xa3\x45\xd7\x71\x8d\xa2\x45\xfe\x26\x46\x33\x62\x49\xb4\xd8\x1f\xb7\x92\x77\x25\xd8\xd9\x7d\xef\x8e\x96\x68\xf7\xd5\x45\x4b\xd9\x7e\x50\xa4\xfc\x19\x3c\x2a\x89\xc7\x98\xd9\x3f\xd6\x4e\xb2\x31\x42\xc9\x93\x76\x1f\x7a\x7a\x66\x00\x55\x6f\xb8\x46\x53\xd5\x3d\xbf\xa9\xf6\x6b\x7b\xcf\xf8\x07\x37\xaa\x9f\x86\x33\x1b\x52\xda\x12\xbb\x9c\xe5\xe2\x52\x09\xde\xba\x72\xbd\x3d\xfe\x3e\x14\xfb\x90\xe7\x44\x7b\xa1\x2d\x32\x00\x68\xd4\x30\x08\xc3\xe0\x37\x00\x00\xff\xff\x0d\x30\xb2\x18\x8b\x01\x00\x00")
+var __1528395661_remove_execution_related_columns_from_campaign_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xce\x41\x6a\x85\x30\x14\x85\xe1\xf9\x5d\xc5\x1d\xb6\x6b\xc8\x48\x6d\x94\x80\x26\x25\x46\xe8\x2c\xa4\x26\xb5\xb7\xd8\x28\x49\xe8\xfa\x0b\x2d\x3e\x9c\x3d\x7c\xe3\xc3\xff\x71\x6a\xde\x09\xc9\x00\x5e\xb4\x7a\x45\xa3\x45\xd7\x71\x8d\xa2\x45\xfe\x26\x46\x33\x62\x49\xb4\xd8\x1f\xb7\x92\x77\x25\xd8\xd9\x7d\xef\x8e\x96\x68\xf7\xd5\x45\x4b\xd9\x7e\x50\xa4\xfc\x19\x3c\x2a\x89\xc7\x98\xd9\x3f\xd6\x4e\xb2\x31\x42\xc9\x93\x76\x1f\x7a\x7a\x66\x00\x55\x6f\xb8\x46\x53\xd5\x3d\xbf\xa9\xf6\x6b\x7b\xcf\xf8\x07\x37\xaa\x9f\x86\x33\x1b\x52\xda\x12\xbb\x9c\xe5\xe2\x52\x09\xde\xba\x72\xbd\x3d\xfe\x3e\x14\xfb\x90\xe7\x44\x7b\xa1\x2d\x32\x00\x68\xd4\x30\x08\xc3\xe0\x77\x00\x0d\x30\xb2\x18\x8b\x01\x00\x00")
 
 func _1528395661_remove_execution_related_columns_from_campaign_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -608,7 +618,7 @@ func _1528395661_remove_execution_related_columns_from_campaign_jobsUpSql() (*as
 	return a, nil
 }
 
-var __1528395662_remove_unused_columns_from_campaign_plansDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8c\xcb\x0a\xc2\x30\x14\x05\xf7\xf9\x8a\xf3\x1f\x59\xf5\x11\xa4\x90\x07\x48\xba\x2e\x97\x7a\xa9\x81\x26\x06\x73\xc5\xc7\xd7\x0b\x2e\x5c\x8b\xcb\x61\x98\xe9\xcd\x61\xf2\x5a\xa9\xce\x46\x73\x44\xec\x7a\x6b\xb0\x52\xae\x94\xb6\xb2\xd4\x9d\x4a\x43\x37\x8e\x18\x82\x9d\x9d\x07\x5d\xb7\x5b\xe6\x22\x0d\xc2\x0f\x81\x0f\x11\x7e\xb6\x56\xff\x98\x7f\x8d\x3c\x2b\xff\xbb\x28\x2b\xef\x7c\x5a\x48\x20\x29\x73\x13\xca\x15\xf7\x24\xe7\x0f\xe2\x75\x29\xac\x95\x1a\x82\x73\x53\xd4\xea\x1d\x00\x00\xff\xff\x10\xd3\x23\x5a\xdf\x00\x00\x00")
+var __1528395662_remove_unused_columns_from_campaign_plansDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8c\xcb\x0a\xc2\x30\x14\x05\xf7\xf9\x8a\xf3\x1f\x59\xf5\x11\xa4\x90\x07\x48\xba\x2e\x97\x7a\xa9\x81\x26\x06\x73\xc5\xc7\xd7\x0b\x2e\x5c\x8b\xcb\x61\x98\xe9\xcd\x61\xf2\x5a\xa9\xce\x46\x73\x44\xec\x7a\x6b\xb0\x52\xae\x94\xb6\xb2\xd4\x9d\x4a\x43\x37\x8e\x18\x82\x9d\x9d\x07\x5d\xb7\x5b\xe6\x22\x0d\xc2\x0f\x81\x0f\x11\x7e\xb6\x56\xff\x98\x7f\x8d\x3c\x2b\xff\xbb\x28\x2b\xef\x7c\x5a\x48\x20\x29\x73\x13\xca\x15\xf7\x24\xe7\x0f\xe2\x75\x29\xac\x95\x1a\x82\x73\x53\xd4\xea\x3d\x00\x10\xd3\x23\x5a\xdf\x00\x00\x00")
 
 func _1528395662_remove_unused_columns_from_campaign_plansDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -628,7 +638,7 @@ func _1528395662_remove_unused_columns_from_campaign_plansDownSql() (*asset, err
 	return a, nil
 }
 
-var __1528395662_remove_unused_columns_from_campaign_plansUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2c\x4a\x2f\xcd\x4d\xcd\x2b\x29\xb6\x26\x5d\x6f\x72\x62\x5e\x72\x6a\x4e\x6a\x4a\x7c\x62\x09\x59\xba\xa1\x8a\x4a\x2a\x0b\x52\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\x40\x10\xc2\x54\xcb\x00\x00\x00")
+var __1528395662_remove_unused_columns_from_campaign_plansUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2c\x4a\x2f\xcd\x4d\xcd\x2b\x29\xb6\x26\x5d\x6f\x72\x62\x5e\x72\x6a\x4e\x6a\x4a\x7c\x62\x09\x59\xba\xa1\x8a\x4a\x2a\x0b\x52\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x40\x10\xc2\x54\xcb\x00\x00\x00")
 
 func _1528395662_remove_unused_columns_from_campaign_plansUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -648,7 +658,7 @@ func _1528395662_remove_unused_columns_from_campaign_plansUpSql() (*asset, error
 	return a, nil
 }
 
-var __1528395663_rename_campaign_plans_to_patch_setsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x46\xd3\x01\x97\xcc\xca\x4f\x82\x6b\x72\xf6\xf7\x09\xf5\xf5\x43\x98\x16\x9f\x99\x82\x61\x50\x7c\x66\x8a\x35\x56\x93\x48\x37\x85\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x8e\xf4\x27\x63\xd3\x00\x00\x00")
+var __1528395663_rename_campaign_plans_to_patch_setsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x46\xd3\x01\x97\xcc\xca\x4f\x82\x6b\x72\xf6\xf7\x09\xf5\xf5\x43\x98\x16\x9f\x99\x82\x61\x50\x7c\x66\x8a\x35\x56\x93\x48\x37\x85\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x30\x00\x8e\xf4\x27\x63\xd3\x00\x00\x00")
 
 func _1528395663_rename_campaign_plans_to_patch_setsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -668,7 +678,7 @@ func _1528395663_rename_campaign_plans_to_patch_setsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395663_rename_campaign_plans_to_patch_setsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\xc6\xa5\x23\x2b\x3f\x09\xae\xc1\xd9\xdf\x27\xd4\xd7\x0f\xd5\xb4\xf8\xcc\x14\x14\x83\xe2\x33\x53\xac\xb1\x9a\x44\xba\x29\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x00\x00\x00\xff\xff\x09\x9e\xbd\x6b\xd3\x00\x00\x00")
+var __1528395663_rename_campaign_plans_to_patch_setsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\x2f\xc8\x49\xcc\x2b\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x88\x2f\x4e\x2d\x29\xc6\xa5\x23\x2b\x3f\x09\xae\xc1\xd9\xdf\x27\xd4\xd7\x0f\xd5\xb4\xf8\xcc\x14\x14\x83\xe2\x33\x53\xac\xb1\x9a\x44\xba\x29\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x01\x00\x09\x9e\xbd\x6b\xd3\x00\x00\x00")
 
 func _1528395663_rename_campaign_plans_to_patch_setsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -688,7 +698,7 @@ func _1528395663_rename_campaign_plans_to_patch_setsUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395664_rename_campaign_jobs_to_patchesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x48\x2d\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x46\x53\x9d\x9c\x91\x98\x97\x9e\x5a\x9c\x5a\x02\x96\x84\x69\x72\xf6\xf7\x09\xf5\xf5\x83\x18\x15\x9f\x99\x82\x6e\x48\x7c\x66\x8a\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xa0\x93\x25\x01\x85\x00\x00\x00")
+var __1528395664_rename_campaign_jobs_to_patchesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x48\x2d\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x46\x53\x9d\x9c\x91\x98\x97\x9e\x5a\x9c\x5a\x02\x96\x84\x69\x72\xf6\xf7\x09\xf5\xf5\x83\x18\x15\x9f\x99\x82\x6e\x48\x7c\x66\x8a\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\xa0\x93\x25\x01\x85\x00\x00\x00")
 
 func _1528395664_rename_campaign_jobs_to_patchesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -708,7 +718,7 @@ func _1528395664_rename_campaign_jobs_to_patchesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395664_rename_campaign_jobs_to_patchesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x48\x2d\x46\x57\x9d\x91\x98\x97\x9e\x5a\x9c\x5a\x82\xa2\xdc\xd9\xdf\x27\xd4\xd7\x0f\xc5\xa8\xf8\xcc\x14\xb8\x29\xf1\x99\x29\xd6\x5c\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x00\x00\x00\xff\xff\x8e\x13\xd6\xae\x85\x00\x00\x00")
+var __1528395664_rename_campaign_jobs_to_patchesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x56\x08\x72\xf5\x73\xf4\x75\x55\x08\xf1\x57\x28\x48\x2c\x49\xce\x48\x2d\x46\x57\x9d\x91\x98\x97\x9e\x5a\x9c\x5a\x82\xa2\xdc\xd9\xdf\x27\xd4\xd7\x0f\xc5\xa8\xf8\xcc\x14\xb8\x29\xf1\x99\x29\xd6\x5c\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x01\x00\x8e\x13\xd6\xae\x85\x00\x00\x00")
 
 func _1528395664_rename_campaign_jobs_to_patchesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -728,7 +738,7 @@ func _1528395664_rename_campaign_jobs_to_patchesUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395665_perms_table_drop_providerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x52\x08\x71\x8d\x08\xb1\x46\xd1\x56\x94\x5a\x90\x4f\xa4\x36\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\x90\x3a\x77\xd5\x7f\x00\x00\x00")
+var __1528395665_perms_table_drop_providerDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x7f\x00\x80\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x75\x73\x65\x72\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x72\x6f\x76\x69\x64\x65\x72\x20\x54\x45\x58\x54\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x70\x72\x6f\x76\x69\x64\x65\x72\x20\x54\x45\x58\x54\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x90\x3a\x77\xd5\x7f\x00\x00\x00")
 
 func _1528395665_perms_table_drop_providerDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -748,7 +758,7 @@ func _1528395665_perms_table_drop_providerDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395665_perms_table_drop_providerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\x08\x4a\xcd\xcd\x2f\x4b\x55\x28\xc9\x48\x55\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x52\x48\xce\xcf\x29\xcd\xcd\xd3\xe3\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x83\xeb\xb4\x46\xd1\x52\x94\x5a\x90\x4f\x84\x16\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\x93\x85\x2e\x66\x96\x00\x00\x00")
+var __1528395665_perms_table_drop_providerUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\x08\x4a\xcd\xcd\x2f\x4b\x55\x28\xc9\x48\x55\x28\x28\xca\x2f\xcb\x4c\x49\x2d\x52\x48\xce\xcf\x29\xcd\xcd\xd3\xe3\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x83\xeb\xb4\x46\xd1\x52\x94\x5a\x90\x4f\x84\x16\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\x93\x85\x2e\x66\x96\x00\x00\x00")
 
 func _1528395665_perms_table_drop_providerUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -768,7 +778,7 @@ func _1528395665_perms_table_drop_providerUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395666_lsif_filenameDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcb\x4e\xc3\x30\x14\x44\xf7\xfe\x8a\xd9\x45\x42\xb4\x3f\x10\x75\x91\x26\x06\x2a\xe5\x51\xa5\x2e\x65\x57\x85\xf8\x56\xb5\x88\x1f\x8a\x6d\x2a\xfe\x1e\xb5\x01\x55\x64\xc1\xd2\xf2\x9c\xb9\x67\xd6\xfc\x79\x53\xa7\x8c\x2d\x16\x28\x46\xeb\xf0\xa9\xe8\x02\x49\x8e\x8c\x24\x13\x60\x0d\x7a\x3b\x44\x6d\x58\xd1\x36\x5b\xbc\x6e\xf8\x01\x83\x57\xa7\xa3\x8c\xda\xf9\x89\xcb\xa4\xc4\x7b\xd7\x7f\x40\x46\xad\xbf\x70\x52\x03\x99\x4e\xd3\x2f\x98\x95\x82\xb7\x10\xd9\xba\xe4\x13\x1a\xdd\x60\x3b\xe9\x91\x15\x05\xf2\xa6\xdc\x57\xf5\x9d\x11\xfc\x4d\xa4\x6c\xbf\x2d\x32\x31\x4b\xef\xb8\xb8\xc7\x56\x48\x92\xf4\x9f\xe6\xdb\xc7\xbc\xfb\xda\x50\x37\x02\xf5\xbe\x2c\x27\xf3\x96\xfa\x91\xba\x40\xd3\xea\x8b\x0a\x67\x8c\xb7\xac\xfc\x91\xf7\x2c\x6f\xf9\x55\x65\x36\x1c\xd9\x0e\x3b\x5e\xf2\x5c\x20\x2e\x1f\x1e\x11\x97\x27\x65\x94\x3f\x93\x3c\x76\x01\x9d\x87\x1b\x6d\x4f\xde\x4f\xef\xa7\xb6\xa9\xfe\x0a\x46\x1c\x5e\x78\xcb\xe1\xc3\xf5\xfa\x0a\x49\x6f\xb5\x1b\x28\x90\x4c\x52\xc6\xf2\xa6\xaa\x36\x22\x65\xdf\x01\x00\x00\xff\xff\xde\x6a\x10\xb2\x9c\x01\x00\x00")
+var __1528395666_lsif_filenameDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x8f\xcb\x4e\xc3\x30\x14\x44\xf7\xfe\x8a\xd9\x45\x42\xb4\x3f\x10\x75\x91\x26\x06\x2a\xe5\x51\xa5\x2e\x65\x57\x85\xf8\x56\xb5\x88\x1f\x8a\x6d\x2a\xfe\x1e\xb5\x01\x55\x64\xc1\xd2\xf2\x9c\xb9\x67\xd6\xfc\x79\x53\xa7\x8c\x2d\x16\x28\x46\xeb\xf0\xa9\xe8\x02\x49\x8e\x8c\x24\x13\x60\x0d\x7a\x3b\x44\x6d\x58\xd1\x36\x5b\xbc\x6e\xf8\x01\x83\x57\xa7\xa3\x8c\xda\xf9\x89\xcb\xa4\xc4\x7b\xd7\x7f\x40\x46\xad\xbf\x70\x52\x03\x99\x4e\xd3\x2f\x98\x95\x82\xb7\x10\xd9\xba\xe4\x13\x1a\xdd\x60\x3b\xe9\x91\x15\x05\xf2\xa6\xdc\x57\xf5\x9d\x11\xfc\x4d\xa4\x6c\xbf\x2d\x32\x31\x4b\xef\xb8\xb8\xc7\x56\x48\x92\xf4\x9f\xe6\xdb\xc7\xbc\xfb\xda\x50\x37\x02\xf5\xbe\x2c\x27\xf3\x96\xfa\x91\xba\x40\xd3\xea\x8b\x0a\x67\x8c\xb7\xac\xfc\x91\xf7\x2c\x6f\xf9\x55\x65\x36\x1c\xd9\x0e\x3b\x5e\xf2\x5c\x20\x2e\x1f\x1e\x11\x97\x27\x65\x94\x3f\x93\x3c\x76\x01\x9d\x87\x1b\x6d\x4f\xde\x4f\xef\xa7\xb6\xa9\xfe\x0a\x46\x1c\x5e\x78\xcb\xe1\xc3\xf5\xfa\x0a\x49\x6f\xb5\x1b\x28\x90\x4c\x52\xc6\xf2\xa6\xaa\x36\x22\x65\xdf\x03\x00\xde\x6a\x10\xb2\x9c\x01\x00\x00")
 
 func _1528395666_lsif_filenameDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -788,7 +798,7 @@ func _1528395666_lsif_filenameDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395666_lsif_filenameUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\x41\x6a\xf3\x30\x10\x46\xf7\x3a\xc5\xb7\x0b\xfc\xfc\xc9\x05\x4c\x17\x8e\x33\x6d\x0d\x76\x5c\x14\xb5\x59\x06\x61\x8d\x89\x40\x96\x84\x25\x35\xd7\x2f\x8d\xb3\x68\xbb\x1c\xe6\xbd\x79\xb3\xa7\x97\xf6\x58\x09\xb1\xdd\xe2\xb0\x84\x88\x4f\xcb\x37\x18\x8e\xec\x0d\xfb\x8c\xe0\x31\x06\x57\x66\x2f\x0e\x72\x78\xc3\x47\x4b\x67\xb8\x64\xa7\x8b\x29\x73\x4c\x3f\xbc\x07\x55\x77\x8a\x24\x54\xbd\xef\x68\xe5\x4a\x74\x41\x9b\x84\xbb\xde\x0c\xdd\x7b\x7f\xc4\x64\x1d\x7b\x3d\xf3\xaa\x4b\x1e\x17\xd6\x99\xd7\xf4\xcd\xe6\x2b\x96\xfb\xda\x3c\x8e\x26\xd1\x48\xaa\x15\xfd\xad\xa3\x3e\xe1\x44\x1d\x35\x0a\x65\xf7\xef\x3f\xca\x6e\xb2\xde\xa6\x2b\x9b\x8b\xce\xd0\x09\x71\x09\x23\xa7\xb4\xce\xcf\x72\xe8\x7f\xbf\x54\x70\x7e\x25\x49\x48\xf9\xbb\xfe\x84\xcd\x18\xe6\xe8\x38\xb3\xd9\x54\x42\x34\x43\xdf\xb7\xaa\x12\x5f\x01\x00\x00\xff\xff\x51\x71\xbc\xfa\x21\x01\x00\x00")
+var __1528395666_lsif_filenameUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\x8e\xc1\x6a\xeb\x30\x10\x45\xf7\xfa\x8a\xbb\x0b\x3c\x5e\xf2\x03\xa6\x0b\xc7\x99\xb6\x06\x3b\x2e\x8a\xda\x2c\x83\xb0\xc6\x44\x20\x4b\xc2\x92\x9a\xdf\x2f\x8d\xb3\x68\xbb\x1c\xe6\x1c\xce\xdd\xd3\x4b\x7b\xac\x84\xd8\x6e\x71\x58\x42\xc4\xa7\xe5\x1b\x0c\x47\xf6\x86\x7d\x46\xf0\x18\x83\x2b\xb3\x17\x07\x39\xbc\xe1\xa3\xa5\x33\x5c\xb2\xd3\xc5\x94\x39\xa6\x1f\xde\x83\xaa\x3b\x45\x12\xaa\xde\x77\xb4\x72\x25\xba\xa0\x4d\xc2\x5d\x6f\x86\xee\xbd\x3f\x62\xb2\x8e\xbd\x9e\x79\xd5\x25\x8f\x0b\xeb\xcc\x6b\xfa\x66\xf3\x15\xcb\xfd\x6d\x1e\xe9\x24\x1a\x49\xb5\xa2\xbf\x75\xd4\x27\x9c\xa8\xa3\x46\xa1\xec\xfe\xfd\x47\xd9\x4d\xd6\xdb\x74\x65\x73\xd1\x19\x3a\x21\x2e\x61\xe4\x94\xd6\xfb\x59\x0e\xfd\xef\x49\x05\xe7\x57\x92\x84\x94\xbf\xeb\x4f\xd8\x8c\x61\x8e\x8e\x33\x9b\x4d\x25\x44\x33\xf4\x7d\xab\x2a\xf1\x35\x00\x51\x71\xbc\xfa\x21\x01\x00\x00")
 
 func _1528395666_lsif_filenameUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -808,7 +818,7 @@ func _1528395666_lsif_filenameUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395667_index_boolean_fields_on_repoDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x28\xca\x2c\x4b\x2c\x49\xb5\xc6\xa3\x24\x2d\xbf\x28\x1b\x9f\x7c\x62\x51\x72\x46\x66\x59\x6a\x8a\x35\x17\x97\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xa1\x2d\x67\x8d\x78\x00\x00\x00")
+var __1528395667_index_boolean_fields_on_repoDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x78\x00\x87\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x70\x72\x69\x76\x61\x74\x65\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x66\x6f\x72\x6b\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x61\x72\x63\x68\x69\x76\x65\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xa1\x2d\x67\x8d\x78\x00\x00\x00")
 
 func _1528395667_index_boolean_fields_on_repoDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -828,7 +838,7 @@ func _1528395667_index_boolean_fields_on_repoDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395667_index_boolean_fields_on_repoUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x28\xca\x2c\x4b\x2c\x49\x55\xf0\xf7\x03\xf3\x35\xa0\x7c\x4d\x6b\x82\x3a\xd3\xf2\x8b\xb2\xe1\xda\x40\x1c\x22\xf4\x24\x16\x25\x67\x64\x96\xa5\xa6\xc0\xf5\xc1\x04\x34\x41\x4e\xf5\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x2d\x25\x7f\xec\xbb\x00\x00\x00")
+var __1528395667_index_boolean_fields_on_repoUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4a\x2d\xc8\x8f\x2f\x28\xca\x2c\x4b\x2c\x49\x55\xf0\xf7\x03\xf3\x35\xa0\x7c\x4d\x6b\x82\x3a\xd3\xf2\x8b\xb2\xe1\xda\x40\x1c\x22\xf4\x24\x16\x25\x67\x64\x96\xa5\xa6\xc0\xf5\xc1\x04\x34\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x2d\x25\x7f\xec\xbb\x00\x00\x00")
 
 func _1528395667_index_boolean_fields_on_repoUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -848,7 +858,7 @@ func _1528395667_index_boolean_fields_on_repoUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395668_campaign_description_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x06\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x11\x64\xbe\x9f\x7f\x08\x4c\xb7\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x20\x00\x00\xff\xff\xe1\x8c\x3b\x97\x90\x00\x00\x00")
+var __1528395668_campaign_description_nullableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x08\x76\x0d\x51\x48\x49\x2d\x4e\x2e\xca\x2c\x28\xc9\xcc\xcf\x53\xb0\x55\x50\x57\x57\x08\xf7\x70\x0d\x72\x45\x11\xf6\x0c\x56\xf0\x0b\xf5\xf1\xb1\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x41\x36\x06\x22\xea\xec\xef\x13\xea\xeb\x87\xa2\x11\x64\xbe\x9f\x7f\x08\x4c\xb7\xb3\xbf\xaf\xaf\x67\x88\x35\x17\x60\x00\xe1\x8c\x3b\x97\x90\x00\x00\x00")
 
 func _1528395668_campaign_description_nullableDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -868,7 +878,7 @@ func _1528395668_campaign_description_nullableDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395668_campaign_description_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x80\x88\x3a\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x85\x06\xb8\x38\x86\x20\x6b\x0c\x76\x0d\x41\x51\x6f\x0b\x56\xa8\x10\xee\xe1\x1a\xe4\x8a\x26\xa1\xae\x6e\xcd\xc5\xe5\xec\xef\xeb\xeb\x19\x62\xcd\x05\x08\x00\x00\xff\xff\x2c\xd9\x1b\x18\x8f\x00\x00\x00")
+var __1528395668_campaign_description_nullableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x2b\x56\x80\x88\x3a\xfb\xfb\x84\xfa\xfa\x29\xa4\xa4\x16\x27\x17\x65\x16\x94\x64\xe6\xe7\x29\xb8\x04\xf9\x07\x28\xf8\xf9\x87\x28\xf8\x85\xfa\xf8\x58\x73\x85\x06\xb8\x38\x86\x20\x6b\x0c\x76\x0d\x41\x51\x6f\x0b\x56\xa8\x10\xee\xe1\x1a\xe4\x8a\x26\xa1\xae\x6e\xcd\xc5\xe5\xec\xef\xeb\xeb\x19\x62\xcd\x05\x18\x00\x2c\xd9\x1b\x18\x8f\x00\x00\x00")
 
 func _1528395668_campaign_description_nullableUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -888,7 +898,7 @@ func _1528395668_campaign_description_nullableUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395669_add_synced_at_to_perms_tablesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xae\xcc\x4b\x4e\x4d\x89\x4f\x2c\xb1\x46\xd1\x53\x94\x5a\x90\x4f\x8c\x1e\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\xe9\xec\x50\xea\x79\x00\x00\x00")
+var __1528395669_add_synced_at_to_perms_tablesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x79\x00\x86\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x75\x73\x65\x72\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x73\x79\x6e\x63\x65\x64\x5f\x61\x74\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x72\x65\x70\x6f\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x73\x79\x6e\x63\x65\x64\x5f\x61\x74\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe9\xec\x50\xea\x79\x00\x00\x00")
 
 func _1528395669_add_synced_at_to_perms_tablesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -908,7 +918,7 @@ func _1528395669_add_synced_at_to_perms_tablesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395669_add_synced_at_to_perms_tablesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xae\xcc\x4b\x4e\x4d\x89\x4f\x2c\x51\x08\xf1\xf4\x75\x0d\x0e\x71\xf4\x0d\x08\x89\xb2\x46\xd1\x5e\x94\x5a\x90\x4f\xa2\x76\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\x40\x00\x00\x00\xff\xff\xbd\x73\x4f\x2f\x8f\x00\x00\x00")
+var __1528395669_add_synced_at_to_perms_tablesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x2d\x4e\x2d\x8a\x2f\x48\x2d\xca\xcd\x2c\x2e\xce\xcc\xcf\x2b\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xae\xcc\x4b\x4e\x4d\x89\x4f\x2c\x51\x08\xf1\xf4\x75\x0d\x0e\x71\xf4\x0d\x08\x89\xb2\x46\xd1\x5e\x94\x5a\x90\x4f\xa2\x76\x2e\x67\x7f\x5f\x5f\xcf\x10\x6b\x2e\xc0\x00\xbd\x73\x4f\x2f\x8f\x00\x00\x00")
 
 func _1528395669_add_synced_at_to_perms_tablesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -928,7 +938,7 @@ func _1528395669_add_synced_at_to_perms_tablesUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395670_lsif_uploadingDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x53\xcb\x6e\xdb\x30\x10\xbc\xeb\x2b\x06\xb9\x38\x29\x94\x7c\x80\x85\x1e\x14\x99\x49\x05\xe8\x91\xea\xd1\xb4\x27\x41\x30\xd7\x35\x01\x4b\x64\x49\xaa\x49\xfe\xbe\x90\x28\xb5\x76\x6b\xe7\xd0\xa3\x96\x33\xbb\xb3\xb3\xa3\x7b\xf6\x18\x67\x81\xe7\xdd\xde\x62\xa3\xa5\xc2\x4f\x41\x2f\x68\x7b\x0e\xd1\x73\x7a\x85\xdd\xb7\x16\x9c\x14\xf5\xdc\x40\xf6\xb0\x7b\x61\x60\xdf\x14\x79\x9b\x22\x7f\xc2\x97\x98\x3d\xe3\x60\xc4\xae\xe1\x43\xa7\x4c\xe0\xaa\x71\xb6\x61\x5f\x5d\x79\x50\x07\xd9\x72\xd3\x68\x52\xd2\x08\x2b\xf5\x5b\x23\x78\xb3\x95\x5d\x27\x6c\xa3\xa5\xb4\xcd\x34\x87\xb4\x93\x10\x69\x6a\x2d\x41\x1e\x38\xa8\x1f\x3a\x2f\x2a\x58\x58\x31\x54\xdf\x9e\xd8\x71\xbf\xc6\xd8\xd6\x52\x63\xa9\x53\x08\x4b\xb0\xac\x4e\xaf\x3d\x00\x58\xfd\x18\x68\x20\xbe\xf2\xdd\x97\xd2\x72\x4b\xc6\x88\xfe\xfb\x52\xd9\xca\x4e\x1d\xc8\xfe\x81\x90\xd6\x52\x13\x5f\x79\x37\x4e\x41\xad\xf8\xa8\x60\x5c\x11\x72\x87\x69\x10\xb6\xf2\x30\x74\xbd\x17\x26\x15\x2b\x50\x85\xf7\xc9\x89\x1a\x33\x75\x9a\x36\x8f\xf2\xa4\x4e\x33\xf4\x43\xd7\xa8\x56\x5b\xe3\xff\xf3\xe4\x28\xc4\x8f\xdf\x5d\xdf\x19\xe0\x26\x4e\x94\x0d\x7b\x08\xeb\xa4\xba\x08\x7a\xcf\x96\xba\x8c\xb3\x47\x5c\x47\x61\xc9\x66\xf4\xf3\x27\x96\x61\xe5\x90\xa3\x23\xa8\xa6\xc2\xec\x18\x58\xb2\x20\xd7\x6b\x4b\xaf\x16\x2c\xdb\xdc\xac\xd7\xe7\xdb\x5f\x94\x54\xb2\x6a\x91\xfd\xbb\xb5\x33\xb6\x7c\x11\x76\xbb\x9f\xce\x8a\xbe\xed\xc8\xb8\xac\x9c\xdf\x21\x58\xbc\x7e\x67\xc3\x82\x65\x61\xca\x50\xe5\xe7\xe8\xe3\xc4\x82\x8c\x95\x9a\xe6\x24\x8f\x99\x1e\xc3\xbd\x64\xaa\xce\xe2\xcf\x35\xfb\xaf\xac\x22\xcf\x4e\x18\xd7\x27\x0c\x1f\x57\x8e\x73\xe5\x63\x64\xf9\x98\x69\x37\xe3\x09\x8a\xe5\x1c\x1f\x8f\xc3\x78\xc6\xe7\x60\x11\xfa\xd7\x3f\x36\x06\xbe\x64\x09\x8b\x2a\x0c\x77\x1f\x7c\x0c\x77\x3b\xd1\x0b\xb3\x27\xde\xb4\x16\xad\xc1\x1c\x7a\xf7\xfd\x50\xe4\xe9\x89\x56\x0c\x97\x55\x04\x9e\x17\xe5\x69\x1a\x57\x81\xf7\x2b\x00\x00\xff\xff\xc4\xfd\x16\x65\x17\x04\x00\x00")
+var __1528395670_lsif_uploadingDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x53\xcb\x6e\xdb\x30\x10\xbc\xeb\x2b\x06\xb9\xd8\x2e\x94\x7c\x80\x85\x1e\x14\x99\x49\x04\xe8\x91\xea\x51\xb7\x27\x41\x30\xd7\xb5\x00\x4b\x64\x49\xaa\x49\xfe\xbe\x90\x28\xb5\x76\x6b\xe7\x90\x23\x97\x33\xbb\xb3\xc3\xe1\x3d\x7b\x0c\x13\xcf\x71\x6e\x6f\xb1\x51\x42\xe2\x57\x43\x2f\xa8\x3b\x8e\xa6\xe3\xf4\x0a\x73\xa8\x0d\x38\x49\xea\xb8\x86\xe8\x60\x0e\x8d\x86\x79\x93\xe4\x6c\xb2\xf4\x19\x5f\x43\xb6\xc5\x51\x37\xfb\x8a\xf7\xad\xd4\x9e\xad\x86\xc9\x86\x7d\xb3\xe5\x5e\x1e\x45\xcd\x75\xa5\x48\x0a\xdd\x18\xa1\xde\xaa\x86\x57\x3b\xd1\xb6\x8d\xa9\x94\x10\xa6\x1a\xe7\x90\xb2\x12\x02\x45\xb5\x21\x88\x23\x07\x75\x7d\xeb\x04\x19\xf3\x0b\x86\xe2\xfb\x33\x3b\xed\x57\x69\x53\x1b\xaa\x0c\xb5\x12\x7e\x0e\x96\x94\xf1\xd2\x01\x80\xc5\xcf\x9e\x7a\xe2\x0b\xd7\x9e\xa4\x12\x3b\xd2\xba\xe9\x7e\xcc\x95\x9d\x68\xe5\x91\xcc\x5f\x08\x29\x25\x14\xf1\x85\xb3\xb2\x0a\x4a\xc9\x07\x05\xc3\x8a\x10\x7b\x8c\x83\xb0\x13\xc7\xbe\xed\x1c\x3f\x2a\x58\x86\xc2\xbf\x8f\xce\xd4\xe8\xb1\xd3\xb8\x79\x90\x46\x65\x9c\xa0\xeb\xdb\x4a\xd6\xca\x68\xf7\xbf\x2b\xbb\x00\xf1\xd3\x7b\xdb\x77\x02\xd8\x89\x23\x65\xc3\x1e\xfc\x32\x2a\xae\x82\xde\xb3\xa5\xcc\xc3\xe4\x11\xcb\xc0\xcf\xd9\x84\xde\x3e\xb1\x04\x0b\x8b\x1c\x1c\x41\x31\x16\x26\xc7\xc0\xa2\x19\xb9\x5e\x1b\x7a\x35\x60\xc9\x66\xb5\x5e\x5f\x6e\x7f\x55\x52\xce\x8a\x59\xf6\x9f\xd6\xd6\xd8\xfc\xa5\x31\xbb\xc3\xf8\xac\xe8\xea\x96\xb4\xcd\xca\xe5\x1d\xbc\xd9\xeb\x77\x36\xcc\x58\xe2\xc7\x0c\x45\x7a\x89\x3e\x4c\xcc\x48\x1b\xa1\x68\x4a\xf2\x90\xe9\x21\xdc\x73\xa6\xca\x24\xfc\x52\xb2\x0f\x65\x15\x69\x72\xc6\x58\x9e\x31\x5c\xdc\x58\xce\x8d\x8b\x21\xe1\x2e\x26\xda\x0a\xdb\x27\x96\xcd\xcf\xf1\xf9\x34\x8c\x17\x7c\xf6\x66\xa1\xff\xfc\x31\xf8\x39\x72\x16\xb1\xa0\x40\x7f\xf7\xc9\x45\x7f\xb7\x6f\xba\x46\x1f\x88\x57\xb5\x41\xad\x31\x85\xde\x9e\x1f\xb2\x34\x3e\xd3\x8a\xfe\xba\x0a\xcf\x71\x82\x34\x8e\xc3\xc2\x73\x7e\x0f\x00\xc4\xfd\x16\x65\x17\x04\x00\x00")
 
 func _1528395670_lsif_uploadingDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -948,7 +958,7 @@ func _1528395670_lsif_uploadingDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395670_lsif_uploadingUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x54\xc1\x6e\xe2\x48\x10\xbd\xfb\x2b\x4a\xb9\x90\xac\x0c\xda\xbd\x82\x72\x20\xd0\x49\x90\xc0\x64\xc1\xde\xec\x6a\xb5\xb2\x3a\x76\x11\xb7\xd2\xee\xf6\x76\x97\x21\x68\x34\xff\x3e\x6a\xb7\x49\x0c\x43\x32\xa3\xb9\xe1\xf2\x7b\x55\xe5\x57\xef\x71\xc3\xee\x66\xd1\x28\x08\xfa\x7d\x98\x14\x5c\x3d\xa3\x1d\xba\xdf\x00\x7d\xe0\x79\x0e\xaa\x2e\xd3\x8a\x1b\xb2\x90\x69\x59\x97\x0a\x48\x83\xb4\x62\x93\xd6\x95\xd4\x3c\xb7\x5d\xac\x2f\x61\xfe\xd3\x84\x9e\xaf\x09\xf5\xdc\x03\x4b\x9c\xf0\x04\x9c\xfa\x22\xaa\xba\x0c\xfa\x7d\xc7\x4c\xd4\x46\x1b\xaa\x15\x27\x89\x7b\xd8\x21\x64\x5c\xf5\xa8\x69\xc6\x61\xcb\x65\xdd\xb4\xe0\x0d\x05\x76\x82\x0a\xa1\x80\x03\x19\xae\x2c\xcf\x48\x68\x15\x82\xd5\x8e\x57\xf0\x6d\x03\x2d\xf9\x0b\xba\xc6\x5c\x01\x2a\x12\x06\xe5\x1e\x14\xee\x7c\x03\xae\x72\xcf\xdd\xa0\x01\x2e\x25\x18\xdc\x18\x54\x19\x5a\x47\xa5\x02\x41\xcb\xdc\x43\xdb\x67\x47\xd5\x0a\x07\x70\xef\x60\xae\xb1\xab\x6e\xd1\x3c\x69\x2b\x68\x0f\x05\x1a\x1c\x34\x62\x4f\x8d\xae\x60\x2b\x70\xd7\x4c\x11\x2a\xc7\x57\xa0\x82\x13\xe4\x58\xa1\xca\x2d\x68\x05\x54\x08\x0b\xb4\xaf\x30\x98\xae\x96\x0f\xf0\xd7\x8c\x3d\x7a\x79\xf2\xba\xac\xec\xc8\x57\x67\xd1\x94\xfd\x7d\x24\x71\x6a\xb0\x72\xe3\xb4\xd9\xa7\x22\x4f\x33\x5d\x96\x82\x52\xa3\x35\xa5\xcd\x1c\x34\xed\xbd\x0d\x3a\x79\x0f\x9f\x1b\x4c\x56\x6c\x1c\x33\x88\xff\x79\x60\xdf\x5f\x21\x25\x2c\x2b\x18\xaf\x81\x45\xc9\xe2\x32\x00\x80\xee\xf9\x42\x5f\xf8\xbf\xc6\x1a\xf3\xc3\x53\x65\x74\x86\xd6\x76\xde\x67\xba\xac\x24\xd2\x3b\x04\x8d\xd1\x06\xf3\x5e\x70\xe5\x57\x8a\x0b\x04\x9e\x51\xcd\x25\x64\x8d\x1b\x83\xf1\x3c\x66\x2b\x88\xc7\x37\x73\x76\xec\x23\xc7\x1f\x4f\xa7\x30\x59\xce\x93\x45\xd4\x31\xaa\x50\x14\x9e\xbe\x3d\xb1\xa6\x50\xf4\xef\x7f\x2d\xa8\xe9\xdf\xc2\xbc\xdf\x1a\x59\xa7\xec\x76\x9c\xcc\xe3\x0f\x41\x9f\xa9\x94\xac\x67\xd1\x9d\xc7\x0d\x87\x84\xaf\x34\x1c\x9e\x47\x7e\xd8\x7d\xcd\xe2\xc3\x06\x6f\xaa\x7a\x85\x6e\x78\xf6\xb2\x11\x52\xbe\xd9\xcd\xc7\xcc\x06\xc9\xc3\xd4\x5d\xaf\xab\x51\xd3\xe6\x5d\x98\x6b\xf8\x23\x3c\x55\xe2\x1a\x7a\x5f\x7e\xff\xda\xf6\x5e\xf0\x17\x74\x7d\x4b\x50\x5a\xf5\x55\x2d\x25\x7f\x92\x3f\x3a\x41\x77\xf9\xf7\x59\x6e\x72\xb4\x8c\x21\x4a\xe6\xf3\x33\x5f\x79\xb2\x45\x17\xed\x57\x59\xef\x04\x65\x85\xcf\x96\xe2\x25\x5a\x6f\xf6\xf3\xaa\x8f\x0e\x2b\x7e\x72\x93\x15\x8b\xc6\x0b\x06\xf1\xf2\x1c\xdd\x4d\x5c\xa1\x25\x6d\xb0\x8d\xa2\x0b\xa5\x4b\xe7\x21\x14\x49\x34\xfb\x33\x61\xbf\x14\x36\x58\x46\x47\x8c\xcb\x23\x46\x08\x17\x9e\x73\x11\x82\x63\x85\xd0\xd2\xae\xe0\xf1\x9e\xad\x58\x6b\x88\xeb\x6e\x78\xce\xd8\x69\x74\x58\xf4\xe4\x4f\xc2\x25\x76\xcd\xe6\x6c\x12\x43\x3d\xf8\x2d\x84\x7a\xb0\x11\x4a\xd8\x02\xf3\x94\x13\x70\x0b\x6d\x48\xfd\xf3\xed\x6a\xb9\x38\x76\x50\xfd\xf1\x16\xa3\x20\x98\x2c\x17\x8b\x59\x3c\x0a\xbe\x05\x00\x00\xff\xff\x11\xf8\xfa\xf4\x45\x06\x00\x00")
+var __1528395670_lsif_uploadingUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x54\xd1\x6e\xe2\x38\x14\x7d\xcf\x57\x5c\xf5\x85\x76\x15\xd0\xee\x2b\xa8\x0f\x29\xb8\x2d\x12\x84\x2e\x24\xdb\x5d\xad\x56\x91\x9b\x5c\x1a\xab\x8e\x9d\xb5\x6f\xa0\x68\x34\xff\x3e\x72\x1c\x5a\x60\x68\x67\x34\x6f\xc4\x39\xe7\xdc\x93\xe3\x7b\xb8\x61\x77\xd3\x78\x14\x04\xfd\x3e\x8c\x4b\xae\x9e\xd1\x0e\xdd\x6f\x80\x3e\xf0\xa2\x00\xd5\x54\x59\xcd\x0d\x59\xc8\xb5\x6c\x2a\x05\xa4\x41\x5a\xb1\xce\x9a\x5a\x6a\x5e\xd8\x43\xac\x3f\xc2\xe2\xa7\x09\x3d\x7f\x26\xd4\x73\x0f\x2c\x71\xc2\x13\x70\xe6\x0f\x51\x35\x55\xd0\xef\xbb\x51\xa9\x5a\x6b\x43\x8d\xe2\x24\x71\x07\x5b\x84\x9c\xab\x1e\xb5\x62\x1c\x36\x5c\x36\xad\x04\x6f\x29\xb0\x15\x54\x0a\x05\x1c\xc8\x70\x65\x79\x4e\x42\xab\x10\xac\x76\xbc\x92\x6f\x5a\x68\xc5\x5f\xd0\x09\x73\x05\xa8\x48\x18\x94\x3b\x50\xb8\xf5\x02\x5c\x15\x9e\xbb\x46\x03\x5c\x4a\x30\xb8\x36\xa8\x72\xb4\x8e\x4a\x25\x82\x96\x85\x87\x76\xcf\x8e\xaa\x15\x0e\xe0\xde\xc1\x9c\xb0\x43\x6d\xd0\x3c\x69\x2b\x68\x07\x25\x1a\x1c\xb4\x61\x4f\x8c\xae\x61\x23\x70\xdb\x4e\x11\xaa\xc0\x57\xa0\x92\x13\x14\x58\xa3\x2a\x2c\x68\x05\x54\x0a\x0b\xb4\xab\x31\x98\x2c\x17\x0f\xf0\xd7\x94\x3d\xfa\x2c\x8b\xa6\xaa\xed\xc8\x9f\x4e\xe3\x09\xfb\xfb\x28\xe2\xcc\x60\xed\xc6\x69\xb3\xcb\x44\x91\xe5\xba\xaa\x04\x65\x46\x6b\xca\xda\x39\x68\xba\xfb\x36\xe8\xe2\xdd\x7f\x6e\x30\x5e\xb2\x28\x61\x90\xfc\xf3\xc0\xbe\xbf\x85\x8c\xb0\xaa\x21\x5a\x01\x8b\xd3\xf9\x65\x00\x00\x87\xd7\x17\xfa\x83\xff\x1b\x6c\xb0\xd8\x3f\xd5\x46\xe7\x68\xed\xc1\xfb\x5c\x57\xb5\x44\x7a\x87\xa0\x31\xda\x60\xd1\x0b\xae\xbc\xa5\xa4\x44\xe0\x39\x35\x5c\x42\xde\x6e\x63\x10\xcd\x12\xb6\x84\x24\xba\x99\x1d\x99\xb2\x2d\x3f\x9a\x4c\x60\xbc\x98\xa5\xf3\xf8\x60\x51\x85\xa2\xf0\xf4\xed\xc9\x6a\x0a\x45\xff\xfe\xd7\x81\x5a\xfd\x0e\xe6\xf7\xad\x8d\x75\xc2\x6e\xa3\x74\x96\x7c\x08\xfa\x2c\xa5\x74\x35\x8d\xef\x3c\x6e\x38\x24\x7c\xa5\xe1\xf0\x3c\xf2\x43\xf5\x15\x4b\xf6\x0e\xde\x52\xf5\x09\xdd\xf0\xfc\x65\x2d\xa4\x7c\x5b\x37\xdf\x4b\x1b\xa4\x0f\x93\x28\x39\xb2\x64\x5b\x99\xf7\x60\xae\xe1\x8f\xf0\xb4\xa4\xd7\xd0\xfb\xf2\xfb\xd7\x4e\x7b\xce\x5f\xd0\xe9\x56\xa0\xb4\xea\xab\x46\x4a\xfe\x24\x7f\x74\x05\x87\xe6\xdf\x67\xb9\xc9\xf1\x22\x81\x38\x9d\xcd\xce\x7c\xe5\x89\x8b\x43\xb4\xb7\xb2\xda\x0a\xca\x4b\xdf\x2d\xc5\x2b\xb4\x7e\xd9\xcf\xa7\x3e\xda\x5b\xfc\xe4\x4e\x96\x2c\x8e\xe6\x0c\x92\xc5\x39\xba\x9b\xb8\x44\x4b\xda\x60\x57\x45\x57\x4a\xd7\xce\x7d\x29\xd2\x78\xfa\x67\xca\x7e\xa9\x6c\xb0\x88\x8f\x18\x97\x47\x8c\x10\x2e\x3c\xe7\x22\x04\x57\xd1\x10\x3a\xda\x15\x3c\xde\xb3\x25\xeb\x16\xe2\xfa\xb0\x3c\x67\xd6\x69\xb4\x37\x7a\xf2\x27\xe1\x1a\xbb\x62\x33\x36\x4e\xa0\x19\xfc\x16\x42\x33\x58\x0b\x25\x6c\x89\x45\xc6\x09\xb8\x85\xae\xa4\xfe\xf9\x76\xb9\x98\x1f\x79\x85\xe6\x63\x17\xa3\x20\x18\x2f\xe6\xf3\x69\x32\x0a\xbe\x0d\x00\x11\xf8\xfa\xf4\x45\x06\x00\x00")
 
 func _1528395670_lsif_uploadingUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -968,7 +978,7 @@ func _1528395670_lsif_uploadingUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395671_remove_tracing_contextDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\xbd\x6e\xe3\x30\x10\x84\x7b\x3e\xc5\x74\x06\x0e\x67\xbf\x80\x70\x85\x2c\xf1\xee\x0c\xe8\xc7\x90\xe9\x38\x9d\xc1\x88\xeb\x88\x88\x4c\x12\xe2\x2a\x71\x10\xe4\xdd\x03\x59\x55\x1c\x24\xe5\x62\x67\x67\xe6\xdb\xb5\xfc\xb7\xa9\x12\x21\x96\x4b\xe4\x83\x0f\x78\xb6\xf4\x02\xed\x0c\xac\x33\x74\x01\x77\x9a\x61\x28\x90\x33\x11\xde\x81\x3b\x1b\xc1\xaf\x81\x44\xde\xd4\x5b\xdc\x6d\xe4\x01\x7d\xb4\xa7\xa3\x19\xcf\x21\xce\x3e\xa9\x31\x78\xd0\xed\x13\xb8\x23\xb4\xbe\x1f\xcf\x4e\xa4\x85\x92\x0d\x54\xba\x2e\xe4\xac\x1f\x43\xef\xb5\x89\x48\xf3\x1c\x59\x5d\xec\xcb\x0a\x3c\xe8\xd6\xba\xc7\x63\xeb\x1d\xd3\x85\xa1\xe4\xbd\x4a\xc4\x7e\x9b\xa7\xea\xe6\x68\x27\xd5\x17\xf5\x1f\x2c\xde\xde\x17\xc9\x0f\x49\xd7\xc5\x37\x59\x93\x63\x55\x2b\x54\xfb\xa2\x98\x29\x1a\x8a\xec\x07\xba\x42\x4c\x4f\x11\x59\x23\xa7\x22\x37\xc8\x48\x77\xd8\xc9\x42\x66\x0a\xe3\xea\xd7\x6f\x8c\xab\x93\x75\x36\x76\x64\x8e\x9a\xa1\x23\xc2\xe0\x5b\x8a\x71\x9e\xff\x36\x75\xf9\xb9\xd5\x88\xc3\x7f\xd9\x48\x44\xd6\x4c\x13\x44\xeb\xcf\xa1\x27\x26\xb3\x48\x84\xc8\xea\xb2\xdc\xa8\x44\x7c\x04\x00\x00\xff\xff\x07\xfe\x83\x2e\xa6\x01\x00\x00")
+var __1528395671_remove_tracing_contextDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\xcd\x4e\xc3\x30\x10\x84\xef\x7e\x8a\xb9\x45\x42\xb4\x2f\x10\xf5\x90\x26\x06\x2a\xe5\xa7\x4a\x5d\xca\xad\x32\xf1\x96\x44\xa4\xb6\x15\x6f\xa0\x08\xf1\xee\xa8\xcd\x89\x22\x38\xae\x76\x76\x66\xbe\x5d\xca\xfb\x55\x19\x0b\x31\x9b\x21\x1b\x9c\xc7\x5b\x47\xef\xd0\xd6\xa0\xb3\x86\x4e\xe0\x56\x33\x0c\x79\xb2\x26\xc0\x59\x70\xdb\x05\xf0\x87\x27\x91\xd5\xd5\x1a\x8f\x2b\xb9\x43\x1f\xba\xc3\xde\x8c\x47\x1f\x26\x9f\xc4\x18\x3c\xeb\xe6\x15\xdc\x12\x1a\xd7\x8f\x47\x2b\x92\x5c\xc9\x1a\x2a\x59\xe6\x72\xd2\x8f\xbe\x77\xda\x04\x24\x59\x86\xb4\xca\xb7\x45\x09\x1e\x74\xd3\xd9\x97\x7d\xe3\x2c\xd3\x89\xa1\xe4\x93\x8a\xc5\x76\x9d\x25\xea\xea\x68\x23\xd5\x2f\xf5\x02\xd1\xe7\x57\x14\xff\x93\x74\x59\xfc\x91\x75\x76\x2c\x2b\x85\x72\x9b\xe7\x13\x45\x4d\x81\xdd\x40\x17\x88\xf3\x53\x44\x5a\xcb\x73\x91\x2b\x64\x24\x1b\x6c\x64\x2e\x53\x85\x71\x7e\x73\x8b\x71\x7e\xe8\x6c\x17\x5a\x32\x7b\xcd\xd0\x01\x7e\x70\x0d\x85\x30\xcd\x77\x75\x55\xfc\x6c\x35\x62\xf7\x20\x6b\x89\xc0\x9a\x09\x0b\x44\x8d\x3b\xfa\x9e\x98\x4c\x14\x0b\x91\x56\x45\xb1\x52\xb1\xf8\x1e\x00\x07\xfe\x83\x2e\xa6\x01\x00\x00")
 
 func _1528395671_remove_tracing_contextDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -988,7 +998,7 @@ func _1528395671_remove_tracing_contextDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395671_remove_tracing_contextUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\xce\xc1\x4a\xc3\x40\x10\xc6\xf1\xfb\x3e\xc5\x77\x2b\x88\xed\x0b\x14\x0f\x69\xba\x6a\x21\x69\x64\x1b\xed\x31\x2c\xd9\xa9\x59\x48\x76\x97\xcc\x6c\xad\x6f\x2f\x1a\x3c\xe8\x71\x60\xf8\x7d\xff\x9d\x7e\x3a\x1c\xb7\x4a\xad\xd7\xd8\xcf\x31\xe1\xea\xe9\x03\x36\x38\xf8\xe0\xe8\x06\x19\xac\xc0\x51\xa2\xe0\x18\x31\x40\x06\xcf\x90\xcf\x44\x6a\x6f\x9a\x17\xbc\x1d\xf4\x19\x23\xfb\x4b\xe7\xf2\x94\x78\x71\x0c\x4d\xf1\x4a\x90\x81\xd0\xc7\x31\x4f\x41\x15\x55\xab\x0d\xda\x62\x57\xe9\xe5\x3b\xa7\x31\x5a\xc7\xf8\x41\xca\xa6\x7a\xad\x8f\x90\xd9\xf6\x3e\xbc\x77\x7d\x0c\x42\x37\xf9\xb5\x58\xe2\xbc\x60\xdf\x69\xaa\x34\xba\x68\xf5\xff\x61\x14\x27\x9c\x74\xa5\xcb\x16\x79\x73\x77\x8f\xbc\xb9\xf8\xe0\x79\x20\xd7\x59\x81\x65\xa4\x39\xf6\xc4\xbc\xdc\x8f\xa6\xa9\xff\x76\x64\x9c\x9f\xb5\xd1\x60\xb1\x42\x78\xc0\xaa\x8f\x53\x1a\x49\xc8\xad\xb6\x4a\x95\x4d\x5d\x1f\xda\xad\xfa\x0a\x00\x00\xff\xff\x80\x9b\x79\xd8\x2c\x01\x00\x00")
+var __1528395671_remove_tracing_contextUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5c\xce\xc1\x4a\xc3\x40\x10\xc6\xf1\xfb\x3e\xc5\x77\x2b\x88\xed\x0b\x14\x0f\x69\xba\x6a\x21\x69\x64\x1b\xed\x31\x2c\xd9\xa9\x59\x48\x76\x97\xcc\x6c\xad\x6f\x2f\x1a\x3c\xe8\x71\x60\xf8\x7d\xff\x9d\x7e\x3a\x1c\xb7\x4a\xad\xd7\xd8\xcf\x31\xe1\xea\xe9\x03\x36\x38\xf8\xe0\xe8\x06\x19\xac\xc0\x51\xa2\xe0\x18\x31\x40\x06\xcf\x90\xcf\x44\x6a\x6f\x9a\x17\xbc\x1d\xf4\x19\x23\xfb\x4b\xe7\xf2\x94\x78\x71\x0c\x4d\xf1\x4a\x90\x81\xd0\xc7\x31\x4f\x41\x15\x55\xab\x0d\xda\x62\x57\xe9\xe5\x3b\xa7\x31\x5a\xc7\xf8\x41\xca\xa6\x7a\xad\x8f\x90\xd9\xf6\x3e\xbc\x77\x7d\x0c\x42\x37\xf9\xb5\x58\xe2\xbc\x60\xdf\x69\xaa\x34\xba\x68\xf5\xff\x61\x14\x27\x9c\x74\xa5\xcb\x16\x79\x73\x77\x8f\xbc\xb9\xf8\xe0\x79\x20\xd7\x59\x81\x65\xa4\x39\xf6\xc4\xbc\xdc\x8f\xa6\xa9\xff\x76\x64\x9c\x9f\xb5\xd1\x60\xb1\x42\x78\xc0\xaa\x8f\x53\x1a\x49\xc8\xad\xb6\x4a\x95\x4d\x5d\x1f\xda\xad\xfa\x1a\x00\x80\x9b\x79\xd8\x2c\x01\x00\x00")
 
 func _1528395671_remove_tracing_contextUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1008,7 +1018,7 @@ func _1528395671_remove_tracing_contextUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395672_emptyDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\xd2\xd5\x55\xf0\xcb\x2f\xc9\xc8\xcc\x4b\x57\x28\xc9\x57\x28\xcd\x4b\xc9\x57\xe4\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x48\xaf\x33\x57\x25\x00\x00\x00")
+var __1528395672_emptyDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x25\x00\xda\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x2d\x2d\x20\x4e\x6f\x74\x68\x69\x6e\x67\x20\x74\x6f\x20\x75\x6e\x64\x6f\x21\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x48\xaf\x33\x57\x25\x00\x00\x00")
 
 func _1528395672_emptyDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1028,7 +1038,7 @@ func _1528395672_emptyDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395672_emptyUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xcd\x41\x0a\xc2\x30\x10\x46\xe1\x7d\x4e\xf1\x5f\xa0\xbd\x40\x77\x16\x91\x2e\xaa\x9b\x5e\x60\x1a\x26\x66\x68\x32\x29\xce\x40\xed\xed\xc5\x8d\xb8\x7d\xf0\xf8\x2e\xd7\xdb\x74\x1f\x42\xe8\x3a\x2c\x59\x0c\x55\x9e\x2f\x72\x69\x8a\x4c\x86\x95\x59\x21\xea\xac\xdf\x44\xa5\x9c\x28\x9c\x1c\x6b\x21\xdd\xfa\xdf\x24\x06\x6f\x30\x66\x48\xc2\xc1\x88\xa4\x48\xf2\x86\x67\xc6\x4a\x71\x8b\xad\xee\xe4\x70\x36\x37\x88\x62\x9c\x70\x88\x67\x90\x82\xeb\xee\xe7\x9f\x9a\xa4\x70\x1f\xc2\xf8\x98\xe7\x69\x19\xc2\x27\x00\x00\xff\xff\x21\xb0\x80\xee\x9f\x00\x00\x00")
+var __1528395672_emptyUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xcd\x41\x0a\xc2\x30\x10\x46\xe1\x7d\x4e\xf1\x5f\xa0\xbd\x40\x77\x16\x91\x2e\xaa\x9b\x5e\x60\x1a\x26\x66\x68\x32\x29\xce\x40\xed\xed\xc5\x8d\xb8\x7d\xf0\xf8\x2e\xd7\xdb\x74\x1f\x42\xe8\x3a\x2c\x59\x0c\x55\x9e\x2f\x72\x69\x8a\x4c\x86\x95\x59\x21\xea\xac\xdf\x44\xa5\x9c\x28\x9c\x1c\x6b\x21\xdd\xfa\xdf\x24\x06\x6f\x30\x66\x48\xc2\xc1\x88\xa4\x48\xf2\x86\x67\xc6\x4a\x71\x8b\xad\xee\xe4\x70\x36\x37\x88\x62\x9c\x70\x88\x67\x90\x82\xeb\xee\xe7\x9f\x9a\xa4\x70\x1f\xc2\xf8\x98\xe7\x69\x19\xc2\x67\x00\x21\xb0\x80\xee\x9f\x00\x00\x00")
 
 func _1528395672_emptyUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1048,7 +1058,7 @@ func _1528395672_emptyUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395673_remove_unused_campaign_jobs_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\x28\x48\x2c\x49\xce\x48\x2d\x8e\x07\xd3\xf1\xc5\xa9\x25\xf1\x99\x29\x0a\xfe\x7e\x30\x71\x85\xd0\x60\x4f\x3f\x77\x85\xa4\x92\xa2\xd4\x54\x05\x0d\x64\x45\x9a\x20\x93\xfc\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\xf4\xbf\x6d\xc3\x5a\x00\x00\x00")
+var __1528395673_remove_unused_campaign_jobs_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5a\x00\xa5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x43\x52\x45\x41\x54\x45\x20\x49\x4e\x44\x45\x58\x20\x70\x61\x74\x63\x68\x65\x73\x5f\x70\x61\x74\x63\x68\x5f\x73\x65\x74\x5f\x69\x64\x20\x4f\x4e\x20\x70\x61\x74\x63\x68\x65\x73\x20\x55\x53\x49\x4e\x47\x20\x62\x74\x72\x65\x65\x20\x28\x70\x61\x74\x63\x68\x5f\x73\x65\x74\x5f\x69\x64\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf4\xbf\x6d\xc3\x5a\x00\x00\x00")
 
 func _1528395673_remove_unused_campaign_jobs_indexDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1068,7 +1078,7 @@ func _1528395673_remove_unused_campaign_jobs_indexDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395673_remove_unused_campaign_jobs_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4e\xcc\x2d\x48\xcc\x4c\xcf\x8b\xcf\xca\x4f\x2a\x8e\x87\xf3\x0a\x72\x12\xf3\xe2\x33\x53\xac\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\x34\x6a\x1c\x95\x46\x00\x00\x00")
+var __1528395673_remove_unused_campaign_jobs_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x46\x00\xb9\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x6a\x6f\x62\x73\x5f\x63\x61\x6d\x70\x61\x69\x67\x6e\x5f\x70\x6c\x61\x6e\x5f\x69\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x34\x6a\x1c\x95\x46\x00\x00\x00")
 
 func _1528395673_remove_unused_campaign_jobs_indexUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1088,7 +1098,7 @@ func _1528395673_remove_unused_campaign_jobs_indexUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395674_add_creation_method_columns_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2e\x4a\x4d\x2c\x49\x4d\x89\x4f\xaa\x8c\x4f\x4e\xcc\x2d\x48\xcc\x4c\xcf\xb3\x26\xcd\x84\xc4\x94\x94\xd4\x94\xf8\x92\x7c\x24\xfd\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x00\x00\x00\xff\xff\x3f\x41\x08\x78\x93\x00\x00\x00")
+var __1528395674_add_creation_method_columns_to_changesetsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x29\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2e\x4a\x4d\x2c\x49\x4d\x89\x4f\xaa\x8c\x4f\x4e\xcc\x2d\x48\xcc\x4c\xcf\xb3\x26\xcd\x84\xc4\x94\x94\xd4\x94\xf8\x92\x7c\x24\xfd\x5c\xce\xfe\xbe\xbe\x9e\x21\xd6\x5c\x80\x01\x00\x3f\x41\x08\x78\x93\x00\x00\x00")
 
 func _1528395674_add_creation_method_columns_to_changesetsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1108,7 +1118,7 @@ func _1528395674_add_creation_method_columns_to_changesetsDownSql() (*asset, err
 	return a, nil
 }
 
-var __1528395674_add_creation_method_columns_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xd0\xc1\x4a\x03\x31\x10\x06\xe0\x7b\x9e\xe2\xbf\xad\x5e\x04\xaf\x5d\x2b\x64\x37\x53\x5d\xc8\x26\xb2\x9b\x45\x6f\x21\x4d\x62\x5d\xd1\x56\x9a\x08\x8a\xf8\xee\x52\x45\x4a\xa1\xd0\x1e\x7a\x0b\x19\xe6\x9b\xf9\xa7\xa2\x9b\x46\x95\x8c\x71\x69\xa8\x83\xe1\x95\x24\xf8\x27\xb7\x5c\xc4\x14\x73\x02\x17\x02\xb5\x96\x43\xab\xe0\xd7\xd1\xe5\x18\xec\xfc\xd3\x7a\xf7\xfa\xe6\xc6\xc5\x12\x95\xd6\x92\xb8\x82\xd2\x06\x6a\x90\x12\x82\x66\x7c\x90\x06\x8f\xee\x25\xc5\xf2\x08\xd5\x85\x10\x83\xcd\xab\xe3\x4d\x36\xdc\x09\x6e\x76\x3c\x97\xe0\x13\xeb\xc9\xec\xe1\xa6\xc8\xeb\xf7\xc8\xee\x6f\xa9\xa3\x5f\x93\x1e\x9a\xde\xf4\x0c\x00\xce\x7a\x92\x54\x1b\x5c\x62\xd6\xe9\x76\x2b\xda\xe7\xd5\x3c\xe1\xaf\x65\xfb\x39\x06\x4c\xe1\xd3\xc5\x18\xc0\x95\xd8\xbc\xfe\x87\xd8\x31\xa4\xc9\x24\xc7\x8f\x8c\xab\x6b\x14\xc5\x81\xfa\xd7\x77\x71\x7e\x20\xc7\xbe\x63\xef\x24\x39\x41\x8a\xcd\x0e\xb5\x6e\xdb\xc6\x94\xec\x27\x00\x00\xff\xff\x74\x11\xe0\x79\x07\x02\x00\x00")
+var __1528395674_add_creation_method_columns_to_changesetsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x90\xc1\x4a\x03\x31\x10\x86\xef\x79\x8a\xff\xb6\x7a\x11\xbc\x76\xad\x90\xdd\x4c\x75\x21\x9b\xc8\x6e\x16\xbd\x85\x34\x89\x75\x45\x5b\x69\x22\x28\xe2\xbb\x4b\x15\x29\x85\x42\x7b\xe8\x6d\x98\x61\xbe\xf9\xbf\xa9\xe8\xa6\x51\x25\x63\x5c\x1a\xea\x60\x78\x25\x09\xfe\xc9\x2d\x17\x31\xc5\x9c\xc0\x85\x40\xad\xe5\xd0\x2a\xf8\x75\x74\x39\x06\x3b\xff\xb4\xde\xbd\xbe\xb9\x71\xb1\x44\xa5\xb5\x24\xae\xa0\xb4\x81\x1a\xa4\x84\xa0\x19\x1f\xa4\xc1\xa3\x7b\x49\xb1\x3c\x82\xea\x42\x88\xc1\xe6\xd5\xf1\x4c\x36\xdc\x09\x6e\x76\x78\x2e\xc1\x27\xd6\x93\xd9\x83\x9b\x22\xaf\xdf\x23\xbb\xbf\xa5\x8e\x7e\x73\xd2\x43\xd3\x9b\x9e\x01\xc0\x59\x4f\x92\x6a\x83\x4b\xcc\x3a\xdd\x6e\x89\xf6\x79\x35\x4f\xf8\x5b\xd9\x36\xc7\x80\x29\x7c\xba\x18\x03\xb8\x12\x9b\xea\xff\x88\x1d\x43\x9a\x4c\x72\xfc\xc8\xb8\xba\x46\x51\x1c\x98\x7f\x7d\x17\xe7\x07\x3c\xf6\x3d\x7b\xc7\xe4\x04\x16\x9b\x0c\xb5\x6e\xdb\xc6\x94\xec\x67\x00\x74\x11\xe0\x79\x07\x02\x00\x00")
 
 func _1528395674_add_creation_method_columns_to_changesetsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1128,7 +1138,7 @@ func _1528395674_add_creation_method_columns_to_changesetsUpSql() (*asset, error
 	return a, nil
 }
 
-var __1528395675_add_index_event_logs_anonymous_user_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x8e\x4f\xcc\xcb\xcf\xab\xcc\xcd\x2f\x2d\x8e\x2f\x2d\x4e\x2d\x8a\xcf\x4c\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x21\x2f\x6b\x31\x44\x00\x00\x00")
+var __1528395675_add_index_event_logs_anonymous_user_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x44\x00\xbb\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x61\x6e\x6f\x6e\x79\x6d\x6f\x75\x73\x5f\x75\x73\x65\x72\x5f\x69\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x21\x2f\x6b\x31\x44\x00\x00\x00")
 
 func _1528395675_add_index_event_logs_anonymous_user_idDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1148,7 +1158,7 @@ func _1528395675_add_index_event_logs_anonymous_user_idDownSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395675_add_index_event_logs_anonymous_user_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\xf0\xf3\x0f\x51\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2d\x4b\xcd\x2b\x89\xcf\xc9\x4f\x2f\x8e\x4f\xcc\xcb\xcf\xab\xcc\xcd\x2f\x2d\x8e\x2f\x2d\x4e\x2d\x8a\xcf\x4c\x51\xf0\xf7\x43\x92\x57\x08\x0d\xf6\xf4\x73\x57\x70\x0a\x09\x72\x75\x55\xd0\xc0\x50\xac\x09\xb2\xc7\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\x39\xe3\xae\xff\x78\x00\x00\x00")
+var __1528395675_add_index_event_logs_anonymous_user_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x78\x00\x87\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x43\x52\x45\x41\x54\x45\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x5f\x61\x6e\x6f\x6e\x79\x6d\x6f\x75\x73\x5f\x75\x73\x65\x72\x5f\x69\x64\x20\x4f\x4e\x20\x65\x76\x65\x6e\x74\x5f\x6c\x6f\x67\x73\x20\x55\x53\x49\x4e\x47\x20\x42\x54\x52\x45\x45\x20\x28\x61\x6e\x6f\x6e\x79\x6d\x6f\x75\x73\x5f\x75\x73\x65\x72\x5f\x69\x64\x29\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x39\xe3\xae\xff\x78\x00\x00\x00")
 
 func _1528395675_add_index_event_logs_anonymous_user_idUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1168,7 +1178,7 @@ func _1528395675_add_index_event_logs_anonymous_user_idUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395676_add_diff_stats_to_patchesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x48\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xc9\x4c\x4b\x8b\x2f\x2e\x49\x2c\x89\x4f\x4c\x49\x49\x4d\xb1\x26\x4b\x6b\x72\x46\x62\x5e\x3a\xb9\x9a\x53\x52\x73\x52\x4b\x40\x9a\xb9\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x01\x00\x00\xff\xff\xe7\x70\xbf\xe0\xc6\x00\x00\x00")
+var __1528395676_add_diff_stats_to_patchesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x49\xce\x48\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xc9\x4c\x4b\x8b\x2f\x2e\x49\x2c\x89\x4f\x4c\x49\x49\x4d\xb1\x26\x4b\x6b\x72\x46\x62\x5e\x3a\xb9\x9a\x53\x52\x73\x52\x4b\x40\x9a\xb9\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\xe7\x70\xbf\xe0\xc6\x00\x00\x00")
 
 func _1528395676_add_diff_stats_to_patchesDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1188,7 +1198,7 @@ func _1528395676_add_diff_stats_to_patchesDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395676_add_diff_stats_to_patchesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xcc\x4b\x0a\xc2\x30\x10\x06\xe0\x7d\x4e\xf1\xdf\x23\xab\xb4\x8d\x12\xc8\x03\xec\x08\xee\xca\xd0\x99\x3e\x40\x8a\xd8\xb9\x3f\x6e\x5d\x8b\x17\xf8\xba\x78\x4d\xd5\x3b\x17\x32\xc5\x1b\x28\x74\x39\xe2\xc5\x36\x6f\x7a\x22\x0c\x03\xfa\x96\xef\xa5\x22\x5d\x50\x1b\x21\x3e\xd2\x48\x23\x64\x5f\x96\xe9\x34\xb6\x89\x45\x54\xb0\x1f\xa6\xab\xbe\xfd\xaf\xca\xbc\xf1\xb1\xfe\xc1\x11\x7d\xaa\x7d\x3b\xae\x6f\xa5\x24\xf2\xee\x13\x00\x00\xff\xff\xb0\x25\x5e\x25\xe7\x00\x00\x00")
+var __1528395676_add_diff_stats_to_patchesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\xcc\x4b\x0a\xc2\x30\x10\x06\xe0\x7d\x4e\xf1\xdf\x23\xab\xb4\x8d\x12\xc8\x03\xec\x08\xee\xca\xd0\x99\x3e\x40\x8a\xd8\xb9\x3f\x6e\x5d\x8b\x17\xf8\xba\x78\x4d\xd5\x3b\x17\x32\xc5\x1b\x28\x74\x39\xe2\xc5\x36\x6f\x7a\x22\x0c\x03\xfa\x96\xef\xa5\x22\x5d\x50\x1b\x21\x3e\xd2\x48\x23\x64\x5f\x96\xe9\x34\xb6\x89\x45\x54\xb0\x1f\xa6\xab\xbe\xfd\xaf\xca\xbc\xf1\xb1\xfe\xc1\x11\x7d\xaa\x7d\x3b\xae\x6f\xa5\x24\xf2\xee\x33\x00\xb0\x25\x5e\x25\xe7\x00\x00\x00")
 
 func _1528395676_add_diff_stats_to_patchesUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1208,7 +1218,7 @@ func _1528395676_add_diff_stats_to_patchesUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395677_add_index_user_external_accounts_user_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2d\x4e\x2d\x8a\x4f\xad\x28\x49\x2d\xca\x4b\xcc\x89\x4f\x4c\x4e\xce\x2f\xcd\x2b\x29\x8e\x07\x0b\x67\xa6\x58\x73\x71\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\xa8\xc4\xe0\xc6\x46\x00\x00\x00")
+var __1528395677_add_index_user_external_accounts_user_idDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x46\x00\xb9\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x75\x73\x65\x72\x5f\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x61\x63\x63\x6f\x75\x6e\x74\x73\x5f\x75\x73\x65\x72\x5f\x69\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xa8\xc4\xe0\xc6\x46\x00\x00\x00")
 
 func _1528395677_add_index_user_external_accounts_user_idDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1228,7 +1238,7 @@ func _1528395677_add_index_user_external_accounts_user_idDownSql() (*asset, erro
 	return a, nil
 }
 
-var __1528395677_add_index_user_external_accounts_user_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\xce\xb1\x0a\x83\x30\x14\x85\xe1\x3d\x4f\x71\xc6\xf6\x19\x9c\x6a\x7b\x6b\x2f\xe8\x0d\x24\x91\xba\x85\x60\xee\x50\x10\x0b\x1a\xa1\x8f\x5f\x2a\x5d\x5d\x0f\xe7\x83\xbf\xa6\x86\xa5\x32\xe6\xea\xe8\x12\x08\x2c\x37\x1a\xc0\x77\x88\x0d\xa0\x81\x7d\xf0\xd8\x56\x5d\xa2\x7e\x8a\x2e\x73\x9a\x62\x1a\xc7\xf7\x36\x97\x35\xee\xf3\x2b\x1b\x00\xb0\x72\xf0\x42\xef\x59\x1a\xd4\xc1\x11\xe1\xf4\x27\xe7\xdd\x3c\x1f\xe4\x08\x59\x27\x2d\x9a\x63\x2a\x60\x0f\xe9\xdb\xf6\x17\x63\xbb\x8e\x43\x65\xbe\x01\x00\x00\xff\xff\x75\x86\x07\xcb\x9d\x00\x00\x00")
+var __1528395677_add_index_user_external_accounts_user_idUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\xcb\xb1\xaa\x83\x40\x10\x46\xe1\x7e\x9e\xe2\x2f\xef\x7d\x06\xab\x68\x26\x66\x40\x47\xd8\x5d\x89\xdd\xb2\xb8\x53\x04\xc4\x80\xae\x90\xc7\x0f\x48\xda\xb4\x87\xf3\xd5\xdc\x8a\x56\x44\x8d\xe3\x4b\x60\x88\x5e\x79\x82\xdc\xa0\x43\x00\x4f\xe2\x83\xc7\xb1\xdb\x16\xed\x5d\x6c\x5b\xd3\x12\xd3\x3c\xbf\x8e\xb5\xec\xf1\xcc\xcf\x4c\x00\x30\xe8\x8f\x0b\xa3\x17\x6d\x51\x07\xc7\x8c\xbf\x2f\xf9\x3f\xcd\xe3\xce\x8e\x91\x6d\xb1\x62\x39\xa6\x02\xf1\xd0\xb1\xeb\x2a\xa2\x66\xe8\x7b\x09\x15\x7d\x06\x00\x75\x86\x07\xcb\x9d\x00\x00\x00")
 
 func _1528395677_add_index_user_external_accounts_user_idUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1248,7 +1258,7 @@ func _1528395677_add_index_user_external_accounts_user_idUpSql() (*asset, error)
 	return a, nil
 }
 
-var __1528395678_lsif_auto_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xc8\x29\xce\x4c\x8b\xcf\xcc\x4b\x49\xad\x48\x2d\xb6\x86\x4a\x44\x06\x20\x8b\xc7\x17\x97\x24\x96\xa4\x5a\x63\xd7\x94\x98\x94\x93\x1a\x5f\x94\x5a\x90\x5f\x9c\x59\x92\x5f\x94\x09\x32\x83\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x10\x00\x00\xff\xff\xdf\x5e\x34\x6b\x6e\x00\x00\x00")
+var __1528395678_lsif_auto_indexDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6e\x00\x91\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6c\x73\x69\x66\x5f\x69\x6e\x64\x65\x78\x65\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x59\x50\x45\x20\x6c\x73\x69\x66\x5f\x69\x6e\x64\x65\x78\x5f\x73\x74\x61\x74\x65\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6c\x73\x69\x66\x5f\x69\x6e\x64\x65\x78\x61\x62\x6c\x65\x5f\x72\x65\x70\x6f\x73\x69\x74\x6f\x72\x69\x65\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xdf\x5e\x34\x6b\x6e\x00\x00\x00")
 
 func _1528395678_lsif_auto_indexDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1268,7 +1278,7 @@ func _1528395678_lsif_auto_indexDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395678_lsif_auto_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\xcf\x6f\xd3\x30\x14\xc7\xef\xf9\x2b\xde\x01\x29\xad\xc4\xa4\x1e\xb8\xd0\x9d\xd2\x62\x46\xb4\x34\x1d\x69\x72\xa8\x10\x58\xc6\x79\x5b\x9f\x48\xec\x60\xbf\xb0\x1f\x08\xfe\x76\x44\xdd\x96\xac\x15\xdb\x8e\xc9\xf7\xe3\x67\x7f\xbe\xf6\x4c\x5c\xa4\xf9\x79\x14\xcd\x0b\x91\x94\x02\xca\x64\x96\x09\x68\x3c\x5d\x4b\x32\x35\xde\xa9\xaf\x0d\x4a\x87\x9d\xf5\xc4\xd6\x11\x7a\x18\x45\x00\x00\x54\xc3\x4a\x14\x69\x92\xc1\x55\x91\x2e\x92\x62\x0d\x97\x62\xfd\x7a\x1b\x1d\xe8\x7b\x49\x35\x90\x61\xbc\x41\x07\xf9\xb2\x84\xbc\xca\x32\xa8\xf2\xf4\x63\x25\x02\xea\x51\x39\xbd\x91\xda\xf6\x86\x4f\xc9\x77\xe2\x7d\x52\x65\x25\x4c\x02\xdc\x39\xd4\xe4\xf1\x85\x74\xa3\x3c\x07\x05\x89\xe6\x7b\x8f\x3d\xd6\x52\x31\x30\xb5\xe8\x59\xb5\x1d\xdc\x12\x6f\xb6\x9f\xf0\x60\x0d\x46\xe3\x41\x07\xeb\xab\x61\x05\xd2\xb3\x62\x84\x64\x05\x22\xaf\x16\x3b\xff\x38\x8c\x8c\xc3\x66\x71\xe7\xac\x46\xef\xc9\xdc\xec\xff\x68\xdb\x76\x0d\xf2\x3f\x04\x9d\xb3\x0e\xeb\xf8\xd1\x4e\x47\x6d\x0f\xeb\x9d\xa5\x17\xff\x6b\x58\xdb\xb6\x25\x06\xc6\x3b\x3e\xf8\x87\xe4\x79\xd1\x43\x4f\xc6\xde\x8e\xc6\x47\xcb\x83\xe9\x89\xfa\x7e\xc9\x5e\x7a\x3a\x3d\x41\x1e\xcf\xb9\x56\xd4\xf4\x0e\xa5\xef\xdb\x56\xb9\xfb\xed\x49\x8f\x12\x56\xfa\x1b\x3b\xa5\x71\x10\x7a\x56\x8e\x9f\x3e\xfe\x6e\x0a\x19\xf2\x9b\x97\x90\x4f\x3f\xc6\xc0\xcc\x97\xf9\xaa\x2c\x92\x34\x2f\x83\x7a\xdf\x35\x56\xd5\x5e\x86\x9a\xe5\x0f\xd5\x50\x2d\xf5\x46\x39\x0f\xf3\x0f\x62\x7e\x09\xa3\xd1\xee\x06\x7e\x43\xfc\xe5\x93\x3a\x7b\x98\x9c\xbd\xfd\xfc\xf3\xcd\xe4\xd7\xab\x78\x3a\xfd\xab\x33\x1e\x87\x5b\x5e\x2e\x16\x69\x79\x1e\xfd\x09\x00\x00\xff\xff\xc6\x7d\x5b\x80\x64\x03\x00\x00")
+var __1528395678_lsif_auto_indexUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x92\xcf\x6e\xd3\x40\x10\x87\xef\x7e\x8a\x39\x20\x39\x96\xa8\x94\x03\x17\xd2\x93\x13\x96\x62\xd5\x71\x8a\x63\x1f\x22\x04\xab\x65\x3d\x6d\x46\xd8\xbb\x66\x77\x4c\xff\x20\x78\x76\x44\x36\x09\xa1\x51\xdb\x1c\xed\xf9\x66\x34\xdf\x6f\x76\x2a\x2e\xb2\xe2\x3c\x8a\x66\xa5\x48\x2b\x01\x55\x3a\xcd\x05\xb4\x9e\xae\x25\x99\x06\xef\xd4\xd7\x16\xa5\xc3\xde\x7a\x62\xeb\x08\x3d\x8c\x22\x00\x00\x6a\x60\x29\xca\x2c\xcd\xe1\xaa\xcc\xe6\x69\xb9\x82\x4b\xb1\x7a\xbd\x29\xed\xe9\x7b\x49\x0d\x90\x61\xbc\x41\x07\xc5\xa2\x82\xa2\xce\x73\xa8\x8b\xec\x63\x2d\x02\xea\x51\x39\xbd\x96\xda\x0e\x86\x8f\xc9\x77\xe2\x7d\x5a\xe7\x15\x8c\x03\xdc\x3b\xd4\xe4\xf1\x44\xba\x55\x9e\x83\x82\x44\xf3\x7d\xc0\x01\x1b\xa9\x18\x98\x3a\xf4\xac\xba\x1e\x6e\x89\xd7\x9b\x4f\x78\xb0\x06\xa3\xe4\x20\x83\xd5\xd5\x61\x04\xd2\xb3\x62\x84\x74\x09\xa2\xa8\xe7\x5b\xff\x38\x8c\x8c\xc3\x6a\x71\xef\xac\x46\xef\xc9\xdc\xec\xfe\x68\xdb\xf5\x2d\xf2\x3f\x04\x9d\xb3\x0e\x9b\x38\x4a\x9e\x4e\xfb\x30\xde\x69\x76\xf1\x54\xc2\xda\x76\x1d\x31\x30\xde\xf1\xde\x3f\x54\x5e\x16\xdd\xe7\x64\xec\xed\x28\x79\xd4\x1e\x4c\x8f\xd4\x77\x2d\x3b\xe9\xc9\xe4\x08\xf9\x7f\xce\xb5\xa2\x76\x70\x28\xfd\xd0\x75\xca\xdd\x6f\x36\x7d\x54\x61\xa5\xbf\xb1\x53\x1a\x0f\x8a\x9e\x95\xe3\xe7\xd7\xdf\x4e\x21\x43\x7e\x7d\x0a\xf9\xfc\x63\x0c\xcc\x6c\x51\x2c\xab\x32\xcd\x8a\x2a\x5c\x7d\xe8\x5b\xab\x1a\x2f\x43\xcc\xf2\x87\x6a\xa9\x91\x7a\xad\x9c\x87\xd9\x07\x31\xbb\x84\xd1\x68\x7b\x81\xdf\x10\x7f\xf9\xa4\xce\x1e\xc6\x67\x6f\x3f\xff\x7c\x33\xfe\xf5\x2a\x9e\x4c\xfe\xea\x24\x49\xb8\xf2\x62\x3e\xcf\xaa\xf3\xe8\xcf\x00\xc6\x7d\x5b\x80\x64\x03\x00\x00")
 
 func _1528395678_lsif_auto_indexUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1288,7 +1298,7 @@ func _1528395678_lsif_auto_indexUpSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395679_change_error_index_on_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x8e\x4f\x2d\x2a\xca\x2f\x8a\xcf\xcb\x2f\x89\xcf\x2b\xcd\xc9\xb1\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x85\x6a\xc3\xa6\x58\xc1\xdf\x0f\x4d\x5c\x41\x03\x2c\xa1\x09\xd2\xed\xef\xeb\xeb\x19\x62\xcd\xc5\x05\x08\x00\x00\xff\xff\x64\x09\xf8\xd1\x84\x00\x00\x00")
+var __1528395679_change_error_index_on_changeset_jobsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x8e\x4f\x2d\x2a\xca\x2f\x8a\xcf\xcb\x2f\x89\xcf\x2b\xcd\xc9\xb1\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x85\x6a\xc3\xa6\x58\xc1\xdf\x0f\xcd\x10\x05\x0d\xb0\x29\x9a\x20\xdd\xfe\xbe\xbe\x9e\x21\xd6\x5c\x5c\x80\x01\x00\x64\x09\xf8\xd1\x84\x00\x00\x00")
 
 func _1528395679_change_error_index_on_changeset_jobsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1308,7 +1318,7 @@ func _1528395679_change_error_index_on_changeset_jobsDownSql() (*asset, error) {
 	return a, nil
 }
 
-var __1528395679_change_error_index_on_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x8e\x4f\x2d\x2a\xca\x2f\xb2\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x85\xaa\xc6\xa6\x26\x3e\x2f\xbf\x24\x3e\xaf\x34\x27\x47\xc1\xdf\x0f\x4d\x81\x82\x86\x06\x58\x89\x82\x67\xb0\x82\x9f\x7f\x88\x82\x5f\xa8\x8f\x8f\xa6\x26\xc8\x50\x7f\x5f\x5f\xcf\x10\x6b\x2e\x2e\x40\x00\x00\x00\xff\xff\xb0\x36\x8b\x13\x92\x00\x00\x00")
+var __1528395679_change_error_index_on_changeset_jobsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\xce\x48\xcc\x4b\x4f\x2d\x4e\x2d\x89\xcf\xca\x4f\x2a\x8e\x4f\x2d\x2a\xca\x2f\xb2\xe6\xe2\x72\x0e\x72\x75\x0c\x71\x85\xaa\xc6\xa6\x26\x3e\x2f\xbf\x24\x3e\xaf\x34\x27\x47\xc1\xdf\x0f\xcd\x10\x05\x0d\x0d\xb0\x31\x0a\x9e\xc1\x0a\x7e\xfe\x21\x0a\x7e\xa1\x3e\x3e\x9a\x9a\x20\x43\xfd\x7d\x7d\x3d\x43\xac\xb9\xb8\x00\x03\x00\xb0\x36\x8b\x13\x92\x00\x00\x00")
 
 func _1528395679_change_error_index_on_changeset_jobsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -1328,6 +1338,206 @@ func _1528395679_change_error_index_on_changeset_jobsUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395680_org_repository_setsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3b\x00\xc4\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x6f\x72\x67\x5f\x72\x65\x70\x6f\x73\x69\x74\x6f\x72\x79\x5f\x73\x65\x74\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x23\xcd\x6f\xba\x3b\x00\x00\x00")
+
+func _1528395680_org_repository_setsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395680_org_repository_setsDownSql,
+		"1528395680_org_repository_sets.down.sql",
+	)
+}
+
+func _1528395680_org_repository_setsDownSql() (*asset, error) {
+	bytes, err := _1528395680_org_repository_setsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395680_org_repository_sets.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x72, 0xc0, 0xd1, 0x1f, 0xd1, 0x52, 0xbe, 0x6b, 0x6c, 0x4e, 0x90, 0x36, 0x27, 0x7b, 0x64, 0x4b, 0x74, 0x32, 0x8e, 0xee, 0xd, 0xf1, 0x2d, 0x68, 0x42, 0x7e, 0x66, 0x50, 0x59, 0x9a, 0x7, 0xc7}}
+	return a, nil
+}
+
+var __1528395680_org_repository_setsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x5f\x6f\xd3\x30\x14\xc5\xdf\xf3\x29\xee\x03\x52\x13\xb4\x22\x84\xb4\x07\x52\xa6\xc9\x4b\x6f\x99\xb5\xd4\x85\xc4\x15\x1b\x55\xb1\x4c\xed\xb5\x96\xf2\x4f\xb1\xc7\xc6\xf8\xf3\xd9\x51\x9c\x01\x41\xab\x80\x69\x8f\xf6\xfd\x9d\x73\x8f\xe5\x73\x82\xaf\x29\x9b\x04\x41\x92\x21\xe1\x08\x9c\x9c\xa4\x08\x74\x06\x6c\xc1\x01\xcf\x69\xce\x73\xa8\xdb\xad\x68\x75\x53\x5b\xe3\xea\xf6\xb3\xb0\xda\x59\x08\x03\x00\x00\xa3\x20\xc7\x8c\x92\x14\xde\x64\x74\x4e\xb2\x0b\x38\xc3\x8b\x03\x3f\xea\x44\x46\x81\xa9\x9c\xde\xea\xd6\xdb\xb1\x65\x9a\x42\x86\x33\xcc\x90\x25\xe8\x7d\x6d\x68\x54\x04\x0b\x06\x53\x4c\x91\x23\x24\x24\x4f\xc8\x14\x7b\x8b\x4a\x96\x1a\x36\xc6\xe9\x1b\xf7\x4b\xdf\x4f\x94\xb6\x9b\xd6\x34\xce\xd4\x15\xfc\x31\x86\x29\xce\xc8\x32\xe5\x30\x1a\xf5\xe4\x20\x77\x67\x67\x3d\xbe\x5a\xef\x11\x7c\xf9\x76\x27\xe9\x38\xd1\x48\xe7\x74\x5b\xfd\x0f\x6f\xac\x50\xfa\x52\x5e\x15\x0e\x3e\xd6\x75\xa1\x65\x75\x9f\xbe\x94\x85\xd5\x3d\xbe\x69\xb5\x74\x5a\x09\xe9\xc0\x99\x52\x5b\x27\xcb\x06\xae\x8d\xdb\xf9\x23\xdc\xd6\x95\xbe\xaf\xaf\xea\xeb\x30\xea\xf5\x57\x8d\x7a\x94\x3e\x59\xb0\x9c\x67\x84\x32\xbe\xef\x63\x85\x7f\xfd\x27\x59\x18\x25\x36\x3b\xd9\x5a\x48\x4e\x31\x39\x83\xb0\xbb\x87\xef\x30\xfa\xb0\x92\xe3\x5b\x32\x7e\xff\x7c\xfc\x72\x1d\x1e\xc7\x83\xd3\xd7\xd5\x58\x3c\x5b\x87\xc7\x47\x83\xbb\x28\x7a\xfa\x64\x14\xc7\xfd\x27\x3e\x20\x40\x29\x6f\x44\xa1\xab\xad\xdb\xfd\xdc\xdf\x85\xb9\xbb\xf2\x59\xe2\xd8\x5b\xc2\xab\x23\x78\x71\x78\x18\x05\xd1\xef\x0e\x2f\x19\x7d\xbb\x44\xa0\x6c\x8a\xe7\xff\xae\xb2\xe8\x42\x18\xe5\x1f\xde\x35\x71\x0f\x12\xf6\xc8\x01\x74\x4c\x34\x79\xdc\x9e\x41\x5d\xfe\xba\x2d\x82\x77\xa7\x98\xe1\xa0\x5e\x93\x20\x48\x16\xf3\x39\xe5\x93\xe0\xc7\x00\x87\xf2\xac\x02\xb6\x03\x00\x00")
+
+func _1528395680_org_repository_setsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395680_org_repository_setsUpSql,
+		"1528395680_org_repository_sets.up.sql",
+	)
+}
+
+func _1528395680_org_repository_setsUpSql() (*asset, error) {
+	bytes, err := _1528395680_org_repository_setsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395680_org_repository_sets.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x88, 0xe1, 0xe3, 0x15, 0xbc, 0x4, 0xd6, 0x46, 0x2f, 0xa2, 0x92, 0x7, 0x37, 0x8a, 0x38, 0x10, 0xae, 0x30, 0xc1, 0x7b, 0xe7, 0xf7, 0xfd, 0x2f, 0x1c, 0xce, 0x91, 0xd8, 0x28, 0xa3, 0x7c, 0x98}}
+	return a, nil
+}
+
+var __1528395681_version_contextsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x38\x00\xc7\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x76\x65\x72\x73\x69\x6f\x6e\x5f\x63\x6f\x6e\x74\x65\x78\x74\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xb8\x68\xb3\x86\x38\x00\x00\x00")
+
+func _1528395681_version_contextsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395681_version_contextsDownSql,
+		"1528395681_version_contexts.down.sql",
+	)
+}
+
+func _1528395681_version_contextsDownSql() (*asset, error) {
+	bytes, err := _1528395681_version_contextsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395681_version_contexts.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x95, 0xbd, 0x4c, 0xfa, 0x42, 0x64, 0x83, 0xf2, 0x52, 0x9d, 0x59, 0xc1, 0x7c, 0xd1, 0x20, 0x16, 0xa9, 0x1e, 0x48, 0x33, 0x63, 0x74, 0x75, 0x5a, 0x96, 0xfc, 0x26, 0x6e, 0x24, 0xed, 0x24, 0xb4}}
+	return a, nil
+}
+
+var __1528395681_version_contextsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x92\x41\x6b\xdc\x30\x10\x85\xef\xfe\x15\xef\xb6\x36\xf4\x54\xc8\xc5\x9b\x16\x14\x7b\xb6\x15\xf1\xca\xad\x2d\x43\x42\x29\xc6\xb5\x85\x57\xa5\x96\x8c\xac\x26\x69\x7f\x7d\xb1\xbc\xa1\xb4\x09\x34\xd0\xa3\xe6\xcd\xfb\xde\x30\x9a\x2b\x7a\xc7\xc5\x3e\x8a\xb2\x8a\x98\x24\x48\x76\x55\x10\xf8\x01\xa2\x94\xa0\x1b\x5e\xcb\x1a\x77\xca\x2d\xda\x9a\xb6\xb7\xc6\xab\x07\xbf\x20\x8e\x00\x40\x0f\xa8\xa9\xe2\xac\xc0\x87\x8a\x1f\x59\x75\x8b\x6b\xba\x7d\x15\x24\xd3\x4d\x0a\xbd\x5e\xbb\x03\x48\x34\x45\xb1\x29\x83\x5a\x7a\xa7\x67\xaf\xad\xc1\x1f\x32\x72\x3a\xb0\xa6\x90\xd8\xed\xb6\x4e\xeb\xc6\x56\x0f\xd0\xc6\xab\x51\x39\x54\x74\xa0\x8a\x44\x46\x35\xac\x1b\x97\x58\x0f\x09\x4a\x81\x9c\x0a\x92\x84\x8c\xd5\x19\xcb\x69\x73\x3a\x75\xa7\xd7\x81\x17\x7c\x5d\xac\xf9\xf2\x4c\xc4\xa7\xcf\xe7\x90\xde\xa9\xce\xab\xa1\xed\x3c\xbc\x9e\xd4\xe2\xbb\x69\xc6\xbd\xf6\xa7\xf0\xc4\x4f\x6b\xd4\x53\xbb\xb1\xf7\x71\xb2\xf9\xbf\xcf\xc3\x7f\xf9\xb3\x52\xd4\xb2\x62\x5c\xc8\x27\x5b\x6e\xd7\x25\xb6\xc6\xfa\x56\x4d\xb3\xff\x81\xec\x3d\x65\xd7\x88\xd7\x2a\x2e\xdf\x62\xb7\x4b\xd3\x6d\xc3\x2f\x45\x4d\xdd\x43\xfb\x4d\x99\xd1\x9f\x1e\x59\xfd\xa9\x73\xe7\x52\xe0\xa6\x69\xe0\xe1\xf2\x0d\x5e\x5f\x5c\x24\x51\xf2\xfb\x2e\x1a\xc1\x3f\x36\x04\x2e\x72\xba\xf9\xc7\x79\x84\xb4\xf5\x6f\xfe\x16\x42\x46\xb2\x7f\x44\xbe\x88\x75\xbe\x82\xe7\x68\x9b\x14\x66\x2c\x8f\x47\x2e\xf7\xd1\xaf\x01\x00\x60\x7c\x4e\x47\xcc\x02\x00\x00")
+
+func _1528395681_version_contextsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395681_version_contextsUpSql,
+		"1528395681_version_contexts.up.sql",
+	)
+}
+
+func _1528395681_version_contextsUpSql() (*asset, error) {
+	bytes, err := _1528395681_version_contextsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395681_version_contexts.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x97, 0x9c, 0xe5, 0x67, 0x86, 0xb0, 0xd1, 0x9e, 0x5a, 0x1f, 0x46, 0x4a, 0xf1, 0x7a, 0xf1, 0x33, 0xec, 0xc7, 0x29, 0x14, 0xba, 0x4d, 0x5d, 0xc7, 0xb5, 0xa7, 0xd6, 0x69, 0x33, 0xcb, 0x5d, 0x38}}
+	return a, nil
+}
+
+var __1528395682_insight_seriesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x62\x00\x9d\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x69\x6e\x73\x69\x67\x68\x74\x5f\x73\x65\x72\x69\x65\x73\x5f\x70\x6f\x69\x6e\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x69\x6e\x73\x69\x67\x68\x74\x5f\x73\x65\x72\x69\x65\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x4a\x11\x66\x6c\x62\x00\x00\x00")
+
+func _1528395682_insight_seriesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395682_insight_seriesDownSql,
+		"1528395682_insight_series.down.sql",
+	)
+}
+
+func _1528395682_insight_seriesDownSql() (*asset, error) {
+	bytes, err := _1528395682_insight_seriesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395682_insight_series.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x79, 0xa8, 0x75, 0x79, 0x16, 0x4e, 0xf3, 0x66, 0x60, 0x9b, 0x6, 0x8a, 0x57, 0x1a, 0x54, 0x74, 0x4d, 0x99, 0x5f, 0xe3, 0xb, 0xaf, 0x48, 0xb5, 0xbe, 0xb8, 0xad, 0x92, 0xc3, 0x56, 0x3e, 0xdd}}
+	return a, nil
+}
+
+var __1528395682_insight_seriesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x54\xdd\x6e\xf2\x38\x10\xbd\xe7\x29\x46\xbd\x01\x24\x40\xbd\xaf\xb4\x52\x0a\xa6\x1b\x95\x86\x2e\x04\xa9\xd5\x6a\x65\xb9\xc9\x40\x46\x4d\x6c\xd6\x76\xca\x76\x9f\x7e\x35\xf9\xa1\x05\x5a\xe8\xea\xbb\x89\x14\xfb\xcc\x99\x1f\x9f\x39\xb7\xe2\x2e\x8c\x6e\x3a\x9d\xf1\x42\x04\xb1\x80\x38\xb8\x9d\x09\x08\xa7\x10\xcd\x63\x10\x4f\xe1\x32\x5e\x02\x69\x47\x9b\xcc\x4b\x87\x96\xd0\x41\xaf\x03\x00\x40\x29\x2c\xc5\x22\x0c\x66\xf0\xb8\x08\x1f\x82\xc5\x33\xdc\x8b\xe7\x41\x75\xe5\xd4\x1b\xa6\xd2\xa1\xb2\x49\x26\x29\x05\xd2\x1e\x37\x68\x2b\xca\x68\x35\x9b\xc1\x42\x4c\xc5\x42\x44\x63\xb1\x3c\xc0\xa2\xeb\x51\xda\x87\x79\x04\x13\x31\x13\xb1\x80\x71\xb0\x1c\x07\x13\x51\xb3\x0e\x87\x10\x67\x08\x5b\x43\xda\x3b\x30\x6b\x50\xd0\x14\xa4\x2c\x42\x62\x8a\x6d\xe9\x31\x85\x1d\xf9\x0c\x3c\x03\xd1\x16\xe4\x1c\x19\x5d\xa1\xf9\xa8\x74\x68\x5b\xae\x5d\x66\x20\xb1\xa8\x38\x86\xfc\xa8\x3a\xae\xfe\x8d\x95\x8c\xbb\x54\x38\x63\xce\xd6\x6b\x71\x6b\x24\xa5\xae\x65\xf9\xf3\xaf\x3d\x4f\xdd\x10\x9f\xdb\x37\x95\xcb\x54\xbd\xbb\x93\x5c\xcd\x28\xbd\xb2\x5e\x7a\x2a\x10\xf8\xe3\xbc\x2a\xb6\x4d\x8b\x7c\xf6\xaf\xd1\x78\x14\xd1\xf4\x24\x95\xbf\x1c\x01\x13\x31\x0d\x56\xb3\x18\xb4\xd9\xf5\xfa\x75\xc6\x72\x9b\xfe\x52\xfc\x78\x1e\x2d\xe3\x45\x10\x46\xf1\x91\x6e\xe4\x41\xbf\x72\x6b\x1c\x79\x7a\x43\x18\xff\x2e\xc6\xf7\xd0\x3b\x9c\xc6\x6f\x70\xdd\xef\xf4\x3f\x64\x19\x46\x13\xf1\x74\x56\x96\xf2\x58\x75\xf3\xe8\x08\xd1\x3b\x42\x30\xfd\x79\x51\xa9\xdc\x99\x4a\x4b\x7f\x97\x58\x62\xab\xa2\x17\x95\xbc\x6e\xac\x29\x75\x0a\x3b\x63\x5f\xd1\x82\xcf\x94\xef\x0c\x87\xad\x08\x1d\xc7\x14\x23\x78\xac\x59\x99\x09\x75\x45\xc1\x92\xe2\x4b\xe8\xd6\xbf\x5d\x70\x5e\x79\x04\xa5\x53\x50\x16\x99\xa3\xcb\x24\x39\x7a\xbe\x34\x3a\x41\x86\x93\x85\x42\xf9\x24\x83\xc4\x94\xda\x03\x39\x70\xde\x58\x4c\x47\x30\x55\x94\x63\xda\xd6\xcf\x99\x6a\x62\x66\x52\x1b\x45\xba\x7e\x7a\x05\x5b\x6b\x12\x74\x4e\xaa\xb5\xe7\x82\xa9\xc0\x01\x94\xda\x53\xce\xfc\xef\x9c\x1c\xba\x68\x2d\xb3\x76\x47\x3f\x37\x03\xd9\x64\xde\x7b\xc2\x6d\x78\xf7\xad\x2d\x34\x32\x38\xbf\x57\x47\x6f\x76\x79\xc1\xce\xb2\xf1\x12\x9e\x5b\xd2\x2b\xd6\xf8\xd5\x65\xa5\xef\xb7\xd1\x23\x78\xfc\xc7\x9f\xae\x40\xfb\xa2\xcd\x16\x9a\xa2\x20\xcf\x32\x64\x74\x7d\x56\x3d\xa1\x6c\x9e\xb0\xae\xb9\xbe\xc8\x89\xb1\x19\x79\x78\x31\x26\x47\xa5\x4f\xd9\xd7\x2a\x77\x58\xa3\x75\x59\xc8\xb5\xa2\xbc\xb4\x78\xea\x1a\xfb\x80\xeb\x1a\xdc\x00\x65\x81\xce\xa9\x0d\x7e\xaa\xe6\x54\x10\x5f\x0d\x60\xdf\xb7\xbd\xe0\x09\x4d\x3a\xd2\xe4\xb2\x9f\x20\xbf\xb7\x89\x5a\x51\xb2\x9a\xb5\x4c\x32\x4c\x5e\x5b\x8b\xa8\x8e\x20\x8c\xa0\xb7\x1f\x36\x74\x9b\x3e\x48\x6f\xba\x83\xcf\xcb\x33\xf8\xd0\x73\xff\xc0\x4a\x56\x51\xf8\xc7\xea\x47\x8e\xd2\x56\xd2\xea\x56\x36\x8a\xab\xfd\x78\x1e\x7d\x5d\x79\x6f\x8f\x1f\xb4\x12\x1d\x34\x3a\xeb\xdf\xfc\x0f\x3f\xfb\x3c\x87\x33\xc9\xf8\xba\xea\x6e\xfe\xf0\x10\xc6\x37\x9d\xff\x06\x00\xbd\x7a\xd2\x3a\xd0\x07\x00\x00")
+
+func _1528395682_insight_seriesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395682_insight_seriesUpSql,
+		"1528395682_insight_series.up.sql",
+	)
+}
+
+func _1528395682_insight_seriesUpSql() (*asset, error) {
+	bytes, err := _1528395682_insight_seriesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395682_insight_series.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xed, 0x3b, 0x20, 0x0, 0xec, 0x47, 0xd3, 0x2e, 0xb3, 0x2c, 0xa4, 0x55, 0x80, 0x3c, 0x5, 0xf2, 0xbd, 0x69, 0x60, 0x56, 0x72, 0x6a, 0x97, 0x68, 0xc1, 0xf0, 0x8, 0x2e, 0x5b, 0x72, 0x1f, 0x64}}
+	return a, nil
+}
+
+var __1528395683_global_symbolsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x61\x00\x9e\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x67\x6c\x6f\x62\x61\x6c\x5f\x73\x79\x6d\x62\x6f\x6c\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x67\x6c\x6f\x62\x61\x6c\x5f\x73\x79\x6d\x62\x6f\x6c\x73\x5f\x72\x65\x70\x6f\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xbd\x32\x03\xeb\x61\x00\x00\x00")
+
+func _1528395683_global_symbolsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395683_global_symbolsDownSql,
+		"1528395683_global_symbols.down.sql",
+	)
+}
+
+func _1528395683_global_symbolsDownSql() (*asset, error) {
+	bytes, err := _1528395683_global_symbolsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395683_global_symbols.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x21, 0xa3, 0xef, 0xe5, 0x39, 0xba, 0xb4, 0x60, 0xba, 0x8c, 0xbb, 0x44, 0xce, 0x33, 0xce, 0x8c, 0x51, 0xea, 0x46, 0xb1, 0x5c, 0x80, 0xb6, 0x5a, 0x27, 0x5d, 0x3f, 0x13, 0x6a, 0xac, 0x4d, 0x80}}
+	return a, nil
+}
+
+var __1528395683_global_symbolsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x92\xc1\x8e\xda\x30\x10\x86\xef\x79\x8a\xb9\x35\x48\xec\xbe\x00\xa7\x00\x06\x45\x0d\xa1\x4a\x82\xb4\x7b\x8a\x4c\x3c\xc4\x56\x13\x3b\xb5\x87\xb2\xf4\xe9\xab\x98\x94\xd2\x25\xa8\xbb\x47\xcf\x3f\xf3\xcd\x3f\x7f\x32\x67\xeb\x38\x9d\x05\xc1\xd3\x13\x14\x12\xa1\x6e\xcc\x9e\x37\xe0\xce\xed\xde\x34\xa0\xb4\xc0\x37\x90\xdc\x01\x49\x1c\x8a\x0e\xcc\xc1\x3f\x05\x1e\xf8\xb1\x21\xd8\x5b\xae\x2b\x09\xe6\xd0\x33\x2c\x76\xc6\x29\x32\x56\xa1\x9b\x82\x33\x40\x92\xd3\x1f\x9c\x43\x6e\x2b\x89\x0e\x84\xd1\x5f\x08\x34\xa2\x00\x32\xf0\xe3\x88\xf6\x7c\xbb\xa1\x07\x39\xb4\x3f\x55\x85\xfd\x32\xe4\x95\xfc\x0b\x3e\x3f\x0f\x26\xcb\xa1\xbb\xf4\x12\x58\xac\x8c\x15\x17\xa7\x95\x69\x5b\x45\xc0\xa9\x27\x9d\xa4\xaa\xe4\xfb\x03\x3c\xd3\x9f\x87\xe2\x86\x0d\x27\xb4\x7e\xbc\x3b\x12\x8a\xe7\x60\x91\xb1\xa8\x60\x50\x44\xf3\x84\x41\xbc\x82\x74\x5b\x00\x7b\x89\xf3\x22\x1f\x37\x11\x06\x00\xe0\x79\xa5\x12\xa0\x34\x61\x8d\x16\xbe\x65\xf1\x26\xca\x5e\xe1\x2b\x7b\x85\x8c\xad\x58\xc6\xd2\x05\xcb\x7d\x5b\xa8\xc4\x04\xb6\x29\x2c\x59\xc2\x0a\x06\x8b\x28\x5f\x44\x4b\x36\xf5\x98\xcb\x15\x3d\x88\xf0\x8d\xfc\xee\x74\x97\x24\x17\xb1\x51\xad\xa2\x52\x2a\x82\xbd\x31\x0d\x72\x7d\xd5\x61\xc9\x56\xd1\x2e\x29\xe0\xc0\x1b\x87\x97\xee\xe1\xd2\x92\x13\x90\x6a\xd1\x11\x6f\x3b\x38\x29\x92\xfe\x09\xbf\x8c\xc6\xfb\x79\x6d\x4e\xe1\x24\x98\xcc\x82\x8f\xc7\x30\x04\xa0\x04\xcc\xe3\x75\xce\xb2\x38\x4a\x6e\xaf\x9f\x8e\xc6\x73\x5d\x7c\x93\xcd\x58\xba\xe1\x30\xf8\x30\x30\xcd\x5b\x1c\xcb\xea\xbb\xd2\xa3\x19\x76\x9c\xe4\x58\xbd\x51\x1a\xef\xdc\x0d\x12\xd7\xf5\x91\xd7\xa3\x6b\x3a\x6e\x51\xd3\x63\xa5\x7c\xe4\xc3\xa9\x5a\x73\x3a\xda\x07\x54\x22\xb4\xfa\x5f\xe9\xf6\xab\xc4\xe9\x92\xbd\xfc\xff\xe7\xec\x13\xdf\xa6\xef\x94\x6b\xa4\xb3\x4f\xd0\x7c\xcc\xf7\xa8\xbe\xfc\x69\x4e\x49\xb6\x6e\xef\x7d\xc1\x2e\x8f\xd3\x35\xd4\x4a\x43\xd8\x98\x13\xda\xb0\x6f\x9e\xf4\x05\x3f\x51\x9a\xce\xf9\x08\xb6\x9b\x4d\x5c\xcc\x82\xdf\x03\x00\x20\xe4\x68\xb1\xc6\x04\x00\x00")
+
+func _1528395683_global_symbolsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395683_global_symbolsUpSql,
+		"1528395683_global_symbols.up.sql",
+	)
+}
+
+func _1528395683_global_symbolsUpSql() (*asset, error) {
+	bytes, err := _1528395683_global_symbolsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395683_global_symbols.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xca, 0xf9, 0x37, 0x5, 0x48, 0x50, 0x17, 0xb0, 0x8c, 0x35, 0x74, 0x2, 0x77, 0xc3, 0x44, 0x8e, 0x1f, 0x5, 0xb, 0x44, 0xc5, 0xe, 0xee, 0x18, 0x5, 0xdf, 0x25, 0x25, 0xc9, 0x25, 0x4, 0x1b}}
+	return a, nil
+}
+
+var __1528395684_search_historyDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x36\x00\xc9\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x65\x61\x72\x63\x68\x5f\x68\x69\x73\x74\x6f\x72\x79\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xea\x94\xc8\x31\x36\x00\x00\x00")
+
+func _1528395684_search_historyDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395684_search_historyDownSql,
+		"1528395684_search_history.down.sql",
+	)
+}
+
+func _1528395684_search_historyDownSql() (*asset, error) {
+	bytes, err := _1528395684_search_historyDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395684_search_history.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x7b, 0xe2, 0x7b, 0x2c, 0x41, 0x63, 0x83, 0x5a, 0xef, 0x4, 0xa3, 0x4d, 0xac, 0xb0, 0xb5, 0xfc, 0x68, 0x11, 0xd8, 0xe6, 0xa, 0xd, 0x78, 0xfd, 0xad, 0x28, 0x35, 0x5a, 0x66, 0x97, 0x70, 0xc5}}
+	return a, nil
+}
+
+var __1528395684_search_historyUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x90\x4f\x8f\xda\x30\x10\xc5\xef\xf9\x14\xef\x08\x12\xbb\x5f\x80\x53\x48\xcc\x2a\x6a\x08\x55\x92\x95\x76\x4f\x91\x89\x67\x89\x5b\xb0\xe9\x78\x52\x9a\x7e\xfa\x2a\x81\xd2\x55\xff\x20\xf5\x62\xc9\x7e\xbf\x79\x6f\xfc\x56\xea\x29\x2b\x96\x51\xf4\xf0\x80\xba\x23\x04\xd2\xdc\x76\x14\xc0\xbd\xc3\x6e\x40\x1f\x88\xc3\x02\xe7\xce\xb6\x1d\x34\x13\x0e\x36\x08\x19\xe8\x00\xe9\xc8\x32\x98\x5a\x72\x72\x9b\x7b\x44\xe9\xcf\x61\x74\xf3\x07\x43\x0c\xe9\xb4\x1b\x49\x30\x09\x39\xb1\xde\xe1\x44\x6c\xbd\x81\x7f\x9b\xde\x83\x15\x42\xeb\xdd\x9b\xdd\xf7\xac\x27\x60\x8c\x31\x74\xa0\x31\x67\x37\x40\x8f\x6e\x3b\xdd\x7e\xde\xb3\xef\x9d\xc1\x27\xbf\x7b\x8c\x92\x52\xc5\xb5\x42\x1d\xaf\x72\x85\x6c\x8d\x62\x5b\x43\xbd\x64\x55\x5d\x5d\x57\x69\x3a\x1b\xc4\xf3\x80\x59\x04\x00\xd6\x60\x95\x3d\x55\xaa\xcc\xe2\x1c\x1f\xcb\x6c\x13\x97\xaf\xf8\xa0\x5e\x17\x93\x3a\xfe\xb2\xb1\x06\xd6\x09\xed\x89\x27\xb7\xe2\x39\xcf\x51\xaa\xb5\x2a\x55\x91\xa8\x6a\x62\xc2\xcc\x9a\x39\xb6\x05\x52\x95\xab\x5a\x21\x89\xab\x24\x4e\xd5\xc5\xe4\x4b\x4f\x3c\x40\xe8\x9b\xdc\xe6\x2f\xc2\x49\x8b\x10\xbb\x46\x86\x13\xfd\x4d\xff\x4a\x1c\xac\x77\x4d\xeb\xdd\xa4\x8e\xc7\x45\x61\x0a\xfd\x41\x9a\xd6\xf7\x4e\xfe\x58\xee\x82\x98\x6b\x6b\xcd\x31\xfc\x83\x68\x99\xb4\x90\x69\xb4\x40\xec\x91\x82\xe8\xe3\x09\x67\x2b\xdd\x74\xc5\x77\xef\xe8\x36\x81\x54\xad\xe3\xe7\xbc\x86\xf3\xe7\xd9\x3c\x9a\x2f\xa3\x9f\x4d\x67\x45\xaa\x5e\xee\x36\xdd\x5c\x5b\x6c\xde\x05\x6e\x8b\xdf\xa0\xd9\x15\x5a\xe0\x1d\x95\xaa\x2a\x99\x2f\xff\x23\xe9\x6e\xc2\x2f\x71\x5a\x7f\xbb\xd9\x64\xf5\x32\xfa\x31\x00\x36\x75\x74\x39\xe8\x02\x00\x00")
+
+func _1528395684_search_historyUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395684_search_historyUpSql,
+		"1528395684_search_history.up.sql",
+	)
+}
+
+func _1528395684_search_historyUpSql() (*asset, error) {
+	bytes, err := _1528395684_search_historyUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395684_search_history.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x47, 0xe9, 0x82, 0xa8, 0xb0, 0xc4, 0xd7, 0xa8, 0xff, 0xf4, 0xdd, 0xf2, 0x2b, 0xf8, 0x10, 0x5b, 0x1e, 0x4d, 0x92, 0x1a, 0x5, 0xff, 0x15, 0x28, 0xb, 0xf8, 0x5a, 0xa0, 0xe7, 0xa9, 0xad, 0xdd}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -1479,17 +1689,29 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395678_lsif_auto_index.up.sql":                                       _1528395678_lsif_auto_indexUpSql,
 	"1528395679_change_error_index_on_changeset_jobs.down.sql":                _1528395679_change_error_index_on_changeset_jobsDownSql,
 	"1528395679_change_error_index_on_changeset_jobs.up.sql":                  _1528395679_change_error_index_on_changeset_jobsUpSql,
+	"1528395680_org_repository_sets.down.sql":                                 _1528395680_org_repository_setsDownSql,
+	"1528395680_org_repository_sets.up.sql":                                   _1528395680_org_repository_setsUpSql,
+	"1528395681_version_contexts.down.sql":                                    _1528395681_version_contextsDownSql,
+	"1528395681_version_contexts.up.sql":                                      _1528395681_version_contextsUpSql,
+	"1528395682_insight_series.down.sql":                                      _1528395682_insight_seriesDownSql,
+	"1528395682_insight_series.up.sql":                                        _1528395682_insight_seriesUpSql,
+	"1528395683_global_symbols.down.sql":                                      _1528395683_global_symbolsDownSql,
+	"1528395683_global_symbols.up.sql":                                        _1528395683_global_symbolsUpSql,
+	"1528395684_search_history.down.sql":                                      _1528395684_search_historyDownSql,
+	"1528395684_search_history.up.sql":                                        _1528395684_search_historyUpSql,
 }
 
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"},
 // AssetDir("data/img") would return []string{"a.png", "b.png"},
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
@@ -1582,6 +1804,16 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395678_lsif_auto_index.up.sql":                                       {_1528395678_lsif_auto_indexUpSql, map[string]*bintree{}},
 	"1528395679_change_error_index_on_changeset_jobs.down.sql":                {_1528395679_change_error_index_on_changeset_jobsDownSql, map[string]*bintree{}},
 	"1528395679_change_error_index_on_changeset_jobs.up.sql":                  {_1528395679_change_error_index_on_changeset_jobsUpSql, map[string]*bintree{}},
+	"1528395680_org_repository_sets.down.sql":                                 {_1528395680_org_repository_setsDownSql, map[string]*bintree{}},
+	"1528395680_org_repository_sets.up.sql":                                   {_1528395680_org_repository_setsUpSql, map[string]*bintree{}},
+	"1528395681_version_contexts.down.sql":                                    {_1528395681_version_contextsDownSql, map[string]*bintree{}},
+	"1528395681_version_contexts.up.sql":                                      {_1528395681_version_contextsUpSql, map[string]*bintree{}},
+	"1528395682_insight_series.down.sql":                                      {_1528395682_insight_seriesDownSql, map[string]*bintree{}},
+	"1528395682_insight_series.up.sql":                                        {_1528395682_insight_seriesUpSql, map[string]*bintree{}},
+	"1528395683_global_symbols.down.sql":                                      {_1528395683_global_symbolsDownSql, map[string]*bintree{}},
+	"1528395683_global_symbols.up.sql":                                        {_1528395683_global_symbolsUpSql, map[string]*bintree{}},
+	"1528395684_search_history.down.sql":                                      {_1528395684_search_historyDownSql, map[string]*bintree{}},
+	"1528395684_search_history.up.sql":                                        {_1528395684_search_historyUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
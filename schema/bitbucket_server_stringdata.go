@@ -81,6 +81,72 @@ const BitbucketServerSchemaJSON = `{
       "default": "http",
       "examples": ["ssh"]
     },
+    "gitLFS": {
+      "description": "Fetch the Git LFS files of repositories on this Bitbucket Server instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the \"http\" gitURLType.",
+      "title": "BitbucketServerGitLFS",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["enabled"],
+      "properties": {
+        "enabled": {
+          "description": "Whether to fetch Git LFS files.",
+          "type": "boolean",
+          "default": false
+        },
+        "maxFileSize": {
+          "description": "The maximum size in bytes of a Git LFS file to fetch. Larger files are shown as Git LFS pointer files.",
+          "type": "integer",
+          "minimum": 0,
+          "default": 104857600
+        }
+      }
+    },
+    "gitFetch": {
+      "description": "Additional Git refs to fetch for repositories on this Bitbucket Server instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.",
+      "title": "BitbucketServerGitFetch",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "refspecs": {
+          "description": "Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "pattern": "^\\+?refs/[^: ]+:refs/[^: ]+$"
+          },
+          "examples": [["+refs/notes/*:refs/notes/*"], ["+refs/review/*:refs/review/*"]]
+        },
+        "remotes": {
+          "description": "Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.",
+          "type": "array",
+          "items": {
+            "title": "BitbucketServerGitRemote",
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["repository", "name", "url"],
+            "properties": {
+              "repository": {
+                "description": "The name of the repository on Sourcegraph into which the remote's branches are fetched.",
+                "type": "string",
+                "examples": ["github.com/sourcegraph/sourcegraph"]
+              },
+              "name": {
+                "description": "The name of the remote. Its branches are fetched into refs/remotes/<name>/.",
+                "type": "string",
+                "pattern": "^[A-Za-z0-9_][A-Za-z0-9_.-]*$",
+                "not": { "const": "origin" },
+                "examples": ["alice"]
+              },
+              "url": {
+                "description": "The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.",
+                "type": "string",
+                "examples": ["https://github.com/alice/sourcegraph.git"]
+              }
+            }
+          }
+        }
+      }
+    },
     "certificate": {
       "description": "TLS certificate of the Bitbucket Server instance. This is only necessary if the certificate is self-signed or signed by an internal CA. To get the certificate run ` + "`" + `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM` + "`" + `. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.",
       "type": "string",
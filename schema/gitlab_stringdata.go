@@ -58,6 +58,72 @@ const GitLabSchemaJSON = `{
       "enum": ["http", "ssh"],
       "default": "http"
     },
+    "gitLFS": {
+      "description": "Fetch the Git LFS files of repositories on this GitLab instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the \"http\" gitURLType.",
+      "title": "GitLabGitLFS",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["enabled"],
+      "properties": {
+        "enabled": {
+          "description": "Whether to fetch Git LFS files.",
+          "type": "boolean",
+          "default": false
+        },
+        "maxFileSize": {
+          "description": "The maximum size in bytes of a Git LFS file to fetch. Larger files are shown as Git LFS pointer files.",
+          "type": "integer",
+          "minimum": 0,
+          "default": 104857600
+        }
+      }
+    },
+    "gitFetch": {
+      "description": "Additional Git refs to fetch for repositories on this GitLab instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.",
+      "title": "GitLabGitFetch",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "refspecs": {
+          "description": "Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "pattern": "^\\+?refs/[^: ]+:refs/[^: ]+$"
+          },
+          "examples": [["+refs/notes/*:refs/notes/*"], ["+refs/review/*:refs/review/*"]]
+        },
+        "remotes": {
+          "description": "Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.",
+          "type": "array",
+          "items": {
+            "title": "GitLabGitRemote",
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["repository", "name", "url"],
+            "properties": {
+              "repository": {
+                "description": "The name of the repository on Sourcegraph into which the remote's branches are fetched.",
+                "type": "string",
+                "examples": ["github.com/sourcegraph/sourcegraph"]
+              },
+              "name": {
+                "description": "The name of the remote. Its branches are fetched into refs/remotes/<name>/.",
+                "type": "string",
+                "pattern": "^[A-Za-z0-9_][A-Za-z0-9_.-]*$",
+                "not": { "const": "origin" },
+                "examples": ["alice"]
+              },
+              "url": {
+                "description": "The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.",
+                "type": "string",
+                "examples": ["https://github.com/alice/sourcegraph.git"]
+              }
+            }
+          }
+        }
+      }
+    },
     "certificate": {
       "description": "TLS certificate of the GitLab instance. This is only necessary if the certificate is self-signed or signed by an internal CA. To get the certificate run ` + "`" + `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM` + "`" + `. To escape the value into a JSON string, you may want to use a tool like https://json-escape-text.now.sh.",
       "type": "string",
@@ -126,6 +192,24 @@ const GitLabSchemaJSON = `{
       "minItems": 1,
       "examples": [["?membership=true&search=foo", "groups/mygroup/projects"]]
     },
+    "webhooks": {
+      "description": "An array of configurations defining existing GitLab webhooks that notify Sourcegraph of pushes, so that repositories are updated immediately. The webhooks must send \"Push events\" (and optionally \"Tag push events\") to https://sourcegraph.example.com/.api/push-webhooks/gitlab.",
+      "type": "array",
+      "items": {
+        "type": "object",
+        "title": "GitLabWebhook",
+        "additionalProperties": false,
+        "required": ["secret"],
+        "properties": {
+          "secret": {
+            "description": "The secret token used when creating the webhook",
+            "type": "string",
+            "minLength": 1
+          }
+        }
+      },
+      "examples": [[{ "secret": "webhook-secret" }]]
+    },
     "repositoryPathPattern": {
       "description": "The pattern used to generate a the corresponding Sourcegraph repository name for a GitLab project. In the pattern, the variable \"{host}\" is replaced with the GitLab URL's host (such as gitlab.example.com), and \"{pathWithNamespace}\" is replaced with the GitLab project's \"namespace/path\" (such as \"myteam/myproject\").\n\nFor example, if your GitLab is https://gitlab.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of \"{host}/{pathWithNamespace}\" would mean that a GitLab project at https://gitlab.example.com/myteam/myproject is available on Sourcegraph at https://src.example.com/gitlab.example.com/myteam/myproject.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
       "type": "string",
@@ -181,6 +181,10 @@ type BitbucketServerConnection struct {
 	Exclude []*ExcludedBitbucketServerRepo `json:"exclude,omitempty"`
 	// ExcludePersonalRepositories description: Whether or not personal repositories should be excluded or not. When true, Sourcegraph will ignore personal repositories it may have access to. See https://docs.sourcegraph.com/integration/bitbucket_server#excluding-personal-repositories for more information.
 	ExcludePersonalRepositories bool `json:"excludePersonalRepositories,omitempty"`
+	// GitFetch description: Additional Git refs to fetch for repositories on this Bitbucket Server instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+	GitFetch *BitbucketServerGitFetch `json:"gitFetch,omitempty"`
+	// GitLFS description: Fetch the Git LFS files of repositories on this Bitbucket Server instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+	GitLFS *BitbucketServerGitLFS `json:"gitLFS,omitempty"`
 	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Bitbucket Server instance.
 	//
 	// If "http", Sourcegraph will access Bitbucket Server repositories using Git URLs of the form http(s)://bitbucket.example.com/scm/myproject/myrepo.git (using https: if the Bitbucket Server instance uses HTTPS).
@@ -225,6 +229,30 @@ type BitbucketServerConnection struct {
 	Webhooks *Webhooks `json:"webhooks,omitempty"`
 }
 
+// BitbucketServerGitFetch description: Additional Git refs to fetch for repositories on this Bitbucket Server instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+type BitbucketServerGitFetch struct {
+	// Refspecs description: Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).
+	Refspecs []string `json:"refspecs,omitempty"`
+	// Remotes description: Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.
+	Remotes []*BitbucketServerGitRemote `json:"remotes,omitempty"`
+}
+
+// BitbucketServerGitLFS description: Fetch the Git LFS files of repositories on this Bitbucket Server instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+type BitbucketServerGitLFS struct {
+	// Enabled description: Whether to fetch Git LFS files.
+	Enabled bool `json:"enabled"`
+	// MaxFileSize description: The maximum size in bytes of a Git LFS file to fetch. Larger files are shown as Git LFS pointer files.
+	MaxFileSize int `json:"maxFileSize,omitempty"`
+}
+type BitbucketServerGitRemote struct {
+	// Name description: The name of the remote. Its branches are fetched into refs/remotes/<name>/.
+	Name string `json:"name"`
+	// Repository description: The name of the repository on Sourcegraph into which the remote's branches are fetched.
+	Repository string `json:"repository"`
+	// Url description: The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.
+	Url string `json:"url"`
+}
+
 // BitbucketServerIdentityProvider description: The source of identity to use when computing permissions. This defines how to compute the Bitbucket Server identity to use for a given Sourcegraph user. When 'username' is used, Sourcegraph assumes usernames are identical in Sourcegraph and Bitbucket Server accounts and `auth.enableUsernameChanges` must be set to false for security reasons.
 type BitbucketServerIdentityProvider struct {
 	Username *BitbucketServerUsernameIdentity
@@ -302,13 +330,29 @@ type Branding struct {
 	Light   *BrandAssets `json:"light,omitempty"`
 }
 
+// BuiltinAuthLockout description: Brute-force protection for password sign-in and password reset. Accounts and client IP addresses with too many failed attempts are temporarily locked out. A site admin can unlock an account before the lockout expires.
+type BuiltinAuthLockout struct {
+	// ConsecutivePeriod description: The period (in seconds) over which failed attempts are counted. The count is reset when no failed attempt happens within this period.
+	ConsecutivePeriod int `json:"consecutivePeriod,omitempty"`
+	// FailedAttemptThreshold description: The number of consecutive failed sign-in attempts for a single account after which the account is locked out.
+	FailedAttemptThreshold int `json:"failedAttemptThreshold,omitempty"`
+	// IpFailedAttemptThreshold description: The number of failed sign-in and password reset attempts from a single client IP address after which further attempts from that address are rejected.
+	IpFailedAttemptThreshold int `json:"ipFailedAttemptThreshold,omitempty"`
+	// LockoutPeriod description: The period (in seconds) for which an account or client IP address stays locked out.
+	LockoutPeriod int `json:"lockoutPeriod,omitempty"`
+	// TrustedProxies description: The IP addresses or CIDR ranges of the reverse proxies and load balancers in front of Sourcegraph. The client IP address of a request is taken from the X-Forwarded-For header only if the request comes from one of these proxies; otherwise the address of the connection is used.
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+}
+
 // BuiltinAuthProvider description: Configures the builtin username-password authentication provider.
 type BuiltinAuthProvider struct {
 	// AllowSignup description: Allows new visitors to sign up for accounts. The sign-up page will be enabled and accessible to all visitors.
 	//
 	// SECURITY: If the site has no users (i.e., during initial setup), it will always allow the first user to sign up and become site admin **without any approval** (first user to sign up becomes the admin).
-	AllowSignup bool   `json:"allowSignup,omitempty"`
-	Type        string `json:"type"`
+	AllowSignup bool `json:"allowSignup,omitempty"`
+	// Lockout description: Brute-force protection for password sign-in and password reset. Accounts and client IP addresses with too many failed attempts are temporarily locked out. A site admin can unlock an account before the lockout expires.
+	Lockout *BuiltinAuthLockout `json:"lockout,omitempty"`
+	Type    string              `json:"type"`
 }
 
 // CloneURLToRepositoryName description: Describes a mapping from clone URL to repository name. The `from` field contains a regular expression with named capturing groups. The `to` field contains a template string that references capturing group names. For instance, if `from` is "^../(?P<name>\w+)$" and `to` is "github.com/user/{name}", the clone URL "../myRepository" would be mapped to the repository name "github.com/user/myRepository".
@@ -354,6 +398,12 @@ type ExcludedBitbucketServerRepo struct {
 	// Pattern description: Regular expression which matches against the name of a Bitbucket Server repo.
 	Pattern string `json:"pattern,omitempty"`
 }
+type ExcludedGerritProject struct {
+	// Name description: The name of a Gerrit project to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gerrit project.
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitHubRepo struct {
 	// Archived description: If set to true, archived repositories will be excluded.
 	Archived bool `json:"archived,omitempty"`
@@ -372,6 +422,18 @@ type ExcludedGitLabProject struct {
 	// Name description: The name of a GitLab project ("group/name") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
 }
+type ExcludedGiteaRepo struct {
+	// Archived description: If set to true, archived repositories will be excluded.
+	Archived bool `json:"archived,omitempty"`
+	// Forks description: If set to true, forks will be excluded.
+	Forks bool `json:"forks,omitempty"`
+	// Id description: The ID of a Gitea repository (as returned by the Gitea API) to exclude from mirroring. Use this to exclude the repository, even if renamed.
+	Id int `json:"id,omitempty"`
+	// Name description: The name of a Gitea repository ("owner/name") to exclude from mirroring.
+	Name string `json:"name,omitempty"`
+	// Pattern description: Regular expression which matches against the name of a Gitea repository ("owner/name").
+	Pattern string `json:"pattern,omitempty"`
+}
 type ExcludedGitoliteRepo struct {
 	// Name description: The name of a Gitolite repo ("my-repo") to exclude from mirroring.
 	Name string `json:"name,omitempty"`
@@ -391,6 +453,8 @@ type ExperimentalFeatures struct {
 	DebugLog *DebugLog `json:"debug.log,omitempty"`
 	// EventLogging description: Enables user event logging inside of the Sourcegraph instance. This will allow admins to have greater visibility of user activity, such as frequently viewed pages, frequent searches, and more. These event logs (and any specific user actions) are only stored locally, and never leave this Sourcegraph instance.
 	EventLogging string `json:"eventLogging,omitempty"`
+	// GlobalSymbolIndex description: Enables the global symbol index: the symbols of the default branch of all repositories are stored in the database and updated as the default branches move, and symbol searches query it before falling back to searching each repository.
+	GlobalSymbolIndex string `json:"globalSymbolIndex,omitempty"`
 	// SearchMultipleRevisionsPerRepository description: Enables searching multiple revisions of the same repository (using `repo:myrepo@branch1:branch2`).
 	SearchMultipleRevisionsPerRepository *bool `json:"searchMultipleRevisionsPerRepository,omitempty"`
 	// StructuralSearch description: Enables structural search.
@@ -411,6 +475,20 @@ type Extensions struct {
 	Disabled *bool `json:"disabled,omitempty"`
 	// RemoteRegistry description: The remote extension registry URL, or `false` to not use a remote extension registry. If not set, the default remote extension registry URL is used.
 	RemoteRegistry interface{} `json:"remoteRegistry,omitempty"`
+	// RemoteRegistrySync description: Periodically import the latest release of each remote extension (limited to `allowRemoteExtensions`, if set) from the remote registry into the private extension registry, so that the extensions can be exported to air-gapped instances. The imported releases are recorded as published by the first site admin.
+	//
+	// Only available in Sourcegraph Enterprise.
+	RemoteRegistrySync *ExtensionsRemoteRegistrySync `json:"remoteRegistrySync,omitempty"`
+}
+
+// ExtensionsRemoteRegistrySync description: Periodically import the latest release of each remote extension (limited to `allowRemoteExtensions`, if set) from the remote registry into the private extension registry, so that the extensions can be exported to air-gapped instances. The imported releases are recorded as published by the first site admin.
+//
+// Only available in Sourcegraph Enterprise.
+type ExtensionsRemoteRegistrySync struct {
+	// CreatePublishers description: Create an organization for each publisher of a remote extension that has no user or organization with the same name in the private extension registry. If false, the extensions of such publishers are not imported.
+	CreatePublishers bool `json:"createPublishers,omitempty"`
+	// IntervalMinutes description: The interval (in minutes) between imports.
+	IntervalMinutes int `json:"intervalMinutes,omitempty"`
 }
 type ExternalIdentity struct {
 	// AuthProviderID description: The value of the `configID` field of the targeted authentication provider.
@@ -422,6 +500,48 @@ type ExternalIdentity struct {
 	Type           string `json:"type"`
 }
 
+// GerritConnection description: Configuration for a connection to Gerrit.
+type GerritConnection struct {
+	// Exclude description: A list of projects to never mirror from this Gerrit instance. Takes precedence over "projects" and "projectPrefixes" configuration.
+	//
+	// Supports excluding by name ({"name": "platform/build"}) or by name pattern ({"pattern": "^archive/.*"}).
+	Exclude []*ExcludedGerritProject `json:"exclude,omitempty"`
+	// GitFetch description: Additional Git refs to fetch for repositories on this Gerrit instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+	GitFetch *GerritGitFetch `json:"gitFetch,omitempty"`
+	// Password description: The HTTP password of the Gerrit account (generated in the Gerrit account settings under "HTTP Credentials").
+	Password string `json:"password,omitempty"`
+	// ProjectPrefixes description: An array of Gerrit project name prefixes. All projects (visible to the configured account) whose names start with one of the prefixes are mirrored on Sourcegraph. The empty string matches all visible projects.
+	ProjectPrefixes []string `json:"projectPrefixes,omitempty"`
+	// Projects description: An array of Gerrit project names (such as "platform/build") to mirror on Sourcegraph.
+	Projects []string `json:"projects,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gerrit project. In the pattern, the variable "{host}" is replaced with the Gerrit URL's host (such as gerrit.example.com), and "{name}" is replaced with the Gerrit project's name (such as "platform/build").
+	//
+	// For example, if your Gerrit is https://gerrit.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{name}" would mean that the Gerrit project "platform/build" is available on Sourcegraph at https://src.example.com/gerrit.example.com/platform/build.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// Url description: URL of a Gerrit instance, such as https://gerrit.example.com.
+	Url string `json:"url"`
+	// Username description: The username of a Gerrit account that can read the projects to mirror. If set, requests to Gerrit and Git clones are authenticated with the username and the account's HTTP password. If not set, only projects that are visible to anonymous users are mirrored.
+	Username string `json:"username,omitempty"`
+}
+
+// GerritGitFetch description: Additional Git refs to fetch for repositories on this Gerrit instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+type GerritGitFetch struct {
+	// Refspecs description: Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).
+	Refspecs []string `json:"refspecs,omitempty"`
+	// Remotes description: Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.
+	Remotes []*GerritGitRemote `json:"remotes,omitempty"`
+}
+type GerritGitRemote struct {
+	// Name description: The name of the remote. Its branches are fetched into refs/remotes/<name>/.
+	Name string `json:"name"`
+	// Repository description: The name of the repository on Sourcegraph into which the remote's branches are fetched.
+	Repository string `json:"repository"`
+	// Url description: The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.
+	Url string `json:"url"`
+}
+
 // GitHubAuthProvider description: Configures the GitHub (or GitHub Enterprise) OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitHub instance: https://developer.github.com/apps/building-oauth-apps/creating-an-oauth-app/. When a user signs into Sourcegraph or links their GitHub account to their existing Sourcegraph account, GitHub will prompt the user for the repo scope.
 type GitHubAuthProvider struct {
 	// AllowOrgs description: Restricts new logins to members of these GitHub organizations. Existing sessions won't be invalidated. Leave empty or unset for no org restrictions.
@@ -462,6 +582,10 @@ type GitHubConnection struct {
 	//
 	// Note: ID is the GitHub GraphQL ID, not the GitHub database ID. eg: "curl https://api.github.com/repos/vuejs/vue | jq .node_id"
 	Exclude []*ExcludedGitHubRepo `json:"exclude,omitempty"`
+	// GitFetch description: Additional Git refs to fetch for repositories on this GitHub instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+	GitFetch *GitHubGitFetch `json:"gitFetch,omitempty"`
+	// GitLFS description: Fetch the Git LFS files of repositories on this GitHub instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+	GitLFS *GitHubGitLFS `json:"gitLFS,omitempty"`
 	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this GitHub instance.
 	//
 	// If "http", Sourcegraph will access GitHub repositories using Git URLs of the form http(s)://github.com/myteam/myproject.git (using https: if the GitHub instance uses HTTPS).
@@ -507,6 +631,30 @@ type GitHubConnection struct {
 	Webhooks []*GitHubWebhook `json:"webhooks,omitempty"`
 }
 
+// GitHubGitFetch description: Additional Git refs to fetch for repositories on this GitHub instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+type GitHubGitFetch struct {
+	// Refspecs description: Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).
+	Refspecs []string `json:"refspecs,omitempty"`
+	// Remotes description: Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.
+	Remotes []*GitHubGitRemote `json:"remotes,omitempty"`
+}
+
+// GitHubGitLFS description: Fetch the Git LFS files of repositories on this GitHub instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+type GitHubGitLFS struct {
+	// Enabled description: Whether to fetch Git LFS files.
+	Enabled bool `json:"enabled"`
+	// MaxFileSize description: The maximum size in bytes of a Git LFS file to fetch. Larger files are shown as Git LFS pointer files.
+	MaxFileSize int `json:"maxFileSize,omitempty"`
+}
+type GitHubGitRemote struct {
+	// Name description: The name of the remote. Its branches are fetched into refs/remotes/<name>/.
+	Name string `json:"name"`
+	// Repository description: The name of the repository on Sourcegraph into which the remote's branches are fetched.
+	Repository string `json:"repository"`
+	// Url description: The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.
+	Url string `json:"url"`
+}
+
 // GitHubRateLimit description: Rate limit applied when making background API requests to GitHub.
 type GitHubRateLimit struct {
 	// Enabled description: true if rate limiting is enabled.
@@ -555,6 +703,10 @@ type GitLabConnection struct {
 	Certificate string `json:"certificate,omitempty"`
 	// Exclude description: A list of projects to never mirror from this GitLab instance. Takes precedence over "projects" and "projectQuery" configuration. Supports excluding by name ({"name": "group/name"}) or by ID ({"id": 42}).
 	Exclude []*ExcludedGitLabProject `json:"exclude,omitempty"`
+	// GitFetch description: Additional Git refs to fetch for repositories on this GitLab instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+	GitFetch *GitLabGitFetch `json:"gitFetch,omitempty"`
+	// GitLFS description: Fetch the Git LFS files of repositories on this GitLab instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+	GitLFS *GitLabGitLFS `json:"gitLFS,omitempty"`
 	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this GitLab instance.
 	//
 	// If "http", Sourcegraph will access GitLab repositories using Git URLs of the form http(s)://gitlab.example.com/myteam/myproject.git (using https: if the GitLab instance uses HTTPS).
@@ -583,6 +735,32 @@ type GitLabConnection struct {
 	Token string `json:"token"`
 	// Url description: URL of a GitLab instance, such as https://gitlab.example.com or (for GitLab.com) https://gitlab.com.
 	Url string `json:"url"`
+	// Webhooks description: An array of configurations defining existing GitLab webhooks that notify Sourcegraph of pushes, so that repositories are updated immediately. The webhooks must send "Push events" (and optionally "Tag push events") to https://sourcegraph.example.com/.api/push-webhooks/gitlab.
+	Webhooks []*GitLabWebhook `json:"webhooks,omitempty"`
+}
+
+// GitLabGitFetch description: Additional Git refs to fetch for repositories on this GitLab instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+type GitLabGitFetch struct {
+	// Refspecs description: Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).
+	Refspecs []string `json:"refspecs,omitempty"`
+	// Remotes description: Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.
+	Remotes []*GitLabGitRemote `json:"remotes,omitempty"`
+}
+
+// GitLabGitLFS description: Fetch the Git LFS files of repositories on this GitLab instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+type GitLabGitLFS struct {
+	// Enabled description: Whether to fetch Git LFS files.
+	Enabled bool `json:"enabled"`
+	// MaxFileSize description: The maximum size in bytes of a Git LFS file to fetch. Larger files are shown as Git LFS pointer files.
+	MaxFileSize int `json:"maxFileSize,omitempty"`
+}
+type GitLabGitRemote struct {
+	// Name description: The name of the remote. Its branches are fetched into refs/remotes/<name>/.
+	Name string `json:"name"`
+	// Repository description: The name of the repository on Sourcegraph into which the remote's branches are fetched.
+	Repository string `json:"repository"`
+	// Url description: The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.
+	Url string `json:"url"`
 }
 type GitLabNameTransformation struct {
 	// Regex description: The regex to match for the occurrences of its replacement.
@@ -604,6 +782,72 @@ type GitLabRateLimit struct {
 	// RequestsPerHour description: Requests per hour permitted. This is an average, calculated per second.
 	RequestsPerHour float64 `json:"requestsPerHour"`
 }
+type GitLabWebhook struct {
+	// Secret description: The secret token used when creating the webhook
+	Secret string `json:"secret"`
+}
+
+// GiteaConnection description: Configuration for a connection to Gitea (or Gogs).
+type GiteaConnection struct {
+	// Exclude description: A list of repositories to never mirror from this Gitea instance. Takes precedence over "orgs", "users", "repositoryQuery" and "repos" configuration.
+	//
+	// Supports excluding by name ({"name": "owner/name"}), by ID ({"id": 42}), by name pattern ({"pattern": "^archive/.*"}), and all forks ({"forks": true}) or archived repositories ({"archived": true}).
+	Exclude []*ExcludedGiteaRepo `json:"exclude,omitempty"`
+	// GitFetch description: Additional Git refs to fetch for repositories on this Gitea instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+	GitFetch *GiteaGitFetch `json:"gitFetch,omitempty"`
+	// GitLFS description: Fetch the Git LFS files of repositories on this Gitea instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+	GitLFS *GiteaGitLFS `json:"gitLFS,omitempty"`
+	// GitURLType description: The type of Git URLs to use for cloning and fetching Git repositories on this Gitea instance.
+	//
+	// If "http", Sourcegraph will access Gitea repositories using Git URLs of the form https://gitea.example.com/myorg/myrepo.git, authenticated with the token.
+	//
+	// If "ssh", Sourcegraph will access Gitea repositories using Git URLs of the form git@gitea.example.com:myorg/myrepo.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.
+	GitURLType string `json:"gitURLType,omitempty"`
+	// InitialRepositoryEnablement description: Defines whether repositories from this Gitea instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Gitea repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately; site admins can still disable them explicitly, and they'll remain disabled.
+	InitialRepositoryEnablement bool `json:"initialRepositoryEnablement,omitempty"`
+	// Orgs description: An array of organization names identifying Gitea organizations whose repositories should be mirrored on Sourcegraph.
+	Orgs []string `json:"orgs,omitempty"`
+	// Repos description: An array of repository "owner/name" strings specifying which Gitea repositories to mirror on Sourcegraph.
+	Repos []string `json:"repos,omitempty"`
+	// RepositoryPathPattern description: The pattern used to generate the corresponding Sourcegraph repository name for a Gitea repository. In the pattern, the variable "{host}" is replaced with the Gitea URL's host (such as gitea.example.com), and "{nameWithOwner}" is replaced with the Gitea repository's "owner/name" (such as "myorg/myrepo").
+	//
+	// For example, if your Gitea is https://gitea.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of "{host}/{nameWithOwner}" would mean that a Gitea repository at https://gitea.example.com/myorg/myrepo is available on Sourcegraph at https://src.example.com/gitea.example.com/myorg/myrepo.
+	//
+	// It is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.
+	RepositoryPathPattern string `json:"repositoryPathPattern,omitempty"`
+	// RepositoryQuery description: An array of repository search queries. The repositories matching each query (that the token's user can access) are mirrored on Sourcegraph. The empty string matches all repositories the token's user can access.
+	RepositoryQuery []string `json:"repositoryQuery,omitempty"`
+	// Token description: An access token for a Gitea or Gogs user who can read the repositories to mirror.
+	Token string `json:"token"`
+	// Url description: URL of a Gitea or Gogs instance, such as https://gitea.example.com.
+	Url string `json:"url"`
+	// Users description: An array of usernames identifying Gitea users whose repositories should be mirrored on Sourcegraph.
+	Users []string `json:"users,omitempty"`
+}
+
+// GiteaGitFetch description: Additional Git refs to fetch for repositories on this Gitea instance, such as custom ref namespaces, and forks whose branches are fetched into the repository they are a fork of. Fetched refs are searchable and listed as Git refs of the repository.
+type GiteaGitFetch struct {
+	// Refspecs description: Refspecs fetched from every repository in addition to branches, tags and code review refs (such as refs/pull/* and refs/changes/*).
+	Refspecs []string `json:"refspecs,omitempty"`
+	// Remotes description: Additional remotes, such as forks, whose branches are fetched into refs/remotes/<name>/ of a repository.
+	Remotes []*GiteaGitRemote `json:"remotes,omitempty"`
+}
+
+// GiteaGitLFS description: Fetch the Git LFS files of repositories on this Gitea instance, so that their contents instead of Git LFS pointer files are searched and shown. Only the files of the default branch are fetched. Requires the "http" gitURLType.
+type GiteaGitLFS struct {
+	// Enabled description: Whether to fetch Git LFS files.
+	Enabled bool `json:"enabled"`
+	// MaxFileSize description: The maximum size in bytes of a Git LFS file to fetch. Larger files are shown as Git LFS pointer files.
+	MaxFileSize int `json:"maxFileSize,omitempty"`
+}
+type GiteaGitRemote struct {
+	// Name description: The name of the remote. Its branches are fetched into refs/remotes/<name>/.
+	Name string `json:"name"`
+	// Repository description: The name of the repository on Sourcegraph into which the remote's branches are fetched.
+	Repository string `json:"repository"`
+	// Url description: The Git clone URL of the remote. Credentials of this code host connection are used for URLs on the same host.
+	Url string `json:"url"`
+}
 
 // GitoliteConnection description: Configuration for a connection to Gitolite.
 type GitoliteConnection struct {
@@ -893,6 +1137,8 @@ type Settings struct {
 	SearchIncludeForks *bool `json:"search.includeForks,omitempty"`
 	// SearchRepositoryGroups description: Named groups of repositories that can be referenced in a search query using the repogroup: operator.
 	SearchRepositoryGroups map[string][]string `json:"search.repositoryGroups,omitempty"`
+	// SearchSaveHistory description: Whether the searches you run are saved in your search history on the server, so that your recent searches are available on all your devices. Set to false in your user settings to opt out: your search history is then deleted and no longer saved. When set to false in organization or global settings, searches are no longer saved, but existing search history is only deleted by an opt-out in user settings.
+	SearchSaveHistory *bool `json:"search.saveHistory,omitempty"`
 	// SearchSavedQueries description: DEPRECATED: Saved search queries
 	SearchSavedQueries []*SearchSavedQueries `json:"search.savedQueries,omitempty"`
 	// SearchScopes description: Predefined search scopes
@@ -1019,6 +1265,8 @@ type SiteConfiguration struct {
 	PermissionsUserMapping *PermissionsUserMapping `json:"permissions.userMapping,omitempty"`
 	// RepoListUpdateInterval description: Interval (in minutes) for checking code hosts (such as GitHub, Gitolite, etc.) for new repositories.
 	RepoListUpdateInterval int `json:"repoListUpdateInterval,omitempty"`
+	// SearchHistoryRetentionDays description: The number of days that the searches of users are kept in their search history. Defaults to 90. If 0, search history is not saved on the server.
+	SearchHistoryRetentionDays *int `json:"search.history.retentionDays,omitempty"`
 	// SearchIndexEnabled description: Whether indexed search is enabled. If unset Sourcegraph detects the environment to decide if indexed search is enabled. Indexed search is RAM heavy, and is disabled by default in the single docker image. All other environments will have it enabled by default. The size of all your repository working copies is the amount of additional RAM required.
 	SearchIndexEnabled *bool `json:"search.index.enabled,omitempty"`
 	// SearchIndexSymbolsEnabled description: Whether indexed symbol search is enabled. This is contingent on the indexed search configuration, and is true by default for instances with indexed search enabled. Enabling this will cause every repository to re-index, which is a time consuming (several hours) operation. Additionally, it requires more storage and ram to accommodate the added symbols information in the search index.
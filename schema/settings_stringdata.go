@@ -129,6 +129,12 @@ const SettingsSchemaJSON = `{
       "default": false,
       "!go": { "pointer": true }
     },
+    "search.saveHistory": {
+      "description": "Whether the searches you run are saved in your search history on the server, so that your recent searches are available on all your devices. Set to false in your user settings to opt out: your search history is then deleted and no longer saved. When set to false in organization or global settings, searches are no longer saved, but existing search history is only deleted by an opt-out in user settings.",
+      "type": "boolean",
+      "default": true,
+      "!go": { "pointer": true }
+    },
     "quicklinks": {
       "description": "Links that should be accessible quickly from the home and search pages.",
       "type": "array",
@@ -34,6 +34,14 @@ const SiteSchemaJSON = `{
       "!go": { "pointer": true },
       "group": "Search"
     },
+    "search.history.retentionDays": {
+      "description": "The number of days that the searches of users are kept in their search history. Defaults to 90. If 0, search history is not saved on the server.",
+      "type": "integer",
+      "minimum": 0,
+      "default": 90,
+      "!go": { "pointer": true },
+      "group": "Search"
+    },
     "search.largeFiles": {
       "description": "A list of file glob patterns where matching files will be indexed and searched regardless of their size. The glob pattern syntax can be found here: https://golang.org/pkg/path/filepath/#Match.",
       "type": "array",
@@ -90,6 +98,12 @@ const SiteSchemaJSON = `{
           "enum": ["enabled", "disabled"],
           "default": "disabled"
         },
+        "globalSymbolIndex": {
+          "description": "Enables the global symbol index: the symbols of the default branch of all repositories are stored in the database and updated as the default branches move, and symbol searches query it before falling back to searching each repository.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
         "bitbucketServerFastPerm": {
           "description": "DEPRECATED: Configure in Bitbucket Server config.",
           "type": "string",
@@ -524,6 +538,25 @@ const SiteSchemaJSON = `{
           "items": {
             "type": "string"
           }
+        },
+        "remoteRegistrySync": {
+          "title": "ExtensionsRemoteRegistrySync",
+          "description": "Periodically import the latest release of each remote extension (limited to ` + "`" + `allowRemoteExtensions` + "`" + `, if set) from the remote registry into the private extension registry, so that the extensions can be exported to air-gapped instances. The imported releases are recorded as published by the first site admin.\n\nOnly available in Sourcegraph Enterprise.",
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "intervalMinutes": {
+              "description": "The interval (in minutes) between imports.",
+              "type": "integer",
+              "minimum": 1,
+              "default": 1440
+            },
+            "createPublishers": {
+              "description": "Create an organization for each publisher of a remote extension that has no user or organization with the same name in the private extension registry. If false, the extensions of such publishers are not imported.",
+              "type": "boolean",
+              "default": false
+            }
+          }
         }
       },
       "default": {
@@ -735,6 +768,53 @@ const SiteSchemaJSON = `{
           "description": "Allows new visitors to sign up for accounts. The sign-up page will be enabled and accessible to all visitors.\n\nSECURITY: If the site has no users (i.e., during initial setup), it will always allow the first user to sign up and become site admin **without any approval** (first user to sign up becomes the admin).",
           "type": "boolean",
           "default": false
+        },
+        "lockout": {
+          "title": "BuiltinAuthLockout",
+          "description": "Brute-force protection for password sign-in and password reset. Accounts and client IP addresses with too many failed attempts are temporarily locked out. A site admin can unlock an account before the lockout expires.",
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "failedAttemptThreshold": {
+              "description": "The number of consecutive failed sign-in attempts for a single account after which the account is locked out.",
+              "type": "integer",
+              "minimum": 1,
+              "default": 5
+            },
+            "ipFailedAttemptThreshold": {
+              "description": "The number of failed sign-in and password reset attempts from a single client IP address after which further attempts from that address are rejected.",
+              "type": "integer",
+              "minimum": 1,
+              "default": 50
+            },
+            "consecutivePeriod": {
+              "description": "The period (in seconds) over which failed attempts are counted. The count is reset when no failed attempt happens within this period.",
+              "type": "integer",
+              "minimum": 1,
+              "default": 3600
+            },
+            "lockoutPeriod": {
+              "description": "The period (in seconds) for which an account or client IP address stays locked out.",
+              "type": "integer",
+              "minimum": 1,
+              "default": 1800
+            },
+            "trustedProxies": {
+              "description": "The IP addresses or CIDR ranges of the reverse proxies and load balancers in front of Sourcegraph. The client IP address of a request is taken from the X-Forwarded-For header only if the request comes from one of these proxies; otherwise the address of the connection is used.",
+              "type": "array",
+              "items": { "type": "string" },
+              "default": []
+            }
+          },
+          "examples": [
+            {
+              "failedAttemptThreshold": 5,
+              "ipFailedAttemptThreshold": 50,
+              "consecutivePeriod": 3600,
+              "lockoutPeriod": 1800,
+              "trustedProxies": ["10.0.0.0/8"]
+            }
+          ]
         }
       }
     },
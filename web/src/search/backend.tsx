@@ -49,7 +49,7 @@ export function search(
             queryGraphQL(
                 gql`
                     query Search($query: String!, $version: SearchVersion!, $patternType: SearchPatternType!, $useCodemod: Boolean!, $versionContext: String) {
-                        search(query: $query, version: $version, patternType: $patternType, versionContext: $versionContext) {
+                        search(query: $query, version: $version, patternType: $patternType, versionContext: $versionContext, saveHistory: true) {
                             results {
                                 __typename
                                 limitHit
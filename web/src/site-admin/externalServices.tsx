@@ -9,6 +9,8 @@ import React from 'react'
 import awsCodeCommitSchemaJSON from '../../../schema/aws_codecommit.schema.json'
 import bitbucketCloudSchemaJSON from '../../../schema/bitbucket_cloud.schema.json'
 import bitbucketServerSchemaJSON from '../../../schema/bitbucket_server.schema.json'
+import gerritSchemaJSON from '../../../schema/gerrit.schema.json'
+import giteaSchemaJSON from '../../../schema/gitea.schema.json'
 import githubSchemaJSON from '../../../schema/github.schema.json'
 import gitlabSchemaJSON from '../../../schema/gitlab.schema.json'
 import gitoliteSchemaJSON from '../../../schema/gitolite.schema.json'
@@ -903,6 +905,160 @@ const GITLAB_SELF_MANAGED: AddExternalServiceOptions = {
 }`,
 }
 
+const GERRIT: AddExternalServiceOptions = {
+    kind: GQL.ExternalServiceKind.GERRIT,
+    title: 'Gerrit',
+    icon: GitIcon,
+    shortDescription: 'Add Gerrit projects.',
+    jsonSchema: gerritSchemaJSON,
+    defaultDisplayName: 'Gerrit',
+    defaultConfig: `{
+  "url": "https://gerrit.example.com",
+  "projectPrefixes": []
+}`,
+    editorActions: [
+        {
+            id: 'setCredentials',
+            label: 'Set username and HTTP password',
+            run: config => {
+                const value = '<username>'
+                const edits = [
+                    ...setProperty(config, ['username'], value, defaultFormattingOptions),
+                    ...setProperty(config, ['password'], '<HTTP password>', defaultFormattingOptions),
+                ]
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addProjectPrefix',
+            label: 'Add projects by name prefix',
+            run: config => {
+                const value = '<project name prefix>'
+                const edits = setProperty(config, ['projectPrefixes', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addProject',
+            label: 'Add single project',
+            run: config => {
+                const value = '<project name>'
+                const edits = setProperty(config, ['projects', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+    ],
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    Set the <Field>url</Field> field to the URL of your Gerrit instance.
+                </li>
+                <li>
+                    To sync projects that are not visible to anonymous users, set the <Field>username</Field> and{' '}
+                    <Field>password</Field> fields to the username and HTTP password (under{' '}
+                    <b>Settings &gt; HTTP Credentials</b>) of an account that can read the projects.
+                </li>
+                <li>
+                    Use the <Field>projectPrefixes</Field> or <Field>projects</Field> fields to select the projects to
+                    sync.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gerrit#configuration"
+                >
+                    the docs for more options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+}
+
+const GITEA: AddExternalServiceOptions = {
+    kind: GQL.ExternalServiceKind.GITEA,
+    title: 'Gitea',
+    icon: GitIcon,
+    shortDescription: 'Add Gitea or Gogs repositories.',
+    jsonSchema: giteaSchemaJSON,
+    defaultDisplayName: 'Gitea',
+    defaultConfig: `{
+  "url": "https://gitea.example.com",
+  "token": "<access token>",
+  "orgs": []
+}`,
+    editorActions: [
+        {
+            id: 'setAccessToken',
+            label: 'Set access token',
+            run: config => {
+                const value = '<access token>'
+                const edits = setProperty(config, ['token'], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addOrgRepositories',
+            label: 'Add repositories in an organization',
+            run: config => {
+                const value = '<organization name>'
+                const edits = setProperty(config, ['orgs', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addUserRepositories',
+            label: 'Add repositories of a user',
+            run: config => {
+                const value = '<username>'
+                const edits = setProperty(config, ['users', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+        {
+            id: 'addRepository',
+            label: 'Add single repository',
+            run: config => {
+                const value = '<owner>/<repository>'
+                const edits = setProperty(config, ['repos', -1], value, defaultFormattingOptions)
+                return { edits, selectText: value }
+            },
+        },
+    ],
+    instructions: (
+        <div>
+            <ol>
+                <li>
+                    Set the <Field>url</Field> field to the URL of your Gitea or Gogs instance.
+                </li>
+                <li>
+                    Create an access token under <b>Settings &gt; Applications</b> for a user who can read the
+                    repositories to sync, and set it as the <Field>token</Field> field.
+                </li>
+                <li>
+                    Use the <Field>orgs</Field>, <Field>users</Field>, <Field>repositoryQuery</Field> or{' '}
+                    <Field>repos</Field> fields to select the repositories to sync.
+                </li>
+            </ol>
+            <p>
+                See{' '}
+                <a
+                    rel="noopener noreferrer"
+                    target="_blank"
+                    href="https://docs.sourcegraph.com/admin/external_service/gitea#configuration"
+                >
+                    the docs for more options
+                </a>
+                , or try one of the buttons below.
+            </p>
+        </div>
+    ),
+}
+
 const GITOLITE: AddExternalServiceOptions = {
     kind: GQL.ExternalServiceKind.GITOLITE,
     title: 'Gitolite',
@@ -1074,6 +1230,8 @@ export const codeHostExternalServices: Record<string, AddExternalServiceOptions>
     bitbucketserver: BITBUCKET_SERVER,
     aws_codecommit: AWS_CODE_COMMIT,
     gitolite: GITOLITE,
+    gerrit: GERRIT,
+    gitea: GITEA,
     git: GENERIC_GIT,
 }
 
@@ -1090,6 +1248,8 @@ export const defaultExternalServices: Record<GQL.ExternalServiceKind, AddExterna
     [GQL.ExternalServiceKind.GITHUB]: GITHUB_DOTCOM,
     [GQL.ExternalServiceKind.BITBUCKETCLOUD]: BITBUCKET_CLOUD,
     [GQL.ExternalServiceKind.BITBUCKETSERVER]: BITBUCKET_SERVER,
+    [GQL.ExternalServiceKind.GERRIT]: GERRIT,
+    [GQL.ExternalServiceKind.GITEA]: GITEA,
     [GQL.ExternalServiceKind.GITLAB]: GITLAB_DOTCOM,
     [GQL.ExternalServiceKind.GITOLITE]: GITOLITE,
     [GQL.ExternalServiceKind.PHABRICATOR]: PHABRICATOR_SERVICE,